@@ -27,14 +27,23 @@ import (
 	_ "mosn.io/mosn/pkg/buffer"
 	_ "mosn.io/mosn/pkg/filter/listener/originaldst"
 	_ "mosn.io/mosn/pkg/filter/network/connectionmanager"
+	_ "mosn.io/mosn/pkg/filter/network/connectproxy"
+	_ "mosn.io/mosn/pkg/filter/network/dnsproxy"
+	_ "mosn.io/mosn/pkg/filter/network/kafka"
+	_ "mosn.io/mosn/pkg/filter/network/mqtt"
+	_ "mosn.io/mosn/pkg/filter/network/mysql"
 	_ "mosn.io/mosn/pkg/filter/network/proxy"
+	_ "mosn.io/mosn/pkg/filter/network/rediscluster"
+	_ "mosn.io/mosn/pkg/filter/network/socks5"
 	_ "mosn.io/mosn/pkg/filter/network/tcpproxy"
+	_ "mosn.io/mosn/pkg/filter/network/websocket"
 	_ "mosn.io/mosn/pkg/filter/stream/faultinject"
 	_ "mosn.io/mosn/pkg/filter/stream/faulttolerance"
 	_ "mosn.io/mosn/pkg/filter/stream/flowcontrol"
 	_ "mosn.io/mosn/pkg/filter/stream/gzip"
 	_ "mosn.io/mosn/pkg/filter/stream/mixer"
 	_ "mosn.io/mosn/pkg/filter/stream/payloadlimit"
+	_ "mosn.io/mosn/pkg/filter/stream/transcoder/grpcweb"
 	_ "mosn.io/mosn/pkg/filter/stream/transcoder/http2bolt"
 	_ "mosn.io/mosn/pkg/metrics/sink"
 	_ "mosn.io/mosn/pkg/metrics/sink/prometheus"
@@ -47,6 +56,7 @@ import (
 	_ "mosn.io/mosn/pkg/protocol/xprotocol/boltv2"
 	_ "mosn.io/mosn/pkg/protocol/xprotocol/dubbo"
 	_ "mosn.io/mosn/pkg/protocol/xprotocol/tars"
+	_ "mosn.io/mosn/pkg/protocol/xprotocol/thrift"
 	_ "mosn.io/mosn/pkg/router"
 	_ "mosn.io/mosn/pkg/stream/http"
 	_ "mosn.io/mosn/pkg/stream/http2"
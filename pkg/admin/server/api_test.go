@@ -18,13 +18,17 @@
 package server
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"mosn.io/mosn/pkg/admin/store"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/upstream/cluster"
 )
 
 func TestKnownFeatures(t *testing.T) {
@@ -91,3 +95,199 @@ func TestGetEnv(t *testing.T) {
 		t.Fatalf("env got %s", string(b))
 	}
 }
+
+func TestSetHostHealthAndGetClusterHealth(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "admin_test_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:18080",
+		},
+	}
+	cluster.NewClusterManagerSingleton([]v2.Cluster{clusterConfig}, map[string][]v2.Host{
+		"admin_test_cluster": []v2.Host{host},
+	})
+
+	body := strings.NewReader(`{"cluster":"admin_test_cluster","address":"127.0.0.1:18080","healthy":false}`)
+	r := httptest.NewRequest("POST", "http://127.0.0.1/api/v1/set_host_health", body)
+	w := httptest.NewRecorder()
+	setHostHealth(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("set host health response status got %d", resp.StatusCode)
+	}
+
+	r = httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_health?cluster=admin_test_cluster", nil)
+	w = httptest.NewRecorder()
+	getClusterHealth(w, r)
+	resp = w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("get cluster health response status got %d", resp.StatusCode)
+	}
+	b, err := ioutil.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("response read error: %v", err)
+	}
+	if strings.Contains(string(b), `"healthy": true`) {
+		t.Fatalf("host should be reported unhealthy, got %s", string(b))
+	}
+
+	r = httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_health?cluster=not_exists", nil)
+	w = httptest.NewRecorder()
+	getClusterHealth(w, r)
+	if w.Result().StatusCode != 400 {
+		t.Fatalf("get cluster health for a missing cluster should fail, got %d", w.Result().StatusCode)
+	}
+}
+
+func TestGetClusterOutliers(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "admin_test_outlier_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:18081",
+		},
+	}
+	// the cluster manager is a process-wide singleton already created by
+	// TestSetHostHealthAndGetClusterHealth, so add this cluster to it
+	// instead of trying to re-create the singleton
+	mngAdapter := cluster.GetClusterMngAdapterInstance()
+	if err := mngAdapter.AddOrUpdatePrimaryCluster(clusterConfig); err != nil {
+		t.Fatalf("add cluster failed, %v", err)
+	}
+	if err := mngAdapter.UpdateClusterHosts("admin_test_outlier_cluster", []v2.Host{host}); err != nil {
+		t.Fatalf("update cluster hosts failed, %v", err)
+	}
+
+	snap := mngAdapter.GetClusterSnapshot(context.Background(), "admin_test_outlier_cluster")
+	outlierHost := snap.HostSet().Hosts()[0]
+	// default Consecutive5xx threshold is 5 consecutive errors
+	for i := 0; i < 5; i++ {
+		cluster.RecordOutlierError(outlierHost, false)
+	}
+
+	r := httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_outliers?cluster=admin_test_outlier_cluster", nil)
+	w := httptest.NewRecorder()
+	getClusterOutliers(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("get cluster outliers response status got %d", resp.StatusCode)
+	}
+	b, err := ioutil.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("response read error: %v", err)
+	}
+	if !strings.Contains(string(b), `"ejected": true`) {
+		t.Fatalf("host should be reported ejected, got %s", string(b))
+	}
+
+	r = httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_outliers?cluster=not_exists", nil)
+	w = httptest.NewRecorder()
+	getClusterOutliers(w, r)
+	if w.Result().StatusCode != 400 {
+		t.Fatalf("get cluster outliers for a missing cluster should fail, got %d", w.Result().StatusCode)
+	}
+}
+
+func TestGetClusterChanges(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:              "admin_test_changes_cluster",
+		LbType:            v2.LB_RANDOM,
+		MaxRequestPerConn: 1024,
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:18082",
+		},
+	}
+	// the cluster manager is a process-wide singleton already created by
+	// TestSetHostHealthAndGetClusterHealth, so add this cluster to it
+	// instead of trying to re-create the singleton
+	mngAdapter := cluster.GetClusterMngAdapterInstance()
+	if err := mngAdapter.AddOrUpdatePrimaryCluster(clusterConfig); err != nil {
+		t.Fatalf("add cluster failed, %v", err)
+	}
+	if err := mngAdapter.UpdateClusterHosts("admin_test_changes_cluster", []v2.Host{host}); err != nil {
+		t.Fatalf("update cluster hosts failed, %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_changes?cluster=admin_test_changes_cluster", nil)
+	w := httptest.NewRecorder()
+	getClusterChanges(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("get cluster changes response status got %d", resp.StatusCode)
+	}
+	b, err := ioutil.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("response read error: %v", err)
+	}
+	if !strings.Contains(string(b), `"127.0.0.1:18082"`) {
+		t.Fatalf("expected the added host to be reported, got %s", string(b))
+	}
+
+	// a config-only push doesn't touch hosts: seed the change summary the
+	// way AddOrUpdatePrimaryCluster would, to check it merges with rather
+	// than clobbers the host diff recorded above.
+	summary, _ := store.GetClusterChangeSummary("admin_test_changes_cluster")
+	summary.ConfigChanged = []string{"max_request_per_conn"}
+	store.SetClusterChangeSummary(summary)
+
+	r = httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_changes?cluster=admin_test_changes_cluster", nil)
+	w = httptest.NewRecorder()
+	getClusterChanges(w, r)
+	b, err = ioutil.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("response read error: %v", err)
+	}
+	if !strings.Contains(string(b), `"max_request_per_conn"`) {
+		t.Fatalf("expected max_request_per_conn to be reported as changed, got %s", string(b))
+	}
+
+	r = httptest.NewRequest("GET", "http://127.0.0.1/api/v1/cluster_changes?cluster=not_exists", nil)
+	w = httptest.NewRecorder()
+	getClusterChanges(w, r)
+	if w.Result().StatusCode != 404 {
+		t.Fatalf("get cluster changes for a missing cluster should fail, got %d", w.Result().StatusCode)
+	}
+}
+
+func TestDrainConnections(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "admin_test_drain_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:18083",
+		},
+	}
+	mngAdapter := cluster.GetClusterMngAdapterInstance()
+	if err := mngAdapter.AddOrUpdatePrimaryCluster(clusterConfig); err != nil {
+		t.Fatalf("add cluster failed, %v", err)
+	}
+	if err := mngAdapter.UpdateClusterHosts("admin_test_drain_cluster", []v2.Host{host}); err != nil {
+		t.Fatalf("update cluster hosts failed, %v", err)
+	}
+
+	body := strings.NewReader(`{"cluster":"admin_test_drain_cluster","address":"127.0.0.1:18083"}`)
+	r := httptest.NewRequest("POST", "http://127.0.0.1/api/v1/drain_connections", body)
+	w := httptest.NewRecorder()
+	drainConnections(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("drain connections response status got %d", resp.StatusCode)
+	}
+
+	body = strings.NewReader(`{"cluster":"admin_test_drain_cluster","address":"127.0.0.1:19999"}`)
+	r = httptest.NewRequest("POST", "http://127.0.0.1/api/v1/drain_connections", body)
+	w = httptest.NewRecorder()
+	drainConnections(w, r)
+	if w.Result().StatusCode != 400 {
+		t.Fatalf("drain connections for a missing host should fail, got %d", w.Result().StatusCode)
+	}
+}
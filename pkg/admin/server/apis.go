@@ -34,6 +34,7 @@ import (
 	"mosn.io/mosn/pkg/metrics/sink/console"
 	"mosn.io/mosn/pkg/plugin"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
 )
 
 var levelMap = map[string]log.Level{
@@ -358,3 +359,255 @@ func getEnv(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(data)
 }
+
+// SetHostHealthData is the request body for setHostHealth
+type SetHostHealthData struct {
+	Cluster string `json:"cluster"`
+	Address string `json:"address"`
+	Healthy bool   `json:"healthy"`
+}
+
+// post data:
+// {"cluster": "cluster_name", "address": "127.0.0.1:8080", "healthy": false}
+func setHostHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "set host health", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: read body failed, %v", "set host health", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "read body error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	data := &SetHostHealthData{}
+	if err := json.Unmarshal(body, data); err != nil || data.Cluster == "" || data.Address == "" {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, set host health failed with bad request data: %s", "set host health", string(body))
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "invalid request data")
+		fmt.Fprint(w, msg)
+		return
+	}
+	if err := cluster.GetClusterMngAdapterInstance().SetHostHealth(data.Cluster, data.Address, data.Healthy); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "set host health", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, err.Error())
+		fmt.Fprint(w, msg)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [set host health] cluster: %s, address: %s, healthy: %t", data.Cluster, data.Address, data.Healthy)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "set host health success\n")
+}
+
+// DrainConnectionsData is the request body for drainConnections
+type DrainConnectionsData struct {
+	Cluster string `json:"cluster"`
+	Address string `json:"address"`
+}
+
+// post data:
+// {"cluster": "cluster_name", "address": "127.0.0.1:8080"}
+func drainConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "drain connections", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: read body failed, %v", "drain connections", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "read body error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	data := &DrainConnectionsData{}
+	if err := json.Unmarshal(body, data); err != nil || data.Cluster == "" || data.Address == "" {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, drain connections failed with bad request data: %s", "drain connections", string(body))
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "invalid request data")
+		fmt.Fprint(w, msg)
+		return
+	}
+	if err := cluster.GetClusterMngAdapterInstance().DrainConnections(data.Cluster, data.Address); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "drain connections", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, err.Error())
+		fmt.Fprint(w, msg)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [drain connections] cluster: %s, address: %s", data.Cluster, data.Address)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "drain connections success\n")
+}
+
+// UpdateCircuitBreakersData is the request body for updateCircuitBreakers
+type UpdateCircuitBreakersData struct {
+	Cluster         string             `json:"cluster"`
+	CircuitBreakers v2.CircuitBreakers `json:"circuit_breakers"`
+}
+
+// post data:
+// {"cluster": "cluster_name", "circuit_breakers": [{"max_connections": 1024}]}
+func updateCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "update circuit breakers", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: read body failed, %v", "update circuit breakers", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "read body error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	data := &UpdateCircuitBreakersData{}
+	if err := json.Unmarshal(body, data); err != nil || data.Cluster == "" {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, update circuit breakers failed with bad request data: %s", "update circuit breakers", string(body))
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "invalid request data")
+		fmt.Fprint(w, msg)
+		return
+	}
+	if err := cluster.GetClusterMngAdapterInstance().UpdateClusterCircuitBreakers(data.Cluster, data.CircuitBreakers); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "update circuit breakers", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, err.Error())
+		fmt.Fprint(w, msg)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [update circuit breakers] cluster: %s", data.Cluster)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "update circuit breakers success\n")
+}
+
+// http://ip:port/api/v1/cluster_health?cluster=cluster_name
+func getClusterHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "get cluster health", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	clusterName := r.URL.Query().Get("cluster")
+	if clusterName == "" {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s no cluster name", "get cluster health")
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "no cluster name")
+		fmt.Fprint(w, msg)
+		return
+	}
+	hosts, err := cluster.GetClusterMngAdapterInstance().GetClusterHostsHealth(clusterName)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "get cluster health", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, err.Error())
+		fmt.Fprint(w, msg)
+		return
+	}
+	data, err := json.MarshalIndent(hosts, "", " ")
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "get cluster health", err)
+		w.WriteHeader(500)
+		msg := fmt.Sprintf(errMsgFmt, "internal error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	w.Write(data)
+}
+
+// http://ip:port/api/v1/clusters
+func getClusters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "get clusters", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	summary := cluster.GetClusterMngAdapterInstance().GetClustersSummary()
+	data, err := json.MarshalIndent(summary, "", " ")
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "get clusters", err)
+		w.WriteHeader(500)
+		msg := fmt.Sprintf(errMsgFmt, "internal error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	w.Write(data)
+}
+
+// http://ip:port/api/v1/cluster_changes?cluster=cluster_name
+// getClusterChanges reports what the most recent AddOrUpdatePrimaryCluster
+// or host update actually changed for a cluster, so an operator can
+// verify what an xDS (or other) config push did without diffing configs
+// by hand. With no cluster query param, it returns every cluster that has
+// been added or updated since mosn started.
+func getClusterChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "get cluster changes", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	clusterName := r.URL.Query().Get("cluster")
+	var result interface{}
+	if clusterName == "" {
+		result = store.GetClusterChangeSummaries()
+	} else {
+		summary, ok := store.GetClusterChangeSummary(clusterName)
+		if !ok {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: no change recorded for cluster: %s", "get cluster changes", clusterName)
+			w.WriteHeader(http.StatusNotFound)
+			msg := fmt.Sprintf(errMsgFmt, "no change recorded for cluster: "+clusterName)
+			fmt.Fprint(w, msg)
+			return
+		}
+		result = summary
+	}
+	data, err := json.MarshalIndent(result, "", " ")
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "get cluster changes", err)
+		w.WriteHeader(500)
+		msg := fmt.Sprintf(errMsgFmt, "internal error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	w.Write(data)
+}
+
+// http://ip:port/api/v1/cluster_outliers?cluster=cluster_name
+func getClusterOutliers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "get cluster outliers", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	clusterName := r.URL.Query().Get("cluster")
+	if clusterName == "" {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s no cluster name", "get cluster outliers")
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "no cluster name")
+		fmt.Fprint(w, msg)
+		return
+	}
+	hosts, err := cluster.GetClusterMngAdapterInstance().GetClusterOutlierState(clusterName)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "get cluster outliers", err)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, err.Error())
+		fmt.Fprint(w, msg)
+		return
+	}
+	data, err := json.MarshalIndent(hosts, "", " ")
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "get cluster outliers", err)
+		w.WriteHeader(500)
+		msg := fmt.Sprintf(errMsgFmt, "internal error")
+		fmt.Fprint(w, msg)
+		return
+	}
+	w.Write(data)
+}
@@ -41,19 +41,26 @@ func RegisterAdminHandleFunc(pattern string, handler func(http.ResponseWriter, *
 func init() {
 	// default admin api
 	apiHandleFuncStore = map[string]func(http.ResponseWriter, *http.Request){
-		"/api/v1/config_dump":     configDump,
-		"/api/v1/stats":           statsDump,
-		"/api/v1/stats_glob":      statsDumpProxyTotal,
-		"/api/v1/update_loglevel": updateLogLevel,
-		"/api/v1/enable_log":      enableLogger,
-		"/api/v1/disbale_log":     disableLogger,
-		"/api/v1/states":          getState,
-		"/api/v1/plugin":          pluginApi,
-		"/stats":                  statsForIstio,
-		"/server_info":            serverInfoForIstio,
-		"/api/v1/features":        knownFeatures,
-		"/api/v1/env":             getEnv,
-		"/":                       help,
+		"/api/v1/config_dump":       configDump,
+		"/api/v1/stats":             statsDump,
+		"/api/v1/stats_glob":        statsDumpProxyTotal,
+		"/api/v1/update_loglevel":   updateLogLevel,
+		"/api/v1/enable_log":        enableLogger,
+		"/api/v1/disbale_log":       disableLogger,
+		"/api/v1/states":            getState,
+		"/api/v1/plugin":            pluginApi,
+		"/stats":                    statsForIstio,
+		"/server_info":              serverInfoForIstio,
+		"/api/v1/features":          knownFeatures,
+		"/api/v1/env":               getEnv,
+		"/api/v1/set_host_health":   setHostHealth,
+		"/api/v1/drain_connections": drainConnections,
+		"/api/v1/clusters":          getClusters,
+		"/api/v1/cluster_health":    getClusterHealth,
+		"/api/v1/cluster_outliers":  getClusterOutliers,
+		"/api/v1/cluster_changes":   getClusterChanges,
+		"/api/v1/circuit_breakers":  updateCircuitBreakers,
+		"/":                         help,
 	}
 }
 
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// ClusterChangeSummary records what an AddOrUpdatePrimaryCluster call
+// actually changed, so an operator can verify what an xDS push (or any
+// other config source) did to a cluster after the fact, via the admin API.
+type ClusterChangeSummary struct {
+	ClusterName   string    `json:"cluster_name"`
+	Time          time.Time `json:"time"`
+	New           bool      `json:"new"`
+	HostsAdded    []string  `json:"hosts_added,omitempty"`
+	HostsRemoved  []string  `json:"hosts_removed,omitempty"`
+	ConfigChanged []string  `json:"config_changed,omitempty"`
+}
+
+// clusterChanges keeps the most recent change summary per cluster. Only
+// the latest one is kept: this is meant for "what did the last push do",
+// not an audit trail.
+var clusterChanges = make(map[string]ClusterChangeSummary)
+var clusterChangesMutex sync.RWMutex
+
+// SetClusterChangeSummary records cluster's most recent change summary,
+// replacing whatever was recorded for it before.
+func SetClusterChangeSummary(summary ClusterChangeSummary) {
+	clusterChangesMutex.Lock()
+	defer clusterChangesMutex.Unlock()
+	clusterChanges[summary.ClusterName] = summary
+}
+
+// GetClusterChangeSummary returns the most recent change summary recorded
+// for clusterName, if any.
+func GetClusterChangeSummary(clusterName string) (ClusterChangeSummary, bool) {
+	clusterChangesMutex.RLock()
+	defer clusterChangesMutex.RUnlock()
+	summary, ok := clusterChanges[clusterName]
+	return summary, ok
+}
+
+// GetClusterChangeSummaries returns the most recent change summary for
+// every cluster that has been added or updated since mosn started.
+func GetClusterChangeSummaries() map[string]ClusterChangeSummary {
+	clusterChangesMutex.RLock()
+	defer clusterChangesMutex.RUnlock()
+	summaries := make(map[string]ClusterChangeSummary, len(clusterChanges))
+	for name, summary := range clusterChanges {
+		summaries[name] = summary
+	}
+	return summaries
+}
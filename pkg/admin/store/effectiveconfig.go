@@ -102,6 +102,15 @@ func SetClusterConfig(clusterName string, cluster v2.Cluster) {
 	tryDump()
 }
 
+// GetClusterConfig returns the config currently stored for clusterName,
+// as it was set by the last SetClusterConfig call.
+func GetClusterConfig(clusterName string) (v2.Cluster, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	cluster, ok := conf.Cluster[clusterName]
+	return cluster, ok
+}
+
 func RemoveClusterConfig(clusterName string) {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -119,6 +128,16 @@ func SetHosts(clusterName string, hostConfigs []v2.Host) {
 	tryDump()
 }
 
+func SetCircuitBreakers(clusterName string, circuitBreakers v2.CircuitBreakers) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if cluster, ok := conf.Cluster[clusterName]; ok {
+		cluster.CirBreThresholds = circuitBreakers
+		conf.Cluster[clusterName] = cluster
+	}
+	tryDump()
+}
+
 func SetRouter(routerName string, router v2.RouterConfiguration) {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -118,6 +118,61 @@ func TestFeatureDump(t *testing.T) {
 
 }
 
+// TestFeatureDumpEDSHosts verifies that hosts delivered by a dynamic
+// source (EDS) are included in the dumped config, in the same per-cluster
+// files ClusterConfigPath uses for normal clusters, so a restarted MOSN
+// can load the last known good host set before reconnecting to the
+// control plane.
+func TestFeatureDumpEDSHosts(t *testing.T) {
+	f := &ConfigAutoFeature{
+		BaseFeatureSpec: featuregate.BaseFeatureSpec{
+			DefaultValue: true,
+		},
+	}
+	Reset()
+	createMosnConfig()
+	cfg := configmanager.Load(testConfigPath)
+	SetMosnConfig(cfg)
+	for _, ln := range cfg.Servers[0].Listeners {
+		SetListenerConfig(ln.Name, ln)
+	}
+	for _, c := range cfg.ClusterManager.Clusters {
+		SetClusterConfig(c.Name, c)
+	}
+
+	edsCluster := v2.Cluster{
+		Name:        "eds_cluster",
+		ClusterType: "EDS",
+		LbType:      "LB_ROUNDROBIN",
+	}
+	SetClusterConfig(edsCluster.Name, edsCluster)
+	SetHosts(edsCluster.Name, []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "172.16.1.154:9080", Weight: 1}},
+		{HostConfig: v2.HostConfig{Address: "172.16.1.155:9080", Weight: 3}},
+	})
+
+	f.dumpConfig()
+	f.doDumpConfig()
+	configmanager.DumpConfig()
+
+	// reload the dumped config as a restarted MOSN process would
+	reloaded := configmanager.Load(testConfigPath)
+	var reloadedCluster *v2.Cluster
+	for i := range reloaded.ClusterManager.Clusters {
+		if reloaded.ClusterManager.Clusters[i].Name == edsCluster.Name {
+			reloadedCluster = &reloaded.ClusterManager.Clusters[i]
+		}
+	}
+	if reloadedCluster == nil {
+		t.Fatal("eds_cluster was not persisted across the dump/reload cycle")
+	}
+	if len(reloadedCluster.Hosts) != 2 ||
+		reloadedCluster.Hosts[0].Address != "172.16.1.154:9080" ||
+		reloadedCluster.Hosts[1].Address != "172.16.1.155:9080" {
+		t.Fatalf("eds_cluster hosts were not persisted as expected, got %v", reloadedCluster.Hosts)
+	}
+}
+
 const testConfigPath = "/tmp/mosn_admin.json"
 
 func createMosnConfig() {
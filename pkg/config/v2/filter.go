@@ -74,6 +74,14 @@ const (
 	RPC_PROXY                   = "rpc_proxy"
 	X_PROXY                     = "x_proxy"
 	Transcoder                  = "transcoder"
+	REDIS_CLUSTER_PROXY         = "redis_cluster_proxy"
+	MYSQL_PROXY                 = "mysql_proxy"
+	KAFKA_PROXY                 = "kafka_proxy"
+	WEBSOCKET_PROXY             = "websocket_proxy"
+	CONNECT_PROXY               = "connect_proxy"
+	SOCKS5_PROXY                = "socks5_proxy"
+	DNS_PROXY                   = "dns_proxy"
+	MQTT_PROXY                  = "mqtt_proxy"
 )
 
 // Stream Filter's Type
@@ -133,6 +133,31 @@ func TestHostUnmarshal(t *testing.T) {
 	}
 }
 
+func TestTransportSocketMatchMarshal(t *testing.T) {
+	tsm := &TransportSocketMatch{
+		TransportSocketMatchConfig: TransportSocketMatchConfig{
+			Name: "istio-mtls",
+		},
+		Match: map[string]string{
+			"tlsMode": "istio",
+		},
+	}
+	b, err := json.Marshal(tsm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ntsm := &TransportSocketMatch{}
+	if err := json.Unmarshal(b, ntsm); err != nil {
+		t.Fatal(err)
+	}
+	if ntsm.Name != "istio-mtls" {
+		t.Fatal("unmarshal result is not expected")
+	}
+	if v, ok := ntsm.Match["tlsMode"]; !ok || v != "istio" {
+		t.Fatal("unmarshal result is not expected")
+	}
+}
+
 func TestHealthCheckFilterMarshal(t *testing.T) {
 	hc := &HealthCheckFilter{
 		HealthCheckFilterConfig: HealthCheckFilterConfig{
@@ -28,14 +28,87 @@ type LeastRequestLbConfig struct {
 func (lbconfig *LeastRequestLbConfig) isCluster_LbConfig() {
 }
 
+// RingHashLbConfig configures the ring built by the ring-hash (ketama)
+// load balancer. MinRingSize/MaxRingSize bound the number of entries in the
+// ring; the actual size is scaled by host weight between those bounds.
+//
+// UseBoundedLoads enables "consistent hashing with bounded loads": when the
+// host a hash would normally land on already carries more than
+// BoundedLoadFactor times the cluster's average active requests, the next
+// host clockwise on the ring is tried instead. BoundedLoadFactor defaults to
+// 1.25 (i.e. a host may carry 25% more than average before load spills over).
+type RingHashLbConfig struct {
+	MinRingSize       uint64
+	MaxRingSize       uint64
+	UseBoundedLoads   bool
+	BoundedLoadFactor float64
+}
+
+func (lbconfig *RingHashLbConfig) isCluster_LbConfig() {
+}
+
+// ZoneAwareLbConfig configures zone-aware routing. Hosts are grouped by the
+// "zone" key in their metadata; LocalZone identifies which group this mosn
+// instance belongs to.
+type ZoneAwareLbConfig struct {
+	LocalZone string
+}
+
+func (lbconfig *ZoneAwareLbConfig) isCluster_LbConfig() {
+}
+
+// ApertureLbConfig configures the aperture load balancer. MinAperture and
+// MaxAperture bound how many hosts, out of the deterministically ordered
+// ring of the whole cluster, this mosn instance will open connections to;
+// the aperture widens automatically between those bounds as load on it
+// increases.
+type ApertureLbConfig struct {
+	MinAperture uint32
+	MaxAperture uint32
+}
+
+func (lbconfig *ApertureLbConfig) isCluster_LbConfig() {
+}
+
+// PriorityLbConfig configures the priority load balancer. OverprovisioningFactor
+// is the default factor applied to every priority level's healthy percentage
+// before it is compared against full capacity; PriorityOverprovisioningFactors
+// overrides that default for specific priority levels, matching Envoy's
+// per-priority overprovisioning_factor semantics.
+type PriorityLbConfig struct {
+	OverprovisioningFactor          float64
+	PriorityOverprovisioningFactors map[int]float64
+}
+
+func (lbconfig *PriorityLbConfig) isCluster_LbConfig() {
+}
+
+// CanaryLbConfig configures the canary load balancer. CanaryPercent is the
+// percentage (0-100) of traffic steered to hosts labelled as canaries via
+// types.CanaryMetadataKey/types.CanaryMetadataValue; the remainder is served
+// by the stable hosts.
+type CanaryLbConfig struct {
+	CanaryPercent uint32
+}
+
+func (lbconfig *CanaryLbConfig) isCluster_LbConfig() {
+}
+
 type IsCluster_LbConfig interface {
 	isCluster_LbConfig()
 }
 
+// HashPolicy is one entry of a route's hash policy chain. Exactly one of
+// Header, Cookie, SourceIP or QueryParameter should be set. Entries are
+// evaluated in the order they appear in RouteAction.HashPolicy; Terminal
+// stops the chain as soon as this entry yields a non-zero hash, otherwise
+// all yielded hashes are combined.
 type HashPolicy struct {
-	Header   *HeaderHashPolicy   `json:"header,omitempty"`
-	Cookie   *CookieHashPolicy   `json:"cookie,omitempty"`
-	SourceIP *SourceIPHashPolicy `json:"source_ip,omitempty"`
+	Header         *HeaderHashPolicy         `json:"header,omitempty"`
+	Cookie         *CookieHashPolicy         `json:"cookie,omitempty"`
+	SourceIP       *SourceIPHashPolicy       `json:"source_ip,omitempty"`
+	QueryParameter *QueryParameterHashPolicy `json:"query_parameter,omitempty"`
+	Terminal       bool                      `json:"terminal,omitempty"`
 }
 
 type HeaderHashPolicy struct {
@@ -50,3 +123,8 @@ type CookieHashPolicy struct {
 
 type SourceIPHashPolicy struct {
 }
+
+// QueryParameterHashPolicy hashes on the value of a URL query parameter.
+type QueryParameterHashPolicy struct {
+	Name string `json:"name,omitempty"`
+}
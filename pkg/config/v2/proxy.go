@@ -28,11 +28,112 @@ type TCPProxy struct {
 	Routes             []*TCPRoute    `json:"routes,omitempty"`
 }
 
-// WebSocketProxy
+// RedisSlotRange assigns an inclusive range of Redis Cluster hash slots
+// (0-16383) to the mosn cluster that currently serves them.
+type RedisSlotRange struct {
+	Cluster string `json:"cluster,omitempty"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+}
+
+// RedisClusterProxy configures a Redis network filter that shards commands
+// across a Redis Cluster deployment by hash slot, following MOVED/ASK
+// redirects as the cluster's own topology reports them.
+type RedisClusterProxy struct {
+	StatPrefix     string           `json:"stat_prefix,omitempty"`
+	DefaultCluster string           `json:"default_cluster,omitempty"`
+	Slots          []RedisSlotRange `json:"slots,omitempty"`
+}
+
+// MySQLProxy configures a MySQL network filter that observes query
+// latency/type for stats and access logs, and optionally splits read-only
+// statements to a replica cluster once the connection has authenticated
+// against both clusters. ReplicaCluster is optional; leaving it empty
+// disables splitting and the filter behaves as an observing pass-through
+// to PrimaryCluster only.
+type MySQLProxy struct {
+	StatPrefix     string `json:"stat_prefix,omitempty"`
+	PrimaryCluster string `json:"primary_cluster,omitempty"`
+	ReplicaCluster string `json:"replica_cluster,omitempty"`
+}
+
+// KafkaProxy configures a Kafka network filter that proxies a single
+// downstream connection to Cluster, records per-topic Produce/Fetch
+// latency, and rewrites the broker addresses a Metadata response
+// advertises to AdvertisedListener so a client that just discovered the
+// cluster's brokers through mosn keeps talking to mosn for its
+// subsequent Produce/Fetch requests, rather than connecting straight to
+// a broker address it can't necessarily even reach.
+type KafkaProxy struct {
+	StatPrefix         string `json:"stat_prefix,omitempty"`
+	Cluster            string `json:"cluster,omitempty"`
+	AdvertisedListener string `json:"advertised_listener,omitempty"`
+}
+
+// WebSocketProxy configures a network filter that completes an HTTP/1.1
+// websocket upgrade handshake against Cluster, then switches the
+// connection to transparent bidirectional byte proxying for the rest of
+// its lifetime.
 type WebSocketProxy struct {
-	StatPrefix         string
-	IdleTimeout        *time.Duration
-	MaxConnectAttempts uint32
+	StatPrefix         string         `json:"stat_prefix,omitempty"`
+	Cluster            string         `json:"cluster,omitempty"`
+	IdleTimeout        *time.Duration `json:"idle_timeout,omitempty"`
+	MaxConnectAttempts uint32         `json:"max_connect_attempts,omitempty"`
+}
+
+// ConnectProxy configures a network filter that terminates an HTTP/1.1
+// CONNECT request on the downstream connection, then switches to
+// transparent bidirectional byte proxying for the rest of its lifetime.
+// If Cluster is set, the CONNECT is tunneled to that cluster instead of
+// dialed directly, letting MOSN act as a tunnel-termination hop in front
+// of another CONNECT-aware peer (e.g. a further MOSN mesh hop) rather
+// than a plain forward proxy.
+type ConnectProxy struct {
+	StatPrefix  string         `json:"stat_prefix,omitempty"`
+	Cluster     string         `json:"cluster,omitempty"`
+	IdleTimeout *time.Duration `json:"idle_timeout,omitempty"`
+}
+
+// Socks5Proxy configures a network filter that terminates a SOCKS5
+// handshake (RFC 1928) on the downstream connection - optionally
+// requiring username/password auth (RFC 1929) - then switches to
+// transparent bidirectional byte proxying for the rest of its lifetime.
+// If Cluster is set, the connection is routed to that cluster instead of
+// dialed directly at the client-requested destination, letting the
+// SOCKS5 destination feed normal cluster routing rather than acting as a
+// plain forward proxy.
+type Socks5Proxy struct {
+	StatPrefix  string         `json:"stat_prefix,omitempty"`
+	Cluster     string         `json:"cluster,omitempty"`
+	IdleTimeout *time.Duration `json:"idle_timeout,omitempty"`
+	Username    string         `json:"username,omitempty"`
+	Password    string         `json:"password,omitempty"`
+}
+
+// DNSProxy configures a network filter that answers DNS-over-TCP queries
+// (RFC 1035 section 4.2.2 framing) for mesh service names out of the
+// cluster manager's own host set, and forwards everything else to
+// UpstreamDNS - letting a transparent-mesh deployment resolve its peers
+// without any external DNS plumbing.
+type DNSProxy struct {
+	StatPrefix  string `json:"stat_prefix,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	UpstreamDNS string `json:"upstream_dns,omitempty"`
+	TTL         uint32 `json:"ttl,omitempty"`
+}
+
+// MQTTProxy configures a network filter that terminates an MQTT 3.1.1 or
+// 5.0 CONNECT packet on the downstream connection, routes it to an
+// upstream cluster by RouteBy's client-id or username, then relays the
+// session byte for byte - counting PUBLISH traffic per topic and
+// answering PINGREQ locally (in addition to forwarding it upstream) so a
+// slow upstream round trip doesn't delay the client's own keepalive.
+type MQTTProxy struct {
+	StatPrefix     string            `json:"stat_prefix,omitempty"`
+	RouteBy        string            `json:"route_by,omitempty"` // "client_id" (default) or "username"
+	Routes         map[string]string `json:"routes,omitempty"`   // RouteBy value -> cluster name
+	DefaultCluster string            `json:"default_cluster,omitempty"`
+	IdleTimeout    *time.Duration    `json:"idle_timeout,omitempty"`
 }
 
 // Proxy
@@ -56,6 +56,7 @@ type RouterActionConfig struct {
 	MetadataConfig          *MetadataConfig      `json:"metadata_match,omitempty"`
 	TimeoutConfig           api.DurationConfig   `json:"timeout,omitempty"`
 	RetryPolicy             *RetryPolicy         `json:"retry_policy,omitempty"`
+	RequestMirrorPolicy     *RequestMirrorPolicy `json:"request_mirror_policy,omitempty"`
 	PrefixRewrite           string               `json:"prefix_rewrite,omitempty"`
 	HostRewrite             string               `json:"host_rewrite,omitempty"`
 	AutoHostRewrite         bool                 `json:"auto_host_rewrite,omitempty"`
@@ -63,6 +64,11 @@ type RouterActionConfig struct {
 	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
 	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
 	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
+	// SubsetFallbackPolicy overrides the cluster's configured subset load
+	// balancer fallback policy for routes that match this rule. One of
+	// "no_fallback", "any_endpoint" or "default_subset"; empty keeps the
+	// cluster's own configuration.
+	SubsetFallbackPolicy string `json:"subset_fallback_policy,omitempty"`
 }
 
 type ClusterWeightConfig struct {
@@ -77,6 +83,18 @@ type RetryPolicyConfig struct {
 	NumRetries         uint32             `json:"num_retries,omitempty"`
 }
 
+// RequestMirrorPolicy configures mirroring (shadow traffic) of a route's
+// requests to another cluster. Mirrored requests are fire-and-forget: their
+// responses are discarded and failures on the shadow path never affect the
+// primary request.
+type RequestMirrorPolicy struct {
+	// Cluster is the shadow cluster requests are duplicated to.
+	Cluster string `json:"cluster,omitempty"`
+	// Percent is the percentage, 0-100, of requests to mirror. A route with
+	// no configured Percent mirrors nothing.
+	Percent uint32 `json:"percent,omitempty"`
+}
+
 // Router, the list of routes that will be matched, in order, for incoming requests.
 // The first route that matches will be used.
 type Router struct {
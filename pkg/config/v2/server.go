@@ -50,9 +50,21 @@ type ListenerType string
 const EGRESS ListenerType = "egress"
 const INGRESS ListenerType = "ingress"
 
+// ListenerNetwork selects the transport a listener binds: "tcp" (the
+// default, used when Network is empty) or "quic". QUIC is accepted as
+// config today, but pkg/network's listener only knows how to open a
+// net.ListenTCP socket, so a "quic" listener fails fast at start-up
+// instead of silently behaving like a TCP one; see the check in
+// (*listener).listen.
+type ListenerNetwork string
+
+const ListenerNetworkTCP ListenerNetwork = "tcp"
+const ListenerNetworkQUIC ListenerNetwork = "quic"
+
 type ListenerConfig struct {
 	Name                  string              `json:"name,omitempty"`
 	Type                  ListenerType        `json:"type,omitempty"`
+	Network               ListenerNetwork     `json:"network,omitempty"`
 	AddrConfig            string              `json:"address,omitempty"`
 	BindToPort            bool                `json:"bind_port,omitempty"`
 	UseOriginalDst        bool                `json:"use_original_dst,omitempty"`
@@ -30,23 +30,62 @@ import (
 )
 
 type HealthCheckConfig struct {
-	Protocol             string                 `json:"protocol,omitempty"`
-	TimeoutConfig        api.DurationConfig     `json:"timeout,omitempty"`
-	IntervalConfig       api.DurationConfig     `json:"interval,omitempty"`
-	IntervalJitterConfig api.DurationConfig     `json:"interval_jitter,omitempty"`
-	HealthyThreshold     uint32                 `json:"healthy_threshold,omitempty"`
-	UnhealthyThreshold   uint32                 `json:"unhealthy_threshold,omitempty"`
-	ServiceName          string                 `json:"service_name,omitempty"`
-	SessionConfig        map[string]interface{} `json:"check_config,omitempty"`
-	CommonCallbacks      []string               `json:"common_callbacks,omitempty"` // HealthCheck support register some common callbacks that are not related to specific cluster
+	Protocol             string             `json:"protocol,omitempty"`
+	TimeoutConfig        api.DurationConfig `json:"timeout,omitempty"`
+	IntervalConfig       api.DurationConfig `json:"interval,omitempty"`
+	IntervalJitterConfig api.DurationConfig `json:"interval_jitter,omitempty"`
+	// InitialJitterConfig bounds a random delay added to the very first check
+	// of each host, so that a config push does not probe every host at once.
+	InitialJitterConfig api.DurationConfig `json:"initial_jitter,omitempty"`
+	// NoTrafficIntervalConfig is the check interval used for a host that has
+	// received no requests since its last check, letting idle hosts be
+	// probed less aggressively than active ones. 0 disables it, always using
+	// IntervalConfig.
+	NoTrafficIntervalConfig api.DurationConfig     `json:"no_traffic_interval,omitempty"`
+	HealthyThreshold        uint32                 `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold      uint32                 `json:"unhealthy_threshold,omitempty"`
+	ServiceName             string                 `json:"service_name,omitempty"`
+	SessionConfig           map[string]interface{} `json:"check_config,omitempty"`
+	CommonCallbacks         []string               `json:"common_callbacks,omitempty"` // HealthCheck support register some common callbacks that are not related to specific cluster
+	// TLS is the transport used to dial a health check session. It is
+	// independent of the cluster's data-path TLS (Cluster.TLS): a cluster
+	// can serve TLS traffic but be health checked in plaintext, or vice
+	// versa, and the SNI/ALPN used for the check can differ from the one
+	// used for real requests. Leaving it unset (Status false) health checks
+	// in plaintext regardless of the cluster's own TLS settings.
+	TLS TLSConfig `json:"tls_context,omitempty"`
 }
 
 type HostConfig struct {
-	Address        string          `json:"address,omitempty"`
-	Hostname       string          `json:"hostname,omitempty"`
-	Weight         uint32          `json:"weight,omitempty"`
-	MetaDataConfig *MetadataConfig `json:"metadata,omitempty"`
-	TLSDisable     bool            `json:"tls_disable,omitempty"`
+	Address           string                `json:"address,omitempty"`
+	Hostname          string                `json:"hostname,omitempty"`
+	Weight            uint32                `json:"weight,omitempty"`
+	MetaDataConfig    *MetadataConfig       `json:"metadata,omitempty"`
+	TLSDisable        bool                  `json:"tls_disable,omitempty"`
+	HealthCheckConfig HostHealthCheckConfig `json:"health_check_config,omitempty"`
+	// Locality identifies the zone/region the host runs in, e.g. "az1". It
+	// is opaque to mosn itself: load balancing and stats keying are the only
+	// consumers, so any operator-chosen string works. Empty means the host
+	// has no locality and is grouped under "" wherever locality is used as
+	// a stats key.
+	Locality string `json:"locality,omitempty"`
+	// MaxConnections caps how many upstream connections a pool may open to
+	// this one host, independent of the cluster's circuit breaker
+	// connection threshold: the cluster's limit is a shared, cluster-wide
+	// budget, so without a per-host cap a single slow or oversubscribed
+	// host could still claim connections up to that whole budget on its
+	// own. Zero means no host-specific cap; the cluster's limit still
+	// applies.
+	MaxConnections uint32 `json:"max_connections,omitempty"`
+}
+
+// HostHealthCheckConfig overrides health check behavior for a single host.
+type HostHealthCheckConfig struct {
+	// Address overrides the host:port a health check session dials for this
+	// host. Traffic is unaffected and still uses HostConfig.Address; this is
+	// only used when a backend exposes its health endpoint on a different
+	// address or port than it serves traffic on.
+	Address string `json:"address,omitempty"`
 }
 
 // ClusterType
@@ -60,6 +99,20 @@ const (
 	EDS_CLUSTER         ClusterType = "EDS"
 	ORIGINALDST_CLUSTER ClusterType = "ORIGINAL_DST"
 	STRICT_DNS_CLUSTER  ClusterType = "STRICT_DNS"
+	LOGICAL_DNS_CLUSTER ClusterType = "LOGICAL_DNS"
+
+	// DYNAMIC_FORWARD_PROXY_CLUSTER has no statically configured hosts: its
+	// load balancer creates a host on demand for every distinct Host header
+	// it sees, resolving and caching its address, so MOSN can act as an
+	// egress proxy to arbitrary domains.
+	DYNAMIC_FORWARD_PROXY_CLUSTER ClusterType = "DYNAMIC_FORWARD_PROXY"
+
+	// AGGREGATE_CLUSTER has no hosts of its own: it references the Clusters
+	// list of other, already-configured clusters by name, and is resolved
+	// to the first one with a healthy host, falling back down the list.
+	// This lets a route target one cluster name while MOSN fails over
+	// between e.g. a primary and a DR datacenter underneath it.
+	AGGREGATE_CLUSTER ClusterType = "AGGREGATE"
 )
 
 // LbType
@@ -67,11 +120,12 @@ type LbType string
 
 // Group of load balancer type
 const (
-	LB_RANDOM        LbType = "LB_RANDOM"
-	LB_ROUNDROBIN    LbType = "LB_ROUNDROBIN"
-	LB_ORIGINAL_DST  LbType = "LB_ORIGINAL_DST"
-	LB_LEAST_REQUEST LbType = "LB_LEAST_REQUEST"
-	LB_MAGLEV        LbType = "LB_MAGLEV"
+	LB_RANDOM                LbType = "LB_RANDOM"
+	LB_ROUNDROBIN            LbType = "LB_ROUNDROBIN"
+	LB_ORIGINAL_DST          LbType = "LB_ORIGINAL_DST"
+	LB_LEAST_REQUEST         LbType = "LB_LEAST_REQUEST"
+	LB_MAGLEV                LbType = "LB_MAGLEV"
+	LB_DYNAMIC_FORWARD_PROXY LbType = "LB_DYNAMIC_FORWARD_PROXY"
 )
 
 type DnsLookupFamily string
@@ -83,7 +137,13 @@ const (
 
 // Cluster represents a cluster's information
 type Cluster struct {
-	Name                 string              `json:"name,omitempty"`
+	Name string `json:"name,omitempty"`
+	// AltStatName is an alternate, stable name used to record this
+	// cluster's stats and to resolve routes, in place of Name. Configure it
+	// when the control plane rotates Name (e.g. a versioned or generated
+	// identifier) so that dashboards and routes referencing the old name
+	// keep working across the rotation. Empty uses Name for both.
+	AltStatName          string              `json:"alt_stat_name,omitempty"`
 	ClusterType          ClusterType         `json:"type,omitempty"`
 	SubType              string              `json:"sub_type,omitempty"` //not used yet
 	LbType               LbType              `json:"lb_type,omitempty"`
@@ -104,6 +164,218 @@ type Cluster struct {
 	DnsResolverConfig    DnsResolverConfig   `json:"dns_resolvers,omitempty"`
 	DnsResolverFile      string              `json:"dns_resolver_file,omitempty"`
 	DnsResolverPort      string              `json:"dns_resolver_port,omitempty"`
+	// HealthyPanicThreshold is the percentage of healthy (non-degraded) hosts
+	// below which the load balancer stops excluding unhealthy hosts and
+	// routes to the whole host set instead, to avoid overloading the few
+	// hosts that are still healthy. 0 uses the default of 50.
+	HealthyPanicThreshold float64 `json:"healthy_panic_threshold,omitempty"`
+	// OutlierDetection configures passive outlier detection: ejecting hosts
+	// that keep failing requests without waiting for an active health check.
+	OutlierDetection OutlierDetection `json:"outlier_detection,omitempty"`
+	// WarmupTimeout bounds how long a newly added cluster may stay in the
+	// warming state - not yet eligible to receive traffic - waiting for its
+	// initial host set and, if health checking is configured, the first
+	// health check round to complete. 0 uses the default of 10s. Once it
+	// elapses the cluster is activated anyway, so a stuck EDS or health
+	// check update cannot permanently block traffic to an otherwise valid
+	// cluster.
+	WarmupTimeout api.DurationConfig `json:"warmup_timeout,omitempty"`
+	// DrainTimeout bounds how long RemovePrimaryCluster waits for a removed
+	// cluster's active requests to finish before its connection pools are
+	// closed. 0 uses the default of 10s.
+	DrainTimeout api.DurationConfig `json:"drain_timeout,omitempty"`
+	// UpstreamProtocol is the protocol MOSN uses to talk to this cluster's
+	// hosts, overriding whatever protocol the proxy/route would otherwise
+	// pick from the downstream connection - e.g. "Http2" to front an
+	// HTTP/2 upstream behind an HTTP/1.1 downstream. Empty or "Auto" keeps
+	// the existing behavior of matching the downstream protocol, except
+	// over TLS with ALPN configured, where Auto picks the highest priority
+	// protocol advertised in TLS.ALPN.
+	UpstreamProtocol string `json:"upstream_protocol,omitempty"`
+	// Clusters is the ordered list of underlying cluster names an AGGREGATE
+	// cluster falls back across. Ignored for every other ClusterType.
+	Clusters []string `json:"clusters,omitempty"`
+	// MaxConnectionDuration bounds how long a connection pool keeps a single
+	// upstream connection alive, in addition to MaxRequestPerConn: once a
+	// connection has been open this long, it is closed and replaced by a new
+	// one the next time the pool needs a connection, as if it had hit its
+	// request limit. 0 means no duration limit.
+	MaxConnectionDuration api.DurationConfig `json:"max_connection_duration,omitempty"`
+	// IdleTimeout bounds how long a pooled upstream connection may sit with
+	// no active stream before it is closed, freeing the socket instead of
+	// leaving it open on the chance a future request lands on it. 0 means
+	// no idle timeout. Only enforced by connection pools that keep more
+	// than one idle connection per host, e.g. the HTTP/1.1 pool.
+	IdleTimeout api.DurationConfig `json:"idle_timeout,omitempty"`
+	// UpstreamConnectionOptions configures the TCP socket options applied to
+	// every connection this cluster opens to its hosts.
+	UpstreamConnectionOptions UpstreamConnectionOptions `json:"upstream_connection_options,omitempty"`
+	// TransportSocketMatches lets a host opt into a different TLS context
+	// than TLS, by matching the host's metadata. Checked in order; the
+	// first match whose Match is a subset of a host's metadata is used for
+	// every connection to that host. A host matching none of them falls
+	// back to TLS, same as if this field were empty. Useful for mixing
+	// mTLS-capable and plaintext endpoints in the same cluster, e.g. during
+	// a mesh migration where only some endpoints have picked up sidecar
+	// certificates yet.
+	TransportSocketMatches []TransportSocketMatch `json:"transport_socket_matches,omitempty"`
+	// ConnectionAffinity binds one upstream connection to each downstream
+	// connection for its lifetime, instead of sharing this cluster's
+	// upstream connections across every downstream connection that routes
+	// to it. Set this for protocols that require connection affinity, such
+	// as some MQ and database proxies that pin session or transaction
+	// state to a single TCP connection.
+	ConnectionAffinity bool `json:"connection_affinity,omitempty"`
+	// Http1ProtocolOptions configures HTTP/1.1-specific upstream connection
+	// reuse behavior for this cluster, on top of the general
+	// MaxRequestPerConn/MaxConnectionDuration/IdleTimeout knobs every
+	// protocol's connection pool already honors.
+	Http1ProtocolOptions Http1ProtocolOptions `json:"http1_protocol_options,omitempty"`
+}
+
+// Http1ProtocolOptions configures how mosn's HTTP/1.1 upstream connection
+// pool reuses connections to this cluster's hosts, to reduce connection
+// churn against backends that would rather serve many requests per
+// connection than pay a new handshake for each one.
+type Http1ProtocolOptions struct {
+	// MaxKeepAliveRequests bounds how many requests a single HTTP/1.1
+	// upstream connection may serve before mosn closes and replaces it,
+	// the same effect as the cluster's general MaxRequestPerConn but scoped
+	// to HTTP/1.1 alone, so it can differ from a cluster's other protocol
+	// pools (e.g. an aggregate cluster mixing HTTP/1.1 and HTTP/2 upstream
+	// protocols). 0 falls back to MaxRequestPerConn.
+	MaxKeepAliveRequests uint32 `json:"max_keepalive_requests,omitempty"`
+	// UseServerKeepAlive, when true, parses the upstream's own
+	// "Keep-Alive: timeout=N[, max=M]" response header and lets it further
+	// tighten - never loosen - this connection's idle timeout and
+	// remaining request budget, so mosn doesn't keep a socket open longer
+	// than the backend itself intends to.
+	UseServerKeepAlive bool `json:"use_server_keep_alive,omitempty"`
+}
+
+// TransportSocketMatch pairs a metadata match criteria with the TLS context
+// to use for hosts that satisfy it. See Cluster.TransportSocketMatches.
+type TransportSocketMatch struct {
+	TransportSocketMatchConfig
+	Match api.Metadata `json:"-"`
+}
+
+// TransportSocketMatchConfig is TransportSocketMatch's wire format; Match is
+// derived from it the same way Host derives MetaData from MetaDataConfig.
+type TransportSocketMatchConfig struct {
+	// Name identifies this match for logging; it has no effect on matching.
+	Name string `json:"name,omitempty"`
+	// MatchConfig is the metadata a host must carry, as a subset, for this
+	// match to apply.
+	MatchConfig *MetadataConfig `json:"match,omitempty"`
+	// TLSContext is the TLS context used for hosts this match applies to.
+	TLSContext TLSConfig `json:"tls_context,omitempty"`
+}
+
+func (m TransportSocketMatch) MarshalJSON() (b []byte, err error) {
+	m.TransportSocketMatchConfig.MatchConfig = metadataToConfig(m.Match)
+	return json.Marshal(m.TransportSocketMatchConfig)
+}
+
+func (m *TransportSocketMatch) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &m.TransportSocketMatchConfig); err != nil {
+		return err
+	}
+	m.Match = configToMetadata(m.MatchConfig)
+	return nil
+}
+
+// UpstreamConnectionOptions configures the TCP socket options Host.CreateConnection
+// applies to an upstream connection right after it is dialed, before it is
+// handed to any protocol codec. Useful for multi-NIC deployments that need
+// to pin a cluster's traffic to a specific source interface, or for
+// clusters that need different keepalive/latency tradeoffs than the rest
+// of the mesh.
+type UpstreamConnectionOptions struct {
+	// DisableNoDelay re-enables Nagle's algorithm on the connection. MOSN
+	// disables it (TCP_NODELAY) by default for upstream connections; set
+	// this to keep existing configs' behavior unchanged by default.
+	DisableNoDelay bool `json:"disable_no_delay,omitempty"`
+	// TCPKeepAlive configures TCP keepalive probing. A zero value leaves the
+	// operating system's defaults in place.
+	TCPKeepAlive TCPKeepaliveConfig `json:"tcp_keepalive,omitempty"`
+	// Tos sets the IPv4 TOS / IPv6 traffic class byte (DSCP marking) on
+	// outgoing packets. 0 leaves it unset.
+	Tos int `json:"tos,omitempty"`
+	// SourceAddress binds the connection's local address before connecting,
+	// e.g. "192.168.1.5" or "192.168.1.5:0", so traffic to this cluster goes
+	// out a specific interface. A port range may be given instead of a
+	// single port, e.g. "192.168.1.5:20000-30000", to bind an ephemeral
+	// port from that range rather than letting the operating system pick
+	// from the whole range. Empty lets the operating system choose both.
+	SourceAddress string `json:"source_address,omitempty"`
+	// Mark sets SO_MARK on the socket used to dial this cluster's upstream
+	// connections, e.g. so host firewall/policy rules or ip rule based
+	// routing can identify and steer this traffic. 0 leaves it unset.
+	// Linux only; ignored on other platforms.
+	Mark int `json:"mark,omitempty"`
+}
+
+// TCPKeepaliveConfig configures TCP keepalive probing on an upstream
+// connection. KeepAlive defaults to enabled as soon as any field here is
+// non-zero; Interval with no Probes/Time set still applies just the probe
+// interval, relying on the operating system's defaults for the rest.
+type TCPKeepaliveConfig struct {
+	// Probes is the maximum number of keepalive probes sent before the
+	// connection is considered dead. 0 uses the operating system default.
+	Probes uint32 `json:"probes,omitempty"`
+	// Time is how long the connection may be idle before the first
+	// keepalive probe is sent. 0 uses the operating system default.
+	Time api.DurationConfig `json:"time,omitempty"`
+	// Interval is the time between successive keepalive probes. 0 uses the
+	// operating system default.
+	Interval api.DurationConfig `json:"interval,omitempty"`
+}
+
+// OutlierDetection configures passive outlier detection for a cluster's
+// hosts, based on the results of requests proxied through it rather than a
+// dedicated active health check.
+type OutlierDetection struct {
+	// Consecutive5xx is the number of consecutive 5xx responses (or
+	// equivalent local failures) that ejects a host. 0 uses the default of 5.
+	Consecutive5xx uint32 `json:"consecutive_5xx,omitempty"`
+	// ConsecutiveGatewayFailure is the number of consecutive "gateway
+	// failures" (connect failures, local resets, 502/503/504 responses) that
+	// ejects a host. 0 uses the default of 5.
+	ConsecutiveGatewayFailure uint32 `json:"consecutive_gateway_failure,omitempty"`
+	// Interval is how often the ejection analysis sweeps the cluster's
+	// hosts. 0 uses the default of 10s.
+	Interval api.DurationConfig `json:"interval,omitempty"`
+	// BaseEjectionTime is the base duration a host stays ejected; actual
+	// ejection time scales with the number of times a host has already been
+	// ejected. 0 uses the default of 30s.
+	BaseEjectionTime api.DurationConfig `json:"base_ejection_time,omitempty"`
+	// MaxEjectionPercent caps the percentage of a cluster's hosts that may be
+	// ejected at once. 0 uses the default of 10.
+	MaxEjectionPercent uint32 `json:"max_ejection_percent,omitempty"`
+	// EnforcingConsecutive5xx is the percentage chance (0-100) that a host
+	// detected as an outlier by Consecutive5xx is actually ejected. 0 uses
+	// the default of 100.
+	EnforcingConsecutive5xx uint32 `json:"enforcing_consecutive_5xx,omitempty"`
+	// EnforcingConsecutiveGatewayFailure is the percentage chance (0-100)
+	// that a host detected as an outlier by ConsecutiveGatewayFailure is
+	// actually ejected. 0 uses the default of 0 (disabled).
+	EnforcingConsecutiveGatewayFailure uint32 `json:"enforcing_consecutive_gateway_failure,omitempty"`
+	// EnforcingSuccessRate is the percentage chance (0-100) that a host
+	// detected as an outlier by the success rate detector is actually
+	// ejected. 0 uses the default of 100.
+	EnforcingSuccessRate uint32 `json:"enforcing_success_rate,omitempty"`
+	// SuccessRateMinimumHosts is the minimum number of hosts in the cluster
+	// required for the success rate detector to run. 0 uses the default of 5.
+	SuccessRateMinimumHosts uint32 `json:"success_rate_minimum_hosts,omitempty"`
+	// SuccessRateRequestVolume is the minimum number of requests a host must
+	// have seen in the last Interval for the success rate detector to
+	// consider it. 0 uses the default of 100.
+	SuccessRateRequestVolume uint32 `json:"success_rate_request_volume,omitempty"`
+	// SuccessRateStdevFactor scales the standard deviation used to compute a
+	// host's success rate ejection threshold, in units of 0.1 (i.e. 1900
+	// means 1.9 standard deviations). 0 uses the default of 1900.
+	SuccessRateStdevFactor uint32 `json:"success_rate_stdev_factor,omitempty"`
 }
 
 type DnsResolverConfig struct {
@@ -119,15 +391,19 @@ type DnsResolverConfig struct {
 // use DurationConfig to parse string to time.Duration
 type HealthCheck struct {
 	HealthCheckConfig
-	Timeout        time.Duration `json:"-"`
-	Interval       time.Duration `json:"-"`
-	IntervalJitter time.Duration `json:"-"`
+	Timeout           time.Duration `json:"-"`
+	Interval          time.Duration `json:"-"`
+	IntervalJitter    time.Duration `json:"-"`
+	InitialJitter     time.Duration `json:"-"`
+	NoTrafficInterval time.Duration `json:"-"`
 }
 
 // Marshal implement a json.Marshaler
 func (hc HealthCheck) MarshalJSON() (b []byte, err error) {
 	hc.HealthCheckConfig.IntervalConfig.Duration = hc.Interval
 	hc.HealthCheckConfig.IntervalJitterConfig.Duration = hc.IntervalJitter
+	hc.HealthCheckConfig.InitialJitterConfig.Duration = hc.InitialJitter
+	hc.HealthCheckConfig.NoTrafficIntervalConfig.Duration = hc.NoTrafficInterval
 	hc.HealthCheckConfig.TimeoutConfig.Duration = hc.Timeout
 	return json.Marshal(hc.HealthCheckConfig)
 }
@@ -139,6 +415,8 @@ func (hc *HealthCheck) UnmarshalJSON(b []byte) error {
 	hc.Timeout = hc.TimeoutConfig.Duration
 	hc.Interval = hc.IntervalConfig.Duration
 	hc.IntervalJitter = hc.IntervalJitterConfig.Duration
+	hc.InitialJitter = hc.InitialJitterConfig.Duration
+	hc.NoTrafficInterval = hc.NoTrafficIntervalConfig.Duration
 	return nil
 }
 
@@ -176,12 +454,26 @@ func (cb *CircuitBreakers) UnmarshalJSON(b []byte) (err error) {
 }
 
 type Thresholds struct {
-	MaxConnections     uint32 `json:"max_connections,omitempty"`
-	MaxPendingRequests uint32 `json:"max_pending_requests,omitempty"`
-	MaxRequests        uint32 `json:"max_requests,omitempty"`
-	MaxRetries         uint32 `json:"max_retries,omitempty"`
+	// Priority selects which routing priority this threshold set applies
+	// to. Empty uses DEFAULT_ROUTING_PRIORITY, so existing single-entry
+	// configurations keep applying to all traffic as before.
+	Priority           RoutingPriority `json:"priority,omitempty"`
+	MaxConnections     uint32          `json:"max_connections,omitempty"`
+	MaxPendingRequests uint32          `json:"max_pending_requests,omitempty"`
+	MaxRequests        uint32          `json:"max_requests,omitempty"`
+	MaxRetries         uint32          `json:"max_retries,omitempty"`
 }
 
+// RoutingPriority selects which of a cluster's circuit breaker threshold
+// sets applies to a request.
+type RoutingPriority string
+
+// Group of routing priority
+const (
+	DEFAULT_ROUTING_PRIORITY RoutingPriority = "DEFAULT"
+	HIGH_ROUTING_PRIORITY    RoutingPriority = "HIGH"
+)
+
 // ClusterSpecInfo is a configuration of subscribe
 type ClusterSpecInfo struct {
 	Subscribes []SubscribeSpec `json:"subscribe,omitempty"`
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connectproxy
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+var headerEnd = []byte("\r\n\r\n")
+
+// splitHead finds the request/status-line-plus-headers block at the front
+// of data (terminated by a blank line, same as HTTP/1.1), returning it and
+// the number of bytes it occupies. Returns (nil, 0) when data doesn't yet
+// hold a complete head - the same "wait for more data" contract the
+// websocket filter's decoder uses.
+func splitHead(data []byte) (head []byte, n int) {
+	idx := bytes.Index(data, headerEnd)
+	if idx < 0 {
+		return nil, 0
+	}
+	return data[:idx], idx + len(headerEnd)
+}
+
+// connectTarget parses an HTTP/1.1 CONNECT request line ("CONNECT
+// host:port HTTP/1.1") out of head, returning the authority it names. ok
+// is false if the request line isn't a CONNECT.
+func connectTarget(head []byte) (authority string, ok bool) {
+	requestLine := head
+	if idx := bytes.IndexByte(head, '\n'); idx >= 0 {
+		requestLine = head[:idx]
+	}
+	fields := strings.Fields(string(requestLine))
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "CONNECT") {
+		return "", false
+	}
+	return fields[1], true
+}
+
+// connectResponse builds the "200 Connection Established" response mosn
+// sends downstream once the tunnel target is reachable, mirroring the
+// line a forward proxy classically answers a CONNECT with.
+func connectResponse() []byte {
+	return []byte("HTTP/1.1 200 Connection Established\r\n\r\n")
+}
+
+// connectErrorResponse builds a failure response for a CONNECT that
+// couldn't be satisfied, e.g. an unreachable target or unhealthy cluster.
+func connectErrorResponse(statusCode int, reason string) []byte {
+	return []byte("HTTP/1.1 " + strconv.Itoa(statusCode) + " " + reason + "\r\n\r\n")
+}
@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connectproxy
+
+import "testing"
+
+func TestSplitHead(t *testing.T) {
+	data := []byte("CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\nbody-bytes")
+	head, n := splitHead(data)
+	if string(head) != "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443" {
+		t.Errorf("splitHead() head = %q", head)
+	}
+	if string(data[n:]) != "body-bytes" {
+		t.Errorf("splitHead() n leaves %q, want \"body-bytes\"", data[n:])
+	}
+
+	if head, n := splitHead([]byte("CONNECT example.com:443 HTTP/1.1\r\n")); head != nil || n != 0 {
+		t.Errorf("splitHead() on incomplete head = (%q, %d), want (nil, 0)", head, n)
+	}
+}
+
+func TestConnectTarget(t *testing.T) {
+	target, ok := connectTarget([]byte("CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n"))
+	if !ok || target != "example.com:443" {
+		t.Errorf("connectTarget() = (%q, %v), want (\"example.com:443\", true)", target, ok)
+	}
+
+	if _, ok := connectTarget([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n")); ok {
+		t.Errorf("connectTarget() on a GET = true, want false")
+	}
+}
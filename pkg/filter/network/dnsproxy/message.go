@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnsproxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	qtypeA    = 1
+	qclassIN  = 1
+	headerLen = 12
+)
+
+// query is the part of a DNS message this filter needs: the header's ID
+// and flags, and the first question's name/type/class. Mesh lookups and
+// upstream forwarding only ever need a single question, which is what
+// every stub resolver sends.
+type query struct {
+	id           uint16
+	flags        uint16
+	name         string // dot-joined, no trailing dot, lower-cased
+	qtype        uint16
+	qclass       uint16
+	questionSize int // length in bytes of the raw QNAME+QTYPE+QCLASS
+}
+
+// parseQuery decodes the header and first question out of a raw DNS
+// message (no length prefix). It returns an error for anything this
+// filter isn't prepared to answer itself - malformed messages, or
+// anything but exactly one question - so the caller can fall back to
+// forwarding the raw bytes upstream unmodified.
+func parseQuery(msg []byte) (*query, error) {
+	if len(msg) < headerLen {
+		return nil, fmt.Errorf("dns message too short: %d bytes", len(msg))
+	}
+	qdcount := binary.BigEndian.Uint16(msg[4:6])
+	if qdcount != 1 {
+		return nil, fmt.Errorf("dns message has %d questions, want 1", qdcount)
+	}
+
+	name, offset, err := decodeName(msg, headerLen)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) < offset+4 {
+		return nil, fmt.Errorf("dns question truncated")
+	}
+	qtype := binary.BigEndian.Uint16(msg[offset : offset+2])
+	qclass := binary.BigEndian.Uint16(msg[offset+2 : offset+4])
+
+	return &query{
+		id:           binary.BigEndian.Uint16(msg[0:2]),
+		flags:        binary.BigEndian.Uint16(msg[2:4]),
+		name:         strings.ToLower(name),
+		qtype:        qtype,
+		qclass:       qclass,
+		questionSize: offset + 4 - headerLen,
+	}, nil
+}
+
+// decodeName decodes a sequence of length-prefixed labels starting at
+// offset, stopping at the terminating zero-length label. Compression
+// pointers aren't supported - real stub-resolver queries don't use them
+// in the question section - and are rejected as an error.
+func decodeName(msg []byte, offset int) (name string, next int, err error) {
+	var labels []string
+	for {
+		if offset >= len(msg) {
+			return "", 0, fmt.Errorf("dns name runs past end of message")
+		}
+		length := int(msg[offset])
+		if length == 0 {
+			offset++
+			break
+		}
+		if length&0xc0 != 0 {
+			return "", 0, fmt.Errorf("dns name compression is not supported in a question")
+		}
+		offset++
+		if offset+length > len(msg) {
+			return "", 0, fmt.Errorf("dns label runs past end of message")
+		}
+		labels = append(labels, string(msg[offset:offset+length]))
+		offset += length
+	}
+	return strings.Join(labels, "."), offset, nil
+}
+
+// buildAnswer builds a success response to q, answering with one A
+// record per address in ips. The question section is copied verbatim
+// from raw (bytes [headerLen : headerLen+q.questionSize)), and each
+// answer's name is a compression pointer back to it.
+func buildAnswer(raw []byte, q *query, ips []net.IP, ttl uint32) []byte {
+	question := raw[headerLen : headerLen+q.questionSize]
+
+	resp := make([]byte, 0, headerLen+len(question)+len(ips)*16)
+	resp = append(resp, 0, 0) // id, filled below
+	binary.BigEndian.PutUint16(resp[0:2], q.id)
+
+	flags := uint16(0x8000)   // QR = 1 (response)
+	flags |= q.flags & 0x7800 // OPCODE, echoed from the query
+	flags |= q.flags & 0x0100 // RD, echoed from the query
+	flags |= 0x0080           // RA = 1, mosn always attempts recursion/forwarding
+	resp = appendUint16(resp, flags)
+	resp = appendUint16(resp, 1)                // QDCOUNT
+	resp = appendUint16(resp, uint16(len(ips))) // ANCOUNT
+	resp = appendUint16(resp, 0)                // NSCOUNT
+	resp = appendUint16(resp, 0)                // ARCOUNT
+
+	resp = append(resp, question...)
+
+	for _, ip := range ips {
+		v4 := ip.To4()
+		if v4 == nil {
+			continue
+		}
+		resp = append(resp, 0xc0, 0x0c) // pointer to the question's name at offset 12
+		resp = appendUint16(resp, qtypeA)
+		resp = appendUint16(resp, qclassIN)
+		resp = append(resp, 0, 0, 0, 0) // TTL, filled below
+		binary.BigEndian.PutUint32(resp[len(resp)-4:], ttl)
+		resp = appendUint16(resp, 4) // RDLENGTH
+		resp = append(resp, v4...)
+	}
+
+	return resp
+}
+
+// buildError builds a response to q with the given RCODE and no answers,
+// e.g. for a mesh name with no healthy hosts.
+func buildError(raw []byte, q *query, rcode uint16) []byte {
+	question := raw[headerLen : headerLen+q.questionSize]
+
+	resp := make([]byte, 0, headerLen+len(question))
+	resp = appendUint16(resp, q.id)
+	flags := uint16(0x8000) | (q.flags & 0x7800) | (q.flags & 0x0100) | 0x0080 | (rcode & 0x000f)
+	resp = appendUint16(resp, flags)
+	resp = appendUint16(resp, 1) // QDCOUNT
+	resp = appendUint16(resp, 0) // ANCOUNT
+	resp = appendUint16(resp, 0) // NSCOUNT
+	resp = appendUint16(resp, 0) // ARCOUNT
+	resp = append(resp, question...)
+	return resp
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
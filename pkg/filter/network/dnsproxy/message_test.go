@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dnsproxy
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// buildQuery builds a minimal, single-question DNS query message for name
+// (e.g. "my-service.mesh") with the given qtype.
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(msg[0:2], id)
+	binary.BigEndian.PutUint16(msg[2:4], 0x0100) // RD = 1
+	binary.BigEndian.PutUint16(msg[4:6], 1)      // QDCOUNT
+
+	for _, label := range splitLabels(name) {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0) // terminating root label
+
+	msg = appendUint16(msg, qtype)
+	msg = appendUint16(msg, qclassIN)
+	return msg
+}
+
+func splitLabels(name string) []string {
+	var labels []string
+	start := 0
+	for i := 0; i <= len(name); i++ {
+		if i == len(name) || name[i] == '.' {
+			labels = append(labels, name[start:i])
+			start = i + 1
+		}
+	}
+	return labels
+}
+
+func TestParseQuery(t *testing.T) {
+	msg := buildQuery(0x1234, "my-service.mesh", qtypeA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery() error = %v", err)
+	}
+	if q.id != 0x1234 {
+		t.Errorf("id = %#x, want 0x1234", q.id)
+	}
+	if q.name != "my-service.mesh" {
+		t.Errorf("name = %q, want my-service.mesh", q.name)
+	}
+	if q.qtype != qtypeA {
+		t.Errorf("qtype = %d, want %d", q.qtype, qtypeA)
+	}
+
+	if _, err := parseQuery(msg[:headerLen-1]); err == nil {
+		t.Error("parseQuery() on a too-short header should error")
+	}
+
+	if _, err := parseQuery(msg[:len(msg)-2]); err == nil {
+		t.Error("parseQuery() on a truncated question should error")
+	}
+}
+
+func TestBuildAnswer(t *testing.T) {
+	msg := buildQuery(0x1234, "my-service.mesh", qtypeA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery() error = %v", err)
+	}
+
+	resp := buildAnswer(msg, q, []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, 30)
+
+	respQ, err := parseQuery(resp)
+	if err != nil {
+		t.Fatalf("parseQuery(response) error = %v", err)
+	}
+	if respQ.id != 0x1234 {
+		t.Errorf("response id = %#x, want 0x1234", respQ.id)
+	}
+	if respQ.flags&0x8000 == 0 {
+		t.Error("response flags missing QR bit")
+	}
+
+	ancount := binary.BigEndian.Uint16(resp[6:8])
+	if ancount != 2 {
+		t.Errorf("ANCOUNT = %d, want 2", ancount)
+	}
+
+	answers := resp[headerLen+q.questionSize:]
+	if len(answers) != 2*16 {
+		t.Fatalf("answers section length = %d, want %d", len(answers), 2*16)
+	}
+	firstIP := net.IP(answers[12:16])
+	if firstIP.String() != "10.0.0.1" {
+		t.Errorf("first answer RDATA = %v, want 10.0.0.1", firstIP)
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	msg := buildQuery(0x1234, "my-service.mesh", qtypeA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery() error = %v", err)
+	}
+
+	resp := buildError(msg, q, rcodeServFail)
+	flags := binary.BigEndian.Uint16(resp[2:4])
+	if flags&0x000f != rcodeServFail {
+		t.Errorf("response RCODE = %d, want %d", flags&0x000f, rcodeServFail)
+	}
+	ancount := binary.BigEndian.Uint16(resp[6:8])
+	if ancount != 0 {
+		t.Errorf("ANCOUNT = %d, want 0", ancount)
+	}
+}
@@ -0,0 +1,262 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dnsproxy is a network filter that answers DNS queries for mesh
+// service names directly out of the cluster manager's own host set, and
+// forwards everything else to a configured upstream resolver - so a
+// transparent-mesh deployment can resolve its peers without any external
+// DNS plumbing.
+//
+// mosn's network filter chain (and its listeners) only run over TCP
+// connections; there is no UDP listener facility in pkg/network today,
+// so this filter speaks DNS-over-TCP (RFC 1035 section 4.2.2: each
+// message prefixed with a 2-byte big-endian length). A conventional UDP
+// port 53 frontend would need a UDP listener added to pkg/network first
+// - a materially larger, transport-layer change - so it isn't attempted
+// here. The upstream forwarding leg does talk plain UDP to UpstreamDNS,
+// since that's the wire format resolvers expect; only the downstream,
+// mosn-facing side is TCP-framed.
+//
+// A query's name is checked against Domain: if it names a subdomain of
+// Domain (or Domain is unset, meaning every query is a candidate) and
+// the remaining label(s) match a configured cluster, the response is
+// synthesized from that cluster's healthy hosts. Anything else -
+// non-mesh names, unhealthy/unknown clusters, non-A queries - is
+// forwarded to UpstreamDNS unchanged.
+package dnsproxy
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	rcodeServFail = 2
+
+	defaultTTL           = 5 * time.Second
+	upstreamQueryTimeout = 2 * time.Second
+	maxUDPMessageSize    = 65535
+)
+
+type proxy struct {
+	config         *v2.DNSProxy
+	clusterManager types.ClusterManager
+	readCallbacks  api.ReadFilterCallbacks
+	stats          types.Metrics
+	ctx            context.Context
+
+	downBuf []byte
+}
+
+func NewProxy(ctx context.Context, config *v2.DNSProxy) api.ReadFilter {
+	stats, err := newStats(config.StatPrefix)
+	if err != nil {
+		log.DefaultLogger.Errorf("[dns_proxy] create stats failed: %v", err)
+	}
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		stats:          stats,
+		ctx:            ctx,
+	}
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+}
+
+func (p *proxy) ReadDisableUpstream(disable bool)   {}
+func (p *proxy) ReadDisableDownstream(disable bool) {}
+
+// OnData splits the stream into its length-prefixed DNS messages and
+// answers each independently; a single connection may carry many
+// queries, same as any other DNS-over-TCP resolver.
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	p.downBuf = append(p.downBuf, buf.Bytes()...)
+	buf.Drain(buf.Len())
+
+	for {
+		if len(p.downBuf) < 2 {
+			return api.Stop
+		}
+		length := int(binary.BigEndian.Uint16(p.downBuf[0:2]))
+		if len(p.downBuf) < 2+length {
+			return api.Stop
+		}
+		msg := append([]byte(nil), p.downBuf[2:2+length]...)
+		p.downBuf = p.downBuf[2+length:]
+		p.handleMessage(msg)
+	}
+}
+
+// handleMessage answers a single raw DNS message (no length prefix),
+// either from the mesh's own host set or by forwarding it upstream.
+func (p *proxy) handleMessage(msg []byte) {
+	q, err := parseQuery(msg)
+	if err != nil {
+		log.DefaultLogger.Debugf("[dns_proxy] forwarding unparseable query upstream: %v", err)
+		p.forwardUpstream(msg)
+		return
+	}
+
+	if q.qtype == qtypeA {
+		if clusterName, ok := p.meshClusterName(q.name); ok {
+			if p.answerFromCluster(msg, q, clusterName) {
+				return
+			}
+		}
+	}
+
+	p.forwardUpstream(msg)
+}
+
+// meshClusterName reports whether qname names a mesh service under
+// Domain, and if so, the cluster name it resolves to. When Domain is
+// unset, every query name is a candidate cluster name.
+func (p *proxy) meshClusterName(qname string) (string, bool) {
+	qname = strings.TrimSuffix(qname, ".")
+	if p.config.Domain == "" {
+		return qname, qname != ""
+	}
+	domain := strings.ToLower(strings.Trim(p.config.Domain, "."))
+	suffix := "." + domain
+	if strings.HasSuffix(qname, suffix) {
+		return strings.TrimSuffix(qname, suffix), true
+	}
+	if qname == domain {
+		return "", false
+	}
+	return "", false
+}
+
+// answerFromCluster looks up clusterName and, if it has at least one
+// healthy host, writes a synthesized A-record response downstream and
+// returns true. It returns false (leaving the query unanswered, for the
+// caller to forward upstream) when the name isn't a known cluster at
+// all - only genuinely unhealthy known clusters get a SERVFAIL.
+func (p *proxy) answerFromCluster(raw []byte, q *query, clusterName string) bool {
+	snapshot := p.clusterManager.GetClusterSnapshot(p.ctx, clusterName)
+	if snapshot == nil || snapshot.HostSet() == nil {
+		return false
+	}
+
+	var ips []net.IP
+	for _, host := range snapshot.HostSet().Hosts() {
+		if !host.Health() {
+			continue
+		}
+		hostAddr, _, err := net.SplitHostPort(host.AddressString())
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(hostAddr); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	if len(ips) == 0 {
+		if p.stats != nil {
+			p.stats.Counter(statMeshNoHealthy).Inc(1)
+		}
+		p.writeResponse(buildError(raw, q, rcodeServFail))
+		return true
+	}
+
+	if p.stats != nil {
+		p.stats.Counter(statMeshAnswered).Inc(1)
+	}
+	p.writeResponse(buildAnswer(raw, q, ips, p.ttl()))
+	return true
+}
+
+func (p *proxy) ttl() uint32 {
+	if p.config.TTL != 0 {
+		return p.config.TTL
+	}
+	return uint32(defaultTTL.Seconds())
+}
+
+// forwardUpstream relays msg to UpstreamDNS over UDP and writes whatever
+// comes back downstream, off the connection's own goroutine so a slow or
+// unreachable resolver can't stall other queries on the connection.
+func (p *proxy) forwardUpstream(msg []byte) {
+	if p.config.UpstreamDNS == "" {
+		log.DefaultLogger.Errorf("[dns_proxy] no upstream_dns configured, dropping unresolved query")
+		if p.stats != nil {
+			p.stats.Counter(statForwardFailed).Inc(1)
+		}
+		return
+	}
+
+	go func() {
+		resp, err := queryUpstream(p.config.UpstreamDNS, msg)
+		if err != nil {
+			log.DefaultLogger.Errorf("[dns_proxy] forward to upstream_dns %s failed: %v", p.config.UpstreamDNS, err)
+			if p.stats != nil {
+				p.stats.Counter(statForwardFailed).Inc(1)
+			}
+			return
+		}
+		if p.stats != nil {
+			p.stats.Counter(statForwarded).Inc(1)
+		}
+		p.writeResponse(resp)
+	}()
+}
+
+func queryUpstream(upstreamAddr string, msg []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", upstreamAddr, upstreamQueryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(upstreamQueryTimeout))
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, maxUDPMessageSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp[:n], nil
+}
+
+// writeResponse frames msg with its 2-byte length prefix and writes it
+// downstream.
+func (p *proxy) writeResponse(msg []byte) {
+	framed := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(framed[0:2], uint16(len(msg)))
+	copy(framed[2:], msg)
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(framed))
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import "encoding/binary"
+
+// rewriteMetadataBrokers replaces the host and port of every broker in a
+// Metadata response's brokers array with host/port, so a client that just
+// asked mosn for the cluster's brokers keeps talking to mosn instead of
+// dialing a broker address of its own. It's scoped to the MetadataResponse
+// v0 wire shape - correlation_id, then [brokers] as {node_id, host, port},
+// then the topics array this filter doesn't need to touch:
+//
+//	int32 correlation_id
+//	int32 brokers_count
+//	repeated { int32 node_id; STRING host; int32 port }
+//	... topics array, copied through unchanged ...
+//
+// Newer response versions add a throttle_time_ms field before the brokers
+// array and a rack STRING per broker; since this filter can't tell a
+// version it doesn't understand from a v0 response that merely looks odd,
+// it validates the parsed shape (sane counts, string lengths, and that it
+// lands exactly on a plausible topics-array count afterwards is NOT
+// checked - only bounds are) and returns ok=false to leave the response
+// untouched whenever anything doesn't add up, rather than risk corrupting
+// bytes it misread.
+func rewriteMetadataBrokers(payload []byte, host string, port int32) (rewritten []byte, ok bool) {
+	if len(payload) < 8 {
+		return nil, false
+	}
+	correlationID := payload[:4]
+	brokerCount := int32(binary.BigEndian.Uint32(payload[4:8]))
+	if brokerCount < 0 || brokerCount > 10000 {
+		return nil, false
+	}
+
+	offset := 8
+	out := append([]byte{}, correlationID...)
+	out = append(out, payload[4:8]...)
+	for i := int32(0); i < brokerCount; i++ {
+		if len(payload) < offset+4 {
+			return nil, false
+		}
+		nodeID := payload[offset : offset+4]
+		offset += 4
+
+		_, strLen, sok := readInt16String(payload[offset:])
+		if !sok {
+			return nil, false
+		}
+		offset += strLen
+
+		if len(payload) < offset+4 {
+			return nil, false
+		}
+		offset += 4 // original port, discarded
+
+		out = append(out, nodeID...)
+		out = putInt16String(out, host)
+		portBytes := make([]byte, 4)
+		binary.BigEndian.PutUint32(portBytes, uint32(port))
+		out = append(out, portBytes...)
+	}
+	out = append(out, payload[offset:]...)
+	return out, true
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func buildMetadataResponse(brokers [][2]interface{}, trailer []byte) []byte {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint32(out[0:4], 42) // correlation_id
+	binary.BigEndian.PutUint32(out[4:8], uint32(len(brokers)))
+	for _, b := range brokers {
+		nodeID := make([]byte, 4)
+		binary.BigEndian.PutUint32(nodeID, uint32(b[0].(int)))
+		out = append(out, nodeID...)
+		out = putInt16String(out, b[1].(string))
+		port := make([]byte, 4)
+		binary.BigEndian.PutUint32(port, uint32(9092))
+		out = append(out, port...)
+	}
+	return append(out, trailer...)
+}
+
+func TestRewriteMetadataBrokers(t *testing.T) {
+	trailer := []byte{0x00, 0x00, 0x00, 0x00} // empty topics array
+	original := buildMetadataResponse([][2]interface{}{
+		{1, "broker-1.internal"},
+		{2, "broker-2.internal"},
+	}, trailer)
+
+	rewritten, ok := rewriteMetadataBrokers(original, "127.0.0.1", 15006)
+	if !ok {
+		t.Fatalf("rewriteMetadataBrokers() ok = false")
+	}
+
+	if got := binary.BigEndian.Uint32(rewritten[0:4]); got != 42 {
+		t.Errorf("correlation_id = %d, want 42", got)
+	}
+	if got := binary.BigEndian.Uint32(rewritten[4:8]); got != 2 {
+		t.Errorf("broker count = %d, want 2", got)
+	}
+
+	offset := 8
+	for i := 0; i < 2; i++ {
+		nodeID := binary.BigEndian.Uint32(rewritten[offset : offset+4])
+		if nodeID != uint32(i+1) {
+			t.Errorf("broker %d node_id = %d, want %d", i, nodeID, i+1)
+		}
+		offset += 4
+		host, n, ok := readInt16String(rewritten[offset:])
+		if !ok || host != "127.0.0.1" {
+			t.Errorf("broker %d host = %q, want 127.0.0.1", i, host)
+		}
+		offset += n
+		port := binary.BigEndian.Uint32(rewritten[offset : offset+4])
+		if port != 15006 {
+			t.Errorf("broker %d port = %d, want 15006", i, port)
+		}
+		offset += 4
+	}
+	if string(rewritten[offset:]) != string(trailer) {
+		t.Errorf("trailing topics bytes = % x, want % x", rewritten[offset:], trailer)
+	}
+}
+
+func TestRewriteMetadataBrokersRejectsGarbage(t *testing.T) {
+	if _, ok := rewriteMetadataBrokers([]byte{0x01, 0x02}, "127.0.0.1", 15006); ok {
+		t.Errorf("rewriteMetadataBrokers() on too-short payload ok = true, want false")
+	}
+}
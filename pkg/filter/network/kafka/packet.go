@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import "encoding/binary"
+
+// frameHeaderLen is the size of the 4-byte big-endian length prefix every
+// Kafka request and response is framed with. The length counts only the
+// bytes that follow it, not itself.
+const frameHeaderLen = 4
+
+// decodeFrame reads a single length-prefixed frame off the front of data,
+// returning (nil, 0, nil) when data doesn't yet hold a complete one - the
+// same "wait for more data" contract the RESP and MySQL decoders in this
+// codebase use.
+func decodeFrame(data []byte) (payload []byte, total int, err error) {
+	if len(data) < frameHeaderLen {
+		return nil, 0, nil
+	}
+	length := int(binary.BigEndian.Uint32(data[:frameHeaderLen]))
+	total = frameHeaderLen + length
+	if len(data) < total {
+		return nil, 0, nil
+	}
+	return data[frameHeaderLen:total], total, nil
+}
+
+// Well-known API keys this filter looks for. The rest are proxied
+// transparently.
+const (
+	apiKeyProduce     = 0
+	apiKeyFetch       = 1
+	apiKeyMetadata    = 3
+	apiKeyAPIVersions = 18
+)
+
+// readInt16String reads a Kafka protocol nullable STRING (an int16 length,
+// -1 for null, followed by that many bytes) starting at data[0]. It returns
+// the string, the number of bytes consumed, and false if data is too short
+// to contain a complete one.
+func readInt16String(data []byte) (s string, n int, ok bool) {
+	if len(data) < 2 {
+		return "", 0, false
+	}
+	length := int(int16(binary.BigEndian.Uint16(data[:2])))
+	if length < 0 {
+		return "", 2, true
+	}
+	if len(data) < 2+length {
+		return "", 0, false
+	}
+	return string(data[2 : 2+length]), 2 + length, true
+}
+
+// putInt16String appends a Kafka protocol STRING encoding of s.
+func putInt16String(buf []byte, s string) []byte {
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(s)))
+	buf = append(buf, length...)
+	return append(buf, s...)
+}
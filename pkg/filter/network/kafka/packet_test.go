@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import "testing"
+
+func TestDecodeFrame(t *testing.T) {
+	raw := []byte{0x00, 0x00, 0x00, 0x03, 'a', 'b', 'c'}
+
+	payload, n, err := decodeFrame(raw)
+	if err != nil {
+		t.Fatalf("decodeFrame() error = %v", err)
+	}
+	if n != len(raw) || string(payload) != "abc" {
+		t.Errorf("decodeFrame() = (%q, %d), want (\"abc\", %d)", payload, n, len(raw))
+	}
+}
+
+func TestDecodeFrameWaitsForMoreData(t *testing.T) {
+	cases := [][]byte{
+		nil,
+		{0x00, 0x00},
+		{0x00, 0x00, 0x00, 0x05, 'a'},
+	}
+	for _, data := range cases {
+		payload, n, err := decodeFrame(data)
+		if payload != nil || n != 0 || err != nil {
+			t.Errorf("decodeFrame(% x) = (%v, %d, %v), want (nil, 0, nil)", data, payload, n, err)
+		}
+	}
+}
+
+func TestReadInt16String(t *testing.T) {
+	data := append([]byte{0x00, 0x05}, "hello"...)
+	s, n, ok := readInt16String(data)
+	if !ok || s != "hello" || n != 7 {
+		t.Errorf("readInt16String() = (%q, %d, %v), want (\"hello\", 7, true)", s, n, ok)
+	}
+
+	nullString := []byte{0xff, 0xff}
+	s, n, ok = readInt16String(nullString)
+	if !ok || s != "" || n != 2 {
+		t.Errorf("readInt16String(null) = (%q, %d, %v), want (\"\", 2, true)", s, n, ok)
+	}
+
+	if _, _, ok := readInt16String([]byte{0x00, 0x05, 'a'}); ok {
+		t.Errorf("readInt16String() on truncated data = true, want false")
+	}
+}
+
+func TestPutInt16String(t *testing.T) {
+	got := putInt16String(nil, "abc")
+	want := []byte{0x00, 0x03, 'a', 'b', 'c'}
+	if string(got) != string(want) {
+		t.Errorf("putInt16String() = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kafka is a network filter that proxies a client speaking the
+// Kafka request/response protocol to a single upstream cluster, recording
+// per-topic Produce/Fetch latency, and rewriting the broker addresses a
+// Metadata response advertises so the client keeps talking to mosn for
+// its follow-up requests instead of connecting straight to a broker it
+// discovered - which, behind mosn, it may not even be able to reach.
+//
+// It's a single-cluster proxy, the same scope as tcpproxy: mosn doesn't
+// track individual brokers or partition leadership, so it can't route a
+// given Produce/Fetch to "the right broker" the way a real Kafka client
+// would against a multi-broker cluster it can see directly. What it can
+// do, and does, is make sure every broker a client learns about through a
+// Metadata response resolves back to this same mosn listener, so the
+// client's own broker/partition bookkeeping keeps working - it just
+// always reconnects to (or reuses) mosn instead of a bare-metal broker
+// address.
+//
+// Kafka's client protocol allows several requests in flight at once, but
+// guarantees responses come back in the exact order the requests were
+// sent - so unlike a request/response protocol where replies can arrive
+// out of order, a simple FIFO queue of pending requests is enough to match
+// each response to the request that produced it, with no correlation_id
+// lookup needed.
+package kafka
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// pendingRequest is one request this filter has forwarded upstream and is
+// still waiting on a response for.
+type pendingRequest struct {
+	apiKey     int16
+	apiVersion int16
+	topic      string
+	started    time.Time
+}
+
+type proxy struct {
+	config             *v2.KafkaProxy
+	clusterManager     types.ClusterManager
+	readCallbacks      api.ReadFilterCallbacks
+	upstreamConnection types.ClientConnection
+	requestInfo        types.RequestInfo
+	stats              types.Metrics
+	ctx                context.Context
+
+	downBuf []byte
+	upBuf   []byte
+	pending []*pendingRequest
+
+	advertisedHost string
+	advertisedPort int32
+}
+
+func NewProxy(ctx context.Context, config *v2.KafkaProxy) api.ReadFilter {
+	stats, err := newStats(config.StatPrefix)
+	if err != nil {
+		log.DefaultLogger.Errorf("[kafka_proxy] create stats failed: %v", err)
+	}
+	p := &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		requestInfo:    network.NewRequestInfo(),
+		stats:          stats,
+		ctx:            ctx,
+	}
+	p.advertisedHost, p.advertisedPort = splitHostPort(config.AdvertisedListener)
+	return p
+}
+
+func splitHostPort(addr string) (string, int32) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0
+	}
+	port := int64(0)
+	for _, c := range portStr {
+		if c < '0' || c > '9' {
+			return "", 0
+		}
+		port = port*10 + int64(c-'0')
+	}
+	return host, int32(port)
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	snapshot := p.clusterManager.GetClusterSnapshot(p.ctx, p.config.Cluster)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		log.DefaultLogger.Errorf("[kafka_proxy] %v", errNoSuchCluster(p.config.Cluster))
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	lbCtx := &lbContext{conn: p.readCallbacks, ctx: p.ctx, cluster: snapshot.ClusterInfo()}
+	connectionData := p.clusterManager.TCPConnForCluster(lbCtx, snapshot)
+	if connectionData.Connection == nil {
+		log.DefaultLogger.Errorf("[kafka_proxy] %v", errNoHealthyUpstream(p.config.Cluster))
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+
+	p.upstreamConnection = connectionData.Connection
+	uc := &upstreamCallbacks{proxy: p}
+	p.upstreamConnection.AddConnectionEventListener(uc)
+	p.upstreamConnection.FilterManager().AddReadFilter(uc)
+	if err := p.upstreamConnection.Connect(); err != nil {
+		log.DefaultLogger.Errorf("[kafka_proxy] connect to cluster %s failed: %v", p.config.Cluster, err)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+	p.requestInfo.SetDownstreamRemoteAddress(p.readCallbacks.Connection().RemoteAddr())
+	p.requestInfo.SetDownstreamLocalAddress(p.readCallbacks.Connection().LocalAddr())
+}
+
+func (p *proxy) ReadDisableUpstream(disable bool)   {}
+func (p *proxy) ReadDisableDownstream(disable bool) {}
+
+// OnData handles bytes from the client: each complete frame is parsed just
+// enough to queue a pendingRequest for its eventual response, then the raw
+// bytes are forwarded upstream unchanged.
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+	p.downBuf = append(p.downBuf, data...)
+
+	for {
+		payload, n, err := decodeFrame(p.downBuf)
+		if err != nil {
+			p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+			return api.Stop
+		}
+		if payload == nil {
+			break
+		}
+		raw := append([]byte(nil), p.downBuf[:n]...)
+		p.downBuf = p.downBuf[n:]
+
+		pr := &pendingRequest{started: time.Now()}
+		if h, hn, ok := parseRequestHeader(payload); ok {
+			pr.apiKey = h.apiKey
+			pr.apiVersion = h.apiVersion
+			if topic, ok := requestTopic(h.apiKey, h.apiVersion, payload[hn:]); ok {
+				pr.topic = topic
+			}
+		}
+		p.pending = append(p.pending, pr)
+
+		if p.upstreamConnection != nil {
+			p.upstreamConnection.Write(buffer.NewIoBufferBytes(raw))
+		}
+	}
+	return api.Stop
+}
+
+// onUpstreamData handles bytes from the broker: each complete frame is
+// matched (FIFO) to the request that produced it, optionally rewritten
+// (Metadata responses), and forwarded downstream.
+func (p *proxy) onUpstreamData(buf buffer.IoBuffer) {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+	p.upBuf = append(p.upBuf, data...)
+
+	for {
+		payload, n, err := decodeFrame(p.upBuf)
+		if err != nil {
+			p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+			return
+		}
+		if payload == nil {
+			break
+		}
+		raw := append([]byte(nil), p.upBuf[:n]...)
+		p.upBuf = p.upBuf[n:]
+
+		var pr *pendingRequest
+		if len(p.pending) > 0 {
+			pr = p.pending[0]
+			p.pending = p.pending[1:]
+		}
+
+		if pr != nil && pr.apiKey == apiKeyMetadata && p.advertisedHost != "" {
+			body := raw[frameHeaderLen:]
+			if rewritten, ok := rewriteMetadataBrokers(body, p.advertisedHost, p.advertisedPort); ok {
+				raw = encodeFrame(rewritten)
+			}
+		}
+
+		if pr != nil {
+			p.recordStats(pr)
+		}
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(raw))
+	}
+}
+
+func encodeFrame(payload []byte) []byte {
+	out := make([]byte, frameHeaderLen+len(payload))
+	out[0] = byte(len(payload) >> 24)
+	out[1] = byte(len(payload) >> 16)
+	out[2] = byte(len(payload) >> 8)
+	out[3] = byte(len(payload))
+	copy(out[frameHeaderLen:], payload)
+	return out
+}
+
+func (p *proxy) recordStats(pr *pendingRequest) {
+	if p.stats == nil {
+		return
+	}
+	p.stats.Histogram(statKey(pr.apiKey, pr.topic)).Update(time.Since(pr.started).Nanoseconds())
+}
+
+func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	if p.upstreamConnection != nil {
+		p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+type upstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (uc *upstreamCallbacks) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	uc.proxy.onUpstreamData(buf)
+	return api.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+func (uc *upstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() && event != api.ConnectFailed && event != api.ConnectTimeout {
+		return
+	}
+	if uc.proxy.readCallbacks != nil {
+		uc.proxy.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+// lbContext is a minimal types.LoadBalancerContext, mirroring tcpproxy's.
+type lbContext struct {
+	conn    api.ReadFilterCallbacks
+	ctx     context.Context
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria { return nil }
+func (c *lbContext) DownstreamConnection() net.Conn                   { return c.conn.Connection().RawConn() }
+func (c *lbContext) DownstreamHeaders() api.HeaderMap                 { return nil }
+func (c *lbContext) DownstreamContext() context.Context               { return c.ctx }
+func (c *lbContext) DownstreamCluster() types.ClusterInfo             { return c.cluster }
+func (c *lbContext) DownstreamRoute() api.Route                       { return nil }
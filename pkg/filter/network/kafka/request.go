@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import "encoding/binary"
+
+// requestHeader is the common request header every Kafka request starts
+// with, regardless of API key: api_key, api_version, correlation_id, then
+// a nullable client_id string.
+type requestHeader struct {
+	apiKey        int16
+	apiVersion    int16
+	correlationID int32
+}
+
+// parseRequestHeader reads a request header off the front of payload,
+// returning the number of bytes it took (so callers can find the
+// API-specific body that follows). It's shape-checked, not validated
+// against a specific protocol version - api_key/api_version/correlation_id
+// have been in the same place since Kafka's very first release.
+func parseRequestHeader(payload []byte) (h requestHeader, n int, ok bool) {
+	if len(payload) < 8 {
+		return requestHeader{}, 0, false
+	}
+	h.apiKey = int16(binary.BigEndian.Uint16(payload[0:2]))
+	h.apiVersion = int16(binary.BigEndian.Uint16(payload[2:4]))
+	h.correlationID = int32(binary.BigEndian.Uint32(payload[4:8]))
+	_, clientIDLen, ok := readInt16String(payload[8:])
+	if !ok {
+		return requestHeader{}, 0, false
+	}
+	return h, 8 + clientIDLen, true
+}
+
+// requestTopic returns the best-effort name of the first topic a Produce
+// or Fetch request body touches, used only to label stats - a single
+// request can name several topics, but this filter reports one label per
+// request rather than parsing the full nested topic/partition arrays.
+// It's scoped to the early, common request versions (Produce v0-v2, Fetch
+// v0-v3) which share the same "topics array of {name, ...}" shape; on
+// newer/flexible-version encodings, or on anything that doesn't parse
+// cleanly, it returns ok=false and the caller just skips labeling that
+// request rather than guessing.
+func requestTopic(apiKey int16, apiVersion int16, body []byte) (topic string, ok bool) {
+	if apiVersion > 3 {
+		return "", false
+	}
+	rest := body
+	switch apiKey {
+	case apiKeyProduce:
+		// v0-v2: [transactional_id nullable? no - only v3+][acks int16][timeout int32][topics...]
+		if len(rest) < 6 {
+			return "", false
+		}
+		rest = rest[6:]
+	case apiKeyFetch:
+		// v0-v3: replica_id int32, max_wait_time int32, min_bytes int32 [, max_bytes int32 (v3)], topics...
+		hdrLen := 12
+		if apiVersion == 3 {
+			hdrLen = 16
+		}
+		if len(rest) < hdrLen {
+			return "", false
+		}
+		rest = rest[hdrLen:]
+	default:
+		return "", false
+	}
+	if len(rest) < 4 {
+		return "", false
+	}
+	count := int32(binary.BigEndian.Uint32(rest[:4]))
+	if count <= 0 {
+		return "", false
+	}
+	name, _, ok := readInt16String(rest[4:])
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
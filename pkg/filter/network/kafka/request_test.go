@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import "testing"
+
+func TestParseRequestHeader(t *testing.T) {
+	payload := []byte{
+		0x00, 0x00, // api_key = 0 (Produce)
+		0x00, 0x01, // api_version = 1
+		0x00, 0x00, 0x00, 0x2a, // correlation_id = 42
+	}
+	payload = append(payload, putInt16String(nil, "my-client")...)
+	payload = append(payload, "trailing body"...)
+
+	h, n, ok := parseRequestHeader(payload)
+	if !ok {
+		t.Fatalf("parseRequestHeader() ok = false")
+	}
+	if h.apiKey != apiKeyProduce || h.apiVersion != 1 || h.correlationID != 42 {
+		t.Errorf("parseRequestHeader() = %+v", h)
+	}
+	if string(payload[n:]) != "trailing body" {
+		t.Errorf("parseRequestHeader() n = %d, left %q", n, payload[n:])
+	}
+}
+
+func TestRequestTopicProduce(t *testing.T) {
+	body := []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x64} // acks(int16) + timeout(int32)
+	body = append(body, 0x00, 0x00, 0x00, 0x01)         // topics array count = 1
+	body = append(body, putInt16String(nil, "orders")...)
+
+	topic, ok := requestTopic(apiKeyProduce, 1, body)
+	if !ok || topic != "orders" {
+		t.Errorf("requestTopic(Produce) = (%q, %v), want (\"orders\", true)", topic, ok)
+	}
+}
+
+func TestRequestTopicFetch(t *testing.T) {
+	body := []byte{
+		0x00, 0x00, 0x00, 0x01, // replica_id
+		0x00, 0x00, 0x00, 0x64, // max_wait_time
+		0x00, 0x00, 0x00, 0x01, // min_bytes
+	}
+	body = append(body, 0x00, 0x00, 0x00, 0x01) // topics array count = 1
+	body = append(body, putInt16String(nil, "clicks")...)
+
+	topic, ok := requestTopic(apiKeyFetch, 2, body)
+	if !ok || topic != "clicks" {
+		t.Errorf("requestTopic(Fetch) = (%q, %v), want (\"clicks\", true)", topic, ok)
+	}
+}
+
+func TestRequestTopicUnsupported(t *testing.T) {
+	if _, ok := requestTopic(apiKeyMetadata, 0, nil); ok {
+		t.Errorf("requestTopic(Metadata) ok = true, want false")
+	}
+	if _, ok := requestTopic(apiKeyProduce, 5, nil); ok {
+		t.Errorf("requestTopic() on an unsupported version ok = true, want false")
+	}
+}
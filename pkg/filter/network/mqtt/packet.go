@@ -0,0 +1,277 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqtt
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MQTT control packet types (MQTT 3.1.1 section 2.2.1 / MQTT 5.0 section 2.1.2).
+const (
+	pktCONNECT    = 1
+	pktCONNACK    = 2
+	pktPUBLISH    = 3
+	pktPINGREQ    = 12
+	pktPINGRESP   = 13
+	pktDISCONNECT = 14
+)
+
+// CONNACK return codes (MQTT 3.1.1 section 3.2.2.3); MQTT 5 reason codes
+// reuse 0x00 for success and use a distinct, larger space for failures
+// that this filter doesn't need to distinguish.
+const (
+	connAckAccepted      = 0x00
+	connAckUnavailable   = 0x03
+	connAckNotAuthorized = 0x05
+)
+
+const maxRemainingLength = 256 * 1024 * 1024 // MQTT's own protocol maximum
+
+// fixedHeader is a decoded MQTT fixed header: packet type, flags, and the
+// length of the variable header + payload that follows it.
+type fixedHeader struct {
+	packetType      byte
+	flags           byte
+	remainingLength int
+	headerLen       int // bytes consumed by the fixed header itself
+}
+
+// decodeFixedHeader decodes the fixed header at the start of data. ok is
+// false if data doesn't yet hold a complete fixed header (the caller
+// should wait for more bytes), and the returned error is only non-nil for
+// a malformed remaining-length encoding.
+func decodeFixedHeader(data []byte) (h fixedHeader, ok bool, err error) {
+	if len(data) < 2 {
+		return fixedHeader{}, false, nil
+	}
+
+	value := 0
+	multiplier := 1
+	i := 1
+	for {
+		if i >= len(data) {
+			return fixedHeader{}, false, nil
+		}
+		b := data[i]
+		value += int(b&0x7f) * multiplier
+		i++
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+		if multiplier > 128*128*128 {
+			return fixedHeader{}, false, fmt.Errorf("mqtt remaining length field is too long")
+		}
+	}
+	if value > maxRemainingLength {
+		return fixedHeader{}, false, fmt.Errorf("mqtt remaining length %d exceeds protocol maximum", value)
+	}
+
+	return fixedHeader{
+		packetType:      data[0] >> 4,
+		flags:           data[0] & 0x0f,
+		remainingLength: value,
+		headerLen:       i,
+	}, true, nil
+}
+
+// packetLen reports the total wire length of the packet described by h,
+// once it's known to be complete.
+func (h fixedHeader) packetLen() int {
+	return h.headerLen + h.remainingLength
+}
+
+// nextPacket looks for one complete MQTT control packet at the start of
+// data, returning its fixed header and total length. ok is false if data
+// doesn't yet hold a complete packet.
+func nextPacket(data []byte) (h fixedHeader, ok bool, err error) {
+	h, ok, err = decodeFixedHeader(data)
+	if !ok || err != nil {
+		return h, false, err
+	}
+	if len(data) < h.packetLen() {
+		return fixedHeader{}, false, nil
+	}
+	return h, true, nil
+}
+
+// connectInfo is the subset of a CONNECT packet's variable header and
+// payload this filter needs for routing.
+type connectInfo struct {
+	protocolLevel byte
+	keepAlive     uint16
+	clientID      string
+	username      string
+	hasUsername   bool
+}
+
+// parseConnect decodes a CONNECT packet's variable header and payload
+// (payload is packet data with the fixed header already stripped, i.e.
+// data[h.headerLen:h.packetLen()]).
+func parseConnect(payload []byte) (connectInfo, error) {
+	off := 0
+
+	protoName, n, err := readUTF8String(payload, off)
+	if err != nil {
+		return connectInfo{}, fmt.Errorf("connect protocol name: %w", err)
+	}
+	off += n
+	if protoName != "MQTT" && protoName != "MQIsdp" {
+		return connectInfo{}, fmt.Errorf("unrecognized mqtt protocol name %q", protoName)
+	}
+
+	if off+2 > len(payload) {
+		return connectInfo{}, fmt.Errorf("connect packet truncated before protocol level/flags")
+	}
+	protocolLevel := payload[off]
+	connectFlags := payload[off+1]
+	off += 2
+
+	if off+2 > len(payload) {
+		return connectInfo{}, fmt.Errorf("connect packet truncated before keep alive")
+	}
+	keepAlive := binary.BigEndian.Uint16(payload[off : off+2])
+	off += 2
+
+	// MQTT 5 inserts a Properties field (variable-length integer length,
+	// then that many bytes) here; earlier versions don't have one.
+	if protocolLevel >= 5 {
+		propLen, n, err := decodeVarInt(payload, off)
+		if err != nil {
+			return connectInfo{}, fmt.Errorf("connect properties: %w", err)
+		}
+		off += n + propLen
+	}
+
+	clientID, n, err := readUTF8String(payload, off)
+	if err != nil {
+		return connectInfo{}, fmt.Errorf("connect client id: %w", err)
+	}
+	off += n
+
+	if connectFlags&0x04 != 0 { // will flag
+		if protocolLevel >= 5 {
+			propLen, n, err := decodeVarInt(payload, off)
+			if err != nil {
+				return connectInfo{}, fmt.Errorf("connect will properties: %w", err)
+			}
+			off += n + propLen
+		}
+		if _, n, err := readUTF8String(payload, off); err != nil {
+			return connectInfo{}, fmt.Errorf("connect will topic: %w", err)
+		} else {
+			off += n
+		}
+		if n, err := skipBinary(payload, off); err != nil {
+			return connectInfo{}, fmt.Errorf("connect will payload: %w", err)
+		} else {
+			off += n
+		}
+	}
+
+	info := connectInfo{
+		protocolLevel: protocolLevel,
+		keepAlive:     keepAlive,
+	}
+	info.clientID = clientID
+
+	if connectFlags&0x80 != 0 { // username flag
+		username, n, err := readUTF8String(payload, off)
+		if err != nil {
+			return connectInfo{}, fmt.Errorf("connect username: %w", err)
+		}
+		off += n
+		info.username = username
+		info.hasUsername = true
+	}
+	// password (if present) isn't needed for routing and is the last
+	// field, so it isn't parsed.
+
+	return info, nil
+}
+
+// peekPublishTopic extracts just the topic name from a PUBLISH packet's
+// variable header, for per-topic accounting - the rest of the packet
+// (packet identifier for QoS>0, and the application payload) is relayed
+// unexamined.
+func peekPublishTopic(payload []byte) (string, error) {
+	topic, _, err := readUTF8String(payload, 0)
+	if err != nil {
+		return "", fmt.Errorf("publish topic: %w", err)
+	}
+	return topic, nil
+}
+
+// buildConnAck builds a CONNACK packet mosn sends itself when it can't
+// establish (or route) the upstream connection - the real broker's own
+// CONNACK is otherwise relayed through unmodified.
+func buildConnAck(returnCode byte) []byte {
+	return []byte{pktCONNACK << 4, 2, 0x00, returnCode}
+}
+
+// buildPingResp builds a PINGRESP packet (MQTT 3.1.1 section 3.13).
+func buildPingResp() []byte {
+	return []byte{pktPINGRESP << 4, 0}
+}
+
+func readUTF8String(data []byte, off int) (string, int, error) {
+	if off+2 > len(data) {
+		return "", 0, fmt.Errorf("truncated before length prefix")
+	}
+	length := int(binary.BigEndian.Uint16(data[off : off+2]))
+	if off+2+length > len(data) {
+		return "", 0, fmt.Errorf("truncated string")
+	}
+	return string(data[off+2 : off+2+length]), 2 + length, nil
+}
+
+func skipBinary(data []byte, off int) (int, error) {
+	if off+2 > len(data) {
+		return 0, fmt.Errorf("truncated before length prefix")
+	}
+	length := int(binary.BigEndian.Uint16(data[off : off+2]))
+	if off+2+length > len(data) {
+		return 0, fmt.Errorf("truncated binary data")
+	}
+	return 2 + length, nil
+}
+
+// decodeVarInt decodes an MQTT 5 "Variable Byte Integer" at off, as used
+// by property lengths, returning the decoded value and the number of
+// bytes it occupied on the wire.
+func decodeVarInt(data []byte, off int) (value, consumed int, err error) {
+	multiplier := 1
+	i := off
+	for {
+		if i >= len(data) {
+			return 0, 0, fmt.Errorf("truncated variable byte integer")
+		}
+		b := data[i]
+		value += int(b&0x7f) * multiplier
+		i++
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+		if multiplier > 128*128*128 {
+			return 0, 0, fmt.Errorf("variable byte integer is too long")
+		}
+	}
+	return value, i - off, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqtt
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func str(s string) []byte {
+	b := make([]byte, 2+len(s))
+	binary.BigEndian.PutUint16(b, uint16(len(s)))
+	copy(b[2:], s)
+	return b
+}
+
+func TestDecodeFixedHeader(t *testing.T) {
+	h, ok, err := decodeFixedHeader([]byte{0x10, 0x02, 0x00, 0x04})
+	if err != nil || !ok || h.packetType != pktCONNECT || h.remainingLength != 2 || h.headerLen != 2 {
+		t.Errorf("decodeFixedHeader() = (%+v, %v, %v)", h, ok, err)
+	}
+
+	if _, ok, err := decodeFixedHeader([]byte{0x10}); ok || err != nil {
+		t.Errorf("decodeFixedHeader() on incomplete data = (%v, %v), want (false, nil)", ok, err)
+	}
+
+	// A remaining-length field with the continuation bit set forever never
+	// terminates and is rejected as malformed rather than read forever.
+	if _, ok, err := decodeFixedHeader([]byte{0x10, 0xff, 0xff, 0xff, 0xff}); ok || err == nil {
+		t.Errorf("decodeFixedHeader() on runaway remaining length = (%v, %v), want (false, err)", ok, err)
+	}
+}
+
+func TestNextPacket(t *testing.T) {
+	data := []byte{0x10, 0x02, 0x00, 0x04, 0xaa}
+	h, ok, err := nextPacket(data)
+	if err != nil || !ok || h.packetLen() != 4 {
+		t.Errorf("nextPacket() = (%+v, %v, %v)", h, ok, err)
+	}
+
+	if _, ok, err := nextPacket([]byte{0x10, 0x02, 0x00}); ok || err != nil {
+		t.Errorf("nextPacket() on incomplete packet = (%v, %v), want (false, nil)", ok, err)
+	}
+}
+
+func TestParseConnectV311NoAuth(t *testing.T) {
+	var payload []byte
+	payload = append(payload, str("MQTT")...)
+	payload = append(payload, 4, 0x00)    // protocol level 4, no flags
+	payload = append(payload, 0x00, 0x3c) // keep alive 60
+	payload = append(payload, str("client-1")...)
+
+	info, err := parseConnect(payload)
+	if err != nil {
+		t.Fatalf("parseConnect() error = %v", err)
+	}
+	if info.clientID != "client-1" || info.keepAlive != 60 || info.hasUsername {
+		t.Errorf("parseConnect() = %+v", info)
+	}
+}
+
+func TestParseConnectV311WithUsername(t *testing.T) {
+	var payload []byte
+	payload = append(payload, str("MQTT")...)
+	payload = append(payload, 4, 0x80) // username flag
+	payload = append(payload, 0x00, 0x0a)
+	payload = append(payload, str("client-2")...)
+	payload = append(payload, str("alice")...)
+
+	info, err := parseConnect(payload)
+	if err != nil {
+		t.Fatalf("parseConnect() error = %v", err)
+	}
+	if !info.hasUsername || info.username != "alice" {
+		t.Errorf("parseConnect() = %+v", info)
+	}
+}
+
+func TestParseConnectV5SkipsProperties(t *testing.T) {
+	var payload []byte
+	payload = append(payload, str("MQTT")...)
+	payload = append(payload, 5, 0x00)
+	payload = append(payload, 0x00, 0x0a)
+	payload = append(payload, 0x02, 0x11, 0x00) // 2-byte properties field, session-expiry-like filler
+	payload = append(payload, str("client-3")...)
+
+	info, err := parseConnect(payload)
+	if err != nil {
+		t.Fatalf("parseConnect() error = %v", err)
+	}
+	if info.clientID != "client-3" || info.protocolLevel != 5 {
+		t.Errorf("parseConnect() = %+v", info)
+	}
+}
+
+func TestParseConnectRejectsUnknownProtocol(t *testing.T) {
+	var payload []byte
+	payload = append(payload, str("BOGUS")...)
+	payload = append(payload, 4, 0x00, 0x00, 0x0a)
+	payload = append(payload, str("client-4")...)
+
+	if _, err := parseConnect(payload); err == nil {
+		t.Errorf("parseConnect() on unknown protocol name = nil error, want error")
+	}
+}
+
+func TestParseConnectTruncated(t *testing.T) {
+	if _, err := parseConnect(str("MQTT")); err == nil {
+		t.Errorf("parseConnect() on truncated payload = nil error, want error")
+	}
+}
+
+func TestPeekPublishTopic(t *testing.T) {
+	payload := append(str("sensors/temp"), 0x00, 0x01, 'x')
+	topic, err := peekPublishTopic(payload)
+	if err != nil || topic != "sensors/temp" {
+		t.Errorf("peekPublishTopic() = (%q, %v)", topic, err)
+	}
+
+	if _, err := peekPublishTopic([]byte{0x00}); err == nil {
+		t.Errorf("peekPublishTopic() on truncated payload = nil error, want error")
+	}
+}
+
+func TestBuildConnAckAndPingResp(t *testing.T) {
+	ack := buildConnAck(connAckAccepted)
+	if len(ack) != 4 || ack[0] != pktCONNACK<<4 || ack[3] != connAckAccepted {
+		t.Errorf("buildConnAck() = %v", ack)
+	}
+
+	resp := buildPingResp()
+	if len(resp) != 2 || resp[0] != pktPINGRESP<<4 {
+		t.Errorf("buildPingResp() = %v", resp)
+	}
+}
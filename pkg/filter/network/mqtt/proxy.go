@@ -0,0 +1,308 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mqtt is a network filter that terminates an MQTT 3.1.1 or 5.0
+// connection's CONNECT packet on the downstream connection, routes it to
+// an upstream cluster by client id or username (RouteBy), then relays
+// the session for the rest of its lifetime - the same handshake-then-
+// relay shape as the socks5 and connectproxy filters.
+//
+// Unlike those filters, the relay isn't a raw byte pipe once the
+// handshake completes: mosn keeps parsing the downstream packet framing
+// for the life of the connection so it can peek two things without
+// altering what's forwarded:
+//
+//   - PUBLISH packets' topic name, for a per-topic publish counter.
+//   - PINGREQ packets, which mosn answers immediately with a locally
+//     built PINGRESP in addition to relaying the PINGREQ upstream
+//     unchanged - the client's keepalive round trip no longer waits on
+//     the upstream hop, while the real broker still sees every PINGREQ
+//     and keeps tracking the session's keepalive itself.
+//
+// Every packet's bytes are relayed upstream exactly as received; mosn
+// never re-encodes a packet it has parsed. Upstream-to-downstream
+// traffic is a pure, unparsed byte relay, since the broker's own
+// CONNACK/PUBACK/etc. don't need inspection. A CONNACK is only
+// synthesized by mosn itself when it cannot route or reach an upstream
+// (mirroring socks5's failConnect); a successfully routed session's
+// CONNACK comes from the real broker.
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+type proxy struct {
+	config             *v2.MQTTProxy
+	clusterManager     types.ClusterManager
+	readCallbacks      api.ReadFilterCallbacks
+	upstreamConnection types.ClientConnection
+	requestInfo        types.RequestInfo
+	stats              types.Metrics
+	ctx                context.Context
+
+	connected bool
+	downBuf   []byte
+}
+
+func NewProxy(ctx context.Context, config *v2.MQTTProxy) api.ReadFilter {
+	stats, err := newStats(config.StatPrefix)
+	if err != nil {
+		log.DefaultLogger.Errorf("[mqtt_proxy] create stats failed: %v", err)
+	}
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		requestInfo:    network.NewRequestInfo(),
+		stats:          stats,
+		ctx:            ctx,
+	}
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+	p.requestInfo.SetDownstreamRemoteAddress(p.readCallbacks.Connection().RemoteAddr())
+	p.requestInfo.SetDownstreamLocalAddress(p.readCallbacks.Connection().LocalAddr())
+}
+
+func (p *proxy) ReadDisableUpstream(disable bool)   {}
+func (p *proxy) ReadDisableDownstream(disable bool) {}
+
+// OnData buffers downstream bytes and pulls out as many complete MQTT
+// packets as are available, routing the first (the CONNECT) and
+// forwarding every packet - including that first one - upstream once
+// the tunnel is up.
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+	p.downBuf = append(p.downBuf, data...)
+
+	for {
+		h, ok, err := nextPacket(p.downBuf)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mqtt_proxy] malformed packet from downstream: %v", err)
+			p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+			return api.Stop
+		}
+		if !ok {
+			break
+		}
+		pkt := p.downBuf[:h.packetLen()]
+		p.downBuf = p.downBuf[h.packetLen():]
+
+		if !p.connected {
+			if h.packetType != pktCONNECT {
+				log.DefaultLogger.Errorf("[mqtt_proxy] first packet from downstream wasn't CONNECT")
+				p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+				return api.Stop
+			}
+			if !p.handleConnect(h, pkt) {
+				return api.Stop
+			}
+			continue
+		}
+
+		p.handlePacket(h, pkt)
+	}
+	return api.Stop
+}
+
+// handleConnect parses the CONNECT packet, routes it to an upstream
+// cluster, and - once the tunnel is up - relays the CONNECT itself
+// upstream unchanged. It returns false if the connection was closed.
+func (p *proxy) handleConnect(h fixedHeader, pkt []byte) bool {
+	info, err := parseConnect(pkt[h.headerLen:h.packetLen()])
+	if err != nil {
+		log.DefaultLogger.Errorf("[mqtt_proxy] malformed CONNECT: %v", err)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return false
+	}
+
+	clusterName := p.routeCluster(info)
+	if clusterName == "" {
+		p.failConnect(errNoRoute(p.routeKey(info)))
+		return false
+	}
+
+	snapshot := p.clusterManager.GetClusterSnapshot(p.ctx, clusterName)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		p.failConnect(errNoSuchCluster(clusterName))
+		return false
+	}
+	lbCtx := &lbContext{conn: p.readCallbacks, ctx: p.ctx, cluster: snapshot.ClusterInfo()}
+	connectionData := p.clusterManager.TCPConnForCluster(lbCtx, snapshot)
+	if connectionData.Connection == nil {
+		p.failConnect(errNoHealthyUpstream(clusterName))
+		return false
+	}
+
+	p.upstreamConnection = connectionData.Connection
+	uc := &upstreamCallbacks{proxy: p}
+	p.upstreamConnection.AddConnectionEventListener(uc)
+	p.upstreamConnection.FilterManager().AddReadFilter(uc)
+	if err := p.upstreamConnection.Connect(); err != nil {
+		p.failConnect(fmt.Errorf("mqtt_proxy: connect to cluster %s failed: %v", clusterName, err))
+		return false
+	}
+	p.connected = true
+
+	if p.stats != nil {
+		p.stats.Counter(statTunnelSuccess).Inc(1)
+	}
+	if p.config.IdleTimeout != nil {
+		p.readCallbacks.Connection().SetIdleTimeout(*p.config.IdleTimeout)
+		p.upstreamConnection.SetIdleTimeout(*p.config.IdleTimeout)
+	}
+
+	p.upstreamConnection.Write(buffer.NewIoBufferBytes(pkt))
+	p.recordBytes(statBytesUpstream, len(pkt))
+	return true
+}
+
+// routeCluster picks the upstream cluster for a CONNECT, by client id or
+// username depending on RouteBy, falling back to DefaultCluster.
+func (p *proxy) routeCluster(info connectInfo) string {
+	key := p.routeKey(info)
+	if key != "" {
+		if c, ok := p.config.Routes[key]; ok {
+			return c
+		}
+	}
+	return p.config.DefaultCluster
+}
+
+func (p *proxy) routeKey(info connectInfo) string {
+	if p.config.RouteBy == "username" {
+		if info.hasUsername {
+			return info.username
+		}
+		return ""
+	}
+	return info.clientID
+}
+
+// handlePacket relays a fully-parsed post-CONNECT packet upstream
+// unchanged, peeking PUBLISH topics for stats and answering PINGREQ
+// locally as well as forwarding it.
+func (p *proxy) handlePacket(h fixedHeader, pkt []byte) {
+	switch h.packetType {
+	case pktPUBLISH:
+		if topic, err := peekPublishTopic(pkt[h.headerLen:h.packetLen()]); err == nil && p.stats != nil {
+			p.stats.Counter(publishStatKey(topic)).Inc(1)
+		}
+	case pktPINGREQ:
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(buildPingResp()))
+	}
+
+	p.upstreamConnection.Write(buffer.NewIoBufferBytes(pkt))
+	p.recordBytes(statBytesUpstream, len(pkt))
+}
+
+func (p *proxy) failConnect(err error) {
+	log.DefaultLogger.Errorf("[mqtt_proxy] %v", err)
+	if p.stats != nil {
+		p.stats.Counter(statTunnelFailure).Inc(1)
+	}
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(buildConnAck(connAckUnavailable)))
+	p.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+}
+
+func (p *proxy) onUpstreamData(buf buffer.IoBuffer) {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(data))
+	p.recordBytes(statBytesDownstream, len(data))
+}
+
+func (p *proxy) recordBytes(key string, n int) {
+	if p.stats == nil || n == 0 {
+		return
+	}
+	p.stats.Counter(key).Inc(int64(n))
+}
+
+func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	if p.upstreamConnection != nil {
+		p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+type upstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (uc *upstreamCallbacks) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	uc.proxy.onUpstreamData(buf)
+	return api.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+func (uc *upstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() && event != api.ConnectFailed && event != api.ConnectTimeout {
+		return
+	}
+	if uc.proxy.readCallbacks != nil {
+		uc.proxy.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+// lbContext is a minimal types.LoadBalancerContext, mirroring tcpproxy's,
+// the websocket filter's, connectproxy's, and socks5's.
+type lbContext struct {
+	conn    api.ReadFilterCallbacks
+	ctx     context.Context
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria { return nil }
+func (c *lbContext) DownstreamConnection() net.Conn                   { return c.conn.Connection().RawConn() }
+func (c *lbContext) DownstreamHeaders() api.HeaderMap                 { return nil }
+func (c *lbContext) DownstreamContext() context.Context               { return c.ctx }
+func (c *lbContext) DownstreamCluster() types.ClusterInfo             { return c.cluster }
+func (c *lbContext) DownstreamRoute() api.Route                       { return nil }
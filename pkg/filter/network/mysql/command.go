@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mysql
+
+import "strings"
+
+// readOnlyStatements are the statement keywords this filter is willing to
+// route to a replica. Anything else - including statements it doesn't
+// recognize - is treated as a write and kept on the primary, since sending
+// an unrecognized statement to a replica by mistake is far worse than
+// sending a genuinely read-only one to the primary.
+var readOnlyStatements = map[string]bool{
+	"SELECT": true, "SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true,
+}
+
+// classify returns the upper-cased leading keyword of a SQL statement, used
+// both for read/write routing and as the stats/access-log command label.
+func classify(sql string) string {
+	sql = strings.TrimLeft(sql, " \t\r\n(")
+	end := strings.IndexAny(sql, " \t\r\n(")
+	if end < 0 {
+		end = len(sql)
+	}
+	return strings.ToUpper(sql[:end])
+}
+
+func isReadOnly(command string) bool {
+	return readOnlyStatements[command]
+}
@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mysql
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	cases := map[string]string{
+		"select * from users":        "SELECT",
+		"  SELECT 1":                 "SELECT",
+		"Insert into users values()": "INSERT",
+		"(select 1)":                 "SELECT",
+		"show tables":                "SHOW",
+		"BEGIN":                      "BEGIN",
+	}
+	for sql, want := range cases {
+		if got := classify(sql); got != want {
+			t.Errorf("classify(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	for _, cmd := range []string{"SELECT", "SHOW", "EXPLAIN", "DESCRIBE", "DESC"} {
+		if !isReadOnly(cmd) {
+			t.Errorf("isReadOnly(%q) = false, want true", cmd)
+		}
+	}
+	for _, cmd := range []string{"INSERT", "UPDATE", "DELETE", "BEGIN", "COMMIT"} {
+		if isReadOnly(cmd) {
+			t.Errorf("isReadOnly(%q) = true, want false", cmd)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mysql
+
+// MySQL's wire protocol frames every packet as a 3-byte little-endian
+// payload length followed by a 1-byte sequence number. Payloads larger than
+// 2^24-1 bytes are split across several packets with increasing sequence
+// numbers and no further framing of their own; this filter doesn't
+// reassemble those (see decodePacket), since the packets it actually needs
+// to inspect - command packets and short OK/ERR replies - never reach that
+// size in practice.
+const packetHeaderLen = 4
+
+// commPacket is one decoded MySQL protocol packet.
+type commPacket struct {
+	Seq     byte
+	Payload []byte
+}
+
+// decodePacket reads a single packet off the front of data, returning
+// (nil, 0, nil) when data doesn't yet hold a complete one - the same
+// "wait for more data" contract the RESP and Thrift decoders in this
+// codebase use.
+func decodePacket(data []byte) (*commPacket, int, error) {
+	if len(data) < packetHeaderLen {
+		return nil, 0, nil
+	}
+	length := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+	total := packetHeaderLen + length
+	if len(data) < total {
+		return nil, 0, nil
+	}
+	return &commPacket{Seq: data[3], Payload: data[packetHeaderLen:total]}, total, nil
+}
+
+// Client command bytes (payload[0] of a command-phase packet).
+const (
+	comQuit  byte = 0x01
+	comQuery byte = 0x03
+	comPing  byte = 0x0e
+)
+
+// Server response leading bytes.
+const (
+	respOK         byte = 0x00
+	respEOF        byte = 0xfe
+	respErr        byte = 0xff
+	respAuthSwitch byte = 0xfe // identical to respEOF; disambiguated by phase and length
+)
+
+// queryText returns the SQL text of a COM_QUERY command packet, and false
+// for any other command.
+func queryText(p *commPacket) (string, bool) {
+	if len(p.Payload) < 1 || p.Payload[0] != comQuery {
+		return "", false
+	}
+	return string(p.Payload[1:]), true
+}
+
+// isOK reports whether a response packet is a MySQL OK packet (leading
+// 0x00). This filter only calls it on handshake-phase packets, where an OK
+// unambiguously ends the auth exchange; it isn't used to inspect
+// command-phase result-set packets, where a leading 0x00 can also just be
+// a small column-count byte.
+func isOK(p *commPacket) bool {
+	return len(p.Payload) > 0 && p.Payload[0] == respOK
+}
+
+func isErr(p *commPacket) bool {
+	return len(p.Payload) > 0 && p.Payload[0] == respErr
+}
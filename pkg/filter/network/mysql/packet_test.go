@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mysql
+
+import "testing"
+
+func TestDecodePacket(t *testing.T) {
+	raw := []byte{0x04, 0x00, 0x00, 0x00, comQuery, 'a', 'b', 'c'}
+
+	pkt, n, err := decodePacket(raw)
+	if err != nil {
+		t.Fatalf("decodePacket() error = %v", err)
+	}
+	if n != len(raw) {
+		t.Fatalf("decodePacket() n = %d, want %d", n, len(raw))
+	}
+	if pkt.Seq != 0 || string(pkt.Payload) != string([]byte{comQuery, 'a', 'b', 'c'}) {
+		t.Errorf("decodePacket() = %+v", pkt)
+	}
+}
+
+func TestDecodePacketWaitsForMoreData(t *testing.T) {
+	cases := [][]byte{
+		nil,
+		{0x00, 0x00},
+		{0x05, 0x00, 0x00, 0x00, 'a'},
+	}
+	for _, data := range cases {
+		pkt, n, err := decodePacket(data)
+		if pkt != nil || n != 0 || err != nil {
+			t.Errorf("decodePacket(% x) = (%v, %d, %v), want (nil, 0, nil)", data, pkt, n, err)
+		}
+	}
+}
+
+func TestQueryText(t *testing.T) {
+	pkt := &commPacket{Payload: append([]byte{comQuery}, "select 1"...)}
+	sql, ok := queryText(pkt)
+	if !ok || sql != "select 1" {
+		t.Errorf("queryText() = (%q, %v), want (%q, true)", sql, ok, "select 1")
+	}
+
+	if _, ok := queryText(&commPacket{Payload: []byte{comPing}}); ok {
+		t.Errorf("queryText() on a non-query command = true, want false")
+	}
+}
+
+func TestIsOKIsErr(t *testing.T) {
+	if !isOK(&commPacket{Payload: []byte{respOK, 0, 0}}) {
+		t.Errorf("isOK() = false for a leading 0x00 packet")
+	}
+	if isOK(&commPacket{Payload: []byte{respErr}}) {
+		t.Errorf("isOK() = true for an ERR packet")
+	}
+	if !isErr(&commPacket{Payload: []byte{respErr, 0x01, 0x02}}) {
+		t.Errorf("isErr() = false for a leading 0xff packet")
+	}
+	if isErr(&commPacket{Payload: []byte{respOK}}) {
+		t.Errorf("isErr() = true for an OK packet")
+	}
+}
@@ -0,0 +1,327 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mysql is a network filter that proxies a client speaking the
+// MySQL wire protocol to a primary cluster, recording per-statement-type
+// latency for stats/access logs, and optionally splitting read-only
+// statements to a replica cluster.
+//
+// Splitting works by opening a second, independently-authenticated upstream
+// connection to the replica alongside the usual primary one, and mirroring
+// the client's handshake-response packet to it. That mirroring has a real
+// limitation worth stating plainly: MySQL's default authentication hashes
+// the client's password against a random nonce ("scramble") the server
+// generates fresh per connection, so the exact same handshake-response
+// bytes that authenticate against the primary's nonce will only also
+// authenticate against the replica's if the two servers happen to produce
+// the same nonce (impossible in practice) or the account being used
+// doesn't depend on it (no password, or an auth plugin that isn't
+// nonce-based). When replica auth fails, splitting is silently disabled for
+// that connection and every statement goes to the primary instead - the
+// connection still works, it just doesn't get the read/write split. This
+// filter also doesn't handle the CLIENT_SSL upgrade (it can't see inside a
+// TLS-wrapped handshake) or multi-round auth plugins beyond the first
+// round for the replica leg specifically; the primary leg is unaffected
+// since it's just relayed byte for byte regardless of how many rounds it
+// takes.
+package mysql
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// pendingQuery records what a backend is currently expected to answer,
+// used only to attribute a latency sample once its response starts
+// arriving.
+type pendingQuery struct {
+	command string
+	started time.Time
+}
+
+// backend is one upstream MySQL connection (primary or replica).
+type backend struct {
+	name    string
+	conn    types.ClientConnection
+	authed  bool
+	failed  bool
+	buf     []byte // handshake-phase packet reassembly buffer
+	pending *pendingQuery
+}
+
+type proxy struct {
+	config         *v2.MySQLProxy
+	clusterManager types.ClusterManager
+	readCallbacks  api.ReadFilterCallbacks
+	requestInfo    types.RequestInfo
+	stats          types.Metrics
+	ctx            context.Context
+
+	ready           bool
+	firstClientSent bool
+
+	primary *backend
+	replica *backend // nil if not configured, or if replica auth failed
+
+	downBuf []byte // command-phase client->server packet reassembly buffer
+}
+
+func NewProxy(ctx context.Context, config *v2.MySQLProxy) api.ReadFilter {
+	stats, err := newStats(config.StatPrefix)
+	if err != nil {
+		log.DefaultLogger.Errorf("[mysql_proxy] create stats failed: %v", err)
+	}
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		requestInfo:    network.NewRequestInfo(),
+		stats:          stats,
+		ctx:            ctx,
+	}
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	primary, err := p.connectBackend("primary", p.config.PrimaryCluster)
+	if err != nil {
+		log.DefaultLogger.Errorf("[mysql_proxy] connect primary cluster %s failed: %v", p.config.PrimaryCluster, err)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	p.primary = primary
+
+	if p.config.ReplicaCluster != "" {
+		replica, err := p.connectBackend("replica", p.config.ReplicaCluster)
+		if err != nil {
+			log.DefaultLogger.Warnf("[mysql_proxy] connect replica cluster %s failed, read/write split disabled: %v", p.config.ReplicaCluster, err)
+		} else {
+			p.replica = replica
+		}
+	}
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+	p.requestInfo.SetDownstreamRemoteAddress(p.readCallbacks.Connection().RemoteAddr())
+	p.requestInfo.SetDownstreamLocalAddress(p.readCallbacks.Connection().LocalAddr())
+}
+
+func (p *proxy) ReadDisableUpstream(disable bool)   {}
+func (p *proxy) ReadDisableDownstream(disable bool) {}
+
+func (p *proxy) connectBackend(name, clusterName string) (*backend, error) {
+	snapshot := p.clusterManager.GetClusterSnapshot(p.ctx, clusterName)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		return nil, errNoSuchCluster(clusterName)
+	}
+	lbCtx := &lbContext{conn: p.readCallbacks, ctx: p.ctx, cluster: snapshot.ClusterInfo()}
+	connectionData := p.clusterManager.TCPConnForCluster(lbCtx, snapshot)
+	if connectionData.Connection == nil {
+		return nil, errNoHealthyUpstream(clusterName)
+	}
+
+	b := &backend{name: name, conn: connectionData.Connection}
+	uc := &upstreamCallbacks{proxy: p, backend: b}
+	connectionData.Connection.AddConnectionEventListener(uc)
+	connectionData.Connection.FilterManager().AddReadFilter(uc)
+	if err := connectionData.Connection.Connect(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// OnData handles bytes from the client. Before the connection is ready
+// (i.e. still inside the handshake), bytes are relayed to the primary
+// as-is; the very first packet is also mirrored to the replica, per the
+// package doc's caveat. Once ready, client packets are decoded so
+// COM_QUERY statements can be classified and routed.
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+
+	if !p.ready {
+		p.primary.conn.Write(buffer.NewIoBufferBytes(data))
+		if !p.firstClientSent {
+			p.firstClientSent = true
+			if p.replica != nil {
+				p.replica.conn.Write(buffer.NewIoBufferBytes(append([]byte(nil), data...)))
+			}
+		}
+		return api.Stop
+	}
+
+	p.downBuf = append(p.downBuf, data...)
+	p.pumpClientCommands()
+	return api.Stop
+}
+
+func (p *proxy) pumpClientCommands() {
+	for {
+		pkt, n, err := decodePacket(p.downBuf)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mysql_proxy] malformed packet from client: %v", err)
+			p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+			return
+		}
+		if pkt == nil {
+			return
+		}
+		raw := append([]byte(nil), p.downBuf[:n]...)
+		p.downBuf = p.downBuf[n:]
+
+		target := p.primary
+		command := ""
+		if sql, ok := queryText(pkt); ok {
+			command = classify(sql)
+			if p.replica != nil && p.replica.authed && !p.replica.failed && isReadOnly(command) {
+				target = p.replica
+			}
+		}
+		target.pending = &pendingQuery{command: command, started: time.Now()}
+		target.conn.Write(buffer.NewIoBufferBytes(raw))
+	}
+}
+
+// handleHandshakeBackendData processes bytes from one backend while the
+// connection is still in its handshake: the primary's bytes are relayed to
+// the client (there's only one real client-facing handshake), and every
+// backend's packets are inspected for the OK/ERR that ends the handshake.
+func (p *proxy) handleHandshakeBackendData(b *backend, data []byte) {
+	if b == p.primary {
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(data))
+	}
+
+	b.buf = append(b.buf, data...)
+	for {
+		pkt, n, err := decodePacket(b.buf)
+		if err != nil || pkt == nil {
+			break
+		}
+		b.buf = b.buf[n:]
+		switch {
+		case isOK(pkt):
+			b.authed = true
+		case isErr(pkt):
+			b.failed = true
+		}
+	}
+
+	if b == p.replica && b.failed {
+		b.conn.Close(api.NoFlush, api.LocalClose)
+		p.replica = nil
+		return
+	}
+	if b == p.primary && (b.authed || b.failed) {
+		p.ready = true
+	}
+}
+
+func (p *proxy) recordStats(pending *pendingQuery) {
+	if p.stats == nil || pending.command == "" {
+		return
+	}
+	p.stats.Histogram(pending.command).Update(time.Since(pending.started).Nanoseconds())
+}
+
+func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	if p.primary != nil {
+		p.primary.conn.Close(api.NoFlush, api.LocalClose)
+	}
+	if p.replica != nil {
+		p.replica.conn.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+// upstreamCallbacks relays data and events for one backend connection.
+type upstreamCallbacks struct {
+	proxy   *proxy
+	backend *backend
+}
+
+func (uc *upstreamCallbacks) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+
+	if !uc.proxy.ready {
+		uc.proxy.handleHandshakeBackendData(uc.backend, data)
+		return api.Stop
+	}
+
+	if uc.backend.pending != nil {
+		uc.proxy.recordStats(uc.backend.pending)
+		uc.backend.pending = nil
+	}
+	// A response is only ever expected back from whichever backend the
+	// matching request was sent to, so no further routing is needed here.
+	uc.proxy.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(data))
+	return api.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+func (uc *upstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() && event != api.ConnectFailed && event != api.ConnectTimeout {
+		return
+	}
+	if uc.backend == uc.proxy.replica {
+		uc.proxy.replica = nil
+		return
+	}
+	if uc.backend == uc.proxy.primary && uc.proxy.readCallbacks != nil {
+		uc.proxy.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+// lbContext is a minimal types.LoadBalancerContext, mirroring tcpproxy's.
+type lbContext struct {
+	conn    api.ReadFilterCallbacks
+	ctx     context.Context
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria { return nil }
+func (c *lbContext) DownstreamConnection() net.Conn                   { return c.conn.Connection().RawConn() }
+func (c *lbContext) DownstreamHeaders() api.HeaderMap                 { return nil }
+func (c *lbContext) DownstreamContext() context.Context               { return c.ctx }
+func (c *lbContext) DownstreamCluster() types.ClusterInfo             { return c.cluster }
+func (c *lbContext) DownstreamRoute() api.Route                       { return nil }
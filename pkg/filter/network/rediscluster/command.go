@@ -0,0 +1,103 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import (
+	"strconv"
+	"strings"
+)
+
+// singleKeyCommands lists the commands this proxy knows how to shard: ones
+// whose first argument (args[1] of the request array) is the key to hash.
+// Multi-key commands (MSET, MGET, ...) and commands that operate cluster-
+// wide or take no key (PING, INFO, CLUSTER, SELECT, ...) are intentionally
+// left out of this set; they're routed to DefaultCluster instead, the same
+// way a real Redis Cluster client falls back to a fixed node for them.
+var singleKeyCommands = map[string]bool{
+	"GET": true, "SET": true, "SETNX": true, "SETEX": true, "PSETEX": true,
+	"APPEND": true, "STRLEN": true, "GETSET": true, "GETDEL": true,
+	"INCR": true, "DECR": true, "INCRBY": true, "DECRBY": true, "INCRBYFLOAT": true,
+	"DEL": true, "EXISTS": true, "TYPE": true, "EXPIRE": true, "PEXPIRE": true,
+	"EXPIREAT": true, "PEXPIREAT": true, "TTL": true, "PTTL": true, "PERSIST": true,
+	"LPUSH": true, "RPUSH": true, "LPUSHX": true, "RPUSHX": true, "LPOP": true,
+	"RPOP": true, "LLEN": true, "LRANGE": true, "LINDEX": true, "LSET": true, "LTRIM": true,
+	"SADD": true, "SREM": true, "SMEMBERS": true, "SISMEMBER": true, "SCARD": true,
+	"HGET": true, "HSET": true, "HDEL": true, "HGETALL": true, "HMGET": true,
+	"HMSET": true, "HEXISTS": true, "HINCRBY": true, "HLEN": true,
+	"ZADD": true, "ZSCORE": true, "ZREM": true, "ZRANGE": true, "ZCARD": true, "ZINCRBY": true,
+}
+
+// commandName returns the upper-cased command name of a decoded request
+// array, or "" if v isn't a well-formed command (a non-empty array of bulk
+// strings).
+func commandName(v *Value) string {
+	if v == nil || v.Type != Array || len(v.Array) == 0 {
+		return ""
+	}
+	first := v.Array[0]
+	if first.Type != BulkString || first.Null {
+		return ""
+	}
+	return strings.ToUpper(first.Str)
+}
+
+// commandKey returns the key argument of a single-key command and true, or
+// ("", false) if the command isn't one this proxy knows how to shard by key.
+func commandKey(v *Value) (string, bool) {
+	name := commandName(v)
+	if !singleKeyCommands[name] {
+		return "", false
+	}
+	if len(v.Array) < 2 || v.Array[1].Type != BulkString || v.Array[1].Null {
+		return "", false
+	}
+	return v.Array[1].Str, true
+}
+
+// redirect describes a MOVED or ASK error reply, e.g. "MOVED 3999 127.0.0.1:6381".
+type redirect struct {
+	ask  bool // ASK vs MOVED
+	slot int
+	addr string
+}
+
+// parseRedirect recognizes a MOVED/ASK error reply and extracts its slot
+// and target address. ok is false for every other reply, including other
+// kinds of errors.
+func parseRedirect(v *Value) (r redirect, ok bool) {
+	if v == nil || v.Type != Error {
+		return redirect{}, false
+	}
+	fields := strings.Fields(v.Str)
+	if len(fields) != 3 {
+		return redirect{}, false
+	}
+	ask := false
+	switch fields[0] {
+	case "MOVED":
+	case "ASK":
+		ask = true
+	default:
+		return redirect{}, false
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return redirect{}, false
+	}
+	return redirect{ask: ask, slot: slot, addr: fields[2]}, true
+}
@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import "testing"
+
+func decodeCommand(t *testing.T, raw string) *Value {
+	t.Helper()
+	v, _, err := Decode([]byte(raw))
+	if err != nil || v == nil {
+		t.Fatalf("Decode(%q) = (%v, %v)", raw, v, err)
+	}
+	return v
+}
+
+func TestCommandKey(t *testing.T) {
+	cases := []struct {
+		raw     string
+		wantKey string
+		wantOk  bool
+	}{
+		{"*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n", "foo", true},
+		{"*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n", "foo", true},
+		{"*1\r\n$4\r\nPING\r\n", "", false},
+		{"*2\r\n$6\r\nSELECT\r\n$1\r\n0\r\n", "", false},
+	}
+	for _, c := range cases {
+		key, ok := commandKey(decodeCommand(t, c.raw))
+		if ok != c.wantOk || key != c.wantKey {
+			t.Errorf("commandKey(%q) = (%q, %v), want (%q, %v)", c.raw, key, ok, c.wantKey, c.wantOk)
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	if got := commandName(decodeCommand(t, "*1\r\n$4\r\nping\r\n")); got != "PING" {
+		t.Errorf("commandName() = %q, want PING (upper-cased)", got)
+	}
+}
+
+func TestParseRedirect(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want redirect
+		ok   bool
+	}{
+		{"moved", "-MOVED 3999 127.0.0.1:6381\r\n", redirect{ask: false, slot: 3999, addr: "127.0.0.1:6381"}, true},
+		{"ask", "-ASK 3999 127.0.0.1:6381\r\n", redirect{ask: true, slot: 3999, addr: "127.0.0.1:6381"}, true},
+		{"other error", "-ERR wrong number of arguments\r\n", redirect{}, false},
+		{"not an error", "+OK\r\n", redirect{}, false},
+	}
+	for _, c := range cases {
+		v := decodeCommand(t, c.raw)
+		got, ok := parseRedirect(v)
+		if ok != c.ok {
+			t.Fatalf("%s: parseRedirect() ok = %v, want %v", c.name, ok, c.ok)
+		}
+		if ok && got != c.want {
+			t.Errorf("%s: parseRedirect() = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+func init() {
+	api.RegisterNetwork(v2.REDIS_CLUSTER_PROXY, CreateRedisClusterProxyFactory)
+}
+
+// redisClusterProxyFilterConfigFactory holds the one topology shared by
+// every downstream connection this filter serves, so a slot remapping
+// learned while serving one client benefits all the others, the same way a
+// real Redis Cluster's topology is shared by all of its clients.
+type redisClusterProxyFilterConfigFactory struct {
+	Proxy    *v2.RedisClusterProxy
+	Topology *topology
+}
+
+func (f *redisClusterProxyFilterConfigFactory) CreateFilterChain(ctx context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+	rf := NewProxy(ctx, f.Proxy, f.Topology)
+	callbacks.AddReadFilter(rf)
+}
+
+func CreateRedisClusterProxyFactory(conf map[string]interface{}) (api.NetworkFilterChainFactory, error) {
+	p, err := ParseRedisClusterProxy(conf)
+	if err != nil {
+		return nil, err
+	}
+	clusterManager := cluster.GetClusterMngAdapterInstance().ClusterManager
+	return &redisClusterProxyFilterConfigFactory{
+		Proxy:    p,
+		Topology: newTopology(p, clusterManager),
+	}, nil
+}
+
+// ParseRedisClusterProxy
+func ParseRedisClusterProxy(cfg map[string]interface{}) (*v2.RedisClusterProxy, error) {
+	proxy := &v2.RedisClusterProxy{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, proxy)
+	} else {
+		return nil, fmt.Errorf("[config] config is not a redis cluster proxy config: %v", err)
+	}
+	return proxy, nil
+}
@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import "strings"
+
+// SlotCount is the number of hash slots a Redis Cluster deployment is
+// partitioned into.
+const SlotCount = 16384
+
+// crc16Poly is the XMODEM/CCITT polynomial Redis Cluster uses for key
+// hashing (see Redis's own src/crc16.c).
+const crc16Poly = 0x1021
+
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ crc16Poly
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// KeySlot computes the hash slot (0 to SlotCount-1) a Redis Cluster key
+// belongs to. A "{tag}" hashtag inside the key, if present and non-empty,
+// is hashed instead of the whole key, so that related keys can be forced
+// onto the same slot for multi-key operations.
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			if tag := key[start+1 : start+1+end]; tag != "" {
+				key = tag
+			}
+		}
+	}
+	return int(crc16(key)) % SlotCount
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import "testing"
+
+// Expected slots are the well-known values used throughout Redis Cluster's
+// own documentation and test suite (e.g. `redis-cli cluster keyslot foo`).
+func TestKeySlot(t *testing.T) {
+	cases := []struct {
+		key  string
+		slot int
+	}{
+		{"foo", 12182},
+		{"bar", 5061},
+		{"123456789", 12739},
+	}
+	for _, c := range cases {
+		if got := KeySlot(c.key); got != c.slot {
+			t.Errorf("KeySlot(%q) = %d, want %d", c.key, got, c.slot)
+		}
+	}
+}
+
+func TestKeySlotHashtag(t *testing.T) {
+	// Keys sharing a non-empty {tag} must land on the same slot as the tag
+	// alone, so multi-key operations on them can be routed to one node.
+	base := KeySlot("user1000")
+	cases := []string{
+		"{user1000}.following",
+		"{user1000}.followers",
+		"foo.{user1000}.bar",
+	}
+	for _, key := range cases {
+		if got := KeySlot(key); got != base {
+			t.Errorf("KeySlot(%q) = %d, want %d (same slot as the {user1000} tag)", key, got, base)
+		}
+	}
+}
+
+func TestKeySlotEmptyHashtagIgnored(t *testing.T) {
+	// An empty {} tag isn't a real hashtag; the whole key is hashed as-is.
+	if got, want := KeySlot("{}foo"), KeySlot("{}foo"); got != want {
+		t.Errorf("KeySlot not stable: %d != %d", got, want)
+	}
+	if KeySlot("{}foo") == KeySlot("foo") {
+		t.Errorf("KeySlot(%q) should not collapse to KeySlot(%q) via an empty hashtag", "{}foo", "foo")
+	}
+}
+
+func TestKeySlotInRange(t *testing.T) {
+	for _, key := range []string{"", "a", "a-very-long-key-used-only-for-range-checking"} {
+		if slot := KeySlot(key); slot < 0 || slot >= SlotCount {
+			t.Errorf("KeySlot(%q) = %d, out of range [0,%d)", key, slot, SlotCount)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// ValueType is a RESP (REdis Serialization Protocol) type tag, the first
+// byte of every RESP value.
+type ValueType byte
+
+const (
+	SimpleString ValueType = '+'
+	Error        ValueType = '-'
+	Integer      ValueType = ':'
+	BulkString   ValueType = '$'
+	Array        ValueType = '*'
+)
+
+// Value is a decoded RESP value. Only Array holds nested values; the other
+// types carry their payload in Str. Null distinguishes a null bulk string
+// ($-1\r\n) or null array (*-1\r\n) from an empty one.
+type Value struct {
+	Type  ValueType
+	Str   string
+	Null  bool
+	Array []*Value
+}
+
+// Decode parses a single RESP value off the front of data. It returns
+// (nil, 0, nil) when data doesn't yet hold a complete value, the same
+// "wait for more data" contract used by the xprotocol sub-protocol decoders
+// in this codebase.
+func Decode(data []byte) (*Value, int, error) {
+	if len(data) == 0 {
+		return nil, 0, nil
+	}
+	switch data[0] {
+	case '+', '-', ':':
+		line, n, ok := readLine(data)
+		if !ok {
+			return nil, 0, nil
+		}
+		return &Value{Type: ValueType(data[0]), Str: string(line[1:])}, n, nil
+	case '$':
+		return decodeBulkString(data)
+	case '*':
+		return decodeArray(data)
+	default:
+		return nil, 0, fmt.Errorf("resp: unknown type byte %q", data[0])
+	}
+}
+
+func readLine(data []byte) (line []byte, n int, ok bool) {
+	idx := bytes.IndexByte(data, '\n')
+	if idx < 1 || data[idx-1] != '\r' {
+		return nil, 0, false
+	}
+	return data[:idx-1], idx + 1, true
+}
+
+func decodeBulkString(data []byte) (*Value, int, error) {
+	line, n, ok := readLine(data)
+	if !ok {
+		return nil, 0, nil
+	}
+	length, err := strconv.Atoi(string(line[1:]))
+	if err != nil {
+		return nil, 0, fmt.Errorf("resp: bad bulk string length: %v", err)
+	}
+	if length < 0 {
+		return &Value{Type: BulkString, Null: true}, n, nil
+	}
+	total := n + length + 2
+	if len(data) < total {
+		return nil, 0, nil
+	}
+	return &Value{Type: BulkString, Str: string(data[n : n+length])}, total, nil
+}
+
+// maxArrayLen bounds the element count a single RESP array header may
+// declare. Without it, a bogus header like "*2147483647\r\n" - just 13
+// bytes, and reachable pre-auth from either the downstream client or the
+// upstream reply - would make decodeArray allocate a multi-gigabyte slice
+// before ever checking that data holds anywhere near that many elements.
+// Real Redis commands and replies never come close to this many elements.
+const maxArrayLen = 1 << 20
+
+func decodeArray(data []byte) (*Value, int, error) {
+	line, n, ok := readLine(data)
+	if !ok {
+		return nil, 0, nil
+	}
+	count, err := strconv.Atoi(string(line[1:]))
+	if err != nil {
+		return nil, 0, fmt.Errorf("resp: bad array length: %v", err)
+	}
+	if count < 0 {
+		return &Value{Type: Array, Null: true}, n, nil
+	}
+	if count > maxArrayLen {
+		return nil, 0, fmt.Errorf("resp: array length %d exceeds maximum %d", count, maxArrayLen)
+	}
+	items := make([]*Value, 0, count)
+	pos := n
+	for i := 0; i < count; i++ {
+		item, m, err := Decode(data[pos:])
+		if err != nil {
+			return nil, 0, err
+		}
+		if item == nil {
+			return nil, 0, nil
+		}
+		items = append(items, item)
+		pos += m
+	}
+	return &Value{Type: Array, Array: items}, pos, nil
+}
+
+// EncodeCommand builds a RESP array-of-bulk-strings command, the wire form
+// every real Redis client sends requests in. It's only needed here for the
+// ASKING command the proxy injects ahead of an ASK-redirected retry; every
+// other command is forwarded to upstream as the exact bytes the client sent.
+func EncodeCommand(args ...string) []byte {
+	buf := []byte(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, a := range args {
+		buf = append(buf, []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(a), a))...)
+	}
+	return buf
+}
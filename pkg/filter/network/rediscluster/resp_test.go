@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import "testing"
+
+func TestDecodeSimpleTypes(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		typ  ValueType
+		str  string
+	}{
+		{"simple string", "+OK\r\n", SimpleString, "OK"},
+		{"error", "-ERR bad\r\n", Error, "ERR bad"},
+		{"integer", ":1000\r\n", Integer, "1000"},
+	}
+	for _, c := range cases {
+		v, n, err := Decode([]byte(c.data))
+		if err != nil {
+			t.Fatalf("%s: Decode() error = %v", c.name, err)
+		}
+		if v == nil {
+			t.Fatalf("%s: Decode() = nil, want a value", c.name)
+		}
+		if v.Type != c.typ || v.Str != c.str {
+			t.Errorf("%s: Decode() = %+v, want type=%c str=%q", c.name, v, c.typ, c.str)
+		}
+		if n != len(c.data) {
+			t.Errorf("%s: consumed %d bytes, want %d", c.name, n, len(c.data))
+		}
+	}
+}
+
+func TestDecodeBulkString(t *testing.T) {
+	v, n, err := Decode([]byte("$5\r\nhello\r\n"))
+	if err != nil || v == nil {
+		t.Fatalf("Decode() = (%v, %v, %v)", v, n, err)
+	}
+	if v.Type != BulkString || v.Str != "hello" || v.Null {
+		t.Errorf("Decode() = %+v, want BulkString hello", v)
+	}
+	if n != 11 {
+		t.Errorf("consumed %d bytes, want 11", n)
+	}
+}
+
+func TestDecodeNullBulkString(t *testing.T) {
+	v, _, err := Decode([]byte("$-1\r\n"))
+	if err != nil || v == nil || !v.Null {
+		t.Fatalf("Decode() = (%+v, %v), want a null bulk string", v, err)
+	}
+}
+
+func TestDecodeArray(t *testing.T) {
+	raw := "*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"
+	v, n, err := Decode([]byte(raw))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if v.Type != Array || len(v.Array) != 2 {
+		t.Fatalf("Decode() = %+v, want a 2-element array", v)
+	}
+	if v.Array[0].Str != "GET" || v.Array[1].Str != "foo" {
+		t.Errorf("Decode() array = %+v, want [GET foo]", v.Array)
+	}
+	if n != len(raw) {
+		t.Errorf("consumed %d bytes, want %d", n, len(raw))
+	}
+}
+
+func TestDecodeArrayRejectsOversizedLength(t *testing.T) {
+	_, _, err := Decode([]byte("*2147483647\r\n"))
+	if err == nil {
+		t.Fatal("Decode() error = nil, want an error for an array length over maxArrayLen")
+	}
+}
+
+func TestDecodeWaitsForMoreData(t *testing.T) {
+	cases := []string{
+		"",
+		"$5\r\nhel",
+		"*2\r\n$3\r\nGET\r\n$3\r\nfo",
+		"+OK",
+	}
+	for _, c := range cases {
+		v, n, err := Decode([]byte(c))
+		if v != nil || n != 0 || err != nil {
+			t.Errorf("Decode(%q) = (%v, %d, %v), want (nil, 0, nil)", c, v, n, err)
+		}
+	}
+}
+
+func TestDecodeStopsAtFirstValue(t *testing.T) {
+	raw := "+OK\r\n+PIPELINED\r\n"
+	v, n, err := Decode([]byte(raw))
+	if err != nil || v.Str != "OK" {
+		t.Fatalf("Decode() = (%+v, %v), want OK", v, err)
+	}
+	if n != len("+OK\r\n") {
+		t.Errorf("consumed %d bytes, want to stop right after the first value", n)
+	}
+}
+
+func TestEncodeCommand(t *testing.T) {
+	got := string(EncodeCommand("SET", "foo", "bar"))
+	want := "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n"
+	if got != want {
+		t.Errorf("EncodeCommand() = %q, want %q", got, want)
+	}
+}
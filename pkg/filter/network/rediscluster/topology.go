@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// topology tracks which mosn cluster currently owns each Redis Cluster hash
+// slot. It starts from the static slot ranges in the filter's config and is
+// refined at runtime as MOVED redirects report slots have migrated. It is
+// shared by every downstream connection the filter serves, the same way the
+// Redis Cluster topology it mirrors is shared by every client of the real
+// cluster.
+type topology struct {
+	mu             sync.RWMutex
+	defaultCluster string
+	slotCluster    [SlotCount]string
+
+	clusterManager types.ClusterManager
+	// candidates are the cluster names mentioned in config; a MOVED/ASK
+	// redirect can only be resolved to one of these, since those are the
+	// only clusters mosn has been told how to connect to.
+	candidates []string
+}
+
+func newTopology(config *v2.RedisClusterProxy, clusterManager types.ClusterManager) *topology {
+	t := &topology{
+		defaultCluster: config.DefaultCluster,
+		clusterManager: clusterManager,
+	}
+	seen := make(map[string]bool)
+	addCandidate := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			t.candidates = append(t.candidates, name)
+		}
+	}
+	for _, r := range config.Slots {
+		addCandidate(r.Cluster)
+		for s := r.Start; s <= r.End && s < SlotCount; s++ {
+			if s >= 0 {
+				t.slotCluster[s] = r.Cluster
+			}
+		}
+	}
+	addCandidate(config.DefaultCluster)
+	return t
+}
+
+// clusterForSlot returns the cluster currently believed to own slot, or the
+// default cluster if the slot hasn't been assigned one.
+func (t *topology) clusterForSlot(slot int) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if slot >= 0 && slot < SlotCount && t.slotCluster[slot] != "" {
+		return t.slotCluster[slot]
+	}
+	return t.defaultCluster
+}
+
+func (t *topology) clusterForKey(key string) string {
+	return t.clusterForSlot(KeySlot(key))
+}
+
+// applyMoved records that slot now belongs to cluster, so that later
+// commands for it are routed there directly instead of hitting the stale
+// node and being redirected again. ASK redirects are deliberately not
+// persisted here: unlike MOVED, ASK means the migration of that one slot is
+// still in progress, and the node being redirected to is only authoritative
+// for the keys already moved, not the slot as a whole.
+func (t *topology) applyMoved(slot int, cluster string) {
+	if slot < 0 || slot >= SlotCount {
+		return
+	}
+	t.mu.Lock()
+	t.slotCluster[slot] = cluster
+	t.mu.Unlock()
+}
+
+// resolveCluster maps a raw "ip:port" redirect address to one of the
+// filter's configured clusters, by checking which cluster's current host
+// set actually contains that address. A MOVED/ASK reply only ever points at
+// a node that's already a member of the Redis Cluster, and mosn routes
+// upstream traffic by cluster name rather than raw address, so a node that
+// isn't part of any cluster this filter was configured with can't be
+// resolved; the redirect is then left unfollowed rather than guessed at.
+func (t *topology) resolveCluster(ctx context.Context, addr string) (string, bool) {
+	for _, name := range t.candidates {
+		snapshot := t.clusterManager.GetClusterSnapshot(ctx, name)
+		if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+			continue
+		}
+		for _, host := range snapshot.HostSet().Hosts() {
+			if host.AddressString() == addr {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
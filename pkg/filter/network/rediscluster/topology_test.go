@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rediscluster
+
+import (
+	"context"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// fakeHost is a types.Host stub that only supports AddressString, the only
+// method topology.resolveCluster needs.
+type fakeHost struct {
+	types.Host
+	addr string
+}
+
+func (h *fakeHost) AddressString() string { return h.addr }
+
+type fakeHostSet struct {
+	types.HostSet
+	hosts []types.Host
+}
+
+func (hs *fakeHostSet) Hosts() []types.Host { return hs.hosts }
+
+type fakeClusterSnapshot struct {
+	types.ClusterSnapshot
+	hostSet types.HostSet
+}
+
+func (s *fakeClusterSnapshot) HostSet() types.HostSet { return s.hostSet }
+
+type fakeClusterManager struct {
+	types.ClusterManager
+	snapshots map[string]types.ClusterSnapshot
+}
+
+func (m *fakeClusterManager) GetClusterSnapshot(ctx context.Context, name string) types.ClusterSnapshot {
+	return m.snapshots[name]
+}
+
+func newFakeClusterManager(clusterHosts map[string][]string) *fakeClusterManager {
+	snapshots := make(map[string]types.ClusterSnapshot)
+	for name, addrs := range clusterHosts {
+		var hosts []types.Host
+		for _, addr := range addrs {
+			hosts = append(hosts, &fakeHost{addr: addr})
+		}
+		snapshots[name] = &fakeClusterSnapshot{hostSet: &fakeHostSet{hosts: hosts}}
+	}
+	return &fakeClusterManager{snapshots: snapshots}
+}
+
+func TestTopologyClusterForSlot(t *testing.T) {
+	config := &v2.RedisClusterProxy{
+		DefaultCluster: "default",
+		Slots: []v2.RedisSlotRange{
+			{Cluster: "shard-a", Start: 0, End: 8191},
+			{Cluster: "shard-b", Start: 8192, End: 16383},
+		},
+	}
+	topo := newTopology(config, newFakeClusterManager(nil))
+
+	cases := []struct {
+		slot int
+		want string
+	}{
+		{0, "shard-a"},
+		{8191, "shard-a"},
+		{8192, "shard-b"},
+		{16383, "shard-b"},
+	}
+	for _, c := range cases {
+		if got := topo.clusterForSlot(c.slot); got != c.want {
+			t.Errorf("clusterForSlot(%d) = %q, want %q", c.slot, got, c.want)
+		}
+	}
+}
+
+func TestTopologyClusterForSlotUnassignedFallsBackToDefault(t *testing.T) {
+	config := &v2.RedisClusterProxy{
+		DefaultCluster: "default",
+		Slots:          []v2.RedisSlotRange{{Cluster: "shard-a", Start: 0, End: 100}},
+	}
+	topo := newTopology(config, newFakeClusterManager(nil))
+	if got := topo.clusterForSlot(5000); got != "default" {
+		t.Errorf("clusterForSlot(unassigned) = %q, want default", got)
+	}
+}
+
+func TestTopologyApplyMoved(t *testing.T) {
+	config := &v2.RedisClusterProxy{
+		DefaultCluster: "default",
+		Slots:          []v2.RedisSlotRange{{Cluster: "shard-a", Start: 0, End: 100}},
+	}
+	topo := newTopology(config, newFakeClusterManager(nil))
+
+	topo.applyMoved(50, "shard-b")
+	if got := topo.clusterForSlot(50); got != "shard-b" {
+		t.Errorf("clusterForSlot(50) after MOVED = %q, want shard-b", got)
+	}
+	// Untouched slots in the same original range are unaffected.
+	if got := topo.clusterForSlot(51); got != "shard-a" {
+		t.Errorf("clusterForSlot(51) = %q, want shard-a (unaffected by neighboring MOVED)", got)
+	}
+}
+
+func TestTopologyResolveCluster(t *testing.T) {
+	config := &v2.RedisClusterProxy{
+		DefaultCluster: "default",
+		Slots: []v2.RedisSlotRange{
+			{Cluster: "shard-a", Start: 0, End: 8191},
+			{Cluster: "shard-b", Start: 8192, End: 16383},
+		},
+	}
+	cm := newFakeClusterManager(map[string][]string{
+		"shard-a": {"10.0.0.1:6379"},
+		"shard-b": {"10.0.0.2:6379"},
+	})
+	topo := newTopology(config, cm)
+
+	name, ok := topo.resolveCluster(context.Background(), "10.0.0.2:6379")
+	if !ok || name != "shard-b" {
+		t.Errorf("resolveCluster(10.0.0.2:6379) = (%q, %v), want (shard-b, true)", name, ok)
+	}
+
+	if _, ok := topo.resolveCluster(context.Background(), "10.0.0.99:6379"); ok {
+		t.Errorf("resolveCluster(unknown addr) = ok=true, want false (node isn't part of any configured cluster)")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package socks5
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+const (
+	socks5Version = 0x05
+	authVersion   = 0x01
+
+	methodNoAuth       = 0x00
+	methodUserPass     = 0x02
+	methodNoAcceptable = 0xff
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+
+	repSucceeded       = 0x00
+	repGeneralFailure  = 0x01
+	repHostUnreachable = 0x04
+)
+
+// parseGreeting parses the client's method-selection greeting (VER,
+// NMETHODS, METHODS...), returning the offered methods and how many
+// bytes it occupies. ok is false when data doesn't yet hold a complete
+// greeting - the caller should wait for more bytes.
+func parseGreeting(data []byte) (methods []byte, n int, ok bool) {
+	if len(data) < 2 {
+		return nil, 0, false
+	}
+	if data[0] != socks5Version {
+		return nil, 0, false
+	}
+	nmethods := int(data[1])
+	if len(data) < 2+nmethods {
+		return nil, 0, false
+	}
+	return data[2 : 2+nmethods], 2 + nmethods, true
+}
+
+// chooseMethod picks methodUserPass when requireAuth is set and the
+// client offered it, methodNoAuth when auth isn't required and the
+// client offered it, or methodNoAcceptable otherwise.
+func chooseMethod(offered []byte, requireAuth bool) byte {
+	want := byte(methodNoAuth)
+	if requireAuth {
+		want = methodUserPass
+	}
+	for _, m := range offered {
+		if m == want {
+			return want
+		}
+	}
+	return methodNoAcceptable
+}
+
+// methodSelectionReply builds the server's response to the greeting.
+func methodSelectionReply(method byte) []byte {
+	return []byte{socks5Version, method}
+}
+
+// parseAuthRequest parses the username/password sub-negotiation (RFC
+// 1929): VER, ULEN, UNAME, PLEN, PASSWD.
+func parseAuthRequest(data []byte) (username, password string, n int, ok bool) {
+	if len(data) < 2 {
+		return "", "", 0, false
+	}
+	ulen := int(data[1])
+	if len(data) < 2+ulen+1 {
+		return "", "", 0, false
+	}
+	plen := int(data[2+ulen])
+	if len(data) < 2+ulen+1+plen {
+		return "", "", 0, false
+	}
+	username = string(data[2 : 2+ulen])
+	password = string(data[2+ulen+1 : 2+ulen+1+plen])
+	return username, password, 2 + ulen + 1 + plen, true
+}
+
+// authReply builds the server's response to the auth sub-negotiation.
+func authReply(success bool) []byte {
+	status := byte(0x01)
+	if success {
+		status = 0x00
+	}
+	return []byte{authVersion, status}
+}
+
+// parseConnectRequest parses the client's SOCKS5 request (VER, CMD, RSV,
+// ATYP, DST.ADDR, DST.PORT), returning the requested destination as a
+// "host:port" string. Only the CONNECT command is supported.
+func parseConnectRequest(data []byte) (addr string, n int, ok bool) {
+	if len(data) < 4 {
+		return "", 0, false
+	}
+	if data[0] != socks5Version || data[1] != cmdConnect {
+		return "", 0, false
+	}
+
+	var host string
+	offset := 4
+	switch data[3] {
+	case atypIPv4:
+		if len(data) < offset+4 {
+			return "", 0, false
+		}
+		host = ipv4ToString(data[offset : offset+4])
+		offset += 4
+	case atypDomain:
+		if len(data) < offset+1 {
+			return "", 0, false
+		}
+		dlen := int(data[offset])
+		offset++
+		if len(data) < offset+dlen {
+			return "", 0, false
+		}
+		host = string(data[offset : offset+dlen])
+		offset += dlen
+	case atypIPv6:
+		if len(data) < offset+16 {
+			return "", 0, false
+		}
+		host = ipv6ToString(data[offset : offset+16])
+		offset += 16
+	default:
+		return "", 0, false
+	}
+
+	if len(data) < offset+2 {
+		return "", 0, false
+	}
+	port := binary.BigEndian.Uint16(data[offset : offset+2])
+	offset += 2
+
+	return host + ":" + strconv.Itoa(int(port)), offset, true
+}
+
+// connectReply builds the server's reply to a SOCKS5 request, carrying
+// the given status and a zeroed IPv4 bound address - mosn doesn't expose
+// the upstream's actual bind address to the client.
+func connectReply(rep byte) []byte {
+	return []byte{socks5Version, rep, 0x00, atypIPv4, 0, 0, 0, 0, 0, 0}
+}
+
+func ipv4ToString(b []byte) string {
+	return strconv.Itoa(int(b[0])) + "." + strconv.Itoa(int(b[1])) + "." + strconv.Itoa(int(b[2])) + "." + strconv.Itoa(int(b[3]))
+}
+
+func ipv6ToString(b []byte) string {
+	groups := make([]string, 8)
+	for i := 0; i < 8; i++ {
+		groups[i] = strconv.FormatUint(uint64(binary.BigEndian.Uint16(b[i*2:i*2+2])), 16)
+	}
+	s := groups[0]
+	for _, g := range groups[1:] {
+		s += ":" + g
+	}
+	return "[" + s + "]"
+}
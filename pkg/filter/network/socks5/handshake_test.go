@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package socks5
+
+import "testing"
+
+func TestParseGreeting(t *testing.T) {
+	methods, n, ok := parseGreeting([]byte{0x05, 0x02, 0x00, 0x02, 0xff})
+	if !ok || n != 4 || string(methods) != string([]byte{0x00, 0x02}) {
+		t.Errorf("parseGreeting() = (%v, %d, %v)", methods, n, ok)
+	}
+
+	if _, _, ok := parseGreeting([]byte{0x05, 0x02, 0x00}); ok {
+		t.Errorf("parseGreeting() on incomplete data = true, want false")
+	}
+
+	if _, _, ok := parseGreeting([]byte{0x04, 0x01, 0x00}); ok {
+		t.Errorf("parseGreeting() on wrong version = true, want false")
+	}
+}
+
+func TestChooseMethod(t *testing.T) {
+	if m := chooseMethod([]byte{0x00, 0x02}, false); m != methodNoAuth {
+		t.Errorf("chooseMethod() = %#x, want methodNoAuth", m)
+	}
+	if m := chooseMethod([]byte{0x00, 0x02}, true); m != methodUserPass {
+		t.Errorf("chooseMethod() = %#x, want methodUserPass", m)
+	}
+	if m := chooseMethod([]byte{0x00}, true); m != methodNoAcceptable {
+		t.Errorf("chooseMethod() = %#x, want methodNoAcceptable", m)
+	}
+}
+
+func TestParseAuthRequest(t *testing.T) {
+	data := []byte{0x01, 0x03, 'b', 'o', 'b', 0x04, 'p', 'a', 's', 's'}
+	user, pass, n, ok := parseAuthRequest(data)
+	if !ok || n != len(data) || user != "bob" || pass != "pass" {
+		t.Errorf("parseAuthRequest() = (%q, %q, %d, %v)", user, pass, n, ok)
+	}
+
+	if _, _, _, ok := parseAuthRequest([]byte{0x01, 0x03, 'b', 'o'}); ok {
+		t.Errorf("parseAuthRequest() on incomplete data = true, want false")
+	}
+}
+
+func TestParseConnectRequest(t *testing.T) {
+	// IPv4: 127.0.0.1:80
+	data := []byte{0x05, 0x01, 0x00, 0x01, 127, 0, 0, 1, 0x00, 0x50}
+	addr, n, ok := parseConnectRequest(data)
+	if !ok || n != len(data) || addr != "127.0.0.1:80" {
+		t.Errorf("parseConnectRequest(ipv4) = (%q, %d, %v)", addr, n, ok)
+	}
+
+	// domain: example.com:443
+	domain := "example.com"
+	data = append([]byte{0x05, 0x01, 0x00, 0x03, byte(len(domain))}, append([]byte(domain), 0x01, 0xbb)...)
+	addr, n, ok = parseConnectRequest(data)
+	if !ok || n != len(data) || addr != "example.com:443" {
+		t.Errorf("parseConnectRequest(domain) = (%q, %d, %v)", addr, n, ok)
+	}
+
+	// unsupported command (BIND)
+	if _, _, ok := parseConnectRequest([]byte{0x05, 0x02, 0x00, 0x01, 127, 0, 0, 1, 0, 80}); ok {
+		t.Errorf("parseConnectRequest() on BIND = true, want false")
+	}
+
+	// incomplete
+	if _, _, ok := parseConnectRequest([]byte{0x05, 0x01, 0x00, 0x01, 127, 0}); ok {
+		t.Errorf("parseConnectRequest() on incomplete data = true, want false")
+	}
+}
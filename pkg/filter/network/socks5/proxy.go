@@ -0,0 +1,359 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package socks5 is a network filter that terminates a SOCKS5 handshake
+// (RFC 1928) on the downstream connection, then switches to transparent
+// bidirectional byte proxying for the rest of the connection's lifetime -
+// the same shape as the connectproxy filter's handshake-then-relay
+// handling, applied to SOCKS5 instead of HTTP CONNECT.
+//
+// The handshake runs through three stages, each buffered until a full
+// message has arrived:
+//
+//  1. The client's method-selection greeting. If Username is configured,
+//     mosn requires and answers with the username/password method (RFC
+//     1929); otherwise it accepts the no-auth method. Any greeting that
+//     doesn't offer the required method is rejected.
+//  2. If auth is required, the username/password sub-negotiation. Wrong
+//     credentials fail the connection immediately.
+//  3. The client's request, of which only CONNECT is supported. The
+//     requested destination feeds the same upstream selection connectproxy
+//     uses: a configured Cluster is routed to via the cluster manager
+//     (letting the SOCKS5 destination drive normal cluster routing),
+//     otherwise mosn dials the destination directly as a forward proxy.
+//
+// Once the upstream connection is up, mosn answers the client's request
+// with a success reply and flips into raw relay mode, with byte-count
+// stats and (if configured) an idle timeout.
+package socks5
+
+import (
+	"context"
+	"net"
+	"reflect"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+type handshakeStage int
+
+const (
+	stageGreeting handshakeStage = iota
+	stageAuth
+	stageRequest
+	stageDone
+)
+
+type proxy struct {
+	config             *v2.Socks5Proxy
+	clusterManager     types.ClusterManager
+	readCallbacks      api.ReadFilterCallbacks
+	upstreamConnection types.ClientConnection
+	requestInfo        types.RequestInfo
+	stats              types.Metrics
+	ctx                context.Context
+
+	stage    handshakeStage
+	target   string
+	relaying bool
+
+	downBuf []byte
+}
+
+func NewProxy(ctx context.Context, config *v2.Socks5Proxy) api.ReadFilter {
+	stats, err := newStats(config.StatPrefix)
+	if err != nil {
+		log.DefaultLogger.Errorf("[socks5_proxy] create stats failed: %v", err)
+	}
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		requestInfo:    network.NewRequestInfo(),
+		stats:          stats,
+		ctx:            ctx,
+	}
+}
+
+func (p *proxy) requireAuth() bool {
+	return p.config.Username != ""
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+	p.requestInfo.SetDownstreamRemoteAddress(p.readCallbacks.Connection().RemoteAddr())
+	p.requestInfo.SetDownstreamLocalAddress(p.readCallbacks.Connection().LocalAddr())
+}
+
+func (p *proxy) ReadDisableUpstream(disable bool)   {}
+func (p *proxy) ReadDisableDownstream(disable bool) {}
+
+// OnData handles bytes from the client: buffered and driven through the
+// greeting/auth/request handshake stages, then relayed byte for byte
+// once the tunnel is confirmed.
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+
+	if p.relaying {
+		if p.upstreamConnection != nil {
+			p.upstreamConnection.Write(buffer.NewIoBufferBytes(data))
+			p.recordBytes(statBytesUpstream, len(data))
+		}
+		return api.Stop
+	}
+
+	if p.stage == stageDone {
+		// Extra bytes pipelined ahead of the tunnel being ready: buffered
+		// until maybeStartRelaying flushes them.
+		p.downBuf = append(p.downBuf, data...)
+		return api.Stop
+	}
+
+	p.downBuf = append(p.downBuf, data...)
+	for p.advanceHandshake() {
+	}
+	return api.Stop
+}
+
+// advanceHandshake drives the handshake state machine as far forward as
+// p.downBuf currently allows, returning true if it made progress and
+// should be called again (a stage completed and there may be more
+// buffered data for the next one).
+func (p *proxy) advanceHandshake() bool {
+	switch p.stage {
+	case stageGreeting:
+		methods, n, ok := parseGreeting(p.downBuf)
+		if !ok {
+			return false
+		}
+		p.downBuf = p.downBuf[n:]
+
+		method := chooseMethod(methods, p.requireAuth())
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(methodSelectionReply(method)))
+		if method == methodNoAcceptable {
+			log.DefaultLogger.Errorf("[socks5_proxy] no acceptable auth method offered")
+			p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+			return false
+		}
+		if method == methodUserPass {
+			p.stage = stageAuth
+		} else {
+			p.stage = stageRequest
+		}
+		return true
+
+	case stageAuth:
+		username, password, n, ok := parseAuthRequest(p.downBuf)
+		if !ok {
+			return false
+		}
+		p.downBuf = p.downBuf[n:]
+
+		success := username == p.config.Username && password == p.config.Password
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(authReply(success)))
+		if !success {
+			log.DefaultLogger.Errorf("[socks5_proxy] auth failed for user %q", username)
+			if p.stats != nil {
+				p.stats.Counter(statAuthFailure).Inc(1)
+			}
+			p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+			return false
+		}
+		p.stage = stageRequest
+		return true
+
+	case stageRequest:
+		target, n, ok := parseConnectRequest(p.downBuf)
+		if !ok {
+			return false
+		}
+		p.downBuf = p.downBuf[n:]
+
+		p.target = target
+		p.stage = stageDone
+		p.connectUpstream()
+		return false
+	}
+	return false
+}
+
+// connectUpstream opens the tunnel: to the configured Cluster if one is
+// set, otherwise by dialing p.target directly.
+func (p *proxy) connectUpstream() {
+	if p.config.Cluster != "" {
+		p.connectUpstreamCluster()
+		return
+	}
+	p.connectUpstreamDirect()
+}
+
+func (p *proxy) connectUpstreamCluster() {
+	snapshot := p.clusterManager.GetClusterSnapshot(p.ctx, p.config.Cluster)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		p.failConnect(errNoSuchCluster(p.config.Cluster))
+		return
+	}
+	lbCtx := &lbContext{conn: p.readCallbacks, ctx: p.ctx, cluster: snapshot.ClusterInfo()}
+	connectionData := p.clusterManager.TCPConnForCluster(lbCtx, snapshot)
+	if connectionData.Connection == nil {
+		p.failConnect(errNoHealthyUpstream(p.config.Cluster))
+		return
+	}
+	p.startUpstream(connectionData.Connection)
+}
+
+func (p *proxy) connectUpstreamDirect() {
+	remoteAddr, err := net.ResolveTCPAddr("tcp", p.target)
+	if err != nil {
+		p.failConnect(errBadTarget(p.target))
+		return
+	}
+	clientConn := network.NewClientConnection(nil, network.DefaultConnectTimeout, nil, remoteAddr, nil)
+	p.startUpstream(clientConn)
+	if err := clientConn.Connect(); err != nil {
+		p.failConnect(err)
+	}
+}
+
+func (p *proxy) startUpstream(conn types.ClientConnection) {
+	p.upstreamConnection = conn
+	uc := &upstreamCallbacks{proxy: p}
+	p.upstreamConnection.AddConnectionEventListener(uc)
+	p.upstreamConnection.FilterManager().AddReadFilter(uc)
+	p.onUpstreamReady()
+}
+
+func (p *proxy) failConnect(err error) {
+	log.DefaultLogger.Errorf("[socks5_proxy] %v", err)
+	if p.stats != nil {
+		p.stats.Counter(statTunnelFailure).Inc(1)
+	}
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(connectReply(repHostUnreachable)))
+	p.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+}
+
+// onUpstreamReady answers the downstream request with success and flips
+// into relay mode once the upstream connection has been established.
+func (p *proxy) onUpstreamReady() {
+	if p.stats != nil {
+		p.stats.Counter(statTunnelSuccess).Inc(1)
+	}
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(connectReply(repSucceeded)))
+	p.maybeStartRelaying()
+}
+
+func (p *proxy) onUpstreamData(buf buffer.IoBuffer) {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(data))
+	p.recordBytes(statBytesDownstream, len(data))
+}
+
+// maybeStartRelaying flips the connection into raw relay mode once the
+// upstream tunnel is up, flushing whatever downstream bytes were
+// pipelined ahead of that point.
+func (p *proxy) maybeStartRelaying() {
+	if p.relaying || p.upstreamConnection == nil {
+		return
+	}
+	p.relaying = true
+
+	if p.config.IdleTimeout != nil {
+		p.readCallbacks.Connection().SetIdleTimeout(*p.config.IdleTimeout)
+		p.upstreamConnection.SetIdleTimeout(*p.config.IdleTimeout)
+	}
+
+	if len(p.downBuf) > 0 {
+		p.upstreamConnection.Write(buffer.NewIoBufferBytes(p.downBuf))
+		p.recordBytes(statBytesUpstream, len(p.downBuf))
+		p.downBuf = nil
+	}
+}
+
+func (p *proxy) recordBytes(key string, n int) {
+	if p.stats == nil || n == 0 {
+		return
+	}
+	p.stats.Counter(key).Inc(int64(n))
+}
+
+func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	if p.upstreamConnection != nil {
+		p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+type upstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (uc *upstreamCallbacks) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	uc.proxy.onUpstreamData(buf)
+	return api.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+func (uc *upstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() && event != api.ConnectFailed && event != api.ConnectTimeout {
+		return
+	}
+	if uc.proxy.readCallbacks != nil {
+		uc.proxy.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+// lbContext is a minimal types.LoadBalancerContext, mirroring tcpproxy's,
+// the websocket filter's, and connectproxy's.
+type lbContext struct {
+	conn    api.ReadFilterCallbacks
+	ctx     context.Context
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria { return nil }
+func (c *lbContext) DownstreamConnection() net.Conn                   { return c.conn.Connection().RawConn() }
+func (c *lbContext) DownstreamHeaders() api.HeaderMap                 { return nil }
+func (c *lbContext) DownstreamContext() context.Context               { return c.ctx }
+func (c *lbContext) DownstreamCluster() types.ClusterInfo             { return c.cluster }
+func (c *lbContext) DownstreamRoute() api.Route                       { return nil }
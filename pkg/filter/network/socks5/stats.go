@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package socks5
+
+import (
+	"fmt"
+
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+// MetricsType is the metrics.NewMetrics type this filter registers its
+// counters under, one instance per stat_prefix.
+const MetricsType = "socks5_proxy"
+
+const (
+	statBytesUpstream   = "bytes_upstream"
+	statBytesDownstream = "bytes_downstream"
+	statTunnelSuccess   = "tunnel_success"
+	statTunnelFailure   = "tunnel_failure"
+	statAuthFailure     = "auth_failure"
+)
+
+func newStats(statPrefix string) (types.Metrics, error) {
+	stats, err := metrics.NewMetrics(MetricsType, map[string]string{"stat_prefix": statPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("socks5_proxy: create stats: %v", err)
+	}
+	return stats, nil
+}
+
+type errNoSuchCluster string
+
+func (e errNoSuchCluster) Error() string {
+	return fmt.Sprintf("socks5_proxy: no such cluster %q", string(e))
+}
+
+type errNoHealthyUpstream string
+
+func (e errNoHealthyUpstream) Error() string {
+	return fmt.Sprintf("socks5_proxy: no healthy upstream in cluster %q", string(e))
+}
+
+type errBadTarget string
+
+func (e errBadTarget) Error() string {
+	return fmt.Sprintf("socks5_proxy: could not resolve request target %q", string(e))
+}
@@ -212,6 +212,11 @@ func (p *proxy) finalizeUpstreamConnectionStats() {
 
 func (p *proxy) onConnectionSuccess() {
 	log.DefaultLogger.Debugf("new upstream connection %d created", p.upstreamConnection.ID())
+
+	if idleTimeout := p.config.IdleTimeout(); idleTimeout > 0 {
+		p.readCallbacks.Connection().SetIdleTimeout(idleTimeout)
+		p.upstreamConnection.SetIdleTimeout(idleTimeout)
+	}
 }
 
 func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
@@ -351,6 +356,13 @@ func NewProxyConfig(config *v2.TCPProxy) ProxyConfig {
 	}
 }
 
+func (pc *proxyConfig) IdleTimeout() time.Duration {
+	if pc.idleTimeout == nil {
+		return 0
+	}
+	return *pc.idleTimeout
+}
+
 func (pc *proxyConfig) GetRouteFromEntries(connection api.Connection) string {
 	if pc.cluster != "" {
 		log.DefaultLogger.Tracef("Tcp Proxy get cluster from config , cluster name = %v", pc.cluster)
@@ -3,6 +3,7 @@ package tcpproxy
 import (
 	"net"
 	"testing"
+	"time"
 
 	"mosn.io/mosn/pkg/config/v2"
 )
@@ -39,3 +40,16 @@ func Test_ParsePortRangeList(t *testing.T) {
 		t.Errorf("test  port range fail")
 	}
 }
+
+func Test_proxyConfig_IdleTimeout(t *testing.T) {
+	pc := NewProxyConfig(&v2.TCPProxy{Cluster: "test"})
+	if got := pc.IdleTimeout(); got != 0 {
+		t.Errorf("IdleTimeout() = %v, want 0 when unconfigured", got)
+	}
+
+	timeout := 30 * time.Second
+	pc = NewProxyConfig(&v2.TCPProxy{Cluster: "test", IdleTimeout: &timeout})
+	if got := pc.IdleTimeout(); got != timeout {
+		t.Errorf("IdleTimeout() = %v, want %v", got, timeout)
+	}
+}
@@ -18,6 +18,8 @@
 package tcpproxy
 
 import (
+	"time"
+
 	"mosn.io/api"
 )
 
@@ -33,6 +35,10 @@ type Proxy interface {
 // ProxyConfig
 type ProxyConfig interface {
 	GetRouteFromEntries(connection api.Connection) string
+
+	// IdleTimeout returns the configured idle timeout for both the
+	// downstream and upstream connections, or 0 if none is configured.
+	IdleTimeout() time.Duration
 }
 
 // UpstreamCallbacks for upstream's callbacks
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+var headerEnd = []byte("\r\n\r\n")
+
+// splitHead finds the request/status-line-plus-headers block at the front
+// of data (terminated by a blank line, same as HTTP/1.1), returning it and
+// the number of bytes it occupies. Returns (nil, 0) when data doesn't yet
+// hold a complete head - the same "wait for more data" contract the other
+// filters' decoders in this codebase use.
+func splitHead(data []byte) (head []byte, n int) {
+	idx := bytes.Index(data, headerEnd)
+	if idx < 0 {
+		return nil, 0
+	}
+	return data[:idx], idx + len(headerEnd)
+}
+
+// headerValue does a case-insensitive lookup of a header named key in a
+// raw HTTP head block (request or status line followed by "Name: value"
+// lines).
+func headerValue(head []byte, key string) (string, bool) {
+	lines := strings.Split(string(head), "\r\n")
+	for _, line := range lines[1:] {
+		colon := strings.IndexByte(line, ':')
+		if colon < 0 {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:colon]), key) {
+			return strings.TrimSpace(line[colon+1:]), true
+		}
+	}
+	return "", false
+}
+
+// hasToken reports whether value contains token as one of its
+// comma-separated, case-insensitive members - the way HTTP's Connection
+// header lists multiple directives (e.g. "keep-alive, Upgrade").
+func hasToken(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUpgradeRequest reports whether head is an HTTP/1.1 request asking to
+// upgrade to the websocket protocol: GET method, Connection: Upgrade, and
+// Upgrade: websocket.
+func isUpgradeRequest(head []byte) bool {
+	requestLine := head
+	if idx := bytes.IndexByte(head, '\n'); idx >= 0 {
+		requestLine = head[:idx]
+	}
+	if !bytes.HasPrefix(requestLine, []byte("GET ")) {
+		return false
+	}
+	conn, ok := headerValue(head, "Connection")
+	if !ok || !hasToken(conn, "upgrade") {
+		return false
+	}
+	upgrade, ok := headerValue(head, "Upgrade")
+	return ok && strings.EqualFold(upgrade, "websocket")
+}
+
+// isSwitchingProtocols reports whether head is a "101 Switching Protocols"
+// HTTP/1.1 status line.
+func isSwitchingProtocols(head []byte) bool {
+	statusLine := head
+	if idx := bytes.IndexByte(head, '\n'); idx >= 0 {
+		statusLine = head[:idx]
+	}
+	fields := strings.Fields(string(statusLine))
+	if len(fields) < 2 {
+		return false
+	}
+	code, err := strconv.Atoi(fields[1])
+	return err == nil && code == 101
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import "testing"
+
+func TestSplitHead(t *testing.T) {
+	data := []byte("GET / HTTP/1.1\r\nHost: a\r\n\r\nbody-bytes")
+	head, n := splitHead(data)
+	if string(head) != "GET / HTTP/1.1\r\nHost: a" {
+		t.Errorf("splitHead() head = %q", head)
+	}
+	if string(data[n:]) != "body-bytes" {
+		t.Errorf("splitHead() n leaves %q, want \"body-bytes\"", data[n:])
+	}
+
+	if head, n := splitHead([]byte("GET / HTTP/1.1\r\nHost: a")); head != nil || n != 0 {
+		t.Errorf("splitHead() on incomplete head = (%q, %d), want (nil, 0)", head, n)
+	}
+}
+
+func TestIsUpgradeRequest(t *testing.T) {
+	good := []byte("GET /chat HTTP/1.1\r\nHost: a\r\nConnection: keep-alive, Upgrade\r\nUpgrade: websocket\r\n")
+	if !isUpgradeRequest(good) {
+		t.Errorf("isUpgradeRequest() = false, want true")
+	}
+
+	notUpgrade := []byte("GET /chat HTTP/1.1\r\nHost: a\r\n")
+	if isUpgradeRequest(notUpgrade) {
+		t.Errorf("isUpgradeRequest() on a plain GET = true, want false")
+	}
+
+	wrongMethod := []byte("POST /chat HTTP/1.1\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n")
+	if isUpgradeRequest(wrongMethod) {
+		t.Errorf("isUpgradeRequest() on POST = true, want false")
+	}
+}
+
+func TestIsSwitchingProtocols(t *testing.T) {
+	if !isSwitchingProtocols([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n")) {
+		t.Errorf("isSwitchingProtocols() = false, want true")
+	}
+	if isSwitchingProtocols([]byte("HTTP/1.1 200 OK\r\n")) {
+		t.Errorf("isSwitchingProtocols() on 200 = true, want false")
+	}
+}
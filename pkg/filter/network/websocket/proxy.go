@@ -0,0 +1,276 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package websocket is a network filter that completes an HTTP/1.1
+// websocket upgrade handshake against a single upstream cluster, then
+// switches the connection to transparent bidirectional byte proxying for
+// the rest of its lifetime, the same way tcpproxy proxies any other raw
+// stream - the only thing this filter adds on top is the one HTTP-shaped
+// negotiation step a websocket connection starts with.
+//
+// It expects the very first bytes on the downstream connection to be a
+// full HTTP/1.1 request head (request line + headers, no body): the head
+// is relayed upstream unmodified, and whatever head the upstream answers
+// with is relayed back downstream unmodified. If that response head says
+// "101 Switching Protocols", the connection flips into raw relay mode with
+// byte-count stats and (if configured) an idle timeout; if it doesn't -
+// the upstream declined the upgrade, or answered with an ordinary HTTP
+// response - the connection still flips into raw relay, since this filter
+// doesn't re-enter HTTP semantics once the first head has gone by (no
+// further request/response framing, keep-alive handling, etc.), matching
+// tcpproxy's scope for everything after the first byte it decides not to
+// interpret.
+package websocket
+
+import (
+	"context"
+	"net"
+	"reflect"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+type proxy struct {
+	config             *v2.WebSocketProxy
+	clusterManager     types.ClusterManager
+	readCallbacks      api.ReadFilterCallbacks
+	upstreamConnection types.ClientConnection
+	requestInfo        types.RequestInfo
+	stats              types.Metrics
+	ctx                context.Context
+
+	downstreamHandshakeDone bool
+	upstreamHandshakeDone   bool
+	relaying                bool
+
+	downBuf []byte
+	upBuf   []byte
+}
+
+func NewProxy(ctx context.Context, config *v2.WebSocketProxy) api.ReadFilter {
+	stats, err := newStats(config.StatPrefix)
+	if err != nil {
+		log.DefaultLogger.Errorf("[websocket_proxy] create stats failed: %v", err)
+	}
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		requestInfo:    network.NewRequestInfo(),
+		stats:          stats,
+		ctx:            ctx,
+	}
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	snapshot := p.clusterManager.GetClusterSnapshot(p.ctx, p.config.Cluster)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		log.DefaultLogger.Errorf("[websocket_proxy] %v", errNoSuchCluster(p.config.Cluster))
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	lbCtx := &lbContext{conn: p.readCallbacks, ctx: p.ctx, cluster: snapshot.ClusterInfo()}
+	connectionData := p.clusterManager.TCPConnForCluster(lbCtx, snapshot)
+	if connectionData.Connection == nil {
+		log.DefaultLogger.Errorf("[websocket_proxy] %v", errNoHealthyUpstream(p.config.Cluster))
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+
+	p.upstreamConnection = connectionData.Connection
+	uc := &upstreamCallbacks{proxy: p}
+	p.upstreamConnection.AddConnectionEventListener(uc)
+	p.upstreamConnection.FilterManager().AddReadFilter(uc)
+	if err := p.upstreamConnection.Connect(); err != nil {
+		log.DefaultLogger.Errorf("[websocket_proxy] connect to cluster %s failed: %v", p.config.Cluster, err)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+	p.requestInfo.SetDownstreamRemoteAddress(p.readCallbacks.Connection().RemoteAddr())
+	p.requestInfo.SetDownstreamLocalAddress(p.readCallbacks.Connection().LocalAddr())
+}
+
+func (p *proxy) ReadDisableUpstream(disable bool)   {}
+func (p *proxy) ReadDisableDownstream(disable bool) {}
+
+// OnData handles bytes from the client: buffered and relayed as one HTTP
+// head while the handshake is still pending, then relayed byte for byte
+// once switched to the relay phase.
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+
+	if p.relaying {
+		if p.upstreamConnection != nil {
+			p.upstreamConnection.Write(buffer.NewIoBufferBytes(data))
+			p.recordBytes(statBytesUpstream, len(data))
+		}
+		return api.Stop
+	}
+
+	if p.downstreamHandshakeDone {
+		// Extra bytes pipelined ahead of the handshake completing:
+		// still headed to the same place once relaying starts.
+		p.downBuf = append(p.downBuf, data...)
+		return api.Stop
+	}
+
+	p.downBuf = append(p.downBuf, data...)
+	head, n := splitHead(p.downBuf)
+	if head == nil {
+		return api.Stop
+	}
+	raw := p.downBuf[:n]
+	rest := append([]byte(nil), p.downBuf[n:]...)
+	p.downBuf = rest
+
+	if !isUpgradeRequest(head) {
+		log.DefaultLogger.Warnf("[websocket_proxy] downstream request is not a websocket upgrade, proxying anyway")
+	}
+	p.downstreamHandshakeDone = true
+	if p.upstreamConnection != nil {
+		p.upstreamConnection.Write(buffer.NewIoBufferBytes(raw))
+	}
+	p.maybeStartRelaying()
+	return api.Stop
+}
+
+func (p *proxy) onUpstreamData(buf buffer.IoBuffer) {
+	data := append([]byte(nil), buf.Bytes()...)
+	buf.Drain(buf.Len())
+
+	if p.relaying {
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(data))
+		p.recordBytes(statBytesDownstream, len(data))
+		return
+	}
+
+	p.upBuf = append(p.upBuf, data...)
+	head, n := splitHead(p.upBuf)
+	if head == nil {
+		return
+	}
+	raw := p.upBuf[:n]
+	rest := append([]byte(nil), p.upBuf[n:]...)
+	p.upBuf = rest
+
+	if !isSwitchingProtocols(head) {
+		log.DefaultLogger.Warnf("[websocket_proxy] upstream declined the websocket upgrade, proxying anyway")
+	}
+	p.upstreamHandshakeDone = true
+	p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(raw))
+	p.maybeStartRelaying()
+}
+
+// maybeStartRelaying flips the connection into raw relay mode once both
+// halves of the handshake have been forwarded, flushing whatever bytes
+// either side sent ahead of that point.
+func (p *proxy) maybeStartRelaying() {
+	if p.relaying || !p.downstreamHandshakeDone || !p.upstreamHandshakeDone {
+		return
+	}
+	p.relaying = true
+
+	if p.config.IdleTimeout != nil {
+		p.readCallbacks.Connection().SetIdleTimeout(*p.config.IdleTimeout)
+		p.upstreamConnection.SetIdleTimeout(*p.config.IdleTimeout)
+	}
+
+	if len(p.downBuf) > 0 {
+		p.upstreamConnection.Write(buffer.NewIoBufferBytes(p.downBuf))
+		p.recordBytes(statBytesUpstream, len(p.downBuf))
+		p.downBuf = nil
+	}
+	if len(p.upBuf) > 0 {
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(p.upBuf))
+		p.recordBytes(statBytesDownstream, len(p.upBuf))
+		p.upBuf = nil
+	}
+}
+
+func (p *proxy) recordBytes(key string, n int) {
+	if p.stats == nil || n == 0 {
+		return
+	}
+	p.stats.Counter(key).Inc(int64(n))
+}
+
+func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	if p.upstreamConnection != nil {
+		p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+type upstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (uc *upstreamCallbacks) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	uc.proxy.onUpstreamData(buf)
+	return api.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+func (uc *upstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() && event != api.ConnectFailed && event != api.ConnectTimeout {
+		return
+	}
+	if uc.proxy.readCallbacks != nil {
+		uc.proxy.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+// lbContext is a minimal types.LoadBalancerContext, mirroring tcpproxy's.
+type lbContext struct {
+	conn    api.ReadFilterCallbacks
+	ctx     context.Context
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria { return nil }
+func (c *lbContext) DownstreamConnection() net.Conn                   { return c.conn.Connection().RawConn() }
+func (c *lbContext) DownstreamHeaders() api.HeaderMap                 { return nil }
+func (c *lbContext) DownstreamContext() context.Context               { return c.ctx }
+func (c *lbContext) DownstreamCluster() types.ClusterInfo             { return c.cluster }
+func (c *lbContext) DownstreamRoute() api.Route                       { return nil }
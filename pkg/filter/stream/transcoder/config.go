@@ -23,6 +23,9 @@ import (
 
 type config struct {
 	Type string `json:"type,omitempty"`
+	// ExtendConfig carries settings specific to Type's Transcoder, for a
+	// Transcoder that also implements Configurable.
+	ExtendConfig map[string]interface{} `json:"extend_config,omitempty"`
 }
 
 func parseConfig(cfg interface{}) (*config, error) {
@@ -51,6 +51,13 @@ func newTranscodeFilter(ctx context.Context, cfg *config) *transcodeFilter {
 		return nil
 	}
 
+	if configurable, ok := transcoder.(Configurable); ok {
+		if err := configurable.Configure(cfg.ExtendConfig); err != nil {
+			log.Proxy.Errorf(ctx, "[stream filter][transcoder] configure transcoder type %s failed: %v", cfg.Type, err)
+			return nil
+		}
+	}
+
 	return &transcodeFilter{
 		ctx:        ctx,
 		cfg:        cfg,
@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcjson
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// route binds one RESTful JSON endpoint to a gRPC method. Unlike a full
+// google.api.http annotation, the HTTP path must match exactly: there is no
+// {variable} binding, so the JSON body is expected to already carry every
+// request field.
+type route struct {
+	HTTPMethod string `json:"http_method"`
+	HTTPPath   string `json:"http_path"`
+	// Method is "package.Service.Method", as it appears in the descriptor set.
+	Method string `json:"method"`
+}
+
+// extendConfig is the grpcjson_simple transcoder's extend_config.
+type extendConfig struct {
+	// DescriptorSetPath is a file compiled with e.g.
+	// `protoc --include_imports --descriptor_set_out=out.pb *.proto`.
+	DescriptorSetPath string  `json:"descriptor_set_path"`
+	Routes            []route `json:"routes"`
+}
+
+func parseExtendConfig(cfg map[string]interface{}) (*extendConfig, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ec := &extendConfig{}
+	if err := json.Unmarshal(data, ec); err != nil {
+		return nil, err
+	}
+	if ec.DescriptorSetPath == "" {
+		return nil, fmt.Errorf("grpcjson: descriptor_set_path is required")
+	}
+	if len(ec.Routes) == 0 {
+		return nil, fmt.Errorf("grpcjson: routes is required")
+	}
+	return ec, nil
+}
@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcjson
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/filter/stream/transcoder"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+func init() {
+	transcoder.MustRegister("grpcjson_simple", &grpcJSON{})
+}
+
+// grpcJSON transcodes a RESTful JSON request into a unary gRPC call and the
+// gRPC response back into JSON, using a compiled proto descriptor set to
+// interpret the request/response messages. See package doc for the
+// supported subset of proto features, and route for the supported subset
+// of REST mapping.
+type grpcJSON struct {
+	mu    sync.RWMutex
+	reg   *registry
+	byKey map[string]*route // "METHOD path" -> route
+}
+
+// Configure loads the descriptor set and route table named by cfg. It may
+// be called more than once, e.g. from multiple filter chains referencing
+// this transcoder type; the last call's configuration wins.
+func (t *grpcJSON) Configure(cfg map[string]interface{}) error {
+	ec, err := parseExtendConfig(cfg)
+	if err != nil {
+		return err
+	}
+	reg, err := loadRegistry(ec.DescriptorSetPath)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]*route, len(ec.Routes))
+	for i := range ec.Routes {
+		r := &ec.Routes[i]
+		if _, ok := reg.method(r.Method); !ok {
+			return fmt.Errorf("grpcjson: route %s %s references unknown method %s", r.HTTPMethod, r.HTTPPath, r.Method)
+		}
+		byKey[routeKey(r.HTTPMethod, r.HTTPPath)] = r
+	}
+
+	t.mu.Lock()
+	t.reg = reg
+	t.byKey = byKey
+	t.mu.Unlock()
+	return nil
+}
+
+func routeKey(httpMethod, httpPath string) string {
+	return httpMethod + " " + httpPath
+}
+
+func (t *grpcJSON) matchRoute(headers types.HeaderMap) (*route, bool) {
+	reqHeaders, ok := headers.(http.RequestHeader)
+	if !ok {
+		return nil, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.byKey == nil {
+		return nil, false
+	}
+	r, ok := t.byKey[routeKey(string(reqHeaders.Method()), string(reqHeaders.RequestURI()))]
+	return r, ok
+}
+
+// Accept reports whether headers is a RESTful JSON request matching one of
+// the configured routes.
+func (t *grpcJSON) Accept(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) bool {
+	_, ok := t.matchRoute(headers)
+	return ok
+}
+
+// TranscodingRequest turns the matched route's JSON body into a gRPC
+// request: an HTTP/2 request addressed at the target method with a
+// length-prefixed protobuf body, see
+// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md.
+func (t *grpcJSON) TranscodingRequest(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error) {
+	r, ok := t.matchRoute(headers)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("grpcjson: no route matched")
+	}
+
+	t.mu.RLock()
+	reg := t.reg
+	t.mu.RUnlock()
+	method, ok := reg.method(r.Method)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("grpcjson: method %s no longer registered", r.Method)
+	}
+
+	obj := make(map[string]interface{})
+	if buf != nil && buf.Len() > 0 {
+		if err := json.Unmarshal(buf.Bytes(), &obj); err != nil {
+			return nil, nil, nil, fmt.Errorf("grpcjson: decode request JSON: %v", err)
+		}
+	}
+	payload, err := marshalMessage(reg, method.requestType, obj)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("grpcjson: encode gRPC request: %v", err)
+	}
+
+	// TranscodingResponse needs method's response type to decode the gRPC
+	// reply; grpcJSON is a process-wide singleton shared by every concurrent
+	// stream, so that can't live on t itself and is stashed on the per-stream
+	// ctx instead, the same way http2bolt_simple threads its sub-protocol.
+	mosnctx.WithValue(ctx, types.ContextKeyTranscoderMethod, method)
+
+	outHeaders := protocol.CommonHeader{
+		protocol.MosnHeaderMethod:  "POST",
+		protocol.MosnHeaderPathKey: method.path,
+		"content-type":             protocol.GRPCContentTypePrefix,
+		"te":                       "trailers",
+	}
+	return outHeaders, buffer.NewIoBufferBytes(encodeGRPCFrame(payload)), trailers, nil
+}
+
+// TranscodingResponse turns a unary gRPC response back into JSON: the
+// grpc-status (from trailers, or headers for a trailers-only response)
+// becomes the HTTP status, and the length-prefixed protobuf body becomes a
+// JSON object.
+func (t *grpcJSON) TranscodingResponse(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error) {
+	method, ok := mosnctx.Get(ctx, types.ContextKeyTranscoderMethod).(*methodDescriptor)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("grpcjson: no in-flight request to match this response to")
+	}
+
+	t.mu.RLock()
+	reg := t.reg
+	t.mu.RUnlock()
+
+	grpcStatus, ok := protocol.GRPCStatusFromHeaderMap(trailers)
+	if !ok {
+		grpcStatus, ok = protocol.GRPCStatusFromHeaderMap(headers)
+	}
+	if !ok {
+		grpcStatus = protocol.GRPCStatusUnknown
+	}
+
+	obj := make(map[string]interface{})
+	if grpcStatus == protocol.GRPCStatusOK && buf != nil && buf.Len() > 0 {
+		payload, err := decodeGRPCFrame(buf.Bytes())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("grpcjson: decode gRPC frame: %v", err)
+		}
+		obj, err = unmarshalMessage(reg, method.responseType, payload)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("grpcjson: decode gRPC response: %v", err)
+		}
+	}
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("grpcjson: encode response JSON: %v", err)
+	}
+
+	outHeaders := protocol.CommonHeader{
+		types.HeaderStatus: fmt.Sprintf("%d", httpStatusFromGRPCStatus(grpcStatus)),
+		"content-type":     "application/json",
+	}
+	return outHeaders, buffer.NewIoBufferBytes(body), nil, nil
+}
+
+// encodeGRPCFrame wraps payload in gRPC's 5-byte length-prefixed message
+// framing: a 1-byte compressed flag (always 0 here) and a 4-byte
+// big-endian length.
+func encodeGRPCFrame(payload []byte) []byte {
+	frame := make([]byte, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	copy(frame[5:], payload)
+	return frame
+}
+
+// decodeGRPCFrame strips gRPC's message framing, returning the single
+// message it wraps; streaming responses of more than one message are not
+// supported.
+func decodeGRPCFrame(frame []byte) ([]byte, error) {
+	if len(frame) < 5 {
+		return nil, fmt.Errorf("frame too short: %d bytes", len(frame))
+	}
+	length := binary.BigEndian.Uint32(frame[1:5])
+	if int(length) != len(frame)-5 {
+		return nil, fmt.Errorf("frame length mismatch: header says %d, got %d", length, len(frame)-5)
+	}
+	return frame[5:], nil
+}
+
+// httpStatusFromGRPCStatus maps a gRPC canonical status code to the HTTP
+// status a REST client expects, following the same table gRPC-gateway and
+// Envoy's grpc_json_transcoder use.
+func httpStatusFromGRPCStatus(status int) int {
+	switch status {
+	case protocol.GRPCStatusOK:
+		return http.OK
+	case protocol.GRPCStatusCancelled:
+		return 499
+	case protocol.GRPCStatusUnknown, protocol.GRPCStatusInternal:
+		return http.InternalServerError
+	case protocol.GRPCStatusDeadlineExceeded:
+		return http.GatewayTimeout
+	case protocol.GRPCStatusResourceExhausted:
+		return http.TooManyRequests
+	case protocol.GRPCStatusUnavailable:
+		return http.ServiceUnavailable
+	default:
+		return http.InternalServerError
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcjson
+
+import (
+	"context"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/valyala/fasthttp"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// buildTestRegistry hand-builds the descriptor a `protoc --descriptor_set_out`
+// would produce for:
+//
+//	message EchoRequest { string message = 1; }
+//	message EchoResponse { string message = 1; }
+//	service Echo { rpc Say(EchoRequest) returns (EchoResponse); }
+func buildTestRegistry() *registry {
+	field := func(name string, num int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		}
+	}
+	req := &descriptorpb.DescriptorProto{
+		Name:  proto.String("EchoRequest"),
+		Field: []*descriptorpb.FieldDescriptorProto{field("message", 1)},
+	}
+	resp := &descriptorpb.DescriptorProto{
+		Name:  proto.String("EchoResponse"),
+		Field: []*descriptorpb.FieldDescriptorProto{field("message", 1)},
+	}
+
+	r := &registry{
+		messages: make(map[string]*descriptorpb.DescriptorProto),
+		methods:  make(map[string]*methodDescriptor),
+	}
+	r.registerMessage("test", req)
+	r.registerMessage("test", resp)
+	r.methods["test.Echo.Say"] = &methodDescriptor{
+		path:         "/test.Echo/Say",
+		requestType:  req,
+		responseType: resp,
+	}
+	return r
+}
+
+func buildRequestHeaders(method, path string) http.RequestHeader {
+	header := &fasthttp.RequestHeader{}
+	header.SetMethod(method)
+	header.SetRequestURI(path)
+	return http.RequestHeader{RequestHeader: header}
+}
+
+func TestGrpcJSONAccept(t *testing.T) {
+	tr := &grpcJSON{
+		reg: buildTestRegistry(),
+		byKey: map[string]*route{
+			routeKey("POST", "/echo"): {HTTPMethod: "POST", HTTPPath: "/echo", Method: "test.Echo.Say"},
+		},
+	}
+
+	if !tr.Accept(context.Background(), buildRequestHeaders("POST", "/echo"), nil, nil) {
+		t.Errorf("Accept() = false, want true for a configured route")
+	}
+	if tr.Accept(context.Background(), buildRequestHeaders("GET", "/echo"), nil, nil) {
+		t.Errorf("Accept() = true, want false for an unconfigured method")
+	}
+	if tr.Accept(context.Background(), protocol.CommonHeader{}, nil, nil) {
+		t.Errorf("Accept() = true, want false for a non-HTTP header")
+	}
+}
+
+func TestGrpcJSONTranscodingRoundTrip(t *testing.T) {
+	tr := &grpcJSON{
+		reg: buildTestRegistry(),
+		byKey: map[string]*route{
+			routeKey("POST", "/echo"): {HTTPMethod: "POST", HTTPPath: "/echo", Method: "test.Echo.Say"},
+		},
+	}
+
+	// a real per-stream context, as OnReceive/Append would pass in: a bare
+	// context.Background() would make WithValue allocate a throwaway wrapper
+	// that TranscodingResponse's ctx never sees.
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+	reqHeaders := buildRequestHeaders("POST", "/echo")
+	reqBody := buffer.NewIoBufferString(`{"message":"hello"}`)
+
+	outHeaders, outBuf, _, err := tr.TranscodingRequest(ctx, reqHeaders, reqBody, nil)
+	if err != nil {
+		t.Fatalf("TranscodingRequest() error = %v", err)
+	}
+	if got, _ := outHeaders.Get(protocol.MosnHeaderPathKey); got != "/test.Echo/Say" {
+		t.Errorf("TranscodingRequest() path = %q, want /test.Echo/Say", got)
+	}
+	payload, err := decodeGRPCFrame(outBuf.Bytes())
+	if err != nil {
+		t.Fatalf("decodeGRPCFrame() error = %v", err)
+	}
+	obj, err := unmarshalMessage(tr.reg, tr.reg.messages["test.EchoRequest"], payload)
+	if err != nil {
+		t.Fatalf("unmarshalMessage() error = %v", err)
+	}
+	if !reflect.DeepEqual(obj, map[string]interface{}{"message": "hello"}) {
+		t.Errorf("decoded gRPC request = %v, want {message: hello}", obj)
+	}
+
+	// simulate the upstream's gRPC reply and feed it back through TranscodingResponse
+	respPayload, err := marshalMessage(tr.reg, tr.reg.messages["test.EchoResponse"], map[string]interface{}{"message": "hello"})
+	if err != nil {
+		t.Fatalf("marshalMessage() error = %v", err)
+	}
+	respHeaders := protocol.CommonHeader{}
+	respTrailers := protocol.CommonHeader{"grpc-status": "0"}
+
+	outRespHeaders, outRespBuf, _, err := tr.TranscodingResponse(ctx, respHeaders, buffer.NewIoBufferBytes(encodeGRPCFrame(respPayload)), respTrailers)
+	if err != nil {
+		t.Fatalf("TranscodingResponse() error = %v", err)
+	}
+	if got, _ := outRespHeaders.Get(types.HeaderStatus); got != "200" {
+		t.Errorf("TranscodingResponse() status = %q, want 200", got)
+	}
+	if want := `{"message":"hello"}`; string(outRespBuf.Bytes()) != want {
+		t.Errorf("TranscodingResponse() body = %s, want %s", outRespBuf.Bytes(), want)
+	}
+}
+
+func TestGrpcJSONTranscodingResponseWithoutRequest(t *testing.T) {
+	tr := &grpcJSON{reg: buildTestRegistry()}
+	if _, _, _, err := tr.TranscodingResponse(context.Background(), protocol.CommonHeader{}, nil, protocol.CommonHeader{"grpc-status": "0"}); err == nil {
+		t.Errorf("TranscodingResponse() error = nil, want an error when no request was transcoded on this stream")
+	}
+}
+
+func TestEncodeDecodeGRPCFrame(t *testing.T) {
+	payload := []byte("hello")
+	frame := encodeGRPCFrame(payload)
+	if len(frame) != 5+len(payload) {
+		t.Fatalf("encodeGRPCFrame() length = %d, want %d", len(frame), 5+len(payload))
+	}
+	if binary.BigEndian.Uint32(frame[1:5]) != uint32(len(payload)) {
+		t.Fatalf("encodeGRPCFrame() length prefix mismatch")
+	}
+	decoded, err := decodeGRPCFrame(frame)
+	if err != nil {
+		t.Fatalf("decodeGRPCFrame() error = %v", err)
+	}
+	if string(decoded) != string(payload) {
+		t.Errorf("decodeGRPCFrame() = %q, want %q", decoded, payload)
+	}
+	if _, err := decodeGRPCFrame(frame[:3]); err == nil {
+		t.Errorf("decodeGRPCFrame() error = nil, want an error for a truncated frame")
+	}
+}
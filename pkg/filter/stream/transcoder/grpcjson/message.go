@@ -0,0 +1,326 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpcjson transcodes between JSON and the protobuf wire format
+// using a compiled descriptor set, without generated Go message types. It
+// covers the scalar, enum, nested message and repeated field cases; maps,
+// oneofs and well-known wrapper types are not supported.
+package grpcjson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// jsonKey is the JSON object key a field is read from/written to: its
+// declared json_name if the descriptor carries one, else its proto name.
+func jsonKey(f *descriptorpb.FieldDescriptorProto) string {
+	if name := f.GetJsonName(); name != "" {
+		return name
+	}
+	return f.GetName()
+}
+
+func isRepeated(f *descriptorpb.FieldDescriptorProto) bool {
+	return f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+}
+
+// marshalMessage encodes a JSON object into protobuf wire bytes according to
+// msg's field layout, resolving nested message types through reg.
+func marshalMessage(reg *registry, msg *descriptorpb.DescriptorProto, obj map[string]interface{}) ([]byte, error) {
+	buf := proto.NewBuffer(nil)
+	for _, f := range msg.GetField() {
+		val, ok := obj[jsonKey(f)]
+		if !ok {
+			val, ok = obj[f.GetName()]
+		}
+		if !ok || val == nil {
+			continue
+		}
+		if isRepeated(f) {
+			items, ok := val.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("field %q: expected a JSON array", f.GetName())
+			}
+			for _, item := range items {
+				if err := marshalField(reg, buf, f, item); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if err := marshalField(reg, buf, f, val); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalField(reg *registry, buf *proto.Buffer, f *descriptorpb.FieldDescriptorProto, val interface{}) error {
+	tag := uint64(f.GetNumber())<<3 | uint64(wireTypeOf(f))
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		v, err := toFloat64(val)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeFixed64(math.Float64bits(v))
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		v, err := toFloat64(val)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeFixed32(uint64(math.Float32bits(float32(v))))
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		v, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeVarint(uint64(v))
+	case descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SINT64:
+		v, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeZigzag64(uint64(v))
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		v, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeFixed64(uint64(v))
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		v, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeFixed32(uint64(v))
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("field %q: expected a JSON string", f.GetName())
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeStringBytes(s)
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("field %q: expected a base64 JSON string", f.GetName())
+		}
+		raw, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("field %q: %v", f.GetName(), err)
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeRawBytes(raw)
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("field %q: expected a JSON object", f.GetName())
+		}
+		nested, ok := reg.messages[trimLeadingDot(f.GetTypeName())]
+		if !ok {
+			return fmt.Errorf("field %q: unresolved message type %s", f.GetName(), f.GetTypeName())
+		}
+		raw, err := marshalMessage(reg, nested, obj)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(tag)
+		buf.EncodeRawBytes(raw)
+	default:
+		return fmt.Errorf("field %q: unsupported proto type %s", f.GetName(), f.GetType())
+	}
+	return nil
+}
+
+// unmarshalMessage decodes protobuf wire bytes into a JSON object according
+// to msg's field layout, resolving nested message types through reg.
+func unmarshalMessage(reg *registry, msg *descriptorpb.DescriptorProto, raw []byte) (map[string]interface{}, error) {
+	fieldsByNumber := make(map[int32]*descriptorpb.FieldDescriptorProto, len(msg.GetField()))
+	for _, f := range msg.GetField() {
+		fieldsByNumber[f.GetNumber()] = f
+	}
+
+	obj := make(map[string]interface{})
+	buf := proto.NewBuffer(raw)
+	for {
+		tag, err := buf.DecodeVarint()
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode tag: %v", err)
+		}
+		fieldNum := int32(tag >> 3)
+		wireType := int(tag & 7)
+
+		f := fieldsByNumber[fieldNum]
+		if f == nil {
+			if err := skipField(buf, wireType); err != nil {
+				return nil, fmt.Errorf("skip unknown field %d: %v", fieldNum, err)
+			}
+			continue
+		}
+
+		val, err := unmarshalField(reg, buf, f, wireType)
+		if err != nil {
+			return nil, err
+		}
+
+		key := jsonKey(f)
+		if isRepeated(f) {
+			items, _ := obj[key].([]interface{})
+			obj[key] = append(items, val)
+		} else {
+			obj[key] = val
+		}
+	}
+	return obj, nil
+}
+
+func unmarshalField(reg *registry, buf *proto.Buffer, f *descriptorpb.FieldDescriptorProto, wireType int) (interface{}, error) {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		v, err := buf.DecodeFixed64()
+		return math.Float64frombits(v), err
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		v, err := buf.DecodeFixed32()
+		return float64(math.Float32frombits(uint32(v))), err
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		v, err := buf.DecodeVarint()
+		return v != 0, err
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		v, err := buf.DecodeVarint()
+		return float64(int64(v)), err
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_UINT32:
+		v, err := buf.DecodeVarint()
+		return float64(v), err
+	case descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SINT64:
+		v, err := buf.DecodeZigzag64()
+		return float64(int64(v)), err
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		v, err := buf.DecodeFixed64()
+		return float64(int64(v)), err
+	case descriptorpb.FieldDescriptorProto_TYPE_FIXED32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		v, err := buf.DecodeFixed32()
+		return float64(int32(v)), err
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return buf.DecodeStringBytes()
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		raw, err := buf.DecodeRawBytes(true)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(raw), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		raw, err := buf.DecodeRawBytes(true)
+		if err != nil {
+			return nil, err
+		}
+		nested, ok := reg.messages[trimLeadingDot(f.GetTypeName())]
+		if !ok {
+			return nil, fmt.Errorf("field %q: unresolved message type %s", f.GetName(), f.GetTypeName())
+		}
+		return unmarshalMessage(reg, nested, raw)
+	default:
+		return nil, fmt.Errorf("field %q: unsupported proto type %s", f.GetName(), f.GetType())
+	}
+}
+
+func skipField(buf *proto.Buffer, wireType int) error {
+	switch wireType {
+	case wireVarint:
+		_, err := buf.DecodeVarint()
+		return err
+	case wireFixed64:
+		_, err := buf.DecodeFixed64()
+		return err
+	case wireBytes:
+		_, err := buf.DecodeRawBytes(false)
+		return err
+	case wireFixed32:
+		_, err := buf.DecodeFixed32()
+		return err
+	default:
+		return fmt.Errorf("unsupported wire type %d", wireType)
+	}
+}
+
+func wireTypeOf(f *descriptorpb.FieldDescriptorProto) int {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return wireFixed64
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT, descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return wireFixed32
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+		descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		return wireBytes
+	default:
+		return wireVarint
+	}
+}
+
+func toFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("expected a JSON number, got %T", val)
+	}
+}
+
+func toInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case int:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("expected a JSON number or bool, got %T", val)
+	}
+}
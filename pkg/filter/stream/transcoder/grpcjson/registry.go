@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcjson
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// registry resolves the descriptor set into the pieces a route needs: the
+// full gRPC method path and its request/response message descriptors.
+type registry struct {
+	messages map[string]*descriptorpb.DescriptorProto
+	methods  map[string]*methodDescriptor
+}
+
+type methodDescriptor struct {
+	// path is the gRPC method's HTTP/2 :path, e.g. "/pkg.Service/Method".
+	path         string
+	requestType  *descriptorpb.DescriptorProto
+	responseType *descriptorpb.DescriptorProto
+}
+
+// loadRegistry parses a compiled FileDescriptorSet (produced by e.g.
+// `protoc --descriptor_set_out=... --include_imports`) at descriptorSetPath
+// into a registry of its messages and service methods.
+func loadRegistry(descriptorSetPath string) (*registry, error) {
+	raw, err := ioutil.ReadFile(descriptorSetPath)
+	if err != nil {
+		return nil, fmt.Errorf("read descriptor set: %v", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(raw, set); err != nil {
+		return nil, fmt.Errorf("parse descriptor set: %v", err)
+	}
+
+	r := &registry{
+		messages: make(map[string]*descriptorpb.DescriptorProto),
+		methods:  make(map[string]*methodDescriptor),
+	}
+	for _, file := range set.GetFile() {
+		pkg := file.GetPackage()
+		for _, msg := range file.GetMessageType() {
+			r.registerMessage(pkg, msg)
+		}
+		for _, svc := range file.GetService() {
+			svcName := qualify(pkg, svc.GetName())
+			for _, method := range svc.GetMethod() {
+				req := r.messages[trimLeadingDot(method.GetInputType())]
+				resp := r.messages[trimLeadingDot(method.GetOutputType())]
+				if req == nil || resp == nil {
+					return nil, fmt.Errorf("method %s.%s references an unresolved message type", svcName, method.GetName())
+				}
+				r.methods[svcName+"."+method.GetName()] = &methodDescriptor{
+					path:         "/" + svcName + "/" + method.GetName(),
+					requestType:  req,
+					responseType: resp,
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+func (r *registry) registerMessage(pkg string, msg *descriptorpb.DescriptorProto) {
+	full := qualify(pkg, msg.GetName())
+	r.messages[full] = msg
+	for _, nested := range msg.GetNestedType() {
+		r.registerMessage(full, nested)
+	}
+}
+
+// method looks up a service method by "package.Service.Method".
+func (r *registry) method(fullMethod string) (*methodDescriptor, bool) {
+	m, ok := r.methods[fullMethod]
+	return m, ok
+}
+
+func qualify(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
+
+func trimLeadingDot(name string) string {
+	if len(name) > 0 && name[0] == '.' {
+		return name[1:]
+	}
+	return name
+}
@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpcweb registers a "grpcweb" transcoder (see
+// pkg/filter/stream/transcoder) that lets a browser speak the gRPC-Web
+// wire protocol (https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md)
+// to mosn while the upstream cluster gets plain gRPC over HTTP/2. Unlike
+// grpcjson_simple, this needs no proto descriptor: gRPC-Web frames the
+// message payload exactly like gRPC does, so the request/response body
+// passes through unmodified - what differs is the outer transport
+// (HTTP/1.1-safe headers, optional base64 body encoding, and trailers
+// smuggled into the response body as an extra length-prefixed frame,
+// since a browser can't read real HTTP trailers).
+package grpcweb
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/filter/stream/transcoder"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// contentTypePrefix identifies a gRPC-Web request/response, e.g.
+// "application/grpc-web", "application/grpc-web+proto",
+// "application/grpc-web-text" or "application/grpc-web-text+proto".
+const contentTypePrefix = "application/grpc-web"
+
+func init() {
+	transcoder.MustRegister("grpcweb", &grpcWeb{})
+}
+
+// grpcWeb transcodes gRPC-Web <-> gRPC. It carries no state of its own -
+// per-request bookkeeping (whether the client used the base64 "-text"
+// variant) travels on the stream's ctx, the same way grpcjson_simple
+// stashes its method lookup there.
+type grpcWeb struct{}
+
+// Accept reports whether headers carry a gRPC-Web content-type.
+func (t *grpcWeb) Accept(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) bool {
+	reqHeaders, ok := headers.(http.RequestHeader)
+	if !ok {
+		return false
+	}
+	ct, ok := reqHeaders.Get("content-type")
+	return ok && strings.HasPrefix(ct, contentTypePrefix)
+}
+
+// TranscodingRequest turns a gRPC-Web request into a plain gRPC one: the
+// body is base64-decoded when the "-text" variant is in use (it is
+// already gRPC's length-prefixed message framing either way), and the
+// content-type drops its "-web" marker.
+func (t *grpcWeb) TranscodingRequest(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error) {
+	reqHeaders, ok := headers.(http.RequestHeader)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("grpcweb: headers is not an HTTP request")
+	}
+	ct, _ := reqHeaders.Get("content-type")
+	isText := strings.HasPrefix(ct, contentTypePrefix+"-text")
+
+	body := buf.Bytes()
+	if isText {
+		decoded, err := decodeBase64(body)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("grpcweb: decode base64 request body: %v", err)
+		}
+		body = decoded
+	}
+
+	// TranscodingResponse needs to know whether to re-encode the reply as
+	// base64; grpcWeb is a process-wide singleton shared by every
+	// concurrent stream, so that can't live on t itself.
+	mosnctx.WithValue(ctx, types.ContextKeyGRPCWebText, isText)
+
+	outHeaders := protocol.CommonHeader{
+		protocol.MosnHeaderMethod:  "POST",
+		protocol.MosnHeaderPathKey: string(reqHeaders.RequestURI()),
+		"content-type":             protocol.GRPCContentTypePrefix + grpcContentTypeSuffix(ct),
+		"te":                       "trailers",
+	}
+	reqHeaders.Range(func(key, value string) bool {
+		key = strings.ToLower(key)
+		switch key {
+		case "content-type", "content-length", "te":
+			// already set above, or invalidated by decoding the body
+		default:
+			outHeaders[key] = value
+		}
+		return true
+	})
+
+	return outHeaders, buffer.NewIoBufferBytes(body), trailers, nil
+}
+
+// TranscodingResponse turns a plain gRPC response back into gRPC-Web: the
+// grpc-status/grpc-message trailers are appended to the body as gRPC-Web's
+// trailer frame (a length-prefixed block with the MSB of its flags byte
+// set), since a browser has no access to real HTTP trailers. If the
+// request came in as "-text", the whole body (message frame plus trailer
+// frame) is re-encoded as base64.
+func (t *grpcWeb) TranscodingResponse(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error) {
+	isText, _ := mosnctx.Get(ctx, types.ContextKeyGRPCWebText).(bool)
+
+	respHeaders, _ := headers.(http.ResponseHeader)
+	ct := ""
+	if respHeaders.ResponseHeader != nil {
+		ct, _ = respHeaders.Get("content-type")
+	}
+
+	body := buf.Bytes()
+	body = append(append([]byte(nil), body...), trailerFrame(headers, trailers)...)
+	if isText {
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+	}
+
+	respContentType := contentTypePrefix
+	if isText {
+		respContentType += "-text"
+	}
+	respContentType += grpcContentTypeSuffix(ct)
+
+	outHeaders := protocol.CommonHeader{
+		types.HeaderStatus: strconv.Itoa(http.OK),
+		"content-type":     respContentType,
+	}
+	return outHeaders, buffer.NewIoBufferBytes(body), nil, nil
+}
+
+// trailerFrame builds gRPC-Web's trailer frame: a 5-byte header (flags
+// byte with bit 0x80 set, then a 4-byte big-endian length) followed by
+// the trailers formatted as an HTTP/1-style header block, matching
+// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md.
+func trailerFrame(headers, trailers types.HeaderMap) []byte {
+	grpcStatus, ok := protocol.GRPCStatusFromHeaderMap(trailers)
+	if !ok {
+		grpcStatus, ok = protocol.GRPCStatusFromHeaderMap(headers)
+	}
+	if !ok {
+		grpcStatus = protocol.GRPCStatusUnknown
+	}
+
+	var block strings.Builder
+	fmt.Fprintf(&block, "%s: %d\r\n", protocol.HeaderGRPCStatus, grpcStatus)
+	if trailers != nil {
+		if msg, ok := trailers.Get(protocol.HeaderGRPCMessage); ok {
+			fmt.Fprintf(&block, "%s: %s\r\n", protocol.HeaderGRPCMessage, msg)
+		}
+	}
+	payload := []byte(block.String())
+
+	frame := make([]byte, 5+len(payload))
+	frame[0] = 0x80
+	frame[1] = byte(len(payload) >> 24)
+	frame[2] = byte(len(payload) >> 16)
+	frame[3] = byte(len(payload) >> 8)
+	frame[4] = byte(len(payload))
+	copy(frame[5:], payload)
+	return frame
+}
+
+// grpcContentTypeSuffix returns "+proto" (or whatever subtype follows the
+// content-type's prefix) so a request/response that named its message
+// format keeps doing so across the transcode.
+func grpcContentTypeSuffix(ct string) string {
+	for _, prefix := range []string{contentTypePrefix + "-text", contentTypePrefix, protocol.GRPCContentTypePrefix} {
+		if strings.HasPrefix(ct, prefix) {
+			return ct[len(prefix):]
+		}
+	}
+	return ""
+}
+
+// decodeBase64 decodes s, tolerating a missing "=" padding the way
+// browsers' grpc-web-text clients sometimes send it.
+func decodeBase64(s []byte) ([]byte, error) {
+	if decoded, err := base64.StdEncoding.DecodeString(string(s)); err == nil {
+		return decoded, nil
+	}
+	return base64.RawStdEncoding.DecodeString(string(s))
+}
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcweb
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+func buildRequestHeaders(contentType string) http.RequestHeader {
+	header := &fasthttp.RequestHeader{}
+	header.SetMethod("POST")
+	header.SetRequestURI("/test.Echo/Say")
+	header.Set("content-type", contentType)
+	header.Set("x-grpc-web", "1")
+	return http.RequestHeader{RequestHeader: header}
+}
+
+func buildResponseHeaders(contentType string) http.ResponseHeader {
+	header := &fasthttp.ResponseHeader{}
+	header.Set("content-type", contentType)
+	return http.ResponseHeader{ResponseHeader: header}
+}
+
+func TestGRPCWebAccept(t *testing.T) {
+	tr := &grpcWeb{}
+	if !tr.Accept(context.Background(), buildRequestHeaders("application/grpc-web+proto"), nil, nil) {
+		t.Error("Accept() = false for a grpc-web request, want true")
+	}
+	if !tr.Accept(context.Background(), buildRequestHeaders("application/grpc-web-text"), nil, nil) {
+		t.Error("Accept() = false for a grpc-web-text request, want true")
+	}
+	if tr.Accept(context.Background(), buildRequestHeaders("application/json"), nil, nil) {
+		t.Error("Accept() = true for a plain JSON request, want false")
+	}
+	if tr.Accept(context.Background(), protocol.CommonHeader{}, nil, nil) {
+		t.Error("Accept() = true for a non-HTTP header map, want false")
+	}
+}
+
+func TestGRPCWebRequestResponseRoundTrip(t *testing.T) {
+	tr := &grpcWeb{}
+	// a real per-stream context, as OnReceive/Append would pass in: a bare
+	// context.Background() would make WithValue allocate a throwaway
+	// wrapper that TranscodingResponse's ctx never sees.
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+
+	message := []byte("\x00\x00\x00\x00\x05hello") // one gRPC message frame
+	reqHeaders := buildRequestHeaders("application/grpc-web+proto")
+	outHeaders, outBuf, _, err := tr.TranscodingRequest(ctx, reqHeaders, buffer.NewIoBufferBytes(message), nil)
+	if err != nil {
+		t.Fatalf("TranscodingRequest error: %v", err)
+	}
+	if ct, _ := outHeaders.Get("content-type"); ct != "application/grpc+proto" {
+		t.Errorf("request content-type = %q, want application/grpc+proto", ct)
+	}
+	if v, _ := outHeaders.Get("x-grpc-web"); v != "1" {
+		t.Errorf("unrelated header x-grpc-web was dropped by transcoding")
+	}
+	if outBuf.String() != string(message) {
+		t.Errorf("request body was modified for a non-text request: %q", outBuf.Bytes())
+	}
+
+	respHeaders := buildResponseHeaders("application/grpc+proto")
+	respTrailers := protocol.CommonHeader{"grpc-status": "0"}
+	outRespHeaders, outRespBuf, outRespTrailers, err := tr.TranscodingResponse(ctx, respHeaders, buffer.NewIoBufferBytes(message), respTrailers)
+	if err != nil {
+		t.Fatalf("TranscodingResponse error: %v", err)
+	}
+	if outRespTrailers != nil {
+		t.Errorf("gRPC-Web response must not carry real trailers, got %v", outRespTrailers)
+	}
+	if ct, _ := outRespHeaders.Get("content-type"); ct != "application/grpc-web+proto" {
+		t.Errorf("response content-type = %q, want application/grpc-web+proto", ct)
+	}
+	if status, _ := outRespHeaders.Get(types.HeaderStatus); status != "200" {
+		t.Errorf("response status = %q, want 200", status)
+	}
+
+	body := outRespBuf.Bytes()
+	if !strings.HasPrefix(string(body), string(message)) {
+		t.Fatalf("response body doesn't start with the original message frame: %q", body)
+	}
+	trailer := body[len(message):]
+	if trailer[0] != 0x80 {
+		t.Fatalf("trailer frame flag byte = %#x, want 0x80", trailer[0])
+	}
+	if !strings.Contains(string(trailer[5:]), "grpc-status: 0") {
+		t.Errorf("trailer frame payload = %q, want it to contain grpc-status: 0", trailer[5:])
+	}
+}
+
+func TestGRPCWebTextRequestIsBase64Decoded(t *testing.T) {
+	tr := &grpcWeb{}
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+
+	message := []byte("\x00\x00\x00\x00\x05hello")
+	encoded := base64.StdEncoding.EncodeToString(message)
+
+	reqHeaders := buildRequestHeaders("application/grpc-web-text")
+	_, outBuf, _, err := tr.TranscodingRequest(ctx, reqHeaders, buffer.NewIoBufferBytes([]byte(encoded)), nil)
+	if err != nil {
+		t.Fatalf("TranscodingRequest error: %v", err)
+	}
+	if outBuf.String() != string(message) {
+		t.Errorf("decoded request body = %q, want %q", outBuf.Bytes(), message)
+	}
+
+	respHeaders := buildResponseHeaders("application/grpc")
+	_, outRespBuf, _, err := tr.TranscodingResponse(ctx, respHeaders, buffer.NewIoBufferBytes(message), protocol.CommonHeader{"grpc-status": "0"})
+	if err != nil {
+		t.Fatalf("TranscodingResponse error: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(outRespBuf.String())
+	if err != nil {
+		t.Fatalf("response body is not valid base64: %v", err)
+	}
+	if !strings.HasPrefix(string(decoded), string(message)) {
+		t.Errorf("decoded response body doesn't start with the original message: %q", decoded)
+	}
+}
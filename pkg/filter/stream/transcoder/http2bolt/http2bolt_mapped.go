@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/filter/stream/transcoder"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	transcoder.MustRegister("http2bolt_mapped", &http2boltMapped{})
+}
+
+// headerMapping renames one header when crossing from HTTP to bolt; the
+// same rule is applied in reverse for the response.
+type headerMapping struct {
+	HTTPHeader string `json:"http_header"`
+	BoltHeader string `json:"bolt_header"`
+}
+
+// mappedExtendConfig is the http2bolt_mapped transcoder's extend_config.
+type mappedExtendConfig struct {
+	HeaderMapping []headerMapping `json:"header_mapping"`
+}
+
+func parseMappedExtendConfig(cfg map[string]interface{}) (*mappedExtendConfig, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ec := &mappedExtendConfig{}
+	if err := json.Unmarshal(data, ec); err != nil {
+		return nil, err
+	}
+	return ec, nil
+}
+
+// http2boltMapped is http2bolt_simple generalized with a configurable,
+// per-route header mapping: unlike http2bolt_simple, which copies every
+// header across unchanged, this renames the headers named in
+// HeaderMapping and passes everything else through as-is, in both
+// directions.
+type http2boltMapped struct {
+	mu     sync.RWMutex
+	toBolt map[string]string // lower-cased http header -> bolt header
+	toHTTP map[string]string // lower-cased bolt header -> http header
+}
+
+// Configure builds the two mapping directions from cfg. It may be called
+// more than once, e.g. from multiple routes referencing this transcoder
+// type with different extend_config; the last call's configuration wins
+// for that route's filter instance, same as grpcjson_simple.
+func (t *http2boltMapped) Configure(cfg map[string]interface{}) error {
+	ec, err := parseMappedExtendConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("http2bolt_mapped: parse extend_config: %v", err)
+	}
+
+	toBolt := make(map[string]string, len(ec.HeaderMapping))
+	toHTTP := make(map[string]string, len(ec.HeaderMapping))
+	for _, m := range ec.HeaderMapping {
+		if m.HTTPHeader == "" || m.BoltHeader == "" {
+			return fmt.Errorf("http2bolt_mapped: header_mapping entry needs both http_header and bolt_header: %+v", m)
+		}
+		toBolt[strings.ToLower(m.HTTPHeader)] = m.BoltHeader
+		toHTTP[strings.ToLower(m.BoltHeader)] = m.HTTPHeader
+	}
+
+	t.mu.Lock()
+	t.toBolt = toBolt
+	t.toHTTP = toHTTP
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *http2boltMapped) Accept(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) bool {
+	_, ok := headers.(http.RequestHeader)
+	return ok
+}
+
+// TranscodingRequest builds a bolt request from an HTTP one, renaming
+// each header found in HeaderMapping and passing the rest through
+// unchanged.
+func (t *http2boltMapped) TranscodingRequest(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error) {
+	sourceRequest, ok := headers.(http.RequestHeader)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("http2bolt_mapped: headers is not an HTTP request")
+	}
+
+	t.mu.RLock()
+	toBolt := t.toBolt
+	t.mu.RUnlock()
+
+	targetHeaders := protocol.CommonHeader{}
+	sourceRequest.Range(func(key, value string) bool {
+		targetHeaders[t.mapHeader(toBolt, key)] = value
+		return true
+	})
+
+	mosnctx.WithValue(ctx, types.ContextSubProtocol, string(bolt.ProtocolName))
+	targetRequest := bolt.NewRpcRequest(0, targetHeaders, buf)
+	return targetRequest, buf, trailers, nil
+}
+
+// TranscodingResponse builds an HTTP response from a bolt one, renaming
+// each header found in HeaderMapping (in reverse) and passing the rest
+// through unchanged.
+func (t *http2boltMapped) TranscodingResponse(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error) {
+	sourceResponse, ok := headers.(*bolt.Response)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("http2bolt_mapped: headers is not a bolt response")
+	}
+
+	t.mu.RLock()
+	toHTTP := t.toHTTP
+	t.mu.RUnlock()
+
+	targetResponse := fasthttp.Response{}
+	sourceResponse.Range(func(key, value string) bool {
+		targetResponse.Header.Set(t.mapHeader(toHTTP, key), value)
+		return true
+	})
+
+	if sourceResponse.ResponseStatus != bolt.ResponseStatusSuccess {
+		targetResponse.SetStatusCode(http.InternalServerError)
+	}
+
+	return http.ResponseHeader{ResponseHeader: &targetResponse.Header}, buf, trailers, nil
+}
+
+func (t *http2boltMapped) mapHeader(mapping map[string]string, key string) string {
+	if mapped, ok := mapping[strings.ToLower(key)]; ok {
+		return mapped
+	}
+	return key
+}
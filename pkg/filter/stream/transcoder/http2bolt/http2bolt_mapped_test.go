@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2bolt
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/pkg/buffer"
+)
+
+func newConfiguredMapped(t *testing.T, mapping []headerMapping) *http2boltMapped {
+	raw := make([]interface{}, 0, len(mapping))
+	for _, m := range mapping {
+		raw = append(raw, map[string]interface{}{
+			"http_header": m.HTTPHeader,
+			"bolt_header": m.BoltHeader,
+		})
+	}
+
+	tr := &http2boltMapped{}
+	if err := tr.Configure(map[string]interface{}{"header_mapping": raw}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	return tr
+}
+
+func TestHttp2boltMapped_Configure(t *testing.T) {
+	tr := &http2boltMapped{}
+	if err := tr.Configure(map[string]interface{}{
+		"header_mapping": []interface{}{
+			map[string]interface{}{"http_header": "X-Service-Name", "bolt_header": "service"},
+		},
+	}); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if tr.toBolt["x-service-name"] != "service" {
+		t.Errorf("toBolt[x-service-name] = %q, want service", tr.toBolt["x-service-name"])
+	}
+	if tr.toHTTP["service"] != "X-Service-Name" {
+		t.Errorf("toHTTP[service] = %q, want X-Service-Name", tr.toHTTP["service"])
+	}
+
+	if err := tr.Configure(map[string]interface{}{
+		"header_mapping": []interface{}{
+			map[string]interface{}{"http_header": "X-Service-Name"},
+		},
+	}); err == nil {
+		t.Error("Configure() with a one-sided mapping entry should error")
+	}
+}
+
+func TestHttp2boltMapped_Accept(t *testing.T) {
+	tr := &http2boltMapped{}
+	if !tr.Accept(context.Background(), buildHttpRequestHeaders(nil), nil, nil) {
+		t.Error("Accept() = false for an http request, want true")
+	}
+	if tr.Accept(context.Background(), &bolt.Request{}, nil, nil) {
+		t.Error("Accept() = true for a non-http header map, want false")
+	}
+}
+
+func TestHttp2boltMapped_TranscodingRequest(t *testing.T) {
+	tr := newConfiguredMapped(t, []headerMapping{
+		{HTTPHeader: "X-Service-Name", BoltHeader: "service"},
+	})
+
+	bufData := buffer.NewIoBufferString("TestHttp2boltMapped_TranscodingRequest")
+	reqHeaders := buildHttpRequestHeaders(map[string]string{"X-Service-Name": "test", "Scene": "ut"})
+
+	got, gotBuf, gotTrailers, err := tr.TranscodingRequest(context.Background(), reqHeaders, bufData, nil)
+	if err != nil {
+		t.Fatalf("TranscodingRequest() error = %v", err)
+	}
+	want := bolt.NewRpcRequest(0, protocol.CommonHeader(map[string]string{"service": "test", "Scene": "ut"}), bufData)
+	if !checkHeadersEqual(got, want) {
+		t.Errorf("TranscodingRequest() headers got = %v, want %v", got, want)
+	}
+	if gotBuf != bufData {
+		t.Errorf("TranscodingRequest() buf got = %v, want %v", gotBuf, bufData)
+	}
+	if gotTrailers != nil {
+		t.Errorf("TranscodingRequest() trailers got = %v, want nil", gotTrailers)
+	}
+}
+
+func TestHttp2boltMapped_TranscodingResponse(t *testing.T) {
+	tr := newConfiguredMapped(t, []headerMapping{
+		{HTTPHeader: "X-Service-Name", BoltHeader: "service"},
+	})
+
+	bufData := buffer.NewIoBufferString("TestHttp2boltMapped_TranscodingResponse")
+	boltResp := bolt.NewRpcResponse(0, bolt.ResponseStatusSuccess, protocol.CommonHeader(map[string]string{"service": "test", "scene": "ut"}), bufData)
+
+	got, gotBuf, gotTrailers, err := tr.TranscodingResponse(context.Background(), boltResp, bufData, nil)
+	if err != nil {
+		t.Fatalf("TranscodingResponse() error = %v", err)
+	}
+	want := buildHttpResponseHeaders(http.OK, map[string]string{"X-Service-Name": "test", "scene": "ut"})
+	if !checkHeadersEqual(got, want) {
+		t.Errorf("TranscodingResponse() headers got = %v, want %v", got, want)
+	}
+	if gotBuf != bufData {
+		t.Errorf("TranscodingResponse() buf got = %v, want %v", gotBuf, bufData)
+	}
+	if gotTrailers != nil {
+		t.Errorf("TranscodingResponse() trailers got = %v, want nil", gotTrailers)
+	}
+
+	failResp := bolt.NewRpcResponse(0, bolt.ResponseStatusServerException, protocol.CommonHeader(map[string]string{"service": "test"}), bufData)
+	got, _, _, err = tr.TranscodingResponse(context.Background(), failResp, bufData, nil)
+	if err != nil {
+		t.Fatalf("TranscodingResponse() error = %v", err)
+	}
+	if got.(http.ResponseHeader).StatusCode() != http.InternalServerError {
+		t.Errorf("TranscodingResponse() status = %d, want %d", got.(http.ResponseHeader).StatusCode(), http.InternalServerError)
+	}
+}
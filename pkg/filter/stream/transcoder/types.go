@@ -35,3 +35,11 @@ type Transcoder interface {
 	// TranscodingResponse
 	TranscodingResponse(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) (types.HeaderMap, types.IoBuffer, types.HeaderMap, error)
 }
+
+// Configurable is implemented by a Transcoder that needs settings beyond the
+// registered type name, e.g. a compiled proto descriptor set. If the
+// Transcoder resolved from a filter's "type" implements it, its Configure is
+// called with that filter's extend_config once, at filter creation.
+type Configurable interface {
+	Configure(cfg map[string]interface{}) error
+}
@@ -32,6 +32,7 @@ const (
 	HealthCheckActiveFailure  = "active_failure"
 	HealthCheckPassiveFailure = "passive_failure"
 	HealthCheckNetworkFailure = "network_failure"
+	HealthCheckTimeoutFailure = "timeout_failure"
 	HealthCheckVeirfyCluster  = "verify_cluster"
 	HealthCheckHealthy        = "healty"
 )
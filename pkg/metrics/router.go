@@ -0,0 +1,36 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import "mosn.io/mosn/pkg/types"
+
+// RouterType represents route-level metrics type
+const RouterType = "router"
+
+// key in a route's weighted clusters
+const (
+	RouterWeightedClusterSelectedTotal = "weighted_cluster_selected_total"
+)
+
+// NewWeightedClusterStats returns a stats with namespace prefix virtual
+// host/cluster, one set per weighted cluster split on a route so each
+// split's share of traffic can be observed independently.
+func NewWeightedClusterStats(virtualHostName string, clusterName string) types.Metrics {
+	metrics, _ := NewMetrics(RouterType, map[string]string{"virtual_host": virtualHostName, "cluster": clusterName})
+	return metrics
+}
@@ -24,7 +24,7 @@ import (
 // UpstreamType represents upstream metrics type
 const UpstreamType = "upstream"
 
-//  key in cluster/host
+// key in cluster/host
 const (
 	UpstreamConnectionTotal                        = "connection_total"
 	UpstreamConnectionClose                        = "connection_close"
@@ -36,6 +36,12 @@ const (
 	UpstreamConnectionLocalCloseWithActiveRequest  = "connection_local_close_with_active_request"
 	UpstreamConnectionRemoteCloseWithActiveRequest = "connection_remote_close_with_active_request"
 	UpstreamConnectionCloseNotify                  = "connection_close_notify"
+	UpstreamConnectionCloseIdle                    = "connection_close_idle"
+	UpstreamConnectionPendingOverflow              = "connection_pending_overflow"
+	UpstreamConnectionIdle                         = "connection_idle"
+	UpstreamConnectionConnectDuration              = "connection_connect_duration"
+	UpstreamConnectionTLSHandshakeDuration         = "connection_tls_handshake_duration"
+	UpstreamRequestQueueDepth                      = "request_queue_depth"
 	UpstreamRequestTotal                           = "request_total"
 	UpstreamRequestActive                          = "request_active"
 	UpstreamRequestLocalReset                      = "request_local_reset"
@@ -47,18 +53,26 @@ const (
 	UpstreamRequestDurationTotal                   = "request_duration_time_total"
 	UpstreamResponseSuccess                        = "response_success"
 	UpstreamResponseFailed                         = "response_failed"
+	UpstreamRequestGRPCTotal                       = "request_grpc_total"
+	UpstreamResponseGRPCSuccess                    = "response_grpc_success"
+	UpstreamResponseGRPCFailed                     = "response_grpc_failed"
 )
 
-//  key in cluster
+// key in cluster
 const (
-	UpstreamRequestRetry         = "request_retry"
-	UpstreamRequestRetryOverflow = "request_retry_overflow"
-	UpstreamLBSubSetsFallBack    = "lb_subsets_fallback"
-	UpstreamLBSubsetsCreated     = "lb_subsets_created"
-	UpstreamBytesReadTotal       = "connection_bytes_read_total"
-	UpstreamBytesReadBuffered    = "connection_bytes_read_buffered"
-	UpstreamBytesWriteTotal      = "connection_bytes_write"
-	UpstreamBytesWriteBuffered   = "connection_bytes_write_buffered"
+	UpstreamRequestRetry           = "request_retry"
+	UpstreamRequestRetryOverflow   = "request_retry_overflow"
+	UpstreamLBSubSetsFallBack      = "lb_subsets_fallback"
+	UpstreamLBSubsetsCreated       = "lb_subsets_created"
+	UpstreamLBHealthyPanic         = "lb_healthy_panic"
+	UpstreamLBCanaryRequest        = "lb_canary_request"
+	UpstreamLBBoundedLoadSpillover = "lb_bounded_load_spillover"
+	UpstreamOutlierEjectTotal      = "outlier_eject_total"
+	UpstreamOutlierUnejectTotal    = "outlier_uneject_total"
+	UpstreamBytesReadTotal         = "connection_bytes_read_total"
+	UpstreamBytesReadBuffered      = "connection_bytes_read_buffered"
+	UpstreamBytesWriteTotal        = "connection_bytes_write"
+	UpstreamBytesWriteBuffered     = "connection_bytes_write_buffered"
 )
 
 // NewHostStats returns a stats that namespace contains cluster and host address
@@ -72,3 +86,43 @@ func NewClusterStats(clusterName string) types.Metrics {
 	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName})
 	return metrics
 }
+
+// key in cluster's circuit breaker resource gauges, one set per routing priority
+const (
+	UpstreamCircuitBreakerConnectionsRemaining     = "circuit_breaker_connections_remaining"
+	UpstreamCircuitBreakerPendingRequestsRemaining = "circuit_breaker_pending_requests_remaining"
+	UpstreamCircuitBreakerRequestsRemaining        = "circuit_breaker_requests_remaining"
+	UpstreamCircuitBreakerRetriesRemaining         = "circuit_breaker_retries_remaining"
+)
+
+// NewClusterResourceStats returns a stats with namespace prefix cluster,
+// scoped to a single routing priority's circuit breaker resources, so a
+// DEFAULT and a HIGH priority threshold set report their remaining
+// capacity as separate gauges.
+func NewClusterResourceStats(clusterName string, priority string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "priority": priority})
+	return metrics
+}
+
+// NewClusterLocalityStats returns a stats with namespace prefix cluster,
+// scoped to a single locality, so each zone's request/error/latency
+// aggregates are exported as their own set of gauges.
+func NewClusterLocalityStats(clusterName string, locality string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "locality": locality})
+	return metrics
+}
+
+// ClusterManagerType represents metrics scoped to the cluster manager
+// itself, rather than to an individual cluster.
+const ClusterManagerType = "cluster_manager"
+
+// key in cluster manager
+const (
+	ClusterManagerWarmingClusters = "warming_clusters"
+)
+
+// NewClusterManagerStats returns a stats with namespace prefix cluster_manager
+func NewClusterManagerStats() types.Metrics {
+	metrics, _ := NewMetrics(ClusterManagerType, nil)
+	return metrics
+}
@@ -1706,6 +1706,15 @@ func (sc *MClientConn) resetStream(se StreamError) error {
 	return nil
 }
 
+// MaxConcurrentStreams returns the peer's SETTINGS_MAX_CONCURRENT_STREAMS,
+// as last advertised in a SETTINGS frame (see processSettings). Safe for
+// concurrent use with everything that mutates it under cc.mu.
+func (cc *MClientConn) MaxConcurrentStreams() uint32 {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.maxConcurrentStreams
+}
+
 func (cc *MClientConn) streamByID(id uint32, andRemove bool) *clientStream {
 	cc.mu.Lock()
 	defer cc.mu.Unlock()
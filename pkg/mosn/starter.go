@@ -360,3 +360,15 @@ func (cmf *clusterManagerFilter) OnCreated(cccb types.ClusterConfigFactoryCb, ch
 	cmf.cccb = cccb
 	cmf.chcb = chcb
 }
+
+func (cmf *clusterManagerFilter) OnClusterAdded(clusterName string) {
+	log.DefaultLogger.Debugf("[mosn] [cluster manager filter] cluster %s added", clusterName)
+}
+
+func (cmf *clusterManagerFilter) OnClusterRemoved(clusterName string) {
+	log.DefaultLogger.Debugf("[mosn] [cluster manager filter] cluster %s removed", clusterName)
+}
+
+func (cmf *clusterManagerFilter) OnHostsChanged(clusterName string, addHosts, delHosts []types.Host) {
+	log.DefaultLogger.Debugf("[mosn] [cluster manager filter] cluster %s hosts changed, %d added, %d removed", clusterName, len(addHosts), len(delHosts))
+}
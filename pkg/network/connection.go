@@ -32,7 +32,9 @@ import (
 	"time"
 
 	"github.com/rcrowley/go-metrics"
+	"golang.org/x/net/ipv4"
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/mtls"
@@ -232,16 +234,23 @@ func (c *connection) attachEventLoop(lctx context.Context) {
 }
 
 func (c *connection) checkUseWriteLoop() bool {
-	tcpAddr, ok := c.remoteAddr.(*net.TCPAddr)
-	if !ok {
-		return false
+	if WriteBatchAll {
+		return true
+	}
+
+	local := false
+	switch addr := c.remoteAddr.(type) {
+	case *net.TCPAddr:
+		local = addr.IP.IsLoopback()
+	case *net.UnixAddr:
+		// a unix domain socket is local to the machine by construction
+		local = true
 	}
-	if tcpAddr.IP.IsLoopback() {
+	if local {
 		log.DefaultLogger.Debugf("[network] [check use writeloop] Connection = %d, Local Address = %+v, Remote Address = %+v",
 			c.id, c.rawConnection.LocalAddr(), c.RemoteAddr())
-		return true
 	}
-	return false
+	return local
 }
 
 func (c *connection) startRWLoop(lctx context.Context) {
@@ -810,6 +819,39 @@ func (c *connection) NextProtocol() string {
 	return ""
 }
 
+// applyConnectionOptions applies connOptions to the freshly dialed raw TCP
+// connection: TCP_NODELAY, SO_KEEPALIVE and TOS/DSCP marking. Source address
+// binding is handled earlier, by Connect's net.Dialer, since it has to be
+// set before dialing rather than after.
+func (cc *clientConnection) applyConnectionOptions() {
+	tc, ok := cc.rawConnection.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	opts := cc.connOptions
+	tc.SetNoDelay(!opts.DisableNoDelay)
+
+	if ka := opts.TCPKeepAlive; ka.Time.Duration > 0 || ka.Interval.Duration > 0 || ka.Probes > 0 {
+		tc.SetKeepAlive(true)
+		if ka.Time.Duration > 0 {
+			tc.SetKeepAlivePeriod(ka.Time.Duration)
+		}
+		if ka.Interval.Duration > 0 || ka.Probes > 0 {
+			if rawConn, err := tc.SyscallConn(); err == nil {
+				setTCPKeepAliveIntervalAndProbes(rawConn, ka.Interval.Duration, ka.Probes)
+			}
+		}
+	}
+
+	if opts.Tos > 0 {
+		if p := ipv4.NewConn(tc); p != nil {
+			if err := p.SetTOS(opts.Tos); err != nil {
+				log.DefaultLogger.Errorf("[network] [client connection connect] set TOS failed, remote address = %s, error = %v", cc.remoteAddr, err)
+			}
+		}
+	}
+}
+
 func (c *connection) SetNoDelay(enable bool) {
 	if c.rawConnection != nil {
 
@@ -905,9 +947,13 @@ func (c *connection) State() api.ConnState {
 type clientConnection struct {
 	connection
 
-	connectTimeout time.Duration
+	connectTimeout       time.Duration
+	connOptions          v2.UpstreamConnectionOptions
+	remoteAddrCandidates []net.Addr
 
 	connectOnce sync.Once
+
+	tlsHandshakeDuration time.Duration
 }
 
 // NewClientConnection new client-side connection
@@ -944,6 +990,87 @@ func NewClientConnection(sourceAddr net.Addr, connectTimeout time.Duration, tlsM
 	return conn
 }
 
+// SetConnectionOptions sets the socket options Connect applies once it
+// dials the raw TCP connection: source address binding, TCP_NODELAY,
+// SO_KEEPALIVE and TOS/DSCP marking. Must be called before Connect.
+func (cc *clientConnection) SetConnectionOptions(opts v2.UpstreamConnectionOptions) {
+	cc.connOptions = opts
+}
+
+func (cc *clientConnection) SetRemoteAddrCandidates(addrs []net.Addr) {
+	cc.remoteAddrCandidates = addrs
+}
+
+// TLSHandshakeDuration returns how long the TLS handshake performed by
+// Connect took, or zero for a plaintext connection or one that has not
+// finished connecting yet.
+func (cc *clientConnection) TLSHandshakeDuration() time.Duration {
+	return cc.tlsHandshakeDuration
+}
+
+// happyEyeballsStagger is the delay before racing the next address
+// candidate, RFC 8305's recommended default "Connection Attempt Delay".
+const happyEyeballsStagger = 250 * time.Millisecond
+
+// dial connects to RemoteAddr, or, when Connect is preceded by
+// SetRemoteAddrCandidates with more than one address, races every
+// candidate per RFC 8305: candidates are dialed in order with a
+// happyEyeballsStagger delay between attempts, and whichever connects first
+// wins, with the rest closed once they complete. The winning address
+// becomes RemoteAddr for the rest of the connection's lifetime.
+func (cc *clientConnection) dial(dialer net.Dialer) (net.Conn, error) {
+	if len(cc.remoteAddrCandidates) <= 1 {
+		return dialer.Dial(cc.RemoteAddr().Network(), cc.RemoteAddr().String())
+	}
+
+	conn, addr, err := dialHappyEyeballs(dialer, cc.remoteAddrCandidates)
+	if err == nil {
+		cc.remoteAddr = addr
+	}
+	return conn, err
+}
+
+func dialHappyEyeballs(dialer net.Dialer, candidates []net.Addr) (net.Conn, net.Addr, error) {
+	type dialResult struct {
+		conn net.Conn
+		addr net.Addr
+		err  error
+	}
+
+	results := make(chan dialResult, len(candidates))
+	for i, addr := range candidates {
+		i, addr := i, addr
+		time.AfterFunc(time.Duration(i)*happyEyeballsStagger, func() {
+			conn, err := dialer.Dial(addr.Network(), addr.String())
+			results <- dialResult{conn: conn, addr: addr, err: err}
+		})
+	}
+
+	var firstErr error
+	for i := 0; i < len(candidates); i++ {
+		res := <-results
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+
+		// a candidate won the race: the rest may still be in flight, close
+		// whatever they eventually produce instead of blocking on them here
+		remaining := len(candidates) - i - 1
+		utils.GoWithRecover(func() {
+			for j := 0; j < remaining; j++ {
+				if res := <-results; res.conn != nil {
+					res.conn.Close()
+				}
+			}
+		}, nil)
+		return res.conn, res.addr, nil
+	}
+	return nil, nil, firstErr
+}
+
 func (cc *clientConnection) Connect() (err error) {
 	cc.connectOnce.Do(func() {
 		var event api.ConnectionEvent
@@ -955,11 +1082,22 @@ func (cc *clientConnection) Connect() (err error) {
 
 		addr := cc.RemoteAddr()
 		if addr != nil {
-			cc.rawConnection, err = net.DialTimeout("tcp", cc.RemoteAddr().String(), timeout)
+			dialer := net.Dialer{Timeout: timeout}
+			if cc.localAddr != nil {
+				dialer.LocalAddr = cc.localAddr
+			}
+			if mark := cc.connOptions.Mark; mark != 0 {
+				dialer.Control = controlWithSocketMark(mark)
+			}
+			cc.rawConnection, err = cc.dial(dialer)
 		} else {
 			err = errors.New("ClientConnection RemoteAddr is nil")
 		}
 
+		if err == nil {
+			cc.applyConnectionOptions()
+		}
+
 		if err != nil {
 			if err == io.EOF {
 				// remote conn closed
@@ -990,6 +1128,21 @@ func (cc *clientConnection) Connect() (err error) {
 
 			}
 
+			if err == nil {
+				// tls.Client wraps the raw connection lazily: the actual
+				// handshake is normally deferred to the first Read/Write.
+				// Do it eagerly here so its cost is visible at connect time
+				// instead of being charged to the first request, and so it
+				// can be timed separately from the TCP connect itself.
+				if hs, ok := cc.rawConnection.(interface{ Handshake() error }); ok {
+					start := time.Now()
+					err = hs.Handshake()
+					if err == nil {
+						cc.tlsHandshakeDuration = time.Since(start)
+					}
+				}
+			}
+
 			if err != nil {
 				event = api.ConnectFailed
 				cc.rawConnection.Close()
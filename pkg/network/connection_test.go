@@ -22,10 +22,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"golang.org/x/sys/unix"
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 )
 
 type MyEventListener struct{}
@@ -121,6 +124,25 @@ func TestConnectTimeout(t *testing.T) {
 	}
 }
 
+// TestCheckUseWriteLoopHonorsWriteBatchAll checks that WriteBatchAll opts a
+// non-loopback connection into the write loop too, on top of the existing
+// loopback/unix-socket cases.
+func TestCheckUseWriteLoopHonorsWriteBatchAll(t *testing.T) {
+	remoteAddr, _ := net.ResolveTCPAddr("tcp", "2.2.2.2:22222")
+	cc := NewClientConnection(nil, 0, nil, remoteAddr, nil).(*clientConnection)
+
+	if cc.checkUseWriteLoop() {
+		t.Fatal("expected a non-loopback connection not to use the write loop by default")
+	}
+
+	WriteBatchAll = true
+	defer func() { WriteBatchAll = false }()
+
+	if !cc.checkUseWriteLoop() {
+		t.Fatal("expected WriteBatchAll to opt every connection into the write loop")
+	}
+}
+
 func TestClientConectionRemoteaddrIsNil(t *testing.T) {
 	conn := NewClientConnection(nil, 0, nil, nil, nil)
 	err := conn.Connect()
@@ -130,6 +152,270 @@ func TestClientConectionRemoteaddrIsNil(t *testing.T) {
 	}
 }
 
+// TestClientConnectionBindsSourceAddress checks that NewClientConnection's
+// sourceAddr is actually used to bind the local address before dialing,
+// rather than silently ignored as it used to be.
+func TestClientConnectionBindsSourceAddress(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	sourceAddr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:0")
+	conn := NewClientConnection(sourceAddr, time.Second, nil, ln.Addr(), nil)
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer conn.Close(api.NoFlush, api.LocalClose)
+
+	local := conn.LocalAddr().(*net.TCPAddr)
+	if !local.IP.IsLoopback() {
+		t.Errorf("expected connection to be bound to the loopback source address, got %v", local)
+	}
+}
+
+// TestClientConnectionAppliesConnectionOptions checks that SetConnectionOptions
+// is honored by Connect: DisableNoDelay should leave Nagle's algorithm enabled
+// instead of the default disabled.
+func TestClientConnectionAppliesConnectionOptions(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	conn := NewClientConnection(nil, time.Second, nil, ln.Addr(), nil)
+	conn.SetConnectionOptions(v2.UpstreamConnectionOptions{DisableNoDelay: true})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer conn.Close(api.NoFlush, api.LocalClose)
+
+	rawConn := conn.RawConn()
+	tc, ok := rawConn.(*net.TCPConn)
+	if !ok {
+		t.Fatalf("expected raw connection to be a *net.TCPConn, got %T", rawConn)
+	}
+	sc, err := tc.SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn failed: %v", err)
+	}
+	var noDelay int
+	var getErr error
+	if err := sc.Control(func(fd uintptr) {
+		noDelay, getErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_NODELAY)
+	}); err != nil {
+		t.Fatalf("Control failed: %v", err)
+	}
+	if getErr != nil {
+		t.Fatalf("GetsockoptInt failed: %v", getErr)
+	}
+	if noDelay != 0 {
+		t.Errorf("expected DisableNoDelay to leave TCP_NODELAY unset, got %d", noDelay)
+	}
+}
+
+// fakeHandshakeConn wraps a net.Conn with an explicit Handshake method, the
+// same shape mtls.TLSConn exposes via its embedded *tls.Conn, so Connect can
+// be tested against it without pulling in the real TLS stack.
+type fakeHandshakeConn struct {
+	net.Conn
+	handshakeDelay time.Duration
+	handshakeErr   error
+	handshakeCalls int
+}
+
+func (c *fakeHandshakeConn) Handshake() error {
+	c.handshakeCalls++
+	time.Sleep(c.handshakeDelay)
+	return c.handshakeErr
+}
+
+type fakeTLSContextManager struct {
+	conn *fakeHandshakeConn
+	err  error
+}
+
+func (m *fakeTLSContextManager) Conn(c net.Conn) (net.Conn, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.conn.Conn = c
+	return m.conn, nil
+}
+
+func (m *fakeTLSContextManager) Enabled() bool { return true }
+
+// TestClientConnectionPerformsTLSHandshakeEagerly checks that Connect drives
+// the TLS handshake itself, rather than leaving it to the first Read/Write,
+// and records how long it took.
+func TestClientConnectionPerformsTLSHandshakeEagerly(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	tlsMng := &fakeTLSContextManager{conn: &fakeHandshakeConn{handshakeDelay: 10 * time.Millisecond}}
+	conn := NewClientConnection(nil, time.Second, tlsMng, ln.Addr(), nil)
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer conn.Close(api.NoFlush, api.LocalClose)
+
+	if tlsMng.conn.handshakeCalls != 1 {
+		t.Fatalf("expected Connect to trigger exactly one handshake, got %d", tlsMng.conn.handshakeCalls)
+	}
+	if d := conn.(*clientConnection).TLSHandshakeDuration(); d < 10*time.Millisecond {
+		t.Errorf("expected TLSHandshakeDuration to reflect the handshake delay, got %v", d)
+	}
+}
+
+// TestClientConnectionFailsOnTLSHandshakeError checks that a failed eager
+// handshake fails Connect, instead of surfacing the error later on first use.
+func TestClientConnectionFailsOnTLSHandshakeError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	tlsMng := &fakeTLSContextManager{conn: &fakeHandshakeConn{handshakeErr: fmt.Errorf("handshake refused")}}
+	conn := NewClientConnection(nil, time.Second, tlsMng, ln.Addr(), nil)
+	if err := conn.Connect(); err == nil {
+		t.Fatal("expected Connect to fail when the TLS handshake fails")
+	}
+}
+
+// TestDialHappyEyeballsPicksFirstSuccess checks that dialHappyEyeballs
+// returns the first candidate to connect even when it is not the first one
+// listed, and reports which address actually won.
+func TestDialHappyEyeballsPicksFirstSuccess(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	// TEST-NET-1 (RFC 5737): reserved for documentation, never routed, so
+	// the dial to it just hangs until the dialer's timeout fires.
+	unreachable, _ := net.ResolveTCPAddr("tcp", "192.0.2.1:1")
+	good := ln.Addr()
+
+	conn, addr, err := dialHappyEyeballs(net.Dialer{Timeout: 2 * time.Second}, []net.Addr{unreachable, good})
+	if err != nil {
+		t.Fatalf("expected the reachable candidate to win the race, got error: %v", err)
+	}
+	defer conn.Close()
+	if addr.String() != good.String() {
+		t.Errorf("expected winning address %v, got %v", good, addr)
+	}
+}
+
+func TestDialHappyEyeballsReturnsErrorWhenAllFail(t *testing.T) {
+	a, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:1")
+	b, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:2")
+
+	if _, _, err := dialHappyEyeballs(net.Dialer{Timeout: time.Second}, []net.Addr{a, b}); err == nil {
+		t.Fatal("expected an error when every candidate fails")
+	}
+}
+
+// TestClientConnectionRacesRemoteAddrCandidates checks that Connect, once
+// SetRemoteAddrCandidates is given more than one address, dials all of them
+// and updates RemoteAddr to whichever one actually connected.
+func TestClientConnectionRacesRemoteAddrCandidates(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	unreachable, _ := net.ResolveTCPAddr("tcp", "192.0.2.1:1")
+	good := ln.Addr()
+
+	conn := NewClientConnection(nil, 2*time.Second, nil, unreachable, nil)
+	conn.SetRemoteAddrCandidates([]net.Addr{unreachable, good})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer conn.Close(api.NoFlush, api.LocalClose)
+
+	if conn.RemoteAddr().String() != good.String() {
+		t.Errorf("expected RemoteAddr to be updated to the winning candidate %v, got %v", good, conn.RemoteAddr())
+	}
+}
+
+// TestClientConnectionDialsUnixSocket checks that Connect dials whatever
+// network RemoteAddr.Network() reports, not just "tcp", so a
+// *net.UnixAddr host connects over a unix domain socket.
+func TestClientConnectionDialsUnixSocket(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "mosn-test.sock")
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	remoteAddr, err := net.ResolveUnixAddr("unix", sockPath)
+	if err != nil {
+		t.Fatalf("resolve unix addr failed: %v", err)
+	}
+
+	conn := NewClientConnection(nil, time.Second, nil, remoteAddr, nil)
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer conn.Close(api.NoFlush, api.LocalClose)
+
+	if _, ok := conn.RawConn().(*net.UnixConn); !ok {
+		t.Fatalf("expected a *net.UnixConn, got %T", conn.RawConn())
+	}
+}
+
 type zeroReadConn struct {
 	net.Conn
 }
@@ -6,15 +6,22 @@ import (
 )
 
 func init() {
-	ConnNewPoolFactories = make(map[types.ProtocolName]connNewPool)
+	ConnNewBoundPoolFactories = make(map[types.ProtocolName]connNewPool)
 }
 
 type connNewPool func(host types.Host) types.ConnectionPool
 
-var ConnNewPoolFactories map[types.ProtocolName]connNewPool
+// ConnNewBoundPoolFactories holds, per protocol, a factory that builds a
+// stream.BoundConnPool instead of the protocol's regular pool registered
+// via types.RegisterConnPoolFactory. It is used for clusters with
+// ConnectionAffinity enabled, where a caller owning a single downstream
+// connection needs a pool that binds one upstream connection to that
+// connection's lifetime.
+var ConnNewBoundPoolFactories map[types.ProtocolName]connNewPool
 
-func RegisterNewPoolFactory(protocol types.ProtocolName, factory connNewPool) {
-	//other
-	log.DefaultLogger.Infof("[network] [ register pool factory] register protocol: %v factory", protocol)
-	ConnNewPoolFactories[protocol] = factory
+// RegisterNewBoundPoolFactory registers factory as the ConnectionAffinity
+// pool constructor for protocol.
+func RegisterNewBoundPoolFactory(protocol types.ProtocolName, factory connNewPool) {
+	log.DefaultLogger.Infof("[network] [ register pool factory] register protocol: %v bound pool factory", protocol)
+	ConnNewBoundPoolFactories[protocol] = factory
 }
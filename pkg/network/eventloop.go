@@ -31,6 +31,14 @@ var (
 	// UseNetpollMode indicates which mode should be used for connection IO processing
 	UseNetpollMode = false
 
+	// WriteBatchAll makes every connection - not just loopback ones - use a
+	// dedicated write loop that buffers concurrent Write() calls and flushes
+	// them together via net.Buffers/writev (see connection.startWriteLoop),
+	// trading one extra goroutine per connection for fewer write syscalls
+	// under high concurrent write load. Off by default, matching
+	// UseNetpollMode's opt-in convention.
+	WriteBatchAll = false
+
 	// read/write goroutine pool
 	readPool  = mosnsync.NewWorkerPool(runtime.NumCPU())
 	writePool = mosnsync.NewWorkerPool(runtime.NumCPU())
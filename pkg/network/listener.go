@@ -19,6 +19,7 @@ package network
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
 	"runtime/debug"
@@ -219,6 +220,16 @@ func (l *listener) Close(lctx context.Context) error {
 func (l *listener) listen(lctx context.Context) error {
 	var err error
 
+	// QUIC listeners are accepted as config (v2.ListenerNetworkQUIC) but
+	// not implemented: this listener is built on net.ListenTCP/Accept,
+	// and there's no UDP socket, connection-ID routing, or HTTP/3 codec
+	// anywhere in mosn to back a real QUIC listener with today. Fail
+	// fast here instead of silently opening a TCP socket for a listener
+	// the operator asked to speak QUIC.
+	if l.config != nil && l.config.Network == v2.ListenerNetworkQUIC {
+		return fmt.Errorf("listener %s: network %q is not implemented", l.name, l.config.Network)
+	}
+
 	var rawl *net.TCPListener
 	if rawl, err = net.ListenTCP("tcp", l.localAddress.(*net.TCPAddr)); err != nil {
 		return err
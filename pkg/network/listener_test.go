@@ -102,3 +102,18 @@ func TestListenerStart(t *testing.T) {
 	}
 
 }
+
+func TestListenerListenRejectsQUIC(t *testing.T) {
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:0")
+	cfg := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name:    "quic_listener",
+			Network: v2.ListenerNetworkQUIC,
+		},
+		Addr: addr,
+	}
+	ln := NewListener(cfg).(*listener)
+	if err := ln.listen(nil); err == nil {
+		t.Error("listen() on a quic network listener should fail, got nil error")
+	}
+}
@@ -0,0 +1,46 @@
+// +build linux
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"mosn.io/mosn/pkg/log"
+)
+
+// controlWithSocketMark returns a net.Dialer.Control func that sets SO_MARK
+// on the dialed socket before it connects, so host firewall/policy rules or
+// ip rule based routing can identify and steer this connection's traffic.
+func controlWithSocketMark(mark int) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_MARK, mark)
+		})
+		if sockErr != nil {
+			log.DefaultLogger.Errorf("[network] set SO_MARK failed, mark = %d, error = %v", mark, sockErr)
+		}
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+}
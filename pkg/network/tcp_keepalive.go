@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"mosn.io/mosn/pkg/log"
+)
+
+// setTCPKeepAliveIntervalAndProbes sets TCP_KEEPINTVL and TCP_KEEPCNT on a
+// connection whose SO_KEEPALIVE is already enabled. net.TCPConn only
+// exposes SetKeepAlivePeriod (TCP_KEEPIDLE), so the interval and probe
+// count need to be set directly on the raw fd.
+func setTCPKeepAliveIntervalAndProbes(rawConn syscall.RawConn, interval time.Duration, probes uint32) {
+	err := rawConn.Control(func(fd uintptr) {
+		if interval > 0 {
+			secs := int(interval.Seconds())
+			if secs < 1 {
+				secs = 1
+			}
+			if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, secs); err != nil {
+				log.DefaultLogger.Errorf("[network] set TCP_KEEPINTVL failed, error = %v", err)
+			}
+		}
+		if probes > 0 {
+			if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPCNT, int(probes)); err != nil {
+				log.DefaultLogger.Errorf("[network] set TCP_KEEPCNT failed, error = %v", err)
+			}
+		}
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("[network] set TCP keepalive options failed, error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// gRPC rides on plain HTTP/2, identified by its content-type, rather than
+// being a distinct MOSN protocol. These helpers let the generic HTTP/2 proxy
+// path recognize gRPC traffic well enough to preserve its semantics: the
+// grpc-status trailer is the request's real outcome even though the HTTP/2
+// :status stays 200, so a locally-generated failure has to carry a
+// grpc-status of its own instead of just an HTTP status code.
+const (
+	// GRPCContentTypePrefix identifies a gRPC request/response by its
+	// content-type header, e.g. "application/grpc" or "application/grpc+proto".
+	GRPCContentTypePrefix = "application/grpc"
+
+	HeaderGRPCStatus  = "grpc-status"
+	HeaderGRPCMessage = "grpc-message"
+)
+
+// gRPC canonical status codes MOSN maps upstream failures to. See
+// https://github.com/grpc/grpc/blob/master/doc/statuscodes.md.
+const (
+	GRPCStatusOK                = 0
+	GRPCStatusCancelled         = 1
+	GRPCStatusUnknown           = 2
+	GRPCStatusDeadlineExceeded  = 4
+	GRPCStatusResourceExhausted = 8
+	GRPCStatusInternal          = 13
+	GRPCStatusUnavailable       = 14
+)
+
+// IsGRPCRequest reports whether headers carry a gRPC content-type.
+func IsGRPCRequest(headers api.HeaderMap) bool {
+	if headers == nil {
+		return false
+	}
+	ct, ok := headers.Get("content-type")
+	return ok && strings.HasPrefix(ct, GRPCContentTypePrefix)
+}
+
+// GRPCStatusFromHeaderMap extracts and parses the grpc-status header from
+// headers, which may be either the response trailers (the common case) or,
+// for a trailers-only response, the response headers themselves. Returns
+// false if headers carries no grpc-status.
+func GRPCStatusFromHeaderMap(headers api.HeaderMap) (int, bool) {
+	if headers == nil {
+		return 0, false
+	}
+	v, ok := headers.Get(HeaderGRPCStatus)
+	if !ok {
+		return 0, false
+	}
+	status, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return status, true
+}
+
+// GRPCStatusFromResetReason maps a stream reset reason to the gRPC status
+// code that best describes it to a gRPC client, mirroring how
+// types.ConvertReasonToCode maps the same reasons to an HTTP status.
+func GRPCStatusFromResetReason(reason types.StreamResetReason) int {
+	switch reason {
+	case types.UpstreamGlobalTimeout, types.UpstreamPerTryTimeout:
+		return GRPCStatusDeadlineExceeded
+	case types.StreamOverflow:
+		return GRPCStatusResourceExhausted
+	case types.StreamRemoteReset, types.UpstreamReset, types.StreamLocalReset, types.StreamConnectionFailed:
+		return GRPCStatusUnavailable
+	case types.StreamConnectionSuccessed:
+		return GRPCStatusOK
+	default:
+		return GRPCStatusUnknown
+	}
+}
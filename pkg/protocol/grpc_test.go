@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestIsGRPCRequest(t *testing.T) {
+	if IsGRPCRequest(nil) {
+		t.Error("expected nil headers to not be gRPC")
+	}
+	if IsGRPCRequest(CommonHeader{"content-type": "application/json"}) {
+		t.Error("expected a non-gRPC content-type to not be gRPC")
+	}
+	if !IsGRPCRequest(CommonHeader{"content-type": "application/grpc"}) {
+		t.Error("expected application/grpc to be gRPC")
+	}
+	if !IsGRPCRequest(CommonHeader{"content-type": "application/grpc+proto"}) {
+		t.Error("expected application/grpc+proto to be gRPC")
+	}
+}
+
+func TestGRPCStatusFromHeaderMap(t *testing.T) {
+	if _, ok := GRPCStatusFromHeaderMap(nil); ok {
+		t.Error("expected nil headers to have no grpc-status")
+	}
+	if _, ok := GRPCStatusFromHeaderMap(CommonHeader{}); ok {
+		t.Error("expected missing grpc-status to be absent")
+	}
+	if _, ok := GRPCStatusFromHeaderMap(CommonHeader{HeaderGRPCStatus: "not-a-number"}); ok {
+		t.Error("expected an unparsable grpc-status to be absent")
+	}
+	status, ok := GRPCStatusFromHeaderMap(CommonHeader{HeaderGRPCStatus: "13"})
+	if !ok || status != GRPCStatusInternal {
+		t.Errorf("expected grpc-status 13, got %d, ok = %v", status, ok)
+	}
+}
+
+func TestGRPCStatusFromResetReason(t *testing.T) {
+	testCases := []struct {
+		reason types.StreamResetReason
+		want   int
+	}{
+		{types.UpstreamGlobalTimeout, GRPCStatusDeadlineExceeded},
+		{types.UpstreamPerTryTimeout, GRPCStatusDeadlineExceeded},
+		{types.StreamOverflow, GRPCStatusResourceExhausted},
+		{types.StreamRemoteReset, GRPCStatusUnavailable},
+		{types.UpstreamReset, GRPCStatusUnavailable},
+		{types.StreamLocalReset, GRPCStatusUnavailable},
+		{types.StreamConnectionFailed, GRPCStatusUnavailable},
+		{types.StreamConnectionSuccessed, GRPCStatusOK},
+	}
+	for _, tc := range testCases {
+		if got := GRPCStatusFromResetReason(tc.reason); got != tc.want {
+			t.Errorf("reason %v: expected %d, got %d", tc.reason, tc.want, got)
+		}
+	}
+}
@@ -48,6 +48,7 @@ func decodeRequest(ctx context.Context, data types.IoBuffer, oneway bool) (cmd i
 		return
 	}
 	data.Drain(frameLen)
+	countFrame()
 
 	// 3. decode header
 	buf := bufferByContext(ctx)
@@ -118,6 +119,7 @@ func decodeResponse(ctx context.Context, data types.IoBuffer) (cmd interface{},
 		return
 	}
 	data.Drain(frameLen)
+	countFrame()
 
 	// 3. decode header
 	buf := bufferByContext(ctx)
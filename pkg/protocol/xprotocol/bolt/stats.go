@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bolt
+
+import (
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+// MetricsType is the metrics.NewMetrics type bolt registers its frame
+// counter under: one process-wide instance, since the codec isn't
+// configured per-listener the way a network filter's stat_prefix is.
+const MetricsType = "bolt"
+
+const statFrameTotal = "frame.total"
+
+var protocolStats types.Metrics
+
+func init() {
+	stats, err := metrics.NewMetrics(MetricsType, nil)
+	if err != nil {
+		log.DefaultLogger.Errorf("[protocol] [bolt] [stats] create stats: %v", err)
+		return
+	}
+	protocolStats = stats
+}
+
+// countFrame tracks how many complete bolt (v1) frames have been decoded.
+// boltv2 keeps its own counter under a distinct metrics type, so operators
+// can tell v1 and v2 traffic apart instead of both folding into one
+// generic "bolt" number.
+func countFrame() {
+	if protocolStats == nil {
+		return
+	}
+	protocolStats.Counter(statFrameTotal).Inc(1)
+}
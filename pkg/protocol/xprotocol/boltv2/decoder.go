@@ -20,6 +20,7 @@ package boltv2
 import (
 	"context"
 	"encoding/binary"
+	"hash/crc32"
 	"strconv"
 
 	"mosn.io/mosn/pkg/variable"
@@ -30,6 +31,17 @@ import (
 	"mosn.io/pkg/buffer"
 )
 
+// checkCrc verifies the CRC32 trailer of a frame whose SwitchCode enabled
+// it: bytes[:frameLen-CrcLen] is the checksummed content, the last CrcLen
+// bytes are the checksum itself.
+func checkCrc(bytes []byte, frameLen int) error {
+	sum := binary.BigEndian.Uint32(bytes[frameLen-CrcLen:])
+	if crc32.ChecksumIEEE(bytes[:frameLen-CrcLen]) != sum {
+		return ErrCrcMismatch
+	}
+	return nil
+}
+
 func decodeRequest(ctx context.Context, data types.IoBuffer, oneway bool) (cmd interface{}, err error) {
 	bytesLen := data.Len()
 	bytes := data.Bytes()
@@ -43,12 +55,24 @@ func decodeRequest(ctx context.Context, data types.IoBuffer, oneway bool) (cmd i
 	classLen := binary.BigEndian.Uint16(bytes[16:18])
 	headerLen := binary.BigEndian.Uint16(bytes[18:20])
 	contentLen := binary.BigEndian.Uint32(bytes[20:24])
+	switchCode := bytes[11]
 
 	frameLen := RequestHeaderLen + int(classLen) + int(headerLen) + int(contentLen)
+	if IsCrcEnable(switchCode) {
+		frameLen += CrcLen
+	}
 	if bytesLen < frameLen {
 		return
 	}
+	if IsCrcEnable(switchCode) {
+		if err = checkCrc(bytes, frameLen); err != nil {
+			data.Drain(frameLen)
+			countFrame()
+			return nil, err
+		}
+	}
 	data.Drain(frameLen)
+	countFrame()
 
 	// 3. decode header
 	buf := bufferByContext(ctx)
@@ -63,7 +87,7 @@ func decodeRequest(ctx context.Context, data types.IoBuffer, oneway bool) (cmd i
 		RequestHeader: bolt.RequestHeader{
 			Protocol:   ProtocolCode,
 			CmdType:    cmdType,
-			CmdCode:    binary.BigEndian.Uint16(bytes[2:4]),
+			CmdCode:    binary.BigEndian.Uint16(bytes[3:5]),
 			Version:    bytes[5],
 			RequestId:  binary.BigEndian.Uint32(bytes[6:10]),
 			Codec:      bytes[10],
@@ -73,7 +97,7 @@ func decodeRequest(ctx context.Context, data types.IoBuffer, oneway bool) (cmd i
 			ContentLen: contentLen,
 		},
 		Version1:   bytes[1],
-		SwitchCode: bytes[11],
+		SwitchCode: switchCode,
 	}
 	request.Data = buffer.GetIoBuffer(frameLen)
 
@@ -98,7 +122,7 @@ func decodeRequest(ctx context.Context, data types.IoBuffer, oneway bool) (cmd i
 		err = xprotocol.DecodeHeader(request.rawHeader, &request.Header)
 	}
 	if contentLen > 0 {
-		request.rawContent = request.rawData[contentIndex:]
+		request.rawContent = request.rawData[contentIndex : contentIndex+int(contentLen)]
 		request.Content = buffer.NewIoBufferBytes(request.rawContent)
 	}
 	return request, err
@@ -117,12 +141,24 @@ func decodeResponse(ctx context.Context, data types.IoBuffer) (cmd interface{},
 	classLen := binary.BigEndian.Uint16(bytes[14:16])
 	headerLen := binary.BigEndian.Uint16(bytes[16:18])
 	contentLen := binary.BigEndian.Uint32(bytes[18:22])
+	switchCode := bytes[11]
 
 	frameLen := ResponseHeaderLen + int(classLen) + int(headerLen) + int(contentLen)
+	if IsCrcEnable(switchCode) {
+		frameLen += CrcLen
+	}
 	if bytesLen < frameLen {
 		return
 	}
+	if IsCrcEnable(switchCode) {
+		if err = checkCrc(bytes, frameLen); err != nil {
+			data.Drain(frameLen)
+			countFrame()
+			return nil, err
+		}
+	}
 	data.Drain(frameLen)
+	countFrame()
 
 	// 3. decode header
 	buf := bufferByContext(ctx)
@@ -141,7 +177,7 @@ func decodeResponse(ctx context.Context, data types.IoBuffer) (cmd interface{},
 			HeaderLen:      headerLen,
 			ContentLen:     contentLen,
 		},
-		SwitchCode: bytes[11],
+		SwitchCode: switchCode,
 		Version1:   bytes[1],
 	}
 	response.Data = buffer.GetIoBuffer(frameLen)
@@ -165,7 +201,7 @@ func decodeResponse(ctx context.Context, data types.IoBuffer) (cmd interface{},
 		err = xprotocol.DecodeHeader(response.rawHeader, &response.Header)
 	}
 	if contentLen > 0 {
-		response.rawContent = response.rawData[contentIndex:]
+		response.rawContent = response.rawData[contentIndex : contentIndex+int(contentLen)]
 		response.Content = buffer.NewIoBufferBytes(response.rawContent)
 	}
 	return response, err
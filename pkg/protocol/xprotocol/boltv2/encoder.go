@@ -20,17 +20,36 @@ package boltv2
 import (
 	"context"
 	"encoding/binary"
+	"hash/crc32"
 
 	"mosn.io/mosn/pkg/protocol/xprotocol"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 )
 
+// rewriteCrc recomputes and overwrites the trailing CRC32 of raw bytes
+// already carrying one, needed whenever the fast path patches a field (the
+// requestId) that the checksum covers.
+func rewriteCrc(raw []byte) {
+	sumAt := len(raw) - CrcLen
+	binary.BigEndian.PutUint32(raw[sumAt:], crc32.ChecksumIEEE(raw[:sumAt]))
+}
+
+// writeCrc appends the CRC32 checksum of everything written to buf so far.
+func writeCrc(buf types.IoBuffer) {
+	var crc [CrcLen]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.ChecksumIEEE(buf.Bytes()))
+	buf.Write(crc[:])
+}
+
 func encodeRequest(ctx context.Context, request *Request) (types.IoBuffer, error) {
 	// 1. fast-path, use existed raw data
 	if request.rawData != nil {
 		// 1. replace requestId
 		binary.BigEndian.PutUint32(request.rawMeta[RequestIdIndex:], request.RequestId)
+		if IsCrcEnable(request.SwitchCode) {
+			rewriteCrc(request.rawData)
+		}
 
 		// 1.2 check if header/content changed
 		if !request.Header.Changed && !request.ContentChanged {
@@ -53,6 +72,9 @@ func encodeRequest(ctx context.Context, request *Request) (types.IoBuffer, error
 		request.ContentLen = uint32(request.Content.Len())
 	}
 	frameLen := RequestHeaderLen + int(request.ClassLen) + int(request.HeaderLen) + int(request.ContentLen)
+	if IsCrcEnable(request.SwitchCode) {
+		frameLen += CrcLen
+	}
 
 	// 2.2 alloc encode buffer, this buffer will be recycled after connection.Write
 	buf := buffer.GetIoBuffer(frameLen)
@@ -84,6 +106,10 @@ func encodeRequest(ctx context.Context, request *Request) (types.IoBuffer, error
 		// use request.Content.WriteTo might have error under retry scene
 		buf.Write(request.Content.Bytes())
 	}
+	// 2.3.5 crc32, when the switch enables it
+	if IsCrcEnable(request.SwitchCode) {
+		writeCrc(buf)
+	}
 
 	return buf, nil
 }
@@ -93,6 +119,9 @@ func encodeResponse(ctx context.Context, response *Response) (types.IoBuffer, er
 	if response.rawData != nil {
 		// 1. replace requestId
 		binary.BigEndian.PutUint32(response.rawMeta[RequestIdIndex:], uint32(response.RequestId))
+		if IsCrcEnable(response.SwitchCode) {
+			rewriteCrc(response.rawData)
+		}
 
 		// 1.2 check if header/content changed
 		if !response.Header.Changed && !response.ContentChanged {
@@ -115,6 +144,9 @@ func encodeResponse(ctx context.Context, response *Response) (types.IoBuffer, er
 		response.ContentLen = uint32(response.Content.Len())
 	}
 	frameLen := ResponseHeaderLen + int(response.ClassLen) + int(response.HeaderLen) + int(response.ContentLen)
+	if IsCrcEnable(response.SwitchCode) {
+		frameLen += CrcLen
+	}
 
 	// 2.2 alloc encode buffer, this buffer will be recycled after connection.Write
 	buf := buffer.GetIoBuffer(frameLen)
@@ -146,6 +178,10 @@ func encodeResponse(ctx context.Context, response *Response) (types.IoBuffer, er
 		// use request.Content.WriteTo might have error under retry scene
 		buf.Write(response.Content.Bytes())
 	}
+	// 2.3.5 crc32, when the switch enables it
+	if IsCrcEnable(response.SwitchCode) {
+		writeCrc(buf)
+	}
 
 	return buf, nil
 }
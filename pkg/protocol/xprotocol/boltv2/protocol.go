@@ -91,7 +91,8 @@ func (proto *boltv2Protocol) Encode(ctx context.Context, model interface{}) (typ
 
 func (proto *boltv2Protocol) Decode(ctx context.Context, data types.IoBuffer) (interface{}, error) {
 	if data.Len() >= LessLen {
-		cmdType := data.Bytes()[1]
+		// index 0 is proto, index 1 is ver1, so cmd type is at index 2
+		cmdType := data.Bytes()[2]
 
 		switch cmdType {
 		case bolt.CmdTypeRequest:
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boltv2
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+func TestBoltV2RequestRoundTripWithCrc(t *testing.T) {
+	proto := &boltv2Protocol{}
+	ctx := context.Background()
+
+	request := &Request{
+		RequestHeader: RequestHeader{
+			RequestHeader: bolt.RequestHeader{
+				Protocol:  ProtocolCode,
+				CmdType:   bolt.CmdTypeRequest,
+				CmdCode:   bolt.CmdCodeRpcRequest,
+				Version:   ProtocolVersion,
+				RequestId: 42,
+				Codec:     bolt.Hessian2Serialize,
+				Timeout:   -1,
+			},
+			SwitchCode: SetCrcEnable(0),
+		},
+	}
+	request.Class = "com.alipay.SomeService"
+	request.ClassLen = uint16(len(request.Class))
+	request.Content = buffer.NewIoBufferString("hello boltv2")
+
+	buf, err := proto.Encode(ctx, request)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+
+	decoded, err := proto.Decode(ctx, buf)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	got, ok := decoded.(*Request)
+	if !ok {
+		t.Fatalf("decode returned %T, want *Request", decoded)
+	}
+	if !IsCrcEnable(got.SwitchCode) {
+		t.Error("decoded switch code lost the CRC bit")
+	}
+	if got.Class != request.Class {
+		t.Errorf("decoded class = %q, want %q", got.Class, request.Class)
+	}
+	if got.Content == nil || got.Content.String() != "hello boltv2" {
+		t.Errorf("decoded content = %v, want %q", got.Content, "hello boltv2")
+	}
+}
+
+func TestBoltV2DecodeCrcMismatch(t *testing.T) {
+	proto := &boltv2Protocol{}
+	ctx := context.Background()
+
+	response := &Response{
+		ResponseHeader: ResponseHeader{
+			ResponseHeader: bolt.ResponseHeader{
+				Protocol:       ProtocolCode,
+				CmdType:        bolt.CmdTypeResponse,
+				CmdCode:        bolt.CmdCodeRpcResponse,
+				Version:        ProtocolVersion,
+				RequestId:      7,
+				Codec:          bolt.Hessian2Serialize,
+				ResponseStatus: bolt.ResponseStatusSuccess,
+			},
+			SwitchCode: SetCrcEnable(0),
+		},
+	}
+	response.Content = buffer.NewIoBufferString("crc'd payload")
+
+	buf, err := proto.Encode(ctx, response)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	corrupted := append([]byte(nil), buf.Bytes()...)
+	corrupted[len(corrupted)-CrcLen-1] ^= 0xff
+
+	if _, err := proto.Decode(ctx, buffer.NewIoBufferBytes(corrupted)); err != ErrCrcMismatch {
+		t.Errorf("decode of corrupted frame returned err=%v, want ErrCrcMismatch", err)
+	}
+}
+
+func TestBoltv2MatcherMagicByte(t *testing.T) {
+	testcases := []struct {
+		data     []byte
+		expected types.MatchResult
+	}{
+		{data: []byte{}, expected: types.MatchAgain},
+		{data: []byte{ProtocolCode, 0, 0}, expected: types.MatchSuccess},
+		{data: []byte{bolt.ProtocolCode, 0, 0}, expected: types.MatchFailed},
+	}
+	for i, tc := range testcases {
+		if got := boltv2Matcher(tc.data); got != tc.expected {
+			t.Errorf("#%d boltv2Matcher(%v) = %v, want %v", i, tc.data, got, tc.expected)
+		}
+	}
+}
@@ -18,6 +18,8 @@
 package boltv2
 
 import (
+	"errors"
+
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -34,4 +36,27 @@ const (
 	RequestIdIndex         = 6
 	RequestHeaderLenIndex  = 18
 	ResponseHeaderLenIndex = 16
+
+	// CRCSwitchIndex is the bit of SwitchCode that marks a frame as carrying
+	// a trailing CRC32 checksum over the whole encoded frame.
+	CRCSwitchIndex uint = 0
+
+	// CrcLen is the size, in bytes, of the CRC32 checksum a frame appends
+	// after its content when CRCSwitchIndex is set.
+	CrcLen int = 4
 )
+
+// ErrCrcMismatch is returned by decode when a frame's SwitchCode declares a
+// trailing CRC32 checksum but the checksum doesn't match the frame.
+var ErrCrcMismatch = errors.New("boltv2 crc32 checksum mismatch")
+
+// IsCrcEnable reports whether a SwitchCode byte enables the trailing CRC32
+// checksum.
+func IsCrcEnable(switchCode byte) bool {
+	return switchCode&(1<<CRCSwitchIndex) > 0
+}
+
+// SetCrcEnable turns on the CRC bit of a SwitchCode byte.
+func SetCrcEnable(switchCode byte) byte {
+	return switchCode | (1 << CRCSwitchIndex)
+}
@@ -23,8 +23,10 @@ import (
 	"fmt"
 
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/xprotocol"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
 )
 
 /**
@@ -91,8 +93,25 @@ func (proto *dubboProtocol) Decode(ctx context.Context, data types.IoBuffer) (in
 
 // heartbeater
 func (proto *dubboProtocol) Trigger(requestId uint64) xprotocol.XFrame {
-	// not support
-	return nil
+	// dubbo's heartbeat is an event request whose payload is a single
+	// hessian2-encoded null ("N"), see org.apache.dubbo.remoting.exchange.support.header.HeartbeatTimerTask
+	payload := []byte{0x4e}
+	return &Frame{
+		Header: Header{
+			Magic:           MagicTag,
+			Flag:            dubboHessian2 | eventFlag | twoWayFlag | requestFlag,
+			Status:          0,
+			Id:              requestId,
+			DataLen:         uint32(len(payload)),
+			IsEvent:         true,
+			IsTwoWay:        true,
+			Direction:       EventRequest,
+			SerializationId: int(dubboHessian2),
+			CommonHeader:    protocol.CommonHeader{},
+		},
+		payload: payload,
+		content: buffer.NewIoBufferBytes(payload),
+	}
 }
 
 func (proto *dubboProtocol) Reply(request xprotocol.XFrame) xprotocol.XRespFrame {
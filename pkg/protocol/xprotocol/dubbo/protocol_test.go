@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDubboProtocolTrigger(t *testing.T) {
+	proto := &dubboProtocol{}
+
+	frame := proto.Trigger(123)
+	if frame == nil {
+		t.Fatal("Trigger() = nil, want a heartbeat request frame")
+	}
+	if !frame.(*Frame).IsHeartbeatFrame() {
+		t.Errorf("Trigger() frame is not marked as a heartbeat")
+	}
+	if frame.GetRequestId() != 123 {
+		t.Errorf("Trigger() request id = %d, want 123", frame.GetRequestId())
+	}
+
+	// the frame must encode into something the decoder recognizes as the
+	// same heartbeat request, the way it would be sent to an upstream.
+	buf, err := proto.Encode(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	decoded, err := proto.Decode(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got, ok := decoded.(*Frame)
+	if !ok {
+		t.Fatalf("Decode() = %T, want *Frame", decoded)
+	}
+	if !got.IsHeartbeatFrame() || got.GetStreamType() != frame.(*Frame).GetStreamType() || got.GetRequestId() != 123 {
+		t.Errorf("Decode(Encode(Trigger())) = %+v, want a matching heartbeat request", got)
+	}
+}
@@ -63,3 +63,11 @@ const (
 const (
 	ResponseStatusSuccess uint16 = 0x14 // 0x14 response status
 )
+
+// flag bits, see dubboProtocol's doc comment for the byte layout
+const (
+	dubboHessian2 byte = 2 // serialization id, lower 5 bits
+	eventFlag     byte = 1 << 5
+	twoWayFlag    byte = 1 << 6
+	requestFlag   byte = 1 << 7
+)
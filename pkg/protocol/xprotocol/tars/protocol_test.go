@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tars
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TarsCloud/TarsGo/tars/protocol/res/requestf"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestTarsProtocolEncodeDecodeRequest(t *testing.T) {
+	proto := &tarsProtocol{}
+
+	req := &Request{
+		CommonHeader: protocol.CommonHeader{},
+		cmd: &requestf.RequestPacket{
+			IRequestId:   42,
+			SServantName: "TestApp.TestServer.obj",
+			SFuncName:    "hello",
+		},
+	}
+
+	buf, err := proto.Encode(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	decoded, err := proto.Decode(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, ok := decoded.(*Request)
+	if !ok {
+		t.Fatalf("Decode() = %T, want *Request", decoded)
+	}
+
+	if got.GetRequestId() != 42 {
+		t.Errorf("GetRequestId() = %d, want 42", got.GetRequestId())
+	}
+	if v, ok := got.Get(ServiceNameHeader); !ok || v != "TestApp.TestServer.obj" {
+		t.Errorf("Get(ServiceNameHeader) = %q, %v, want %q, true", v, ok, "TestApp.TestServer.obj")
+	}
+	if v, ok := got.Get(MethodNameHeader); !ok || v != "hello" {
+		t.Errorf("Get(MethodNameHeader) = %q, %v, want %q, true", v, ok, "hello")
+	}
+}
+
+func TestTarsProtocolEncodeDecodeResponse(t *testing.T) {
+	proto := &tarsProtocol{}
+
+	resp := &Response{
+		cmd: &requestf.ResponsePacket{
+			IRequestId: 42,
+			IRet:       int32(ResponseStatusSuccess),
+		},
+	}
+
+	buf, err := proto.Encode(context.Background(), resp)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	decoded, err := proto.Decode(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, ok := decoded.(*Response)
+	if !ok {
+		t.Fatalf("Decode() = %T, want *Response", decoded)
+	}
+	if got.GetRequestId() != 42 {
+		t.Errorf("GetRequestId() = %d, want 42", got.GetRequestId())
+	}
+	if got.GetStatusCode() != uint32(ResponseStatusSuccess) {
+		t.Errorf("GetStatusCode() = %d, want %d", got.GetStatusCode(), ResponseStatusSuccess)
+	}
+}
+
+func TestTarsMatcher(t *testing.T) {
+	req := &Request{
+		CommonHeader: protocol.CommonHeader{},
+		cmd: &requestf.RequestPacket{
+			IRequestId:   1,
+			SServantName: "TestApp.TestServer.obj",
+			SFuncName:    "hello",
+		},
+	}
+	buf, err := encodeRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("encodeRequest() error = %v", err)
+	}
+
+	if status := tarsMatcher(buf.Bytes()); status != types.MatchSuccess {
+		t.Errorf("tarsMatcher() = %v, want MatchSuccess", status)
+	}
+}
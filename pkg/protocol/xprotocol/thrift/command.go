@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+import (
+	"strings"
+
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type Header struct {
+	Framed      bool  // wire form was length-prefixed
+	Compact     bool  // TCompactProtocol vs TBinaryProtocol strict
+	MessageType int32 // call/reply/exception/oneway
+	SeqId       uint64
+	Name        string // message name; "Service:Method" when the client multiplexes services
+	protocol.CommonHeader
+}
+
+type Frame struct {
+	Header
+
+	payload []byte // the argument/result struct, forwarded opaquely
+
+	data    types.IoBuffer // wrapper of the whole message (header + payload)
+	content types.IoBuffer // wrapper of payload
+}
+
+// ~ XFrame
+func (f *Frame) GetRequestId() uint64 {
+	return f.SeqId
+}
+
+func (f *Frame) SetRequestId(id uint64) {
+	f.SeqId = id
+}
+
+func (f *Frame) IsHeartbeatFrame() bool {
+	// thrift has no protocol-level heartbeat message
+	return false
+}
+
+func (f *Frame) GetStreamType() xprotocol.StreamType {
+	switch f.MessageType {
+	case MessageTypeOneway:
+		return xprotocol.RequestOneWay
+	case MessageTypeReply, MessageTypeException:
+		return xprotocol.Response
+	default:
+		return xprotocol.Request
+	}
+}
+
+func (f *Frame) GetHeader() types.HeaderMap {
+	return f
+}
+
+func (f *Frame) GetData() types.IoBuffer {
+	return f.content
+}
+
+func (f *Frame) SetData(data types.IoBuffer) {
+	f.content = data
+}
+
+// ~ XRespFrame
+func (f *Frame) GetStatusCode() uint32 {
+	if f.MessageType == MessageTypeException {
+		return exceptionStatusCode
+	}
+	return 0
+}
+
+// ~ ServiceAware. The method name is a structural part of the thrift
+// message itself, unlike bolt's free-form header, so it has to be parsed
+// out of the message rather than read off a header; this is the only
+// implementation of ServiceAware in the repo today, feeding the generic
+// x-mosn-rpc-service/x-mosn-rpc-method header injection already wired up in
+// pkg/stream/xprotocol for method-name-based routing and header-keyed stats.
+func (f *Frame) GetServiceName() string {
+	service, _ := splitMultiplexedName(f.Name)
+	return service
+}
+
+func (f *Frame) GetMethodName() string {
+	_, method := splitMultiplexedName(f.Name)
+	return method
+}
+
+// splitMultiplexedName splits a TMultiplexedProtocol-style "Service:Method"
+// message name into its service and method parts. A name with no colon has
+// no service qualifier, which is the common case for a client that isn't
+// multiplexing several services on one connection.
+func splitMultiplexedName(name string) (service, method string) {
+	if idx := strings.IndexByte(name, ':'); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "", name
+}
@@ -0,0 +1,326 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// errShortBuffer signals that the currently buffered data ran out
+// mid-parse; decodeFrame turns it into the (nil, nil) "wait for more data"
+// contract the xprotocol connection loop expects, the same way dubbo's
+// Decode pre-checks data.Len() against the header and payload length before
+// ever trying to parse.
+var errShortBuffer = errors.New("thrift: short buffer")
+
+// reader reads out of a fixed byte slice, reporting errShortBuffer instead
+// of panicking when a read runs past the end.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errShortBuffer
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) readBytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errShortBuffer
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) readInt16() (int16, error) {
+	b, err := r.readBytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (r *reader) readInt32() (int32, error) {
+	b, err := r.readBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+// readVarint reads an unsigned LEB128 varint, as used by TCompactProtocol
+// for both the seqid and string/container lengths.
+func (r *reader) readVarint() (uint64, error) {
+	var result uint64
+	var shift uint
+	for {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+		shift += 7
+		if shift >= 64 {
+			return 0, fmt.Errorf("thrift: varint too long")
+		}
+	}
+}
+
+func decodeFrame(ctx context.Context, data types.IoBuffer) (interface{}, error) {
+	raw := data.Bytes()
+	if len(raw) < 4 {
+		return nil, nil
+	}
+
+	framed := !looksLikeMessageBegin(raw)
+	bodyOffset := 0
+	totalLen := 0
+	if framed {
+		frameLen := int(binary.BigEndian.Uint32(raw[:4]))
+		if frameLen < 0 {
+			return nil, fmt.Errorf("thrift: negative frame length %d", frameLen)
+		}
+		if len(raw) < 4+frameLen {
+			return nil, nil
+		}
+		bodyOffset = 4
+		totalLen = 4 + frameLen
+	}
+	if len(raw) <= bodyOffset {
+		return nil, nil
+	}
+
+	compact := raw[bodyOffset] == compactProtocolID
+	r := &reader{data: raw, pos: bodyOffset}
+
+	var (
+		msgType int32
+		name    string
+		seqid   uint64
+		err     error
+	)
+	if compact {
+		msgType, name, seqid, err = decodeCompactMessageBegin(r)
+	} else {
+		msgType, name, seqid, err = decodeBinaryMessageBegin(r)
+	}
+	if err != nil {
+		if err == errShortBuffer {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("thrift: decode message header: %v", err)
+	}
+	argsStart := r.pos
+
+	if !framed {
+		if compact {
+			return nil, fmt.Errorf("thrift: unframed TCompactProtocol messages are not supported, use framed transport")
+		}
+		if err := skipStruct(r); err != nil {
+			if err == errShortBuffer {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("thrift: skip argument struct: %v", err)
+		}
+		totalLen = r.pos
+	}
+
+	frameBytes := make([]byte, totalLen)
+	copy(frameBytes, raw[:totalLen])
+
+	frame := &Frame{
+		Header: Header{
+			Framed:       framed,
+			Compact:      compact,
+			MessageType:  msgType,
+			SeqId:        seqid,
+			Name:         name,
+			CommonHeader: protocol.CommonHeader{},
+		},
+		payload: frameBytes[argsStart:],
+	}
+	frame.data = buffer.NewIoBufferBytes(frameBytes)
+	frame.content = buffer.NewIoBufferBytes(frame.payload)
+
+	data.Drain(totalLen)
+	return frame, nil
+}
+
+// decodeBinaryMessageBegin parses a TBinaryProtocol-strict message-begin:
+// i32 version_and_type, string name, i32 seqid.
+func decodeBinaryMessageBegin(r *reader) (msgType int32, name string, seqid uint64, err error) {
+	header, err := r.readInt32()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	if uint32(header)&binaryVersionMask != binaryVersion1 {
+		return 0, "", 0, fmt.Errorf("not a strict TBinaryProtocol message (header=%#x)", uint32(header))
+	}
+	msgType = int32(uint32(header) & binaryTypeMask)
+
+	nameLen, err := r.readInt32()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	nameBytes, err := r.readBytes(int(nameLen))
+	if err != nil {
+		return 0, "", 0, err
+	}
+	name = string(nameBytes)
+
+	seq, err := r.readInt32()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	return msgType, name, uint64(uint32(seq)), nil
+}
+
+// decodeCompactMessageBegin parses a TCompactProtocol message-begin: byte
+// protocol id, byte version_and_type, varint seqid, varint name length,
+// name bytes.
+func decodeCompactMessageBegin(r *reader) (msgType int32, name string, seqid uint64, err error) {
+	protoID, err := r.readByte()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	if protoID != compactProtocolID {
+		return 0, "", 0, fmt.Errorf("not a TCompactProtocol message (protocol id=%#x)", protoID)
+	}
+	verType, err := r.readByte()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	msgType = int32((verType >> 5) & 0x07)
+
+	seqid, err = r.readVarint()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	nameLen, err := r.readVarint()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	nameBytes, err := r.readBytes(int(nameLen))
+	if err != nil {
+		return 0, "", 0, err
+	}
+	return msgType, string(nameBytes), seqid, nil
+}
+
+// skipStruct consumes a TBinaryProtocol-encoded struct: a sequence of field
+// headers (type, then id) each followed by that field's value, terminated
+// by a TypeStop field type. It is only needed to find the end of an
+// unframed message, whose argument struct isn't otherwise length-prefixed.
+func skipStruct(r *reader) error {
+	for {
+		fieldType, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		if fieldType == TypeStop {
+			return nil
+		}
+		if _, err := r.readInt16(); err != nil { // field id
+			return err
+		}
+		if err := skipValue(r, fieldType); err != nil {
+			return err
+		}
+	}
+}
+
+func skipValue(r *reader, typ byte) error {
+	switch typ {
+	case TypeBool, TypeByte:
+		_, err := r.readByte()
+		return err
+	case TypeI16:
+		_, err := r.readInt16()
+		return err
+	case TypeI32:
+		_, err := r.readInt32()
+		return err
+	case TypeI64, TypeDouble:
+		_, err := r.readBytes(8)
+		return err
+	case TypeString:
+		n, err := r.readInt32()
+		if err != nil {
+			return err
+		}
+		_, err = r.readBytes(int(n))
+		return err
+	case TypeStruct:
+		return skipStruct(r)
+	case TypeMap:
+		keyType, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		valType, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		size, err := r.readInt32()
+		if err != nil {
+			return err
+		}
+		for i := int32(0); i < size; i++ {
+			if err := skipValue(r, keyType); err != nil {
+				return err
+			}
+			if err := skipValue(r, valType); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TypeSet, TypeList:
+		elemType, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		size, err := r.readInt32()
+		if err != nil {
+			return err
+		}
+		for i := int32(0); i < size; i++ {
+			if err := skipValue(r, elemType); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown field type %d", typ)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// buildArgStruct returns a minimal, valid binary-protocol-encoded struct:
+// one string field (id 1) then the terminating TypeStop.
+func buildArgStruct(value string) []byte {
+	b := []byte{TypeString, 0x00, 0x01}
+	lenBuf := make([]byte, 4)
+	putUint32(lenBuf, uint32(len(value)))
+	b = append(b, lenBuf...)
+	b = append(b, value...)
+	b = append(b, TypeStop)
+	return b
+}
+
+func putUint32(b []byte, v uint32) {
+	b[0] = byte(v >> 24)
+	b[1] = byte(v >> 16)
+	b[2] = byte(v >> 8)
+	b[3] = byte(v)
+}
+
+func decodeOnce(t *testing.T, raw []byte) *Frame {
+	t.Helper()
+	buf := buffer.NewIoBufferBytes(raw)
+	got, err := decodeFrame(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("decodeFrame() error = %v", err)
+	}
+	frame, ok := got.(*Frame)
+	if !ok {
+		t.Fatalf("decodeFrame() = %T, want *Frame (raw decode result: %v)", got, got)
+	}
+	return frame
+}
+
+func TestDecodeBinaryFramedRoundTrip(t *testing.T) {
+	frame := &Frame{Header: Header{Framed: true, MessageType: MessageTypeCall, SeqId: 42, Name: "echo"}, payload: buildArgStruct("hi")}
+	buf, err := encodeFrame(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+
+	got := decodeOnce(t, buf.Bytes())
+	if got.MessageType != MessageTypeCall || got.Name != "echo" || got.SeqId != 42 {
+		t.Errorf("decoded frame = %+v, want type=%d name=echo seqid=42", got.Header, MessageTypeCall)
+	}
+	if got.Compact {
+		t.Errorf("decoded frame Compact = true, want false")
+	}
+	if !got.Framed {
+		t.Errorf("decoded frame Framed = false, want true")
+	}
+	if string(got.payload) != string(frame.payload) {
+		t.Errorf("decoded payload = %v, want %v", got.payload, frame.payload)
+	}
+}
+
+func TestDecodeBinaryUnframedRoundTrip(t *testing.T) {
+	frame := &Frame{Header: Header{Framed: false, MessageType: MessageTypeOneway, SeqId: 7, Name: "notify"}, payload: buildArgStruct("world")}
+	buf, err := encodeFrame(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+
+	// append a second message right after the first one, the way pipelined
+	// requests on the same connection would arrive; decodeFrame must stop
+	// exactly at the first message's boundary via skip-decoding, not
+	// consume the second one too.
+	second := &Frame{Header: Header{Framed: false, MessageType: MessageTypeCall, SeqId: 8, Name: "next"}, payload: buildArgStruct("!")}
+	secondBuf, err := encodeFrame(context.Background(), second)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+	combined := append(buf.Bytes(), secondBuf.Bytes()...)
+
+	iobuf := buffer.NewIoBufferBytes(combined)
+	got, err := decodeFrame(context.Background(), iobuf)
+	if err != nil {
+		t.Fatalf("decodeFrame() error = %v", err)
+	}
+	f, ok := got.(*Frame)
+	if !ok {
+		t.Fatalf("decodeFrame() = %T, want *Frame", got)
+	}
+	if f.Name != "notify" || f.SeqId != 7 || f.GetStreamType() != xprotocol.RequestOneWay {
+		t.Errorf("decoded first frame = %+v, want name=notify seqid=7 oneway", f.Header)
+	}
+	if iobuf.Len() != secondBuf.Len() {
+		t.Errorf("after decoding first message, remaining buffer len = %d, want %d (only the second message left)", iobuf.Len(), secondBuf.Len())
+	}
+}
+
+func TestDecodeCompactFramedRoundTrip(t *testing.T) {
+	frame := &Frame{Header: Header{Framed: true, Compact: true, MessageType: MessageTypeReply, SeqId: 99, Name: "svc:method"}, payload: []byte("opaque-result-bytes")}
+	buf, err := encodeFrame(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+
+	got := decodeOnce(t, buf.Bytes())
+	if !got.Compact || !got.Framed {
+		t.Errorf("decoded frame Compact/Framed = %v/%v, want true/true", got.Compact, got.Framed)
+	}
+	if got.MessageType != MessageTypeReply || got.SeqId != 99 {
+		t.Errorf("decoded frame = %+v, want type=%d seqid=99", got.Header, MessageTypeReply)
+	}
+	if got.GetServiceName() != "svc" || got.GetMethodName() != "method" {
+		t.Errorf("GetServiceName/GetMethodName = %q/%q, want svc/method", got.GetServiceName(), got.GetMethodName())
+	}
+	if string(got.payload) != "opaque-result-bytes" {
+		t.Errorf("decoded payload = %q, want opaque-result-bytes", got.payload)
+	}
+}
+
+func TestDecodeUnframedCompactUnsupported(t *testing.T) {
+	frame := &Frame{Header: Header{Framed: false, Compact: true, MessageType: MessageTypeCall, SeqId: 1, Name: "x"}, payload: []byte{}}
+	buf, err := encodeFrame(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+	if _, err := decodeFrame(context.Background(), buf); err == nil {
+		t.Errorf("decodeFrame() error = nil, want an error for unframed TCompactProtocol")
+	}
+}
+
+func TestDecodeFrameWaitsForMoreData(t *testing.T) {
+	frame := &Frame{Header: Header{Framed: true, MessageType: MessageTypeCall, SeqId: 1, Name: "echo"}, payload: buildArgStruct("hi")}
+	buf, err := encodeFrame(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+	truncated := buffer.NewIoBufferBytes(buf.Bytes()[:buf.Len()-2])
+
+	got, err := decodeFrame(context.Background(), truncated)
+	if got != nil || err != nil {
+		t.Errorf("decodeFrame(truncated) = (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+// TestServiceAwareHeaderInjection mirrors the generic ServiceAware
+// consumption logic in pkg/stream/xprotocol/conn.go (frame.(ServiceAware)
+// -> Set the rpc-service/rpc-method headers on the frame itself), which is
+// what a decoded Frame actually needs to satisfy for method-name-based
+// routing to work end to end.
+func TestServiceAwareHeaderInjection(t *testing.T) {
+	frame := &Frame{Header: Header{Framed: true, MessageType: MessageTypeCall, SeqId: 1, Name: "EchoService:echo"}, payload: buildArgStruct("hi")}
+	buf, err := encodeFrame(context.Background(), frame)
+	if err != nil {
+		t.Fatalf("encodeFrame() error = %v", err)
+	}
+	got := decodeOnce(t, buf.Bytes())
+
+	var xf xprotocol.XFrame = got
+	aware, ok := xf.(xprotocol.ServiceAware)
+	if !ok {
+		t.Fatalf("*Frame does not implement xprotocol.ServiceAware")
+	}
+	xf.GetHeader().Set(types.HeaderRPCService, aware.GetServiceName())
+	xf.GetHeader().Set(types.HeaderRPCMethod, aware.GetMethodName())
+
+	if v, ok := got.GetHeader().Get(types.HeaderRPCService); !ok || v != "EchoService" {
+		t.Errorf("HeaderRPCService = %q, %v; want EchoService, true", v, ok)
+	}
+	if v, ok := got.GetHeader().Get(types.HeaderRPCMethod); !ok || v != "echo" {
+		t.Errorf("HeaderRPCMethod = %q, %v; want echo, true", v, ok)
+	}
+}
+
+func TestSplitMultiplexedName(t *testing.T) {
+	cases := []struct {
+		name, wantService, wantMethod string
+	}{
+		{"echo", "", "echo"},
+		{"EchoService:echo", "EchoService", "echo"},
+	}
+	for _, c := range cases {
+		service, method := splitMultiplexedName(c.name)
+		if service != c.wantService || method != c.wantMethod {
+			t.Errorf("splitMultiplexedName(%q) = (%q, %q), want (%q, %q)", c.name, service, method, c.wantService, c.wantMethod)
+		}
+	}
+}
+
+func TestThriftMatcher(t *testing.T) {
+	binaryUnframed := []byte{0x80, 0x01, 0x00, byte(MessageTypeCall)}
+	compactUnframed := []byte{compactProtocolID, compactVersion, 0x00, 0x00}
+
+	framedFrame := &Frame{Header: Header{Framed: true, MessageType: MessageTypeCall, SeqId: 1, Name: "x"}, payload: buildArgStruct("v")}
+	framedBuf, _ := encodeFrame(context.Background(), framedFrame)
+
+	cases := []struct {
+		name string
+		data []byte
+		want types.MatchResult
+	}{
+		{"binary unframed", binaryUnframed, types.MatchSuccess},
+		{"compact unframed", compactUnframed, types.MatchSuccess},
+		{"framed", framedBuf.Bytes(), types.MatchSuccess},
+		{"garbage", []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, types.MatchFailed},
+		{"too short", []byte{0x80}, types.MatchAgain},
+	}
+	for _, c := range cases {
+		if got := thriftMatcher(c.data); got != c.want {
+			t.Errorf("thriftMatcher(%s) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
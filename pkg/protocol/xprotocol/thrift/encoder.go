@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+import (
+	"context"
+	"encoding/binary"
+
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// encodeFrame re-serializes frame's message-begin header and appends its
+// (possibly untouched) argument struct bytes. Unlike dubbo's fixed 16-byte
+// header, thrift's compact encoding is variable-width (varint seqid and
+// name length), so patching a borrowed raw buffer in place isn't safe here;
+// the header is always rebuilt from the current field values instead, which
+// also makes SetRequestId (used by the stream layer to remap the seqid for
+// the upstream connection) correct regardless of encoding.
+func encodeFrame(ctx context.Context, frame *Frame) (types.IoBuffer, error) {
+	body := encodeMessageBegin(frame)
+	body = append(body, frame.payload...)
+
+	if frame.Framed {
+		buf := buffer.GetIoBuffer(4 + len(body))
+		buf.WriteUint32(uint32(len(body)))
+		buf.Write(body)
+		return buf, nil
+	}
+	return buffer.NewIoBufferBytes(body), nil
+}
+
+func encodeMessageBegin(frame *Frame) []byte {
+	if frame.Compact {
+		return encodeCompactMessageBegin(frame)
+	}
+	return encodeBinaryMessageBegin(frame)
+}
+
+func encodeBinaryMessageBegin(frame *Frame) []byte {
+	nameBytes := []byte(frame.Name)
+	buf := make([]byte, 0, 12+len(nameBytes))
+
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, binaryVersion1|(uint32(frame.MessageType)&binaryTypeMask))
+	buf = append(buf, header...)
+
+	nameLen := make([]byte, 4)
+	binary.BigEndian.PutUint32(nameLen, uint32(len(nameBytes)))
+	buf = append(buf, nameLen...)
+	buf = append(buf, nameBytes...)
+
+	seqid := make([]byte, 4)
+	binary.BigEndian.PutUint32(seqid, uint32(frame.SeqId))
+	buf = append(buf, seqid...)
+	return buf
+}
+
+func encodeCompactMessageBegin(frame *Frame) []byte {
+	buf := []byte{compactProtocolID, (byte(frame.MessageType&0x07) << 5) | compactVersion}
+	buf = appendVarint(buf, frame.SeqId)
+	buf = appendVarint(buf, uint64(len(frame.Name)))
+	buf = append(buf, frame.Name...)
+	return buf
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for {
+		if v&^uint64(0x7f) == 0 {
+			return append(buf, byte(v))
+		}
+		buf = append(buf, byte(v&0x7f)|0x80)
+		v >>= 7
+	}
+}
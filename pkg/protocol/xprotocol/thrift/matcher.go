@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+import (
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	xprotocol.RegisterMatcher(ProtocolName, thriftMatcher)
+}
+
+// thriftMatcher recognizes a message-begin either at the start of the
+// buffer (unframed) or right after a 4-byte length prefix (framed).
+func thriftMatcher(data []byte) types.MatchResult {
+	if len(data) < 4 {
+		return types.MatchAgain
+	}
+	if looksLikeMessageBegin(data) {
+		return types.MatchSuccess
+	}
+	if len(data) < 8 {
+		return types.MatchAgain
+	}
+	if looksLikeMessageBegin(data[4:]) {
+		return types.MatchSuccess
+	}
+	return types.MatchFailed
+}
+
+// looksLikeMessageBegin reports whether data starts with a recognizable
+// TBinaryProtocol-strict or TCompactProtocol message-begin marker.
+func looksLikeMessageBegin(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	if data[0] == compactProtocolID {
+		return true
+	}
+	return data[0] == 0x80 && data[1] == 0x01
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+import (
+	"context"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+/**
+* Thrift protocol, TBinaryProtocol (strict) or TCompactProtocol, each
+* optionally wrapped in the framed transport (a 4-byte big-endian length
+* prefix ahead of the message). See matcher.go for how the two transports
+* and two protocol encodings are told apart, and decoder.go for the wire
+* layouts.
+*
+* Only the message-begin header (message type, name, seqid) is decoded;
+* the argument/result struct that follows is forwarded as opaque bytes,
+* the same way the dubbo sub-protocol stops decoding once it has read the
+* RPC-invocation header fields.
+ */
+func init() {
+	xprotocol.RegisterProtocol(ProtocolName, &thriftProtocol{})
+}
+
+type thriftProtocol struct{}
+
+// ~ types.Protocol
+func (proto *thriftProtocol) Name() types.ProtocolName {
+	return ProtocolName
+}
+
+func (proto *thriftProtocol) Encode(ctx context.Context, model interface{}) (types.IoBuffer, error) {
+	if frame, ok := model.(*Frame); ok {
+		return encodeFrame(ctx, frame)
+	}
+	log.Proxy.Errorf(ctx, "[protocol][thrift] encode with unknown command : %+v", model)
+	return nil, xprotocol.ErrUnknownType
+}
+
+func (proto *thriftProtocol) Decode(ctx context.Context, data types.IoBuffer) (interface{}, error) {
+	return decodeFrame(ctx, data)
+}
+
+// ~ Heartbeater
+//
+// Thrift has no protocol-level heartbeat/ping message the way bolt and
+// dubbo do, so there is nothing for a keepalive to send or reply to.
+func (proto *thriftProtocol) Trigger(requestId uint64) xprotocol.XFrame {
+	// not support
+	return nil
+}
+
+func (proto *thriftProtocol) Reply(request xprotocol.XFrame) xprotocol.XRespFrame {
+	// not support
+	return nil
+}
+
+// ~ Hijacker
+//
+// A hijacked response (e.g. no route found) has to be encoded back to the
+// client, but Hijack is only given a status code, not the request frame it
+// is answering; without the request's message name, seqid or wire encoding
+// (binary vs compact, framed vs not) there's no way to build a message the
+// client can actually decode, so this is left unsupported like dubbo's
+// Hijack, rather than emit a response that merely looks complete.
+func (proto *thriftProtocol) Hijack(statusCode uint32) xprotocol.XRespFrame {
+	// not support
+	return nil
+}
+
+func (proto *thriftProtocol) Mapping(httpStatusCode uint32) uint32 {
+	// not support
+	return 0
+}
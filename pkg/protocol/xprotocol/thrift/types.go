@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package thrift
+
+const (
+	ProtocolName = "thrift"
+)
+
+// TBinaryProtocol message-begin layout: a big-endian i32 whose top 16 bits
+// are a strict-mode version marker and whose low byte is the message type,
+// see https://github.com/apache/thrift/blob/master/doc/specs/thrift-binary-protocol.md.
+// The legacy non-strict encoding (a bare name-length i32 with no version
+// marker) is not distinguishable from strict mode without heuristics and is
+// out of scope here.
+const (
+	binaryVersionMask uint32 = 0xffff0000
+	binaryVersion1    uint32 = 0x80010000
+	binaryTypeMask    uint32 = 0x000000ff
+)
+
+// TCompactProtocol message-begin layout, see
+// https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md.
+// Only the framed transport is supported for compact messages: unframed
+// compact requires skip-decoding the argument struct to find the frame
+// boundary, and compact's field headers and scalar types use a delta/zigzag
+// varint scheme unlike binary's fixed-width fields, which is real added
+// complexity this package doesn't take on.
+const (
+	compactProtocolID byte = 0x82
+	compactVersion     byte = 1
+)
+
+// message types, shared by TBinaryProtocol and TCompactProtocol
+const (
+	MessageTypeCall      int32 = 1
+	MessageTypeReply     int32 = 2
+	MessageTypeException int32 = 3
+	MessageTypeOneway    int32 = 4
+)
+
+// field types (TType), see the Thrift IDL spec; 5, 7 and 9 are reserved and
+// unused, so this list is not contiguous.
+const (
+	TypeStop   byte = 0
+	TypeBool   byte = 2
+	TypeByte   byte = 3
+	TypeDouble byte = 4
+	TypeI16    byte = 6
+	TypeI32    byte = 8
+	TypeI64    byte = 10
+	TypeString byte = 11
+	TypeStruct byte = 12
+	TypeMap    byte = 13
+	TypeSet    byte = 14
+	TypeList   byte = 15
+)
+
+// exceptionStatusCode is the generic status code reported for a
+// MessageTypeException reply; thrift's TApplicationException carries its
+// own, more specific type code inside the message body instead.
+const exceptionStatusCode uint32 = 1
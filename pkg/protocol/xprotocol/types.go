@@ -50,7 +50,6 @@ var (
 
 // XFrame represents the minimal programmable object of the protocol.
 type XFrame interface {
-	// TODO: make multiplexing optional, and maybe we can support PING-PONG protocol in this framework.
 	Multiplexing
 
 	HeartbeatPredicate
@@ -64,6 +63,32 @@ type XFrame interface {
 	SetData(data types.IoBuffer)
 }
 
+// PoolMode selects how the connection pool built for a sub-protocol manages
+// its upstream connections.
+type PoolMode int
+
+const (
+	// Multiplex keeps a single upstream connection per sub-protocol and
+	// relies on Multiplexing's request-id semantics to distinguish
+	// concurrently in-flight requests on it. This is the default for a
+	// protocol that does not implement PoolModeProvider.
+	Multiplex PoolMode = iota
+
+	// PingPong dedicates one upstream connection to one in-flight request
+	// at a time, the way a request/response protocol without a usable
+	// request-id typically works: the pool keeps a set of connections and
+	// hands out an idle one per stream, dialing a new one when none is
+	// idle.
+	PingPong
+)
+
+// PoolModeProvider is implemented by an XProtocol that wants to declare its
+// connection pool mode explicitly. A protocol that does not implement it
+// gets the default, Multiplex.
+type PoolModeProvider interface {
+	PoolMode() PoolMode
+}
+
 // XRespFrame expose response status code based on the XFrame
 type XRespFrame interface {
 	XFrame
@@ -96,6 +121,20 @@ type GoAwayPredicate interface {
 	IsGoAwayFrame() bool
 }
 
+// StreamEndPredicate is implemented by an XFrame belonging to a sub-protocol
+// that can split a single logical request or response into more than one
+// wire frame under the same request-id - e.g. a streaming RPC that sends
+// several messages before it completes. A frame that doesn't implement it
+// is always treated as the end of its stream, which is exactly today's
+// unary behavior and requires no change from any existing sub-protocol.
+type StreamEndPredicate interface {
+	// IsEndFrame reports whether this is the last frame of its stream. A
+	// false return keeps the request-id's stream open so further frames
+	// under the same id are delivered to the same receiver instead of
+	// starting, or completing, a new one.
+	IsEndFrame() bool
+}
+
 // XProtocol provides extra ability(Heartbeater, Hijacker) to interacts with the proxy framework based on the Protocol interface.
 // e.g. A request which cannot find route should be responded with a error response like '404 Not Found', that is what Hijacker
 // interface exactly provides.
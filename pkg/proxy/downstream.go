@@ -66,6 +66,10 @@ type downStream struct {
 	perRetryTimer   *utils.Timer
 	responseTimer   *utils.Timer
 
+	// attemptedHosts records every host already chosen for this request
+	// (across retries), so the load balancer can avoid picking them again.
+	attemptedHosts []types.Host
+
 	// ~~~ downstream request buf
 	downstreamReqHeaders  types.HeaderMap
 	downstreamReqDataBuf  types.IoBuffer
@@ -184,10 +188,10 @@ func (s *downStream) endStream() {
 
 // Clean up on the very end of the stream: end stream or reset stream
 // Resources to clean up / reset:
-// 	+ upstream request
-// 	+ all timers
-// 	+ all filters
-//  + remove stream in proxy context
+//   - upstream request
+//   - all timers
+//   - all filters
+//   - remove stream in proxy context
 func (s *downStream) cleanStream() {
 	if !atomic.CompareAndSwapUint32(&s.downstreamCleaned, 0, 1) {
 		return
@@ -658,6 +662,13 @@ func (s *downStream) getDownstreamProtocol() (prot types.ProtocolName) {
 }
 
 func (s *downStream) getUpstreamProtocol() (currentProtocol types.ProtocolName) {
+	// a cluster-configured upstream protocol overrides the proxy/route config,
+	// so a cluster can front e.g. an HTTP/2 upstream behind an HTTP/1.1
+	// downstream without the route needing to know about it.
+	if s.cluster != nil && s.cluster.UpstreamProtocol() != "" {
+		return s.cluster.UpstreamProtocol()
+	}
+
 	configProtocol := s.proxy.config.UpstreamProtocol
 
 	// if route exists upstream protocol, it will replace the proxy config's upstream protocol
@@ -721,6 +732,8 @@ func (s *downStream) chooseHost(endStream bool) {
 	s.cluster = s.snapshot.ClusterInfo()
 	s.requestInfo.SetRouteEntry(s.route.RouteRule())
 
+	s.mirrorRequest()
+
 	pool, err := s.initializeUpstreamConnectionPool(s)
 	if err != nil {
 		log.Proxy.Alertf(s.context, types.ErrorKeyUpstreamConn, "initialize Upstream Connection Pool error, request can't be proxyed, error = %v", err)
@@ -952,6 +965,10 @@ func (s *downStream) initializeUpstreamConnectionPool(lbCtx types.LoadBalancerCo
 		return nil, fmt.Errorf("[proxy] [downstream] no healthy upstream in cluster %s", s.cluster.Name())
 	}
 
+	if host := connPool.Host(); host != nil {
+		s.attemptedHosts = append(s.attemptedHosts, host)
+	}
+
 	// TODO: update upstream stats
 
 	return connPool, nil
@@ -1084,15 +1101,28 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 		s.requestInfo.SetResponseFlag(reasonFlag)
 		code = types.ConvertReasonToCode(reason)
 
+		isGRPC := protocol.IsGRPCRequest(s.downstreamReqHeaders)
+
 		if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 			s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+			if isGRPC {
+				s.upstreamRequest.host.HostStats().UpstreamResponseGRPCFailed.Inc(1)
+				s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseGRPCFailed.Inc(1)
+			}
 		}
 
 		// clear reset flag
 		log.Proxy.Infof(s.context, "[proxy] [downstream] onUpstreamReset, send hijack, reason %v", reason)
 		atomic.CompareAndSwapUint32(&s.upstreamReset, 1, 0)
-		s.sendHijackReply(code, s.downstreamReqHeaders)
+		if isGRPC {
+			// gRPC conveys its outcome via the grpc-status trailer, not the
+			// HTTP/2 :status, so a locally-generated failure needs a
+			// grpc-status of its own instead of just an HTTP status code.
+			s.sendGRPCHijackReply(protocol.GRPCStatusFromResetReason(reason), s.downstreamReqHeaders)
+		} else {
+			s.sendHijackReply(code, s.downstreamReqHeaders)
+		}
 	}
 }
 
@@ -1123,6 +1153,7 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 
 	// directResponse for no route should be nil
 	if s.route != nil {
+		s.setPendingAffinityCookie(headers)
 		s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
 	}
 
@@ -1137,16 +1168,52 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 func (s *downStream) handleUpstreamStatusCode() {
 	// todo: support config?
 	if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
+		// gRPC's real outcome is a grpc-status trailer, not the HTTP/2 :status
+		// (which stays 200 regardless), so it is classified separately once
+		// the trailers have arrived, see handleUpstreamGRPCStatus.
+		if protocol.IsGRPCRequest(s.downstreamReqHeaders) {
+			return
+		}
+		host := s.upstreamRequest.host
+		localityStats := host.ClusterInfo().LocalityStats(host.Locality())
 		if s.requestInfo.ResponseCode() >= http.InternalServerError {
-			s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
-			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+			host.HostStats().UpstreamResponseFailed.Inc(1)
+			host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+			localityStats.UpstreamResponseFailed.Inc(1)
 		} else {
-			s.upstreamRequest.host.HostStats().UpstreamResponseSuccess.Inc(1)
-			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseSuccess.Inc(1)
+			host.HostStats().UpstreamResponseSuccess.Inc(1)
+			host.ClusterInfo().Stats().UpstreamResponseSuccess.Inc(1)
+			localityStats.UpstreamResponseSuccess.Inc(1)
 		}
 	}
 }
 
+// handleUpstreamGRPCStatus classifies a completed gRPC response by its
+// grpc-status trailer (or, for a trailers-only response, the response
+// headers) rather than the HTTP/2 :status, which is 200 whether or not the
+// RPC itself succeeded. A response carrying no grpc-status at all counts as
+// a failure: something upstream of gRPC's own error reporting went wrong.
+func (s *downStream) handleUpstreamGRPCStatus() {
+	if s.upstreamRequest == nil || s.upstreamRequest.host == nil {
+		return
+	}
+	if !protocol.IsGRPCRequest(s.downstreamReqHeaders) {
+		return
+	}
+	host := s.upstreamRequest.host
+	status, ok := protocol.GRPCStatusFromHeaderMap(s.downstreamRespTrailers)
+	if !ok {
+		status, ok = protocol.GRPCStatusFromHeaderMap(s.downstreamRespHeaders)
+	}
+	if ok && status == protocol.GRPCStatusOK {
+		host.HostStats().UpstreamResponseGRPCSuccess.Inc(1)
+		host.ClusterInfo().Stats().UpstreamResponseGRPCSuccess.Inc(1)
+	} else {
+		host.HostStats().UpstreamResponseGRPCFailed.Inc(1)
+		host.ClusterInfo().Stats().UpstreamResponseGRPCFailed.Inc(1)
+	}
+}
+
 func (s *downStream) onUpstreamData(endStream bool) {
 	if endStream {
 		s.onUpstreamResponseRecvFinished()
@@ -1186,6 +1253,8 @@ func (s *downStream) onUpstreamResponseRecvFinished() {
 		s.upstreamRequest.resetStream()
 	}
 
+	s.handleUpstreamGRPCStatus()
+
 	// todo: stats
 	// todo: logs
 
@@ -1280,6 +1349,31 @@ func (s *downStream) sendHijackReply(code int, headers types.HeaderMap) {
 	s.directResponse = true
 }
 
+// sendGRPCHijackReply builds a locally-generated response for a gRPC
+// request that failed before an upstream response arrived. Per gRPC's
+// HTTP/2 mapping the transport-level status stays 200 regardless of the
+// RPC's outcome; the real outcome goes in the grpc-status (and
+// grpc-message) trailer instead.
+func (s *downStream) sendGRPCHijackReply(grpcStatus int, headers types.HeaderMap) {
+	log.Proxy.Warnf(s.context, "[proxy] [downstream] set grpc hijack reply, proxyId = %d, grpc-status = %d, with headers = %t", s.ID, grpcStatus, headers == nil)
+	if headers == nil {
+		raw := make(map[string]string, 5)
+		headers = protocol.CommonHeader(raw)
+	}
+	s.requestInfo.SetResponseCode(http.OK)
+
+	headers.Set(types.HeaderStatus, strconv.Itoa(http.OK))
+
+	trailers := protocol.CommonHeader(make(map[string]string, 2))
+	trailers.Set(protocol.HeaderGRPCStatus, strconv.Itoa(grpcStatus))
+
+	atomic.StoreUint32(&s.reuseBuffer, 0)
+	s.downstreamRespHeaders = headers
+	s.downstreamRespDataBuf = nil
+	s.downstreamRespTrailers = trailers
+	s.directResponse = true
+}
+
 // TODO: rpc status code may be not matched
 // TODO: rpc content(body) is not matched the headers, rpc should not hijack with body, use sendHijackReply instead
 func (s *downStream) sendHijackReplyWithBody(code int, headers types.HeaderMap, body string) {
@@ -1358,11 +1452,19 @@ func (s *downStream) AddStreamAccessLog(accessLog api.AccessLog) {
 
 // types.LoadBalancerContext
 func (s *downStream) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	var criteria api.MetadataMatchCriteria
 	if nil != s.requestInfo.RouteEntry() {
-		return s.requestInfo.RouteEntry().MetadataMatchCriteria(s.cluster.Name())
+		criteria = s.requestInfo.RouteEntry().MetadataMatchCriteria(s.cluster.Name())
 	}
 
-	return nil
+	dynamic, ok := mosnctx.Get(s.context, types.ContextKeyDynamicMetadataMatchCriteria).(map[string]interface{})
+	if !ok || len(dynamic) == 0 {
+		return criteria
+	}
+	if criteria == nil {
+		criteria = router.NewMetadataMatchCriteriaImpl(nil)
+	}
+	return criteria.MergeMatchCriteria(dynamic)
 }
 
 func (s *downStream) DownstreamConnection() net.Conn {
@@ -1385,6 +1487,48 @@ func (s *downStream) DownstreamRoute() api.Route {
 	return s.route
 }
 
+// PreviousHosts returns the hosts already attempted for this request. It is
+// not part of types.LoadBalancerContext; load balancers that want to avoid
+// re-selecting a failed host on retry type-assert for it.
+func (s *downStream) PreviousHosts() []types.Host {
+	return s.attemptedHosts
+}
+
+// affinityCookieGenerator is implemented by HashPolicy implementations that
+// can issue a new affinity cookie when a request doesn't carry one (e.g.
+// cookieHashPolicyImpl). It is not part of api.HashPolicy; the proxy
+// type-asserts for it after choosing a host.
+type affinityCookieGenerator interface {
+	PendingCookie(ctx context.Context) (name, value string, maxAge int, path string, ok bool)
+}
+
+// setPendingAffinityCookie issues a Set-Cookie header for session affinity
+// if the route's hash policy wants one and the request didn't already carry
+// one, so subsequent requests from the same client stick to the same host.
+func (s *downStream) setPendingAffinityCookie(headers types.HeaderMap) {
+	policy := s.route.RouteRule().Policy()
+	if policy == nil {
+		return
+	}
+	hashPolicy := policy.HashPolicy()
+	if hashPolicy == nil {
+		return
+	}
+	generator, ok := hashPolicy.(affinityCookieGenerator)
+	if !ok {
+		return
+	}
+	name, value, maxAge, path, ok := generator.PendingCookie(s.context)
+	if !ok {
+		return
+	}
+	cookie := fmt.Sprintf("%s=%s; Max-Age=%d", name, value, maxAge)
+	if path != "" {
+		cookie += "; Path=" + path
+	}
+	headers.Add("Set-Cookie", cookie)
+}
+
 func (s *downStream) giveStream() {
 	if atomic.LoadUint32(&s.reuseBuffer) != 1 {
 		return
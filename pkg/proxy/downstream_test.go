@@ -30,6 +30,7 @@ import (
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
 	"mosn.io/pkg/buffer"
 
 	mosnctx "mosn.io/mosn/pkg/context"
@@ -160,6 +161,44 @@ func TestDirectResponse(t *testing.T) {
 	}
 }
 
+// fakeClusterInfoWithUpstreamProtocol is a minimal types.ClusterInfo stub
+// that only overrides UpstreamProtocol, for exercising the override in
+// getUpstreamProtocol without pulling in a full cluster.
+type fakeClusterInfoWithUpstreamProtocol struct {
+	types.ClusterInfo
+	upstreamProtocol types.ProtocolName
+}
+
+func (ci *fakeClusterInfoWithUpstreamProtocol) UpstreamProtocol() types.ProtocolName {
+	return ci.upstreamProtocol
+}
+
+func TestGetUpstreamProtocolClusterOverride(t *testing.T) {
+	s := &downStream{
+		context: context.Background(),
+		proxy: &proxy{
+			config: &v2.Proxy{UpstreamProtocol: string(mockProtocol)},
+		},
+		route:   &mockRoute{},
+		cluster: &fakeClusterInfoWithUpstreamProtocol{upstreamProtocol: types.ProtocolName("altMockProtocol")},
+	}
+	assert.Equal(t, types.ProtocolName("altMockProtocol"), s.getUpstreamProtocol(),
+		"cluster's UpstreamProtocol should override the proxy/route-chosen upstream protocol")
+}
+
+func TestGetUpstreamProtocolNoClusterOverrideUsesConfig(t *testing.T) {
+	s := &downStream{
+		context: context.Background(),
+		proxy: &proxy{
+			config: &v2.Proxy{UpstreamProtocol: string(mockProtocol)},
+		},
+		route:   &mockRoute{},
+		cluster: &fakeClusterInfoWithUpstreamProtocol{},
+	}
+	assert.Equal(t, mockProtocol, s.getUpstreamProtocol(),
+		"proxy config's upstream protocol should be used when the cluster has no override")
+}
+
 func TestSetDownstreamRouter(t *testing.T) {
 	s := &downStream{
 		context: context.Background(),
@@ -342,3 +381,105 @@ func TestProcessError(t *testing.T) {
 		t.Errorf("TestprocessError Error")
 	}
 }
+
+func TestHandleUpstreamStatusCodeRecordsLocalityStats(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "locality_downstream_test_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	c := cluster.NewCluster(clusterConfig)
+	info := c.Snapshot().ClusterInfo()
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:  "127.0.0.1:19101",
+			Locality: "az1",
+		},
+	}, info)
+
+	requestInfo := &network.RequestInfo{}
+	requestInfo.SetResponseCode(200)
+	s := &downStream{
+		requestInfo:     requestInfo,
+		upstreamRequest: &upstreamRequest{host: host},
+	}
+	s.handleUpstreamStatusCode()
+
+	requestInfo = &network.RequestInfo{}
+	requestInfo.SetResponseCode(503)
+	s = &downStream{
+		requestInfo:     requestInfo,
+		upstreamRequest: &upstreamRequest{host: host},
+	}
+	s.handleUpstreamStatusCode()
+
+	localityStats := info.LocalityStats("az1")
+	if localityStats.UpstreamResponseSuccess.Count() != 1 {
+		t.Errorf("expected az1 success count 1, got %d", localityStats.UpstreamResponseSuccess.Count())
+	}
+	if localityStats.UpstreamResponseFailed.Count() != 1 {
+		t.Errorf("expected az1 failed count 1, got %d", localityStats.UpstreamResponseFailed.Count())
+	}
+}
+
+func TestHandleUpstreamStatusCodeSkipsGRPCRequests(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "grpc_downstream_test_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	c := cluster.NewCluster(clusterConfig)
+	info := c.Snapshot().ClusterInfo()
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:19102",
+		},
+	}, info)
+
+	requestInfo := &network.RequestInfo{}
+	requestInfo.SetResponseCode(200)
+	s := &downStream{
+		requestInfo:          requestInfo,
+		upstreamRequest:      &upstreamRequest{host: host},
+		downstreamReqHeaders: protocol.CommonHeader{"content-type": "application/grpc"},
+	}
+	s.handleUpstreamStatusCode()
+
+	if host.HostStats().UpstreamResponseSuccess.Count() != 0 {
+		t.Errorf("expected a gRPC request to skip the HTTP status classification, got success count %d", host.HostStats().UpstreamResponseSuccess.Count())
+	}
+	if host.HostStats().UpstreamResponseFailed.Count() != 0 {
+		t.Errorf("expected a gRPC request to skip the HTTP status classification, got failed count %d", host.HostStats().UpstreamResponseFailed.Count())
+	}
+}
+
+func TestHandleUpstreamGRPCStatus(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "grpc_status_test_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	c := cluster.NewCluster(clusterConfig)
+	info := c.Snapshot().ClusterInfo()
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:19103",
+		},
+	}, info)
+
+	newStream := func(trailers types.HeaderMap) *downStream {
+		return &downStream{
+			upstreamRequest:        &upstreamRequest{host: host},
+			downstreamReqHeaders:   protocol.CommonHeader{"content-type": "application/grpc"},
+			downstreamRespTrailers: trailers,
+		}
+	}
+
+	newStream(protocol.CommonHeader{"grpc-status": "0"}).handleUpstreamGRPCStatus()
+	newStream(protocol.CommonHeader{"grpc-status": "13"}).handleUpstreamGRPCStatus()
+	newStream(nil).handleUpstreamGRPCStatus()
+
+	if host.HostStats().UpstreamResponseGRPCSuccess.Count() != 1 {
+		t.Errorf("expected 1 gRPC success, got %d", host.HostStats().UpstreamResponseGRPCSuccess.Count())
+	}
+	if host.HostStats().UpstreamResponseGRPCFailed.Count() != 2 {
+		t.Errorf("expected 2 gRPC failures (a non-OK status and a missing status), got %d", host.HostStats().UpstreamResponseGRPCFailed.Count())
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// shadowPercentage is implemented by api.ShadowPolicy values that also
+// carry a mirror percentage (router.shadowPolicyImpl does, via the exported
+// router.MirrorPercentage interface). It is not part of api.ShadowPolicy,
+// so mirrorRequest type-asserts for it, the same way subset_loadbalancer.go
+// asserts for SubsetFallbackPolicy.
+type shadowPercentage interface {
+	MirrorPercent() uint32
+}
+
+// mirrorRequest duplicates the downstream request to the route's shadow
+// cluster, if one is configured and the mirror percentage roll passes. The
+// mirrored request runs over the shadow cluster's own connection pool, so it
+// draws from that cluster's own resource manager and circuit breakers rather
+// than the primary cluster's, and is fire-and-forget: its response is
+// discarded and any failure is only logged, never surfaced to the
+// downstream caller.
+func (s *downStream) mirrorRequest() {
+	policy := s.route.RouteRule().Policy()
+	if policy == nil {
+		return
+	}
+	sp := policy.ShadowPolicy()
+	if sp == nil || sp.ClusterName() == "" {
+		return
+	}
+	percent := uint32(100)
+	if withPercent, ok := sp.(shadowPercentage); ok {
+		percent = withPercent.MirrorPercent()
+	}
+	if percent == 0 {
+		return
+	}
+	if percent < 100 && uint32(rand.Intn(100)) >= percent {
+		return
+	}
+
+	clusterName := sp.ClusterName()
+	snapshot := s.proxy.clusterManager.GetClusterSnapshot(s.context, clusterName)
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		log.Proxy.Warnf(s.context, "[proxy] [downstream] mirror cluster %s not found", clusterName)
+		return
+	}
+
+	pool := s.proxy.clusterManager.ConnPoolForCluster(s, snapshot, s.getUpstreamProtocol())
+	if pool == nil {
+		log.Proxy.Warnf(s.context, "[proxy] [downstream] no available host to mirror request to cluster %s", clusterName)
+		return
+	}
+
+	headers := s.downstreamReqHeaders.Clone()
+	var data types.IoBuffer
+	if s.downstreamReqDataBuf != nil {
+		data = s.downstreamReqDataBuf.Clone()
+	}
+	var trailers types.HeaderMap
+	if s.downstreamReqTrailers != nil {
+		trailers = s.downstreamReqTrailers.Clone()
+	}
+
+	pool.NewStream(s.context, &discardStreamReceiver{}, &mirrorEventListener{
+		ctx:      s.context,
+		cluster:  clusterName,
+		headers:  headers,
+		data:     data,
+		trailers: trailers,
+	})
+}
+
+// mirrorEventListener sends a mirrored request once its connection is
+// ready, ignoring the pool's success/failure result beyond logging it -
+// mirror traffic never affects the primary response.
+type mirrorEventListener struct {
+	ctx      context.Context
+	cluster  string
+	headers  types.HeaderMap
+	data     types.IoBuffer
+	trailers types.HeaderMap
+}
+
+func (l *mirrorEventListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	log.Proxy.Warnf(l.ctx, "[proxy] [downstream] mirror request to cluster %s failed to get a connection, reason: %v", l.cluster, reason)
+}
+
+func (l *mirrorEventListener) OnReady(sender types.StreamSender, host types.Host) {
+	endStream := l.data == nil && l.trailers == nil
+	if err := sender.AppendHeaders(l.ctx, l.headers, endStream); err != nil {
+		log.Proxy.Warnf(l.ctx, "[proxy] [downstream] mirror request to cluster %s append headers failed: %v", l.cluster, err)
+		return
+	}
+	if endStream {
+		return
+	}
+	if l.data != nil {
+		endStream = l.trailers == nil
+		if err := sender.AppendData(l.ctx, l.data, endStream); err != nil {
+			log.Proxy.Warnf(l.ctx, "[proxy] [downstream] mirror request to cluster %s append data failed: %v", l.cluster, err)
+			return
+		}
+		if endStream {
+			return
+		}
+	}
+	if l.trailers != nil {
+		if err := sender.AppendTrailers(l.ctx, l.trailers); err != nil {
+			log.Proxy.Warnf(l.ctx, "[proxy] [downstream] mirror request to cluster %s append trailers failed: %v", l.cluster, err)
+		}
+	}
+}
+
+// discardStreamReceiver discards a mirrored request's response - shadow
+// traffic responses are never used.
+type discardStreamReceiver struct{}
+
+func (d *discardStreamReceiver) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+}
+
+func (d *discardStreamReceiver) OnDecodeError(ctx context.Context, err error, headers types.HeaderMap) {
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockShadowPolicy struct {
+	cluster string
+	percent uint32
+}
+
+func (p *mockShadowPolicy) ClusterName() string   { return p.cluster }
+func (p *mockShadowPolicy) RuntimeKey() string    { return "" }
+func (p *mockShadowPolicy) MirrorPercent() uint32 { return p.percent }
+
+type mockMirrorPolicy struct {
+	api.Policy
+	shadow api.ShadowPolicy
+}
+
+func (p *mockMirrorPolicy) ShadowPolicy() api.ShadowPolicy {
+	return p.shadow
+}
+
+type mockMirrorRouteRule struct {
+	mockRouteRule
+	policy api.Policy
+}
+
+func (r *mockMirrorRouteRule) Policy() api.Policy {
+	return r.policy
+}
+
+type mockMirrorClusterManager struct {
+	mockClusterManager
+	newStreamCalled bool
+}
+
+func (m *mockMirrorClusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol api.Protocol) types.ConnectionPool {
+	return &mockMirrorConnPool{cm: m}
+}
+
+type mockMirrorConnPool struct {
+	types.ConnectionPool
+	cm *mockMirrorClusterManager
+}
+
+func (p *mockMirrorConnPool) NewStream(ctx context.Context, receiver types.StreamReceiveListener, listener types.PoolEventListener) {
+	p.cm.newStreamCalled = true
+	listener.OnReady(&mockMirrorStreamSender{}, nil)
+}
+
+type mockMirrorStreamSender struct {
+	types.StreamSender
+}
+
+func (s *mockMirrorStreamSender) AppendHeaders(ctx context.Context, headers api.HeaderMap, endStream bool) error {
+	return nil
+}
+
+func newMirrorTestDownStream(percent uint32) (*downStream, *mockMirrorClusterManager) {
+	cm := &mockMirrorClusterManager{}
+	s := &downStream{
+		context: context.Background(),
+		proxy: &proxy{
+			config:         &v2.Proxy{},
+			clusterManager: cm,
+		},
+		route: &mockRoute{
+			rule: &mockMirrorRouteRule{
+				policy: &mockMirrorPolicy{
+					shadow: &mockShadowPolicy{cluster: "shadowCluster", percent: percent},
+				},
+			},
+		},
+		downstreamReqHeaders: protocol.CommonHeader{},
+	}
+	return s, cm
+}
+
+func TestMirrorRequestSkippedWhenPercentZero(t *testing.T) {
+	s, cm := newMirrorTestDownStream(0)
+	s.mirrorRequest()
+	if cm.newStreamCalled {
+		t.Error("mirrorRequest should not dispatch a request when percent is 0")
+	}
+}
+
+func TestMirrorRequestDispatchedWhenPercentFull(t *testing.T) {
+	s, cm := newMirrorTestDownStream(100)
+	s.mirrorRequest()
+	if !cm.newStreamCalled {
+		t.Error("mirrorRequest should dispatch a request when percent is 100")
+	}
+}
+
+func TestMirrorRequestNoShadowPolicy(t *testing.T) {
+	cm := &mockMirrorClusterManager{}
+	s := &downStream{
+		context: context.Background(),
+		proxy: &proxy{
+			config:         &v2.Proxy{},
+			clusterManager: cm,
+		},
+		route:                &mockRoute{},
+		downstreamReqHeaders: protocol.CommonHeader{},
+	}
+	s.mirrorRequest()
+	if cm.newStreamCalled {
+		t.Error("mirrorRequest should not dispatch a request when no shadow policy is configured")
+	}
+}
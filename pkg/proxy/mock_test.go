@@ -97,6 +97,10 @@ func (c *mockRouteRule) FinalizeResponseHeaders(headers api.HeaderMap, requestIn
 	return
 }
 
+func (r *mockRouteRule) Policy() api.Policy {
+	return nil
+}
+
 type mockDirectRule struct {
 	status int
 	body   string
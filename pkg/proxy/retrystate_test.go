@@ -65,6 +65,7 @@ func (r *fakeResource) Decrease()           {}
 func (r *fakeResource) Max() uint64         { return 10 }
 func (r *fakeResource) Cur() int64          { return 5 }
 func (r *fakeResource) UpdateCur(cur int64) {}
+func (r *fakeResource) Remaining() int64    { return 5 }
 
 func TestRetryState(t *testing.T) {
 	rcfg := &v2.Router{}
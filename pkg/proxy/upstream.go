@@ -28,6 +28,7 @@ import (
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
 )
 
 // types.StreamEventListener
@@ -76,6 +77,11 @@ func (r *upstreamRequest) OnResetStream(reason types.StreamResetReason) {
 	if r.setupRetry {
 		return
 	}
+
+	if r.host != nil && isOutlierGatewayFailure(reason) {
+		cluster.RecordOutlierError(r.host, true)
+	}
+
 	// todo: check if we get a reset on encode request headers. e.g. send failed
 	if !atomic.CompareAndSwapUint32(&r.downStream.upstreamReset, 0, 1) {
 		return
@@ -85,6 +91,19 @@ func (r *upstreamRequest) OnResetStream(reason types.StreamResetReason) {
 	r.downStream.sendNotify()
 }
 
+// isOutlierGatewayFailure reports whether reason represents a connection or
+// request failure that passive outlier detection counts as a "gateway
+// failure", as opposed to e.g. a local resource overflow that is not the
+// upstream host's fault.
+func isOutlierGatewayFailure(reason types.StreamResetReason) bool {
+	switch reason {
+	case types.StreamConnectionFailed, types.StreamLocalReset, types.StreamRemoteReset,
+		types.UpstreamReset, types.UpstreamGlobalTimeout, types.UpstreamPerTryTimeout:
+		return true
+	}
+	return false
+}
+
 func (r *upstreamRequest) OnDestroyStream() {}
 
 func (r *upstreamRequest) endStream() {
@@ -93,6 +112,10 @@ func (r *upstreamRequest) endStream() {
 	r.host.HostStats().UpstreamRequestDurationTotal.Inc(upstreamResponseDurationNs)
 	r.host.ClusterInfo().Stats().UpstreamRequestDuration.Update(upstreamResponseDurationNs)
 	r.host.ClusterInfo().Stats().UpstreamRequestDurationTotal.Inc(upstreamResponseDurationNs)
+	localityStats := r.host.ClusterInfo().LocalityStats(r.host.Locality())
+	localityStats.UpstreamRequestTotal.Inc(1)
+	localityStats.UpstreamRequestDuration.Update(upstreamResponseDurationNs)
+	localityStats.UpstreamRequestDurationTotal.Inc(upstreamResponseDurationNs)
 
 	// todo: record upstream process time in request info
 }
@@ -108,6 +131,11 @@ func (r *upstreamRequest) OnReceive(ctx context.Context, headers types.HeaderMap
 
 	if code, err := protocol.MappingHeaderStatusCode(r.downStream.context, r.protocol, headers); err == nil {
 		r.downStream.requestInfo.SetResponseCode(code)
+		if code >= 500 {
+			cluster.RecordOutlierError(r.host, code == 502 || code == 503 || code == 504)
+		} else {
+			cluster.RecordOutlierSuccess(r.host)
+		}
 	}
 
 	r.downStream.requestInfo.SetResponseReceivedDuration(time.Now())
@@ -280,6 +308,11 @@ func (r *upstreamRequest) OnReady(sender types.StreamSender, host types.Host) {
 	// start a upstream send
 	r.startTime = time.Now()
 
+	if protocol.IsGRPCRequest(r.downStream.downstreamReqHeaders) {
+		r.host.HostStats().UpstreamRequestGRPCTotal.Inc(1)
+		r.host.ClusterInfo().Stats().UpstreamRequestGRPCTotal.Inc(1)
+	}
+
 	r.downStream.requestInfo.OnUpstreamHostSelected(host)
 	r.downStream.requestInfo.SetUpstreamLocalAddress(host.AddressString())
 
@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+func TestUpstreamRequestEndStreamRecordsLocalityStats(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "locality_upstream_test_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	c := cluster.NewCluster(clusterConfig)
+	info := c.Snapshot().ClusterInfo()
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:  "127.0.0.1:19102",
+			Locality: "az1",
+		},
+	}, info)
+
+	r := &upstreamRequest{host: host, startTime: time.Now()}
+	r.endStream()
+	r = &upstreamRequest{host: host, startTime: time.Now()}
+	r.endStream()
+
+	localityStats := info.LocalityStats("az1")
+	if localityStats.UpstreamRequestTotal.Count() != 2 {
+		t.Errorf("expected az1 request total 2, got %d", localityStats.UpstreamRequestTotal.Count())
+	}
+	if localityStats.UpstreamRequestDurationTotal.Count() == 0 {
+		t.Errorf("expected az1 duration total to be recorded, got 0")
+	}
+}
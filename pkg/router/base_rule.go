@@ -83,7 +83,11 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleI
 		lock: sync.Mutex{},
 	}
 	// add clusters
-	base.weightedClusters, base.totalClusterWeight = getWeightedClusterEntry(route.Route.WeightedClusters)
+	var vHostName string
+	if vHost != nil {
+		vHostName = vHost.Name()
+	}
+	base.weightedClusters, base.totalClusterWeight = getWeightedClusterEntry(vHostName, route.Route.WeightedClusters)
 	if len(route.Route.MetadataMatch) > 0 {
 		base.defaultCluster.clusterMetadataMatchCriteria = NewMetadataMatchCriteriaImpl(route.Route.MetadataMatch)
 	}
@@ -95,26 +99,61 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleI
 			numRetries:   route.Route.RetryPolicy.NumRetries,
 		}
 	}
-	// add hash policy
-	if route.Route.HashPolicy != nil && len(route.Route.HashPolicy) >= 1 {
-		hp := route.Route.HashPolicy[0]
-		if hp.Header != nil {
-			base.policy.hashPolicy = &headerHashPolicyImpl{
-				key: hp.Header.Key,
-			}
+	// add shadow policy
+	if route.Route.RequestMirrorPolicy != nil {
+		base.policy.shadowPolicy = &shadowPolicyImpl{
+			cluster: route.Route.RequestMirrorPolicy.Cluster,
+			percent: route.Route.RequestMirrorPolicy.Percent,
 		}
-		if hp.Cookie != nil {
-			base.policy.hashPolicy = &cookieHashPolicyImpl{
-				name: hp.Cookie.Name,
-				path: hp.Cookie.Path,
-				ttl:  hp.Cookie.TTL,
+	}
+	// add hash policy chain: each configured entry is evaluated in order,
+	// with Terminal controlling whether the chain stops at that entry
+	if len(route.Route.HashPolicy) >= 1 {
+		policies := make([]terminalHashPolicy, 0, len(route.Route.HashPolicy))
+		for _, hp := range route.Route.HashPolicy {
+			var impl api.HashPolicy
+			switch {
+			case hp.Header != nil:
+				impl = &headerHashPolicyImpl{key: hp.Header.Key}
+			case hp.Cookie != nil:
+				impl = &cookieHashPolicyImpl{
+					name: hp.Cookie.Name,
+					path: hp.Cookie.Path,
+					ttl:  hp.Cookie.TTL,
+				}
+			case hp.SourceIP != nil:
+				impl = &sourceIPHashPolicyImpl{}
+			case hp.QueryParameter != nil:
+				impl = &queryParameterHashPolicyImpl{name: hp.QueryParameter.Name}
 			}
+			if impl != nil {
+				policies = append(policies, terminalHashPolicy{policy: impl, terminal: hp.Terminal})
+			}
+		}
+		switch len(policies) {
+		case 0:
+		case 1:
+			base.policy.hashPolicy = policies[0].policy
+		default:
+			base.policy.hashPolicy = &hashPolicyChain{policies: policies}
 		}
 	}
 	// use source ip hash policy as default hash policy
 	if base.policy.hashPolicy == nil {
 		base.policy.hashPolicy = &sourceIPHashPolicyImpl{}
 	}
+	// add subset fallback policy override
+	switch route.Route.SubsetFallbackPolicy {
+	case "no_fallback":
+		base.policy.subsetFallbackPolicy = types.NoFallBack
+		base.policy.hasSubsetFallback = true
+	case "any_endpoint":
+		base.policy.subsetFallbackPolicy = types.AnyEndPoint
+		base.policy.hasSubsetFallback = true
+	case "default_subset":
+		base.policy.subsetFallbackPolicy = types.DefaultSubset
+		base.policy.hasSubsetFallback = true
+	}
 	// add direct repsonse rule
 	if route.DirectResponse != nil {
 		base.directResponseRule = &directResponseImpl{
@@ -146,6 +185,7 @@ func (rri *RouteRuleImplBase) ClusterName() string {
 	for _, weightCluster := range rri.weightedClusters {
 		selectedValue = selectedValue - int(weightCluster.clusterWeight)
 		if selectedValue <= 0 {
+			weightCluster.selectedTotal.Inc(1)
 			return weightCluster.clusterName
 		}
 	}
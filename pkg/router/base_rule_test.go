@@ -157,6 +157,35 @@ func TestWeightedClusterSelect(t *testing.T) {
 	}
 }
 
+func TestShadowPolicy(t *testing.T) {
+	// no mirror policy configured
+	routerMock1 := &v2.Router{}
+	routerMock1.Route = v2.RouteAction{
+		RouterActionConfig: v2.RouterActionConfig{
+			ClusterName: "defaultCluster",
+		},
+	}
+	rb, err := NewRouteRuleImplBase(nil, routerMock1)
+	assert.NoErrorf(t, err, "new routerule impl failed %+v", err)
+	assert.Nilf(t, rb.Policy().ShadowPolicy(), "shadow policy should be nil when not configured")
+
+	// mirror policy configured
+	routerMock1.Route.RequestMirrorPolicy = &v2.RequestMirrorPolicy{
+		Cluster: "shadowCluster",
+		Percent: 30,
+	}
+	rb, err = NewRouteRuleImplBase(nil, routerMock1)
+	assert.NoErrorf(t, err, "new routerule impl failed %+v", err)
+	sp := rb.Policy().ShadowPolicy()
+	assert.NotNilf(t, sp, "shadow policy should not be nil")
+	assert.Equalf(t, "shadowCluster", sp.ClusterName(), "shadow policy cluster name mismatch")
+	withPercent, ok := sp.(MirrorPercentage)
+	assert.Truef(t, ok, "shadow policy should implement MirrorPercentage")
+	if ok {
+		assert.Equalf(t, uint32(30), withPercent.MirrorPercent(), "shadow policy percent mismatch")
+	}
+}
+
 func Test_RouteRuleImplBase_matchRoute_matchMethod(t *testing.T) {
 	route := &v2.Router{
 		RouterConfig: v2.RouterConfig{
@@ -599,7 +628,7 @@ func TestParseHashPolicy(t *testing.T) {
 				{
 					Header: &v2.HeaderHashPolicy{Key: "header_key"},
 				},
-				// test parse first hash policy
+				// test parse a chain of multiple hash policies
 				{
 					SourceIP: &v2.SourceIPHashPolicy{},
 				},
@@ -609,11 +638,18 @@ func TestParseHashPolicy(t *testing.T) {
 
 	rb, err := NewRouteRuleImplBase(nil, routerMock1)
 	assert.NoErrorf(t, err, "new routerule impl failed %+v", err)
-	headerHp, ok := rb.policy.hashPolicy.(*headerHashPolicyImpl)
-	assert.Truef(t, ok, "hash policy should be headerHashPolicyImpl type")
+	chain, ok := rb.policy.hashPolicy.(*hashPolicyChain)
+	assert.Truef(t, ok, "hash policy should be hashPolicyChain type")
 	if ok {
-		assert.Equalf(t, "header_key", headerHp.key,
-			"headerHashPolicyImpl key should be 'header_key'")
+		assert.Lenf(t, chain.policies, 2, "hash policy chain should have 2 entries")
+		headerHp, ok := chain.policies[0].policy.(*headerHashPolicyImpl)
+		assert.Truef(t, ok, "first chain entry should be headerHashPolicyImpl type")
+		if ok {
+			assert.Equalf(t, "header_key", headerHp.key,
+				"headerHashPolicyImpl key should be 'header_key'")
+		}
+		_, ok = chain.policies[1].policy.(*sourceIPHashPolicyImpl)
+		assert.Truef(t, ok, "second chain entry should be sourceIPHashPolicyImpl type")
 	}
 
 	// test parse each type of hash policy
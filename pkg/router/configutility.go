@@ -18,6 +18,7 @@
 package router
 
 import (
+	"fmt"
 	"mosn.io/mosn/pkg/protocol"
 	"regexp"
 	"sort"
@@ -137,10 +138,21 @@ func (mmcti *MetadataMatchCriteriaImpl) MetadataMatchCriteria() []api.MetadataMa
 	return mmcti.MatchCriteriaArray
 }
 
-// MergeMatchCriteria
-// No usage currently
+// MergeMatchCriteria returns a new MetadataMatchCriteria containing mmcti's
+// criteria with metadataMatches merged in; a key present in both keeps the
+// value from metadataMatches. This lets dynamic metadata contributed by a
+// stream filter at load balancing time (e.g. extracted from a header or a
+// JWT claim) override the static per-route metadata match configured for
+// the route, without mutating the route's own criteria.
 func (mmcti *MetadataMatchCriteriaImpl) MergeMatchCriteria(metadataMatches map[string]interface{}) api.MetadataMatchCriteria {
-	return nil
+	stringMatches := make(map[string]string, len(metadataMatches))
+	for k, v := range metadataMatches {
+		stringMatches[k] = fmt.Sprintf("%v", v)
+	}
+
+	merged := &MetadataMatchCriteriaImpl{}
+	merged.extractMetadataMatchCriteria(mmcti, stringMatches)
+	return merged
 }
 
 func (mmcti *MetadataMatchCriteriaImpl) Len() int {
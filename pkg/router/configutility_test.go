@@ -215,3 +215,29 @@ func TestMetadataMatchCriteriaImplSort(t *testing.T) {
 		}
 	}
 }
+
+func TestMetadataMatchCriteriaImplMergeMatchCriteria(t *testing.T) {
+	base := NewMetadataMatchCriteriaImpl(map[string]string{
+		"version": "v1",
+		"label":   "green",
+	})
+
+	merged := base.MergeMatchCriteria(map[string]interface{}{
+		"version": "v2",
+		"region":  "us-west",
+	})
+
+	want := []api.MetadataMatchCriterion{
+		&MetadataMatchCriterionImpl{Name: "label", Value: "green"},
+		&MetadataMatchCriterionImpl{Name: "region", Value: "us-west"},
+		&MetadataMatchCriterionImpl{Name: "version", Value: "v2"},
+	}
+	if !reflect.DeepEqual(merged.MetadataMatchCriteria(), want) {
+		t.Errorf("MergeMatchCriteria() = %v, want %v", merged.MetadataMatchCriteria(), want)
+	}
+
+	// base criteria must be unaffected by the merge
+	if len(base.MetadataMatchCriteria()) != 2 {
+		t.Errorf("base criteria mutated by MergeMatchCriteria()")
+	}
+}
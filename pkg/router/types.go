@@ -19,7 +19,9 @@ package router
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
@@ -27,6 +29,7 @@ import (
 	"time"
 
 	"github.com/dchest/siphash"
+	gometrics "github.com/rcrowley/go-metrics"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/variable"
 
@@ -77,6 +80,11 @@ type weightedClusterEntry struct {
 	clusterName                  string
 	clusterWeight                uint32
 	clusterMetadataMatchCriteria *MetadataMatchCriteriaImpl
+	// selectedTotal counts how many times this split has been chosen by
+	// ClusterName, so each split's actual traffic share can be observed
+	// independently of the others. nil for the route's default cluster,
+	// which isn't part of a weighted split.
+	selectedTotal gometrics.Counter
 }
 
 type Matchable interface {
@@ -91,9 +99,11 @@ type RouteBase interface {
 
 // Policy
 type policy struct {
-	retryPolicy  *retryPolicyImpl
-	shadowPolicy *shadowPolicyImpl //TODO: not implement yet
-	hashPolicy   api.HashPolicy
+	retryPolicy          *retryPolicyImpl
+	shadowPolicy         *shadowPolicyImpl //TODO: not implement yet
+	hashPolicy           api.HashPolicy
+	subsetFallbackPolicy types.FallBackPolicy
+	hasSubsetFallback    bool
 }
 
 func (p *policy) RetryPolicy() api.RetryPolicy {
@@ -101,6 +111,9 @@ func (p *policy) RetryPolicy() api.RetryPolicy {
 }
 
 func (p *policy) ShadowPolicy() api.ShadowPolicy {
+	if p.shadowPolicy == nil {
+		return nil
+	}
 	return p.shadowPolicy
 }
 
@@ -108,6 +121,14 @@ func (p *policy) HashPolicy() api.HashPolicy {
 	return p.hashPolicy
 }
 
+// SubsetFallbackPolicy returns the route's subset load balancer fallback
+// policy override, if one was configured. It is not part of api.Policy;
+// callers that care about the override (the cluster subset load balancer)
+// type-assert for it.
+func (p *policy) SubsetFallbackPolicy() (types.FallBackPolicy, bool) {
+	return p.subsetFallbackPolicy, p.hasSubsetFallback
+}
+
 type retryPolicyImpl struct {
 	retryOn      bool
 	retryTimeout time.Duration
@@ -138,6 +159,7 @@ func (p *retryPolicyImpl) NumRetries() uint32 {
 type shadowPolicyImpl struct {
 	cluster    string
 	runtimeKey string
+	percent    uint32
 }
 
 func (spi *shadowPolicyImpl) ClusterName() string {
@@ -148,6 +170,20 @@ func (spi *shadowPolicyImpl) RuntimeKey() string {
 	return spi.runtimeKey
 }
 
+// MirrorPercent returns the percentage, 0-100, of requests that should be
+// mirrored to the shadow cluster.
+func (spi *shadowPolicyImpl) MirrorPercent() uint32 {
+	return spi.percent
+}
+
+// MirrorPercentage is implemented by api.ShadowPolicy values that also
+// carry a mirror percentage. It is not part of api.ShadowPolicy; callers
+// that need the fraction (the proxy, before duplicating a request)
+// type-assert for it, the same way SubsetFallbackPolicy is exposed above.
+type MirrorPercentage interface {
+	MirrorPercent() uint32
+}
+
 // RouterRuleFactory creates a RouteBase
 type RouterRuleFactory func(base *RouteRuleImplBase, header []v2.HeaderMatcher) RouteBase
 
@@ -182,19 +218,79 @@ func (hp *headerHashPolicyImpl) GenerateHash(ctx context.Context) uint64 {
 	return 0
 }
 
+// queryParameterHashPolicyImpl hashes on the value of a URL query parameter.
+type queryParameterHashPolicyImpl struct {
+	name string
+}
+
+func (hp *queryParameterHashPolicyImpl) GenerateHash(ctx context.Context) uint64 {
+	value, err := variable.GetProtocolResource(ctx, api.ARG, hp.name)
+	if err == nil {
+		return getHashByString(fmt.Sprintf("%s:%s", hp.name, value))
+	}
+	return 0
+}
+
+// terminalHashPolicy pairs a HashPolicy with whether it should stop the
+// chain once it yields a hash.
+type terminalHashPolicy struct {
+	policy   api.HashPolicy
+	terminal bool
+}
+
+// hashPolicyChain evaluates a route's configured hash policies in order,
+// combining every hash they yield, stopping early at the first policy that
+// both yields a hash and is marked terminal. This gives ring-hash/maglev a
+// single, consistent key source built out of multiple signals (header,
+// cookie, source IP, query parameter) instead of only ever looking at the
+// first configured policy.
+type hashPolicyChain struct {
+	policies []terminalHashPolicy
+}
+
+func (c *hashPolicyChain) GenerateHash(ctx context.Context) uint64 {
+	var hash uint64
+	for _, p := range c.policies {
+		h := p.policy.GenerateHash(ctx)
+		if h == 0 {
+			continue
+		}
+		hash ^= h
+		if p.terminal {
+			break
+		}
+	}
+	return hash
+}
+
+// PendingCookie delegates to the first policy in the chain that can
+// generate an affinity cookie, so a chain that includes a cookie hash policy
+// still gets its Set-Cookie behavior (see proxy.affinityCookieGenerator).
+func (c *hashPolicyChain) PendingCookie(ctx context.Context) (name, value string, maxAge int, path string, ok bool) {
+	for _, p := range c.policies {
+		if g, isGenerator := p.policy.(interface {
+			PendingCookie(ctx context.Context) (string, string, int, string, bool)
+		}); isGenerator {
+			return g.PendingCookie(ctx)
+		}
+	}
+	return "", "", 0, "", false
+}
+
 type cookieHashPolicyImpl struct {
 	name string
-	// path and ttl field are used for generate cookie value,
-	// they are not being used currently.
+	// path is used when generating the affinity cookie's Set-Cookie header,
+	// ttl controls the generated cookie's Max-Age.
 	path string
 	ttl  api.DurationConfig
 }
 
 // GenerateHash is httpCookieHashPolicyImpl hash generate logic.
 //
-// !!! please notice, in envoy or istio cookie may be generated if cookie value is not found,
-// MOSN does NOT implement this strategy yet. When cookie value is not found a
-// hash '0' will always be returned.
+// When the incoming request has no affinity cookie yet, a hash '0' is
+// returned so the request is handled by whatever host the consistent-hash
+// LB's default behavior selects; PendingCookie is used by the proxy to
+// issue a cookie for that request so later ones stick to the same host.
 func (hp *cookieHashPolicyImpl) GenerateHash(ctx context.Context) uint64 {
 	cookieName := hp.name
 	cookieValue, err := variable.GetProtocolResource(ctx, api.COOKIE, cookieName)
@@ -205,6 +301,30 @@ func (hp *cookieHashPolicyImpl) GenerateHash(ctx context.Context) uint64 {
 	return 0
 }
 
+// PendingCookie reports the affinity cookie that should be set on the
+// response, if the request did not already carry one. The proxy
+// type-asserts for this optional interface after choosing a host, so plain
+// HashPolicy implementations (header, source IP) are unaffected.
+func (hp *cookieHashPolicyImpl) PendingCookie(ctx context.Context) (name, value string, maxAge int, path string, ok bool) {
+	if _, err := variable.GetProtocolResource(ctx, api.COOKIE, hp.name); err == nil {
+		// request already carries an affinity cookie, nothing to generate
+		return "", "", 0, "", false
+	}
+	return hp.name, newCookieValue(), int(hp.ttl.Duration.Seconds()), hp.path, true
+}
+
+// newCookieValue generates a random affinity cookie value. It does not need
+// to be predictable or tied to the chosen host, only to stay stable across a
+// client's subsequent requests, which the client's cookie jar already
+// guarantees.
+func newCookieValue() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", getHashByString(fmt.Sprintf("%d", time.Now().UnixNano())))
+	}
+	return hex.EncodeToString(b)
+}
+
 type sourceIPHashPolicyImpl struct{}
 
 func (hp *sourceIPHashPolicyImpl) GenerateHash(ctx context.Context) uint64 {
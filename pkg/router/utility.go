@@ -22,17 +22,24 @@ import (
 
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 )
 
-func getWeightedClusterEntry(weightedClusters []v2.WeightedCluster) (map[string]weightedClusterEntry, uint32) {
+// getWeightedClusterEntry builds a split entry per weighted cluster,
+// labeled with virtualHostName so each split's selection count can be
+// told apart from a same-named cluster used as a split target on a
+// different route.
+func getWeightedClusterEntry(virtualHostName string, weightedClusters []v2.WeightedCluster) (map[string]weightedClusterEntry, uint32) {
 	weightedClusterEntries := make(map[string]weightedClusterEntry)
 	var totalWeight uint32
 	for _, weightedCluster := range weightedClusters {
+		stats := metrics.NewWeightedClusterStats(virtualHostName, weightedCluster.Cluster.Name)
 		weightedClusterEntries[weightedCluster.Cluster.Name] = weightedClusterEntry{
 			clusterName:                  weightedCluster.Cluster.Name,
 			clusterWeight:                weightedCluster.Cluster.Weight,
 			clusterMetadataMatchCriteria: NewMetadataMatchCriteriaImpl(weightedCluster.Cluster.MetadataMatch),
+			selectedTotal:                stats.Counter(metrics.RouterWeightedClusterSelectedTotal),
 		}
 		totalWeight = totalWeight + weightedCluster.Cluster.Weight
 	}
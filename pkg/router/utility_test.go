@@ -74,7 +74,16 @@ func Test_getWeightedClusterEntryAndVerify(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			entry, _ := getWeightedClusterEntry(tt.args.weightedClusters)
+			entry, _ := getWeightedClusterEntry("test_vhost", tt.args.weightedClusters)
+			for name, e := range entry {
+				if e.selectedTotal == nil {
+					t.Errorf("expected a selectedTotal counter to be created for cluster %s", name)
+				}
+				// selectedTotal is a stats handle, not part of the parsed config -
+				// zero it out before comparing against the expected entries.
+				e.selectedTotal = nil
+				entry[name] = e
+			}
 			if !reflect.DeepEqual(entry, tt.want.value) {
 				t.Errorf("get weighted cluster entry and verify name = %s got1 = %v, want %v", tt.name, entry, tt.want.value)
 			}
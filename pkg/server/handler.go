@@ -20,6 +20,7 @@ package server
 import (
 	"container/list"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"mosn.io/mosn/pkg/metrics"
@@ -43,6 +44,7 @@ import (
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
 	"mosn.io/pkg/utils"
 )
 
@@ -66,6 +68,7 @@ func NewHandler(clusterManagerFilter types.ClusterManagerFilter, clMng types.Clu
 	}
 
 	clusterManagerFilter.OnCreated(ch, ch)
+	clMng.RegisterClusterManagerFilter(clusterManagerFilter)
 
 	return ch
 }
@@ -726,6 +729,10 @@ func (ac *activeConnection) OnEvent(event api.ConnectionEvent) {
 	}
 }
 
+// maxHealthSnapshotSize bounds the inherited health store payload sent
+// alongside the listener fds during a hot restart
+const maxHealthSnapshotSize = 1 << 20
+
 func sendInheritListeners() (net.Conn, error) {
 	lf := ListListenersFile()
 	if lf == nil {
@@ -768,7 +775,11 @@ func sendInheritListeners() (net.Conn, error) {
 	}
 
 	uc := unixConn.(*net.UnixConn)
-	buf := make([]byte, 1)
+	buf, err := json.Marshal(cluster.DumpHealthStore())
+	if err != nil {
+		log.DefaultLogger.Errorf("[server] marshal health store failed, inherited listeners will start with cold health state: %v", err)
+		buf = []byte("{}")
+	}
 	rights := syscall.UnixRights(fds...)
 	n, oobn, err := uc.WriteMsgUnix(buf, rights, nil)
 	if err != nil {
@@ -776,7 +787,7 @@ func sendInheritListeners() (net.Conn, error) {
 		return nil, err
 	}
 	if n != len(buf) || oobn != len(rights) {
-		log.DefaultLogger.Errorf("[server] WriteMsgUnix = %d, %d; want 1, %d", n, oobn, len(rights))
+		log.DefaultLogger.Errorf("[server] WriteMsgUnix = %d, %d; want %d, %d", n, oobn, len(buf), len(rights))
 		return nil, err
 	}
 
@@ -814,12 +825,21 @@ func GetInheritListeners() ([]net.Listener, net.Conn, error) {
 	}
 	log.StartLogger.Infof("[server] Get InheritListeners Accept")
 
-	buf := make([]byte, 1)
+	buf := make([]byte, maxHealthSnapshotSize)
 	oob := make([]byte, 1024)
-	_, oobn, _, _, err := uc.ReadMsgUnix(buf, oob)
+	n, oobn, _, _, err := uc.ReadMsgUnix(buf, oob)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	var healthSnapshot map[string]api.HealthFlag
+	if err := json.Unmarshal(buf[:n], &healthSnapshot); err != nil {
+		log.StartLogger.Errorf("[server] unmarshal inherited health store failed, starting with cold health state: %v", err)
+	} else {
+		cluster.LoadHealthStore(healthSnapshot)
+		log.StartLogger.Infof("[server] restored %d inherited health flags", len(healthSnapshot))
+	}
+
 	scms, err := unix.ParseSocketControlMessage(oob[0:oobn])
 	if err != nil {
 		log.StartLogger.Errorf("[server] ParseSocketControlMessage: %v", err)
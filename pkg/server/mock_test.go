@@ -29,6 +29,8 @@ type mockClusterManager struct {
 	types.ClusterManager
 }
 
+func (cm *mockClusterManager) RegisterClusterManagerFilter(filter types.ClusterManagerFilter) {}
+
 type mockClusterManagerFilter struct {
 	cccb types.ClusterConfigFactoryCb
 	chcb types.ClusterHostFactoryCb
@@ -39,6 +41,12 @@ func (cmf *mockClusterManagerFilter) OnCreated(cccb types.ClusterConfigFactoryCb
 	cmf.chcb = chcb
 }
 
+func (cmf *mockClusterManagerFilter) OnClusterAdded(clusterName string) {}
+
+func (cmf *mockClusterManagerFilter) OnClusterRemoved(clusterName string) {}
+
+func (cmf *mockClusterManagerFilter) OnHostsChanged(clusterName string, addHosts, delHosts []types.Host) {}
+
 type mockNetworkFilter struct{}
 
 func (nf *mockNetworkFilter) OnData(buffer buffer.IoBuffer) api.FilterStatus {
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// BoundConnPool is a types.ConnectionPool that binds a single upstream
+// connection to its owner for as long as the pool lives, instead of the
+// shared, multi-connection behavior of the per-protocol pools registered
+// through types.RegisterConnPoolFactory. A caller that owns exactly one
+// downstream connection creates a BoundConnPool for a cluster with
+// ConnectionAffinity enabled and keeps it for that downstream connection's
+// lifetime, so every stream it proxies reuses the same upstream connection -
+// the pattern some MQ and database protocols require to keep session or
+// transaction state pinned to a single TCP connection.
+type BoundConnPool struct {
+	protocol           types.ProtocolName
+	createStreamClient func(ctx context.Context, connData types.CreateConnectionData) Client
+
+	host atomic.Value
+
+	mux    sync.Mutex
+	client Client
+}
+
+// NewBoundConnPool creates a BoundConnPool for host. createStreamClient
+// builds the protocol-specific codec client around a freshly created
+// connection, mirroring each protocol pool's own private createStreamClient
+// method.
+func NewBoundConnPool(protocol types.ProtocolName, host types.Host, createStreamClient func(ctx context.Context, connData types.CreateConnectionData) Client) *BoundConnPool {
+	p := &BoundConnPool{
+		protocol:           protocol,
+		createStreamClient: createStreamClient,
+	}
+	p.host.Store(host)
+	return p
+}
+
+func (p *BoundConnPool) Protocol() api.Protocol {
+	return p.protocol
+}
+
+func (p *BoundConnPool) CheckAndInit(ctx context.Context) bool {
+	return true
+}
+
+func (p *BoundConnPool) SupportTLS() bool {
+	return p.Host().SupportTLS()
+}
+
+func (p *BoundConnPool) Host() types.Host {
+	return p.host.Load().(types.Host)
+}
+
+func (p *BoundConnPool) UpdateHost(h types.Host) {
+	p.host.Store(h)
+}
+
+func (p *BoundConnPool) NewStream(ctx context.Context, receiver types.StreamReceiveListener, listener types.PoolEventListener) {
+	host := p.Host()
+	client, reason := p.boundClient(ctx)
+	if client == nil {
+		listener.OnFailure(reason, host)
+		return
+	}
+
+	if !host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+		listener.OnFailure(types.Overflow, host)
+		host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		return
+	}
+	host.HostStats().UpstreamRequestTotal.Inc(1)
+	host.HostStats().UpstreamRequestActive.Inc(1)
+	host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
+	host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
+	host.ClusterInfo().ResourceManager().Requests().Increase()
+
+	streamSender := client.NewStream(ctx, receiver)
+	listener.OnReady(streamSender, host)
+}
+
+// boundClient returns the bound connection's client, dialing it on first
+// use and reusing it on every later call until it closes.
+func (p *BoundConnPool) boundClient(ctx context.Context) (Client, types.PoolFailureReason) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.client != nil {
+		return p.client, ""
+	}
+
+	host := p.Host()
+	var client Client
+	err := RetryConnect(host, func() error {
+		data := host.CreateConnection(ctx)
+		data.Connection.AddConnectionEventListener(p)
+		client = p.createStreamClient(ctx, data)
+		if err := data.Connection.Connect(); err != nil {
+			return err
+		}
+		RecordTLSHandshakeDuration(host, data.Connection)
+		return nil
+	})
+	if err != nil {
+		return nil, types.ConnectionFailure
+	}
+
+	host.HostStats().UpstreamConnectionTotal.Inc(1)
+	host.HostStats().UpstreamConnectionActive.Inc(1)
+	host.ClusterInfo().Stats().UpstreamConnectionTotal.Inc(1)
+	host.ClusterInfo().Stats().UpstreamConnectionActive.Inc(1)
+
+	p.client = client
+	return client, ""
+}
+
+// OnEvent implements api.ConnectionEventListener, releasing the bound
+// connection once it closes so the next stream dials a fresh one.
+func (p *BoundConnPool) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() && !event.ConnectFailure() {
+		return
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.client != nil {
+		host := p.Host()
+		host.HostStats().UpstreamConnectionActive.Dec(1)
+		host.ClusterInfo().Stats().UpstreamConnectionActive.Dec(1)
+		p.client = nil
+	}
+}
+
+func (p *BoundConnPool) Shutdown() {
+	p.Close()
+}
+
+func (p *BoundConnPool) Close() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+type boundTestConnection struct {
+	types.ClientConnection
+	connectErr error
+	listener   api.ConnectionEventListener
+}
+
+func (c *boundTestConnection) AddConnectionEventListener(listener api.ConnectionEventListener) {
+	c.listener = listener
+}
+
+func (c *boundTestConnection) Connect() error {
+	return c.connectErr
+}
+
+func (c *boundTestConnection) TLSHandshakeDuration() time.Duration { return 0 }
+
+type boundTestHost struct {
+	types.Host
+	ci         *retryTestClusterInfo
+	connectErr error
+	dials      int
+}
+
+func (h *boundTestHost) ClusterInfo() types.ClusterInfo { return h.ci }
+
+func (h *boundTestHost) HostStats() types.HostStats {
+	return types.HostStats{
+		UpstreamConnectionTotal:           gometrics.NewCounter(),
+		UpstreamConnectionActive:          gometrics.NewCounter(),
+		UpstreamConnectionIdle:            gometrics.NewGauge(),
+		UpstreamConnectionConnectDuration: gometrics.NewHistogram(gometrics.NewUniformSample(100)),
+		UpstreamRequestQueueDepth:         gometrics.NewGauge(),
+		UpstreamRequestTotal:              gometrics.NewCounter(),
+		UpstreamRequestActive:             gometrics.NewCounter(),
+	}
+}
+
+func (h *boundTestHost) SupportTLS() bool { return false }
+
+func (h *boundTestHost) MaxConnections() uint32 { return 0 }
+
+func (h *boundTestHost) CreateConnection(ctx context.Context) types.CreateConnectionData {
+	h.dials++
+	return types.CreateConnectionData{
+		Connection: &boundTestConnection{connectErr: h.connectErr},
+		Host:       h,
+	}
+}
+
+func newBoundTestHost() *boundTestHost {
+	_, ci := newRetryTestHost(5)
+	ci.stats = types.ClusterStats{
+		UpstreamRequestTotal:              gometrics.NewCounter(),
+		UpstreamRequestActive:             gometrics.NewCounter(),
+		UpstreamRequestPendingOverflow:    gometrics.NewCounter(),
+		UpstreamConnectionTotal:           gometrics.NewCounter(),
+		UpstreamConnectionActive:          gometrics.NewCounter(),
+		UpstreamConnectionRetry:           gometrics.NewCounter(),
+		UpstreamConnectionIdle:            gometrics.NewGauge(),
+		UpstreamConnectionConnectDuration: gometrics.NewHistogram(gometrics.NewUniformSample(100)),
+		UpstreamRequestQueueDepth:         gometrics.NewGauge(),
+	}
+	return &boundTestHost{ci: ci}
+}
+
+type boundTestClient struct {
+	Client
+	conn    *boundTestConnection
+	closed  bool
+	streams int
+}
+
+func (c *boundTestClient) NewStream(ctx context.Context, receiver types.StreamReceiveListener) types.StreamSender {
+	c.streams++
+	return nil
+}
+
+func (c *boundTestClient) AddConnectionEventListener(listener api.ConnectionEventListener) {}
+
+func (c *boundTestClient) Close() { c.closed = true }
+
+func boundTestFailureListener(t *testing.T) *boundTestPoolListener {
+	return &boundTestPoolListener{t: t}
+}
+
+type boundTestPoolListener struct {
+	t         *testing.T
+	readyHost types.Host
+	failure   types.PoolFailureReason
+	ready     bool
+}
+
+func (l *boundTestPoolListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.failure = reason
+}
+
+func (l *boundTestPoolListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.ready = true
+	l.readyHost = host
+}
+
+func TestBoundConnPoolReusesSameClientAcrossStreams(t *testing.T) {
+	host := newBoundTestHost()
+	var built []*boundTestClient
+	pool := NewBoundConnPool(protocolNameForTest, host, func(ctx context.Context, connData types.CreateConnectionData) Client {
+		c := &boundTestClient{conn: connData.Connection.(*boundTestConnection)}
+		built = append(built, c)
+		return c
+	})
+
+	for i := 0; i < 3; i++ {
+		listener := boundTestFailureListener(t)
+		pool.NewStream(context.Background(), nil, listener)
+		if !listener.ready {
+			t.Fatalf("stream %d: expected OnReady, got failure %v", i, listener.failure)
+		}
+	}
+
+	if len(built) != 1 {
+		t.Fatalf("expected exactly one dial, got %d", len(built))
+	}
+	if host.dials != 1 {
+		t.Fatalf("expected exactly one CreateConnection call, got %d", host.dials)
+	}
+	if built[0].streams != 3 {
+		t.Fatalf("expected 3 streams on the bound client, got %d", built[0].streams)
+	}
+}
+
+func TestBoundConnPoolRedialsAfterClose(t *testing.T) {
+	host := newBoundTestHost()
+	var built []*boundTestClient
+	pool := NewBoundConnPool(protocolNameForTest, host, func(ctx context.Context, connData types.CreateConnectionData) Client {
+		c := &boundTestClient{conn: connData.Connection.(*boundTestConnection)}
+		built = append(built, c)
+		return c
+	})
+
+	pool.NewStream(context.Background(), nil, boundTestFailureListener(t))
+	if len(built) != 1 {
+		t.Fatalf("expected one dial before close, got %d", len(built))
+	}
+
+	pool.OnEvent(api.LocalClose)
+
+	pool.NewStream(context.Background(), nil, boundTestFailureListener(t))
+	if len(built) != 2 {
+		t.Fatalf("expected a redial after close, got %d dials", len(built))
+	}
+}
+
+func TestBoundConnPoolSurfacesConnectFailure(t *testing.T) {
+	host := newBoundTestHost()
+	host.connectErr = errors.New("connect refused")
+	pool := NewBoundConnPool(protocolNameForTest, host, func(ctx context.Context, connData types.CreateConnectionData) Client {
+		return &boundTestClient{conn: connData.Connection.(*boundTestConnection)}
+	})
+
+	listener := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, listener)
+	if listener.ready {
+		t.Fatal("expected failure, got OnReady")
+	}
+	if listener.failure != types.ConnectionFailure {
+		t.Fatalf("expected ConnectionFailure, got %v", listener.failure)
+	}
+}
+
+const protocolNameForTest = types.ProtocolName("bound-test")
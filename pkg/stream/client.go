@@ -101,6 +101,33 @@ func (c *client) ActiveRequestsNum() int {
 	return c.ClientStreamConnection.ActiveStreamsNum()
 }
 
+// maxConcurrentStreamer is implemented by ClientStreamConnections whose
+// protocol negotiates a maximum number of concurrently open streams, such
+// as HTTP/2.
+type maxConcurrentStreamer interface {
+	MaxConcurrentStreams() uint32
+}
+
+func (c *client) MaxConcurrentStreams() uint32 {
+	if m, ok := c.ClientStreamConnection.(maxConcurrentStreamer); ok {
+		return m.MaxConcurrentStreams()
+	}
+	return 0
+}
+
+// pinger is implemented by ClientStreamConnections that support an active
+// protocol-level liveness probe, such as HTTP/2's PING frame.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+func (c *client) Ping(ctx context.Context) error {
+	if p, ok := c.ClientStreamConnection.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
 func (c *client) SetConnectionCollector(read, write metrics.Counter) {
 	c.Connection.SetCollector(read, write)
 }
@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import "mosn.io/mosn/pkg/types"
+
+// HostConnectionsMax returns the tightest of the cluster's circuit breaker
+// connection limit and host's own MaxConnections cap - the ceiling a pool's
+// dial-gating check should enforce against its own count of connections
+// open to host. Either limit may be 0 (unlimited); the result is 0 only if
+// both are.
+func HostConnectionsMax(host types.Host) uint64 {
+	clusterMax := host.ClusterInfo().ResourceManager().Connections().Max()
+	hostMax := uint64(host.MaxConnections())
+
+	switch {
+	case clusterMax == 0:
+		return hostMax
+	case hostMax == 0:
+		return clusterMax
+	case hostMax < clusterMax:
+		return hostMax
+	default:
+		return clusterMax
+	}
+}
+
+// HostConnectionsLimited reports whether host's own MaxConnections, rather
+// than the cluster's circuit breaker connection limit, is the constraint a
+// dial-gating check is about to enforce - the condition under which a
+// refusal should count against UpstreamConnectionPendingOverflow.
+func HostConnectionsLimited(host types.Host) bool {
+	hostMax := uint64(host.MaxConnections())
+	if hostMax == 0 {
+		return false
+	}
+	clusterMax := host.ClusterInfo().ResourceManager().Connections().Max()
+	return clusterMax == 0 || hostMax <= clusterMax
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import "testing"
+
+func TestHostConnectionsMax(t *testing.T) {
+	cases := []struct {
+		name       string
+		clusterMax uint64
+		hostMax    uint32
+		want       uint64
+	}{
+		{"both unlimited", 0, 0, 0},
+		{"only cluster limited", 5, 0, 5},
+		{"only host limited", 0, 5, 5},
+		{"host tighter", 10, 2, 2},
+		{"cluster tighter", 2, 10, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			host := newPingPongTestHost(c.clusterMax)
+			host.hostMaxConns = c.hostMax
+			if got := HostConnectionsMax(host); got != c.want {
+				t.Fatalf("got %d, want %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestHostConnectionsLimited(t *testing.T) {
+	cases := []struct {
+		name       string
+		clusterMax uint64
+		hostMax    uint32
+		want       bool
+	}{
+		{"both unlimited", 0, 0, false},
+		{"only cluster limited", 5, 0, false},
+		{"only host limited", 0, 5, true},
+		{"host tighter", 10, 2, true},
+		{"cluster tighter", 2, 10, false},
+		{"equal", 5, 5, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			host := newPingPongTestHost(c.clusterMax)
+			host.hostMaxConns = c.hostMax
+			if got := HostConnectionsLimited(host); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
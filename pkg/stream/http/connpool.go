@@ -35,8 +35,10 @@ import (
 //const defaultIdleTimeout = time.Second * 60 // not used yet
 
 func init() {
-	network.RegisterNewPoolFactory(protocol.HTTP1, NewConnPool)
-	types.RegisterConnPoolFactory(protocol.HTTP1, true)
+	network.RegisterNewBoundPoolFactory(protocol.HTTP1, NewBoundConnPool)
+	types.RegisterConnPoolFactory(protocol.HTTP1, func(ctx context.Context, host types.Host) types.ConnectionPool {
+		return NewConnPool(host)
+	})
 }
 
 // types.ConnectionPool
@@ -48,6 +50,10 @@ type connPool struct {
 
 	statReport bool
 
+	idleTimeout time.Duration
+	closeOnce   sync.Once
+	closeCh     chan struct{}
+
 	clientMux        sync.Mutex
 	availableClients []*activeClient // available clients
 	totalClientCount uint64          // total clients
@@ -55,13 +61,18 @@ type connPool struct {
 
 func NewConnPool(host types.Host) types.ConnectionPool {
 	pool := &connPool{
-		supportTLS: host.SupportTLS(),
+		supportTLS:  host.SupportTLS(),
+		idleTimeout: host.ClusterInfo().IdleTimeout(),
+		closeCh:     make(chan struct{}),
 	}
 	pool.host.Store(host)
 
 	if pool.statReport {
 		pool.report()
 	}
+	if pool.idleTimeout > 0 {
+		pool.evictIdleClients()
+	}
 
 	return pool
 }
@@ -112,6 +123,15 @@ func (p *connPool) NewStream(ctx context.Context, receiver types.StreamReceiveLi
 		host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
 		host.ClusterInfo().ResourceManager().Requests().Increase()
 
+		totalStream := atomic.AddUint64(&c.totalStream, 1)
+		if exceedsConnectionLimits(host.ClusterInfo(), totalStream, c.createTime) {
+			// this connection has served enough requests or lived long enough,
+			// close it instead of returning it to availableClients once the
+			// current stream finishes, so the next request lands on a new
+			// (potentially rebalanced) connection
+			c.closeConn = true
+		}
+
 		streamEncoder := c.client.NewStream(ctx, receiver)
 		streamEncoder.GetStream().AddEventListener(c)
 		listener.OnReady(streamEncoder, host)
@@ -126,7 +146,7 @@ func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types
 	host := p.Host()
 	n := len(p.availableClients)
 	// max conns is 0 means no limit
-	maxConns := host.ClusterInfo().ResourceManager().Connections().Max()
+	maxConns := str.HostConnectionsMax(host)
 	// no available client
 	if n == 0 {
 		atomic.AddUint64(&p.totalClientCount, 1)
@@ -145,6 +165,9 @@ func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types
 			p.clientMux.Unlock()
 			host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
 			host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+			if str.HostConnectionsLimited(host) {
+				host.HostStats().UpstreamConnectionPendingOverflow.Inc(1)
+			}
 			return nil, types.Overflow
 		}
 	} else {
@@ -156,17 +179,25 @@ func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types
 		if maxConns != 0 && usedConns > maxConns {
 			host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
 			host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+			if str.HostConnectionsLimited(host) {
+				host.HostStats().UpstreamConnectionPendingOverflow.Inc(1)
+			}
 			return nil, types.Overflow
 		}
 
 		c := p.availableClients[n]
 		p.availableClients[n] = nil
 		p.availableClients = p.availableClients[:n]
+		str.AdjustIdleConnections(host, -1)
 		return c, ""
 	}
 }
 
 func (p *connPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+	})
+
 	p.clientMux.Lock()
 	defer p.clientMux.Unlock()
 
@@ -209,6 +240,7 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 			if c == client {
 				p.availableClients[i] = nil
 				p.availableClients = append(p.availableClients[:i], p.availableClients[i+1:]...)
+				str.AdjustIdleConnections(host, -1)
 				break
 			}
 		}
@@ -234,7 +266,9 @@ func (p *connPool) onStreamDestroy(client *activeClient) {
 	// return to pool
 	p.clientMux.Lock()
 	if !client.closed {
+		client.idleSince = time.Now()
 		p.availableClients = append(p.availableClients, client)
+		str.AdjustIdleConnections(host, 1)
 	}
 	p.clientMux.Unlock()
 }
@@ -258,6 +292,82 @@ func (p *connPool) createStreamClient(context context.Context, connData types.Cr
 	return str.NewStreamClient(context, protocol.HTTP1, connData.Connection, connData.Host)
 }
 
+// NewBoundConnPool creates a connection pool that binds a single upstream
+// connection to host for the pool's lifetime, for clusters with
+// ConnectionAffinity enabled.
+func NewBoundConnPool(host types.Host) types.ConnectionPool {
+	return str.NewBoundConnPool(protocol.HTTP1, host, func(ctx context.Context, connData types.CreateConnectionData) str.Client {
+		return str.NewStreamClient(ctx, protocol.HTTP1, connData.Connection, connData.Host)
+	})
+}
+
+// exceedsConnectionLimits reports whether a connection that has served
+// totalStream requests since createTime has hit the cluster's configured
+// MaxRequestsPerConn or MaxConnectionDuration. Either limit is ignored when
+// it is zero (unlimited). Http1ProtocolOptions.MaxKeepAliveRequests, when
+// set, overrides MaxRequestsPerConn for HTTP/1.1's own pool, so a cluster
+// can keep its general MaxRequestPerConn for other upstream protocols while
+// tuning HTTP/1.1 reuse against a specific backend separately.
+func exceedsConnectionLimits(info types.ClusterInfo, totalStream uint64, createTime time.Time) bool {
+	maxReq := info.MaxRequestsPerConn()
+	if opts := info.Http1ProtocolOptions(); opts.MaxKeepAliveRequests > 0 {
+		maxReq = opts.MaxKeepAliveRequests
+	}
+	if maxReq > 0 && totalStream >= uint64(maxReq) {
+		return true
+	}
+	if maxDuration := info.MaxConnectionDuration(); maxDuration > 0 && time.Since(createTime) >= maxDuration {
+		return true
+	}
+	return false
+}
+
+// evictIdleClients runs in the background for the lifetime of the pool,
+// periodically closing available clients that have had no active stream
+// for at least idleTimeout, so an upstream host that has gone quiet
+// doesn't keep sockets open on the chance a future request lands on them.
+func (p *connPool) evictIdleClients() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(p.idleTimeout)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.closeIdleClients()
+			case <-p.closeCh:
+				return
+			}
+		}
+	}, nil)
+}
+
+func (p *connPool) closeIdleClients() {
+	host := p.Host()
+
+	p.clientMux.Lock()
+	remaining := p.availableClients[:0]
+	var expired []*activeClient
+	for _, c := range p.availableClients {
+		if time.Since(c.idleSince) >= p.idleTimeout {
+			c.closed = true
+			expired = append(expired, c)
+			atomic.AddUint64(&p.totalClientCount, ^uint64(0))
+		} else {
+			remaining = append(remaining, c)
+		}
+	}
+	p.availableClients = remaining
+	p.clientMux.Unlock()
+
+	if len(expired) > 0 {
+		str.AdjustIdleConnections(host, -int64(len(expired)))
+	}
+	for _, c := range expired {
+		c.client.Close()
+		host.ClusterInfo().Stats().UpstreamConnectionCloseIdle.Inc(1)
+	}
+}
+
 func (p *connPool) report() {
 	// report
 	utils.GoWithRecover(func() {
@@ -281,23 +391,34 @@ type activeClient struct {
 	closeWithActiveReq bool
 	closed             bool
 	closeConn          bool
+	createTime         time.Time
+	idleSince          time.Time
 }
 
 func newActiveClient(ctx context.Context, pool *connPool) (*activeClient, types.PoolFailureReason) {
 	ac := &activeClient{
-		pool: pool,
+		pool:       pool,
+		createTime: time.Now(),
 	}
 
 	host := pool.Host()
-	data := host.CreateConnection(ctx)
-	codecClient := pool.createStreamClient(ctx, data)
-	codecClient.AddConnectionEventListener(ac)
-	codecClient.SetStreamConnectionEventListener(ac)
 
-	ac.client = codecClient
-	ac.host = data
+	err := str.RetryConnect(host, func() error {
+		data := host.CreateConnection(ctx)
+		codecClient := pool.createStreamClient(contextWithHTTP1ProtocolOptions(ctx, host.ClusterInfo().Http1ProtocolOptions()), data)
+		codecClient.AddConnectionEventListener(ac)
+		codecClient.SetStreamConnectionEventListener(ac)
 
-	if err := ac.client.Connect(); err != nil {
+		ac.client = codecClient
+		ac.host = data
+
+		if err := ac.client.Connect(); err != nil {
+			return err
+		}
+		str.RecordTLSHandshakeDuration(host, data.Connection)
+		return nil
+	})
+	if err != nil {
 		return nil, types.ConnectionFailure
 	}
 
@@ -307,7 +428,7 @@ func newActiveClient(ctx context.Context, pool *connPool) (*activeClient, types.
 	host.ClusterInfo().Stats().UpstreamConnectionActive.Inc(1)
 
 	// bytes total adds all connections data together
-	codecClient.SetConnectionCollector(host.ClusterInfo().Stats().UpstreamBytesReadTotal, host.ClusterInfo().Stats().UpstreamBytesWriteTotal)
+	ac.client.SetConnectionCollector(host.ClusterInfo().Stats().UpstreamBytesReadTotal, host.ClusterInfo().Stats().UpstreamBytesWriteTotal)
 
 	return ac, ""
 }
@@ -20,8 +20,10 @@ package http
 import (
 	"context"
 	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/network"
+	str "mosn.io/mosn/pkg/stream"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/upstream/cluster"
 	"sync"
@@ -31,13 +33,33 @@ import (
 
 type fakeClusterInfo struct {
 	types.ClusterInfo
-	mgr types.ResourceManager
+	mgr                types.ResourceManager
+	idleTimeout        time.Duration
+	stats              types.ClusterStats
+	maxRequestsPerConn uint32
+	http1Opts          v2.Http1ProtocolOptions
+}
+
+func (ci *fakeClusterInfo) MaxRequestsPerConn() uint32 {
+	return ci.maxRequestsPerConn
+}
+
+func (ci *fakeClusterInfo) MaxConnectionDuration() time.Duration {
+	return 0
+}
+
+func (ci *fakeClusterInfo) Http1ProtocolOptions() v2.Http1ProtocolOptions {
+	return ci.http1Opts
 }
 
 func (ci *fakeClusterInfo) ResourceManager() types.ResourceManager {
 	return ci.mgr
 }
 
+func (ci *fakeClusterInfo) ConnectionOptions() v2.UpstreamConnectionOptions {
+	return v2.UpstreamConnectionOptions{}
+}
+
 func (ci *fakeClusterInfo) Name() string {
 	return "test"
 }
@@ -54,6 +76,10 @@ func (ci *fakeClusterInfo) TLSMng() types.TLSContextManager {
 	return &fakeTLSContextManager{}
 }
 
+func (ci *fakeClusterInfo) TLSMngByMetadata(meta api.Metadata) types.TLSContextManager {
+	return ci.TLSMng()
+}
+
 func (ci *fakeClusterInfo) ConnectTimeout() time.Duration {
 	return network.DefaultConnectTimeout
 }
@@ -62,14 +88,26 @@ func (ci *fakeClusterInfo) ConnBufferLimitBytes() uint32 {
 	return 0
 }
 
+func (ci *fakeClusterInfo) IdleTimeout() time.Duration {
+	return ci.idleTimeout
+}
+
 func (ci *fakeClusterInfo) Stats() types.ClusterStats {
-	return types.ClusterStats{
-		UpstreamRequestPendingOverflow:                 metrics.NewCounter(),
-		UpstreamConnectionRemoteCloseWithActiveRequest: metrics.NewCounter(),
-		UpstreamConnectionTotal:                        metrics.NewCounter(),
-		UpstreamConnectionActive:                       metrics.NewCounter(),
-		UpstreamConnectionConFail:                      metrics.NewCounter(),
+	if ci.stats.UpstreamConnectionCloseIdle == nil {
+		ci.stats = types.ClusterStats{
+			UpstreamRequestPendingOverflow:                 metrics.NewCounter(),
+			UpstreamConnectionRemoteCloseWithActiveRequest: metrics.NewCounter(),
+			UpstreamConnectionTotal:                        metrics.NewCounter(),
+			UpstreamConnectionActive:                       metrics.NewCounter(),
+			UpstreamConnectionConFail:                      metrics.NewCounter(),
+			UpstreamConnectionCloseIdle:                    metrics.NewCounter(),
+			UpstreamConnectionRetry:                        metrics.NewCounter(),
+			UpstreamConnectionIdle:                         metrics.NewGauge(),
+			UpstreamConnectionConnectDuration:              metrics.NewHistogram(metrics.NewUniformSample(100)),
+			UpstreamRequestQueueDepth:                      metrics.NewGauge(),
+		}
 	}
+	return ci.stats
 }
 
 type fakeResourceManager struct {
@@ -81,6 +119,10 @@ func (mgr *fakeResourceManager) Connections() types.Resource {
 	return &fakeResource{max: mgr.max}
 }
 
+func (mgr *fakeResourceManager) Retries() types.Resource {
+	return &fakeResource{max: mgr.max}
+}
+
 type fakeResource struct {
 	max uint64
 }
@@ -88,11 +130,12 @@ type fakeResource struct {
 func (r *fakeResource) CanCreate() bool {
 	return true
 }
-func (r *fakeResource) Increase()       {}
-func (r *fakeResource) Decrease()       {}
-func (r *fakeResource) Cur() int64      { return 0 }
-func (r *fakeResource) UpdateCur(int64) {}
-func (r *fakeResource) Max() uint64     { return r.max }
+func (r *fakeResource) Increase()        {}
+func (r *fakeResource) Decrease()        {}
+func (r *fakeResource) Cur() int64       { return 0 }
+func (r *fakeResource) UpdateCur(int64)  {}
+func (r *fakeResource) Max() uint64      { return r.max }
+func (r *fakeResource) Remaining() int64 { return int64(r.max) - r.Cur() }
 
 func TestGetAvailableClient(t *testing.T) {
 
@@ -123,3 +166,85 @@ func TestGetAvailableClient(t *testing.T) {
 		t.Fatal("limit max connections failed")
 	}
 }
+
+// fakeStreamClient is a str.Client that only needs to support Close(), for
+// exercising connPool's idle client eviction without a real connection.
+type fakeStreamClient struct {
+	str.Client
+	closed bool
+}
+
+func (c *fakeStreamClient) Close() {
+	c.closed = true
+}
+
+func TestConnPoolCloseIdleClients(t *testing.T) {
+	ci := &fakeClusterInfo{
+		mgr:         &fakeResourceManager{max: 10},
+		idleTimeout: time.Minute,
+	}
+	hc := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:  "127.0.0.1:10003",
+			Hostname: "127.0.0.1:10003",
+		},
+	}
+	host := cluster.NewSimpleHost(hc, ci)
+	pool := NewConnPool(host).(*connPool)
+	defer pool.Close()
+
+	stale := &fakeStreamClient{}
+	fresh := &fakeStreamClient{}
+	pool.clientMux.Lock()
+	pool.availableClients = []*activeClient{
+		{pool: pool, client: stale, idleSince: time.Now().Add(-time.Hour)},
+		{pool: pool, client: fresh, idleSince: time.Now()},
+	}
+	pool.totalClientCount = 2
+	pool.clientMux.Unlock()
+
+	pool.closeIdleClients()
+
+	if !stale.closed {
+		t.Fatal("expected the long-idle client to be closed")
+	}
+	if fresh.closed {
+		t.Fatal("expected the freshly returned client to be kept")
+	}
+	pool.clientMux.Lock()
+	remaining := len(pool.availableClients)
+	pool.clientMux.Unlock()
+	if remaining != 1 {
+		t.Fatalf("expected 1 available client to remain, got %d", remaining)
+	}
+	if got := ci.Stats().UpstreamConnectionCloseIdle.Count(); got != 1 {
+		t.Fatalf("expected UpstreamConnectionCloseIdle to be incremented once, got %d", got)
+	}
+}
+
+func TestExceedsConnectionLimits(t *testing.T) {
+	now := time.Now()
+
+	// generic MaxRequestsPerConn applies when no HTTP/1.1-specific override
+	// is configured.
+	ci := &fakeClusterInfo{maxRequestsPerConn: 10}
+	if exceedsConnectionLimits(ci, 9, now) {
+		t.Fatal("expected 9 requests to stay under a limit of 10")
+	}
+	if !exceedsConnectionLimits(ci, 10, now) {
+		t.Fatal("expected 10 requests to hit a limit of 10")
+	}
+
+	// Http1ProtocolOptions.MaxKeepAliveRequests overrides MaxRequestsPerConn
+	// for the HTTP/1.1 pool alone.
+	ci = &fakeClusterInfo{
+		maxRequestsPerConn: 100,
+		http1Opts:          v2.Http1ProtocolOptions{MaxKeepAliveRequests: 5},
+	}
+	if exceedsConnectionLimits(ci, 50, now) == false {
+		t.Fatal("expected the http1 override of 5 to apply over the general limit of 100")
+	}
+	if exceedsConnectionLimits(ci, 4, now) {
+		t.Fatal("expected 4 requests to stay under the http1 override of 5")
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -73,6 +74,23 @@ var (
 	}
 )
 
+// http1OptionsCtxKey is an unexported context key used to thread a
+// cluster's Http1ProtocolOptions from connpool.go's newActiveClient down to
+// newClientStreamConnection. It doesn't go through types.ContextKey since
+// it never needs to leave this package: str.NewStreamClient's factory
+// signature is shared by every protocol's codec, so it has no way to carry
+// a protocol-specific, cluster-scoped config struct like this one.
+type http1OptionsCtxKey struct{}
+
+func contextWithHTTP1ProtocolOptions(ctx context.Context, opts v2.Http1ProtocolOptions) context.Context {
+	return context.WithValue(ctx, http1OptionsCtxKey{}, opts)
+}
+
+func http1ProtocolOptionsFromContext(ctx context.Context) v2.Http1ProtocolOptions {
+	opts, _ := ctx.Value(http1OptionsCtxKey{}).(v2.Http1ProtocolOptions)
+	return opts
+}
+
 type streamConnFactory struct{}
 
 func (f *streamConnFactory) CreateClientStream(context context.Context, connection types.ClientConnection,
@@ -201,6 +219,9 @@ type clientStreamConnection struct {
 	mutex                         sync.RWMutex
 	connectionEventListener       api.ConnectionEventListener
 	streamConnectionEventListener types.StreamConnectionEventListener
+
+	http1Opts      v2.Http1ProtocolOptions
+	keepAliveTimer *time.Timer
 }
 
 func newClientStreamConnection(ctx context.Context, connection types.ClientConnection,
@@ -217,6 +238,7 @@ func newClientStreamConnection(ctx context.Context, connection types.ClientConne
 		connectionEventListener:       connCallbacks,
 		streamConnectionEventListener: streamConnCallbacks,
 		requestSent:                   make(chan bool, 1),
+		http1Opts:                     http1ProtocolOptionsFromContext(ctx),
 	}
 
 	csc.br = bufio.NewReader(csc)
@@ -278,6 +300,10 @@ func (conn *clientStreamConnection) serve() {
 			s.connection.streamConnectionEventListener.OnGoAway()
 		}
 
+		if conn.http1Opts.UseServerKeepAlive {
+			conn.armKeepAliveTimer(s.response.Header.Peek("Keep-Alive"))
+		}
+
 		if atomic.LoadInt32(&s.readDisableCount) <= 0 {
 			s.handleResponse()
 		}
@@ -301,9 +327,62 @@ func (conn *clientStreamConnection) NewStream(ctx context.Context, receiver type
 	conn.mutex.Lock()
 	conn.stream = s
 	conn.mutex.Unlock()
+
+	if conn.http1Opts.UseServerKeepAlive {
+		conn.stopKeepAliveTimer()
+	}
 	return s
 }
 
+// armKeepAliveTimer parses an upstream response's "Keep-Alive" header
+// (RFC-style "timeout=N[, max=M]") and, if it advertises a timeout, starts
+// or resets a local timer that closes this connection when it fires - so
+// mosn stops treating the connection as reusable at the same point the
+// upstream itself would, instead of only finding out on the next request's
+// connection error. A response with no parseable timeout leaves any
+// previously armed timer alone.
+func (conn *clientStreamConnection) armKeepAliveTimer(headerValue []byte) {
+	timeout, ok := parseKeepAliveTimeout(headerValue)
+	if !ok {
+		return
+	}
+
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if conn.keepAliveTimer != nil {
+		conn.keepAliveTimer.Stop()
+	}
+	conn.keepAliveTimer = time.AfterFunc(timeout, func() {
+		conn.conn.Close(api.NoFlush, api.LocalClose)
+	})
+}
+
+func (conn *clientStreamConnection) stopKeepAliveTimer() {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if conn.keepAliveTimer != nil {
+		conn.keepAliveTimer.Stop()
+		conn.keepAliveTimer = nil
+	}
+}
+
+// parseKeepAliveTimeout extracts the "timeout" parameter (in seconds) from
+// an HTTP "Keep-Alive: timeout=N, max=M" header value.
+func parseKeepAliveTimeout(headerValue []byte) (time.Duration, bool) {
+	for _, part := range strings.Split(string(headerValue), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "timeout") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
 func (conn *clientStreamConnection) ActiveStreamsNum() int {
 	conn.mutex.RLock()
 	defer conn.mutex.RUnlock()
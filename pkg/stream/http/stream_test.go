@@ -19,6 +19,7 @@ package http
 
 import (
 	"testing"
+	"time"
 
 	"net"
 
@@ -27,6 +28,7 @@ import (
 
 	"github.com/valyala/fasthttp"
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
@@ -268,6 +270,54 @@ func Test_clientStream_CheckReasonError(t *testing.T) {
 
 }
 
+func Test_parseKeepAliveTimeout(t *testing.T) {
+	cases := []struct {
+		header string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"timeout=5", 5 * time.Second, true},
+		{"timeout=5, max=100", 5 * time.Second, true},
+		{"max=100, timeout=30", 30 * time.Second, true},
+		{"max=100", 0, false},
+		{"", 0, false},
+		{"timeout=0", 0, false},
+		{"timeout=bogus", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseKeepAliveTimeout([]byte(c.header))
+		if ok != c.wantOk || got != c.want {
+			t.Errorf("parseKeepAliveTimeout(%q) = (%v, %v), want (%v, %v)", c.header, got, ok, c.want, c.wantOk)
+		}
+	}
+}
+
+func Test_clientStreamConnection_armKeepAliveTimer(t *testing.T) {
+	remoteAddr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:12200")
+	csc := &clientStreamConnection{
+		streamConnection: streamConnection{
+			conn: network.NewClientConnection(nil, 0, nil, remoteAddr, nil),
+		},
+		http1Opts: v2.Http1ProtocolOptions{UseServerKeepAlive: true},
+	}
+
+	// A header with no parseable timeout leaves the connection alone.
+	csc.armKeepAliveTimer([]byte("max=100"))
+	if csc.keepAliveTimer != nil {
+		t.Fatal("expected no timer to be armed without a timeout parameter")
+	}
+
+	csc.armKeepAliveTimer([]byte("timeout=30"))
+	if csc.keepAliveTimer == nil {
+		t.Fatal("expected a timer to be armed for a timeout=30 header")
+	}
+
+	csc.stopKeepAliveTimer()
+	if csc.keepAliveTimer != nil {
+		t.Fatal("expected stopKeepAliveTimer to clear the timer")
+	}
+}
+
 func convertHeader(payload protocol.CommonHeader) http.RequestHeader {
 	header := http.RequestHeader{&fasthttp.RequestHeader{}, nil}
 
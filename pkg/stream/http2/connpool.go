@@ -21,6 +21,7 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"mosn.io/api"
 	mosnctx "mosn.io/mosn/pkg/context"
@@ -29,30 +30,74 @@ import (
 	"mosn.io/mosn/pkg/protocol"
 	str "mosn.io/mosn/pkg/stream"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	// keepaliveTimeout bounds how long a single idle-connection PING probe
+	// waits for its ACK, so one stuck peer can't stall the whole sweep.
+	keepaliveTimeout = 5 * time.Second
+	// maxKeepaliveMisses is how many consecutive PING probes an idle
+	// connection may fail to answer before it is closed.
+	maxKeepaliveMisses = 3
 )
 
 func init() {
-	network.RegisterNewPoolFactory(protocol.HTTP2, NewConnPool)
-	types.RegisterConnPoolFactory(protocol.HTTP2, true)
+	network.RegisterNewBoundPoolFactory(protocol.HTTP2, NewBoundConnPool)
+	types.RegisterConnPoolFactory(protocol.HTTP2, func(ctx context.Context, host types.Host) types.ConnectionPool {
+		return NewConnPool(host)
+	})
+}
+
+// pendingStream is a NewStream call that arrived while every connection was
+// at its max_concurrent_streams limit and the pool was at its connection
+// limit. It is replayed once a stream slot frees up, see dispatchPending.
+type pendingStream struct {
+	ctx             context.Context
+	responseDecoder types.StreamReceiveListener
+	listener        types.PoolEventListener
 }
 
 // types.ConnectionPool
-// activeClient used as connected client
 // host is the upstream
+//
+// connPool multiplexes requests over a small set of HTTP/2 connections per
+// host instead of one connection per request: NewStream hands a request to
+// the least-loaded connection that has room left under its peer-negotiated
+// max_concurrent_streams, opens a new connection (up to the cluster's
+// configured connection limit) when every existing one is full, and queues
+// the request when the pool itself is saturated, to be served as soon as a
+// stream finishes or a connection frees up. This keeps long-lived streams,
+// such as gRPC's, spread evenly instead of piling up on one connection.
+// Connections that go idle are kept alive with periodic PING probes and
+// closed once they stop answering, see probeIdleClients.
 type connPool struct {
-	activeClient *activeClient
-	host         atomic.Value
-	supportTLS   bool
+	host       atomic.Value
+	supportTLS bool
 
-	mux sync.Mutex
+	idleTimeout time.Duration
+	closeOnce   sync.Once
+	closeCh     chan struct{}
+
+	mux              sync.Mutex
+	activeClients    []*activeClient
+	totalClientCount uint64 // active and in-flight connection count, across all of activeClients
+	pending          []*pendingStream
 }
 
 // NewConnPool
 func NewConnPool(host types.Host) types.ConnectionPool {
 	pool := &connPool{
-		supportTLS: host.SupportTLS(),
+		supportTLS:  host.SupportTLS(),
+		idleTimeout: host.ClusterInfo().IdleTimeout(),
+		closeCh:     make(chan struct{}),
 	}
 	pool.host.Store(host)
+
+	if pool.idleTimeout > 0 {
+		pool.startIdleKeepalive()
+	}
+
 	return pool
 }
 
@@ -84,53 +129,270 @@ func (p *connPool) CheckAndInit(ctx context.Context) bool {
 func (p *connPool) NewStream(ctx context.Context,
 	responseDecoder types.StreamReceiveListener, listener types.PoolEventListener) {
 
-	activeClient := func() *activeClient {
-		p.mux.Lock()
-		defer p.mux.Unlock()
-		if p.activeClient != nil && atomic.LoadUint32(&p.activeClient.goaway) == 1 {
-			p.activeClient = nil
-		}
-		if p.activeClient == nil {
-			p.activeClient = newActiveClient(ctx, p)
+	host := p.Host()
+	if !host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+		listener.OnFailure(types.Overflow, host)
+		host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		return
+	}
+
+	ps := &pendingStream{ctx: ctx, responseDecoder: responseDecoder, listener: listener}
+
+	p.mux.Lock()
+	if client := p.pickClientLocked(); client != nil {
+		p.mux.Unlock()
+		p.dispatch(client, ps)
+		return
+	}
+	if !p.reserveConnSlotLocked(host) {
+		// every connection is full and the pool is at its connection limit:
+		// queue the stream for the next freed stream slot, see
+		// dispatchPending.
+		if !host.ClusterInfo().ResourceManager().PendingRequests().CanCreate() {
+			p.mux.Unlock()
+			listener.OnFailure(types.Overflow, host)
+			host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+			host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+			return
 		}
-		return p.activeClient
-	}()
+		host.ClusterInfo().ResourceManager().PendingRequests().Increase()
+		p.pending = append(p.pending, ps)
+		str.AdjustQueueDepth(host, 1)
+		p.mux.Unlock()
+		return
+	}
+	p.mux.Unlock()
 
-	host := p.Host()
-	if activeClient == nil {
+	client := newActiveClient(ctx, p)
+	if client == nil {
+		p.releaseConnSlot()
 		listener.OnFailure(types.ConnectionFailure, host)
 		return
 	}
+	p.mux.Lock()
+	p.activeClients = append(p.activeClients, client)
+	p.mux.Unlock()
+	p.dispatch(client, ps)
+}
 
-	if !host.ClusterInfo().ResourceManager().Requests().CanCreate() {
-		listener.OnFailure(types.Overflow, host)
-		host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
-		host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
-	} else {
-		atomic.AddUint64(&activeClient.totalStream, 1)
-		host.HostStats().UpstreamRequestTotal.Inc(1)
-		host.HostStats().UpstreamRequestActive.Inc(1)
-		host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
-		host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
-		host.ClusterInfo().ResourceManager().Requests().Increase()
-		streamEncoder := activeClient.client.NewStream(ctx, responseDecoder)
-		streamEncoder.GetStream().AddEventListener(activeClient)
+// pickClientLocked returns the least-loaded activeClient that is neither
+// going away nor at its peer-negotiated max_concurrent_streams (by active
+// stream count), or nil if none qualifies. Spreading streams this way keeps
+// long-lived streams, such as gRPC's, from piling up on whichever connection
+// happens to be first, which would otherwise leave later connections idle.
+// Callers must hold p.mux.
+func (p *connPool) pickClientLocked() *activeClient {
+	var best *activeClient
+	var bestLoad int32
+	for _, c := range p.activeClients {
+		if atomic.LoadUint32(&c.goaway) == 1 {
+			continue
+		}
+		load := atomic.LoadInt32(&c.activeRequest)
+		if max := c.client.MaxConcurrentStreams(); max != 0 && uint32(load) >= max {
+			continue
+		}
+		if best == nil || load < bestLoad {
+			best = c
+			bestLoad = load
+		}
+	}
+	return best
+}
 
-		listener.OnReady(streamEncoder, host)
+// reserveConnSlotLocked reserves a connection slot for a new activeClient if
+// the tighter of the cluster's connection limit and host's own
+// MaxConnections (0 means unlimited) allows it, and reports whether it did.
+// Callers must hold p.mux; on success, the caller is responsible for
+// creating the connection and calling releaseConnSlot if that fails.
+func (p *connPool) reserveConnSlotLocked(host types.Host) bool {
+	maxConns := str.HostConnectionsMax(host)
+	if maxConns != 0 && p.totalClientCount >= maxConns {
+		if str.HostConnectionsLimited(host) {
+			host.HostStats().UpstreamConnectionPendingOverflow.Inc(1)
+		}
+		return false
 	}
+	p.totalClientCount++
+	return true
+}
+
+func (p *connPool) releaseConnSlot() {
+	p.mux.Lock()
+	p.totalClientCount--
+	p.mux.Unlock()
+}
 
-	return
+// dispatch hands ps a stream on client, accounting it the same way a
+// directly-served NewStream call would.
+func (p *connPool) dispatch(client *activeClient, ps *pendingStream) {
+	host := p.Host()
+	totalStream := atomic.AddUint64(&client.totalStream, 1)
+	atomic.AddInt32(&client.activeRequest, 1)
+	if exceedsConnectionLimits(host.ClusterInfo(), totalStream, client.createTime) {
+		// the connection is no longer handed out to future streams; it is
+		// closed once its in-flight streams finish, see onStreamDestroy
+		atomic.StoreUint32(&client.goaway, 1)
+	}
+	host.HostStats().UpstreamRequestTotal.Inc(1)
+	host.HostStats().UpstreamRequestActive.Inc(1)
+	host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
+	host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
+	host.ClusterInfo().ResourceManager().Requests().Increase()
+	streamEncoder := client.client.NewStream(ps.ctx, ps.responseDecoder)
+	streamEncoder.GetStream().AddEventListener(client)
+
+	ps.listener.OnReady(streamEncoder, host)
+}
+
+// dispatchPending replays queued streams once stream or connection
+// capacity frees up, called from onStreamDestroy and onConnectionEvent.
+func (p *connPool) dispatchPending() {
+	host := p.Host()
+	for {
+		p.mux.Lock()
+		if len(p.pending) == 0 {
+			p.mux.Unlock()
+			return
+		}
+		if client := p.pickClientLocked(); client != nil {
+			ps := p.pending[0]
+			p.pending = p.pending[1:]
+			p.mux.Unlock()
+			host.ClusterInfo().ResourceManager().PendingRequests().Decrease()
+			str.AdjustQueueDepth(host, -1)
+			p.dispatch(client, ps)
+			continue
+		}
+		if !p.reserveConnSlotLocked(host) {
+			p.mux.Unlock()
+			return
+		}
+		ps := p.pending[0]
+		p.pending = p.pending[1:]
+		p.mux.Unlock()
+
+		client := newActiveClient(ps.ctx, p)
+		if client == nil {
+			p.releaseConnSlot()
+			host.ClusterInfo().ResourceManager().PendingRequests().Decrease()
+			str.AdjustQueueDepth(host, -1)
+			ps.listener.OnFailure(types.ConnectionFailure, host)
+			continue
+		}
+		p.mux.Lock()
+		p.activeClients = append(p.activeClients, client)
+		p.mux.Unlock()
+		host.ClusterInfo().ResourceManager().PendingRequests().Decrease()
+		str.AdjustQueueDepth(host, -1)
+		p.dispatch(client, ps)
+	}
+}
+
+// startIdleKeepalive runs in the background for the lifetime of the pool,
+// periodically PING-probing connections that have had no active stream for
+// at least idleTimeout, so a quietly-dead peer is caught and replaced
+// instead of being handed out to the next request that lands on it.
+func (p *connPool) startIdleKeepalive() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(p.idleTimeout)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.probeIdleClients()
+			case <-p.closeCh:
+				return
+			}
+		}
+	}, nil)
+}
+
+// probeIdleClients sends a PING to every connection that has been idle for
+// at least idleTimeout, and closes those that fail to answer maxKeepaliveMisses
+// times in a row. A connection that answers has its miss count reset and its
+// idle window restarted, so it is probed again after another full idleTimeout.
+func (p *connPool) probeIdleClients() {
+	p.mux.Lock()
+	clients := make([]*activeClient, len(p.activeClients))
+	copy(clients, p.activeClients)
+	p.mux.Unlock()
+
+	for _, c := range clients {
+		if atomic.LoadInt32(&c.activeRequest) != 0 {
+			continue
+		}
+		since, _ := c.idleSince.Load().(time.Time)
+		if time.Since(since) < p.idleTimeout {
+			continue
+		}
+		p.probeClient(c)
+	}
+}
+
+func (p *connPool) probeClient(c *activeClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), keepaliveTimeout)
+	defer cancel()
+
+	if err := c.client.Ping(ctx); err != nil {
+		if atomic.AddUint32(&c.keepaliveMisses, 1) < maxKeepaliveMisses {
+			return
+		}
+		log.DefaultLogger.Infof("http2 connPool: closing idle connection after %d missed keepalive probes: %v", maxKeepaliveMisses, err)
+		p.removeClient(c)
+		c.client.Close()
+		return
+	}
+	atomic.StoreUint32(&c.keepaliveMisses, 0)
+	c.idleSince.Store(time.Now())
+}
+
+// removeClient drops client from the pool's rotation and frees its
+// connection slot, e.g. once it is fully closed.
+func (p *connPool) removeClient(client *activeClient) {
+	p.mux.Lock()
+	for i, c := range p.activeClients {
+		if c == client {
+			p.activeClients = append(p.activeClients[:i], p.activeClients[i+1:]...)
+			p.totalClientCount--
+			break
+		}
+	}
+	p.mux.Unlock()
 }
 
 func (p *connPool) Close() {
-	activeClient := p.activeClient
-	if activeClient != nil {
-		activeClient.client.Close()
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+	})
+
+	p.mux.Lock()
+	clients := p.activeClients
+	p.mux.Unlock()
+
+	for _, c := range clients {
+		c.client.Close()
 	}
 }
 
+// Shutdown marks every current connection as going away: no new stream is
+// dispatched to it (see pickClientLocked), and it is closed for real as
+// soon as its in-flight streams finish (see onStreamDestroy), or right away
+// if it is already idle. The pool itself keeps working, so a NewStream call
+// arriving after Shutdown still succeeds by opening a fresh connection.
 func (p *connPool) Shutdown() {
-	//TODO: http2 connpool do nothing for shutdown
+	p.mux.Lock()
+	clients := make([]*activeClient, len(p.activeClients))
+	copy(clients, p.activeClients)
+	p.mux.Unlock()
+
+	for _, c := range clients {
+		atomic.StoreUint32(&c.goaway, 1)
+		if atomic.LoadInt32(&c.activeRequest) == 0 {
+			c.client.Close()
+		}
+	}
 }
 
 func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionEvent) {
@@ -147,12 +409,8 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 				host.ClusterInfo().Stats().UpstreamConnectionRemoteCloseWithActiveRequest.Inc(1)
 			}
 		}
-		if atomic.LoadUint32(&client.goaway) == 1 {
-			return
-		}
-		p.mux.Lock()
-		p.activeClient = nil
-		p.mux.Unlock()
+		p.removeClient(client)
+		p.dispatchPending()
 	} else if event == api.ConnectTimeout {
 		host.HostStats().UpstreamRequestTimeout.Inc(1)
 		host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
@@ -167,6 +425,31 @@ func (p *connPool) onStreamDestroy(client *activeClient) {
 	host.HostStats().UpstreamRequestActive.Dec(1)
 	host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
 	host.ClusterInfo().ResourceManager().Requests().Decrease()
+
+	// a client marked by goaway has already been detached from the pool, see
+	// NewStream; once its last in-flight stream finishes, close it for real
+	if atomic.AddInt32(&client.activeRequest, -1) == 0 {
+		if atomic.LoadUint32(&client.goaway) == 1 {
+			client.client.Close()
+		} else {
+			client.idleSince.Store(time.Now())
+		}
+	}
+	p.dispatchPending()
+}
+
+// exceedsConnectionLimits reports whether a connection that has served
+// totalStream requests since createTime has hit the cluster's configured
+// MaxRequestsPerConn or MaxConnectionDuration. Either limit is ignored when
+// it is zero (unlimited).
+func exceedsConnectionLimits(info types.ClusterInfo, totalStream uint64, createTime time.Time) bool {
+	if maxReq := info.MaxRequestsPerConn(); maxReq > 0 && totalStream >= uint64(maxReq) {
+		return true
+	}
+	if maxDuration := info.MaxConnectionDuration(); maxDuration > 0 && time.Since(createTime) >= maxDuration {
+		return true
+	}
+	return false
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -188,6 +471,15 @@ func (p *connPool) createStreamClient(context context.Context, connData types.Cr
 	return str.NewStreamClient(context, protocol.HTTP2, connData.Connection, connData.Host)
 }
 
+// NewBoundConnPool creates a connection pool that binds a single upstream
+// connection to host for the pool's lifetime, for clusters with
+// ConnectionAffinity enabled.
+func NewBoundConnPool(host types.Host) types.ConnectionPool {
+	return str.NewBoundConnPool(protocol.HTTP2, host, func(ctx context.Context, connData types.CreateConnectionData) str.Client {
+		return str.NewStreamClient(ctx, protocol.HTTP2, connData.Connection, connData.Host)
+	})
+}
+
 // types.StreamEventListener
 // types.ConnectionEventListener
 // types.StreamConnectionEventListener
@@ -197,18 +489,33 @@ type activeClient struct {
 	host               types.CreateConnectionData
 	closeWithActiveReq bool
 	totalStream        uint64
+	activeRequest      int32
 	goaway             uint32
+	createTime         time.Time
+	idleSince          atomic.Value // time.Time, since when activeRequest has been 0
+	keepaliveMisses    uint32       // consecutive PING probes gone unanswered while idle
 }
 
 func newActiveClient(ctx context.Context, pool *connPool) *activeClient {
 	ac := &activeClient{
-		pool: pool,
+		pool:       pool,
+		createTime: time.Now(),
 	}
+	ac.idleSince.Store(time.Now())
 
 	host := pool.Host()
-	data := host.CreateConnection(ctx)
-	data.Connection.AddConnectionEventListener(ac)
-	if err := data.Connection.Connect(); err != nil {
+
+	var data types.CreateConnectionData
+	err := str.RetryConnect(host, func() error {
+		data = host.CreateConnection(ctx)
+		data.Connection.AddConnectionEventListener(ac)
+		if err := data.Connection.Connect(); err != nil {
+			return err
+		}
+		str.RecordTLSHandshakeDuration(host, data.Connection)
+		return nil
+	})
+	if err != nil {
 		log.DefaultLogger.Debugf("http2 underlying connection error: %v", err)
 		return nil
 	}
@@ -0,0 +1,427 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	str "mosn.io/mosn/pkg/stream"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+type fakeClusterInfo struct {
+	types.ClusterInfo
+	mgr         types.ResourceManager
+	stats       types.ClusterStats
+	idleTimeout time.Duration
+}
+
+func (ci *fakeClusterInfo) ResourceManager() types.ResourceManager {
+	return ci.mgr
+}
+
+func (ci *fakeClusterInfo) Name() string {
+	return "test"
+}
+
+func (ci *fakeClusterInfo) ConnectTimeout() time.Duration {
+	return network.DefaultConnectTimeout
+}
+
+type fakeTLSContextManager struct {
+	types.TLSContextManager
+}
+
+func (mg *fakeTLSContextManager) Enabled() bool {
+	return false
+}
+
+func (ci *fakeClusterInfo) TLSMng() types.TLSContextManager {
+	return &fakeTLSContextManager{}
+}
+
+func (ci *fakeClusterInfo) TLSMngByMetadata(meta api.Metadata) types.TLSContextManager {
+	return ci.TLSMng()
+}
+
+func (ci *fakeClusterInfo) MaxRequestsPerConn() uint32 {
+	return 0
+}
+
+func (ci *fakeClusterInfo) MaxConnectionDuration() time.Duration {
+	return 0
+}
+
+func (ci *fakeClusterInfo) ConnectionOptions() v2.UpstreamConnectionOptions {
+	return v2.UpstreamConnectionOptions{}
+}
+
+func (ci *fakeClusterInfo) ConnBufferLimitBytes() uint32 {
+	return 0
+}
+
+func (ci *fakeClusterInfo) IdleTimeout() time.Duration {
+	return ci.idleTimeout
+}
+
+func (ci *fakeClusterInfo) Stats() types.ClusterStats {
+	if ci.stats.UpstreamRequestTotal == nil {
+		ci.stats = types.ClusterStats{
+			UpstreamRequestPendingOverflow:    metrics.NewCounter(),
+			UpstreamRequestTotal:              metrics.NewCounter(),
+			UpstreamRequestActive:             metrics.NewCounter(),
+			UpstreamConnectionTotal:           metrics.NewCounter(),
+			UpstreamConnectionActive:          metrics.NewCounter(),
+			UpstreamConnectionConFail:         metrics.NewCounter(),
+			UpstreamBytesReadTotal:            metrics.NewCounter(),
+			UpstreamBytesWriteTotal:           metrics.NewCounter(),
+			UpstreamConnectionIdle:            metrics.NewGauge(),
+			UpstreamConnectionConnectDuration: metrics.NewHistogram(metrics.NewUniformSample(100)),
+			UpstreamRequestQueueDepth:         metrics.NewGauge(),
+		}
+	}
+	return ci.stats
+}
+
+type fakeResourceManager struct {
+	types.ResourceManager
+	conns    fakeResource
+	pending  fakeResource
+	requests fakeResource
+	retries  denyingResource
+}
+
+func newFakeResourceManager(maxConns, maxPending uint64) *fakeResourceManager {
+	return &fakeResourceManager{
+		conns:   fakeResource{max: maxConns},
+		pending: fakeResource{max: maxPending},
+	}
+}
+
+func (mgr *fakeResourceManager) Connections() types.Resource {
+	return &mgr.conns
+}
+
+func (mgr *fakeResourceManager) PendingRequests() types.Resource {
+	return &mgr.pending
+}
+
+func (mgr *fakeResourceManager) Requests() types.Resource {
+	return &mgr.requests
+}
+
+func (mgr *fakeResourceManager) Retries() types.Resource {
+	return &mgr.retries
+}
+
+// denyingResource never allows a retry, matching the real ResourceManager's
+// default of zero configured retries.
+type denyingResource struct{}
+
+func (denyingResource) CanCreate() bool   { return false }
+func (denyingResource) Increase()         {}
+func (denyingResource) Decrease()         {}
+func (denyingResource) Cur() int64        { return 0 }
+func (denyingResource) UpdateCur(c int64) {}
+func (denyingResource) Max() uint64       { return 0 }
+func (denyingResource) Remaining() int64  { return 0 }
+
+type fakeResource struct {
+	max uint64
+	cur int64
+}
+
+func (r *fakeResource) CanCreate() bool {
+	return r.max == 0 || uint64(r.cur) < r.max
+}
+func (r *fakeResource) Increase()         { r.cur++ }
+func (r *fakeResource) Decrease()         { r.cur-- }
+func (r *fakeResource) Cur() int64        { return r.cur }
+func (r *fakeResource) UpdateCur(c int64) { r.cur = c }
+func (r *fakeResource) Max() uint64       { return r.max }
+func (r *fakeResource) Remaining() int64  { return int64(r.max) - r.cur }
+
+// fakeStreamClient is a str.Client whose MaxConcurrentStreams and Close are
+// controllable, for exercising connPool's connection selection and
+// bookkeeping without a real HTTP/2 connection.
+type fakeStreamClient struct {
+	str.Client
+	maxStreams uint32
+	closed     bool
+	pingErr    error
+}
+
+func (c *fakeStreamClient) MaxConcurrentStreams() uint32 {
+	return c.maxStreams
+}
+
+func (c *fakeStreamClient) Close() {
+	c.closed = true
+}
+
+func (c *fakeStreamClient) Ping(ctx context.Context) error {
+	return c.pingErr
+}
+
+func (c *fakeStreamClient) NewStream(ctx context.Context, respDecoder types.StreamReceiveListener) types.StreamSender {
+	return &fakeStreamSender{stream: &fakeStream{}}
+}
+
+// fakeStream and fakeStreamSender are just enough of types.Stream and
+// types.StreamSender for dispatch to call GetStream().AddEventListener
+// without touching a real HTTP/2 codec.
+type fakeStream struct {
+	types.Stream
+	listeners []types.StreamEventListener
+}
+
+func (s *fakeStream) AddEventListener(l types.StreamEventListener) {
+	s.listeners = append(s.listeners, l)
+}
+
+type fakeStreamSender struct {
+	types.StreamSender
+	stream *fakeStream
+}
+
+func (s *fakeStreamSender) GetStream() types.Stream {
+	return s.stream
+}
+
+func TestPickClientLocked(t *testing.T) {
+	pool := &connPool{}
+
+	full := &activeClient{client: &fakeStreamClient{maxStreams: 2}, activeRequest: 2}
+	goneAway := &activeClient{client: &fakeStreamClient{maxStreams: 0}}
+	goneAway.goaway = 1
+	unlimited := &activeClient{client: &fakeStreamClient{maxStreams: 0}, activeRequest: 100}
+	pool.activeClients = []*activeClient{full, goneAway, unlimited}
+
+	if got := pool.pickClientLocked(); got != unlimited {
+		t.Fatalf("expected the unlimited client to be picked, got %v", got)
+	}
+
+	pool.activeClients = []*activeClient{full, goneAway}
+	if got := pool.pickClientLocked(); got != nil {
+		t.Fatalf("expected no client to qualify, got %v", got)
+	}
+
+	room := &activeClient{client: &fakeStreamClient{maxStreams: 2}, activeRequest: 1}
+	pool.activeClients = []*activeClient{full, room}
+	if got := pool.pickClientLocked(); got != room {
+		t.Fatalf("expected the client with room left to be picked, got %v", got)
+	}
+}
+
+func TestPickClientLockedPicksLeastLoaded(t *testing.T) {
+	pool := &connPool{}
+
+	busy := &activeClient{client: &fakeStreamClient{maxStreams: 10}, activeRequest: 7}
+	idle := &activeClient{client: &fakeStreamClient{maxStreams: 10}, activeRequest: 1}
+	mid := &activeClient{client: &fakeStreamClient{maxStreams: 10}, activeRequest: 4}
+	pool.activeClients = []*activeClient{busy, mid, idle}
+
+	if got := pool.pickClientLocked(); got != idle {
+		t.Fatalf("expected the least-loaded client to be picked, got %v", got)
+	}
+}
+
+func TestReserveConnSlotLocked(t *testing.T) {
+	pool := &connPool{}
+	ci := &fakeClusterInfo{mgr: newFakeResourceManager(2, 0)}
+	hc := v2.Host{HostConfig: v2.HostConfig{Address: "127.0.0.1:10012"}}
+	host := cluster.NewSimpleHost(hc, ci)
+
+	pool.mux.Lock()
+	if !pool.reserveConnSlotLocked(host) || !pool.reserveConnSlotLocked(host) {
+		t.Fatal("expected the first two reservations to succeed")
+	}
+	if pool.reserveConnSlotLocked(host) {
+		t.Fatal("expected a third reservation to fail once the connection limit is reached")
+	}
+	pool.mux.Unlock()
+
+	pool.releaseConnSlot()
+	pool.mux.Lock()
+	if !pool.reserveConnSlotLocked(host) {
+		t.Fatal("expected a reservation to succeed after releasing a slot")
+	}
+	pool.mux.Unlock()
+}
+
+func TestNewStreamQueuesWhenSaturated(t *testing.T) {
+	ci := &fakeClusterInfo{mgr: newFakeResourceManager(1, 1)}
+	hc := v2.Host{HostConfig: v2.HostConfig{Address: "127.0.0.1:10013"}}
+	host := cluster.NewSimpleHost(hc, ci)
+	pool := NewConnPool(host).(*connPool)
+
+	full := &activeClient{pool: pool, client: &fakeStreamClient{maxStreams: 1}, activeRequest: 1}
+	pool.activeClients = []*activeClient{full}
+	pool.totalClientCount = 1
+
+	l := &fakePoolEventListener{}
+	pool.NewStream(context.Background(), nil, l)
+
+	if l.failed {
+		t.Fatalf("expected the stream to be queued rather than failed, reason: %v", l.reason)
+	}
+	pool.mux.Lock()
+	pending := len(pool.pending)
+	pool.mux.Unlock()
+	if pending != 1 {
+		t.Fatalf("expected 1 queued stream, got %d", pending)
+	}
+
+	// a second stream arrives while the pool is at both its connection and
+	// pending-request limits, so it must be rejected with Overflow
+	l2 := &fakePoolEventListener{}
+	pool.NewStream(context.Background(), nil, l2)
+	if !l2.failed || l2.reason != types.Overflow {
+		t.Fatalf("expected the second stream to overflow, got failed=%v reason=%v", l2.failed, l2.reason)
+	}
+
+	// freeing up the full connection's only stream slot must dispatch the
+	// queued stream onto it
+	pool.onStreamDestroy(full)
+	pool.mux.Lock()
+	pending = len(pool.pending)
+	pool.mux.Unlock()
+	if pending != 0 {
+		t.Fatalf("expected the queued stream to be dispatched, %d still pending", pending)
+	}
+}
+
+type fakePoolEventListener struct {
+	failed bool
+	reason types.PoolFailureReason
+	ready  bool
+}
+
+func (l *fakePoolEventListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.failed = true
+	l.reason = reason
+}
+
+func (l *fakePoolEventListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.ready = true
+}
+
+func TestRemoveClientReleasesConnSlot(t *testing.T) {
+	pool := &connPool{}
+	c1 := &activeClient{client: &fakeStreamClient{}}
+	c2 := &activeClient{client: &fakeStreamClient{}}
+	pool.activeClients = []*activeClient{c1, c2}
+	pool.totalClientCount = 2
+
+	pool.removeClient(c1)
+
+	if len(pool.activeClients) != 1 || pool.activeClients[0] != c2 {
+		t.Fatalf("expected only c2 to remain, got %v", pool.activeClients)
+	}
+	if pool.totalClientCount != 1 {
+		t.Fatalf("expected totalClientCount to drop to 1, got %d", pool.totalClientCount)
+	}
+}
+
+func TestConnPoolConnectionLimitConcurrent(t *testing.T) {
+	var max uint64 = 3
+	ci := &fakeClusterInfo{mgr: newFakeResourceManager(max, 0)}
+	hc := v2.Host{HostConfig: v2.HostConfig{Address: "127.0.0.1:10014"}}
+	host := cluster.NewSimpleHost(hc, ci)
+	pool := NewConnPool(host).(*connPool)
+
+	wg := sync.WaitGroup{}
+	wg.Add(50)
+	for i := 0; i < 50; i++ {
+		go func() {
+			defer wg.Done()
+			pool.NewStream(context.Background(), nil, &fakePoolEventListener{})
+		}()
+	}
+	wg.Wait()
+
+	if pool.totalClientCount > max {
+		t.Fatalf("limit max connections failed, got %d", pool.totalClientCount)
+	}
+}
+
+func TestProbeIdleClientsSkipsBusyAndFreshClients(t *testing.T) {
+	pool := &connPool{idleTimeout: time.Hour}
+
+	busy := &activeClient{pool: pool, client: &fakeStreamClient{pingErr: errors.New("should not be called")}, activeRequest: 1}
+	busy.idleSince.Store(time.Now().Add(-time.Hour * 2))
+
+	fresh := &activeClient{pool: pool, client: &fakeStreamClient{pingErr: errors.New("should not be called")}}
+	fresh.idleSince.Store(time.Now())
+
+	pool.activeClients = []*activeClient{busy, fresh}
+
+	pool.probeIdleClients()
+
+	if busy.keepaliveMisses != 0 || fresh.keepaliveMisses != 0 {
+		t.Fatalf("expected busy and fresh clients to be skipped, got misses %d and %d", busy.keepaliveMisses, fresh.keepaliveMisses)
+	}
+}
+
+func TestProbeClientResetsMissesOnSuccess(t *testing.T) {
+	pool := &connPool{idleTimeout: time.Millisecond}
+	c := &activeClient{pool: pool, client: &fakeStreamClient{}, keepaliveMisses: maxKeepaliveMisses - 1}
+	c.idleSince.Store(time.Now().Add(-time.Hour))
+	pool.activeClients = []*activeClient{c}
+
+	pool.probeClient(c)
+
+	if c.keepaliveMisses != 0 {
+		t.Fatalf("expected a successful ping to reset keepaliveMisses, got %d", c.keepaliveMisses)
+	}
+	if since := c.idleSince.Load().(time.Time); time.Since(since) > time.Second {
+		t.Fatalf("expected idleSince to be refreshed after a successful ping")
+	}
+	if len(pool.activeClients) != 1 {
+		t.Fatalf("expected the client to remain in the pool after a successful ping")
+	}
+}
+
+func TestProbeClientClosesAfterMaxMisses(t *testing.T) {
+	pool := &connPool{idleTimeout: time.Millisecond}
+	fc := &fakeStreamClient{pingErr: errors.New("connection is dead")}
+	c := &activeClient{pool: pool, client: fc, keepaliveMisses: maxKeepaliveMisses - 1}
+	c.idleSince.Store(time.Now().Add(-time.Hour))
+	pool.activeClients = []*activeClient{c}
+	pool.totalClientCount = 1
+
+	pool.probeClient(c)
+
+	if !fc.closed {
+		t.Fatal("expected the connection to be closed after exceeding maxKeepaliveMisses")
+	}
+	if len(pool.activeClients) != 0 {
+		t.Fatalf("expected the client to be removed from the pool, got %v", pool.activeClients)
+	}
+}
+
+var _ api.ConnectionEventListener = (*activeClient)(nil)
@@ -620,6 +620,19 @@ func (conn *clientStreamConnection) ActiveStreamsNum() int {
 	return len(conn.streams)
 }
 
+// MaxConcurrentStreams implements stream.maxConcurrentStreamer, exposing
+// the peer's negotiated SETTINGS_MAX_CONCURRENT_STREAMS to the connection
+// pool so it can cap how many streams it hands out per connection.
+func (conn *clientStreamConnection) MaxConcurrentStreams() uint32 {
+	return conn.mClientConn.MaxConcurrentStreams()
+}
+
+// Ping implements stream.pinger, letting the connection pool send a
+// protocol-level PING to check whether an idle connection is still alive.
+func (conn *clientStreamConnection) Ping(ctx context.Context) error {
+	return conn.mClientConn.Ping(ctx)
+}
+
 func (conn *clientStreamConnection) CheckReasonError(connected bool, event api.ConnectionEvent) (types.StreamResetReason, bool) {
 	reason := types.StreamConnectionSuccessed
 	if event.IsClose() || event.ConnectFailure() {
@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// PingPongConnPool is a reusable types.ConnectionPool for protocols that
+// cannot multiplex concurrent requests over a single connection - each
+// pooled connection serves one in-flight stream at a time, the way a
+// classic request/response ("ping-pong") protocol works. It keeps a set of
+// idle connections and hands one out per stream, dialing a new one (up to
+// the cluster's configured max connections) when none is idle, exactly the
+// way pkg/stream/http's connPool manages HTTP/1.1 connections - this type
+// exists so other protocols that need the same shape don't reimplement it.
+type PingPongConnPool struct {
+	protocol           types.ProtocolName
+	createStreamClient func(ctx context.Context, connData types.CreateConnectionData) Client
+
+	host atomic.Value
+
+	clientMux        sync.Mutex
+	availableClients []*pingPongClient
+	totalClientCount uint64
+}
+
+// NewPingPongConnPool creates a PingPongConnPool for host. createStreamClient
+// builds the protocol-specific codec client around a freshly created
+// connection, mirroring each protocol pool's own private createStreamClient
+// method.
+func NewPingPongConnPool(protocol types.ProtocolName, host types.Host, createStreamClient func(ctx context.Context, connData types.CreateConnectionData) Client) *PingPongConnPool {
+	p := &PingPongConnPool{
+		protocol:           protocol,
+		createStreamClient: createStreamClient,
+	}
+	p.host.Store(host)
+	return p
+}
+
+func (p *PingPongConnPool) Protocol() api.Protocol {
+	return p.protocol
+}
+
+func (p *PingPongConnPool) CheckAndInit(ctx context.Context) bool {
+	return true
+}
+
+func (p *PingPongConnPool) SupportTLS() bool {
+	return p.Host().SupportTLS()
+}
+
+func (p *PingPongConnPool) Host() types.Host {
+	return p.host.Load().(types.Host)
+}
+
+func (p *PingPongConnPool) UpdateHost(h types.Host) {
+	p.host.Store(h)
+}
+
+func (p *PingPongConnPool) NewStream(ctx context.Context, receiver types.StreamReceiveListener, listener types.PoolEventListener) {
+	host := p.Host()
+	c, reason := p.getAvailableClient(ctx)
+	if c == nil {
+		listener.OnFailure(reason, host)
+		return
+	}
+
+	if !host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+		listener.OnFailure(types.Overflow, host)
+		host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		return
+	}
+	host.HostStats().UpstreamRequestTotal.Inc(1)
+	host.HostStats().UpstreamRequestActive.Inc(1)
+	host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
+	host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
+	host.ClusterInfo().ResourceManager().Requests().Increase()
+
+	streamSender := c.client.NewStream(ctx, receiver)
+	streamSender.GetStream().AddEventListener(c)
+	listener.OnReady(streamSender, host)
+}
+
+// getAvailableClient returns an idle connection, dialing a new one when none
+// is idle and the tighter of the cluster's configured connection limit and
+// the host's own MaxConnections allows it.
+func (p *PingPongConnPool) getAvailableClient(ctx context.Context) (*pingPongClient, types.PoolFailureReason) {
+	p.clientMux.Lock()
+	host := p.Host()
+	n := len(p.availableClients)
+	// max conns is 0 means no limit
+	maxConns := HostConnectionsMax(host)
+	if n == 0 {
+		atomic.AddUint64(&p.totalClientCount, 1)
+		if maxConns == 0 || atomic.LoadUint64(&p.totalClientCount) <= maxConns {
+			// unlock immediately, allowing concurrent dials
+			p.clientMux.Unlock()
+			c, reason := p.newPingPongClient(ctx)
+			if c == nil {
+				// To subtract a signed positive constant value c from x, do AddUint64(&x, ^uint64(c-1)).
+				atomic.AddUint64(&p.totalClientCount, ^uint64(0))
+			}
+			return c, reason
+		}
+		// To subtract a signed positive constant value c from x, do AddUint64(&x, ^uint64(c-1)).
+		atomic.AddUint64(&p.totalClientCount, ^uint64(0))
+		p.clientMux.Unlock()
+		host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		if HostConnectionsLimited(host) {
+			host.HostStats().UpstreamConnectionPendingOverflow.Inc(1)
+		}
+		return nil, types.Overflow
+	}
+
+	defer p.clientMux.Unlock()
+	n--
+	c := p.availableClients[n]
+	p.availableClients[n] = nil
+	p.availableClients = p.availableClients[:n]
+	AdjustIdleConnections(host, -1)
+	return c, ""
+}
+
+func (p *PingPongConnPool) newPingPongClient(ctx context.Context) (*pingPongClient, types.PoolFailureReason) {
+	host := p.Host()
+	c := &pingPongClient{pool: p}
+
+	err := RetryConnect(host, func() error {
+		data := host.CreateConnection(ctx)
+		data.Connection.AddConnectionEventListener(c)
+		c.client = p.createStreamClient(ctx, data)
+		if err := data.Connection.Connect(); err != nil {
+			return err
+		}
+		RecordTLSHandshakeDuration(host, data.Connection)
+		return nil
+	})
+	if err != nil {
+		return nil, types.ConnectionFailure
+	}
+
+	host.HostStats().UpstreamConnectionTotal.Inc(1)
+	host.HostStats().UpstreamConnectionActive.Inc(1)
+	host.ClusterInfo().Stats().UpstreamConnectionTotal.Inc(1)
+	host.ClusterInfo().Stats().UpstreamConnectionActive.Inc(1)
+
+	return c, ""
+}
+
+func (p *PingPongConnPool) Close() {
+	p.clientMux.Lock()
+	defer p.clientMux.Unlock()
+	for _, c := range p.availableClients {
+		c.client.Close()
+	}
+}
+
+func (p *PingPongConnPool) Shutdown() {
+	p.Close()
+}
+
+func (p *PingPongConnPool) onConnectionEvent(c *pingPongClient, event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+
+	host := p.Host()
+	host.HostStats().UpstreamConnectionActive.Dec(1)
+	host.ClusterInfo().Stats().UpstreamConnectionActive.Dec(1)
+
+	p.clientMux.Lock()
+	defer p.clientMux.Unlock()
+	// To subtract a signed positive constant value c from x, do AddUint64(&x, ^uint64(c-1)).
+	atomic.AddUint64(&p.totalClientCount, ^uint64(0))
+	for i, avail := range p.availableClients {
+		if avail == c {
+			p.availableClients[i] = nil
+			p.availableClients = append(p.availableClients[:i], p.availableClients[i+1:]...)
+			AdjustIdleConnections(host, -1)
+			break
+		}
+	}
+	c.closed = true
+}
+
+func (p *PingPongConnPool) onStreamDestroy(c *pingPongClient) {
+	host := p.Host()
+	host.HostStats().UpstreamRequestActive.Dec(1)
+	host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
+	host.ClusterInfo().ResourceManager().Requests().Decrease()
+
+	p.clientMux.Lock()
+	if !c.closed {
+		p.availableClients = append(p.availableClients, c)
+		AdjustIdleConnections(host, 1)
+	}
+	p.clientMux.Unlock()
+}
+
+// pingPongClient is one connection owned by a PingPongConnPool - it serves
+// exactly one in-flight stream at a time, and is returned to the pool's
+// availableClients once that stream finishes.
+type pingPongClient struct {
+	pool   *PingPongConnPool
+	client Client
+	closed bool
+}
+
+// OnEvent implements api.ConnectionEventListener.
+func (c *pingPongClient) OnEvent(event api.ConnectionEvent) {
+	c.pool.onConnectionEvent(c, event)
+}
+
+// OnDestroyStream implements types.StreamEventListener.
+func (c *pingPongClient) OnDestroyStream() {
+	c.pool.onStreamDestroy(c)
+}
+
+// OnResetStream implements types.StreamEventListener.
+func (c *pingPongClient) OnResetStream(reason types.StreamResetReason) {
+	if reason == types.StreamLocalReset && !c.closed {
+		c.client.Close()
+	}
+}
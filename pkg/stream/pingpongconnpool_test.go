@@ -0,0 +1,188 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"testing"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+type pingPongTestStream struct {
+	types.Stream
+}
+
+func (s *pingPongTestStream) AddEventListener(types.StreamEventListener) {}
+
+type pingPongTestStreamSender struct {
+	types.StreamSender
+}
+
+func (s *pingPongTestStreamSender) GetStream() types.Stream { return &pingPongTestStream{} }
+
+type pingPongTestClient struct {
+	Client
+	conn    *boundTestConnection
+	closed  bool
+	streams int
+}
+
+func (c *pingPongTestClient) NewStream(ctx context.Context, receiver types.StreamReceiveListener) types.StreamSender {
+	c.streams++
+	return &pingPongTestStreamSender{}
+}
+
+func (c *pingPongTestClient) AddConnectionEventListener(listener api.ConnectionEventListener) {}
+
+func (c *pingPongTestClient) Close() { c.closed = true }
+
+type pingPongTestHost struct {
+	*boundTestHost
+	hostMaxConns              uint32
+	requestPendingOverflow    gometrics.Counter
+	connectionPendingOverflow gometrics.Counter
+}
+
+func (h *pingPongTestHost) HostStats() types.HostStats {
+	stats := h.boundTestHost.HostStats()
+	stats.UpstreamRequestPendingOverflow = h.requestPendingOverflow
+	stats.UpstreamConnectionPendingOverflow = h.connectionPendingOverflow
+	return stats
+}
+
+func (h *pingPongTestHost) MaxConnections() uint32 { return h.hostMaxConns }
+
+func newPingPongTestHost(maxConns uint64) *pingPongTestHost {
+	host := newBoundTestHost()
+	host.ci.mgr.(*retryTestResourceManager).connections.max = maxConns
+	host.ci.stats.UpstreamRequestPendingOverflow = gometrics.NewCounter()
+	return &pingPongTestHost{
+		boundTestHost:             host,
+		requestPendingOverflow:    gometrics.NewCounter(),
+		connectionPendingOverflow: gometrics.NewCounter(),
+	}
+}
+
+func newPingPongTestPool(host *pingPongTestHost) (*PingPongConnPool, *[]*pingPongTestClient) {
+	var built []*pingPongTestClient
+	pool := NewPingPongConnPool(protocolNameForTest, host, func(ctx context.Context, connData types.CreateConnectionData) Client {
+		c := &pingPongTestClient{conn: connData.Connection.(*boundTestConnection)}
+		built = append(built, c)
+		return c
+	})
+	return pool, &built
+}
+
+func TestPingPongConnPoolDialsSeparateConnectionForEachConcurrentStream(t *testing.T) {
+	host := newPingPongTestHost(0)
+	pool, built := newPingPongTestPool(host)
+
+	l1 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l1)
+	l2 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l2)
+
+	if !l1.ready || !l2.ready {
+		t.Fatalf("expected both streams ready, got %v %v", l1.failure, l2.failure)
+	}
+	if len(*built) != 2 {
+		t.Fatalf("expected two distinct connections for two concurrent streams, got %d", len(*built))
+	}
+}
+
+func TestPingPongConnPoolReusesConnectionOnceItsStreamFinishes(t *testing.T) {
+	host := newPingPongTestHost(0)
+	pool, built := newPingPongTestPool(host)
+
+	l1 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l1)
+	if !l1.ready {
+		t.Fatalf("expected stream ready, got %v", l1.failure)
+	}
+	c := (*built)[0]
+	pool.onStreamDestroy(&pingPongClient{pool: pool, client: c})
+
+	l2 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l2)
+	if !l2.ready {
+		t.Fatalf("expected stream ready, got %v", l2.failure)
+	}
+	if len(*built) != 1 {
+		t.Fatalf("expected the idle connection to be reused, got %d dials", len(*built))
+	}
+}
+
+func TestPingPongConnPoolOverflowsAtMaxConnections(t *testing.T) {
+	host := newPingPongTestHost(1)
+	pool, built := newPingPongTestPool(host)
+
+	l1 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l1)
+	if !l1.ready {
+		t.Fatalf("expected first stream ready, got %v", l1.failure)
+	}
+
+	l2 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l2)
+	if l2.ready {
+		t.Fatal("expected second concurrent stream to overflow, got OnReady")
+	}
+	if l2.failure != types.Overflow {
+		t.Fatalf("expected Overflow, got %v", l2.failure)
+	}
+	if len(*built) != 1 {
+		t.Fatalf("expected only one connection to have been dialed, got %d", len(*built))
+	}
+	if host.connectionPendingOverflow.Count() != 0 {
+		t.Fatalf("expected the cluster-wide cap alone not to count as a host cap overflow, got %d", host.connectionPendingOverflow.Count())
+	}
+}
+
+// TestPingPongConnPoolOverflowsAtHostMaxConnections verifies that a host's
+// own MaxConnections cap is enforced even when the cluster's circuit
+// breaker connection limit still has headroom, and that hitting it is
+// recorded as a host-specific overflow rather than the cluster-wide one.
+func TestPingPongConnPoolOverflowsAtHostMaxConnections(t *testing.T) {
+	host := newPingPongTestHost(10)
+	host.hostMaxConns = 1
+	pool, built := newPingPongTestPool(host)
+
+	l1 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l1)
+	if !l1.ready {
+		t.Fatalf("expected first stream ready, got %v", l1.failure)
+	}
+
+	l2 := boundTestFailureListener(t)
+	pool.NewStream(context.Background(), nil, l2)
+	if l2.ready {
+		t.Fatal("expected second concurrent stream to overflow the host cap, got OnReady")
+	}
+	if l2.failure != types.Overflow {
+		t.Fatalf("expected Overflow, got %v", l2.failure)
+	}
+	if len(*built) != 1 {
+		t.Fatalf("expected only one connection to have been dialed, got %d", len(*built))
+	}
+	if host.connectionPendingOverflow.Count() != 1 {
+		t.Fatalf("expected the host cap overflow to be counted, got %d", host.connectionPendingOverflow.Count())
+	}
+}
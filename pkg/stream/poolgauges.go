@@ -0,0 +1,45 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import "mosn.io/mosn/pkg/types"
+
+// AdjustIdleConnections updates host's UpstreamConnectionIdle gauge (and the
+// cluster-wide one it rolls up into) by delta, e.g. +1 when a connection is
+// returned to a pool's idle set and -1 when it is handed out or closed.
+// Callers must already hold whatever lock protects their own idle-set
+// bookkeeping, since this only keeps the exported gauges in sync with it.
+func AdjustIdleConnections(host types.Host, delta int64) {
+	hostGauge := host.HostStats().UpstreamConnectionIdle
+	hostGauge.Update(hostGauge.Value() + delta)
+
+	clusterGauge := host.ClusterInfo().Stats().UpstreamConnectionIdle
+	clusterGauge.Update(clusterGauge.Value() + delta)
+}
+
+// AdjustQueueDepth updates host's UpstreamRequestQueueDepth gauge (and the
+// cluster-wide one it rolls up into) by delta, the same way AdjustIdleConnections
+// does for idle connections, for pools that queue streams (e.g. http2's
+// connPool.pending) rather than failing fast when saturated.
+func AdjustQueueDepth(host types.Host, delta int64) {
+	hostGauge := host.HostStats().UpstreamRequestQueueDepth
+	hostGauge.Update(hostGauge.Value() + delta)
+
+	clusterGauge := host.ClusterInfo().Stats().UpstreamRequestQueueDepth
+	clusterGauge.Update(clusterGauge.Value() + delta)
+}
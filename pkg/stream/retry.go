@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// MaxConnectRetries bounds how many additional dial attempts RetryConnect
+// makes after connect's first failure.
+const MaxConnectRetries = 2
+
+// connectRetryBaseInterval is the backoff before the first retry; each
+// further retry doubles the previous interval.
+const connectRetryBaseInterval = 25 * time.Millisecond
+
+// RetryConnect calls connect, which is expected to establish a fresh
+// upstream connection (CreateConnection plus Connect) and stash it wherever
+// the caller's activeClient keeps it, and retries on failure with
+// exponential backoff, gated by the cluster's Retries resource so a
+// misbehaving upstream can't turn every stream into an unbounded retry
+// storm. Every attempt calls connect anew rather than re-using the failed
+// ClientConnection, so a host resolving to more than one address picks a
+// different candidate on each retry the same way CreateConnection's Happy
+// Eyeballs racing does within a single attempt. Each retry is counted in
+// UpstreamConnectionRetry. On success, the total wall-clock time spent in
+// RetryConnect (including any backoff and retries) is sampled into
+// UpstreamConnectionConnectDuration.
+func RetryConnect(host types.Host, connect func() error) error {
+	start := time.Now()
+
+	err := connect()
+	if err == nil {
+		recordConnectDuration(host, start)
+		return nil
+	}
+
+	retries := host.ClusterInfo().ResourceManager().Retries()
+	backoff := connectRetryBaseInterval
+	for i := 0; i < MaxConnectRetries && retries.CanCreate(); i++ {
+		retries.Increase()
+		host.ClusterInfo().Stats().UpstreamConnectionRetry.Inc(1)
+
+		time.Sleep(backoff)
+		backoff *= 2
+
+		err = connect()
+		retries.Decrease()
+		if err == nil {
+			recordConnectDuration(host, start)
+			return nil
+		}
+	}
+	return err
+}
+
+func recordConnectDuration(host types.Host, start time.Time) {
+	elapsed := int64(time.Since(start))
+	host.HostStats().UpstreamConnectionConnectDuration.Update(elapsed)
+	host.ClusterInfo().Stats().UpstreamConnectionConnectDuration.Update(elapsed)
+}
+
+// RecordTLSHandshakeDuration samples how long conn's TLS handshake took, if
+// it performed one, into host's and its cluster's
+// UpstreamConnectionTLSHandshakeDuration histograms. Callers are expected to
+// invoke it right after a successful conn.Connect() inside their RetryConnect
+// closure. Plaintext connections report a zero TLSHandshakeDuration and are
+// not recorded.
+func RecordTLSHandshakeDuration(host types.Host, conn types.ClientConnection) {
+	d := conn.TLSHandshakeDuration()
+	if d == 0 {
+		return
+	}
+	elapsed := int64(d)
+	host.HostStats().UpstreamConnectionTLSHandshakeDuration.Update(elapsed)
+	host.ClusterInfo().Stats().UpstreamConnectionTLSHandshakeDuration.Update(elapsed)
+}
@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+type retryTestResource struct {
+	max uint64
+	cur int64
+}
+
+func (r *retryTestResource) CanCreate() bool   { return uint64(r.cur) < r.max }
+func (r *retryTestResource) Increase()         { r.cur++ }
+func (r *retryTestResource) Decrease()         { r.cur-- }
+func (r *retryTestResource) Cur() int64        { return r.cur }
+func (r *retryTestResource) UpdateCur(c int64) { r.cur = c }
+func (r *retryTestResource) Max() uint64       { return r.max }
+func (r *retryTestResource) Remaining() int64  { return int64(r.max) - r.cur }
+
+type retryTestResourceManager struct {
+	types.ResourceManager
+	retries     retryTestResource
+	requests    retryTestResource
+	connections retryTestResource
+}
+
+func (m *retryTestResourceManager) Retries() types.Resource { return &m.retries }
+
+func (m *retryTestResourceManager) Requests() types.Resource { return &m.requests }
+
+func (m *retryTestResourceManager) Connections() types.Resource { return &m.connections }
+
+type retryTestClusterInfo struct {
+	types.ClusterInfo
+	mgr   types.ResourceManager
+	stats types.ClusterStats
+}
+
+func (c *retryTestClusterInfo) ResourceManager() types.ResourceManager { return c.mgr }
+func (c *retryTestClusterInfo) Stats() types.ClusterStats              { return c.stats }
+
+type retryTestHost struct {
+	types.Host
+	ci types.ClusterInfo
+}
+
+func (h *retryTestHost) ClusterInfo() types.ClusterInfo { return h.ci }
+
+func (h *retryTestHost) HostStats() types.HostStats {
+	return types.HostStats{
+		UpstreamConnectionConnectDuration: gometrics.NewHistogram(gometrics.NewUniformSample(100)),
+	}
+}
+
+func newRetryTestHost(maxRetries uint64) (*retryTestHost, *retryTestClusterInfo) {
+	mgr := &retryTestResourceManager{
+		retries:     retryTestResource{max: maxRetries},
+		requests:    retryTestResource{max: 1024},
+		connections: retryTestResource{max: 1024},
+	}
+	ci := &retryTestClusterInfo{
+		mgr: mgr,
+		stats: types.ClusterStats{
+			UpstreamConnectionRetry:           gometrics.NewCounter(),
+			UpstreamConnectionIdle:            gometrics.NewGauge(),
+			UpstreamConnectionConnectDuration: gometrics.NewHistogram(gometrics.NewUniformSample(100)),
+			UpstreamRequestQueueDepth:         gometrics.NewGauge(),
+		},
+	}
+	return &retryTestHost{ci: ci}, ci
+}
+
+func TestRetryConnectSucceedsFirstTryWithoutRetrying(t *testing.T) {
+	host, ci := newRetryTestHost(5)
+
+	attempts := 0
+	err := RetryConnect(host, func() error {
+		attempts++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts)
+	}
+	if ci.stats.UpstreamConnectionRetry.Count() != 0 {
+		t.Fatalf("expected no retries counted, got %d", ci.stats.UpstreamConnectionRetry.Count())
+	}
+}
+
+func TestRetryConnectRetriesUntilSuccess(t *testing.T) {
+	host, ci := newRetryTestHost(5)
+
+	attempts := 0
+	err := RetryConnect(host, func() error {
+		attempts++
+		if attempts <= MaxConnectRetries {
+			return errors.New("connect refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if want := MaxConnectRetries + 1; attempts != want {
+		t.Fatalf("expected %d attempts, got %d", want, attempts)
+	}
+	if got := ci.stats.UpstreamConnectionRetry.Count(); got != int64(MaxConnectRetries) {
+		t.Fatalf("expected %d retries counted, got %d", MaxConnectRetries, got)
+	}
+}
+
+func TestRetryConnectGivesUpAfterMaxRetries(t *testing.T) {
+	host, ci := newRetryTestHost(5)
+
+	attempts := 0
+	wantErr := errors.New("connect refused")
+	err := RetryConnect(host, func() error {
+		attempts++
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("expected the last attempt's error, got %v", err)
+	}
+	if want := MaxConnectRetries + 1; attempts != want {
+		t.Fatalf("expected %d attempts, got %d", want, attempts)
+	}
+	if got := ci.stats.UpstreamConnectionRetry.Count(); got != int64(MaxConnectRetries) {
+		t.Fatalf("expected %d retries counted, got %d", MaxConnectRetries, got)
+	}
+}
+
+func TestRetryConnectHonorsRetriesResourceLimit(t *testing.T) {
+	host, ci := newRetryTestHost(0)
+
+	attempts := 0
+	err := RetryConnect(host, func() error {
+		attempts++
+		return errors.New("connect refused")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected no retries when the Retries resource is exhausted, got %d attempts", attempts)
+	}
+	if ci.stats.UpstreamConnectionRetry.Count() != 0 {
+		t.Fatalf("expected no retries counted, got %d", ci.stats.UpstreamConnectionRetry.Count())
+	}
+}
@@ -49,6 +49,17 @@ type Client interface {
 
 	ActiveRequestsNum() int
 
+	// MaxConcurrentStreams returns the maximum number of streams the
+	// underlying connection will allow to be open at once, as negotiated by
+	// the protocol (e.g. HTTP/2's SETTINGS_MAX_CONCURRENT_STREAMS). Returns
+	// 0 when the protocol has no such concept or the limit isn't known yet.
+	MaxConcurrentStreams() uint32
+
+	// Ping sends a protocol-level liveness probe and blocks until the peer
+	// acknowledges it, ctx is done, or the connection closes. Returns nil
+	// without sending anything for protocols with no such probe.
+	Ping(ctx context.Context) error
+
 	NewStream(context context.Context, respDecoder types.StreamReceiveListener) types.StreamSender
 
 	SetConnectionCollector(read, write metrics.Counter)
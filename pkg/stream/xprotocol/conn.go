@@ -271,6 +271,16 @@ func (sc *streamConn) handleFrame(ctx context.Context, frame xprotocol.XFrame) {
 	}
 }
 
+// handleRequest turns a decoded request frame into a new server stream.
+// Each call always starts a brand new stream, even for a frame whose
+// xprotocol.StreamEndPredicate says it isn't the end of its own stream:
+// unlike handleResponse's clientStreams, a server stream's buffers are
+// pooled per decode context (see streamBuffersByContext) and recycled as
+// soon as sc.ctxManager.Next() moves on, so it can't be kept alive across
+// several decoded frames without changing that pooling lifecycle. A
+// sub-protocol that streams multiple request frames to mosn - as opposed
+// to multiple response frames, which handleResponse already supports -
+// needs that follow-on change before it can be proxied this way.
 func (sc *streamConn) handleRequest(ctx context.Context, frame xprotocol.XFrame, oneway bool) {
 	// 1. heartbeat process
 	if frame.IsHeartbeatFrame() {
@@ -338,18 +348,28 @@ func (sc *streamConn) handleRequest(ctx context.Context, frame xprotocol.XFrame,
 func (sc *streamConn) handleResponse(ctx context.Context, frame xprotocol.XFrame) {
 	requestId := frame.GetRequestId()
 
-	// for client stream, remove stream on response read
+	// a frame only ends its stream if it says so explicitly (streaming
+	// sub-protocols) or doesn't support saying so at all (every other,
+	// unary sub-protocol keeps today's one-frame-and-done behavior)
+	end := true
+	if predicate, ok := frame.(xprotocol.StreamEndPredicate); ok {
+		end = predicate.IsEndFrame()
+	}
+
+	// for client stream, remove stream once its response is complete
 	sc.clientMutex.Lock()
 	defer sc.clientMutex.Unlock()
 
 	if clientStream, ok := sc.clientStreams[requestId]; ok {
-		delete(sc.clientStreams, requestId)
+		if end {
+			delete(sc.clientStreams, requestId)
+		}
 
 		// transmit buffer ctx
 		buffer.TransmitBufferPoolContext(clientStream.ctx, ctx)
 
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
-			log.Proxy.Debugf(clientStream.ctx, "[stream] [xprotocol] receive response, requestId = %v", requestId)
+			log.Proxy.Debugf(clientStream.ctx, "[stream] [xprotocol] receive response, requestId = %v, end = %v", requestId, end)
 		}
 
 		clientStream.receiver.OnReceive(clientStream.ctx, frame.GetHeader(), frame.GetData(), nil)
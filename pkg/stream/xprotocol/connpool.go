@@ -42,20 +42,45 @@ const (
 )
 
 func init() {
-	network.RegisterNewPoolFactory(protocol.Xprotocol, NewConnPool)
-	types.RegisterConnPoolFactory(protocol.Xprotocol, true)
+	network.RegisterNewBoundPoolFactory(protocol.Xprotocol, NewBoundConnPool)
+	types.RegisterConnPoolFactory(protocol.Xprotocol, func(ctx context.Context, host types.Host) types.ConnectionPool {
+		return NewConnPool(host)
+	})
 }
 
 // types.ConnectionPool
 // activeClient used as connected client
 // host is the upstream
 type connPool struct {
-	activeClients sync.Map //sub protocol -> activeClient
+	activeClients sync.Map //sub protocol -> activeClient, used when the sub protocol multiplexes
+	pingPongPools sync.Map //sub protocol -> *str.PingPongConnPool, used when the sub protocol does not
 	host          atomic.Value
 	mux           sync.Mutex
 	supportTLS    bool
 }
 
+// poolModeFor returns the sub protocol's declared xprotocol.PoolMode, or
+// xprotocol.Multiplex if it registered no opinion via xprotocol.PoolModeProvider.
+func poolModeFor(subProtocol types.ProtocolName) xprotocol.PoolMode {
+	if subProtocol == "" {
+		return xprotocol.Multiplex
+	}
+	if provider, ok := xprotocol.GetProtocol(subProtocol).(xprotocol.PoolModeProvider); ok {
+		return provider.PoolMode()
+	}
+	return xprotocol.Multiplex
+}
+
+// pingPongPoolFor returns the PingPongConnPool for subProtocol, creating it
+// on first use.
+func (p *connPool) pingPongPoolFor(subProtocol types.ProtocolName) *str.PingPongConnPool {
+	if v, ok := p.pingPongPools.Load(subProtocol); ok {
+		return v.(*str.PingPongConnPool)
+	}
+	v, _ := p.pingPongPools.LoadOrStore(subProtocol, str.NewPingPongConnPool(protocol.Xprotocol, p.Host(), p.createStreamClient))
+	return v.(*str.PingPongConnPool)
+}
+
 // NewConnPool
 func NewConnPool(host types.Host) types.ConnectionPool {
 	p := &connPool{
@@ -99,6 +124,10 @@ func (p *connPool) Host() types.Host {
 func (p *connPool) UpdateHost(h types.Host) {
 	// TODO: update tls support flag
 	p.host.Store(h)
+	p.pingPongPools.Range(func(k, v interface{}) bool {
+		v.(*str.PingPongConnPool).UpdateHost(h)
+		return true
+	})
 }
 
 func (p *connPool) CheckAndInit(ctx context.Context) bool {
@@ -106,6 +135,10 @@ func (p *connPool) CheckAndInit(ctx context.Context) bool {
 
 	subProtocol := getSubProtocol(ctx)
 
+	if poolModeFor(subProtocol) == xprotocol.PingPong {
+		return p.pingPongPoolFor(subProtocol).CheckAndInit(ctx)
+	}
+
 	v, ok := p.activeClients.Load(subProtocol)
 	if !ok {
 		fakeclient := &activeClient{}
@@ -135,6 +168,11 @@ func (p *connPool) NewStream(ctx context.Context,
 	responseDecoder types.StreamReceiveListener, listener types.PoolEventListener) {
 	subProtocol := getSubProtocol(ctx)
 
+	if poolModeFor(subProtocol) == xprotocol.PingPong {
+		p.pingPongPoolFor(subProtocol).NewStream(ctx, responseDecoder, listener)
+		return
+	}
+
 	client, _ := p.activeClients.Load(subProtocol)
 	host := p.Host()
 
@@ -154,10 +192,20 @@ func (p *connPool) NewStream(ctx context.Context,
 		host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
 		host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
 	} else {
-		atomic.AddUint64(&activeClient.totalStream, 1)
+		totalStream := atomic.AddUint64(&activeClient.totalStream, 1)
 		host.HostStats().UpstreamRequestTotal.Inc(1)
 		host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
 
+		if exceedsConnectionLimits(host.ClusterInfo(), totalStream, activeClient.createTime) {
+			// detach the client from the pool so the next CheckAndInit call
+			// creates a fresh one; this client is closed once its in-flight
+			// requests finish, see onStreamDestroy
+			p.mux.Lock()
+			p.activeClients.Delete(subProtocol)
+			p.mux.Unlock()
+			atomic.StoreUint32(&activeClient.shouldClose, 1)
+		}
+
 		var streamEncoder types.StreamSender
 		// oneway
 		if responseDecoder == nil {
@@ -166,6 +214,7 @@ func (p *connPool) NewStream(ctx context.Context,
 			streamEncoder = activeClient.client.NewStream(ctx, responseDecoder)
 			streamEncoder.GetStream().AddEventListener(activeClient)
 
+			atomic.AddInt32(&activeClient.activeRequest, 1)
 			host.HostStats().UpstreamRequestActive.Inc(1)
 			host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
 			host.ClusterInfo().ResourceManager().Requests().Increase()
@@ -187,6 +236,10 @@ func (p *connPool) Close() {
 	}
 
 	p.activeClients.Range(f)
+	p.pingPongPools.Range(func(k, v interface{}) bool {
+		v.(*str.PingPongConnPool).Close()
+		return true
+	})
 }
 
 // Shutdown stop the keepalive, so the connection will be idle after requests finished
@@ -199,6 +252,10 @@ func (p *connPool) Shutdown() {
 		return true
 	}
 	p.activeClients.Range(f)
+	p.pingPongPools.Range(func(k, v interface{}) bool {
+		v.(*str.PingPongConnPool).Shutdown()
+		return true
+	})
 }
 
 func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionEvent) {
@@ -251,6 +308,27 @@ func (p *connPool) onStreamDestroy(client *activeClient) {
 	host.HostStats().UpstreamRequestActive.Dec(1)
 	host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
 	host.ClusterInfo().ResourceManager().Requests().Decrease()
+
+	// a client marked by shouldClose has already been detached from the
+	// pool, see NewStream; once its last in-flight request finishes, close
+	// it for real
+	if atomic.AddInt32(&client.activeRequest, -1) == 0 && atomic.LoadUint32(&client.shouldClose) == 1 {
+		client.client.Close()
+	}
+}
+
+// exceedsConnectionLimits reports whether a connection that has served
+// totalStream requests since createTime has hit the cluster's configured
+// MaxRequestsPerConn or MaxConnectionDuration. Either limit is ignored when
+// it is zero (unlimited).
+func exceedsConnectionLimits(info types.ClusterInfo, totalStream uint64, createTime time.Time) bool {
+	if maxReq := info.MaxRequestsPerConn(); maxReq > 0 && totalStream >= uint64(maxReq) {
+		return true
+	}
+	if maxDuration := info.MaxConnectionDuration(); maxDuration > 0 && time.Since(createTime) >= maxDuration {
+		return true
+	}
+	return false
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -272,6 +350,15 @@ func (p *connPool) createStreamClient(context context.Context, connData types.Cr
 	return str.NewStreamClient(context, protocol.Xprotocol, connData.Connection, connData.Host)
 }
 
+// NewBoundConnPool creates a connection pool that binds a single upstream
+// connection to host for the pool's lifetime, for clusters with
+// ConnectionAffinity enabled.
+func NewBoundConnPool(host types.Host) types.ConnectionPool {
+	return str.NewBoundConnPool(protocol.Xprotocol, host, func(ctx context.Context, connData types.CreateConnectionData) str.Client {
+		return str.NewStreamClient(ctx, protocol.Xprotocol, connData.Connection, connData.Host)
+	})
+}
+
 // keepAliveListener is a types.ConnectionEventListener
 type keepAliveListener struct {
 	keepAlive types.KeepAlive
@@ -294,43 +381,55 @@ type activeClient struct {
 	host               types.CreateConnectionData
 	closeWithActiveReq bool
 	totalStream        uint64
+	activeRequest      int32
+	shouldClose        uint32
 	state              uint32
+	createTime         time.Time
 }
 
 func newActiveClient(ctx context.Context, subProtocol types.ProtocolName, pool *connPool) *activeClient {
 	ac := &activeClient{
 		subProtocol: subProtocol,
 		pool:        pool,
+		createTime:  time.Now(),
 	}
 
 	host := pool.Host()
-	data := host.CreateConnection(ctx)
-	connCtx := mosnctx.WithValue(ctx, types.ContextKeyConnectionID, data.Connection.ID())
-	connCtx = mosnctx.WithValue(ctx, types.ContextSubProtocol, string(subProtocol))
-	codecClient := pool.createStreamClient(connCtx, data)
-	codecClient.AddConnectionEventListener(ac)
-	codecClient.SetStreamConnectionEventListener(ac)
-
-	ac.client = codecClient
-	ac.host = data
-
-	// Add Keep Alive
-	// protocol is from onNewDetectStream
-	if subProtocol != "" {
-		// check heartbeat enable, hack: judge trigger result of Heartbeater
-		proto := xprotocol.GetProtocol(subProtocol)
-		if heartbeater, ok := proto.(xprotocol.Heartbeater); ok && heartbeater.Trigger(0) != nil {
-			// create keepalive
-			rpcKeepAlive := NewKeepAlive(codecClient, subProtocol, time.Second, 6)
-			rpcKeepAlive.StartIdleTimeout()
-			ac.keepAlive = &keepAliveListener{
-				keepAlive: rpcKeepAlive,
+
+	err := str.RetryConnect(host, func() error {
+		data := host.CreateConnection(ctx)
+		connCtx := mosnctx.WithValue(ctx, types.ContextKeyConnectionID, data.Connection.ID())
+		connCtx = mosnctx.WithValue(ctx, types.ContextSubProtocol, string(subProtocol))
+		codecClient := pool.createStreamClient(connCtx, data)
+		codecClient.AddConnectionEventListener(ac)
+		codecClient.SetStreamConnectionEventListener(ac)
+
+		ac.client = codecClient
+		ac.host = data
+
+		// Add Keep Alive
+		// protocol is from onNewDetectStream
+		if subProtocol != "" {
+			// check heartbeat enable, hack: judge trigger result of Heartbeater
+			proto := xprotocol.GetProtocol(subProtocol)
+			if heartbeater, ok := proto.(xprotocol.Heartbeater); ok && heartbeater.Trigger(0) != nil {
+				// create keepalive
+				rpcKeepAlive := NewKeepAlive(codecClient, subProtocol, time.Second, 6)
+				rpcKeepAlive.StartIdleTimeout()
+				ac.keepAlive = &keepAliveListener{
+					keepAlive: rpcKeepAlive,
+				}
+				ac.client.AddConnectionEventListener(ac.keepAlive)
 			}
-			ac.client.AddConnectionEventListener(ac.keepAlive)
 		}
-	}
 
-	if err := ac.client.Connect(); err != nil {
+		if err := ac.client.Connect(); err != nil {
+			return err
+		}
+		str.RecordTLSHandshakeDuration(host, data.Connection)
+		return nil
+	})
+	if err != nil {
 		return nil
 	}
 
@@ -341,7 +440,7 @@ func newActiveClient(ctx context.Context, subProtocol types.ProtocolName, pool *
 	host.ClusterInfo().Stats().UpstreamConnectionActive.Inc(1)
 
 	// bytes total adds all connections data together
-	codecClient.SetConnectionCollector(host.ClusterInfo().Stats().UpstreamBytesReadTotal, host.ClusterInfo().Stats().UpstreamBytesWriteTotal)
+	ac.client.SetConnectionCollector(host.ClusterInfo().Stats().UpstreamBytesReadTotal, host.ClusterInfo().Stats().UpstreamBytesWriteTotal)
 
 	return ac
 }
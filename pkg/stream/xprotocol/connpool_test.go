@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+// pingPongBolt wraps the bolt protocol only to declare PoolMode() PingPong,
+// so it can be registered under its own name without touching the real
+// bolt.ProtocolName registration used by the rest of the suite.
+type pingPongBolt struct {
+	xprotocol.XProtocol
+}
+
+func (p *pingPongBolt) PoolMode() xprotocol.PoolMode { return xprotocol.PingPong }
+
+const pingPongTestProtocol = types.ProtocolName("bolt-pingpong-test")
+
+func init() {
+	xprotocol.RegisterProtocol(pingPongTestProtocol, &pingPongBolt{XProtocol: xprotocol.GetProtocol(bolt.ProtocolName)})
+}
+
+// connPoolTestResource and connPoolTestResourceManager give the test's
+// cluster info a ResourceManager with configurable limits, so pool
+// exhaustion behavior can be exercised - mock_test.go's mockClusterInfo
+// only returns an always-available ResourceManager.
+type connPoolTestResource struct {
+	max uint64
+	cur int64
+}
+
+func (r *connPoolTestResource) CanCreate() bool   { return uint64(r.cur) < r.max }
+func (r *connPoolTestResource) Increase()         { r.cur++ }
+func (r *connPoolTestResource) Decrease()         { r.cur-- }
+func (r *connPoolTestResource) Cur() int64        { return r.cur }
+func (r *connPoolTestResource) UpdateCur(c int64) { r.cur = c }
+func (r *connPoolTestResource) Max() uint64       { return r.max }
+func (r *connPoolTestResource) Remaining() int64  { return int64(r.max) - r.cur }
+
+type connPoolTestResourceManager struct {
+	types.ResourceManager
+	requests    connPoolTestResource
+	connections connPoolTestResource
+}
+
+func (m *connPoolTestResourceManager) Requests() types.Resource    { return &m.requests }
+func (m *connPoolTestResourceManager) Connections() types.Resource { return &m.connections }
+
+type connPoolTestClusterInfo struct {
+	types.ClusterInfo
+	mgr   *connPoolTestResourceManager
+	stats types.ClusterStats
+}
+
+func (c *connPoolTestClusterInfo) Name() string                 { return "test" }
+func (c *connPoolTestClusterInfo) ConnBufferLimitBytes() uint32 { return 1024 }
+func (c *connPoolTestClusterInfo) ConnectionOptions() v2.UpstreamConnectionOptions {
+	return v2.UpstreamConnectionOptions{}
+}
+func (c *connPoolTestClusterInfo) ResourceManager() types.ResourceManager { return c.mgr }
+func (c *connPoolTestClusterInfo) Stats() types.ClusterStats              { return c.stats }
+func (c *connPoolTestClusterInfo) ConnectTimeout() time.Duration          { return time.Second }
+
+func newConnPoolTestHost(t *testing.T, srv *mockServer, maxConns uint64) types.Host {
+	info := &connPoolTestClusterInfo{
+		mgr: &connPoolTestResourceManager{
+			requests:    connPoolTestResource{max: 1024},
+			connections: connPoolTestResource{max: maxConns},
+		},
+		stats: types.ClusterStats{
+			UpstreamRequestTotal:              gometrics.NewCounter(),
+			UpstreamRequestActive:             gometrics.NewCounter(),
+			UpstreamRequestPendingOverflow:    gometrics.NewCounter(),
+			UpstreamConnectionTotal:           gometrics.NewCounter(),
+			UpstreamConnectionActive:          gometrics.NewCounter(),
+			UpstreamBytesReadTotal:            gometrics.NewCounter(),
+			UpstreamBytesWriteTotal:           gometrics.NewCounter(),
+			UpstreamConnectionIdle:            gometrics.NewGauge(),
+			UpstreamConnectionConnectDuration: gometrics.NewHistogram(gometrics.NewUniformSample(100)),
+			UpstreamRequestQueueDepth:         gometrics.NewGauge(),
+		},
+	}
+	cfg := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:    srv.AddrString(),
+			TLSDisable: true,
+		},
+	}
+	return cluster.NewSimpleHost(cfg, info)
+}
+
+type connPoolTestListener struct {
+	t       *testing.T
+	ready   bool
+	failure types.PoolFailureReason
+}
+
+func (l *connPoolTestListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.failure = reason
+}
+
+func (l *connPoolTestListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.ready = true
+}
+
+func TestPoolModeForDefaultsToMultiplex(t *testing.T) {
+	if mode := poolModeFor(bolt.ProtocolName); mode != xprotocol.Multiplex {
+		t.Fatalf("expected bolt (no PoolModeProvider) to default to Multiplex, got %v", mode)
+	}
+	if mode := poolModeFor(""); mode != xprotocol.Multiplex {
+		t.Fatalf("expected empty sub protocol to default to Multiplex, got %v", mode)
+	}
+}
+
+func TestPoolModeForHonorsDeclaredPingPong(t *testing.T) {
+	if mode := poolModeFor(pingPongTestProtocol); mode != xprotocol.PingPong {
+		t.Fatalf("expected declared PingPong mode, got %v", mode)
+	}
+}
+
+func TestConnPoolRoutesDeclaredPingPongSubProtocolToPingPongPool(t *testing.T) {
+	log.DefaultLogger.SetLogLevel(log.DEBUG)
+	defer log.DefaultLogger.SetLogLevel(log.INFO)
+
+	srv, err := newMockServer(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	srv.GoServe()
+
+	host := newConnPoolTestHost(t, srv, 0)
+	pool := NewConnPool(host).(*connPool)
+
+	newCtx := func() context.Context {
+		return mosnctx.WithValue(context.Background(), types.ContextSubProtocol, string(pingPongTestProtocol))
+	}
+
+	if !pool.CheckAndInit(newCtx()) {
+		t.Fatal("expected ping-pong sub protocol to be ready immediately")
+	}
+	if _, ok := pool.activeClients.Load(pingPongTestProtocol); ok {
+		t.Fatal("expected ping-pong sub protocol not to use the multiplex activeClients map")
+	}
+
+	// Each stream gets its own context, exactly as proxy.go/downstream.go do
+	// for real requests - mosnctx.WithValue mutates a *valueCtx in place, so
+	// reusing one context across streams would let the second stream's own
+	// codec (conn.go's clientStream.ctx) stomp the first's sub protocol.
+	l1 := &connPoolTestListener{t: t}
+	pool.NewStream(newCtx(), nil, l1)
+	l2 := &connPoolTestListener{t: t}
+	pool.NewStream(newCtx(), nil, l2)
+
+	// give the server goroutines a moment to accept both dialed connections
+	time.Sleep(50 * time.Millisecond)
+
+	if !l1.ready || !l2.ready {
+		t.Fatalf("expected both streams ready, got failures %v %v", l1.failure, l2.failure)
+	}
+	if _, ok := pool.pingPongPools.Load(pingPongTestProtocol); !ok {
+		t.Fatal("expected a PingPongConnPool to have been created for the declared sub protocol")
+	}
+}
@@ -19,9 +19,12 @@ package xprotocol
 
 import (
 	"context"
+	"strings"
 
 	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
 	"mosn.io/mosn/pkg/stream"
 	"mosn.io/mosn/pkg/types"
 )
@@ -48,6 +51,44 @@ func (f *streamConnFactory) CreateBiDirectStream(context context.Context, connec
 	return newStreamConnection(context, connection, clientCallbacks, serverCallbacks)
 }
 
-func (f *streamConnFactory) ProtocolMatch(context context.Context, prot string, magic []byte) error {
+// ProtocolMatch lets "Auto" downstream protocol detection (see
+// pkg/proxy's use of stream.SelectStreamFactoryProtocol) dispatch into
+// xprotocol, on top of the existing HTTP/1.x and HTTP/2 detection: it
+// tries the magic bytes against every sub-protocol named in the
+// connection's sub_protocol extend config (the same comma-separated list
+// newStreamConnection uses to build an XEngine once xprotocol has
+// already been chosen), the same way a multi-protocol xprotocol listener
+// already sniffs which of several configured RPC protocols a connection
+// speaks. Auto-detecting across every x-protocol ever registered,
+// whether or not the listener was configured to expect it, would risk
+// mistaking one RPC protocol's frame for another on byte patterns alone;
+// sub_protocol keeps the candidate set to what the operator opted into.
+func (f *streamConnFactory) ProtocolMatch(ctx context.Context, prot string, magic []byte) error {
+	if ctx == nil {
+		return stream.FAILED
+	}
+	value := mosnctx.Get(ctx, types.ContextSubProtocol)
+	subProtocol, ok := value.(string)
+	if !ok || subProtocol == "" {
+		return stream.FAILED
+	}
+
+	again := false
+	for _, name := range strings.Split(subProtocol, ",") {
+		matchFunc := xprotocol.GetMatcher(types.ProtocolName(name))
+		if matchFunc == nil {
+			continue
+		}
+		switch matchFunc(magic) {
+		case types.MatchSuccess:
+			return nil
+		case types.MatchAgain:
+			again = true
+		}
+	}
+
+	if again {
+		return stream.EAGAIN
+	}
 	return stream.FAILED
 }
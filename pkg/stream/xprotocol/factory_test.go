@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"context"
+	"testing"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	_ "mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	_ "mosn.io/mosn/pkg/protocol/xprotocol/dubbo"
+	"mosn.io/mosn/pkg/stream"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestProtocolMatchWithoutSubProtocolConfigured(t *testing.T) {
+	f := &streamConnFactory{}
+	if err := f.ProtocolMatch(context.Background(), "", []byte{1, 2, 3}); err != stream.FAILED {
+		t.Errorf("ProtocolMatch() with no sub_protocol configured = %v, want stream.FAILED", err)
+	}
+}
+
+func TestProtocolMatchAgainstConfiguredSubProtocols(t *testing.T) {
+	f := &streamConnFactory{}
+	ctx := mosnctx.WithValue(context.Background(), types.ContextSubProtocol, "bolt,dubbo")
+
+	if err := f.ProtocolMatch(ctx, "", []byte{1, 2, 3}); err != nil {
+		t.Errorf("ProtocolMatch() with bolt magic byte = %v, want nil", err)
+	}
+	if err := f.ProtocolMatch(ctx, "", []byte{}); err != stream.EAGAIN {
+		t.Errorf("ProtocolMatch() with no data yet = %v, want stream.EAGAIN", err)
+	}
+	notBoltNotDubbo := make([]byte, 16) // long enough that dubbo's magic-tag check runs instead of asking for more
+	notBoltNotDubbo[0] = 0xff
+	if err := f.ProtocolMatch(ctx, "", notBoltNotDubbo); err != stream.FAILED {
+		t.Errorf("ProtocolMatch() with data matching neither configured protocol = %v, want stream.FAILED", err)
+	}
+}
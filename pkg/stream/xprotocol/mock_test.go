@@ -22,6 +22,7 @@ import (
 	"net"
 	"time"
 
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/protocol/xprotocol"
 	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
@@ -146,3 +147,30 @@ func (ci *mockClusterInfo) SourceAddress() net.Addr {
 func (ci *mockClusterInfo) ConnectTimeout() time.Duration {
 	return network.DefaultConnectTimeout
 }
+
+func (ci *mockClusterInfo) ConnectionOptions() v2.UpstreamConnectionOptions {
+	return v2.UpstreamConnectionOptions{}
+}
+
+func (ci *mockClusterInfo) ResourceManager() types.ResourceManager {
+	return &mockResourceManager{}
+}
+
+type mockResource struct{}
+
+func (r *mockResource) CanCreate() bool  { return true }
+func (r *mockResource) Increase()        {}
+func (r *mockResource) Decrease()        {}
+func (r *mockResource) Cur() int64       { return 0 }
+func (r *mockResource) UpdateCur(int64)  {}
+func (r *mockResource) Max() uint64      { return 0 }
+func (r *mockResource) Remaining() int64 { return 0 }
+
+type mockResourceManager struct {
+	types.ResourceManager
+}
+
+func (m *mockResourceManager) Connections() types.Resource     { return &mockResource{} }
+func (m *mockResourceManager) PendingRequests() types.Resource { return &mockResource{} }
+func (m *mockResourceManager) Requests() types.Resource        { return &mockResource{} }
+func (m *mockResourceManager) Retries() types.Resource         { return &mockResource{} }
@@ -73,9 +73,12 @@ func (s *xStream) AppendHeaders(ctx context.Context, headers types.HeaderMap, en
 		s.frame = frame
 	}
 
-	// endStream
+	// endStream, or flush this frame and keep the stream open for more -
+	// see flushFrame's doc comment
 	if endStream {
 		s.endStream()
+	} else {
+		s.flushFrame()
 	}
 	return
 }
@@ -100,6 +103,8 @@ func (s *xStream) AppendData(context context.Context, data types.IoBuffer, endSt
 
 	if endStream {
 		s.endStream()
+	} else {
+		s.flushFrame()
 	}
 
 	return nil
@@ -125,32 +130,44 @@ func (s *xStream) endStream() {
 		log.Proxy.Debugf(s.ctx, "[stream] [xprotocol] endStream, direction = %d, requestId = %v", s.direction, s.id)
 	}
 
-	if s.frame != nil {
-		// replace requestID
-		s.frame.SetRequestId(s.id)
+	s.flushFrame()
+}
 
-		// remove injected headers
-		if _, ok := s.frame.(xprotocol.ServiceAware); ok {
-			s.frame.GetHeader().Del(types.HeaderRPCService)
-			s.frame.GetHeader().Del(types.HeaderRPCMethod)
-		}
+// flushFrame encodes and writes s.frame, without destroying the stream
+// afterwards. AppendHeaders/AppendData call this instead of endStream when
+// their endStream argument is false, so a sub-protocol whose frame
+// implements xprotocol.StreamEndPredicate can send more than one frame
+// under the same request-id - e.g. a streaming RPC relaying several
+// messages before the stream actually closes.
+func (s *xStream) flushFrame() {
+	if s.frame == nil {
+		return
+	}
 
-		buf, err := s.sc.protocol.Encode(s.ctx, s.frame)
-		if err != nil {
-			log.Proxy.Errorf(s.ctx, "[stream] [xprotocol] encode error:%s, requestId = %v", err.Error(), s.id)
-			s.ResetStream(types.StreamLocalReset)
-			return
-		}
+	// replace requestID
+	s.frame.SetRequestId(s.id)
 
-		err = s.sc.netConn.Write(buf)
+	// remove injected headers
+	if _, ok := s.frame.(xprotocol.ServiceAware); ok {
+		s.frame.GetHeader().Del(types.HeaderRPCService)
+		s.frame.GetHeader().Del(types.HeaderRPCMethod)
+	}
 
-		if err != nil {
-			log.Proxy.Errorf(s.ctx, "[stream] [xprotocol] endStream, requestId = %v, error = %v", s.id, err)
-			if err == types.ErrConnectionHasClosed {
-				s.ResetStream(types.StreamConnectionFailed)
-			} else {
-				s.ResetStream(types.StreamLocalReset)
-			}
+	buf, err := s.sc.protocol.Encode(s.ctx, s.frame)
+	if err != nil {
+		log.Proxy.Errorf(s.ctx, "[stream] [xprotocol] encode error:%s, requestId = %v", err.Error(), s.id)
+		s.ResetStream(types.StreamLocalReset)
+		return
+	}
+
+	err = s.sc.netConn.Write(buf)
+
+	if err != nil {
+		log.Proxy.Errorf(s.ctx, "[stream] [xprotocol] flushFrame, requestId = %v, error = %v", s.id, err)
+		if err == types.ErrConnectionHasClosed {
+			s.ResetStream(types.StreamConnectionFailed)
+		} else {
+			s.ResetStream(types.StreamLocalReset)
 		}
 	}
 }
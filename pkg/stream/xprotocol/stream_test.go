@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// unaryTestFrame is a minimal xprotocol.XFrame that doesn't implement
+// xprotocol.StreamEndPredicate at all, like every sub-protocol in this
+// repo today.
+type unaryTestFrame struct {
+	requestId uint64
+	header    protocol.CommonHeader
+	data      types.IoBuffer
+}
+
+func (f *unaryTestFrame) GetRequestId() uint64   { return f.requestId }
+func (f *unaryTestFrame) SetRequestId(id uint64) { f.requestId = id }
+func (f *unaryTestFrame) IsHeartbeatFrame() bool { return false }
+func (f *unaryTestFrame) GetStreamType() xprotocol.StreamType {
+	return xprotocol.Response
+}
+func (f *unaryTestFrame) GetHeader() types.HeaderMap  { return f.header }
+func (f *unaryTestFrame) GetData() types.IoBuffer     { return f.data }
+func (f *unaryTestFrame) SetData(data types.IoBuffer) { f.data = data }
+
+// streamingTestFrame additionally implements xprotocol.StreamEndPredicate,
+// so handleResponse's multi-frame handling can be exercised without a real
+// streaming sub-protocol.
+type streamingTestFrame struct {
+	unaryTestFrame
+	end bool
+}
+
+func (f *streamingTestFrame) IsEndFrame() bool { return f.end }
+
+type recordingReceiver struct {
+	receives int
+}
+
+func (r *recordingReceiver) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+	r.receives++
+}
+func (r *recordingReceiver) OnDecodeError(context context.Context, err error, headers types.HeaderMap) {
+}
+
+func TestHandleResponseKeepsMultiFrameStreamOpenUntilEndFrame(t *testing.T) {
+	sc := &streamConn{
+		clientStreams: make(map[uint64]*xStream, 1),
+	}
+	receiver := &recordingReceiver{}
+	clientStream := &xStream{id: 1, sc: sc, receiver: receiver, ctx: context.Background()}
+	sc.clientStreams[1] = clientStream
+
+	// first frame isn't the end: the stream must stay registered so a
+	// later frame under the same request-id is still delivered
+	sc.handleResponse(context.Background(), &streamingTestFrame{unaryTestFrame: unaryTestFrame{requestId: 1, header: protocol.CommonHeader{}, data: buffer.NewIoBuffer(0)}, end: false})
+	if _, ok := sc.clientStreams[1]; !ok {
+		t.Fatal("non-end frame should not remove the client stream")
+	}
+	if receiver.receives != 1 {
+		t.Fatalf("receives = %d, want 1", receiver.receives)
+	}
+
+	// second, end frame: the stream is now complete and removed
+	sc.handleResponse(context.Background(), &streamingTestFrame{unaryTestFrame: unaryTestFrame{requestId: 1, header: protocol.CommonHeader{}, data: buffer.NewIoBuffer(0)}, end: true})
+	if _, ok := sc.clientStreams[1]; ok {
+		t.Fatal("end frame should remove the client stream")
+	}
+	if receiver.receives != 2 {
+		t.Fatalf("receives = %d, want 2", receiver.receives)
+	}
+}
+
+func TestHandleResponseUnaryFrameEndsImmediately(t *testing.T) {
+	sc := &streamConn{
+		clientStreams: make(map[uint64]*xStream, 1),
+	}
+	receiver := &recordingReceiver{}
+	clientStream := &xStream{id: 1, sc: sc, receiver: receiver, ctx: context.Background()}
+	sc.clientStreams[1] = clientStream
+
+	frame := &unaryTestFrame{requestId: 1, header: protocol.CommonHeader{}, data: buffer.NewIoBuffer(0)}
+	sc.handleResponse(context.Background(), frame)
+
+	if _, ok := sc.clientStreams[1]; ok {
+		t.Fatal("a frame without StreamEndPredicate should end its stream immediately")
+	}
+}
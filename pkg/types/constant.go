@@ -38,6 +38,7 @@ const (
 	HeaderXprotocolRespStatus      = "x-mosn-xprotocol-resp-status"
 	HeaderXprotocolRespIsException = "x-mosn-xprotocol-resp-is-exception"
 	HeaderXprotocolHeartbeat       = "x-protocol-heartbeat"
+	HeaderUpstreamHostOverride     = "x-mosn-upstream-host"
 )
 
 // Error messages
@@ -44,6 +44,9 @@ const (
 	ContextKeyProxyGeneralConfig
 	ContextKeyDownStreamProtocol
 	ContextKeyDownStreamHeaders
+	ContextKeyDynamicMetadataMatchCriteria
+	ContextKeyTranscoderMethod
+	ContextKeyGRPCWebText
 	ContextKeyEnd
 )
 
@@ -25,10 +25,21 @@ const (
 	FailureNetwork FailureType = "Network"
 	FailurePassive FailureType = "Passive"
 	FailureActive  FailureType = "Active"
+	// FailureTimeout is a check that did not get a response before the
+	// checker's own timeout elapsed, as opposed to a check that got an
+	// immediate network-level error (connection refused, reset, ...)
+	FailureTimeout FailureType = "Timeout"
 )
 
-// HealthCheckCb is the health check's callback function
-type HealthCheckCb func(host Host, changedState bool, isHealthy bool)
+// HealthCheckCb is the health check's callback function. changedState
+// reports whether this check flipped the host's reported health state;
+// wasHealthy and isHealthy are the host's health immediately before and
+// after the check. reason explains why isHealthy is false, and is ignored
+// when isHealthy is true. A callback that needs to avoid flapping on a
+// single probe should rely on changedState rather than isHealthy alone,
+// since the health checker already only flips state after the configured
+// healthy/unhealthy threshold of consecutive results.
+type HealthCheckCb func(host Host, changedState bool, isHealthy bool, wasHealthy bool, reason FailureType)
 
 // HealthChecker is a framework for connection management
 // When NewCluster is called, and the config contains health check related, mosn will create
@@ -56,5 +67,8 @@ type HealthCheckSession interface {
 
 // HealthCheckSessionFactory creates a HealthCheckSession
 type HealthCheckSessionFactory interface {
-	NewSession(cfg map[string]interface{}, host Host) HealthCheckSession
+	// NewSession creates a session for host. tlsMng is the health check's
+	// own TLS transport, independent of the cluster's data-path TLS; it is
+	// nil when the health check is configured to run in plaintext.
+	NewSession(cfg map[string]interface{}, host Host, tlsMng TLSContextManager) HealthCheckSession
 }
@@ -29,14 +29,50 @@ type LoadBalancerType string
 
 // The load balancer's types
 const (
-	RoundRobin         LoadBalancerType = "LB_ROUNDROBIN"
-	Random             LoadBalancerType = "LB_RANDOM"
-	WeightedRoundRobin LoadBalancerType = "LB_WEIGHTED_ROUNDROBIN"
-	ORIGINAL_DST       LoadBalancerType = "LB_ORIGINAL_DST"
-	LeastActiveRequest LoadBalancerType = "LB_LEAST_REQUEST"
-	Maglev             LoadBalancerType = "LB_MAGLEV"
+	RoundRobin            LoadBalancerType = "LB_ROUNDROBIN"
+	Random                LoadBalancerType = "LB_RANDOM"
+	WeightedRoundRobin    LoadBalancerType = "LB_WEIGHTED_ROUNDROBIN"
+	ORIGINAL_DST          LoadBalancerType = "LB_ORIGINAL_DST"
+	LeastActiveRequest    LoadBalancerType = "LB_LEAST_REQUEST"
+	LeastActiveConnection LoadBalancerType = "LB_LEAST_CONNECTION"
+	Maglev                LoadBalancerType = "LB_MAGLEV"
+	RingHash              LoadBalancerType = "LB_RING_HASH"
+	EWMA                  LoadBalancerType = "LB_EWMA"
+	ZoneAware             LoadBalancerType = "LB_ZONE_AWARE"
+	Priority              LoadBalancerType = "LB_PRIORITY"
+	Aperture              LoadBalancerType = "LB_APERTURE"
+	Canary                LoadBalancerType = "LB_CANARY"
+	DynamicForwardProxy   LoadBalancerType = "LB_DYNAMIC_FORWARD_PROXY"
 )
 
+// ZoneMetadataKey is the well-known host metadata key used to group hosts
+// into localities for zone-aware load balancing.
+const ZoneMetadataKey = "zone"
+
+// PriorityMetadataKey is the well-known host metadata key used to assign a
+// host to a priority level. Hosts without this key default to priority 0.
+// Lower numbers are higher priority.
+const PriorityMetadataKey = "priority"
+
+// CanaryMetadataKey is the well-known host metadata key used to mark a host
+// as a canary for the canary load balancer, e.g. version=canary.
+const CanaryMetadataKey = "version"
+
+// CanaryMetadataValue is the well-known host metadata value, under
+// CanaryMetadataKey, that marks a host as a canary.
+const CanaryMetadataValue = "canary"
+
+// DefaultCanaryPercent is the default percentage of traffic routed to
+// canary-labelled hosts when no CanaryLbConfig is supplied.
+const DefaultCanaryPercent = 5
+
+// DefaultPriorityOverprovisioningFactor is the default factor applied when
+// computing how much of a priority level's healthy percentage is counted as
+// available before traffic spills over to the next priority. A factor of
+// 1.4 matches Envoy's default and means a priority level stays fully loaded
+// until its healthy percentage drops below ~71%.
+const DefaultPriorityOverprovisioningFactor = 1.4
+
 // LoadBalancer is a upstream load balancer.
 // When a request comes, the LoadBalancer will choose a upstream cluster's host to handle the request.
 type LoadBalancer interface {
@@ -71,6 +107,29 @@ type LoadBalancerContext interface {
 	DownstreamRoute() api.Route
 }
 
+// LBSelectionEvent carries the details of a single load balancer pick,
+// reported to every registered LBSelectionObserver regardless of which
+// load balancing algorithm produced it.
+type LBSelectionEvent struct {
+	// ClusterName is the cluster the pick was made for.
+	ClusterName string
+	// LBType is the load balancing algorithm that made the pick.
+	LBType LoadBalancerType
+	// Candidates is the number of hosts the load balancer chose among.
+	Candidates int
+	// Chosen is the host that was picked, or nil if none was available.
+	Chosen Host
+}
+
+// LBSelectionObserver receives a notification for every host a load
+// balancer picks. It is the extension point for sampling-based debug
+// tooling (e.g. an admin endpoint) that needs to inspect live selection
+// distribution without recompiling MOSN; see
+// pkg/upstream/cluster.RegisterLBSelectionObserver.
+type LBSelectionObserver interface {
+	OnHostChosen(event LBSelectionEvent)
+}
+
 // LBSubsetEntry is a entry that stored in the subset hierarchy.
 type LBSubsetEntry interface {
 	// Initialized returns the entry is initialized or not.
@@ -180,6 +180,21 @@ type ClientConnection interface {
 
 	// connect to server in a async way
 	Connect() error
+
+	// SetConnectionOptions sets the socket options applied when Connect
+	// dials the connection. Must be called before Connect.
+	SetConnectionOptions(opts v2.UpstreamConnectionOptions)
+
+	// SetRemoteAddrCandidates sets extra addresses to race against
+	// RemoteAddr when Connect dials, for a dual-stack host that resolves to
+	// more than one address family. Must be called before Connect; a nil or
+	// single-address list is a no-op and Connect just dials RemoteAddr.
+	SetRemoteAddrCandidates(addrs []net.Addr)
+
+	// TLSHandshakeDuration returns how long the TLS handshake performed by
+	// Connect took. It is zero for plaintext connections, or if Connect has
+	// not completed a handshake yet.
+	TLSHandshakeDuration() time.Duration
 }
 
 // Default connection arguments
@@ -19,6 +19,7 @@ package types
 
 import (
 	"context"
+	"encoding/json"
 	"net"
 	"sort"
 	"time"
@@ -33,6 +34,11 @@ import (
 //           1              * | 1                          1 | 1          *
 //   clusterManager --------- cluster  --------- --------- hostSet------hosts
 
+// MemberUpdateCb is a cluster's host set change callback, registered via
+// ClusterManager.AddClusterMemberUpdateCb. It is only invoked when a host
+// update actually adds or removes hosts.
+type MemberUpdateCb func(addHosts, delHosts []Host)
+
 // ClusterManager manages connection pools and load balancing for upstream clusters.
 type ClusterManager interface {
 	// Add or update a cluster via API.
@@ -47,30 +53,173 @@ type ClusterManager interface {
 	// Deprecated: PutClusterSnapshot exists for historical compatibility and should not be used.
 	PutClusterSnapshot(ClusterSnapshot)
 
-	// UpdateClusterHosts used to update cluster's hosts
-	// temp interface todo: remove it
+	// UpdateClusterHosts replaces a cluster's entire host list. It is
+	// implemented as a diff against the current host set (see UpdateHosts),
+	// so hosts present in both the old and new list keep their existing
+	// Host object and no callback fires if the resulting host set is
+	// unchanged.
 	UpdateClusterHosts(cluster string, hosts []v2.Host) error
 
+	// UpdateHosts applies a diff to a cluster's host set: add contains
+	// hosts to add or update, remove contains addresses to remove. Hosts
+	// named in neither list are left untouched, keeping their existing
+	// Host object so in-flight connection pools and per-host state stay
+	// valid across the update. AddClusterMemberUpdateCb callbacks only
+	// fire when the diff actually changes the host set.
+	UpdateHosts(cluster string, add []v2.Host, remove []string) error
+
 	// AppendClusterHosts used to add cluster's hosts
 	AppendClusterHosts(clusterName string, hostConfigs []v2.Host) error
 
+	// AddClusterMemberUpdateCb registers a callback invoked after
+	// UpdateHosts (or UpdateClusterHosts/AppendClusterHosts/
+	// RemoveClusterHosts, which all go through it) actually changes
+	// cluster's host set, with the hosts added and removed by that change.
+	AddClusterMemberUpdateCb(cluster string, cb MemberUpdateCb) error
+
 	// Get or Create tcp conn pool for a cluster
 	TCPConnForCluster(balancerContext LoadBalancerContext, snapshot ClusterSnapshot) CreateConnectionData
 
 	// ConnPoolForCluster used to get protocol related conn pool
 	ConnPoolForCluster(balancerContext LoadBalancerContext, snapshot ClusterSnapshot, protocol api.Protocol) ConnectionPool
 
+	// BoundConnPoolForCluster returns a freshly created, uncached conn pool
+	// that binds a single upstream connection to its caller for the pool's
+	// lifetime, for a ConnectionAffinity cluster. Unlike ConnPoolForCluster,
+	// the returned pool is never shared: the caller owns it and should keep
+	// it for as long as its own, single downstream connection lives.
+	BoundConnPoolForCluster(balancerContext LoadBalancerContext, snapshot ClusterSnapshot, protocol api.Protocol) ConnectionPool
+
 	// RemovePrimaryCluster used to remove cluster from set
 	RemovePrimaryCluster(clusters ...string) error
 
 	// ClusterExist, used to check whether 'clusterName' exist or not
 	ClusterExist(clusterName string) bool
 
-	// RemoveClusterHosts, remove the host by address string
+	// RemoveClusterHosts removes the hosts identified by address string.
+	// They stop receiving new assignments immediately (see types.Draining),
+	// but are only removed from the host set, and their connection pools
+	// only closed, once their in-flight requests finish or the cluster's
+	// DrainTimeout elapses, whichever comes first.
 	RemoveClusterHosts(clusterName string, hosts []string) error
 
+	// DrainConnections marks host's pooled connections, across every
+	// protocol, as draining: no new stream is assigned to them and each is
+	// closed once its in-flight requests finish (immediately if it is
+	// already idle), sending a protocol-level GOAWAY first where the
+	// protocol supports it. Unlike RemoveClusterHosts, the host itself is
+	// left in the cluster's host set and keeps receiving new connections,
+	// so it is safe to call ahead of a graceful process restart or before
+	// actually removing the host, to shed old connections without a gap
+	// in availability.
+	DrainConnections(clusterName string, host string) error
+
+	// SetHostHealth forces the active health check state of a host in
+	// cluster, identified by its address string, independent of the next
+	// active health check result. Used to let an operator drain a bad
+	// instance, or bring one back, without waiting for the health checker.
+	SetHostHealth(clusterName string, addr string, healthy bool) error
+
+	// GetClusterHostsHealth returns the current HealthFlag and health state
+	// of every host in cluster.
+	GetClusterHostsHealth(clusterName string) ([]HostHealth, error)
+
+	// GetClusterOutlierState returns the current passive outlier detection
+	// state of every host in cluster, for admin-facing inspection.
+	GetClusterOutlierState(clusterName string) ([]OutlierHostState, error)
+
+	// UpdateClusterCircuitBreakers updates cluster's circuit breaker
+	// thresholds in place, without rebuilding the cluster or its
+	// connection pools, so an operator can loosen or tighten limits
+	// during an incident without a config push round-trip.
+	UpdateClusterCircuitBreakers(clusterName string, circuitBreakers v2.CircuitBreakers) error
+
+	// GetClustersSummary returns a config, circuit-breaker usage and
+	// per-host summary of every cluster currently known to the cluster
+	// manager, for admin-facing triage.
+	GetClustersSummary() []ClusterSummary
+
 	// Destroy the cluster manager
 	Destroy()
+
+	// RegisterClusterManagerFilter registers filter to be notified, via
+	// its OnClusterAdded/OnClusterRemoved/OnHostsChanged methods, of
+	// topology changes across every cluster, regardless of which API
+	// triggered them (static config, xds, or the admin API).
+	RegisterClusterManagerFilter(filter ClusterManagerFilter)
+}
+
+// ClusterSummary reports one cluster's config, circuit-breaker usage, and
+// per-host state, for admin-facing inspection.
+type ClusterSummary struct {
+	Name        string            `json:"name"`
+	ClusterType v2.ClusterType    `json:"type"`
+	LbType      LoadBalancerType  `json:"lb_type"`
+	Circuit     CircuitBreakerUse `json:"circuit_breakers"`
+	Hosts       []HostSummary     `json:"hosts"`
+}
+
+// CircuitBreakerUse reports a cluster's current circuit breaker usage
+// against its configured thresholds, for admin-facing inspection. Max is
+// 0 when a resource has no configured limit.
+type CircuitBreakerUse struct {
+	Connections     ResourceUse `json:"connections"`
+	PendingRequests ResourceUse `json:"pending_requests"`
+	Requests        ResourceUse `json:"requests"`
+	Retries         ResourceUse `json:"retries"`
+}
+
+// ResourceUse reports one circuit breaker resource's current usage
+// against its configured max.
+type ResourceUse struct {
+	Current int64  `json:"current"`
+	Max     uint64 `json:"max,omitempty"`
+}
+
+// HostSummary reports one host's address, weight, metadata, health flags
+// and key stats, for admin-facing inspection.
+type HostSummary struct {
+	Address          string         `json:"address"`
+	Weight           uint32         `json:"weight"`
+	Metadata         HostMetadata   `json:"metadata"`
+	Healthy          bool           `json:"healthy"`
+	HealthFlags      api.HealthFlag `json:"health_flags"`
+	RequestActive    int64          `json:"request_active"`
+	RequestTotal     int64          `json:"request_total"`
+	ConnectionActive int64          `json:"connection_active"`
+	ConnectionTotal  int64          `json:"connection_total"`
+}
+
+// HostMetadata is an api.Metadata that marshals itself through the standard
+// library rather than jsoniter's reflect-based map encoder, which the admin
+// API's jsoniter instance (pkg/admin/server) cannot use on a non-nil map
+// under this Go runtime.
+type HostMetadata api.Metadata
+
+func (m HostMetadata) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string(m))
+}
+
+// HostHealth reports a host's current health state, as observed via its
+// HealthFlag bits, for admin-facing inspection.
+type HostHealth struct {
+	Address string         `json:"address"`
+	Healthy bool           `json:"healthy"`
+	Flags   api.HealthFlag `json:"health_flags"`
+}
+
+// OutlierHostState reports a host's current passive outlier detection
+// state, for admin-facing inspection. SuccessRate and HasSuccessRate
+// reflect the last success-rate sweep the host was eligible for (it had
+// reached SuccessRateRequestVolume requests in that interval); a host that
+// has never reached the volume threshold has HasSuccessRate false.
+type OutlierHostState struct {
+	Address          string    `json:"address"`
+	Ejected          bool      `json:"ejected"`
+	EjectionCount    uint32    `json:"ejection_count"`
+	LastEjectionTime time.Time `json:"last_ejection_time,omitempty"`
+	SuccessRate      float64   `json:"success_rate,omitempty"`
+	HasSuccessRate   bool      `json:"has_success_rate"`
 }
 
 // ClusterSnapshot is a thread-safe cluster snapshot
@@ -99,6 +248,13 @@ type Cluster interface {
 	// UpdateHosts updates the host set's hosts
 	UpdateHosts([]Host)
 
+	// UpdateHostMetadata mutates the metadata of the host at addr in
+	// place and reindexes the load balancer's metadata subsets, instead
+	// of removing and re-adding the host as UpdateHosts would. This keeps
+	// the host's stats and health check state intact across the update.
+	// Returns false if no host in the cluster's current host set has addr.
+	UpdateHostMetadata(addr string, meta api.Metadata) bool
+
 	// Add health check callbacks in health checker
 	AddHealthCheckCallbacks(cb HealthCheckCb)
 
@@ -109,10 +265,35 @@ type Cluster interface {
 // HostPredicate checks wether the host is matched the metadata
 type HostPredicate func(Host) bool
 
+// DegradedActiveHC marks a host that is passing active health checks but
+// has reported a degraded status (for example via a health-check response
+// header). Unlike api.FAILED_ACTIVE_HC, a host flagged this way is still
+// considered healthy by Host.Health(); load balancers that are aware of it
+// should only route to it when there isn't enough non-degraded capacity.
+const DegradedActiveHC api.HealthFlag = 0x04
+
+// Draining marks a host that has been removed from its cluster's
+// configuration but is still finishing up work: it is excluded from
+// Host.Health(), so load balancers stop assigning it new requests, while
+// its existing connection pools and in-flight requests are left alone
+// until ClusterManager.RemoveClusterHosts' drain deadline elapses and the
+// host is removed for real.
+const Draining api.HealthFlag = 0x08
+
 // HostSet is as set of hosts that contains all of the endpoints for a given
 type HostSet interface {
 	// Hosts returns all hosts that make up the set at the current time.
 	Hosts() []Host
+
+	// DegradedHosts returns the hosts in the set that are flagged
+	// DegradedActiveHC.
+	DegradedHosts() []Host
+
+	// UpdateHostMetadata mutates the metadata of the host at addr in
+	// place, without removing or re-adding it, so its stats and health
+	// check state are unaffected. Returns false if no host in the set has
+	// that address.
+	UpdateHostMetadata(addr string, meta api.Metadata) bool
 }
 
 // Host is an upstream host
@@ -122,6 +303,10 @@ type Host interface {
 	// HostStats returns the host stats metrics
 	HostStats() HostStats
 
+	// Locality returns the zone/region the host runs in, as configured on
+	// its v2.HostConfig. Empty if the host has no locality configured.
+	Locality() string
+
 	// ClusterInfo returns the cluster info
 	ClusterInfo() ClusterInfo
 
@@ -132,6 +317,11 @@ type Host interface {
 	Address() net.Addr
 	// Config creates a host config by the host attributes
 	Config() v2.Host
+
+	// MaxConnections returns the host's own connection cap, as configured
+	// on its v2.HostConfig. Zero means the host has no cap of its own and
+	// only the cluster's ResourceManager().Connections() limit applies.
+	MaxConnections() uint32
 }
 
 // ClusterInfo defines a cluster's information
@@ -151,15 +341,40 @@ type ClusterInfo interface {
 	// MaxRequestsPerConn returns a connection's max request
 	MaxRequestsPerConn() uint32
 
+	// MaxConnectionDuration returns how long a connection pool may keep a
+	// single upstream connection alive before it is closed and replaced,
+	// independent of MaxRequestsPerConn. Zero means no duration limit.
+	MaxConnectionDuration() time.Duration
+
+	// IdleTimeout returns how long a pooled upstream connection may sit
+	// with no active stream before it is closed. Zero means no idle
+	// timeout.
+	IdleTimeout() time.Duration
+
+	// Http1ProtocolOptions returns the cluster's HTTP/1.1-specific upstream
+	// connection reuse configuration.
+	Http1ProtocolOptions() v2.Http1ProtocolOptions
+
 	// Stats returns the cluster's stats metrics
 	Stats() ClusterStats
 
+	// LocalityStats returns the request/error/latency aggregates for hosts
+	// in the given locality, creating them on first use. locality is
+	// whatever a host's Locality() returns; "" is a valid locality for
+	// hosts that don't set one.
+	LocalityStats(locality string) LocalityStats
+
 	// ResourceManager returns the ResourceManager
 	ResourceManager() ResourceManager
 
 	// TLSMng returns the tls manager
 	TLSMng() TLSContextManager
 
+	// TLSMngByMetadata returns the tls manager a host with the given
+	// metadata should use: the first configured transport socket match
+	// whose criteria is a subset of meta, or TLSMng() if none match.
+	TLSMngByMetadata(meta api.Metadata) TLSContextManager
+
 	// LbSubsetInfo returns the load balancer subset's config
 	LbSubsetInfo() LBSubsetInfo
 
@@ -171,6 +386,43 @@ type ClusterInfo interface {
 
 	// Optional configuration for the load balancing algorithm selected by
 	LbConfig() v2.IsCluster_LbConfig
+
+	// HealthyPanicThreshold returns the percentage (0-100) of healthy hosts
+	// below which the load balancer enters panic mode and routes to all
+	// hosts, healthy or not. Defaults to 50 when not configured.
+	HealthyPanicThreshold() float64
+
+	// OutlierDetection returns the cluster's passive outlier detection
+	// configuration.
+	OutlierDetection() v2.OutlierDetection
+
+	// DrainTimeout returns how long RemovePrimaryCluster waits for this
+	// cluster's active requests to finish before closing its connection
+	// pools. Defaults to 10s when not configured.
+	DrainTimeout() time.Duration
+
+	// UpstreamProtocol returns the protocol ConnPoolForCluster uses to
+	// talk to this cluster's hosts. Empty means the caller's requested
+	// protocol (usually the downstream protocol) should be used instead.
+	UpstreamProtocol() ProtocolName
+
+	// AggregateClusterNames returns the ordered list of underlying cluster
+	// names an AGGREGATE cluster falls back across. Empty for every other
+	// ClusterType.
+	AggregateClusterNames() []string
+
+	// AltStatName returns the stable alias this cluster's stats are
+	// recorded under, in place of Name. Empty when Name is used.
+	AltStatName() string
+
+	// ConnectionOptions returns the TCP socket options CreateConnection
+	// applies to every connection this cluster opens to its hosts.
+	ConnectionOptions() v2.UpstreamConnectionOptions
+
+	// ConnectionAffinity reports whether ConnPoolForCluster should bind one
+	// upstream connection to each downstream connection for its lifetime
+	// instead of handing out connections from the cluster's shared pool.
+	ConnectionAffinity() bool
 }
 
 // ResourceManager manages different types of Resource
@@ -186,6 +438,12 @@ type ResourceManager interface {
 
 	// Retries resource to count retries
 	Retries() Resource
+
+	// ForPriority returns the ResourceManager whose Resources are backed by
+	// the circuit breaker threshold set configured for priority, falling
+	// back to the DEFAULT priority's thresholds if priority has none of
+	// its own configured.
+	ForPriority(priority v2.RoutingPriority) ResourceManager
 }
 
 // Resource is a interface to statistics information
@@ -196,6 +454,10 @@ type Resource interface {
 	Max() uint64
 	Cur() int64
 	UpdateCur(int64)
+
+	// Remaining returns the resource's remaining capacity (Max minus Cur),
+	// or -1 if Max is 0 (unlimited).
+	Remaining() int64
 }
 
 // HostStats defines a host's statistics information
@@ -209,17 +471,52 @@ type HostStats struct {
 	UpstreamConnectionLocalCloseWithActiveRequest  metrics.Counter
 	UpstreamConnectionRemoteCloseWithActiveRequest metrics.Counter
 	UpstreamConnectionCloseNotify                  metrics.Counter
-	UpstreamRequestTotal                           metrics.Counter
-	UpstreamRequestActive                          metrics.Counter
-	UpstreamRequestLocalReset                      metrics.Counter
-	UpstreamRequestRemoteReset                     metrics.Counter
-	UpstreamRequestTimeout                         metrics.Counter
-	UpstreamRequestFailureEject                    metrics.Counter
-	UpstreamRequestPendingOverflow                 metrics.Counter
-	UpstreamRequestDuration                        metrics.Histogram
-	UpstreamRequestDurationTotal                   metrics.Counter
-	UpstreamResponseSuccess                        metrics.Counter
-	UpstreamResponseFailed                         metrics.Counter
+	// UpstreamConnectionPendingOverflow counts dial attempts refused because
+	// the host's own MaxConnections cap (not the cluster-wide
+	// ResourceManager().Connections() limit) was already reached.
+	UpstreamConnectionPendingOverflow metrics.Counter
+	// UpstreamConnectionIdle is the number of connections a pool currently
+	// holds open to this host but has no active stream on, i.e. ready to be
+	// handed out immediately without dialing.
+	UpstreamConnectionIdle metrics.Gauge
+	// UpstreamConnectionConnectDuration is the wall-clock time, in
+	// nanoseconds, a pool spent dialing and connecting to this host, sampled
+	// once per successful connection.
+	UpstreamConnectionConnectDuration metrics.Histogram
+	// UpstreamConnectionTLSHandshakeDuration is the wall-clock time, in
+	// nanoseconds, spent performing the TLS handshake for a connection to
+	// this host, sampled once per successful handshake. Not recorded for
+	// plaintext connections.
+	UpstreamConnectionTLSHandshakeDuration metrics.Histogram
+	// UpstreamRequestQueueDepth is the number of streams currently queued by
+	// a pool waiting for a connection or stream slot to free up, for pools
+	// that queue (e.g. http2's connPool.pending) rather than failing fast
+	// with UpstreamRequestPendingOverflow. Always 0 for pools that don't.
+	UpstreamRequestQueueDepth      metrics.Gauge
+	UpstreamRequestTotal           metrics.Counter
+	UpstreamRequestActive          metrics.Counter
+	UpstreamRequestLocalReset      metrics.Counter
+	UpstreamRequestRemoteReset     metrics.Counter
+	UpstreamRequestTimeout         metrics.Counter
+	UpstreamRequestFailureEject    metrics.Counter
+	UpstreamRequestPendingOverflow metrics.Counter
+	UpstreamRequestDuration        metrics.Histogram
+	UpstreamRequestDurationTotal   metrics.Counter
+	UpstreamResponseSuccess        metrics.Counter
+	UpstreamResponseFailed         metrics.Counter
+	// UpstreamRequestGRPCTotal counts requests recognized as gRPC (by their
+	// content-type) sent to this host. gRPC reports its real outcome via the
+	// grpc-status trailer rather than the HTTP status code, which stays 200
+	// on both success and failure, so it needs its own success/failure
+	// counters alongside UpstreamResponseSuccess/UpstreamResponseFailed.
+	UpstreamRequestGRPCTotal metrics.Counter
+	// UpstreamResponseGRPCSuccess counts gRPC responses whose grpc-status
+	// was OK (0), including ones synthesized locally for a reset upstream
+	// request, see protocol.GRPCStatusFromResetReason.
+	UpstreamResponseGRPCSuccess metrics.Counter
+	// UpstreamResponseGRPCFailed counts gRPC responses whose grpc-status was
+	// not OK, or whose grpc-status could not be determined at all.
+	UpstreamResponseGRPCFailed metrics.Counter
 }
 
 // ClusterStats defines a cluster's statistics information
@@ -234,23 +531,61 @@ type ClusterStats struct {
 	UpstreamConnectionLocalCloseWithActiveRequest  metrics.Counter
 	UpstreamConnectionRemoteCloseWithActiveRequest metrics.Counter
 	UpstreamConnectionCloseNotify                  metrics.Counter
-	UpstreamBytesReadTotal                         metrics.Counter
-	UpstreamBytesWriteTotal                        metrics.Counter
-	UpstreamRequestTotal                           metrics.Counter
-	UpstreamRequestActive                          metrics.Counter
-	UpstreamRequestLocalReset                      metrics.Counter
-	UpstreamRequestRemoteReset                     metrics.Counter
-	UpstreamRequestRetry                           metrics.Counter
-	UpstreamRequestRetryOverflow                   metrics.Counter
-	UpstreamRequestTimeout                         metrics.Counter
-	UpstreamRequestFailureEject                    metrics.Counter
-	UpstreamRequestPendingOverflow                 metrics.Counter
-	UpstreamRequestDuration                        metrics.Histogram
-	UpstreamRequestDurationTotal                   metrics.Counter
-	UpstreamResponseSuccess                        metrics.Counter
-	UpstreamResponseFailed                         metrics.Counter
-	LBSubSetsFallBack                              metrics.Counter
-	LBSubsetsCreated                               metrics.Gauge
+	UpstreamConnectionCloseIdle                    metrics.Counter
+	// UpstreamConnectionIdle is the cluster-wide total of UpstreamConnectionIdle
+	// across all of the cluster's hosts.
+	UpstreamConnectionIdle metrics.Gauge
+	// UpstreamConnectionConnectDuration is the cluster-wide equivalent of
+	// HostStats.UpstreamConnectionConnectDuration.
+	UpstreamConnectionConnectDuration metrics.Histogram
+	// UpstreamConnectionTLSHandshakeDuration is the cluster-wide equivalent
+	// of HostStats.UpstreamConnectionTLSHandshakeDuration.
+	UpstreamConnectionTLSHandshakeDuration metrics.Histogram
+	// UpstreamRequestQueueDepth is the cluster-wide equivalent of
+	// HostStats.UpstreamRequestQueueDepth.
+	UpstreamRequestQueueDepth      metrics.Gauge
+	UpstreamBytesReadTotal         metrics.Counter
+	UpstreamBytesWriteTotal        metrics.Counter
+	UpstreamRequestTotal           metrics.Counter
+	UpstreamRequestActive          metrics.Counter
+	UpstreamRequestLocalReset      metrics.Counter
+	UpstreamRequestRemoteReset     metrics.Counter
+	UpstreamRequestRetry           metrics.Counter
+	UpstreamRequestRetryOverflow   metrics.Counter
+	UpstreamRequestTimeout         metrics.Counter
+	UpstreamRequestFailureEject    metrics.Counter
+	UpstreamRequestPendingOverflow metrics.Counter
+	UpstreamRequestDuration        metrics.Histogram
+	UpstreamRequestDurationTotal   metrics.Counter
+	UpstreamResponseSuccess        metrics.Counter
+	UpstreamResponseFailed         metrics.Counter
+	// UpstreamRequestGRPCTotal is the cluster-wide equivalent of
+	// HostStats.UpstreamRequestGRPCTotal.
+	UpstreamRequestGRPCTotal metrics.Counter
+	// UpstreamResponseGRPCSuccess is the cluster-wide equivalent of
+	// HostStats.UpstreamResponseGRPCSuccess.
+	UpstreamResponseGRPCSuccess metrics.Counter
+	// UpstreamResponseGRPCFailed is the cluster-wide equivalent of
+	// HostStats.UpstreamResponseGRPCFailed.
+	UpstreamResponseGRPCFailed metrics.Counter
+	LBSubSetsFallBack          metrics.Counter
+	LBSubsetsCreated           metrics.Gauge
+	LBHealthyPanic             metrics.Counter
+	LBCanaryRequest            metrics.Counter
+	LBBoundedLoadSpillover     metrics.Counter
+	OutlierEjectTotal          metrics.Counter
+	OutlierUnejectTotal        metrics.Counter
+}
+
+// LocalityStats defines the request/error/latency aggregates a cluster
+// keeps for a single locality, so a locality-aware load balancer (or any
+// other consumer) can compare load across zones without walking every host.
+type LocalityStats struct {
+	UpstreamRequestTotal         metrics.Counter
+	UpstreamRequestDuration      metrics.Histogram
+	UpstreamRequestDurationTotal metrics.Counter
+	UpstreamResponseSuccess      metrics.Counter
+	UpstreamResponseFailed       metrics.Counter
 }
 
 type CreateConnectionData struct {
@@ -274,6 +609,20 @@ type ClusterHostFactoryCb interface {
 
 type ClusterManagerFilter interface {
 	OnCreated(cccb ClusterConfigFactoryCb, chcb ClusterHostFactoryCb)
+
+	// OnClusterAdded is called after a new cluster is added to the cluster
+	// manager. Not called for an update to an already-existing cluster.
+	OnClusterAdded(clusterName string)
+
+	// OnClusterRemoved is called after a cluster is removed from the
+	// cluster manager.
+	OnClusterRemoved(clusterName string)
+
+	// OnHostsChanged is called after a cluster's host set actually
+	// changes, with the hosts added and removed by that change. It is
+	// wired up the same way AddClusterMemberUpdateCb callbacks are: it
+	// does not fire for an update that leaves the host set unchanged.
+	OnHostsChanged(clusterName string, addHosts, delHosts []Host)
 }
 
 // RegisterUpstreamUpdateMethodCb is a callback interface
@@ -373,13 +722,23 @@ func (ss *SortedStringSetType) Swap(i, j int) {
 	ss.keys[i], ss.keys[j] = ss.keys[j], ss.keys[i]
 }
 
+// ConnPoolFactory constructs a ConnectionPool for host. Protocols register
+// one via RegisterConnPoolFactory so ConnPoolForCluster can build a new pool
+// on demand instead of every protocol package reaching into a shared
+// registry of its own.
+type ConnPoolFactory func(ctx context.Context, host Host) ConnectionPool
+
 func init() {
-	ConnPoolFactories = make(map[api.Protocol]bool)
+	ConnPoolFactories = make(map[api.Protocol]ConnPoolFactory)
 }
 
-var ConnPoolFactories map[api.Protocol]bool
+// ConnPoolFactories holds, per protocol, the factory used to build a new
+// connection pool for a host.
+var ConnPoolFactories map[api.Protocol]ConnPoolFactory
 
-func RegisterConnPoolFactory(protocol api.Protocol, registered bool) {
+// RegisterConnPoolFactory registers factory as the connection pool
+// constructor for protocol.
+func RegisterConnPoolFactory(protocol api.Protocol, factory ConnPoolFactory) {
 	//other
-	ConnPoolFactories[protocol] = registered
+	ConnPoolFactories[protocol] = factory
 }
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/dchest/siphash"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	// defaultMinAperture is the narrowest slice of the host ring a mosn
+	// instance will restrict itself to, so a small cluster still gets some
+	// spread even under no load.
+	defaultMinAperture = 3
+	// apertureWidenLoadFactor is the average number of active requests per
+	// host, within the current aperture, above which the aperture widens to
+	// bring more hosts into rotation.
+	apertureWidenLoadFactor = 2.0
+)
+
+// apertureLoadBalancer implements deterministic aperture load balancing
+// (as described by Finagle's d-aperture): every mosn instance sorts the
+// cluster's hosts into the same deterministic ring, then restricts itself
+// to a contiguous slice ("aperture") of that ring, so that for a large
+// cluster each instance only ever opens connections to a bounded subset of
+// hosts instead of the whole fleet. Which slice an instance owns is derived
+// from a per-process seed, so instances spread themselves across the ring
+// without needing to coordinate. The aperture automatically widens when the
+// hosts currently in it are getting overloaded, and narrows back down once
+// load subsides, bounded by [minAperture, maxAperture].
+type apertureLoadBalancer struct {
+	ring        []types.Host
+	seed        uint64
+	minAperture uint32
+	maxAperture uint32
+	rrIndex     uint32
+}
+
+func newApertureLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	lb := &apertureLoadBalancer{
+		minAperture: defaultMinAperture,
+		seed:        localInstanceSeed(),
+	}
+	if info != nil && info.LbConfig() != nil {
+		if cfg, ok := info.LbConfig().(*v2.ApertureLbConfig); ok {
+			if cfg.MinAperture > 0 {
+				lb.minAperture = cfg.MinAperture
+			}
+			lb.maxAperture = cfg.MaxAperture
+		}
+	}
+
+	lb.ring = append(lb.ring, hosts.Hosts()...)
+	sort.Slice(lb.ring, func(i, j int) bool {
+		return lb.ring[i].AddressString() < lb.ring[j].AddressString()
+	})
+	return lb
+}
+
+// localInstanceSeed derives a seed identifying this mosn process, used to
+// pick a deterministic-but-distinct starting offset on the ring so that
+// different instances of the same cluster spread their apertures instead of
+// all of them covering the exact same hosts.
+func localInstanceSeed() uint64 {
+	hostname, _ := os.Hostname()
+	key := hostname + "_" + strconv.Itoa(os.Getpid())
+	return siphash.Hash(0, 0, []byte(key))
+}
+
+func (lb *apertureLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	total := len(lb.ring)
+	if total == 0 {
+		return nil
+	}
+
+	maxAperture := lb.maxAperture
+	if maxAperture == 0 || maxAperture > uint32(total) {
+		maxAperture = uint32(total)
+	}
+	minAperture := lb.minAperture
+	if minAperture > maxAperture {
+		minAperture = maxAperture
+	}
+
+	offset := int(lb.seed % uint64(total))
+	aperture := lb.currentAperture(offset, total, minAperture, maxAperture)
+
+	for i := uint32(0); i < aperture; i++ {
+		idx := atomic.AddUint32(&lb.rrIndex, 1) % aperture
+		host := lb.ring[(offset+int(idx))%total]
+		if host.Health() {
+			return host
+		}
+	}
+	return nil
+}
+
+// currentAperture measures the average load across the minimum aperture
+// window and widens it, up to maxAperture, when that average exceeds
+// apertureWidenLoadFactor active requests per host.
+func (lb *apertureLoadBalancer) currentAperture(offset, total int, minAperture, maxAperture uint32) uint32 {
+	if minAperture >= maxAperture {
+		return maxAperture
+	}
+
+	var totalActive int64
+	for i := uint32(0); i < minAperture; i++ {
+		host := lb.ring[(offset+int(i))%total]
+		totalActive += host.HostStats().UpstreamRequestActive.Count()
+	}
+	avgActive := float64(totalActive) / float64(minAperture)
+	if avgActive <= apertureWidenLoadFactor {
+		return minAperture
+	}
+
+	widened := minAperture + uint32(avgActive/apertureWidenLoadFactor)
+	if widened > maxAperture {
+		widened = maxAperture
+	}
+	return widened
+}
+
+func (lb *apertureLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.ring) > 0
+}
+
+func (lb *apertureLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.ring)
+}
@@ -40,11 +40,11 @@ func BenchmarkHostConfig(b *testing.B) {
 		hostname:      "Testhost",
 		addressString: "127.0.0.1:8080",
 		weight:        100,
-		metaData: api.Metadata{
-			"zone":    "a",
-			"version": "1",
-		},
 	}
+	host.metaData.Store(api.Metadata{
+		"zone":    "a",
+		"version": "1",
+	})
 	b.Run("Host.Config", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			host.Config()
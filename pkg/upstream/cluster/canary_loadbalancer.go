@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// isCanaryHost reports whether host is labelled as a canary via
+// types.CanaryMetadataKey/types.CanaryMetadataValue.
+func isCanaryHost(host types.Host) bool {
+	return host.Metadata()[types.CanaryMetadataKey] == types.CanaryMetadataValue
+}
+
+// canaryLoadBalancer steers a configurable percentage of traffic to hosts
+// labelled as canaries, leaving the rest on the stable hosts, without
+// requiring a separate cluster or subset for the canary pool.
+type canaryLoadBalancer struct {
+	mutex         sync.Mutex
+	rand          *rand.Rand
+	hosts         types.HostSet
+	info          types.ClusterInfo
+	canaryPercent uint32
+}
+
+func newCanaryLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	lb := &canaryLoadBalancer{
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		hosts:         hosts,
+		info:          info,
+		canaryPercent: types.DefaultCanaryPercent,
+	}
+	if info != nil && info.LbConfig() != nil {
+		if cfg, ok := info.LbConfig().(*v2.CanaryLbConfig); ok {
+			lb.canaryPercent = cfg.CanaryPercent
+		}
+	}
+	return lb
+}
+
+func (lb *canaryLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	allHosts := lb.hosts.Hosts()
+	if len(allHosts) == 0 {
+		return nil
+	}
+
+	var canary, stable []types.Host
+	for _, h := range allHosts {
+		if isCanaryHost(h) {
+			canary = append(canary, h)
+		} else {
+			stable = append(stable, h)
+		}
+	}
+
+	if len(canary) == 0 {
+		return lb.pick(stable)
+	}
+	if len(stable) == 0 {
+		lb.recordCanary()
+		return lb.pick(canary)
+	}
+
+	lb.mutex.Lock()
+	roll := lb.rand.Float64() * 100
+	lb.mutex.Unlock()
+	if roll < float64(lb.canaryPercent) {
+		lb.recordCanary()
+		return lb.pick(canary)
+	}
+	return lb.pick(stable)
+}
+
+// recordCanary increments the cluster's canary-selection stat.
+func (lb *canaryLoadBalancer) recordCanary() {
+	if lb.info != nil {
+		lb.info.Stats().LBCanaryRequest.Inc(1)
+	}
+}
+
+func (lb *canaryLoadBalancer) pick(hosts []types.Host) types.Host {
+	if len(hosts) == 0 {
+		return nil
+	}
+	lb.mutex.Lock()
+	idx := lb.rand.Intn(len(hosts))
+	lb.mutex.Unlock()
+	return hosts[idx]
+}
+
+func (lb *canaryLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *canaryLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
@@ -18,6 +18,7 @@
 package cluster
 
 import (
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/upstream/healthcheck"
 )
@@ -54,12 +56,13 @@ func NewCluster(clusterConfig v2.Cluster) types.Cluster {
 
 // simpleCluster is an implementation of types.Cluster
 type simpleCluster struct {
-	info          *clusterInfo
-	mutex         sync.Mutex
-	healthChecker types.HealthChecker
-	lbInstance    types.LoadBalancer // load balancer used for this cluster
-	hostSet       *hostSet
-	snapshot      atomic.Value
+	info            *clusterInfo
+	mutex           sync.Mutex
+	healthChecker   types.HealthChecker
+	outlierDetector *outlierDetector
+	lbInstance      types.LoadBalancer // load balancer used for this cluster
+	hostSet         *hostSet
+	snapshot        atomic.Value
 }
 
 func newSimpleCluster(clusterConfig v2.Cluster) types.Cluster {
@@ -67,16 +70,34 @@ func newSimpleCluster(clusterConfig v2.Cluster) types.Cluster {
 	if clusterConfig.ClusterType == v2.ORIGINALDST_CLUSTER {
 		clusterConfig.LbType = v2.LB_ORIGINAL_DST
 	}
+	if clusterConfig.ClusterType == v2.DYNAMIC_FORWARD_PROXY_CLUSTER {
+		clusterConfig.LbType = v2.LB_DYNAMIC_FORWARD_PROXY
+	}
+	statName := clusterConfig.Name
+	if clusterConfig.AltStatName != "" {
+		statName = clusterConfig.AltStatName
+	}
 	info := &clusterInfo{
-		name:                 clusterConfig.Name,
-		clusterType:          clusterConfig.ClusterType,
-		maxRequestsPerConn:   clusterConfig.MaxRequestPerConn,
-		connBufferLimitBytes: clusterConfig.ConnBufferLimitBytes,
-		stats:                newClusterStats(clusterConfig.Name),
-		lbSubsetInfo:         NewLBSubsetInfo(&clusterConfig.LBSubSetConfig), // new subset load balancer info
-		lbOriDstInfo:         NewLBOriDstInfo(&clusterConfig.LBOriDstConfig), // new oridst load balancer info
-		lbType:               types.LoadBalancerType(clusterConfig.LbType),
-		resourceManager:      NewResourceManager(clusterConfig.CirBreThresholds),
+		name:                  clusterConfig.Name,
+		clusterType:           clusterConfig.ClusterType,
+		maxRequestsPerConn:    clusterConfig.MaxRequestPerConn,
+		connBufferLimitBytes:  clusterConfig.ConnBufferLimitBytes,
+		stats:                 newClusterStats(statName),
+		lbSubsetInfo:          NewLBSubsetInfo(&clusterConfig.LBSubSetConfig), // new subset load balancer info
+		lbOriDstInfo:          NewLBOriDstInfo(&clusterConfig.LBOriDstConfig), // new oridst load balancer info
+		lbType:                types.LoadBalancerType(clusterConfig.LbType),
+		resourceManager:       NewResourceManager(clusterConfig.Name, clusterConfig.CirBreThresholds),
+		healthyPanicThreshold: clusterConfig.HealthyPanicThreshold,
+		outlierDetection:      clusterConfig.OutlierDetection,
+		drainTimeout:          clusterConfig.DrainTimeout.Duration,
+		upstreamProtocol:      resolveUpstreamProtocol(clusterConfig),
+		aggregateClusterNames: clusterConfig.Clusters,
+		maxConnectionDuration: clusterConfig.MaxConnectionDuration.Duration,
+		idleTimeout:           clusterConfig.IdleTimeout.Duration,
+		altStatName:           clusterConfig.AltStatName,
+		connectionOptions:     clusterConfig.UpstreamConnectionOptions,
+		connectionAffinity:    clusterConfig.ConnectionAffinity,
+		http1ProtocolOptions:  clusterConfig.Http1ProtocolOptions,
 	}
 
 	// set ConnectTimeout
@@ -92,6 +113,17 @@ func newSimpleCluster(clusterConfig v2.Cluster) types.Cluster {
 		log.DefaultLogger.Alertf("cluster.config", "[upstream] [cluster] [new cluster] create tls context manager failed, %v", err)
 	}
 	info.tlsMng = mgr
+	for _, tsm := range clusterConfig.TransportSocketMatches {
+		tsmMgr, err := mtls.NewTLSClientContextManager(&tsm.TLSContext)
+		if err != nil {
+			log.DefaultLogger.Alertf("cluster.config", "[upstream] [cluster] [new cluster] create transport socket match %s tls context manager failed, %v", tsm.Name, err)
+			continue
+		}
+		info.transportSocketMatches = append(info.transportSocketMatches, transportSocketMatch{
+			match:  tsm.Match,
+			tlsMng: tsmMgr,
+		})
+	}
 	cluster := &simpleCluster{
 		info: info,
 	}
@@ -106,6 +138,11 @@ func newSimpleCluster(clusterConfig v2.Cluster) types.Cluster {
 		log.DefaultLogger.Infof("[upstream] [cluster] [new cluster] cluster %s have health check", clusterConfig.Name)
 		cluster.healthChecker = healthcheck.CreateHealthCheck(clusterConfig.HealthCheck)
 	}
+	if clusterConfig.OutlierDetection != (v2.OutlierDetection{}) {
+		log.DefaultLogger.Infof("[upstream] [cluster] [new cluster] cluster %s have outlier detection", clusterConfig.Name)
+		cluster.outlierDetector = newOutlierDetector(info)
+		cluster.outlierDetector.Start()
+	}
 	return cluster
 }
 
@@ -132,9 +169,40 @@ func (sc *simpleCluster) UpdateHosts(newHosts []types.Host) {
 	if sc.healthChecker != nil {
 		sc.healthChecker.SetHealthCheckerHostSet(hostSet)
 	}
+	if sc.outlierDetector != nil {
+		sc.outlierDetector.SetHosts(hostSet)
+	}
 
 }
 
+// UpdateHostMetadata mutates the metadata of the host at addr in place and
+// reindexes the load balancer against the same hostSet, instead of going
+// through UpdateHosts. Host identity is preserved, so healthChecker and
+// outlierDetector are left untouched: neither has any host to diff against,
+// since the hostSet they were given is still the same object.
+func (sc *simpleCluster) UpdateHostMetadata(addr string, meta api.Metadata) bool {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	if sc.hostSet == nil || !sc.hostSet.UpdateHostMetadata(addr, meta) {
+		return false
+	}
+	info := sc.info
+	hostSet := sc.hostSet
+	var lb types.LoadBalancer
+	if info.lbSubsetInfo.IsEnabled() {
+		lb = NewSubsetLoadBalancer(info, hostSet)
+	} else {
+		lb = NewLoadBalancer(info, hostSet)
+	}
+	sc.lbInstance = lb
+	sc.snapshot.Store(&clusterSnapshot{
+		lb:      lb,
+		hostSet: hostSet,
+		info:    info,
+	})
+	return true
+}
+
 func (sc *simpleCluster) Snapshot() types.ClusterSnapshot {
 	si := sc.snapshot.Load()
 	if snap, ok := si.(*clusterSnapshot); ok {
@@ -157,21 +225,57 @@ func (sc *simpleCluster) StopHealthChecking() {
 	if sc.healthChecker != nil {
 		sc.healthChecker.Stop()
 	}
+	if sc.outlierDetector != nil {
+		sc.outlierDetector.Stop()
+	}
 }
 
 type clusterInfo struct {
-	name                 string
-	clusterType          v2.ClusterType
-	lbType               types.LoadBalancerType // if use subset lb , lbType is used as inner LB algorithm for choosing subset's host
-	connBufferLimitBytes uint32
-	maxRequestsPerConn   uint32
-	resourceManager      types.ResourceManager
-	stats                types.ClusterStats
-	lbSubsetInfo         types.LBSubsetInfo
-	lbOriDstInfo         types.LBOriDstInfo
-	tlsMng               types.TLSContextManager
-	connectTimeout       time.Duration
-	lbConfig             v2.IsCluster_LbConfig
+	name                   string
+	clusterType            v2.ClusterType
+	lbType                 types.LoadBalancerType // if use subset lb , lbType is used as inner LB algorithm for choosing subset's host
+	connBufferLimitBytes   uint32
+	maxRequestsPerConn     uint32
+	resourceManager        types.ResourceManager
+	stats                  types.ClusterStats
+	lbSubsetInfo           types.LBSubsetInfo
+	lbOriDstInfo           types.LBOriDstInfo
+	tlsMng                 types.TLSContextManager
+	connectTimeout         time.Duration
+	lbConfig               v2.IsCluster_LbConfig
+	healthyPanicThreshold  float64
+	outlierDetection       v2.OutlierDetection
+	drainTimeout           time.Duration
+	upstreamProtocol       types.ProtocolName
+	aggregateClusterNames  []string
+	maxConnectionDuration  time.Duration
+	idleTimeout            time.Duration
+	altStatName            string
+	connectionOptions      v2.UpstreamConnectionOptions
+	connectionAffinity     bool
+	http1ProtocolOptions   v2.Http1ProtocolOptions
+	transportSocketMatches []transportSocketMatch
+	localityStats          sync.Map // locality string -> types.LocalityStats
+}
+
+// transportSocketMatch pairs a host metadata match criteria with the tls
+// manager built from its TLSContext, a resolved form of
+// v2.TransportSocketMatch that clusterInfo selects between in
+// TLSMngByMetadata.
+type transportSocketMatch struct {
+	match  api.Metadata
+	tlsMng types.TLSContextManager
+}
+
+// metadataMatches reports whether match is a subset of meta, i.e. every
+// key/value pair in match is also present in meta.
+func metadataMatches(match, meta api.Metadata) bool {
+	for k, v := range match {
+		if meta[k] != v {
+			return false
+		}
+	}
+	return true
 }
 
 func updateClusterResourceManager(ci types.ClusterInfo, rm types.ResourceManager) {
@@ -200,6 +304,18 @@ func (ci *clusterInfo) MaxRequestsPerConn() uint32 {
 	return ci.maxRequestsPerConn
 }
 
+func (ci *clusterInfo) MaxConnectionDuration() time.Duration {
+	return ci.maxConnectionDuration
+}
+
+func (ci *clusterInfo) IdleTimeout() time.Duration {
+	return ci.idleTimeout
+}
+
+func (ci *clusterInfo) Http1ProtocolOptions() v2.Http1ProtocolOptions {
+	return ci.http1ProtocolOptions
+}
+
 func (ci *clusterInfo) Stats() types.ClusterStats {
 	return ci.stats
 }
@@ -212,6 +328,15 @@ func (ci *clusterInfo) TLSMng() types.TLSContextManager {
 	return ci.tlsMng
 }
 
+func (ci *clusterInfo) TLSMngByMetadata(meta api.Metadata) types.TLSContextManager {
+	for _, tsm := range ci.transportSocketMatches {
+		if metadataMatches(tsm.match, meta) {
+			return tsm.tlsMng
+		}
+	}
+	return ci.tlsMng
+}
+
 func (ci *clusterInfo) LbSubsetInfo() types.LBSubsetInfo {
 	return ci.lbSubsetInfo
 }
@@ -228,6 +353,97 @@ func (ci *clusterInfo) LbConfig() v2.IsCluster_LbConfig {
 	return ci.lbConfig
 }
 
+// defaultHealthyPanicThreshold matches Envoy's default: below 50% healthy
+// capacity, the load balancer panics and routes to the whole host set.
+const defaultHealthyPanicThreshold = 50
+
+func (ci *clusterInfo) HealthyPanicThreshold() float64 {
+	if ci.healthyPanicThreshold > 0 {
+		return ci.healthyPanicThreshold
+	}
+	return defaultHealthyPanicThreshold
+}
+
+func (ci *clusterInfo) OutlierDetection() v2.OutlierDetection {
+	return ci.outlierDetection
+}
+
+// defaultDrainTimeout bounds how long RemovePrimaryCluster waits for a
+// removed cluster's active requests to finish before closing its
+// connection pools.
+const defaultDrainTimeout = 10 * time.Second
+
+func (ci *clusterInfo) DrainTimeout() time.Duration {
+	if ci.drainTimeout > 0 {
+		return ci.drainTimeout
+	}
+	return defaultDrainTimeout
+}
+
+func (ci *clusterInfo) UpstreamProtocol() types.ProtocolName {
+	return ci.upstreamProtocol
+}
+
+func (ci *clusterInfo) AggregateClusterNames() []string {
+	return ci.aggregateClusterNames
+}
+
+func (ci *clusterInfo) AltStatName() string {
+	return ci.altStatName
+}
+
+func (ci *clusterInfo) ConnectionOptions() v2.UpstreamConnectionOptions {
+	return ci.connectionOptions
+}
+
+func (ci *clusterInfo) ConnectionAffinity() bool {
+	return ci.connectionAffinity
+}
+
+// resolveUpstreamProtocol turns a cluster's configured UpstreamProtocol
+// into the protocol ConnPoolForCluster should use, or "" to defer to the
+// caller's requested protocol (the existing downstream-follows behavior).
+// "Auto" over TLS with ALPN configured statically resolves to the
+// highest-priority protocol advertised in TLS.ALPN: MOSN picks its
+// connection pool/codec before dialing, so it cannot switch on the
+// protocol a real TLS handshake negotiates, but it can honor the same
+// preference order the handshake would advertise.
+func resolveUpstreamProtocol(clusterConfig v2.Cluster) types.ProtocolName {
+	switch clusterConfig.UpstreamProtocol {
+	case "", "Auto":
+		return alpnUpstreamProtocol(clusterConfig.TLS.ALPN)
+	default:
+		return types.ProtocolName(clusterConfig.UpstreamProtocol)
+	}
+}
+
+// alpnProtocolPriority lists the ALPN protocol IDs alpnUpstreamProtocol
+// recognizes, in the order they are preferred when more than one is
+// configured, mirroring how a TLS handshake would prefer h2 over http/1.1.
+var alpnProtocolPriority = []struct {
+	alpn     string
+	protocol types.ProtocolName
+}{
+	{"h2", protocol.HTTP2},
+	{"http/1.1", protocol.HTTP1},
+}
+
+func alpnUpstreamProtocol(alpn string) types.ProtocolName {
+	if alpn == "" {
+		return ""
+	}
+	configured := make(map[string]struct{})
+	for _, p := range strings.Split(alpn, ",") {
+		configured[strings.TrimSpace(p)] = struct{}{}
+	}
+	for _, candidate := range alpnProtocolPriority {
+		if _, ok := configured[candidate.alpn]; ok {
+			return candidate.protocol
+		}
+	}
+	return ""
+}
+
 type clusterSnapshot struct {
 	info    types.ClusterInfo
 	hostSet types.HostSet
@@ -63,3 +63,7 @@ func (ca *MngAdapter) TriggerHostDel(clusterName string, hosts []string) error {
 func (ca *MngAdapter) TriggerHostAppend(clusterName string, hostAppend []v2.Host) error {
 	return ca.AppendClusterHosts(clusterName, hostAppend)
 }
+
+func (ca *MngAdapter) TriggerConnectionDrain(clusterName string, host string) error {
+	return ca.DrainConnections(clusterName, host)
+}
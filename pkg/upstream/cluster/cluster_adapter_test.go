@@ -19,10 +19,13 @@ package cluster
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -338,12 +341,165 @@ func TestClusterRemoveHostWithSnapshot(t *testing.T) {
 	_createClusterManager()
 	oldSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	GetClusterMngAdapterInstance().TriggerHostDel("test1", []string{"127.0.0.1:10001"})
-	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+
+	// the host is drained in the background (see RemoveClusterHosts), so it
+	// only disappears from the snapshot once that finishes, not right away.
+	var newSnap types.ClusterSnapshot
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		newSnap = GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+		if len(newSnap.HostSet().Hosts()) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
 	if !(len(oldSnap.HostSet().Hosts()) == 2 && len(newSnap.HostSet().Hosts()) == 1) {
 		t.Fatal("remove hosts snapshot check failed")
 	}
 }
 
+func TestClusterUpdateHostsPreservesUnchangedHosts(t *testing.T) {
+	_createClusterManager()
+	oldSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	oldHosts := oldSnap.HostSet().Hosts()
+	var oldHost0 types.Host
+	for _, h := range oldHosts {
+		if h.AddressString() == "127.0.0.1:10000" {
+			oldHost0 = h
+		}
+	}
+	if oldHost0 == nil {
+		t.Fatal("expected host 127.0.0.1:10000 to exist before update")
+	}
+
+	var added, removed []types.Host
+	if err := GetClusterMngAdapterInstance().AddClusterMemberUpdateCb("test1", func(addHosts, delHosts []types.Host) {
+		added, removed = addHosts, delHosts
+	}); err != nil {
+		t.Fatalf("add cluster member update cb failed, %v", err)
+	}
+
+	// host 127.0.0.1:10000 is unchanged, so the update must reuse the same
+	// Host object and the callback must only report the actual change.
+	if err := GetClusterMngAdapterInstance().UpdateClusterHosts("test1", []v2.Host{
+		{
+			HostConfig: v2.HostConfig{Address: "127.0.0.1:10000"},
+			MetaData:   api.Metadata{"version": "1.0.0", "zone": "a"},
+		},
+		{
+			HostConfig: v2.HostConfig{Address: "127.0.0.1:10002"},
+		},
+	}); err != nil {
+		t.Fatalf("update cluster hosts failed, %v", err)
+	}
+
+	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	var newHost0 types.Host
+	for _, h := range newSnap.HostSet().Hosts() {
+		if h.AddressString() == "127.0.0.1:10000" {
+			newHost0 = h
+		}
+	}
+	if newHost0 != oldHost0 {
+		t.Fatal("expected unchanged host to keep its existing Host object")
+	}
+	if len(added) != 1 || added[0].AddressString() != "127.0.0.1:10002" {
+		t.Fatalf("expected callback to report only the added host, got %v", added)
+	}
+	if len(removed) != 1 || removed[0].AddressString() != "127.0.0.1:10001" {
+		t.Fatalf("expected callback to report only the removed host, got %v", removed)
+	}
+}
+
+// recordingClusterManagerFilter is a types.ClusterManagerFilter that just
+// records every lifecycle call it receives, for test assertions.
+type recordingClusterManagerFilter struct {
+	added   []string
+	removed []string
+	changed []string
+}
+
+func (f *recordingClusterManagerFilter) OnCreated(cccb types.ClusterConfigFactoryCb, chcb types.ClusterHostFactoryCb) {
+}
+
+func (f *recordingClusterManagerFilter) OnClusterAdded(clusterName string) {
+	f.added = append(f.added, clusterName)
+}
+
+func (f *recordingClusterManagerFilter) OnClusterRemoved(clusterName string) {
+	f.removed = append(f.removed, clusterName)
+}
+
+func (f *recordingClusterManagerFilter) OnHostsChanged(clusterName string, addHosts, delHosts []types.Host) {
+	f.changed = append(f.changed, clusterName)
+}
+
+func TestClusterManagerFilterLifecycleHooks(t *testing.T) {
+	cm := _createClusterManager()
+	filter := &recordingClusterManagerFilter{}
+	cm.RegisterClusterManagerFilter(filter)
+
+	newCluster := v2.Cluster{Name: "test2", LbType: v2.LB_RANDOM}
+	if err := cm.AddOrUpdatePrimaryCluster(newCluster); err != nil {
+		t.Fatalf("add cluster failed: %v", err)
+	}
+	if len(filter.added) != 1 || filter.added[0] != "test2" {
+		t.Fatalf("expected OnClusterAdded(test2) exactly once, got %v", filter.added)
+	}
+
+	// updating an already-existing cluster must not fire OnClusterAdded again.
+	if err := cm.AddOrUpdatePrimaryCluster(newCluster); err != nil {
+		t.Fatalf("update cluster failed: %v", err)
+	}
+	if len(filter.added) != 1 {
+		t.Fatalf("expected an update to not fire OnClusterAdded again, got %v", filter.added)
+	}
+
+	if err := cm.UpdateClusterHosts("test1", []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:10003"}},
+	}); err != nil {
+		t.Fatalf("update cluster hosts failed: %v", err)
+	}
+	if len(filter.changed) != 1 || filter.changed[0] != "test1" {
+		t.Fatalf("expected OnHostsChanged(test1) exactly once, got %v", filter.changed)
+	}
+
+	if err := cm.RemovePrimaryCluster("test2"); err != nil {
+		t.Fatalf("remove cluster failed: %v", err)
+	}
+	if len(filter.removed) != 1 || filter.removed[0] != "test2" {
+		t.Fatalf("expected OnClusterRemoved(test2) exactly once, got %v", filter.removed)
+	}
+}
+
+func TestClusterUpdateHostsNoopSkipsCallback(t *testing.T) {
+	_createClusterManager()
+	called := false
+	if err := GetClusterMngAdapterInstance().AddClusterMemberUpdateCb("test1", func(addHosts, delHosts []types.Host) {
+		called = true
+	}); err != nil {
+		t.Fatalf("add cluster member update cb failed, %v", err)
+	}
+	if err := GetClusterMngAdapterInstance().UpdateHosts("test1", nil, nil); err != nil {
+		t.Fatalf("update hosts failed, %v", err)
+	}
+	if called {
+		t.Fatal("expected no-op update to not fire the member update callback")
+	}
+}
+
+func TestPutClusterSnapshotIsNoop(t *testing.T) {
+	_createClusterManager()
+	before := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	// PutClusterSnapshot is kept only for historical callers; it must not
+	// affect what a later GetClusterSnapshot returns.
+	GetClusterMngAdapterInstance().PutClusterSnapshot(before)
+	after := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	if before != after {
+		t.Fatal("PutClusterSnapshot is expected to be a no-op")
+	}
+}
+
 func TestConnPoolForCluster(t *testing.T) {
 	_createClusterManager()
 	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
@@ -405,3 +561,638 @@ func TestConnPoolUpdateTLS(t *testing.T) {
 	}
 
 }
+
+// TestConnPoolForClusterNoUpstreamProtocolUsesCaller documents that
+// ConnPoolForCluster itself is not where a cluster's UpstreamProtocol
+// override is applied - that happens in downStream.getUpstreamProtocol
+// (see TestGetUpstreamProtocolClusterOverride in pkg/proxy), so it always
+// uses whatever protocol the caller asks for.
+func TestConnPoolForClusterNoUpstreamProtocolUsesCaller(t *testing.T) {
+	_createClusterManager()
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
+	connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol)
+	if connPool == nil {
+		t.Fatal("get conn pool failed")
+	}
+	if connPool.Protocol() != mockProtocol {
+		t.Fatalf("expected caller's protocol to be used when cluster has no override, got %v", connPool.Protocol())
+	}
+}
+
+func TestSetHostHealthAndGetClusterHostsHealth(t *testing.T) {
+	_createClusterManager()
+
+	if err := GetClusterMngAdapterInstance().SetHostHealth("test1", "127.0.0.1:10000", false); err != nil {
+		t.Fatalf("set host health failed, %v", err)
+	}
+	hosts, err := GetClusterMngAdapterInstance().GetClusterHostsHealth("test1")
+	if err != nil {
+		t.Fatalf("get cluster hosts health failed, %v", err)
+	}
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+	for _, h := range hosts {
+		if h.Address == "127.0.0.1:10000" {
+			if h.Healthy || h.Flags&api.FAILED_ACTIVE_HC == 0 {
+				t.Fatalf("host %s should be unhealthy with FAILED_ACTIVE_HC set, got %+v", h.Address, h)
+			}
+		} else if !h.Healthy {
+			t.Fatalf("host %s should still be healthy, got %+v", h.Address, h)
+		}
+	}
+
+	if err := GetClusterMngAdapterInstance().SetHostHealth("test1", "127.0.0.1:10000", true); err != nil {
+		t.Fatalf("restore host health failed, %v", err)
+	}
+	hosts, _ = GetClusterMngAdapterInstance().GetClusterHostsHealth("test1")
+	for _, h := range hosts {
+		if !h.Healthy {
+			t.Fatalf("host %s should be healthy again, got %+v", h.Address, h)
+		}
+	}
+
+	if err := GetClusterMngAdapterInstance().SetHostHealth("test1", "127.0.0.1:10099", true); err == nil {
+		t.Fatal("set host health should fail for a host that does not exist")
+	}
+	if _, err := GetClusterMngAdapterInstance().GetClusterHostsHealth("not_exists"); err == nil {
+		t.Fatal("get cluster hosts health should fail for a cluster that does not exist")
+	}
+}
+
+func TestRemoveClusterHostsDrains(t *testing.T) {
+	_createClusterManager()
+
+	clusterConfig := v2.Cluster{
+		Name:         "drain_test",
+		LbType:       v2.LB_RANDOM,
+		DrainTimeout: api.DurationConfig{Duration: 200 * time.Millisecond},
+	}
+	// use addresses not shared with any other test in this file: host health
+	// flags are keyed globally by address (see GetHealthFlagPointer), so
+	// draining a host here would otherwise leak into any other test that
+	// happens to reuse the same address.
+	hosts := []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:20900"}},
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:20901"}},
+	}
+	if err := GetClusterMngAdapterInstance().TriggerClusterAndHostsAddOrUpdate(clusterConfig, hosts); err != nil {
+		t.Fatalf("create cluster failed, %v", err)
+	}
+
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "drain_test")
+	var removed types.Host
+	for _, h := range snap.HostSet().Hosts() {
+		if h.AddressString() == "127.0.0.1:20900" {
+			removed = h
+		}
+	}
+	if removed == nil {
+		t.Fatal("host 127.0.0.1:20900 not found in snapshot")
+	}
+	// simulate an in-flight request on the host being removed
+	removed.HostStats().UpstreamRequestActive.Inc(1)
+
+	if err := GetClusterMngAdapterInstance().RemoveClusterHosts("drain_test", []string{"127.0.0.1:20900"}); err != nil {
+		t.Fatalf("remove cluster hosts failed, %v", err)
+	}
+
+	// the host is marked draining and excluded from Health() immediately,
+	// but is not yet gone from the host set
+	if removed.Health() {
+		t.Fatal("removed host should be unhealthy (draining) immediately")
+	}
+	snap = GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "drain_test")
+	if len(snap.HostSet().Hosts()) != 2 {
+		t.Fatalf("expected host to still be present while draining, got %d hosts", len(snap.HostSet().Hosts()))
+	}
+
+	// finish the in-flight request before the drain deadline, and the host
+	// should be removed for real without waiting out the full timeout
+	removed.HostStats().UpstreamRequestActive.Dec(1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		snap = GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "drain_test")
+		if len(snap.HostSet().Hosts()) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(snap.HostSet().Hosts()) != 1 {
+		t.Fatalf("expected drained host to be removed, got %d hosts", len(snap.HostSet().Hosts()))
+	}
+	if snap.HostSet().Hosts()[0].AddressString() != "127.0.0.1:20901" {
+		t.Fatalf("expected remaining host 127.0.0.1:20901, got %s", snap.HostSet().Hosts()[0].AddressString())
+	}
+
+	if err := GetClusterMngAdapterInstance().RemoveClusterHosts("drain_test", []string{"127.0.0.1:20999"}); err != nil {
+		t.Fatalf("removing an address that does not exist should be a no-op, got %v", err)
+	}
+	if err := GetClusterMngAdapterInstance().RemoveClusterHosts("not_exists", []string{"127.0.0.1:20900"}); err == nil {
+		t.Fatal("remove cluster hosts should fail for a cluster that does not exist")
+	}
+}
+
+func TestDrainConnections(t *testing.T) {
+	_createClusterManager()
+
+	snapshot := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	mockLbCtx := newMockLbContext(map[string]string{
+		"zone":    "a",
+		"version": "1.0.0",
+	})
+	pool := GetClusterMngAdapterInstance().ConnPoolForCluster(mockLbCtx, snapshot, mockProtocol)
+	mp := pool.(*mockConnPool)
+	addr := mp.Host().AddressString()
+
+	if err := GetClusterMngAdapterInstance().DrainConnections("test1", addr); err != nil {
+		t.Fatalf("drain connections failed: %v", err)
+	}
+	if atomic.LoadInt32(&mp.shutdownCall) != 1 {
+		t.Fatalf("expected the host's pool to be shut down, got %d calls", mp.shutdownCall)
+	}
+
+	// unlike RemoveClusterHosts, the host and its pool stay in place: a
+	// request landing on it right after still gets served by the same pool
+	snapshot = GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	if pool2 := GetClusterMngAdapterInstance().ConnPoolForCluster(mockLbCtx, snapshot, mockProtocol); pool2 != pool {
+		t.Fatal("expected the same pool to still be in use after draining")
+	}
+	found := false
+	for _, h := range snapshot.HostSet().Hosts() {
+		if h.AddressString() == addr {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected the drained host to still be present in the cluster")
+	}
+
+	if err := GetClusterMngAdapterInstance().DrainConnections("test1", "127.0.0.1:20099"); err == nil {
+		t.Fatal("drain connections should fail for a host that does not exist")
+	}
+	if err := GetClusterMngAdapterInstance().DrainConnections("not_exists", addr); err == nil {
+		t.Fatal("drain connections should fail for a cluster that does not exist")
+	}
+}
+
+func TestGetClustersSummary(t *testing.T) {
+	_createClusterManager()
+
+	if err := GetClusterMngAdapterInstance().UpdateClusterCircuitBreakers("test1", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 10},
+		},
+	}); err != nil {
+		t.Fatalf("update circuit breakers failed, %v", err)
+	}
+	if err := GetClusterMngAdapterInstance().SetHostHealth("test1", "127.0.0.1:10000", false); err != nil {
+		t.Fatalf("set host health failed, %v", err)
+	}
+
+	summary := GetClusterMngAdapterInstance().GetClustersSummary()
+	var test1 *types.ClusterSummary
+	for i := range summary {
+		if summary[i].Name == "test1" {
+			test1 = &summary[i]
+		}
+	}
+	if test1 == nil {
+		t.Fatal("cluster test1 not found in summary")
+	}
+	if test1.LbType != types.Random {
+		t.Fatalf("expected lb type %s, got %s", types.Random, test1.LbType)
+	}
+	if test1.Circuit.Connections.Max != 10 {
+		t.Fatalf("expected connections max 10, got %d", test1.Circuit.Connections.Max)
+	}
+	if len(test1.Hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(test1.Hosts))
+	}
+	for _, h := range test1.Hosts {
+		if h.Address == "127.0.0.1:10000" {
+			if h.Healthy {
+				t.Fatalf("host %s should be unhealthy, got %+v", h.Address, h)
+			}
+		} else if !h.Healthy {
+			t.Fatalf("host %s should be healthy, got %+v", h.Address, h)
+		}
+	}
+}
+
+func TestUpdateClusterCircuitBreakers(t *testing.T) {
+	_createClusterManager()
+
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	rm := snap.ClusterInfo().ResourceManager()
+	if rm.Connections().Max() != 0 {
+		t.Fatalf("expected no configured connections limit before update, got %d", rm.Connections().Max())
+	}
+
+	if err := GetClusterMngAdapterInstance().UpdateClusterCircuitBreakers("test1", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 10},
+		},
+	}); err != nil {
+		t.Fatalf("update circuit breakers failed, %v", err)
+	}
+
+	// the ResourceManager is updated in place: a snapshot taken before the
+	// update still observes the new limit, and no new connection pool is
+	// required.
+	if got := rm.Connections().Max(); got != 10 {
+		t.Fatalf("expected connections limit updated to 10, got %d", got)
+	}
+
+	if err := GetClusterMngAdapterInstance().UpdateClusterCircuitBreakers("not_exists", v2.CircuitBreakers{}); err == nil {
+		t.Fatal("update circuit breakers should fail for a cluster that does not exist")
+	}
+}
+
+func TestGetClusterOutlierState(t *testing.T) {
+	_createClusterManager()
+
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	var ejectHost types.Host
+	for _, h := range snap.HostSet().Hosts() {
+		if h.AddressString() == "127.0.0.1:10000" {
+			ejectHost = h
+		}
+	}
+	if ejectHost == nil {
+		t.Fatal("host 127.0.0.1:10000 not found in snapshot")
+	}
+	// default ConsecutiveGatewayFailure threshold is 5 consecutive errors
+	for i := 0; i < defaultConsecutive5xx; i++ {
+		RecordOutlierError(ejectHost, false)
+	}
+
+	states, err := GetClusterMngAdapterInstance().GetClusterOutlierState("test1")
+	if err != nil {
+		t.Fatalf("get cluster outlier state failed, %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(states))
+	}
+	for _, s := range states {
+		if s.Address == "127.0.0.1:10000" {
+			if !s.Ejected || s.EjectionCount != 1 || s.LastEjectionTime.IsZero() {
+				t.Fatalf("host %s should be ejected once with a last ejection time, got %+v", s.Address, s)
+			}
+		} else if s.Ejected {
+			t.Fatalf("host %s should not be ejected, got %+v", s.Address, s)
+		}
+	}
+
+	if _, err := GetClusterMngAdapterInstance().GetClusterOutlierState("not_exists"); err == nil {
+		t.Fatal("get cluster outlier state should fail for a cluster that does not exist")
+	}
+}
+
+func TestClusterWarmup(t *testing.T) {
+	_createClusterManager()
+
+	if err := GetClusterMngAdapterInstance().TriggerClusterAddOrUpdate(v2.Cluster{
+		Name:   "warming_test",
+		LbType: v2.LB_RANDOM,
+	}); err != nil {
+		t.Fatalf("add cluster failed: %v", err)
+	}
+	// the cluster exists, but without health checking configured, it is
+	// not routable until its initial host set arrives
+	if GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "warming_test") != nil {
+		t.Fatal("cluster should not be routable before its initial host set arrives")
+	}
+
+	if err := GetClusterMngAdapterInstance().UpdateClusterHosts("warming_test", []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:10002"}},
+	}); err != nil {
+		t.Fatalf("update cluster hosts failed: %v", err)
+	}
+	if GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "warming_test") == nil {
+		t.Fatal("cluster should be routable once its initial host set arrives")
+	}
+
+	// updating an existing cluster's config keeps its already-warmed-up
+	// state, carrying the existing hosts over immediately
+	if err := GetClusterMngAdapterInstance().TriggerClusterAddOrUpdate(v2.Cluster{
+		Name:   "warming_test",
+		LbType: v2.LB_ROUNDROBIN,
+	}); err != nil {
+		t.Fatalf("update cluster failed: %v", err)
+	}
+	if GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "warming_test") == nil {
+		t.Fatal("updating an existing cluster should not re-enter warming")
+	}
+}
+
+func TestClusterWarmupTimeout(t *testing.T) {
+	_createClusterManager()
+
+	if err := GetClusterMngAdapterInstance().TriggerClusterAddOrUpdate(v2.Cluster{
+		Name:          "warming_timeout_test",
+		LbType:        v2.LB_RANDOM,
+		WarmupTimeout: api.DurationConfig{Duration: 10 * time.Millisecond},
+	}); err != nil {
+		t.Fatalf("add cluster failed: %v", err)
+	}
+	if GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "warming_timeout_test") != nil {
+		t.Fatal("cluster should not be routable before its warmup timeout elapses")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "warming_timeout_test") == nil {
+		t.Fatal("cluster should be routable once its warmup timeout elapses, even without hosts")
+	}
+}
+
+func TestRemovePrimaryClusterDrain(t *testing.T) {
+	_createClusterManager()
+
+	snapshot := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	mockLbCtx := newMockLbContext(map[string]string{
+		"zone":    "a",
+		"version": "1.0.0",
+	})
+	pool := GetClusterMngAdapterInstance().ConnPoolForCluster(mockLbCtx, snapshot, mockProtocol)
+	mp := pool.(*mockConnPool)
+
+	active := snapshot.ClusterInfo().Stats().UpstreamRequestActive
+	active.Inc(1)
+
+	if err := GetClusterMngAdapterInstance().RemovePrimaryCluster("test1"); err != nil {
+		t.Fatalf("remove cluster failed: %v", err)
+	}
+
+	// an active request should hold the pool open past Shutdown
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&mp.shutdownCall) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&mp.shutdownCall) == 0 {
+		t.Fatal("pool should have been shut down once the cluster was removed")
+	}
+	if atomic.LoadInt32(&mp.closeCall) != 0 {
+		t.Fatal("pool should not be closed while a request is still active")
+	}
+
+	active.Dec(1)
+
+	deadline = time.Now().Add(time.Second)
+	for atomic.LoadInt32(&mp.closeCall) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&mp.closeCall) == 0 {
+		t.Fatal("pool should be closed once the active request finishes")
+	}
+}
+
+func _createAggregateClusterManager() types.ClusterManager {
+	primary := v2.Cluster{Name: "primary", LbType: v2.LB_RANDOM}
+	dr := v2.Cluster{Name: "dr", LbType: v2.LB_RANDOM}
+	aggregate := v2.Cluster{
+		Name:        "aggregate",
+		ClusterType: v2.AGGREGATE_CLUSTER,
+		Clusters:    []string{"primary", "dr"},
+	}
+	clusterManagerInstance.Destroy() // Destroy for test
+	return NewClusterManagerSingleton([]v2.Cluster{primary, dr, aggregate}, map[string][]v2.Host{
+		"primary":   []v2.Host{{HostConfig: v2.HostConfig{Address: "127.0.0.1:20000"}}},
+		"dr":        []v2.Host{{HostConfig: v2.HostConfig{Address: "127.0.0.1:20001"}}},
+		"aggregate": {},
+	})
+}
+
+func TestAggregateClusterPrefersFirstHealthyCluster(t *testing.T) {
+	_createAggregateClusterManager()
+
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "aggregate")
+	if snap == nil {
+		t.Fatal("aggregate cluster snapshot should not be nil")
+	}
+	if snap.ClusterInfo().Name() != "primary" {
+		t.Fatalf("expected aggregate to resolve to primary, got %s", snap.ClusterInfo().Name())
+	}
+}
+
+func TestAggregateClusterFallsBackWhenPrimaryUnhealthy(t *testing.T) {
+	_createAggregateClusterManager()
+
+	if err := GetClusterMngAdapterInstance().SetHostHealth("primary", "127.0.0.1:20000", false); err != nil {
+		t.Fatalf("set host health failed: %v", err)
+	}
+
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "aggregate")
+	if snap == nil {
+		t.Fatal("aggregate cluster snapshot should not be nil")
+	}
+	if snap.ClusterInfo().Name() != "dr" {
+		t.Fatalf("expected aggregate to fall back to dr, got %s", snap.ClusterInfo().Name())
+	}
+}
+
+func TestAggregateClusterFallsBackToFirstWhenNoneHealthy(t *testing.T) {
+	_createAggregateClusterManager()
+
+	if err := GetClusterMngAdapterInstance().SetHostHealth("primary", "127.0.0.1:20000", false); err != nil {
+		t.Fatalf("set host health failed: %v", err)
+	}
+	if err := GetClusterMngAdapterInstance().SetHostHealth("dr", "127.0.0.1:20001", false); err != nil {
+		t.Fatalf("set host health failed: %v", err)
+	}
+
+	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "aggregate")
+	if snap == nil {
+		t.Fatal("aggregate cluster snapshot should not be nil when no underlying cluster is healthy")
+	}
+	if snap.ClusterInfo().Name() != "primary" {
+		t.Fatalf("expected aggregate to fall back to the first cluster in the list, got %s", snap.ClusterInfo().Name())
+	}
+}
+
+func TestAggregateClusterSelfReferenceIgnored(t *testing.T) {
+	loop := v2.Cluster{
+		Name:        "loop",
+		ClusterType: v2.AGGREGATE_CLUSTER,
+		Clusters:    []string{"loop"},
+	}
+	clusterManagerInstance.Destroy() // Destroy for test
+	NewClusterManagerSingleton([]v2.Cluster{loop}, map[string][]v2.Host{"loop": {}})
+
+	if snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "loop"); snap != nil {
+		t.Fatal("a self-referencing aggregate cluster should not resolve to a snapshot")
+	}
+}
+
+func TestClusterAltStatNameAliasing(t *testing.T) {
+	renamed := v2.Cluster{
+		Name:        "renamed-v2",
+		AltStatName: "original",
+		LbType:      v2.LB_RANDOM,
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:30000",
+		},
+	}
+	clusterManagerInstance.Destroy() // Destroy for test
+	NewClusterManagerSingleton([]v2.Cluster{renamed}, map[string][]v2.Host{"renamed-v2": {host}})
+
+	cm := GetClusterMngAdapterInstance()
+
+	if !cm.ClusterExist("original") {
+		t.Fatal("cluster should be resolvable by its alt stat name")
+	}
+
+	snap := cm.GetClusterSnapshot(context.Background(), "original")
+	if snap == nil {
+		t.Fatal("cluster snapshot should resolve by alt stat name")
+	}
+	if snap.ClusterInfo().Name() != "renamed-v2" {
+		t.Fatalf("expected snapshot to resolve to renamed-v2, got %s", snap.ClusterInfo().Name())
+	}
+	foundAltStatsName := false
+	for _, m := range metrics.GetAll() {
+		if m.Type() == metrics.UpstreamType && m.Labels()["cluster"] == "original" {
+			foundAltStatsName = true
+		}
+	}
+	if !foundAltStatsName {
+		t.Fatal("expected stats to be recorded under the alt stat name \"original\"")
+	}
+
+	if err := cm.SetHostHealth("original", "127.0.0.1:30000", false); err != nil {
+		t.Fatalf("set host health by alt stat name failed: %v", err)
+	}
+	health, err := cm.GetClusterHostsHealth("original")
+	if err != nil {
+		t.Fatalf("get cluster hosts health by alt stat name failed: %v", err)
+	}
+	if len(health) != 1 || health[0].Healthy {
+		t.Fatalf("expected host to be unhealthy after SetHostHealth by alt stat name, got %+v", health)
+	}
+
+	// updating the cluster to drop its alt stat name clears the alias
+	renamed.AltStatName = ""
+	if err := cm.AddOrUpdatePrimaryCluster(renamed); err != nil {
+		t.Fatalf("update cluster failed: %v", err)
+	}
+	if cm.ClusterExist("original") {
+		t.Fatal("alt stat name alias should be cleared once the cluster no longer configures it")
+	}
+	if !cm.ClusterExist("renamed-v2") {
+		t.Fatal("cluster should still be resolvable by its primary name")
+	}
+}
+
+// TestClusterManagerUpdateSelectorsRetainsPoolsAndStats changes a cluster's
+// subset selectors (the same change TestClusterManagerUpdateClusterSelectors
+// makes) and checks the two things that change doesn't re-create: the
+// connection pool for an existing host, and the cluster's stats counters.
+// AddOrUpdatePrimaryCluster rebuilds the subset index with a fresh
+// clusterInfo and load balancer, but carries the old hosts over to it and
+// leaves connection pools (keyed by address in the cluster manager) and
+// stats (looked up by cluster name in the metrics registry) untouched.
+//
+// The cluster has a single host so ChooseHost has nothing to pick between,
+// keeping the pool lookup deterministic across the selector change.
+func TestClusterManagerUpdateSelectorsRetainsPoolsAndStats(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "subset-update",
+		LbType: v2.LB_RANDOM,
+		LBSubSetConfig: v2.LBSubsetConfig{
+			FallBackPolicy: 1, // AnyEndPoint
+			SubsetSelectors: [][]string{
+				{"version"},
+			},
+		},
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:20000",
+		},
+		MetaData: api.Metadata{
+			"version": "1.0.0",
+			"zone":    "a",
+		},
+	}
+	clusterManagerInstance.Destroy() // Destroy for test
+	cm := NewClusterManagerSingleton([]v2.Cluster{clusterConfig}, map[string][]v2.Host{
+		"subset-update": {host},
+	})
+
+	snap := cm.GetClusterSnapshot(context.Background(), "subset-update")
+	// no match criteria: with a single host in the cluster, this is
+	// unaffected by whatever the subset selectors happen to be.
+	mockLbCtx := newMockLbContext(nil)
+	pool := cm.ConnPoolForCluster(mockLbCtx, snap, mockProtocol)
+	if pool == nil {
+		t.Fatal("expected a connection pool before the update")
+	}
+
+	stats := snap.ClusterInfo().Stats()
+	stats.UpstreamRequestTotal.Inc(1)
+
+	clusterConfig.LBSubSetConfig.SubsetSelectors = [][]string{
+		{"zone"},
+	}
+	if err := cm.AddOrUpdatePrimaryCluster(clusterConfig); err != nil {
+		t.Fatalf("update cluster failed: %v", err)
+	}
+
+	newSnap := cm.GetClusterSnapshot(context.Background(), "subset-update")
+	newPool := cm.ConnPoolForCluster(mockLbCtx, newSnap, mockProtocol)
+	if newPool != pool {
+		t.Fatal("connection pool should be retained across a subset selector update, but was re-created")
+	}
+
+	if newSnap.ClusterInfo().Stats().UpstreamRequestTotal.Count() != 1 {
+		t.Fatal("cluster stats should be retained across a subset selector update, but were reset")
+	}
+
+	// the subset index itself was rebuilt: "zone" alone is now a valid
+	// selector, which it wasn't before the update.
+	if host := newSnap.LoadBalancer().ChooseHost(newMockLbContext(map[string]string{
+		"zone": "a",
+	})); host == nil {
+		t.Fatal("expected the rebuilt subset index to match the new selector")
+	}
+}
+
+func TestClusterConfigFieldsChanged(t *testing.T) {
+	base := v2.Cluster{
+		Name:              "test",
+		LbType:            v2.LB_RANDOM,
+		MaxRequestPerConn: 1024,
+		Hosts: []v2.Host{
+			{HostConfig: v2.HostConfig{Address: "127.0.0.1:8080"}},
+		},
+	}
+	unchanged := base
+	if changed := clusterConfigFieldsChanged(base, unchanged); len(changed) != 0 {
+		t.Fatalf("expected no fields changed, got %v", changed)
+	}
+
+	// only the host list differs: Hosts is tracked separately, via
+	// UpdateHosts, so it must not show up here.
+	hostsOnly := base
+	hostsOnly.Hosts = []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:8081"}},
+	}
+	if changed := clusterConfigFieldsChanged(base, hostsOnly); len(changed) != 0 {
+		t.Fatalf("expected Hosts changes to be ignored, got %v", changed)
+	}
+
+	updated := base
+	updated.MaxRequestPerConn = 2048
+	updated.LbType = v2.LB_ROUNDROBIN
+	changed := clusterConfigFieldsChanged(base, updated)
+	if len(changed) != 2 {
+		t.Fatalf("expected 2 fields changed, got %v", changed)
+	}
+	got := map[string]bool{changed[0]: true, changed[1]: true}
+	if !got["max_request_per_conn"] || !got["lb_type"] {
+		t.Fatalf("expected max_request_per_conn and lb_type to be reported, got %v", changed)
+	}
+}
@@ -22,16 +22,18 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/admin/store"
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
 )
 
 var errNilCluster = errors.New("cannot update nil cluster")
@@ -56,12 +58,45 @@ func refreshHostsConfig(c types.Cluster) {
 
 const globalTLSMetrics = "global"
 
+// defaultWarmupTimeout bounds how long a cluster may stay in the warming
+// state before it is activated regardless of whether its initial host set
+// and first health check round have completed.
+const defaultWarmupTimeout = 10 * time.Second
+
+// warmingCluster tracks a newly added cluster's progress towards becoming
+// eligible for traffic: it needs its initial host set and, if health
+// checking is configured, every one of those hosts to complete its first
+// health check. It is removed from clusterManager.warmingMap once warming
+// completes or its timeout fallback fires.
+type warmingCluster struct {
+	mutex         sync.Mutex
+	healthCheck   bool
+	gotHosts      bool
+	pendingChecks map[string]struct{}
+	timer         *time.Timer
+	activated     sync.Once
+}
+
 // types.ClusterManager
 type clusterManager struct {
-	clustersMap      sync.Map
+	clustersMap      shardedClusterMap
+	warmingMap       sync.Map
 	protocolConnPool sync.Map
+	memberUpdateCbs  sync.Map // cluster name -> *memberUpdateCbList
+	aliasMap         sync.Map // alt stat name -> primary cluster name
+	clusterAliasOf   sync.Map // primary cluster name -> alt stat name, the reverse of aliasMap
 	tlsMetrics       *mtls.TLSStats
+	stats            *clusterManagerStats
 	mux              sync.Mutex
+	filtersMutex     sync.Mutex
+	filters          []types.ClusterManagerFilter
+}
+
+// memberUpdateCbList is the set of MemberUpdateCb callbacks registered for
+// one cluster.
+type memberUpdateCbList struct {
+	mutex sync.Mutex
+	cbs   []types.MemberUpdateCb
 }
 
 type clusterManagerSingleton struct {
@@ -85,6 +120,7 @@ func NewClusterManagerSingleton(clusters []v2.Cluster, clusterMap map[string][]v
 	}
 	clusterManagerInstance.clusterManager = &clusterManager{
 		tlsMetrics: mtls.NewStats(globalTLSMetrics),
+		stats:      newClusterManagerStats(),
 	}
 	for k := range types.ConnPoolFactories {
 		clusterManagerInstance.protocolConnPool.Store(k, &sync.Map{})
@@ -116,8 +152,12 @@ func (cm *clusterManager) AddOrUpdatePrimaryCluster(cluster v2.Cluster) error {
 	}
 	// check update or new
 	clusterName := cluster.Name
+	// capture the config being replaced, if any, so its change summary can
+	// be recorded for the admin API before it is overwritten
+	oldConfig, hadOldConfig := store.GetClusterConfig(clusterName)
 	// set config
 	store.SetClusterConfig(clusterName, cluster)
+	recordClusterConfigChange(clusterName, oldConfig, cluster, hadOldConfig)
 	// add or update
 	ci, exists := cm.clustersMap.Load(clusterName)
 	if exists {
@@ -140,13 +180,195 @@ func (cm *clusterManager) AddOrUpdatePrimaryCluster(cluster v2.Cluster) error {
 		refreshHostsConfig(c)
 	}
 	cm.clustersMap.Store(clusterName, newCluster)
+	if !exists {
+		cm.startWarming(newCluster, cluster)
+	}
+	cm.updateClusterAlias(clusterName, cluster.AltStatName)
 	log.DefaultLogger.Infof("[cluster] [cluster manager] [AddOrUpdatePrimaryCluster] cluster %s updated", clusterName)
+	if !exists {
+		for _, f := range cm.clusterManagerFilters() {
+			f.OnClusterAdded(clusterName)
+		}
+	}
 	return nil
 }
 
+// recordClusterConfigChange stores a ClusterChangeSummary for clusterName
+// reflecting the config fields a push just changed, so the admin API can
+// report what an xDS (or other) config push actually did. Host set
+// changes are recorded separately, by UpdateHosts, and merged in here so
+// a config-only push doesn't clobber the last host diff.
+func recordClusterConfigChange(clusterName string, oldConfig, newConfig v2.Cluster, hadOldConfig bool) {
+	summary, _ := store.GetClusterChangeSummary(clusterName)
+	summary.ClusterName = clusterName
+	summary.Time = time.Now()
+	summary.New = !hadOldConfig
+	if hadOldConfig {
+		summary.ConfigChanged = clusterConfigFieldsChanged(oldConfig, newConfig)
+	} else {
+		summary.ConfigChanged = nil
+	}
+	store.SetClusterChangeSummary(summary)
+}
+
+// clusterConfigFieldsChanged returns the JSON field names of the
+// top-level v2.Cluster fields that differ between old and new. Hosts is
+// skipped: host set changes go through UpdateHosts, which tracks them as
+// additions and removals instead of a single opaque "hosts differ" flag.
+func clusterConfigFieldsChanged(old, new v2.Cluster) []string {
+	var changed []string
+	t := reflect.TypeOf(old)
+	oldVal := reflect.ValueOf(old)
+	newVal := reflect.ValueOf(new)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name == "Hosts" {
+			continue
+		}
+		if reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+			continue
+		}
+		name := field.Tag.Get("json")
+		if idx := strings.Index(name, ","); idx >= 0 {
+			name = name[:idx]
+		}
+		if name == "" {
+			name = field.Name
+		}
+		changed = append(changed, name)
+	}
+	return changed
+}
+
+// updateClusterAlias records clusterName's current alt stat name, so
+// resolveClusterName can find it under either name, replacing whatever
+// alias it was previously registered under, if any. An empty altName
+// clears the alias.
+func (cm *clusterManager) updateClusterAlias(clusterName, altName string) {
+	if old, ok := cm.clusterAliasOf.Load(clusterName); ok {
+		if oldAlias := old.(string); oldAlias != altName {
+			cm.aliasMap.Delete(oldAlias)
+		}
+	}
+	if altName == "" {
+		cm.clusterAliasOf.Delete(clusterName)
+		return
+	}
+	cm.aliasMap.Store(altName, clusterName)
+	cm.clusterAliasOf.Store(clusterName, altName)
+}
+
+// resolveClusterName returns the primary cluster name a lookup name
+// refers to: name itself, unless name is registered as another
+// cluster's alt stat name, in which case that cluster's primary name is
+// returned instead. This lets routes and admin calls keep referencing a
+// cluster's old name across a control-plane rename, as long as the new
+// config carries the old name as AltStatName.
+func (cm *clusterManager) resolveClusterName(name string) string {
+	if primary, ok := cm.aliasMap.Load(name); ok {
+		return primary.(string)
+	}
+	return name
+}
+
+// loadCluster looks up name in clustersMap, resolving it through
+// resolveClusterName first so an alt stat name finds the same cluster as
+// its primary name.
+func (cm *clusterManager) loadCluster(name string) (interface{}, bool) {
+	return cm.clustersMap.Load(cm.resolveClusterName(name))
+}
+
+// startWarming puts a newly added cluster into the warming state: it is
+// stored in clustersMap immediately (so admin APIs and health check
+// callback registration can see it), but GetClusterSnapshot withholds it
+// from traffic until its initial host set arrives and, if health checking
+// is configured, every one of those hosts completes its first health
+// check, or its warmup timeout elapses, whichever comes first.
+func (cm *clusterManager) startWarming(c types.Cluster, cluster v2.Cluster) {
+	wc := &warmingCluster{
+		healthCheck: cluster.HealthCheck.ServiceName != "",
+	}
+	cm.warmingMap.Store(cluster.Name, wc)
+	cm.stats.warmingClusters.Update(cm.stats.warmingClusters.Value() + 1)
+
+	timeout := defaultWarmupTimeout
+	if cluster.WarmupTimeout.Duration > 0 {
+		timeout = cluster.WarmupTimeout.Duration
+	}
+	wc.timer = time.AfterFunc(timeout, func() {
+		if cm.finishWarming(cluster.Name, wc) {
+			log.DefaultLogger.Infof("[upstream] [cluster manager] cluster %s activated by warmup timeout %s, initial host set or health check round did not complete in time", cluster.Name, timeout)
+		}
+	})
+
+	c.AddHealthCheckCallbacks(func(host types.Host, changed bool, isHealthy bool, wasHealthy bool, reason types.FailureType) {
+		cm.onWarmingHealthCheck(cluster.Name, wc, host)
+	})
+}
+
+// onHostsUpdated is called whenever a cluster's host set is replaced. If
+// the cluster is warming, it records that the initial host set has
+// arrived, completing warmup unless health checking is configured and
+// some of those hosts have not yet completed their first check.
+func (cm *clusterManager) onHostsUpdated(clusterName string, hosts []types.Host) {
+	v, ok := cm.warmingMap.Load(clusterName)
+	if !ok {
+		return
+	}
+	wc := v.(*warmingCluster)
+	wc.mutex.Lock()
+	if wc.gotHosts {
+		wc.mutex.Unlock()
+		return
+	}
+	wc.gotHosts = true
+	if wc.healthCheck {
+		pending := make(map[string]struct{}, len(hosts))
+		for _, h := range hosts {
+			pending[h.AddressString()] = struct{}{}
+		}
+		wc.pendingChecks = pending
+	}
+	done := len(wc.pendingChecks) == 0
+	wc.mutex.Unlock()
+	if done {
+		cm.finishWarming(clusterName, wc)
+	}
+}
+
+// onWarmingHealthCheck is called on every health check completion for a
+// warming cluster's hosts, so it can tell once every host present when the
+// initial host set arrived has completed its first check.
+func (cm *clusterManager) onWarmingHealthCheck(clusterName string, wc *warmingCluster, host types.Host) {
+	wc.mutex.Lock()
+	delete(wc.pendingChecks, host.AddressString())
+	done := wc.gotHosts && len(wc.pendingChecks) == 0
+	wc.mutex.Unlock()
+	if done {
+		cm.finishWarming(clusterName, wc)
+	}
+}
+
+// finishWarming removes a cluster from the warming state, exactly once.
+// It returns whether this call was the one that finished it, so callers
+// that log cluster activation don't log it twice.
+func (cm *clusterManager) finishWarming(clusterName string, wc *warmingCluster) bool {
+	finished := false
+	wc.activated.Do(func() {
+		finished = true
+	})
+	if !finished {
+		return false
+	}
+	wc.timer.Stop()
+	cm.warmingMap.Delete(clusterName)
+	cm.stats.warmingClusters.Update(cm.stats.warmingClusters.Value() - 1)
+	return true
+}
+
 // AddClusterHealthCheckCallbacks adds a health check callback function into cluster
 func (cm *clusterManager) AddClusterHealthCheckCallbacks(name string, cb types.HealthCheckCb) error {
-	ci, ok := cm.clustersMap.Load(name)
+	ci, ok := cm.loadCluster(name)
 	if ok {
 		c := ci.(types.Cluster)
 		c.AddHealthCheckCallbacks(cb)
@@ -156,7 +378,7 @@ func (cm *clusterManager) AddClusterHealthCheckCallbacks(name string, cb types.H
 }
 
 func (cm *clusterManager) ClusterExist(clusterName string) bool {
-	_, ok := cm.clustersMap.Load(clusterName)
+	_, ok := cm.loadCluster(clusterName)
 	return ok
 }
 
@@ -181,87 +403,583 @@ func (cm *clusterManager) RemovePrimaryCluster(clusterNames ...string) error {
 		c.StopHealthChecking()
 
 		cm.clustersMap.Delete(clusterName)
+		if wv, warming := cm.warmingMap.Load(clusterName); warming {
+			cm.finishWarming(clusterName, wv.(*warmingCluster))
+		}
+		cm.updateClusterAlias(clusterName, "")
 		store.RemoveClusterConfig(clusterName)
 		if log.DefaultLogger.GetLogLevel() >= log.INFO {
 			log.DefaultLogger.Infof("[upstream] [cluster manager] Remove Primary Cluster, Cluster Name = %s", clusterName)
 		}
+		utils.GoWithRecover(func() {
+			cm.drainCluster(clusterName, c.Snapshot())
+		}, nil)
+		for _, f := range cm.clusterManagerFilters() {
+			f.OnClusterRemoved(clusterName)
+		}
 	}
 	return nil
 }
 
-// UpdateClusterHosts update all hosts in the cluster
+// drainPollInterval is how often drainCluster polls a removed cluster's
+// active request count while waiting for it to reach zero.
+const drainPollInterval = 100 * time.Millisecond
+
+// drainCluster is run in the background by RemovePrimaryCluster, after the
+// cluster has already been removed from clustersMap so it can no longer be
+// looked up for new connection pool assignments. It shuts down the removed
+// cluster's connection pools so they stop accepting new streams, waits for
+// their in-flight requests to finish up to the cluster's DrainTimeout, then
+// closes the pools.
+func (cm *clusterManager) drainCluster(clusterName string, snap types.ClusterSnapshot) {
+	pools := cm.takeConnectionPools(snap.HostSet().Hosts())
+	for _, pool := range pools {
+		pool.Shutdown()
+	}
+
+	active := snap.ClusterInfo().Stats().UpstreamRequestActive
+	timeout := snap.ClusterInfo().DrainTimeout()
+	deadline := time.Now().Add(timeout)
+	for active.Count() > 0 && time.Now().Before(deadline) {
+		time.Sleep(drainPollInterval)
+	}
+	if c := active.Count(); c > 0 {
+		log.DefaultLogger.Warnf("[upstream] [cluster manager] cluster %s drain timeout %s elapsed with %d active requests still outstanding, closing connection pools anyway", clusterName, timeout, c)
+	} else if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [cluster manager] cluster %s drained", clusterName)
+	}
+
+	for _, pool := range pools {
+		pool.Close()
+	}
+}
+
+// takeConnectionPools removes and returns every connection pool, across all
+// protocols, keyed by one of hosts' addresses.
+func (cm *clusterManager) takeConnectionPools(hosts []types.Host) []types.ConnectionPool {
+	addrs := make(map[string]struct{}, len(hosts))
+	for _, h := range hosts {
+		addrs[h.AddressString()] = struct{}{}
+	}
+	var pools []types.ConnectionPool
+	cm.protocolConnPool.Range(func(_, v interface{}) bool {
+		connectionPool := v.(*sync.Map)
+		connectionPool.Range(func(k, pv interface{}) bool {
+			if _, ok := addrs[k.(string)]; ok {
+				pools = append(pools, pv.(types.ConnectionPool))
+				connectionPool.Delete(k)
+			}
+			return true
+		})
+		return true
+	})
+	return pools
+}
+
+// UpdateClusterHosts replaces a cluster's entire host list with hostConfigs,
+// by diffing it against the current host set: any address present in both
+// keeps its existing Host object, and addresses missing from hostConfigs
+// are removed.
 func (cm *clusterManager) UpdateClusterHosts(clusterName string, hostConfigs []v2.Host) error {
+	snap, ok := cm.clusterSnapshot(clusterName, "UpdateClusterHosts")
+	if !ok {
+		return fmt.Errorf("cluster %s is not exists", clusterName)
+	}
+
+	keep := make(map[string]struct{}, len(hostConfigs))
+	for _, hc := range hostConfigs {
+		keep[hc.Address] = struct{}{}
+	}
+	var remove []string
+	for _, h := range snap.HostSet().Hosts() {
+		if _, ok := keep[h.AddressString()]; !ok {
+			remove = append(remove, h.AddressString())
+		}
+	}
+	return cm.UpdateHosts(clusterName, hostConfigs, remove)
+}
+
+// UpdateHosts applies a diff to clusterName's host set: add contains hosts
+// to add or update, remove contains addresses to remove. Hosts named in
+// neither list keep their existing Host object, so in-flight connection
+// pools and per-host state stay valid across the update. The resulting
+// host set is only swapped in, and registered MemberUpdateCb callbacks
+// only fire, if the diff actually adds or removes something.
+func (cm *clusterManager) UpdateHosts(clusterName string, add []v2.Host, remove []string) error {
+	clusterName = cm.resolveClusterName(clusterName)
 	ci, ok := cm.clustersMap.Load(clusterName)
 	if !ok {
-		log.DefaultLogger.Errorf("[upstream] [cluster manager] UpdateClusterHosts cluster %s not found", clusterName)
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] UpdateHosts cluster %s not found", clusterName)
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
 	snap := c.Snapshot()
-	hosts := make([]types.Host, 0, len(hostConfigs))
-	for _, hc := range hostConfigs {
-		hosts = append(hosts, NewSimpleHost(hc, snap.ClusterInfo()))
+	newHosts, addedHosts, removedHosts := diffHosts(snap.HostSet().Hosts(), add, remove, snap.ClusterInfo())
+
+	// always record the resulting host set for warmup bookkeeping, even
+	// when nothing changed, so a cluster configured with zero hosts still
+	// completes warmup instead of relying on WarmupTimeout.
+	cm.onHostsUpdated(clusterName, newHosts)
+
+	if len(addedHosts) == 0 && len(removedHosts) == 0 {
+		return nil
 	}
-	c.UpdateHosts(hosts)
+	c.UpdateHosts(newHosts)
 	refreshHostsConfig(c)
+	recordClusterHostChange(clusterName, addedHosts, removedHosts)
+	cm.notifyMemberUpdate(clusterName, addedHosts, removedHosts)
+	return nil
+}
+
+// recordClusterHostChange updates clusterName's ClusterChangeSummary with
+// the addresses just added and removed, preserving whatever ConfigChanged
+// a previous AddOrUpdatePrimaryCluster call recorded for it, so a
+// hosts-only push doesn't erase the last config diff.
+func recordClusterHostChange(clusterName string, addedHosts, removedHosts []types.Host) {
+	summary, _ := store.GetClusterChangeSummary(clusterName)
+	summary.ClusterName = clusterName
+	summary.Time = time.Now()
+	summary.HostsAdded = nil
+	for _, h := range addedHosts {
+		summary.HostsAdded = append(summary.HostsAdded, h.AddressString())
+	}
+	summary.HostsRemoved = nil
+	for _, h := range removedHosts {
+		summary.HostsRemoved = append(summary.HostsRemoved, h.AddressString())
+	}
+	store.SetClusterChangeSummary(summary)
+}
+
+// diffHosts applies add/remove to current, returning the resulting host
+// list plus the hosts actually added (new or changed) and removed. Hosts
+// named in neither add nor remove are passed through unchanged, keeping
+// their existing Host object. A host in add whose address already exists
+// in current and whose config is unchanged is also passed through as-is.
+func diffHosts(current []types.Host, add []v2.Host, remove []string, info types.ClusterInfo) (newHosts, addedHosts, removedHosts []types.Host) {
+	removeSet := make(map[string]struct{}, len(remove))
+	for _, addr := range remove {
+		removeSet[addr] = struct{}{}
+	}
+
+	kept := make(map[string]int, len(current)) // address -> index in newHosts
+	for _, h := range current {
+		if _, del := removeSet[h.AddressString()]; del {
+			removedHosts = append(removedHosts, h)
+			continue
+		}
+		kept[h.AddressString()] = len(newHosts)
+		newHosts = append(newHosts, h)
+	}
+
+	for _, hc := range add {
+		if i, ok := kept[hc.Address]; ok {
+			if !hostConfigChanged(newHosts[i], hc) {
+				continue
+			}
+			h := NewSimpleHost(hc, info)
+			newHosts[i] = h
+			addedHosts = append(addedHosts, h)
+			continue
+		}
+		h := NewSimpleHost(hc, info)
+		kept[hc.Address] = len(newHosts)
+		newHosts = append(newHosts, h)
+		addedHosts = append(addedHosts, h)
+	}
+	return
+}
+
+// hostConfigChanged reports whether hc describes a different host than
+// existing, so UpdateHosts knows whether it must replace the Host object
+// or can keep the existing one (and its connection pools) untouched.
+func hostConfigChanged(existing types.Host, hc v2.Host) bool {
+	return !reflect.DeepEqual(existing.Config(), hc)
+}
+
+// clusterSnapshot looks up clusterName's current snapshot, logging and
+// reporting not-found the same way every host-update entry point does.
+func (cm *clusterManager) clusterSnapshot(clusterName, caller string) (types.ClusterSnapshot, bool) {
+	ci, ok := cm.loadCluster(clusterName)
+	if !ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] %s cluster %s not found", caller, clusterName)
+		return nil, false
+	}
+	return ci.(types.Cluster).Snapshot(), true
+}
+
+// AddClusterMemberUpdateCb registers cb to be invoked after any host
+// update to clusterName that actually adds or removes hosts.
+func (cm *clusterManager) AddClusterMemberUpdateCb(clusterName string, cb types.MemberUpdateCb) error {
+	if !cm.ClusterExist(clusterName) {
+		return fmt.Errorf("cluster %s is not exists", clusterName)
+	}
+	v, _ := cm.memberUpdateCbs.LoadOrStore(clusterName, &memberUpdateCbList{})
+	list := v.(*memberUpdateCbList)
+	list.mutex.Lock()
+	list.cbs = append(list.cbs, cb)
+	list.mutex.Unlock()
 	return nil
 }
 
+func (cm *clusterManager) notifyMemberUpdate(clusterName string, add, remove []types.Host) {
+	if v, ok := cm.memberUpdateCbs.Load(clusterName); ok {
+		list := v.(*memberUpdateCbList)
+		list.mutex.Lock()
+		cbs := make([]types.MemberUpdateCb, len(list.cbs))
+		copy(cbs, list.cbs)
+		list.mutex.Unlock()
+		for _, cb := range cbs {
+			cb(add, remove)
+		}
+	}
+	for _, f := range cm.clusterManagerFilters() {
+		f.OnHostsChanged(clusterName, add, remove)
+	}
+}
+
+// RegisterClusterManagerFilter registers filter to be notified of cluster
+// add/remove and host set changes, in addition to the OnCreated callback
+// every ClusterManagerFilter already gets.
+func (cm *clusterManager) RegisterClusterManagerFilter(filter types.ClusterManagerFilter) {
+	cm.filtersMutex.Lock()
+	defer cm.filtersMutex.Unlock()
+	cm.filters = append(cm.filters, filter)
+}
+
+// clusterManagerFilters returns a snapshot of the registered
+// ClusterManagerFilters, safe to call without holding filtersMutex.
+func (cm *clusterManager) clusterManagerFilters() []types.ClusterManagerFilter {
+	cm.filtersMutex.Lock()
+	defer cm.filtersMutex.Unlock()
+	filters := make([]types.ClusterManagerFilter, len(cm.filters))
+	copy(filters, cm.filters)
+	return filters
+}
+
 // AppendClusterHosts adds new hosts into cluster
 func (cm *clusterManager) AppendClusterHosts(clusterName string, hostConfigs []v2.Host) error {
-	ci, ok := cm.clustersMap.Load(clusterName)
+	return cm.UpdateHosts(clusterName, hostConfigs, nil)
+}
+
+// RemoveClusterHosts removes hosts from cluster by address string. The
+// hosts are marked types.Draining immediately, so load balancers stop
+// picking them for new requests, but they are only actually removed from
+// the host set, and their connection pools only closed, once their
+// in-flight requests finish or the cluster's DrainTimeout elapses,
+// whichever comes first. See drainHosts.
+func (cm *clusterManager) RemoveClusterHosts(clusterName string, addrs []string) error {
+	snap, ok := cm.clusterSnapshot(clusterName, "RemoveClusterHosts")
 	if !ok {
-		log.DefaultLogger.Errorf("[upstream] [cluster manager] AppendClusterHosts cluster %s not found", clusterName)
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
-	c := ci.(types.Cluster)
-	snap := c.Snapshot()
-	hosts := make([]types.Host, 0, len(hostConfigs))
-	for _, hc := range hostConfigs {
-		hosts = append(hosts, NewSimpleHost(hc, snap.ClusterInfo()))
+
+	removeSet := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		removeSet[addr] = struct{}{}
 	}
-	hosts = append(hosts, snap.HostSet().Hosts()...)
-	c.UpdateHosts(hosts)
-	refreshHostsConfig(c)
+	var draining []types.Host
+	for _, h := range snap.HostSet().Hosts() {
+		if _, ok := removeSet[h.AddressString()]; ok {
+			h.SetHealthFlag(types.Draining)
+			draining = append(draining, h)
+		}
+	}
+	if len(draining) == 0 {
+		return nil
+	}
+
+	utils.GoWithRecover(func() {
+		cm.drainHosts(clusterName, snap.ClusterInfo(), draining, addrs)
+	}, nil)
 	return nil
 }
 
-// RemoveClusterHosts removes hosts from cluster by address string
-func (cm *clusterManager) RemoveClusterHosts(clusterName string, addrs []string) error {
-	ci, ok := cm.clustersMap.Load(clusterName)
+// drainHosts is run in the background by RemoveClusterHosts, after the
+// hosts have already been marked types.Draining so they stop receiving
+// new requests. It shuts down the draining hosts' connection pools so
+// they stop accepting new streams, waits for their in-flight requests to
+// finish up to the cluster's DrainTimeout, closes the pools, then removes
+// the hosts from the cluster's host set for real.
+func (cm *clusterManager) drainHosts(clusterName string, info types.ClusterInfo, draining []types.Host, addrs []string) {
+	pools := cm.takeConnectionPools(draining)
+	for _, pool := range pools {
+		pool.Shutdown()
+	}
+
+	activeRequests := func() int64 {
+		var n int64
+		for _, h := range draining {
+			n += h.HostStats().UpstreamRequestActive.Count()
+		}
+		return n
+	}
+
+	timeout := info.DrainTimeout()
+	deadline := time.Now().Add(timeout)
+	for activeRequests() > 0 && time.Now().Before(deadline) {
+		time.Sleep(drainPollInterval)
+	}
+	if n := activeRequests(); n > 0 {
+		log.DefaultLogger.Warnf("[upstream] [cluster manager] cluster %s drain timeout %s elapsed with %d active requests still outstanding on removed hosts %v, removing anyway", clusterName, timeout, n, addrs)
+	} else if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [cluster manager] cluster %s hosts %v drained", clusterName, addrs)
+	}
+
+	for _, pool := range pools {
+		pool.Close()
+	}
+
+	if err := cm.UpdateHosts(clusterName, nil, addrs); err != nil {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] cluster %s remove drained hosts %v failed: %v", clusterName, addrs, err)
+	}
+
+	// health flags are keyed globally by address (see GetHealthFlagPointer)
+	// and reused across hosts, so a host later re-added at one of these
+	// addresses must not come back marked Draining forever.
+	for _, h := range draining {
+		h.ClearHealthFlag(types.Draining)
+	}
+}
+
+// DrainConnections shuts down host's connection pools, across every
+// protocol, without touching the cluster's host set: host keeps its
+// current health state and load balancers can still pick it, but every
+// connection pooled for it right now is marked draining, see
+// types.ConnectionPool.Shutdown. Unlike RemoveClusterHosts/drainHosts, the
+// pools are not taken out of protocolConnPool, so a request landing on host
+// after this call still gets served, just over a fresh connection.
+func (cm *clusterManager) DrainConnections(clusterName string, addr string) error {
+	snap, ok := cm.clusterSnapshot(clusterName, "DrainConnections")
+	if !ok {
+		return fmt.Errorf("cluster %s is not exists", clusterName)
+	}
+
+	found := false
+	for _, h := range snap.HostSet().Hosts() {
+		if h.AddressString() == addr {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("host %s is not exists in cluster %s", addr, clusterName)
+	}
+
+	for _, pool := range cm.connectionPoolsForAddr(addr) {
+		pool.Shutdown()
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [cluster manager] cluster %s host %s connections marked draining", clusterName, addr)
+	}
+	return nil
+}
+
+// connectionPoolsForAddr returns every connection pool, across all
+// protocols, currently pooled for addr, without removing them from
+// protocolConnPool.
+func (cm *clusterManager) connectionPoolsForAddr(addr string) []types.ConnectionPool {
+	var pools []types.ConnectionPool
+	cm.protocolConnPool.Range(func(_, v interface{}) bool {
+		connectionPool := v.(*sync.Map)
+		if pv, ok := connectionPool.Load(addr); ok {
+			pools = append(pools, pv.(types.ConnectionPool))
+		}
+		return true
+	})
+	return pools
+}
+
+// SetHostHealth forces a host's active health check state, by address
+func (cm *clusterManager) SetHostHealth(clusterName string, addr string, healthy bool) error {
+	ci, ok := cm.loadCluster(clusterName)
 	if !ok {
-		log.DefaultLogger.Errorf("[upstream] [cluster manager] RemoveClusterHosts cluster %s not found", clusterName)
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] SetHostHealth cluster %s not found", clusterName)
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
 	snap := c.Snapshot()
+	for _, h := range snap.HostSet().Hosts() {
+		if h.AddressString() == addr {
+			if healthy {
+				h.ClearHealthFlag(api.FAILED_ACTIVE_HC)
+			} else {
+				h.SetHealthFlag(api.FAILED_ACTIVE_HC)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s is not exists in cluster %s", addr, clusterName)
+}
+
+// GetClusterHostsHealth returns the current health state of every host in cluster
+func (cm *clusterManager) GetClusterHostsHealth(clusterName string) ([]types.HostHealth, error) {
+	ci, ok := cm.loadCluster(clusterName)
+	if !ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] GetClusterHostsHealth cluster %s not found", clusterName)
+		return nil, fmt.Errorf("cluster %s is not exists", clusterName)
+	}
+	c := ci.(types.Cluster)
+	snap := c.Snapshot()
 	hosts := snap.HostSet().Hosts()
-	newHosts := make([]types.Host, len(hosts))
-	copy(newHosts, hosts)
-	sortedHosts := types.SortedHosts(newHosts)
-	sort.Sort(sortedHosts)
-	for _, addr := range addrs {
-		i := sort.Search(sortedHosts.Len(), func(i int) bool {
-			return sortedHosts[i].AddressString() >= addr
+	result := make([]types.HostHealth, 0, len(hosts))
+	for _, h := range hosts {
+		result = append(result, types.HostHealth{
+			Address: h.AddressString(),
+			Healthy: h.Health(),
+			Flags:   h.HealthFlag(),
 		})
-		// found it, delete it
-		if i < sortedHosts.Len() && sortedHosts[i].AddressString() == addr {
-			sortedHosts = append(sortedHosts[:i], sortedHosts[i+1:]...)
-		}
 	}
-	c.UpdateHosts(sortedHosts)
-	refreshHostsConfig(c)
+	return result, nil
+}
+
+// GetClusterOutlierState returns the current passive outlier detection
+// state of every host in cluster
+func (cm *clusterManager) GetClusterOutlierState(clusterName string) ([]types.OutlierHostState, error) {
+	ci, ok := cm.loadCluster(clusterName)
+	if !ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] GetClusterOutlierState cluster %s not found", clusterName)
+		return nil, fmt.Errorf("cluster %s is not exists", clusterName)
+	}
+	c := ci.(types.Cluster)
+	snap := c.Snapshot()
+	hosts := snap.HostSet().Hosts()
+	result := make([]types.OutlierHostState, 0, len(hosts))
+	for _, h := range hosts {
+		result = append(result, GetOutlierHostState(h))
+	}
+	return result, nil
+}
+
+// UpdateClusterCircuitBreakers updates cluster's circuit breaker thresholds
+// in place. It reuses the same sync path AddOrUpdatePrimaryCluster takes
+// when a config push changes a cluster's thresholds, but only touches the
+// ResourceManager's Max values, leaving the cluster, its hosts and its
+// connection pools untouched.
+func (cm *clusterManager) UpdateClusterCircuitBreakers(clusterName string, circuitBreakers v2.CircuitBreakers) error {
+	clusterName = cm.resolveClusterName(clusterName)
+	ci, ok := cm.clustersMap.Load(clusterName)
+	if !ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] UpdateClusterCircuitBreakers cluster %s not found", clusterName)
+		return fmt.Errorf("cluster %s is not exists", clusterName)
+	}
+	c := ci.(types.Cluster)
+	oldResourceManager := c.Snapshot().ClusterInfo().ResourceManager()
+	newResourceManager := NewResourceManager(clusterName, circuitBreakers)
+	updateResourceValue(oldResourceManager, newResourceManager)
+	store.SetCircuitBreakers(clusterName, circuitBreakers)
+	log.DefaultLogger.Infof("[upstream] [cluster manager] UpdateClusterCircuitBreakers cluster %s updated", clusterName)
 	return nil
 }
 
+// GetClustersSummary returns a config, circuit-breaker usage and per-host
+// summary of every cluster currently known to the cluster manager.
+func (cm *clusterManager) GetClustersSummary() []types.ClusterSummary {
+	var summaries []types.ClusterSummary
+	cm.clustersMap.Range(func(_, v interface{}) bool {
+		c := v.(types.Cluster)
+		snap := c.Snapshot()
+		info := snap.ClusterInfo()
+		rm := info.ResourceManager()
+
+		hosts := snap.HostSet().Hosts()
+		hostSummaries := make([]types.HostSummary, 0, len(hosts))
+		for _, h := range hosts {
+			stats := h.HostStats()
+			hostSummaries = append(hostSummaries, types.HostSummary{
+				Address:          h.AddressString(),
+				Weight:           h.Weight(),
+				Metadata:         types.HostMetadata(h.Metadata()),
+				Healthy:          h.Health(),
+				HealthFlags:      h.HealthFlag(),
+				RequestActive:    stats.UpstreamRequestActive.Count(),
+				RequestTotal:     stats.UpstreamRequestTotal.Count(),
+				ConnectionActive: stats.UpstreamConnectionActive.Count(),
+				ConnectionTotal:  stats.UpstreamConnectionTotal.Count(),
+			})
+		}
+
+		summaries = append(summaries, types.ClusterSummary{
+			Name:        info.Name(),
+			ClusterType: info.ClusterType(),
+			LbType:      info.LbType(),
+			Circuit: types.CircuitBreakerUse{
+				Connections:     types.ResourceUse{Current: rm.Connections().Cur(), Max: rm.Connections().Max()},
+				PendingRequests: types.ResourceUse{Current: rm.PendingRequests().Cur(), Max: rm.PendingRequests().Max()},
+				Requests:        types.ResourceUse{Current: rm.Requests().Cur(), Max: rm.Requests().Max()},
+				Retries:         types.ResourceUse{Current: rm.Retries().Cur(), Max: rm.Retries().Max()},
+			},
+			Hosts: hostSummaries,
+		})
+		return true
+	})
+	return summaries
+}
+
 // GetClusterSnapshot returns cluster snap
 // do not needs PutClusterSnapshot any more
 func (cm *clusterManager) GetClusterSnapshot(ctx context.Context, clusterName string) types.ClusterSnapshot {
+	return cm.getClusterSnapshot(ctx, clusterName, nil)
+}
+
+func (cm *clusterManager) getClusterSnapshot(ctx context.Context, clusterName string, visited map[string]struct{}) types.ClusterSnapshot {
+	clusterName = cm.resolveClusterName(clusterName)
 	ci, ok := cm.clustersMap.Load(clusterName)
 	if !ok {
 		return nil
 	}
-	return ci.(types.Cluster).Snapshot()
+	if _, warming := cm.warmingMap.Load(clusterName); warming {
+		return nil
+	}
+	snap := ci.(types.Cluster).Snapshot()
+	names := snap.ClusterInfo().AggregateClusterNames()
+	if len(names) == 0 {
+		return snap
+	}
+	if _, ok := visited[clusterName]; ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] aggregate cluster %s references itself, ignoring", clusterName)
+		return nil
+	}
+	if visited == nil {
+		visited = make(map[string]struct{})
+	}
+	visited[clusterName] = struct{}{}
+	return cm.resolveAggregateSnapshot(ctx, names, visited)
+}
+
+// resolveAggregateSnapshot walks an AGGREGATE cluster's ordered list of
+// underlying cluster names and returns the snapshot of the first one that
+// has a healthy host, falling back down the list when an earlier cluster
+// has none. If none of them do, it falls back to the first cluster that
+// resolved at all, so the aggregate cluster still routes somewhere instead
+// of going dark. This is what lets an aggregate cluster model primary/DR
+// datacenter failover without the router needing to know about the
+// underlying clusters.
+func (cm *clusterManager) resolveAggregateSnapshot(ctx context.Context, names []string, visited map[string]struct{}) types.ClusterSnapshot {
+	var fallback types.ClusterSnapshot
+	for _, name := range names {
+		snap := cm.getClusterSnapshot(ctx, name, visited)
+		if snap == nil {
+			continue
+		}
+		if fallback == nil {
+			fallback = snap
+		}
+		if hasHealthyHost(snap) {
+			return snap
+		}
+	}
+	return fallback
+}
+
+// hasHealthyHost reports whether a cluster snapshot has at least one host
+// that Host.Health() still considers healthy.
+func hasHealthyHost(snap types.ClusterSnapshot) bool {
+	for _, host := range snap.HostSet().Hosts() {
+		if host.Health() {
+			return true
+		}
+	}
+	return false
 }
 
 func (cm *clusterManager) PutClusterSnapshot(snap types.ClusterSnapshot) {
@@ -271,13 +989,47 @@ func (cm *clusterManager) TCPConnForCluster(lbCtx types.LoadBalancerContext, sna
 	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
 		return types.CreateConnectionData{}
 	}
-	host := snapshot.LoadBalancer().ChooseHost(lbCtx)
+	host := chooseHostWithOverride(snapshot.LoadBalancer(), lbCtx, snapshot)
 	if host == nil {
 		return types.CreateConnectionData{}
 	}
 	return host.CreateConnection(context.Background())
 }
 
+// hostOverrideFromHeaders returns the host in snapshot whose address matches
+// the types.HeaderUpstreamHostOverride header, if a stream filter set one.
+// It lets debugging tools and A/B test filters pin a request to a specific
+// upstream host, bypassing the configured load balancer for that request.
+func hostOverrideFromHeaders(lbCtx types.LoadBalancerContext, snapshot types.ClusterSnapshot) types.Host {
+	headers := lbCtx.DownstreamHeaders()
+	if headers == nil {
+		return nil
+	}
+	addr, ok := headers.Get(types.HeaderUpstreamHostOverride)
+	if !ok || addr == "" {
+		return nil
+	}
+	for _, host := range snapshot.HostSet().Hosts() {
+		if host.AddressString() == addr {
+			return host
+		}
+	}
+	log.DefaultLogger.Warnf("[upstream] [cluster manager] upstream host override %s not found in cluster %s, falling back to load balancer", addr, snapshot.ClusterInfo().Name())
+	return nil
+}
+
+// chooseHostWithOverride picks a host the same way ChooseHostAvoidingPrevious
+// does, unless the request carries a host override header, in which case
+// that host is used directly.
+func chooseHostWithOverride(lb types.LoadBalancer, lbCtx types.LoadBalancerContext, snapshot types.ClusterSnapshot) types.Host {
+	if lbCtx != nil {
+		if host := hostOverrideFromHeaders(lbCtx, snapshot); host != nil {
+			return host
+		}
+	}
+	return ChooseHostAvoidingPrevious(lb, lbCtx)
+}
+
 func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol types.ProtocolName) types.ConnectionPool {
 	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
 		log.DefaultLogger.Errorf("[upstream] [cluster manager]  %s ConnPool For Cluster is nil", protocol)
@@ -290,6 +1042,24 @@ func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerC
 	return pool
 }
 
+func (cm *clusterManager) BoundConnPoolForCluster(balancerContext types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol types.ProtocolName) types.ConnectionPool {
+	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] %s BoundConnPool For Cluster is nil", protocol)
+		return nil
+	}
+	factory, ok := network.ConnNewBoundPoolFactories[protocol]
+	if !ok {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] BoundConnPoolForCluster Failed; protocol %v is not registered as a bound pool factory", protocol)
+		return nil
+	}
+	host := chooseHostWithOverride(snapshot.LoadBalancer(), balancerContext, snapshot)
+	if host == nil {
+		log.DefaultLogger.Errorf("[upstream] [cluster manager] BoundConnPoolForCluster Failed; %v", errNilHostChoose)
+		return nil
+	}
+	return factory(host)
+}
+
 const (
 	maxHostsCounts  = 3
 	maxTryConnTimes = 7
@@ -302,7 +1072,7 @@ var (
 )
 
 func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBalancerContext, clusterSnapshot types.ClusterSnapshot, protocol types.ProtocolName) (types.ConnectionPool, error) {
-	factory, ok := network.ConnNewPoolFactories[protocol]
+	factory, ok := types.ConnPoolFactories[protocol]
 	if !ok {
 		return nil, fmt.Errorf("protocol %v is not registered is pool factory", protocol)
 	}
@@ -317,7 +1087,7 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 		try = maxHostsCounts
 	}
 	for i := 0; i < try; i++ {
-		host := clusterSnapshot.LoadBalancer().ChooseHost(balancerContext)
+		host := chooseHostWithOverride(clusterSnapshot.LoadBalancer(), balancerContext, clusterSnapshot)
 		if host == nil {
 			return nil, errNilHostChoose
 		}
@@ -345,7 +1115,7 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 				pool := connPool.(types.ConnectionPool)
 				return pool, true
 			}
-			pool := factory(host)
+			pool := factory(balancerContext.DownstreamContext(), host)
 			connectionPool.Store(addr, pool)
 			return pool, false
 		}
@@ -368,7 +1138,7 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 						}
 						connectionPool.Delete(addr)
 						pool.Shutdown()
-						pool = factory(host)
+						pool = factory(balancerContext.DownstreamContext(), host)
 						connectionPool.Store(addr, pool)
 					}
 				}()
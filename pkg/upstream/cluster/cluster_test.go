@@ -1,10 +1,12 @@
 package cluster
 
 import (
+	"sync"
 	"testing"
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -108,6 +110,52 @@ func TestClusterUpdateHosts(t *testing.T) {
 	}
 }
 
+// TestClusterSnapshotCopyOnWrite verifies that a cluster's snapshot is an
+// immutable copy swapped atomically on update, so a reader never sees a
+// partially updated host set and previously taken snapshots are unaffected
+// by later updates, even when reads and updates race.
+func TestClusterSnapshotCopyOnWrite(t *testing.T) {
+	cluster := _createTestCluster()
+	pool := makePool(100)
+	cluster.UpdateHosts(pool.MakeHosts(10, api.Metadata{"version": "1"}))
+
+	oldSnap := cluster.Snapshot()
+	if len(oldSnap.HostSet().Hosts()) != 10 {
+		t.Fatalf("expected 10 hosts in the original snapshot, got %d", len(oldSnap.HostSet().Hosts()))
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				// reads must never observe a lock and never block the writer
+				if snap := cluster.Snapshot(); snap == nil {
+					t.Error("snapshot must never be nil once hosts have been set")
+				}
+			}
+		}
+	}()
+
+	cluster.UpdateHosts(pool.MakeHosts(20, api.Metadata{"version": "2"}))
+	close(stop)
+	wg.Wait()
+
+	// the snapshot taken before the update must be untouched
+	if len(oldSnap.HostSet().Hosts()) != 10 {
+		t.Fatalf("old snapshot was mutated by a later update, now has %d hosts", len(oldSnap.HostSet().Hosts()))
+	}
+	newSnap := cluster.Snapshot()
+	if len(newSnap.HostSet().Hosts()) != 20 {
+		t.Fatalf("expected 20 hosts after update, got %d", len(newSnap.HostSet().Hosts()))
+	}
+}
+
 func TestUpdateHostLabels(t *testing.T) {
 	cluster := _createTestCluster()
 	host := &mockHost{
@@ -164,3 +212,113 @@ func TestUpdateHostLabels(t *testing.T) {
 		}
 	}
 }
+
+// TestClusterUpdateHostMetadata verifies that UpdateHostMetadata mutates a
+// host's metadata without recreating it (stats and health state stay tied
+// to the same object) and reindexes the subset load balancer so the host
+// is routed under its new subset.
+func TestClusterUpdateHostMetadata(t *testing.T) {
+	cluster := _createTestCluster()
+	info := cluster.Snapshot().ClusterInfo()
+	host := NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:8080",
+		},
+		MetaData: api.Metadata{"version": "1"},
+	}, info)
+	cluster.UpdateHosts([]types.Host{host})
+
+	// unknown address is rejected
+	if cluster.UpdateHostMetadata("127.0.0.1:9999", api.Metadata{"version": "2"}) {
+		t.Fatal("expected UpdateHostMetadata to fail for an unknown address")
+	}
+
+	beforeSnap := cluster.Snapshot()
+	beforeHost := beforeSnap.HostSet().Hosts()[0]
+
+	if !cluster.UpdateHostMetadata("127.0.0.1:8080", api.Metadata{"version": "2", "zone": "a"}) {
+		t.Fatal("expected UpdateHostMetadata to succeed for a known address")
+	}
+
+	afterSnap := cluster.Snapshot()
+	afterHost := afterSnap.HostSet().Hosts()[0]
+	if beforeHost != afterHost {
+		t.Fatal("expected the same Host object after UpdateHostMetadata, host identity should not change")
+	}
+	if afterHost.Metadata()["version"] != "2" {
+		t.Fatalf("expected metadata to be updated in place, got %v", afterHost.Metadata())
+	}
+
+	// the subset load balancer must be reindexed against the new metadata
+	newSubLb := afterSnap.LoadBalancer().(*subsetLoadBalancer)
+	result := &subSetMapResult{
+		result: map[string][]string{},
+	}
+	result.RangeSubsetMap("", newSubLb.subSets)
+	expectedResult := map[string]int{
+		"version->2->":          1,
+		"version->2->zone->a->": 1,
+	}
+	if len(result.result) != len(expectedResult) {
+		t.Fatalf("expected %d subsets after metadata update, got %d", len(expectedResult), len(result.result))
+	}
+	for p, count := range expectedResult {
+		sub, ok := result.result[p]
+		if !ok || len(sub) != count {
+			t.Fatalf("%s is not expected, exists: %v, count: %d", p, ok, len(sub))
+		}
+	}
+}
+
+func TestResolveUpstreamProtocol(t *testing.T) {
+	cases := []struct {
+		name     string
+		cluster  v2.Cluster
+		expected types.ProtocolName
+	}{
+		{
+			name:     "explicit protocol passes through",
+			cluster:  v2.Cluster{UpstreamProtocol: "Http2"},
+			expected: protocol.HTTP2,
+		},
+		{
+			name:     "unset defers to the caller",
+			cluster:  v2.Cluster{},
+			expected: "",
+		},
+		{
+			name:     "auto without alpn defers to the caller",
+			cluster:  v2.Cluster{UpstreamProtocol: "Auto"},
+			expected: "",
+		},
+		{
+			name: "auto with h2 and http/1.1 alpn prefers h2",
+			cluster: v2.Cluster{
+				UpstreamProtocol: "Auto",
+				TLS:              v2.TLSConfig{ALPN: "h2,http/1.1"},
+			},
+			expected: protocol.HTTP2,
+		},
+		{
+			name: "auto with only http/1.1 alpn",
+			cluster: v2.Cluster{
+				UpstreamProtocol: "Auto",
+				TLS:              v2.TLSConfig{ALPN: "http/1.1"},
+			},
+			expected: protocol.HTTP1,
+		},
+		{
+			name: "auto with unrecognized alpn defers to the caller",
+			cluster: v2.Cluster{
+				UpstreamProtocol: "Auto",
+				TLS:              v2.TLSConfig{ALPN: "spdy/3.1"},
+			},
+			expected: "",
+		},
+	}
+	for _, c := range cases {
+		if got := resolveUpstreamProtocol(c.cluster); got != c.expected {
+			t.Errorf("%s: resolveUpstreamProtocol() = %q, want %q", c.name, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	RegisterLBType(types.DynamicForwardProxy, newDynamicForwardProxyLoadBalancer)
+}
+
+// DefaultDynamicForwardProxyHostTTL bounds how long an on-demand host
+// created by DynamicForwardProxyLoadBalancer is kept after it was last
+// used, before being garbage collected.
+var DefaultDynamicForwardProxyHostTTL = 5 * time.Minute
+
+// dynamicForwardProxyGCInterval bounds how often ChooseHost sweeps expired
+// entries: sweeping on every call would cost a full map scan per request,
+// so it is amortized to at most once per interval.
+const dynamicForwardProxyGCInterval = time.Minute
+
+type dfpHostEntry struct {
+	host       types.Host
+	lastAccess time.Time
+}
+
+// DynamicForwardProxyLoadBalancer has no statically configured hosts: for
+// every distinct Host header it sees, it resolves and caches a host on
+// demand (the host's address resolution itself is cached and refreshed by
+// the shared DNS cache used by every simpleHost, GetOrCreateAddr/AddrStore).
+// Cache entries unused for longer than DefaultDynamicForwardProxyHostTTL
+// are garbage collected so egress to arbitrary domains doesn't grow this
+// cache without bound.
+type DynamicForwardProxyLoadBalancer struct {
+	mutex     sync.Mutex
+	host      map[string]*dfpHostEntry
+	lastSweep time.Time
+}
+
+func newDynamicForwardProxyLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	return &DynamicForwardProxyLoadBalancer{
+		host: make(map[string]*dfpHostEntry),
+	}
+}
+
+func (lb *DynamicForwardProxyLoadBalancer) ChooseHost(lbCtx types.LoadBalancerContext) types.Host {
+	headers := lbCtx.DownstreamHeaders()
+	if headers == nil {
+		return nil
+	}
+	hostHeader, ok := headers.Get("host")
+	if !ok || hostHeader == "" {
+		return nil
+	}
+	if !strings.Contains(hostHeader, ":") {
+		hostHeader = hostHeader + ":80"
+	}
+	cluster := lbCtx.DownstreamCluster()
+
+	now := time.Now()
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.sweep(now)
+
+	entry, ok := lb.host[hostHeader]
+	if !ok {
+		config := v2.Host{
+			HostConfig: v2.HostConfig{
+				Address:  hostHeader,
+				Hostname: hostHeader,
+			},
+		}
+		entry = &dfpHostEntry{host: NewSimpleHost(config, cluster)}
+		lb.host[hostHeader] = entry
+	}
+	entry.lastAccess = now
+	return entry.host
+}
+
+// sweep removes cache entries unused for longer than
+// DefaultDynamicForwardProxyHostTTL. It runs inline from ChooseHost, at
+// most once per dynamicForwardProxyGCInterval, rather than as a background
+// goroutine: the load balancer is recreated on every UpdateHosts, and
+// there is no hook to stop a background goroutine when that happens.
+func (lb *DynamicForwardProxyLoadBalancer) sweep(now time.Time) {
+	if now.Sub(lb.lastSweep) < dynamicForwardProxyGCInterval {
+		return
+	}
+	lb.lastSweep = now
+	for hostHeader, entry := range lb.host {
+		if now.Sub(entry.lastAccess) > DefaultDynamicForwardProxyHostTTL {
+			delete(lb.host, hostHeader)
+		}
+	}
+}
+
+func (lb *DynamicForwardProxyLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return true
+}
+
+func (lb *DynamicForwardProxyLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return 1
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestDynamicForwardProxyChooseHost(t *testing.T) {
+	hostSet := &hostSet{}
+	lb := newDynamicForwardProxyLoadBalancer(nil, hostSet)
+
+	cluster := &clusterInfo{
+		name:   "testDynamicForwardProxy",
+		lbType: types.DynamicForwardProxy,
+	}
+
+	lbCtx := &LbCtx{
+		cluster: cluster,
+		headers: &Header{v: map[string]string{"host": "example.com"}},
+	}
+
+	host := lb.ChooseHost(lbCtx)
+	if host == nil || host.AddressString() != "example.com:80" {
+		t.Fatalf("expected host created on demand for the host header, got %v", host)
+	}
+
+	// a second request for the same Host header must reuse the cached host
+	host2 := lb.ChooseHost(lbCtx)
+	if host2 != host {
+		t.Fatalf("expected cached host to be reused for the same Host header")
+	}
+
+	// a different Host header must get its own host, without losing the first
+	lbCtx2 := &LbCtx{
+		cluster: cluster,
+		headers: &Header{v: map[string]string{"host": "other.com:8080"}},
+	}
+	host3 := lb.ChooseHost(lbCtx2)
+	if host3 == nil || host3.AddressString() != "other.com:8080" {
+		t.Fatalf("expected a distinct host for a different Host header, got %v", host3)
+	}
+
+	dfplb := lb.(*DynamicForwardProxyLoadBalancer)
+	if len(dfplb.host) != 2 {
+		t.Fatalf("expected 2 cached hosts, got %d", len(dfplb.host))
+	}
+
+	// entries unused past the TTL are garbage collected on the next sweep
+	dfplb.host["example.com:80"].lastAccess = time.Now().Add(-2 * DefaultDynamicForwardProxyHostTTL)
+	dfplb.lastSweep = time.Time{}
+	dfplb.sweep(time.Now())
+	if _, ok := dfplb.host["example.com:80"]; ok {
+		t.Fatal("expected expired host entry to be garbage collected")
+	}
+	if _, ok := dfplb.host["other.com:8080"]; !ok {
+		t.Fatal("expected unexpired host entry to survive the sweep")
+	}
+}
+
+func TestDynamicForwardProxyChooseHostNoHeader(t *testing.T) {
+	hostSet := &hostSet{}
+	lb := newDynamicForwardProxyLoadBalancer(nil, hostSet)
+	lbCtx := &LbCtx{cluster: &clusterInfo{name: "testDynamicForwardProxy"}}
+	if host := lb.ChooseHost(lbCtx); host != nil {
+		t.Fatalf("expected no host without a Host header, got %v", host)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	// ewmaRefreshInterval is how often host latency is resampled and the
+	// EDF scheduler weights are rebuilt.
+	ewmaRefreshInterval = time.Second * 5
+	// ewmaDecay is the smoothing factor applied on every refresh: the new
+	// sample contributes ewmaDecay of the updated average.
+	ewmaDecay = 0.3
+	// defaultLatencyMs is the EWMA seed for a host with no samples yet, so
+	// that new hosts are not flooded with traffic before they have data.
+	defaultLatencyMs = 1.0
+)
+
+// ewmaLoadBalancer picks hosts inversely weighted by an exponentially
+// weighted moving average of their observed request latency, so hosts that
+// are slowing down gradually receive less traffic without needing to wait
+// for health-check failure.
+type ewmaLoadBalancer struct {
+	*EdfLoadBalancer
+
+	mutex sync.RWMutex
+	ewma  map[string]float64
+
+	stop chan struct{}
+}
+
+func newEwmaLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	lb := &ewmaLoadBalancer{
+		ewma: make(map[string]float64),
+		stop: make(chan struct{}),
+	}
+	lb.EdfLoadBalancer = newEdfLoadBalancerLoadBalancer(hosts, lb.unweightChooseHost, lb.hostWeight)
+	lb.sample()
+
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(ewmaRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				lb.sample()
+				lb.refresh(lb.hosts.Hosts())
+			case <-lb.stop:
+				return
+			}
+		}
+	}, nil)
+
+	return lb
+}
+
+// sample refreshes the EWMA latency for every current host from its
+// HostStats.UpstreamRequestDuration histogram.
+func (lb *ewmaLoadBalancer) sample() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	for _, host := range lb.hosts.Hosts() {
+		addr := host.AddressString()
+		meanMs := float64(host.HostStats().UpstreamRequestDuration.Mean()) / float64(time.Millisecond)
+		if meanMs <= 0 {
+			meanMs = defaultLatencyMs
+		}
+		if prev, ok := lb.ewma[addr]; ok {
+			lb.ewma[addr] = prev*(1-ewmaDecay) + meanMs*ewmaDecay
+		} else {
+			lb.ewma[addr] = meanMs
+		}
+	}
+}
+
+func (lb *ewmaLoadBalancer) hostWeight(item WeightItem) float64 {
+	host := item.(types.Host)
+	lb.mutex.RLock()
+	latency, ok := lb.ewma[host.AddressString()]
+	lb.mutex.RUnlock()
+	if !ok || latency <= 0 {
+		latency = defaultLatencyMs
+	}
+	return float64(host.Weight()) / latency
+}
+
+func (lb *ewmaLoadBalancer) unweightChooseHost(context types.LoadBalancerContext) types.Host {
+	targets := lb.hosts.Hosts()
+	total := len(targets)
+	if total == 0 {
+		return nil
+	}
+	lb.mutex.Lock()
+	idx := lb.rand.Intn(total)
+	lb.mutex.Unlock()
+	return targets[idx]
+}
+
+func (lb *ewmaLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *ewmaLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
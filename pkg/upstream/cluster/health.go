@@ -54,3 +54,33 @@ func ClearHealthFlag(p *uint64, flag api.HealthFlag) {
 	f &= ^uint64(flag)
 	atomic.StoreUint64(p, f)
 }
+
+// DumpHealthStore snapshots the current health flags of every known address.
+// It is used to carry health state across a hot restart, so the new process
+// does not have to re-learn it from scratch via health checking.
+func DumpHealthStore() map[string]api.HealthFlag {
+	snapshot := make(map[string]api.HealthFlag)
+	healthStore.Range(func(key, value interface{}) bool {
+		addr, ok := key.(string)
+		if !ok {
+			return true
+		}
+		p, ok := value.(*uint64)
+		if !ok {
+			return true
+		}
+		snapshot[addr] = api.HealthFlag(atomic.LoadUint64(p))
+		return true
+	})
+	return snapshot
+}
+
+// LoadHealthStore restores health flags from a snapshot produced by
+// DumpHealthStore. It can be called before any host exists: GetHealthFlagPointer
+// reuses whatever pointer is already stored for an address, so hosts created
+// afterwards transparently pick up the restored flags.
+func LoadHealthStore(snapshot map[string]api.HealthFlag) {
+	for addr, flag := range snapshot {
+		atomic.StoreUint64(GetHealthFlagPointer(addr), uint64(flag))
+	}
+}
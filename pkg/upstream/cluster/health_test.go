@@ -99,3 +99,34 @@ func TestHealthFlagShare(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestHealthStoreDumpLoad(t *testing.T) {
+	// clear health store
+	healthStore = sync.Map{}
+
+	addr := "127.0.0.1:8080"
+	testHost := &simpleHost{
+		healthFlags: GetHealthFlagPointer(addr),
+	}
+	testHost.SetHealthFlag(api.FAILED_ACTIVE_HC)
+
+	snapshot := DumpHealthStore()
+	if snapshot[addr] != api.FAILED_ACTIVE_HC {
+		t.Fatalf("unexpected dumped flag: %v", snapshot[addr])
+	}
+
+	// simulate a hot restart: a fresh store, then restore from the snapshot
+	// before any host is created for addr
+	healthStore = sync.Map{}
+	LoadHealthStore(snapshot)
+
+	restoredHost := &simpleHost{
+		healthFlags: GetHealthFlagPointer(addr),
+	}
+	if restoredHost.Health() {
+		t.Fatal("restored host should be unhealthy")
+	}
+	if !restoredHost.ContainHealthFlag(api.FAILED_ACTIVE_HC) {
+		t.Fatal("restored host should contain failed active flag")
+	}
+}
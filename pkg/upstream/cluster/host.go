@@ -19,7 +19,11 @@ package cluster
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"net"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -33,30 +37,37 @@ import (
 
 // simpleHost is an implement of types.Host and types.HostInfo
 type simpleHost struct {
-	hostname      string
-	addressString string
-	clusterInfo   types.ClusterInfo
-	stats         types.HostStats
-	metaData      api.Metadata
-	tlsDisable    bool
-	weight        uint32
-	healthFlags   *uint64
+	hostname          string
+	addressString     string
+	clusterInfo       types.ClusterInfo
+	stats             types.HostStats
+	metaData          atomic.Value // api.Metadata
+	tlsDisable        bool
+	weight            uint32
+	healthFlags       *uint64
+	healthCheckConfig v2.HostHealthCheckConfig
+	locality          string
+	maxConnections    uint32
 }
 
 func NewSimpleHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
 	// clusterInfo should not be nil
 	// pre resolve address
 	GetOrCreateAddr(config.Address)
-	return &simpleHost{
-		hostname:      config.Hostname,
-		addressString: config.Address,
-		clusterInfo:   clusterInfo,
-		stats:         newHostStats(clusterInfo.Name(), config.Address),
-		metaData:      config.MetaData,
-		tlsDisable:    config.TLSDisable,
-		weight:        config.Weight,
-		healthFlags:   GetHealthFlagPointer(config.Address),
+	sh := &simpleHost{
+		hostname:          config.Hostname,
+		addressString:     config.Address,
+		clusterInfo:       clusterInfo,
+		stats:             newHostStats(clusterInfo.Name(), config.Address),
+		tlsDisable:        config.TLSDisable,
+		weight:            config.Weight,
+		healthFlags:       GetHealthFlagPointer(config.Address),
+		healthCheckConfig: config.HealthCheckConfig,
+		locality:          config.Locality,
+		maxConnections:    config.MaxConnections,
 	}
+	sh.metaData.Store(config.MetaData)
+	return sh
 }
 
 // types.HostInfo Implement
@@ -64,8 +75,27 @@ func (sh *simpleHost) Hostname() string {
 	return sh.hostname
 }
 
+// types.Host Implement
+func (sh *simpleHost) Locality() string {
+	return sh.locality
+}
+
+// types.Host Implement
+func (sh *simpleHost) MaxConnections() uint32 {
+	return sh.maxConnections
+}
+
 func (sh *simpleHost) Metadata() api.Metadata {
-	return sh.metaData
+	meta, _ := sh.metaData.Load().(api.Metadata)
+	return meta
+}
+
+// UpdateMetadata swaps the host's metadata in place, without touching its
+// address, stats or health check state. Safe for concurrent use with
+// Metadata() and everything derived from it (SupportTLS, CreateConnection,
+// Config), since metaData is stored behind an atomic.Value.
+func (sh *simpleHost) UpdateMetadata(meta api.Metadata) {
+	sh.metaData.Store(meta)
 }
 
 func (sh *simpleHost) ClusterInfo() types.ClusterInfo {
@@ -91,27 +121,36 @@ func (sh *simpleHost) Weight() uint32 {
 func (sh *simpleHost) Config() v2.Host {
 	return v2.Host{
 		HostConfig: v2.HostConfig{
-			Address:    sh.addressString,
-			Hostname:   sh.hostname,
-			TLSDisable: sh.tlsDisable,
-			Weight:     sh.weight,
+			Address:           sh.addressString,
+			Hostname:          sh.hostname,
+			TLSDisable:        sh.tlsDisable,
+			Weight:            sh.weight,
+			HealthCheckConfig: sh.healthCheckConfig,
+			Locality:          sh.locality,
+			MaxConnections:    sh.maxConnections,
 		},
-		MetaData: sh.metaData,
+		MetaData: sh.Metadata(),
 	}
 }
 
 func (sh *simpleHost) SupportTLS() bool {
-	return IsSupportTLS() && !sh.tlsDisable && sh.clusterInfo.TLSMng().Enabled()
+	return IsSupportTLS() && !sh.tlsDisable && sh.clusterInfo.TLSMngByMetadata(sh.Metadata()).Enabled()
 }
 
 // types.Host Implement
 func (sh *simpleHost) CreateConnection(context context.Context) types.CreateConnectionData {
 	var tlsMng types.TLSContextManager
 	if sh.SupportTLS() {
-		tlsMng = sh.clusterInfo.TLSMng()
+		tlsMng = sh.clusterInfo.TLSMngByMetadata(sh.Metadata())
+	}
+	connOptions := sh.clusterInfo.ConnectionOptions()
+	sourceAddr := resolveSourceAddr(connOptions.SourceAddress)
+	clientConn := network.NewClientConnection(sourceAddr, sh.clusterInfo.ConnectTimeout(), tlsMng, sh.Address(), nil)
+	if candidates := GetOrCreateHappyEyeballsAddrs(sh.addressString); len(candidates) > 1 {
+		clientConn.SetRemoteAddrCandidates(candidates)
 	}
-	clientConn := network.NewClientConnection(nil, sh.clusterInfo.ConnectTimeout(), tlsMng, sh.Address(), nil)
 	clientConn.SetBufferLimit(sh.clusterInfo.ConnBufferLimitBytes())
+	clientConn.SetConnectionOptions(connOptions)
 
 	return types.CreateConnectionData{
 		Connection: clientConn,
@@ -119,6 +158,59 @@ func (sh *simpleHost) CreateConnection(context context.Context) types.CreateConn
 	}
 }
 
+// resolveSourceAddr turns a cluster's configured SourceAddress into a
+// net.Addr for NewClientConnection's sourceAddr parameter, so the dialer
+// binds the connection's local address before connecting. The port may be
+// a single value or a "start-end" range, in which case a random port from
+// the range is picked for this connection. Returns nil (let the operating
+// system choose) when address is empty or unparsable.
+func resolveSourceAddr(address string) net.Addr {
+	if address == "" {
+		return nil
+	}
+	if !strings.Contains(address, ":") {
+		address += ":0"
+	}
+	host, portRange, err := net.SplitHostPort(address)
+	if err != nil {
+		log.DefaultLogger.Errorf("[upstream] [host] invalid upstream_connection_options.source_address %q, error: %v", address, err)
+		return nil
+	}
+	port, err := resolveSourcePort(portRange)
+	if err != nil {
+		log.DefaultLogger.Errorf("[upstream] [host] invalid upstream_connection_options.source_address %q, error: %v", address, err)
+		return nil
+	}
+	addr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		log.DefaultLogger.Errorf("[upstream] [host] invalid upstream_connection_options.source_address %q, error: %v", address, err)
+		return nil
+	}
+	return addr
+}
+
+// resolveSourcePort parses a bind port, which is either a single number or
+// a "start-end" range. A range yields a random port from within it, so
+// repeated dials from this host spread across the range instead of all
+// competing for the same ephemeral port.
+func resolveSourcePort(port string) (int, error) {
+	if i := strings.IndexByte(port, '-'); i >= 0 {
+		start, err := strconv.Atoi(port[:i])
+		if err != nil {
+			return 0, err
+		}
+		end, err := strconv.Atoi(port[i+1:])
+		if err != nil {
+			return 0, err
+		}
+		if end < start {
+			return 0, fmt.Errorf("port range end %d is before start %d", end, start)
+		}
+		return start + rand.Intn(end-start+1), nil
+	}
+	return strconv.Atoi(port)
+}
+
 func (sh *simpleHost) ClearHealthFlag(flag api.HealthFlag) {
 	ClearHealthFlag(sh.healthFlags, flag)
 }
@@ -135,15 +227,32 @@ func (sh *simpleHost) HealthFlag() api.HealthFlag {
 	return api.HealthFlag(atomic.LoadUint64(sh.healthFlags))
 }
 
+// Health reports whether the host is healthy, ignoring DegradedActiveHC: a
+// degraded host still passes health checks, it just gets deprioritized by
+// degraded-aware load balancers via HostSet.DegradedHosts().
 func (sh *simpleHost) Health() bool {
-	return atomic.LoadUint64(sh.healthFlags) == 0
+	return atomic.LoadUint64(sh.healthFlags)&^uint64(types.DegradedActiveHC) == 0
 }
 
+// unixAddrPrefix marks a v2.Host.Address as a unix domain socket path
+// instead of a host:port, e.g. "unix:///var/run/app.sock", so a sidecar can
+// reach a co-located process without the overhead of a loopback TCP
+// connection.
+const unixAddrPrefix = "unix://"
+
 // net.Addr reuse for same address, valid in simple type
 // Update DNS cache using asynchronous mode
 var AddrStore *utils.ExpiredMap = utils.NewExpiredMap(
 	func(key interface{}) (interface{}, bool) {
-		addr, err := net.ResolveTCPAddr("tcp", key.(string))
+		addrstr := key.(string)
+		if strings.HasPrefix(addrstr, unixAddrPrefix) {
+			// a unix socket path is set with utils.NeverExpire and never
+			// goes through the update handler, but handle it here too in
+			// case that ever changes
+			addr, err := net.ResolveUnixAddr("unix", strings.TrimPrefix(addrstr, unixAddrPrefix))
+			return addr, err == nil
+		}
+		addr, err := net.ResolveTCPAddr("tcp", addrstr)
 		if err == nil {
 			return addr, true
 		}
@@ -156,6 +265,18 @@ func GetOrCreateAddr(addrstr string) net.Addr {
 		return addr.(net.Addr)
 	}
 
+	if strings.HasPrefix(addrstr, unixAddrPrefix) {
+		addr, err := net.ResolveUnixAddr("unix", strings.TrimPrefix(addrstr, unixAddrPrefix))
+		if err != nil {
+			log.DefaultLogger.Errorf("[upstream] resolve addr %s failed: %v", addrstr, err)
+			return nil
+		}
+		// a unix socket path never expires: there's no DNS behind it to
+		// re-resolve
+		AddrStore.Set(addrstr, addr, utils.NeverExpire)
+		return addr
+	}
+
 	addr, err := net.ResolveTCPAddr("tcp", addrstr)
 	if err != nil {
 		log.DefaultLogger.Errorf("[upstream] resolve addr %s failed: %v", addrstr, err)
@@ -173,3 +294,116 @@ func GetOrCreateAddr(addrstr string) net.Addr {
 
 	return addr
 }
+
+// happyEyeballsAddrStore caches every address a host currently resolves to,
+// refreshed on the same schedule as AddrStore, so CreateConnection doesn't
+// re-resolve on every new connection just to find address families to race.
+var happyEyeballsAddrStore *utils.ExpiredMap = utils.NewExpiredMap(
+	func(key interface{}) (interface{}, bool) {
+		addrs, err := resolveAllAddrs(key.(string))
+		if err != nil {
+			return nil, false
+		}
+		return addrs, true
+	}, false)
+
+// resolveAllAddrs resolves every address addrstr's host currently maps to,
+// interleaved by address family per RFC 8305 section 4, so a dual-stack
+// host tries both families early instead of exhausting one before the
+// other.
+func resolveAllAddrs(addrstr string) ([]net.Addr, error) {
+	host, port, err := net.SplitHostPort(addrstr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(context.Background(), host)
+	if err != nil {
+		return nil, err
+	}
+	p, err := net.LookupPort("tcp", port)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]net.Addr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, &net.TCPAddr{IP: ip.IP, Port: p, Zone: ip.Zone})
+	}
+	return interleaveAddrFamilies(addrs), nil
+}
+
+// interleaveAddrFamilies reorders addrs by alternating address families,
+// starting with whichever family the resolver returned first, per RFC 8305
+// section 4.
+func interleaveAddrFamilies(addrs []net.Addr) []net.Addr {
+	if len(addrs) < 2 {
+		return addrs
+	}
+
+	firstIsV6 := isIPv6Addr(addrs[0])
+	var sameFamily, otherFamily []net.Addr
+	for _, addr := range addrs {
+		if isIPv6Addr(addr) == firstIsV6 {
+			sameFamily = append(sameFamily, addr)
+		} else {
+			otherFamily = append(otherFamily, addr)
+		}
+	}
+
+	interleaved := make([]net.Addr, 0, len(addrs))
+	for i := 0; i < len(sameFamily) || i < len(otherFamily); i++ {
+		if i < len(sameFamily) {
+			interleaved = append(interleaved, sameFamily[i])
+		}
+		if i < len(otherFamily) {
+			interleaved = append(interleaved, otherFamily[i])
+		}
+	}
+	return interleaved
+}
+
+func isIPv6Addr(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.To4() == nil
+}
+
+// GetOrCreateHappyEyeballsAddrs returns every address addrstr's host
+// currently resolves to, for CreateConnection to race per RFC 8305. A
+// literal IP address, a unix domain socket path, or a hostname that only
+// resolves to a single address family, returns a single-element slice:
+// there is nothing to race, and callers dial that one address as before.
+func GetOrCreateHappyEyeballsAddrs(addrstr string) []net.Addr {
+	if strings.HasPrefix(addrstr, unixAddrPrefix) {
+		if addr := GetOrCreateAddr(addrstr); addr != nil {
+			return []net.Addr{addr}
+		}
+		return nil
+	}
+
+	if addrs, _ := happyEyeballsAddrStore.Get(addrstr); addrs != nil {
+		return addrs.([]net.Addr)
+	}
+
+	host, _, err := net.SplitHostPort(addrstr)
+	if err != nil {
+		return nil
+	}
+
+	addrs, err := resolveAllAddrs(addrstr)
+	if err != nil || len(addrs) == 0 {
+		if addr := GetOrCreateAddr(addrstr); addr != nil {
+			return []net.Addr{addr}
+		}
+		return nil
+	}
+
+	if net.ParseIP(host) != nil {
+		// literal IP address, don't set an expire time
+		happyEyeballsAddrStore.Set(addrstr, addrs, utils.NeverExpire)
+	} else {
+		// TODO support config or depends on DNS TTL for expire time, same as
+		// GetOrCreateAddr
+		happyEyeballsAddrStore.Set(addrstr, addrs, 15*time.Second)
+	}
+	return addrs
+}
@@ -20,6 +20,7 @@ package cluster
 import (
 	"sync"
 
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 )
@@ -36,6 +37,54 @@ func (hs *hostSet) Hosts() []types.Host {
 	return hs.allHosts
 }
 
+// DegradedHosts returns the hosts flagged types.DegradedActiveHC.
+func (hs *hostSet) DegradedHosts() []types.Host {
+	return degradedHosts(hs.allHosts)
+}
+
+// degradedHosts filters hosts down to the ones flagged types.DegradedActiveHC.
+func degradedHosts(hosts []types.Host) []types.Host {
+	var degraded []types.Host
+	for _, h := range hosts {
+		if h.ContainHealthFlag(types.DegradedActiveHC) {
+			degraded = append(degraded, h)
+		}
+	}
+	return degraded
+}
+
+// metadataUpdater is implemented by Host types that support in-place
+// metadata mutation via HostSet.UpdateHostMetadata.
+type metadataUpdater interface {
+	UpdateMetadata(api.Metadata)
+}
+
+// updateHostMetadata finds the host at addr in hosts and mutates its
+// metadata in place, leaving its identity (and therefore its stats and
+// health state) untouched. Returns false if addr isn't found, or the host
+// found doesn't support metadata mutation.
+func updateHostMetadata(hosts []types.Host, addr string, meta api.Metadata) bool {
+	for _, h := range hosts {
+		if h.AddressString() != addr {
+			continue
+		}
+		mu, ok := h.(metadataUpdater)
+		if !ok {
+			return false
+		}
+		mu.UpdateMetadata(meta)
+		return true
+	}
+	return false
+}
+
+// UpdateHostMetadata mutates the metadata of the host at addr in place.
+// The caller is responsible for reindexing anything derived from host
+// metadata, such as the subset load balancer's subsets.
+func (hs *hostSet) UpdateHostMetadata(addr string, meta api.Metadata) bool {
+	return updateHostMetadata(hs.allHosts, addr, meta)
+}
+
 func (hs *hostSet) createSubset(predicate types.HostPredicate) types.HostSet {
 	allHosts := hs.Hosts()
 	var subHosts []types.Host
@@ -86,3 +135,17 @@ type subHostSet struct {
 func (sub *subHostSet) Hosts() []types.Host {
 	return sub.allHosts
 }
+
+// DegradedHosts returns the hosts flagged types.DegradedActiveHC.
+func (sub *subHostSet) DegradedHosts() []types.Host {
+	return degradedHosts(sub.allHosts)
+}
+
+// UpdateHostMetadata mutates the metadata of the host at addr in place.
+// Since subHostSet's allHosts holds the same Host objects as its parent
+// hostSet, mutating here is visible there too; the subset membership
+// itself is not recomputed, since subHostSet is an ephemeral view rebuilt
+// whenever the load balancer reindexes.
+func (sub *subHostSet) UpdateHostMetadata(addr string, meta api.Metadata) bool {
+	return updateHostMetadata(sub.allHosts, addr, meta)
+}
@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"net"
+	"testing"
+)
+
+func TestResolveSourceAddr(t *testing.T) {
+	if addr := resolveSourceAddr(""); addr != nil {
+		t.Errorf("expected nil for an empty address, got %v", addr)
+	}
+
+	if addr := resolveSourceAddr("not a valid address"); addr != nil {
+		t.Errorf("expected nil for an unparsable address, got %v", addr)
+	}
+
+	addr := resolveSourceAddr("127.0.0.1")
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || !tcpAddr.IP.IsLoopback() {
+		t.Errorf("expected a loopback TCP address for 127.0.0.1, got %v", addr)
+	}
+
+	addr = resolveSourceAddr("127.0.0.1:12345")
+	tcpAddr, ok = addr.(*net.TCPAddr)
+	if !ok || tcpAddr.Port != 12345 {
+		t.Errorf("expected port 12345 to be preserved, got %v", addr)
+	}
+}
+
+func TestResolveSourceAddrPortRange(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		addr := resolveSourceAddr("127.0.0.1:20000-20010")
+		tcpAddr, ok := addr.(*net.TCPAddr)
+		if !ok || tcpAddr.Port < 20000 || tcpAddr.Port > 20010 {
+			t.Fatalf("expected a port within [20000, 20010], got %v", addr)
+		}
+	}
+
+	if addr := resolveSourceAddr("127.0.0.1:20010-20000"); addr != nil {
+		t.Errorf("expected nil for a reversed port range, got %v", addr)
+	}
+
+	if addr := resolveSourceAddr("127.0.0.1:not-a-range"); addr != nil {
+		t.Errorf("expected nil for an unparsable port range, got %v", addr)
+	}
+}
+
+func TestInterleaveAddrFamilies(t *testing.T) {
+	v4 := func(ip string) net.Addr { return &net.TCPAddr{IP: net.ParseIP(ip)} }
+	v6 := func(ip string) net.Addr { return &net.TCPAddr{IP: net.ParseIP(ip)} }
+
+	single := []net.Addr{v4("10.0.0.1")}
+	if got := interleaveAddrFamilies(single); len(got) != 1 {
+		t.Fatalf("expected a single address to pass through unchanged, got %v", got)
+	}
+
+	addrs := []net.Addr{
+		v6("::1"),
+		v6("::2"),
+		v4("10.0.0.1"),
+		v4("10.0.0.2"),
+		v4("10.0.0.3"),
+	}
+	got := interleaveAddrFamilies(addrs)
+	want := []string{"::1", "10.0.0.1", "::2", "10.0.0.2", "10.0.0.3"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d addresses, got %d: %v", len(want), len(got), got)
+	}
+	for i, addr := range got {
+		if addr.(*net.TCPAddr).IP.String() != want[i] {
+			t.Fatalf("expected %v at position %d, got %v", want, i, got)
+		}
+	}
+
+	// a v4-first list interleaves the same way, starting with v4
+	got = interleaveAddrFamilies([]net.Addr{v4("10.0.0.1"), v6("::1"), v4("10.0.0.2")})
+	want = []string{"10.0.0.1", "::1", "10.0.0.2"}
+	for i, addr := range got {
+		if addr.(*net.TCPAddr).IP.String() != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestGetOrCreateAddrUnixSocket(t *testing.T) {
+	addr := GetOrCreateAddr("unix:///var/run/mosn/upstream.sock")
+	unixAddr, ok := addr.(*net.UnixAddr)
+	if !ok || unixAddr.Name != "/var/run/mosn/upstream.sock" || unixAddr.Net != "unix" {
+		t.Fatalf("expected a unix addr for /var/run/mosn/upstream.sock, got %v", addr)
+	}
+
+	// cached lookups return the same resolved address
+	if addr2 := GetOrCreateAddr("unix:///var/run/mosn/upstream.sock"); addr2 != addr {
+		t.Fatalf("expected the cached unix addr to be reused, got %v", addr2)
+	}
+}
+
+func TestGetOrCreateHappyEyeballsAddrsUnixSocket(t *testing.T) {
+	addrs := GetOrCreateHappyEyeballsAddrs("unix:///var/run/mosn/upstream.sock")
+	if len(addrs) != 1 {
+		t.Fatalf("expected a unix socket to have nothing to race, got %v", addrs)
+	}
+	if _, ok := addrs[0].(*net.UnixAddr); !ok {
+		t.Fatalf("expected a unix addr, got %T", addrs[0])
+	}
+}
+
+func TestGetOrCreateHappyEyeballsAddrsLiteralIP(t *testing.T) {
+	addrs := GetOrCreateHappyEyeballsAddrs("127.0.0.1:8080")
+	if len(addrs) != 1 {
+		t.Fatalf("expected a literal IP to resolve to a single candidate, got %v", addrs)
+	}
+	tcpAddr, ok := addrs[0].(*net.TCPAddr)
+	if !ok || !tcpAddr.IP.IsLoopback() || tcpAddr.Port != 8080 {
+		t.Fatalf("expected 127.0.0.1:8080, got %v", addrs[0])
+	}
+
+	if addrs := GetOrCreateHappyEyeballsAddrs("not a valid address"); addrs != nil {
+		t.Fatalf("expected nil for an unparsable address, got %v", addrs)
+	}
+}
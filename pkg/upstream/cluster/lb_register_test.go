@@ -150,6 +150,41 @@ func TestMaglevLoadBalancer(t *testing.T) {
 	}
 }
 
+type recordingLBSelectionObserver struct {
+	events []types.LBSelectionEvent
+}
+
+func (o *recordingLBSelectionObserver) OnHostChosen(event types.LBSelectionEvent) {
+	o.events = append(o.events, event)
+}
+
+func TestLBSelectionObserver(t *testing.T) {
+	defer func() { lbSelectionObservers = nil }()
+
+	observer := &recordingLBSelectionObserver{}
+	RegisterLBSelectionObserver(observer)
+
+	set := &mockHostSet{
+		hosts: []types.Host{
+			&mockHost{name: "host1", addr: "127.0.0.1:8001"},
+			&mockHost{name: "host2", addr: "127.0.0.1:8002"},
+		},
+	}
+	lb := NewLoadBalancer(&clusterInfo{name: "test-cluster", lbType: types.RoundRobin}, set)
+	host := lb.ChooseHost(nil)
+
+	if host == nil {
+		t.Fatal("choose host failed")
+	}
+	if len(observer.events) != 1 {
+		t.Fatalf("expected 1 selection event, got %d", len(observer.events))
+	}
+	event := observer.events[0]
+	if event.ClusterName != "test-cluster" || event.LBType != types.RoundRobin || event.Candidates != 2 || event.Chosen != host {
+		t.Errorf("unexpected selection event: %+v", event)
+	}
+}
+
 // Test Used in cluster
 func TestNewLBCluster(t *testing.T) {
 	cfg := v2.Cluster{
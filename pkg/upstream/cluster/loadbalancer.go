@@ -19,10 +19,12 @@ package cluster
 
 import (
 	"math/rand"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/dchest/siphash"
 	"github.com/trainyao/go-maglev"
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
@@ -31,15 +33,82 @@ import (
 )
 
 // NewLoadBalancer can be register self defined type
-var lbFactories map[types.LoadBalancerType]func(types.ClusterInfo, types.HostSet) types.LoadBalancer
+var (
+	lbFactoriesMutex sync.RWMutex
+	lbFactories      map[types.LoadBalancerType]func(types.ClusterInfo, types.HostSet) types.LoadBalancer
+)
 
+// RegisterLBType registers a factory for lbType, so clusters configured with
+// that type use it in NewLoadBalancer. Besides the built-in types registered
+// in this package's init(), it is the extension point for custom load
+// balancing algorithms (e.g. shipped as Go plugins, see pkg/plugin) - it is
+// safe to call concurrently with cluster creation/update, and a cluster
+// picks up a newly registered factory the next time its load balancer is
+// (re)built, without requiring a MOSN restart.
 func RegisterLBType(lbType types.LoadBalancerType, f func(types.ClusterInfo, types.HostSet) types.LoadBalancer) {
+	lbFactoriesMutex.Lock()
+	defer lbFactoriesMutex.Unlock()
 	if lbFactories == nil {
 		lbFactories = make(map[types.LoadBalancerType]func(types.ClusterInfo, types.HostSet) types.LoadBalancer)
 	}
 	lbFactories[lbType] = f
 }
 
+var (
+	lbSelectionObserversMutex sync.RWMutex
+	lbSelectionObservers      []types.LBSelectionObserver
+)
+
+// RegisterLBSelectionObserver registers an observer that is notified after
+// every load balancer's ChooseHost decision, across all clusters and load
+// balancing algorithms. It is safe to call concurrently with cluster
+// creation/update; like RegisterLBType, a cluster's load balancer starts
+// reporting to newly registered observers the next time it is (re)built,
+// without requiring a MOSN restart.
+func RegisterLBSelectionObserver(observer types.LBSelectionObserver) {
+	lbSelectionObserversMutex.Lock()
+	defer lbSelectionObserversMutex.Unlock()
+	lbSelectionObservers = append(lbSelectionObservers, observer)
+}
+
+func notifyLBSelection(info types.ClusterInfo, lbType types.LoadBalancerType, candidates int, chosen types.Host) {
+	lbSelectionObserversMutex.RLock()
+	observers := lbSelectionObservers
+	lbSelectionObserversMutex.RUnlock()
+	if len(observers) == 0 {
+		return
+	}
+	var clusterName string
+	if info != nil {
+		clusterName = info.Name()
+	}
+	event := types.LBSelectionEvent{
+		ClusterName: clusterName,
+		LBType:      lbType,
+		Candidates:  candidates,
+		Chosen:      chosen,
+	}
+	for _, o := range observers {
+		o.OnHostChosen(event)
+	}
+}
+
+// observingLoadBalancer wraps a LoadBalancer so every ChooseHost decision it
+// makes is reported to the registered LBSelectionObservers, without any of
+// the individual load balancing algorithms needing to know about tracing.
+type observingLoadBalancer struct {
+	types.LoadBalancer
+	hosts  types.HostSet
+	info   types.ClusterInfo
+	lbType types.LoadBalancerType
+}
+
+func (lb *observingLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	chosen := lb.LoadBalancer.ChooseHost(context)
+	notifyLBSelection(lb.info, lb.lbType, len(lb.hosts.Hosts()), chosen)
+	return chosen
+}
+
 var rrFactory *roundRobinLoadBalancerFactory
 
 func init() {
@@ -50,15 +119,85 @@ func init() {
 	RegisterLBType(types.Random, newRandomLoadBalancer)
 	RegisterLBType(types.WeightedRoundRobin, newWRRLoadBalancer)
 	RegisterLBType(types.LeastActiveRequest, newleastActiveRequestLoadBalancer)
+	RegisterLBType(types.LeastActiveConnection, newLeastActiveConnectionLoadBalancer)
 	RegisterLBType(types.Maglev, newMaglevLoadBalancer)
+	RegisterLBType(types.RingHash, newRingHashLoadBalancer)
+	RegisterLBType(types.EWMA, newEwmaLoadBalancer)
+	RegisterLBType(types.ZoneAware, newZoneAwareLoadBalancer)
+	RegisterLBType(types.Priority, newPriorityLoadBalancer)
+	RegisterLBType(types.Aperture, newApertureLoadBalancer)
+	RegisterLBType(types.Canary, newCanaryLoadBalancer)
 }
 
 func NewLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
 	lbType := info.LbType()
-	if f, ok := lbFactories[lbType]; ok {
-		return f(info, hosts)
+	lbFactoriesMutex.RLock()
+	f, ok := lbFactories[lbType]
+	lbFactoriesMutex.RUnlock()
+
+	var lb types.LoadBalancer
+	if ok {
+		lb = f(info, hosts)
+	} else {
+		lb = rrFactory.newRoundRobinLoadBalancer(info, hosts)
 	}
-	return rrFactory.newRoundRobinLoadBalancer(info, hosts)
+
+	lbSelectionObserversMutex.RLock()
+	hasObservers := len(lbSelectionObservers) > 0
+	lbSelectionObserversMutex.RUnlock()
+	if hasObservers {
+		return &observingLoadBalancer{LoadBalancer: lb, hosts: hosts, info: info, lbType: lbType}
+	}
+	return lb
+}
+
+// previousHostsProvider is implemented by a LoadBalancerContext that tracks
+// the hosts already attempted for the current request (e.g. on retry).
+type previousHostsProvider interface {
+	PreviousHosts() []types.Host
+}
+
+// maxRetryHostAttempts bounds how many extra times ChooseHostAvoidingPrevious
+// will re-query the load balancer to avoid a previously attempted host, so
+// it can't loop forever against a tiny host set.
+const maxRetryHostAttempts = 5
+
+// ChooseHostAvoidingPrevious selects a host from lb the same way ChooseHost
+// does, but when ctx reports hosts already attempted for this request (see
+// previousHostsProvider), it retries a bounded number of times to avoid
+// handing back one of them again - useful so a retry doesn't land on the
+// same failed host.
+func ChooseHostAvoidingPrevious(lb types.LoadBalancer, ctx types.LoadBalancerContext) types.Host {
+	host := lb.ChooseHost(ctx)
+	if ctx == nil || host == nil {
+		return host
+	}
+	provider, ok := ctx.(previousHostsProvider)
+	if !ok {
+		return host
+	}
+	previous := provider.PreviousHosts()
+	if len(previous) == 0 {
+		return host
+	}
+
+	for attempt := 0; attempt < maxRetryHostAttempts && wasAttempted(host, previous); attempt++ {
+		next := lb.ChooseHost(ctx)
+		if next == nil {
+			break
+		}
+		host = next
+	}
+	return host
+}
+
+func wasAttempted(host types.Host, previous []types.Host) bool {
+	for _, p := range previous {
+		if p.AddressString() == host.AddressString() {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadBalancer Implementations
@@ -107,6 +246,7 @@ func (lb *randomLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
 }
 
 type roundRobinLoadBalancer struct {
+	info    types.ClusterInfo
 	hosts   types.HostSet
 	rrIndex uint32
 }
@@ -117,14 +257,22 @@ type roundRobinLoadBalancerFactory struct {
 }
 
 func (f *roundRobinLoadBalancerFactory) newRoundRobinLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
-	var idx uint32
 	hostsList := hosts.Hosts()
+	// when hosts carry unequal weights, honor them the same way the
+	// weighted round robin load balancer does (EDF scheduling), instead of
+	// cycling through hosts with equal probability.
+	if !hostWeightsAreEqual(hostsList) {
+		return newWRRLoadBalancer(info, hosts)
+	}
+
+	var idx uint32
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 	if len(hostsList) != 0 {
 		idx = f.rand.Uint32() % uint32(len(hostsList))
 	}
 	return &roundRobinLoadBalancer{
+		info:    info,
 		hosts:   hosts,
 		rrIndex: idx,
 	}
@@ -136,14 +284,50 @@ func (lb *roundRobinLoadBalancer) ChooseHost(context types.LoadBalancerContext)
 	if total == 0 {
 		return nil
 	}
+	// when too few hosts are healthy, panic mode kicks in: ignore health
+	// state entirely and spread load across the whole host set, rather than
+	// overwhelming the handful of hosts that are still healthy.
+	if lb.inPanicMode(targets, total) {
+		index := atomic.AddUint32(&lb.rrIndex, 1) % uint32(total)
+		return targets[index]
+	}
+	// first pass: prefer a healthy, non-degraded host
+	var degradedFallback types.Host
 	for i := 0; i < total; i++ {
 		index := atomic.AddUint32(&lb.rrIndex, 1) % uint32(total)
 		host := targets[index]
+		if !host.Health() {
+			continue
+		}
+		if host.ContainHealthFlag(types.DegradedActiveHC) {
+			if degradedFallback == nil {
+				degradedFallback = host
+			}
+			continue
+		}
+		return host
+	}
+	// no non-degraded capacity left, fall back to a degraded host
+	return degradedFallback
+}
+
+// inPanicMode reports whether the healthy percentage among targets has
+// fallen below the cluster's configured healthy panic threshold.
+func (lb *roundRobinLoadBalancer) inPanicMode(targets []types.Host, total int) bool {
+	if lb.info == nil {
+		return false
+	}
+	healthy := 0
+	for _, host := range targets {
 		if host.Health() {
-			return host
+			healthy++
 		}
 	}
-	return nil
+	panicking := float64(healthy)*100 < lb.info.HealthyPanicThreshold()*float64(total)
+	if panicking {
+		lb.info.Stats().LBHealthyPanic.Inc(1)
+	}
+	return panicking
 }
 
 func (lb *roundRobinLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
@@ -155,8 +339,8 @@ func (lb *roundRobinLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) in
 }
 
 /*
- A round robin load balancer. When in weighted mode, EDF scheduling is used. When in not
- weighted mode, simple RR index selection is used.
+A round robin load balancer. When in weighted mode, EDF scheduling is used. When in not
+weighted mode, simple RR index selection is used.
 */
 type WRRLoadBalancer struct {
 	*EdfLoadBalancer
@@ -207,8 +391,12 @@ type leastActiveRequestLoadBalancer struct {
 func newleastActiveRequestLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
 	lb := &leastActiveRequestLoadBalancer{}
 	if info != nil && info.LbConfig() != nil {
-		lb.choice = info.LbConfig().(*v2.LeastRequestLbConfig).ChoiceCount
-	} else {
+		if cfg, ok := info.LbConfig().(*v2.LeastRequestLbConfig); ok {
+			lb.choice = cfg.ChoiceCount
+		}
+	}
+	// a power-of-choices selection needs at least two candidates to compare
+	if lb.choice < default_choice {
 		lb.choice = default_choice
 	}
 	lb.EdfLoadBalancer = newEdfLoadBalancerLoadBalancer(hosts, lb.unweightChooseHost, lb.hostWeight)
@@ -246,6 +434,60 @@ func (lb *leastActiveRequestLoadBalancer) unweightChooseHost(context types.LoadB
 
 }
 
+// leastActiveConnectionLoadBalancer chooses the host with the least active
+// connections, using HostStats.UpstreamConnectionActive rather than
+// UpstreamRequestActive. It suits long-lived, connection-oriented upstreams
+// such as TCP-proxied databases or message queues, where a single
+// connection carries many requests (or none at all) so request-based
+// least-loaded metrics do not reflect actual host load.
+type leastActiveConnectionLoadBalancer struct {
+	*EdfLoadBalancer
+	choice uint32
+}
+
+func newLeastActiveConnectionLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	lb := &leastActiveConnectionLoadBalancer{}
+	if info != nil && info.LbConfig() != nil {
+		if cfg, ok := info.LbConfig().(*v2.LeastRequestLbConfig); ok {
+			lb.choice = cfg.ChoiceCount
+		}
+	}
+	// a power-of-choices selection needs at least two candidates to compare
+	if lb.choice < default_choice {
+		lb.choice = default_choice
+	}
+	lb.EdfLoadBalancer = newEdfLoadBalancerLoadBalancer(hosts, lb.unweightChooseHost, lb.hostWeight)
+	return lb
+}
+
+func (lb *leastActiveConnectionLoadBalancer) hostWeight(item WeightItem) float64 {
+	host := item.(types.Host)
+	return float64(host.Weight()) / float64(host.HostStats().UpstreamConnectionActive.Count()+1)
+}
+
+func (lb *leastActiveConnectionLoadBalancer) unweightChooseHost(context types.LoadBalancerContext) types.Host {
+	allHosts := lb.hosts.Hosts()
+	total := len(allHosts)
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	var candicate types.Host
+	// Choose `choice` times and return the best one
+	// See The Power of Two Random Choices: A Survey of Techniques and Results
+	//  http://www.eecs.harvard.edu/~michaelm/postscripts/handbook2001.pdf
+	for cur := 0; cur < int(lb.choice); cur++ {
+		randIdx := lb.rand.Intn(total)
+		tempHost := allHosts[randIdx]
+		if candicate == nil {
+			candicate = tempHost
+			continue
+		}
+		if candicate.HostStats().UpstreamConnectionActive.Count() > tempHost.HostStats().UpstreamConnectionActive.Count() {
+			candicate = tempHost
+		}
+	}
+	return candicate
+}
+
 type EdfLoadBalancer struct {
 	scheduler *edfSchduler
 	hosts     types.HostSet
@@ -375,6 +617,46 @@ func newMaglevLoadBalancer(info types.ClusterInfo, set types.HostSet) types.Load
 	return mgv
 }
 
+// hashFromContext computes the hash key that consistent-hash load balancers
+// (maglev, ring-hash) use to pick a host, and whether a key could be
+// computed at all. It prefers the route's configured HashPolicy, matching
+// how HTTP-level LBs pick a key; a route with no HashPolicy configured still
+// means "no hash available" so callers keep their existing round-robin/nil
+// fallback behavior. Protocols with no route concept at all, such as
+// tcpproxy, have a nil DownstreamRoute(); in that case we fall back to
+// hashing the downstream connection's source IP directly, so a TCP flow
+// still gets a sticky host across reconnects and host-list churn.
+func hashFromContext(ctx types.LoadBalancerContext) (hash uint64, ok bool) {
+	if route := ctx.DownstreamRoute(); route != nil {
+		if rule := route.RouteRule(); rule != nil {
+			hashPolicy := rule.Policy().HashPolicy()
+			if hashPolicy == nil {
+				return 0, false
+			}
+			return hashPolicy.GenerateHash(ctx.DownstreamContext()), true
+		}
+		return 0, false
+	}
+	if conn := ctx.DownstreamConnection(); conn != nil {
+		if addr := conn.RemoteAddr(); addr != nil {
+			return sourceAddrHash(addr), true
+		}
+	}
+	return 0, false
+}
+
+// sourceAddrHash hashes just the IP portion of addr, so a client keeps the
+// same hash across reconnects from different ephemeral source ports.
+func sourceAddrHash(addr net.Addr) uint64 {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		if ip := tcpAddr.IP.To4(); ip != nil {
+			return siphash.Hash(0xbeefcafebabedead, 0, ip)
+		}
+		return siphash.Hash(0xbeefcafebabedead, 0, tcpAddr.IP)
+	}
+	return siphash.Hash(0xbeefcafebabedead, 0, []byte(addr.String()))
+}
+
 type maglevLoadBalancer struct {
 	hosts  types.HostSet
 	maglev *maglev.Table
@@ -386,17 +668,10 @@ func (lb *maglevLoadBalancer) ChooseHost(ctx types.LoadBalancerContext) types.Ho
 		return nil
 	}
 
-	route := ctx.DownstreamRoute()
-	if route == nil || route.RouteRule() == nil {
-		return nil
-	}
-
-	hashPolicy := route.RouteRule().Policy().HashPolicy()
-	if hashPolicy == nil {
+	hash, ok := hashFromContext(ctx)
+	if !ok {
 		return nil
 	}
-
-	hash := hashPolicy.GenerateHash(ctx.DownstreamContext())
 	index := lb.maglev.Lookup(hash)
 	chosen := lb.hosts.Hosts()[index]
 
@@ -27,6 +27,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/types"
 )
@@ -294,6 +295,99 @@ func TestLARChooseHost(t *testing.T) {
 
 }
 
+func TestLeastActiveConnectionChooseHost(t *testing.T) {
+	hosts := createHostsetWithStats(exampleHostConfigs(), "test")
+	balancer := NewLoadBalancer(&clusterInfo{lbType: types.LeastActiveConnection}, hosts)
+	host := balancer.ChooseHost(newMockLbContext(nil))
+	assert.NotNil(t, host)
+
+	for _, host := range hosts.Hosts() {
+		host.HostStats().UpstreamConnectionActive.Inc(10)
+	}
+	hosts.allHosts[6].HostStats().UpstreamConnectionActive.Dec(10)
+	// new lb to refresh edf
+	balancer = NewLoadBalancer(&clusterInfo{lbType: types.LeastActiveConnection}, hosts)
+	actual := balancer.ChooseHost(newMockLbContext(nil))
+	assert.Equal(t, hosts.allHosts[6], actual)
+}
+
+func TestPriorityLoadBalancerOverprovisioningFactor(t *testing.T) {
+	healthStore = sync.Map{}
+	newHosts := func() *hostSet {
+		hosts := createHostset([]v2.Host{
+			{HostConfig: v2.HostConfig{Hostname: "p0-a", Address: "127.0.0.1:9001"}, MetaData: map[string]string{types.PriorityMetadataKey: "0"}},
+			{HostConfig: v2.HostConfig{Hostname: "p0-b", Address: "127.0.0.1:9002"}, MetaData: map[string]string{types.PriorityMetadataKey: "0"}},
+			{HostConfig: v2.HostConfig{Hostname: "p1-a", Address: "127.0.0.1:9003"}, MetaData: map[string]string{types.PriorityMetadataKey: "1"}},
+			{HostConfig: v2.HostConfig{Hostname: "p1-b", Address: "127.0.0.1:9004"}, MetaData: map[string]string{types.PriorityMetadataKey: "1"}},
+		})
+		// priority 0 is 50% healthy
+		hosts.allHosts[0].SetHealthFlag(api.FAILED_ACTIVE_HC)
+		return hosts
+	}
+
+	// with the default 1.4 factor, 50% healthy * 1.4 = 0.7 < 1, so priority 0
+	// is degraded and some requests spill over to priority 1.
+	hosts := newHosts()
+	balancer := NewLoadBalancer(&clusterInfo{lbType: types.Priority}, hosts)
+	spilled := false
+	for i := 0; i < 200; i++ {
+		if host := balancer.ChooseHost(newMockLbContext(nil)); host != nil && hostPriority(host) == 1 {
+			spilled = true
+			break
+		}
+	}
+	assert.True(t, spilled, "expected some requests to spill over to priority 1 with the default factor")
+
+	// overriding priority 0's factor to 2.0 makes 50%*2.0 = 1.0 >= 1, so it is
+	// treated as fully available and never spills over.
+	hosts = newHosts()
+	balancer = NewLoadBalancer(&clusterInfo{
+		lbType: types.Priority,
+		lbConfig: &v2.PriorityLbConfig{
+			OverprovisioningFactor:          types.DefaultPriorityOverprovisioningFactor,
+			PriorityOverprovisioningFactors: map[int]float64{0: 2.0},
+		},
+	}, hosts)
+	for i := 0; i < 200; i++ {
+		host := balancer.ChooseHost(newMockLbContext(nil))
+		assert.Equal(t, 0, hostPriority(host))
+	}
+}
+
+func TestCanaryLoadBalancerChooseHost(t *testing.T) {
+	healthStore = sync.Map{}
+	hosts := createHostset([]v2.Host{
+		{HostConfig: v2.HostConfig{Hostname: "stable-a", Address: "127.0.0.1:9101"}},
+		{HostConfig: v2.HostConfig{Hostname: "stable-b", Address: "127.0.0.1:9102"}},
+		{HostConfig: v2.HostConfig{Hostname: "canary-a", Address: "127.0.0.1:9103"}, MetaData: map[string]string{types.CanaryMetadataKey: types.CanaryMetadataValue}},
+	})
+
+	info := &clusterInfo{
+		lbType: types.Canary,
+		stats:  newClusterStats("canary-test"),
+		lbConfig: &v2.CanaryLbConfig{
+			CanaryPercent: 100,
+		},
+	}
+	balancer := NewLoadBalancer(info, hosts)
+
+	// with CanaryPercent 100, every request should land on the canary host
+	// and the canary stat should track every selection.
+	for i := 0; i < 20; i++ {
+		host := balancer.ChooseHost(newMockLbContext(nil))
+		assert.True(t, isCanaryHost(host))
+	}
+	assert.Equal(t, int64(20), info.Stats().LBCanaryRequest.Count())
+
+	// with no canary hosts present, traffic stays on the stable pool.
+	stableOnly := createHostset([]v2.Host{
+		{HostConfig: v2.HostConfig{Hostname: "stable-a", Address: "127.0.0.1:9104"}},
+	})
+	balancer = NewLoadBalancer(&clusterInfo{lbType: types.Canary, stats: newClusterStats("canary-test-2")}, stableOnly)
+	host := balancer.ChooseHost(newMockLbContext(nil))
+	assert.False(t, isCanaryHost(host))
+}
+
 func mockRequest(host types.Host, active bool, times int) {
 	for i := 0; i < times; i++ {
 		if active {
@@ -371,6 +465,46 @@ func Test_maglevLoadBalancerFallback(t *testing.T) {
 	// assert other 9 hosts is checked healthy
 	assert.Equalf(t, 9, hostSet.healthCheckVisitedCount, "host name should be 'host-0'")
 }
+func Test_apertureLoadBalancer(t *testing.T) {
+	hosts := createHostsetWithStats(exampleHostConfigs(), "test")
+	// UpstreamRequestActive is backed by the process-global metrics registry,
+	// keyed by cluster name + host address, so a counter bumped by an earlier
+	// run of this test (or any other test sharing these addresses) would
+	// otherwise leak in here. Start every run from a clean count.
+	for _, h := range hosts.Hosts() {
+		h.HostStats().UpstreamRequestActive.Clear()
+	}
+	lb := newApertureLoadBalancer(&clusterInfo{lbType: types.Aperture}, hosts)
+
+	host := lb.ChooseHost(newMockLbContext(nil))
+	assert.NotNil(t, host)
+
+	// same seed every call, so repeated choices stay within the minimum
+	// aperture window until load picks up
+	aperture := lb.(*apertureLoadBalancer)
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		h := lb.ChooseHost(newMockLbContext(nil))
+		if !assert.NotNil(t, h) {
+			t.FailNow()
+		}
+		seen[h.AddressString()] = true
+	}
+	assert.LessOrEqual(t, len(seen), int(defaultMinAperture))
+
+	// push enough active requests onto the aperture's hosts to force it
+	// to widen and bring other hosts into rotation
+	for _, h := range hosts.Hosts() {
+		mockRequest(h, true, 10)
+	}
+	widened := aperture.currentAperture(int(aperture.seed%uint64(len(aperture.ring))), len(aperture.ring), aperture.minAperture, uint32(len(aperture.ring)))
+	assert.Greater(t, widened, aperture.minAperture)
+
+	// empty host set chooses nothing
+	empty := newApertureLoadBalancer(nil, &hostSet{})
+	assert.Nil(t, empty.ChooseHost(newMockLbContext(nil)))
+}
+
 func getMockClusterInfo() *mockClusterInfo {
 	return &mockClusterInfo{
 		name: "mockClusterInfo",
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+func newLocalityStats(clusterName string, locality string) types.LocalityStats {
+	s := metrics.NewClusterLocalityStats(clusterName, locality)
+	return types.LocalityStats{
+		UpstreamRequestTotal:         s.Counter(metrics.UpstreamRequestTotal),
+		UpstreamRequestDuration:      s.Histogram(metrics.UpstreamRequestDuration),
+		UpstreamRequestDurationTotal: s.Counter(metrics.UpstreamRequestDurationTotal),
+		UpstreamResponseSuccess:      s.Counter(metrics.UpstreamResponseSuccess),
+		UpstreamResponseFailed:       s.Counter(metrics.UpstreamResponseFailed),
+	}
+}
+
+// LocalityStats returns clusterName's request/error/latency aggregates for
+// locality, creating and registering its metrics on first use. Localities
+// are not known ahead of time, unlike routing priorities, so they are kept
+// in a sync.Map keyed by locality string rather than pre-built.
+func (ci *clusterInfo) LocalityStats(locality string) types.LocalityStats {
+	if v, ok := ci.localityStats.Load(locality); ok {
+		return v.(types.LocalityStats)
+	}
+	stats := newLocalityStats(ci.name, locality)
+	actual, _ := ci.localityStats.LoadOrStore(locality, stats)
+	return actual.(types.LocalityStats)
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestClusterInfoLocalityStats(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "locality_stats_test_cluster",
+		LbType: v2.LB_RANDOM,
+	}
+	c := NewCluster(clusterConfig)
+	info := c.Snapshot().ClusterInfo()
+
+	az1 := info.LocalityStats("az1")
+	az1.UpstreamRequestTotal.Inc(1)
+	az1.UpstreamResponseSuccess.Inc(1)
+
+	az2 := info.LocalityStats("az2")
+	az2.UpstreamRequestTotal.Inc(1)
+	az2.UpstreamResponseFailed.Inc(1)
+
+	// same locality returns the same, already-populated stats rather than a
+	// fresh zeroed set
+	again := info.LocalityStats("az1")
+	if again.UpstreamRequestTotal.Count() != 1 {
+		t.Fatalf("expected az1 request total to be 1, got %d", again.UpstreamRequestTotal.Count())
+	}
+	if az2.UpstreamRequestTotal.Count() != 1 {
+		t.Fatalf("expected az2 request total to be 1, got %d", az2.UpstreamRequestTotal.Count())
+	}
+	if az1.UpstreamResponseFailed.Count() != 0 {
+		t.Fatalf("expected az1 and az2 stats not to share counters, got az1 failed count %d", az1.UpstreamResponseFailed.Count())
+	}
+
+	// hosts with no locality configured are grouped under ""
+	empty := info.LocalityStats("")
+	empty.UpstreamRequestTotal.Inc(1)
+	if empty.UpstreamRequestTotal.Count() != 1 {
+		t.Fatalf("expected empty locality request total to be 1, got %d", empty.UpstreamRequestTotal.Count())
+	}
+}
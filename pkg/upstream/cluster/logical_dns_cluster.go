@@ -0,0 +1,280 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+func init() {
+	RegisterClusterType(v2.LOGICAL_DNS_CLUSTER, newLogicalDnsCluster)
+}
+
+// logicalDnsCluster resolves its configured hostnames the same way a
+// STRICT_DNS cluster does, but it never grows its host set: it keeps
+// exactly one host per configured address, uses only the first address in
+// each DNS response, and updates that host's dial address in place on
+// every re-resolve instead of replacing the host set. This avoids host-set
+// explosion on round-robin DNS names with large answer sets, while new
+// connections still pick up the latest resolved address.
+type logicalDnsCluster struct {
+	*simpleCluster
+	dnsResolver     *network.DnsResolver
+	dnsLookupFamily v2.DnsLookupFamily
+	respectDnsTTL   bool
+	dnsRefreshRate  time.Duration
+	resolveTargets  []*logicalResolveTarget
+	mutex           sync.Mutex
+}
+
+type logicalResolveTarget struct {
+	config         *v2.Host
+	dnsAddress     string
+	port           string
+	host           *logicalHost
+	cluster        *logicalDnsCluster
+	resolveTimer   *utils.Timer
+	resolveTimeout *utils.Timer
+	refreshTimeout time.Duration
+	dnsRefreshRate chan time.Duration
+	stop           chan struct{}
+	timeout        chan bool
+}
+
+func newLogicalDnsCluster(clusterConfig v2.Cluster) types.Cluster {
+	cluster := &logicalDnsCluster{
+		simpleCluster:   newSimpleCluster(clusterConfig).(*simpleCluster),
+		dnsLookupFamily: clusterConfig.DnsLookupFamily,
+		respectDnsTTL:   clusterConfig.RespectDnsTTL,
+	}
+
+	if clusterConfig.DnsRefreshRate != nil {
+		cluster.dnsRefreshRate = clusterConfig.DnsRefreshRate.Duration
+	}
+
+	if clusterConfig.DnsResolverConfig.Servers != nil {
+		cluster.dnsResolver = network.NewDnsResolver(&clusterConfig.DnsResolverConfig)
+	} else {
+		cluster.dnsResolver = network.NewDnsResolverFromFile(clusterConfig.DnsResolverFile, clusterConfig.DnsResolverPort)
+	}
+
+	return cluster
+}
+
+func (ldc *logicalDnsCluster) UpdateHosts(newHosts []types.Host) {
+	ldc.mutex.Lock()
+	defer ldc.mutex.Unlock()
+	ldc.StopResolve()
+
+	var rts []*logicalResolveTarget
+	var hosts []types.Host
+	for _, h := range newHosts {
+		addr, port := getHostPortFromAddr(h.AddressString())
+		if addr == "" {
+			if log.DefaultLogger.GetLogLevel() >= log.ERROR {
+				log.DefaultLogger.Errorf("[upstream] [logical_dns_cluster] config address format error: %s", h.AddressString())
+			}
+			continue
+		}
+		if port == "" {
+			port = "80"
+		}
+		config := h.Config()
+		rt := &logicalResolveTarget{
+			dnsAddress:     addr,
+			port:           port,
+			config:         &config,
+			refreshTimeout: DefaultRefreshTimeout,
+			dnsRefreshRate: make(chan time.Duration),
+			stop:           make(chan struct{}),
+			timeout:        make(chan bool),
+			cluster:        ldc,
+			host:           newLogicalHost(config, ldc.info),
+		}
+
+		rts = append(rts, rt)
+		hosts = append(hosts, rt.host)
+		// if address is already an ip, skip dns resolution
+		if net.ParseIP(rt.dnsAddress) != nil {
+			continue
+		}
+		utils.GoWithRecover(func() {
+			rt.StartResolve()
+		}, nil)
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[upstream] [logical dns cluster] create a resolver for address: %s", rt.dnsAddress)
+		}
+	}
+	ldc.resolveTargets = rts
+
+	// the host set size is fixed at one host per configured address: DNS
+	// re-resolution updates each host's dial address in place instead of
+	// replacing the host set, so it is only set once here.
+	ldc.simpleCluster.UpdateHosts(hosts)
+}
+
+func (ldc *logicalDnsCluster) StopResolve() {
+	for _, rt := range ldc.resolveTargets {
+		rt.StopResolve()
+	}
+}
+
+// calculateNextResolveInterval mirrors strictDnsCluster's: respect the DNS
+// TTL when configured, otherwise fall back to the configured refresh rate
+// or DefaultRefreshInterval.
+func (ldc *logicalDnsCluster) calculateNextResolveInterval(ttl time.Duration) time.Duration {
+	dnsRefreshRate := ldc.dnsRefreshRate
+	if ldc.respectDnsTTL && ttl > 0 {
+		dnsRefreshRate = ttl + time.Second
+	} else if ldc.dnsRefreshRate == 0 {
+		dnsRefreshRate = DefaultRefreshInterval
+	}
+	return dnsRefreshRate
+}
+
+func (rt *logicalResolveTarget) StopResolve() {
+	close(rt.stop)
+}
+
+func (rt *logicalResolveTarget) StartResolve() {
+	defer func() {
+		if r := recover(); r != nil {
+			if log.DefaultLogger.GetLogLevel() >= log.ERROR {
+				log.DefaultLogger.Errorf("[upstream] [logical_dns_cluster] [resolver] panic %v\n%s", r, string(debug.Stack()))
+			}
+		}
+		rt.resolveTimer.Stop()
+		rt.resolveTimeout.Stop()
+	}()
+
+	// start resolve now
+	rt.resolveTimer = utils.NewTimer(0, rt.OnResolve)
+	for {
+		select {
+		case <-rt.stop:
+			rt.resolveTimeout.Stop()
+			rt.resolveTimer.Stop()
+			return
+		default:
+			select {
+			case <-rt.stop:
+				rt.resolveTimeout.Stop()
+				rt.resolveTimer.Stop()
+				return
+			case <-rt.timeout:
+				rt.resolveTimer.Stop()
+				rt.resolveTimer = utils.NewTimer(time.Second, rt.OnResolve)
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf("[upstream] [logical dns cluster] timeout received when resolve dns address :%s", rt.dnsAddress)
+				}
+			case ttl := <-rt.dnsRefreshRate:
+				rt.resolveTimeout.Stop()
+				rt.resolveTimer = utils.NewTimer(ttl, rt.OnResolve)
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf("[upstream] [logical dns cluster] start next resolve dns timer, address:%s, ttl:%d", rt.dnsAddress, ttl)
+				}
+			}
+		}
+	}
+}
+
+func (rt *logicalResolveTarget) OnTimeout() {
+	rt.timeout <- true
+}
+
+func (rt *logicalResolveTarget) OnResolve() {
+	rt.resolveTimeout.Stop()
+	rt.resolveTimeout = utils.NewTimer(rt.refreshTimeout, rt.OnTimeout)
+	ldc := rt.cluster
+	dnsResponse := ldc.dnsResolver.DnsResolve(rt.dnsAddress, ldc.dnsLookupFamily)
+	if dnsResponse == nil || len(*dnsResponse) == 0 {
+		rt.dnsRefreshRate <- ldc.calculateNextResolveInterval(0)
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[upstream] [logical dns cluster] resolve failed and start a new task")
+		}
+		return
+	}
+
+	// logical dns only ever uses the first resolved address
+	rsp := (*dnsResponse)[0]
+	newAddr := rsp.Address + ":" + rt.port
+	rt.host.UpdateResolvedAddress(newAddr)
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [logical dns cluster] resolve dns result updated, cluster_name:%s, address:%s, resolved:%s", ldc.info.Name(), rt.dnsAddress, newAddr)
+	}
+
+	rt.dnsRefreshRate <- ldc.calculateNextResolveInterval(rsp.Ttl)
+}
+
+// logicalHost behaves like simpleHost, except its dial address is kept up
+// to date by a LOGICAL_DNS cluster's background re-resolution instead of
+// being fixed at construction time: AddressString (used for pool keying,
+// stats and health checks) stays the configured address, while
+// CreateConnection always dials the most recently resolved address.
+type logicalHost struct {
+	*simpleHost
+	resolvedAddr atomic.Value // net.Addr
+}
+
+func newLogicalHost(config v2.Host, clusterInfo types.ClusterInfo) *logicalHost {
+	return &logicalHost{
+		simpleHost: NewSimpleHost(config, clusterInfo).(*simpleHost),
+	}
+}
+
+func (lh *logicalHost) Address() net.Addr {
+	if addr, ok := lh.resolvedAddr.Load().(net.Addr); ok {
+		return addr
+	}
+	return lh.simpleHost.Address()
+}
+
+func (lh *logicalHost) CreateConnection(context context.Context) types.CreateConnectionData {
+	var tlsMng types.TLSContextManager
+	if lh.SupportTLS() {
+		tlsMng = lh.clusterInfo.TLSMngByMetadata(lh.Metadata())
+	}
+	connOptions := lh.clusterInfo.ConnectionOptions()
+	sourceAddr := resolveSourceAddr(connOptions.SourceAddress)
+	clientConn := network.NewClientConnection(sourceAddr, lh.clusterInfo.ConnectTimeout(), tlsMng, lh.Address(), nil)
+	clientConn.SetBufferLimit(lh.clusterInfo.ConnBufferLimitBytes())
+	clientConn.SetConnectionOptions(connOptions)
+
+	return types.CreateConnectionData{
+		Connection: clientConn,
+		Host:       lh,
+	}
+}
+
+// UpdateResolvedAddress is called by the owning LOGICAL_DNS cluster
+// whenever it re-resolves this host's address.
+func (lh *logicalHost) UpdateResolvedAddress(addrstr string) {
+	if addr := GetOrCreateAddr(addrstr); addr != nil {
+		lh.resolvedAddr.Store(addr)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// TestLogicalDnsClusterSingleHost drives a LOGICAL_DNS cluster's resolve
+// target directly, without a real DNS lookup: the configured address is
+// already an IP, so no background resolver goroutine is started. It
+// verifies the host set always has exactly one host per configured
+// address, and that re-resolving updates the dial address in place
+// instead of growing the host set.
+func TestLogicalDnsClusterSingleHost(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:        "logical_dns_cluster",
+		LbType:      v2.LB_ROUNDROBIN,
+		ClusterType: v2.LOGICAL_DNS_CLUSTER,
+	}
+	c := NewCluster(clusterConfig)
+	ldc := c.(*logicalDnsCluster)
+
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:  "192.0.2.1:80",
+			Hostname: "example.com",
+		},
+	}
+	ldc.UpdateHosts([]types.Host{NewSimpleHost(host, ldc.info)})
+	if len(ldc.resolveTargets) != 1 {
+		t.Fatalf("expected 1 resolve target, got %d", len(ldc.resolveTargets))
+	}
+	rt := ldc.resolveTargets[0]
+
+	hosts := ldc.Snapshot().HostSet().Hosts()
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+	if hosts[0].AddressString() != "192.0.2.1:80" {
+		t.Fatalf("expected configured address before any resolve, got %s", hosts[0].AddressString())
+	}
+
+	// simulate a DNS response resolving to a different address: the host
+	// set must stay at one host, and that host's dial address must
+	// change, while its AddressString (identity) stays stable.
+	rt.host.UpdateResolvedAddress("10.0.0.1:80")
+	if addr := rt.host.Address(); addr == nil || addr.String() != "10.0.0.1:80" {
+		t.Fatalf("expected resolved dial address 10.0.0.1:80, got %v", addr)
+	}
+	if rt.host.AddressString() != "192.0.2.1:80" {
+		t.Fatalf("expected AddressString to remain the configured address, got %s", rt.host.AddressString())
+	}
+
+	hosts = ldc.Snapshot().HostSet().Hosts()
+	if len(hosts) != 1 {
+		t.Fatalf("host set exploded after re-resolve: got %d hosts", len(hosts))
+	}
+
+	// re-resolving again must still keep the host set at one host, even
+	// with yet another different address.
+	rt.host.UpdateResolvedAddress("10.0.0.2:80")
+	if addr := rt.host.Address(); addr == nil || addr.String() != "10.0.0.2:80" {
+		t.Fatalf("expected resolved dial address 10.0.0.2:80, got %v", addr)
+	}
+	hosts = ldc.Snapshot().HostSet().Hosts()
+	if len(hosts) != 1 {
+		t.Fatalf("host set exploded after second re-resolve: got %d hosts", len(hosts))
+	}
+}
@@ -25,7 +25,6 @@ import (
 	"sync/atomic"
 
 	"mosn.io/api"
-	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -39,6 +38,10 @@ func (hs *mockHostSet) Hosts() []types.Host {
 	return hs.hosts
 }
 
+func (hs *mockHostSet) DegradedHosts() []types.Host {
+	return degradedHosts(hs.hosts)
+}
+
 func getMockHostSet(count int) *mockHostSet {
 	hosts := []types.Host{}
 	hostCount := count
@@ -88,7 +91,7 @@ func (h *mockHost) Health() bool {
 	if mhs, ok := h.hostSet.(*mockHostSet); ok {
 		mhs.healthCheckVisitedCount++
 	}
-	return atomic.LoadUint64(h.healthFlag) == 0
+	return atomic.LoadUint64(h.healthFlag)&^uint64(types.DegradedActiveHC) == 0
 }
 
 func (h *mockHost) ClearHealthFlag(flag api.HealthFlag) {
@@ -108,6 +111,13 @@ func (h *mockHost) SetHealthFlag(flag api.HealthFlag) {
 func (h *mockHost) HealthFlag() api.HealthFlag {
 	return api.HealthFlag(atomic.LoadUint64(h.healthFlag))
 }
+
+func (h *mockHost) ContainHealthFlag(flag api.HealthFlag) bool {
+	if h.healthFlag == nil {
+		h.healthFlag = GetHealthFlagPointer(h.addr)
+	}
+	return atomic.LoadUint64(h.healthFlag)&uint64(flag) > 0
+}
 func (h *mockHost) HostStats() types.HostStats {
 	return h.stats
 }
@@ -155,8 +165,10 @@ func makePool(size int) *ipPool {
 }
 
 type mockConnPool struct {
-	host       atomic.Value
-	supportTLS bool
+	host         atomic.Value
+	supportTLS   bool
+	shutdownCall int32
+	closeCall    int32
 	types.ConnectionPool
 }
 
@@ -175,9 +187,11 @@ func (p *mockConnPool) SupportTLS() bool {
 }
 
 func (p *mockConnPool) Shutdown() {
+	atomic.AddInt32(&p.shutdownCall, 1)
 }
 
 func (p *mockConnPool) Close() {
+	atomic.AddInt32(&p.closeCall, 1)
 }
 
 func (p *mockConnPool) NewStream(ctx context.Context, receiver types.StreamReceiveListener, listener types.PoolEventListener) {
@@ -197,14 +211,13 @@ func (p *mockConnPool) UpdateHost(h types.Host) {
 }
 
 func init() {
-	network.RegisterNewPoolFactory(mockProtocol, func(h types.Host) types.ConnectionPool {
+	types.RegisterConnPoolFactory(mockProtocol, func(ctx context.Context, h types.Host) types.ConnectionPool {
 		pool := &mockConnPool{
 			supportTLS: h.SupportTLS(),
 		}
 		pool.host.Store(h)
 		return pool
 	})
-	types.RegisterConnPoolFactory(mockProtocol, true)
 }
 
 type mockLbContext struct {
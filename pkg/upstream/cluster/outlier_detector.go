@@ -0,0 +1,356 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+// Defaults for v2.OutlierDetection fields that are left unset (0), mirroring
+// Envoy's defaults for the same knobs.
+const (
+	defaultConsecutive5xx            = 5
+	defaultConsecutiveGatewayFailure = 5
+	defaultBaseEjectionTime          = 30 * time.Second
+	defaultSuccessRateInterval       = 10 * time.Second
+	defaultSuccessRateMinimumHosts   = 5
+	defaultSuccessRateRequestVolume  = 100
+	// defaultSuccessRateStdevFactor is in units of 0.1 stdev, so 1900 means
+	// hosts more than 1.9 standard deviations below the mean success rate
+	// are ejected.
+	defaultSuccessRateStdevFactor = 1900
+	defaultMaxEjectionPercent     = 10
+	defaultEnforcingSuccessRate   = 100
+)
+
+// outlierState is a host's passive outlier detection state, keyed and reused
+// by host address the same way health.go reuses health flag pointers across
+// host set rebuilds.
+type outlierState struct {
+	mutex              sync.Mutex
+	consecutive5xx     uint32
+	consecutiveGateway uint32
+	ejectionCount      uint32
+	lastEjectionTime   time.Time
+	ejectTimer         *time.Timer
+	// windowRequests and windowSuccesses count requests since the last
+	// success-rate sweep by outlierDetector; they are read and reset
+	// together by snapshotAndResetWindow.
+	windowRequests  uint32
+	windowSuccesses uint32
+	// lastSuccessRate and hasSuccessRate record the result of the most
+	// recent success-rate sweep the host was eligible for, for admin-facing
+	// inspection; they do not affect ejection decisions.
+	lastSuccessRate float64
+	hasSuccessRate  bool
+}
+
+// snapshotAndResetWindow returns the request/success counts accumulated
+// since the previous call and resets them, so each success-rate sweep only
+// sees requests from its own interval.
+func (s *outlierState) snapshotAndResetWindow() (requests, successes uint32) {
+	s.mutex.Lock()
+	requests, successes = s.windowRequests, s.windowSuccesses
+	s.windowRequests, s.windowSuccesses = 0, 0
+	s.mutex.Unlock()
+	return
+}
+
+var outlierStore = sync.Map{}
+
+func getOutlierState(addr string) *outlierState {
+	v, _ := outlierStore.LoadOrStore(addr, &outlierState{})
+	return v.(*outlierState)
+}
+
+// RecordOutlierSuccess clears a host's consecutive error counters after a
+// successful request. It does not affect a host that is already ejected;
+// that is only cleared once its ejection timer expires.
+func RecordOutlierSuccess(host types.Host) {
+	if host == nil {
+		return
+	}
+	state := getOutlierState(host.AddressString())
+	state.mutex.Lock()
+	state.consecutive5xx = 0
+	state.consecutiveGateway = 0
+	state.windowRequests++
+	state.windowSuccesses++
+	state.mutex.Unlock()
+}
+
+// RecordOutlierError records a failed request against host for passive
+// outlier detection. gatewayFailure additionally counts the failure towards
+// ConsecutiveGatewayFailure, for connect failures, local resets and 502/503/
+// 504 responses. Once either consecutive error threshold configured on the
+// host's cluster is reached, the host is ejected: marked
+// api.FAILED_OUTLIER_CHECK for BaseEjectionTime (scaled by how many times it
+// has already been ejected), after which it is automatically unejected.
+func RecordOutlierError(host types.Host, gatewayFailure bool) {
+	if host == nil || host.ClusterInfo() == nil {
+		return
+	}
+	cfg := host.ClusterInfo().OutlierDetection()
+	consecutive5xx := cfg.Consecutive5xx
+	if consecutive5xx == 0 {
+		consecutive5xx = defaultConsecutive5xx
+	}
+	consecutiveGatewayFailure := cfg.ConsecutiveGatewayFailure
+	if consecutiveGatewayFailure == 0 {
+		consecutiveGatewayFailure = defaultConsecutiveGatewayFailure
+	}
+
+	state := getOutlierState(host.AddressString())
+	state.mutex.Lock()
+	state.windowRequests++
+	state.consecutive5xx++
+	eject := state.consecutive5xx >= consecutive5xx
+	if gatewayFailure {
+		state.consecutiveGateway++
+		eject = eject || state.consecutiveGateway >= consecutiveGatewayFailure
+	}
+	if eject {
+		state.consecutive5xx = 0
+		state.consecutiveGateway = 0
+		state.ejectionCount++
+	}
+	ejectionCount := state.ejectionCount
+	state.mutex.Unlock()
+
+	if eject {
+		ejectHost(host, state, ejectionCount, cfg)
+	}
+}
+
+// ejectHost marks host unhealthy via api.FAILED_OUTLIER_CHECK and schedules
+// it to be automatically unejected after BaseEjectionTime * ejectionCount,
+// the same "multiply by number of past ejections" backoff Envoy uses.
+func ejectHost(host types.Host, state *outlierState, ejectionCount uint32, cfg v2.OutlierDetection) {
+	baseEjectionTime := defaultBaseEjectionTime
+	if cfg.BaseEjectionTime.Duration > 0 {
+		baseEjectionTime = cfg.BaseEjectionTime.Duration
+	}
+	ejectionTime := time.Duration(ejectionCount) * baseEjectionTime
+
+	host.SetHealthFlag(api.FAILED_OUTLIER_CHECK)
+	if ci := host.ClusterInfo(); ci != nil {
+		ci.Stats().OutlierEjectTotal.Inc(1)
+	}
+	log.DefaultLogger.Infof("[upstream] [outlier detection] host %s ejected for %s", host.AddressString(), ejectionTime)
+
+	state.mutex.Lock()
+	state.lastEjectionTime = time.Now()
+	if state.ejectTimer != nil {
+		state.ejectTimer.Stop()
+	}
+	state.ejectTimer = time.AfterFunc(ejectionTime, func() {
+		host.ClearHealthFlag(api.FAILED_OUTLIER_CHECK)
+		if ci := host.ClusterInfo(); ci != nil {
+			ci.Stats().OutlierUnejectTotal.Inc(1)
+		}
+		log.DefaultLogger.Infof("[upstream] [outlier detection] host %s unejected", host.AddressString())
+	})
+	state.mutex.Unlock()
+}
+
+// outlierDetector periodically sweeps a cluster's hosts for success-rate
+// based outlier ejection. Unlike the consecutive-error ejection done inline
+// by RecordOutlierError, this needs to look at the whole host set at once:
+// it computes the mean and standard deviation of per-host success rate
+// over the last interval, ejects hosts falling too far below the mean, and
+// caps how many hosts may be ejected via MaxEjectionPercent.
+type outlierDetector struct {
+	info types.ClusterInfo
+
+	mutex sync.Mutex
+	hosts []types.Host
+
+	interval time.Duration
+	rander   *rand.Rand
+	stop     chan struct{}
+}
+
+func newOutlierDetector(info types.ClusterInfo) *outlierDetector {
+	interval := defaultSuccessRateInterval
+	if d := info.OutlierDetection().Interval.Duration; d > 0 {
+		interval = d
+	}
+	return &outlierDetector{
+		info:     info,
+		interval: interval,
+		rander:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:     make(chan struct{}),
+	}
+}
+
+// only called in cluster, lock in cluster
+func (d *outlierDetector) SetHosts(hostSet types.HostSet) {
+	d.mutex.Lock()
+	d.hosts = hostSet.Hosts()
+	d.mutex.Unlock()
+}
+
+// only called in cluster, lock in cluster
+func (d *outlierDetector) Start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.sweep()
+			case <-d.stop:
+				return
+			}
+		}
+	}, nil)
+}
+
+// only called in cluster, lock in cluster
+func (d *outlierDetector) Stop() {
+	close(d.stop)
+}
+
+// successRate is a host's success rate over the last sweep interval.
+type successRate struct {
+	host types.Host
+	rate float64
+}
+
+func (d *outlierDetector) sweep() {
+	d.mutex.Lock()
+	hosts := d.hosts
+	d.mutex.Unlock()
+
+	cfg := d.info.OutlierDetection()
+	minimumHosts := cfg.SuccessRateMinimumHosts
+	if minimumHosts == 0 {
+		minimumHosts = defaultSuccessRateMinimumHosts
+	}
+	requestVolume := cfg.SuccessRateRequestVolume
+	if requestVolume == 0 {
+		requestVolume = defaultSuccessRateRequestVolume
+	}
+
+	var rates []successRate
+	ejectedCount := 0
+	for _, host := range hosts {
+		if host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+			ejectedCount++
+		}
+		state := getOutlierState(host.AddressString())
+		requests, successes := state.snapshotAndResetWindow()
+		if requests < requestVolume {
+			continue
+		}
+		rate := float64(successes) / float64(requests) * 100
+
+		state.mutex.Lock()
+		state.lastSuccessRate = rate
+		state.hasSuccessRate = true
+		state.mutex.Unlock()
+
+		rates = append(rates, successRate{host: host, rate: rate})
+	}
+	if len(rates) < int(minimumHosts) {
+		return
+	}
+
+	mean, stdev := meanAndStdev(rates)
+	stdevFactor := cfg.SuccessRateStdevFactor
+	if stdevFactor == 0 {
+		stdevFactor = defaultSuccessRateStdevFactor
+	}
+	threshold := mean - stdev*(float64(stdevFactor)/1000)
+
+	maxEjectionPercent := cfg.MaxEjectionPercent
+	if maxEjectionPercent == 0 {
+		maxEjectionPercent = defaultMaxEjectionPercent
+	}
+	maxEjections := len(hosts) * int(maxEjectionPercent) / 100
+
+	enforcingSuccessRate := cfg.EnforcingSuccessRate
+	if enforcingSuccessRate == 0 {
+		enforcingSuccessRate = defaultEnforcingSuccessRate
+	}
+
+	for _, r := range rates {
+		if r.rate >= threshold {
+			continue
+		}
+		if ejectedCount >= maxEjections {
+			log.DefaultLogger.Infof("[upstream] [outlier detection] host %s is a success rate outlier but max ejection percent reached, not ejecting", r.host.AddressString())
+			continue
+		}
+		if d.rander.Intn(100) >= int(enforcingSuccessRate) {
+			continue
+		}
+
+		state := getOutlierState(r.host.AddressString())
+		state.mutex.Lock()
+		state.ejectionCount++
+		ejectionCount := state.ejectionCount
+		state.mutex.Unlock()
+
+		ejectHost(r.host, state, ejectionCount, cfg)
+		ejectedCount++
+	}
+}
+
+// GetOutlierHostState returns host's current passive outlier detection
+// state, for admin-facing inspection.
+func GetOutlierHostState(host types.Host) types.OutlierHostState {
+	state := getOutlierState(host.AddressString())
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return types.OutlierHostState{
+		Address:          host.AddressString(),
+		Ejected:          host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK),
+		EjectionCount:    state.ejectionCount,
+		LastEjectionTime: state.lastEjectionTime,
+		SuccessRate:      state.lastSuccessRate,
+		HasSuccessRate:   state.hasSuccessRate,
+	}
+}
+
+// meanAndStdev computes the population mean and standard deviation of the
+// success rates in rates.
+func meanAndStdev(rates []successRate) (mean, stdev float64) {
+	var sum float64
+	for _, r := range rates {
+		sum += r.rate
+	}
+	mean = sum / float64(len(rates))
+
+	var variance float64
+	for _, r := range rates {
+		variance += (r.rate - mean) * (r.rate - mean)
+	}
+	variance /= float64(len(rates))
+	stdev = math.Sqrt(variance)
+	return
+}
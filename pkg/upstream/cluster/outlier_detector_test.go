@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestOutlierDetectionConsecutive5xx(t *testing.T) {
+	healthStore = sync.Map{}
+	outlierStore = sync.Map{}
+
+	addr := "127.0.0.1:8081"
+	info := &clusterInfo{
+		stats: newClusterStats("outlier-test-1"),
+		outlierDetection: v2.OutlierDetection{
+			Consecutive5xx:   3,
+			BaseEjectionTime: api.DurationConfig{Duration: time.Millisecond},
+		},
+	}
+	host := &simpleHost{
+		addressString: addr,
+		clusterInfo:   info,
+		healthFlags:   GetHealthFlagPointer(addr),
+	}
+
+	for i := 0; i < 2; i++ {
+		RecordOutlierError(host, false)
+	}
+	if host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should not be ejected before reaching the consecutive 5xx threshold")
+	}
+
+	RecordOutlierError(host, false)
+	if !host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should be ejected after reaching the consecutive 5xx threshold")
+	}
+
+	// host is automatically unejected once BaseEjectionTime elapses
+	time.Sleep(50 * time.Millisecond)
+	if host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should be unejected after its ejection time elapses")
+	}
+}
+
+func TestOutlierDetectionSuccessResetsCounter(t *testing.T) {
+	healthStore = sync.Map{}
+	outlierStore = sync.Map{}
+
+	addr := "127.0.0.1:8082"
+	info := &clusterInfo{
+		stats: newClusterStats("outlier-test-2"),
+		outlierDetection: v2.OutlierDetection{
+			Consecutive5xx: 3,
+		},
+	}
+	host := &simpleHost{
+		addressString: addr,
+		clusterInfo:   info,
+		healthFlags:   GetHealthFlagPointer(addr),
+	}
+
+	RecordOutlierError(host, false)
+	RecordOutlierError(host, false)
+	RecordOutlierSuccess(host)
+	RecordOutlierError(host, false)
+	RecordOutlierError(host, false)
+	if host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("a success in between should have reset the consecutive error count")
+	}
+}
+
+func TestOutlierDetectionConsecutiveGatewayFailure(t *testing.T) {
+	healthStore = sync.Map{}
+	outlierStore = sync.Map{}
+
+	addr := "127.0.0.1:8083"
+	info := &clusterInfo{
+		stats: newClusterStats("outlier-test-3"),
+		outlierDetection: v2.OutlierDetection{
+			Consecutive5xx:            100,
+			ConsecutiveGatewayFailure: 2,
+		},
+	}
+	host := &simpleHost{
+		addressString: addr,
+		clusterInfo:   info,
+		healthFlags:   GetHealthFlagPointer(addr),
+	}
+
+	RecordOutlierError(host, true)
+	if host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should not be ejected before reaching the consecutive gateway failure threshold")
+	}
+	RecordOutlierError(host, true)
+	if !host.ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should be ejected after reaching the consecutive gateway failure threshold")
+	}
+}
+
+func TestOutlierDetectionSuccessRateSweep(t *testing.T) {
+	healthStore = sync.Map{}
+	outlierStore = sync.Map{}
+
+	info := &clusterInfo{
+		stats: newClusterStats("outlier-test-4"),
+		outlierDetection: v2.OutlierDetection{
+			// a high consecutive5xx threshold keeps this test isolated to the
+			// success-rate sweep, rather than also tripping consecutive-error
+			// ejection.
+			Consecutive5xx:           1000,
+			SuccessRateMinimumHosts:  5,
+			SuccessRateRequestVolume: 10,
+			SuccessRateStdevFactor:   1900,
+			MaxEjectionPercent:       100,
+			EnforcingSuccessRate:     100,
+			BaseEjectionTime:         api.DurationConfig{Duration: time.Hour},
+		},
+	}
+
+	var hosts []types.Host
+	for i := 0; i < 5; i++ {
+		addr := "127.0.0.1:809" + string(rune('0'+i))
+		host := &simpleHost{
+			addressString: addr,
+			clusterInfo:   info,
+			healthFlags:   GetHealthFlagPointer(addr),
+		}
+		hosts = append(hosts, host)
+		for j := 0; j < 10; j++ {
+			if i == 0 {
+				// one host fails every request, the rest are all healthy
+				RecordOutlierError(host, false)
+			} else {
+				RecordOutlierSuccess(host)
+			}
+		}
+	}
+
+	detector := newOutlierDetector(info)
+	detector.SetHosts(&hostSet{allHosts: hosts})
+	detector.sweep()
+
+	if !hosts[0].ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host with a 0% success rate should be ejected as a statistical outlier")
+	}
+	for i := 1; i < len(hosts); i++ {
+		if hosts[i].ContainHealthFlag(api.FAILED_OUTLIER_CHECK) {
+			t.Fatalf("host %d with a 100%% success rate should not be ejected", i)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// hostPriority returns the priority level of a host, defaulting to 0 (the
+// highest priority) when the host carries no types.PriorityMetadataKey.
+func hostPriority(host types.Host) int {
+	v, ok := host.Metadata()[types.PriorityMetadataKey]
+	if !ok {
+		return 0
+	}
+	p, err := strconv.Atoi(v)
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
+// priorityLoadBalancer groups hosts into priority levels and only drains
+// traffic into a lower priority level once the healthy percentage of every
+// higher priority level has collapsed below its overprovisioning threshold.
+type priorityLoadBalancer struct {
+	mutex                      sync.Mutex
+	rand                       *rand.Rand
+	hosts                      types.HostSet
+	defaultOverprovisionFactor float64
+	overprovisionFactors       map[int]float64
+	rrLB                       types.LoadBalancer
+}
+
+func newPriorityLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	lb := &priorityLoadBalancer{
+		rand:                       rand.New(rand.NewSource(time.Now().UnixNano())),
+		hosts:                      hosts,
+		defaultOverprovisionFactor: types.DefaultPriorityOverprovisioningFactor,
+		rrLB:                       rrFactory.newRoundRobinLoadBalancer(info, hosts),
+	}
+	if info != nil && info.LbConfig() != nil {
+		if cfg, ok := info.LbConfig().(*v2.PriorityLbConfig); ok {
+			if cfg.OverprovisioningFactor > 0 {
+				lb.defaultOverprovisionFactor = cfg.OverprovisioningFactor
+			}
+			lb.overprovisionFactors = cfg.PriorityOverprovisioningFactors
+		}
+	}
+	return lb
+}
+
+// overprovisionFactor returns the overprovisioning factor configured for
+// priority level p, falling back to the cluster-wide default when p has no
+// override.
+func (lb *priorityLoadBalancer) overprovisionFactor(p int) float64 {
+	if f, ok := lb.overprovisionFactors[p]; ok && f > 0 {
+		return f
+	}
+	return lb.defaultOverprovisionFactor
+}
+
+// priorityLevels groups the current hosts by priority and returns the
+// distinct priority numbers in ascending (highest-priority-first) order.
+func (lb *priorityLoadBalancer) priorityLevels() map[int][]types.Host {
+	levels := make(map[int][]types.Host)
+	for _, h := range lb.hosts.Hosts() {
+		p := hostPriority(h)
+		levels[p] = append(levels[p], h)
+	}
+	return levels
+}
+
+// PriorityStats reports, for every priority level currently present, the
+// fraction of its hosts that are healthy.
+func (lb *priorityLoadBalancer) PriorityStats() map[int]float64 {
+	stats := make(map[int]float64)
+	for p, hosts := range lb.priorityLevels() {
+		healthy := 0
+		for _, h := range hosts {
+			if h.Health() {
+				healthy++
+			}
+		}
+		stats[p] = float64(healthy) / float64(len(hosts))
+	}
+	return stats
+}
+
+func (lb *priorityLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	levels := lb.priorityLevels()
+	if len(levels) == 0 {
+		return nil
+	}
+	priorities := make([]int, 0, len(levels))
+	for p := range levels {
+		priorities = append(priorities, p)
+	}
+	sort.Ints(priorities)
+
+	for _, p := range priorities {
+		hosts := levels[p]
+		var healthy []types.Host
+		for _, h := range hosts {
+			if h.Health() {
+				healthy = append(healthy, h)
+			}
+		}
+		healthyPct := float64(len(healthy)) / float64(len(hosts))
+		factor := lb.overprovisionFactor(p)
+		// this priority level still has enough capacity on its own: stop
+		// draining further down and serve from it.
+		if healthyPct*factor >= 1 {
+			if len(healthy) > 0 {
+				return lb.pick(healthy)
+			}
+			continue
+		}
+		// this level is degraded: serve the fraction of traffic its
+		// remaining capacity can take and let the rest fall through to the
+		// next priority level.
+		if len(healthy) > 0 {
+			lb.mutex.Lock()
+			roll := lb.rand.Float64()
+			lb.mutex.Unlock()
+			if roll < healthyPct*factor {
+				return lb.pick(healthy)
+			}
+		}
+	}
+
+	// every priority level is overloaded: fall back to the globally
+	// healthiest available host rather than dropping the request.
+	return lb.rrLB.ChooseHost(context)
+}
+
+func (lb *priorityLoadBalancer) pick(hosts []types.Host) types.Host {
+	lb.mutex.Lock()
+	idx := lb.rand.Intn(len(hosts))
+	lb.mutex.Unlock()
+	return hosts[idx]
+}
+
+func (lb *priorityLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *priorityLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
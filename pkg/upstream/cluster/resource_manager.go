@@ -20,7 +20,9 @@ package cluster
 import (
 	"sync/atomic"
 
+	gometrics "github.com/rcrowley/go-metrics"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -32,74 +34,137 @@ const (
 	DefaultMaxRetries         uint64 = 0
 )
 
-// ResourceManager
-type resourcemanager struct {
+// resourceSet is one priority's circuit breaker thresholds.
+type resourceSet struct {
 	connections     *resource
 	pendingRequests *resource
 	requests        *resource
 	retries         *resource
 }
 
-func NewResourceManager(circuitBreakers v2.CircuitBreakers) types.ResourceManager {
-	maxConnections := DefaultMaxConnections
-	maxPendingRequests := DefaultMaxPendingRequests
-	maxRequests := DefaultMaxRequests
-	maxRetries := DefaultMaxRetries
+func newResourceSet(clusterName string, priority v2.RoutingPriority, t v2.Thresholds) *resourceSet {
+	s := metrics.NewClusterResourceStats(clusterName, string(priority))
+	return &resourceSet{
+		connections:     newResource(uint64(t.MaxConnections), s.Gauge(metrics.UpstreamCircuitBreakerConnectionsRemaining)),
+		pendingRequests: newResource(uint64(t.MaxPendingRequests), s.Gauge(metrics.UpstreamCircuitBreakerPendingRequestsRemaining)),
+		requests:        newResource(uint64(t.MaxRequests), s.Gauge(metrics.UpstreamCircuitBreakerRequestsRemaining)),
+		retries:         newResource(uint64(t.MaxRetries), s.Gauge(metrics.UpstreamCircuitBreakerRetriesRemaining)),
+	}
+}
+
+// ResourceManager
+type resourcemanager struct {
+	def  *resourceSet
+	high *resourceSet
+}
 
-	// note: we don't support group cb by priority
-	if circuitBreakers.Thresholds != nil && len(circuitBreakers.Thresholds) > 0 {
-		maxConnections = uint64(circuitBreakers.Thresholds[0].MaxConnections)
-		maxPendingRequests = uint64(circuitBreakers.Thresholds[0].MaxPendingRequests)
-		maxRequests = uint64(circuitBreakers.Thresholds[0].MaxRequests)
-		maxRetries = uint64(circuitBreakers.Thresholds[0].MaxRetries)
+// NewResourceManager builds a ResourceManager from a cluster's configured
+// circuit breaker thresholds. circuitBreakers may contain one threshold
+// set per v2.RoutingPriority; a priority with no threshold set of its own
+// shares the DEFAULT priority's thresholds, so existing single-entry
+// configurations keep applying to all traffic as before.
+func NewResourceManager(clusterName string, circuitBreakers v2.CircuitBreakers) types.ResourceManager {
+	def := v2.Thresholds{}
+	high := v2.Thresholds{}
+	hasHigh := false
+	for _, t := range circuitBreakers.Thresholds {
+		if t.Priority == v2.HIGH_ROUTING_PRIORITY {
+			high = t
+			hasHigh = true
+			continue
+		}
+		def = t
 	}
 
-	return &resourcemanager{
-		connections: &resource{
-			max: maxConnections,
-		},
-		pendingRequests: &resource{
-			max: maxPendingRequests,
-		},
-		requests: &resource{
-			max: maxRequests,
-		},
-		retries: &resource{
-			max: maxRetries,
-		},
+	rm := &resourcemanager{
+		def: newResourceSet(clusterName, v2.DEFAULT_ROUTING_PRIORITY, def),
 	}
+	if hasHigh {
+		rm.high = newResourceSet(clusterName, v2.HIGH_ROUTING_PRIORITY, high)
+	} else {
+		rm.high = rm.def
+	}
+	return rm
 }
 
 func (rm *resourcemanager) Connections() types.Resource {
-	return rm.connections
+	return rm.def.connections
 }
 
 func (rm *resourcemanager) PendingRequests() types.Resource {
-	return rm.pendingRequests
+	return rm.def.pendingRequests
 }
 
 func (rm *resourcemanager) Requests() types.Resource {
-	return rm.requests
+	return rm.def.requests
 }
 
 func (rm *resourcemanager) Retries() types.Resource {
-	return rm.retries
+	return rm.def.retries
+}
+
+func (rm *resourcemanager) ForPriority(priority v2.RoutingPriority) types.ResourceManager {
+	if priority == v2.HIGH_ROUTING_PRIORITY {
+		return &priorityResourceManager{set: rm.high}
+	}
+	return &priorityResourceManager{set: rm.def}
+}
+
+// priorityResourceManager is the ResourceManager view ForPriority returns:
+// it is already scoped to one resourceSet, so a further ForPriority call
+// just returns itself.
+type priorityResourceManager struct {
+	set *resourceSet
+}
+
+func (rm *priorityResourceManager) Connections() types.Resource {
+	return rm.set.connections
+}
+
+func (rm *priorityResourceManager) PendingRequests() types.Resource {
+	return rm.set.pendingRequests
+}
+
+func (rm *priorityResourceManager) Requests() types.Resource {
+	return rm.set.requests
+}
+
+func (rm *priorityResourceManager) Retries() types.Resource {
+	return rm.set.retries
+}
+
+func (rm *priorityResourceManager) ForPriority(priority v2.RoutingPriority) types.ResourceManager {
+	return rm
 }
 
 func updateResourceValue(oldRM, newRM types.ResourceManager) {
 	nrm := newRM.(*resourcemanager)
 	orm := oldRM.(*resourcemanager)
 
-	orm.connections.max = nrm.connections.max
-	orm.pendingRequests.max = nrm.pendingRequests.max
-	orm.requests.max = nrm.requests.max
-	orm.retries.max = nrm.retries.max
+	updateResourceSetValue(orm.def, nrm.def)
+	if orm.high != orm.def || nrm.high != nrm.def {
+		updateResourceSetValue(orm.high, nrm.high)
+	}
+}
+
+func updateResourceSetValue(old, new *resourceSet) {
+	old.connections.max = new.connections.max
+	old.pendingRequests.max = new.pendingRequests.max
+	old.requests.max = new.requests.max
+	old.retries.max = new.retries.max
 }
 
 // Resource
 type resource struct {
-	current int64
-	max     uint64
+	current   int64
+	max       uint64
+	remaining gometrics.Gauge
+}
+
+func newResource(max uint64, remaining gometrics.Gauge) *resource {
+	r := &resource{max: max, remaining: remaining}
+	r.refreshRemaining()
+	return r
 }
 
 func (r *resource) CanCreate() bool {
@@ -118,12 +183,14 @@ func (r *resource) CanCreate() bool {
 func (r *resource) Increase() {
 	if r.max != 0 {
 		atomic.AddInt64(&r.current, 1)
+		r.refreshRemaining()
 	}
 }
 
 func (r *resource) Decrease() {
 	if r.max != 0 {
 		atomic.AddInt64(&r.current, -1)
+		r.refreshRemaining()
 	}
 }
 
@@ -137,4 +204,25 @@ func (r *resource) Cur() int64 {
 
 func (r *resource) UpdateCur(cur int64) {
 	r.current = cur
+	r.refreshRemaining()
+}
+
+func (r *resource) Remaining() int64 {
+	if r.max == 0 {
+		return -1
+	}
+	remaining := int64(r.max) - atomic.LoadInt64(&r.current)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// refreshRemaining updates the resource's remaining-capacity gauge, if one
+// is registered, so dashboards scraping cluster metrics can show headroom
+// without polling the admin API.
+func (r *resource) refreshRemaining() {
+	if r.remaining != nil {
+		r.remaining.Update(r.Remaining())
+	}
 }
@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestResourceManagerDefaultOnly(t *testing.T) {
+	rm := NewResourceManager("test_rm_default", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 2},
+		},
+	})
+
+	if !rm.Connections().CanCreate() {
+		t.Fatal("expected connections resource to allow creation below max")
+	}
+	rm.Connections().Increase()
+	rm.Connections().Increase()
+	if rm.Connections().CanCreate() {
+		t.Fatal("expected connections resource to be exhausted at max")
+	}
+
+	// HIGH priority should fall back to the DEFAULT thresholds when none
+	// of its own are configured, sharing the same underlying resource.
+	high := rm.ForPriority(v2.HIGH_ROUTING_PRIORITY)
+	if high.Connections().CanCreate() {
+		t.Fatal("expected HIGH priority to share the exhausted DEFAULT connections resource")
+	}
+}
+
+func TestResourceManagerPerPriority(t *testing.T) {
+	rm := NewResourceManager("test_rm_priority", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 1},
+			{Priority: v2.HIGH_ROUTING_PRIORITY, MaxConnections: 2},
+		},
+	})
+
+	rm.Connections().Increase()
+	if rm.Connections().CanCreate() {
+		t.Fatal("expected DEFAULT priority connections resource to be exhausted at max 1")
+	}
+
+	high := rm.ForPriority(v2.HIGH_ROUTING_PRIORITY)
+	if !high.Connections().CanCreate() {
+		t.Fatal("expected HIGH priority connections resource to have its own, unexhausted limit")
+	}
+	high.Connections().Increase()
+	high.Connections().Increase()
+	if high.Connections().CanCreate() {
+		t.Fatal("expected HIGH priority connections resource to be exhausted at its own max 2")
+	}
+
+	// ForPriority on an already-scoped manager just returns itself.
+	if high.ForPriority(v2.DEFAULT_ROUTING_PRIORITY) != high {
+		t.Fatal("expected ForPriority on a scoped ResourceManager to return itself")
+	}
+}
+
+func TestResourceRemaining(t *testing.T) {
+	rm := NewResourceManager("test_rm_remaining", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 3},
+		},
+	})
+
+	r := rm.Connections()
+	if got := r.Remaining(); got != 3 {
+		t.Fatalf("expected remaining 3 before any use, got %d", got)
+	}
+	r.Increase()
+	if got := r.Remaining(); got != 2 {
+		t.Fatalf("expected remaining 2 after one increase, got %d", got)
+	}
+	r.Decrease()
+	if got := r.Remaining(); got != 3 {
+		t.Fatalf("expected remaining 3 after decrease, got %d", got)
+	}
+
+	unlimited := rm.Requests()
+	if got := unlimited.Remaining(); got != -1 {
+		t.Fatalf("expected -1 remaining for an unconfigured, unlimited resource, got %d", got)
+	}
+}
+
+func TestUpdateResourceValue(t *testing.T) {
+	oldRM := NewResourceManager("test_rm_update", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 1},
+			{Priority: v2.HIGH_ROUTING_PRIORITY, MaxConnections: 1},
+		},
+	})
+	newRM := NewResourceManager("test_rm_update", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxConnections: 5},
+			{Priority: v2.HIGH_ROUTING_PRIORITY, MaxConnections: 10},
+		},
+	})
+
+	updateResourceValue(oldRM, newRM)
+
+	if got := oldRM.Connections().Max(); got != 5 {
+		t.Fatalf("expected DEFAULT max updated to 5, got %d", got)
+	}
+	if got := oldRM.ForPriority(v2.HIGH_ROUTING_PRIORITY).Connections().Max(); got != 10 {
+		t.Fatalf("expected HIGH max updated to 10, got %d", got)
+	}
+}
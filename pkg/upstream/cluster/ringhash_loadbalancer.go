@@ -0,0 +1,245 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dchest/siphash"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	defaultMinRingSize = 1024
+	defaultMaxRingSize = 1024 * 1024 * 8
+
+	// defaultBoundedLoadFactor allows a host to carry up to 25% more than
+	// the cluster's average active requests before bounded-load spillover
+	// walks the ring to the next host, matching Envoy's default.
+	defaultBoundedLoadFactor = 1.25
+)
+
+// ringHashEntry is a single point on the ring-hash's ring.
+type ringHashEntry struct {
+	hash uint64
+	host types.Host
+}
+
+// ringHashLoadBalancer is a consistent hash (ketama style) load balancer.
+// Every host is hashed onto a ring a number of times proportional to its
+// weight; ChooseHost hashes the request (using the route's HashPolicy) and
+// walks clockwise to the nearest ring entry.
+type ringHashLoadBalancer struct {
+	hosts types.HostSet
+	ring  []ringHashEntry
+	info  types.ClusterInfo
+
+	useBoundedLoads   bool
+	boundedLoadFactor float64
+}
+
+func newRingHashLoadBalancer(info types.ClusterInfo, set types.HostSet) types.LoadBalancer {
+	lb := &ringHashLoadBalancer{
+		hosts:             set,
+		info:              info,
+		boundedLoadFactor: defaultBoundedLoadFactor,
+	}
+
+	minRingSize := uint64(defaultMinRingSize)
+	maxRingSize := uint64(defaultMaxRingSize)
+	if info != nil && info.LbConfig() != nil {
+		if cfg, ok := info.LbConfig().(*v2.RingHashLbConfig); ok {
+			if cfg.MinRingSize > 0 {
+				minRingSize = cfg.MinRingSize
+			}
+			if cfg.MaxRingSize > 0 {
+				maxRingSize = cfg.MaxRingSize
+			}
+			lb.useBoundedLoads = cfg.UseBoundedLoads
+			if cfg.BoundedLoadFactor > 1 {
+				lb.boundedLoadFactor = cfg.BoundedLoadFactor
+			}
+		}
+	}
+	if maxRingSize < minRingSize {
+		maxRingSize = minRingSize
+	}
+
+	lb.buildRing(minRingSize, maxRingSize)
+	return lb
+}
+
+// buildRing builds a ring whose size is the sum of each host's weighted
+// share of minRingSize, never exceeding maxRingSize.
+func (lb *ringHashLoadBalancer) buildRing(minRingSize, maxRingSize uint64) {
+	hosts := lb.hosts.Hosts()
+	if len(hosts) == 0 {
+		return
+	}
+
+	var totalWeight uint64
+	for _, h := range hosts {
+		w := uint64(h.Weight())
+		if w == 0 {
+			w = 1
+		}
+		totalWeight += w
+	}
+
+	ring := make([]ringHashEntry, 0, minRingSize)
+	for _, h := range hosts {
+		w := uint64(h.Weight())
+		if w == 0 {
+			w = 1
+		}
+		replicas := w * minRingSize / totalWeight
+		if replicas == 0 {
+			replicas = 1
+		}
+		for i := uint64(0); i < replicas && uint64(len(ring)) < maxRingSize; i++ {
+			key := fmt.Sprintf("%s_%d", h.AddressString(), i)
+			hash := siphash.Hash(0, 0, []byte(key))
+			ring = append(ring, ringHashEntry{hash: hash, host: h})
+		}
+	}
+
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	lb.ring = ring
+}
+
+func (lb *ringHashLoadBalancer) ChooseHost(ctx types.LoadBalancerContext) types.Host {
+	if len(lb.ring) == 0 {
+		return nil
+	}
+
+	hash, ok := hashFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	chosen := lb.lookup(hash)
+	if chosen != nil && !chosen.Health() {
+		chosen = lb.chooseHealthyFrom(hash)
+	}
+
+	if chosen != nil && lb.useBoundedLoads {
+		chosen = lb.applyBoundedLoad(hash, chosen)
+	}
+
+	if chosen == nil {
+		log.Proxy.Infof(ctx.DownstreamContext(), "[lb][ringhash] hash %d got nil host", hash)
+	}
+	return chosen
+}
+
+// applyBoundedLoad implements consistent hashing with bounded loads: if start
+// is already carrying more than boundedLoadFactor times the cluster's
+// average active requests, the ring is walked clockwise from hash until a
+// host under that cap is found. If every host is over the cap, start is
+// returned as-is.
+func (lb *ringHashLoadBalancer) applyBoundedLoad(hash uint64, start types.Host) types.Host {
+	avg := lb.averageActiveRequests()
+	if avg <= 0 {
+		return start
+	}
+	loadCap := avg * lb.boundedLoadFactor
+	if float64(start.HostStats().UpstreamRequestActive.Count()) < loadCap {
+		return start
+	}
+
+	idx := sort.Search(len(lb.ring), func(i int) bool {
+		return lb.ring[i].hash >= hash
+	})
+	total := len(lb.ring)
+	for i := 0; i < total; i++ {
+		entry := lb.ring[(idx+i)%total]
+		if !entry.host.Health() {
+			continue
+		}
+		if float64(entry.host.HostStats().UpstreamRequestActive.Count()) < loadCap {
+			if entry.host != start {
+				lb.recordSpillover()
+			}
+			return entry.host
+		}
+	}
+	// every host is over the cap: stick with the originally hashed host
+	// rather than dropping the request.
+	return start
+}
+
+// averageActiveRequests returns the cluster's average active request count
+// across its distinct hosts.
+func (lb *ringHashLoadBalancer) averageActiveRequests() float64 {
+	hosts := lb.hosts.Hosts()
+	if len(hosts) == 0 {
+		return 0
+	}
+	var total int64
+	for _, h := range hosts {
+		total += h.HostStats().UpstreamRequestActive.Count()
+	}
+	return float64(total) / float64(len(hosts))
+}
+
+func (lb *ringHashLoadBalancer) recordSpillover() {
+	if lb.info != nil {
+		lb.info.Stats().LBBoundedLoadSpillover.Inc(1)
+	}
+}
+
+// lookup finds the first ring entry whose hash is >= the given hash,
+// wrapping around to the first entry if the hash is greater than all of them.
+func (lb *ringHashLoadBalancer) lookup(hash uint64) types.Host {
+	idx := sort.Search(len(lb.ring), func(i int) bool {
+		return lb.ring[i].hash >= hash
+	})
+	if idx == len(lb.ring) {
+		idx = 0
+	}
+	return lb.ring[idx].host
+}
+
+// chooseHealthyFrom walks the ring clockwise from hash to find a healthy host.
+func (lb *ringHashLoadBalancer) chooseHealthyFrom(hash uint64) types.Host {
+	idx := sort.Search(len(lb.ring), func(i int) bool {
+		return lb.ring[i].hash >= hash
+	})
+	total := len(lb.ring)
+	for i := 0; i < total; i++ {
+		entry := lb.ring[(idx+i)%total]
+		if entry.host.Health() {
+			return entry.host
+		}
+	}
+	return nil
+}
+
+func (lb *ringHashLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *ringHashLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
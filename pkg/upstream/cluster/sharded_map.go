@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// clusterMapShards is the number of shards a shardedClusterMap splits its
+// keys across. A config push that adds or updates many clusters spreads
+// its writes across these shards instead of contending on sync.Map's
+// single dirty-map mutex, so it no longer serializes behind unrelated
+// clusters' updates.
+const clusterMapShards = 64
+
+// shardedClusterMap is a map[string]interface{} split into a fixed number
+// of independently-locked sync.Map shards, keyed by a hash of the lookup
+// name. It exists for clusterManager.clustersMap: with tens of thousands
+// of clusters, concurrent AddOrUpdatePrimaryCluster calls from a bulk
+// config push would otherwise all contend on the same sync.Map's dirty
+// map mutex. Reads (Load, Range) are unaffected either way, since
+// sync.Map already serves those without blocking.
+type shardedClusterMap struct {
+	shards [clusterMapShards]sync.Map
+}
+
+func (m *shardedClusterMap) shardFor(key string) *sync.Map {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &m.shards[h.Sum32()%clusterMapShards]
+}
+
+func (m *shardedClusterMap) Load(key string) (value interface{}, ok bool) {
+	return m.shardFor(key).Load(key)
+}
+
+func (m *shardedClusterMap) Store(key string, value interface{}) {
+	m.shardFor(key).Store(key, value)
+}
+
+func (m *shardedClusterMap) Delete(key string) {
+	m.shardFor(key).Delete(key)
+}
+
+// Range calls f sequentially for each key and value present in every
+// shard. As with sync.Map.Range, f's return value of false stops the
+// iteration, but only within the shard currently being ranged over.
+func (m *shardedClusterMap) Range(f func(key, value interface{}) bool) {
+	for i := range m.shards {
+		stop := false
+		m.shards[i].Range(func(k, v interface{}) bool {
+			if !f(k, v) {
+				stop = true
+				return false
+			}
+			return true
+		})
+		if stop {
+			return
+		}
+	}
+}
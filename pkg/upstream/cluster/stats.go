@@ -18,6 +18,7 @@
 package cluster
 
 import (
+	gometrics "github.com/rcrowley/go-metrics"
 	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 )
@@ -35,6 +36,11 @@ func newHostStats(clustername string, addr string) types.HostStats {
 		UpstreamConnectionLocalCloseWithActiveRequest:  s.Counter(metrics.UpstreamConnectionLocalCloseWithActiveRequest),
 		UpstreamConnectionRemoteCloseWithActiveRequest: s.Counter(metrics.UpstreamConnectionRemoteCloseWithActiveRequest),
 		UpstreamConnectionCloseNotify:                  s.Counter(metrics.UpstreamConnectionCloseNotify),
+		UpstreamConnectionPendingOverflow:              s.Counter(metrics.UpstreamConnectionPendingOverflow),
+		UpstreamConnectionIdle:                         s.Gauge(metrics.UpstreamConnectionIdle),
+		UpstreamConnectionConnectDuration:              s.Histogram(metrics.UpstreamConnectionConnectDuration),
+		UpstreamConnectionTLSHandshakeDuration:         s.Histogram(metrics.UpstreamConnectionTLSHandshakeDuration),
+		UpstreamRequestQueueDepth:                      s.Gauge(metrics.UpstreamRequestQueueDepth),
 		UpstreamRequestTotal:                           s.Counter(metrics.UpstreamRequestTotal),
 		UpstreamRequestActive:                          s.Counter(metrics.UpstreamRequestActive),
 		UpstreamRequestLocalReset:                      s.Counter(metrics.UpstreamRequestLocalReset),
@@ -46,6 +52,23 @@ func newHostStats(clustername string, addr string) types.HostStats {
 		UpstreamRequestDurationTotal:                   s.Counter(metrics.UpstreamRequestDurationTotal),
 		UpstreamResponseSuccess:                        s.Counter(metrics.UpstreamResponseSuccess),
 		UpstreamResponseFailed:                         s.Counter(metrics.UpstreamResponseFailed),
+		UpstreamRequestGRPCTotal:                       s.Counter(metrics.UpstreamRequestGRPCTotal),
+		UpstreamResponseGRPCSuccess:                    s.Counter(metrics.UpstreamResponseGRPCSuccess),
+		UpstreamResponseGRPCFailed:                     s.Counter(metrics.UpstreamResponseGRPCFailed),
+	}
+}
+
+type clusterManagerStats struct {
+	// warmingClusters is the number of clusters currently waiting on their
+	// initial host set and, if health checking is configured, the first
+	// health check round, before they are eligible for traffic.
+	warmingClusters gometrics.Gauge
+}
+
+func newClusterManagerStats() *clusterManagerStats {
+	s := metrics.NewClusterManagerStats()
+	return &clusterManagerStats{
+		warmingClusters: s.Gauge(metrics.ClusterManagerWarmingClusters),
 	}
 }
 
@@ -62,6 +85,11 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamConnectionLocalCloseWithActiveRequest:  s.Counter(metrics.UpstreamConnectionLocalCloseWithActiveRequest),
 		UpstreamConnectionRemoteCloseWithActiveRequest: s.Counter(metrics.UpstreamConnectionRemoteCloseWithActiveRequest),
 		UpstreamConnectionCloseNotify:                  s.Counter(metrics.UpstreamConnectionCloseNotify),
+		UpstreamConnectionCloseIdle:                    s.Counter(metrics.UpstreamConnectionCloseIdle),
+		UpstreamConnectionIdle:                         s.Gauge(metrics.UpstreamConnectionIdle),
+		UpstreamConnectionConnectDuration:              s.Histogram(metrics.UpstreamConnectionConnectDuration),
+		UpstreamConnectionTLSHandshakeDuration:         s.Histogram(metrics.UpstreamConnectionTLSHandshakeDuration),
+		UpstreamRequestQueueDepth:                      s.Gauge(metrics.UpstreamRequestQueueDepth),
 		UpstreamBytesReadTotal:                         s.Counter(metrics.UpstreamBytesReadTotal),
 		UpstreamBytesWriteTotal:                        s.Counter(metrics.UpstreamBytesWriteTotal),
 		UpstreamRequestTotal:                           s.Counter(metrics.UpstreamRequestTotal),
@@ -77,7 +105,15 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamRequestDurationTotal:                   s.Counter(metrics.UpstreamRequestDurationTotal),
 		UpstreamResponseSuccess:                        s.Counter(metrics.UpstreamResponseSuccess),
 		UpstreamResponseFailed:                         s.Counter(metrics.UpstreamResponseFailed),
+		UpstreamRequestGRPCTotal:                       s.Counter(metrics.UpstreamRequestGRPCTotal),
+		UpstreamResponseGRPCSuccess:                    s.Counter(metrics.UpstreamResponseGRPCSuccess),
+		UpstreamResponseGRPCFailed:                     s.Counter(metrics.UpstreamResponseGRPCFailed),
 		LBSubSetsFallBack:                              s.Counter(metrics.UpstreamLBSubSetsFallBack),
 		LBSubsetsCreated:                               s.Gauge(metrics.UpstreamLBSubsetsCreated),
+		LBHealthyPanic:                                 s.Counter(metrics.UpstreamLBHealthyPanic),
+		LBCanaryRequest:                                s.Counter(metrics.UpstreamLBCanaryRequest),
+		LBBoundedLoadSpillover:                         s.Counter(metrics.UpstreamLBBoundedLoadSpillover),
+		OutlierEjectTotal:                              s.Counter(metrics.UpstreamOutlierEjectTotal),
+		OutlierUnejectTotal:                            s.Counter(metrics.UpstreamOutlierUnejectTotal),
 	}
 }
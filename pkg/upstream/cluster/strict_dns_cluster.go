@@ -312,11 +312,11 @@ func (rt *ResolveTarget) OnResolve() {
 			addressString: newAddr,
 			clusterInfo:   sdc.info,
 			stats:         stat,
-			metaData:      rt.config.MetaData,
 			tlsDisable:    rt.config.TLSDisable,
 			weight:        rt.config.Weight,
 			healthFlags:   GetHealthFlagPointer(newAddr),
 		}
+		host.metaData.Store(rt.config.MetaData)
 		hosts = append(hosts, host)
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 			log.DefaultLogger.Debugf("[upstream] [strict dns cluster] resolve dns result, address:%s, addr:%s, ttl:%.3f", rt.dnsAddress, newAddr, rsp.Ttl.Seconds())
@@ -130,8 +130,8 @@ func TestDynamicClusterUpdateHosts(t *testing.T) {
 	for i, ip := range ip3 {
 		host := &simpleHost{
 			addressString: ip,
-			metaData:      metas[i],
 		}
+		host.metaData.Store(metas[i])
 		host3 = append(host3, host)
 	}
 	if hostEqual(&host1, &host2) {
@@ -147,3 +147,60 @@ func TestDynamicClusterUpdateHosts(t *testing.T) {
 		t.Errorf("[upstream][strict dns cluster] hosts should be equal.")
 	}
 }
+
+// TestStrictDnsClusterUpdateDynamicHostsDiff exercises updateDynamicHosts
+// directly, without a real DNS lookup: it uses an already-resolved (IP)
+// address so no background resolver goroutine is started, then drives
+// updateDynamicHosts as the resolver would. It verifies the cluster's
+// snapshot is only replaced when the resolved hosts actually differ from
+// the current host set.
+func TestStrictDnsClusterUpdateDynamicHostsDiff(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:        "dynamic_diff_cluster",
+		LbType:      v2.LB_ROUNDROBIN,
+		ClusterType: v2.STRICT_DNS_CLUSTER,
+	}
+	c := NewCluster(clusterConfig)
+	sdc := c.(*strictDnsCluster)
+
+	// configured with an address that is already an IP, so UpdateHosts
+	// creates a resolve target but does not start a background resolver
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:  "192.0.2.1:80",
+			Hostname: "example.com",
+		},
+	}
+	sdc.UpdateHosts([]types.Host{NewSimpleHost(host, sdc.info)})
+	if len(sdc.resolveTargets) != 1 {
+		t.Fatalf("expected 1 resolve target, got %d", len(sdc.resolveTargets))
+	}
+	rt := sdc.resolveTargets[0]
+
+	resolved := []types.Host{NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{Address: "10.0.0.1:80", Hostname: "example.com"},
+	}, sdc.info)}
+	sdc.updateDynamicHosts(resolved, rt)
+	snap1 := sdc.Snapshot()
+	if addrs := snap1.HostSet().Hosts(); len(addrs) != 1 || addrs[0].AddressString() != "10.0.0.1:80" {
+		t.Fatalf("expected host set to contain resolved address, got %+v", addrs)
+	}
+
+	// re-applying the same resolved set is not a diff, so the snapshot must not be replaced
+	sdc.updateDynamicHosts(resolved, rt)
+	if snap2 := sdc.Snapshot(); snap2 != snap1 {
+		t.Fatal("updateDynamicHosts replaced the snapshot even though the resolved hosts did not change")
+	}
+
+	changed := []types.Host{NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{Address: "10.0.0.2:80", Hostname: "example.com"},
+	}, sdc.info)}
+	sdc.updateDynamicHosts(changed, rt)
+	snap3 := sdc.Snapshot()
+	if snap3 == snap1 {
+		t.Fatal("updateDynamicHosts did not replace the snapshot even though the resolved hosts changed")
+	}
+	if addrs := snap3.HostSet().Hosts(); len(addrs) != 1 || addrs[0].AddressString() != "10.0.0.2:80" {
+		t.Fatalf("expected updated host address, got %+v", addrs)
+	}
+}
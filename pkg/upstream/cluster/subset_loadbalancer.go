@@ -20,6 +20,7 @@ package cluster
 import (
 	"reflect"
 	"sort"
+	"sync"
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
@@ -33,15 +34,32 @@ type subsetLoadBalancer struct {
 	subSets        types.LbSubsetMap  // final trie-like structure used to stored easily searched subset
 	fallbackSubset *LBSubsetEntryImpl // subset entry generated according to fallback policy
 	hostSet        *hostSet
+	info           *clusterInfo
+
+	// fallbackOverrides lazily builds and caches one subset entry per
+	// override policy, so a route can ask for a different fallback
+	// behaviour than the cluster's own configuration without rebuilding
+	// the fallback subset on every request.
+	fallbackMu        sync.Mutex
+	fallbackOverrides map[types.FallBackPolicy]*LBSubsetEntryImpl
+	defaultSubsetCfg  types.SubsetMetadata
+}
+
+// routeFallbackPolicyOverride is implemented by the router's policy when a
+// route configures a subset_fallback_policy override.
+type routeFallbackPolicyOverride interface {
+	SubsetFallbackPolicy() (types.FallBackPolicy, bool)
 }
 
 func NewSubsetLoadBalancer(info *clusterInfo, hostSet *hostSet) types.LoadBalancer {
 	subsetInfo := info.lbSubsetInfo
 	subsetLB := &subsetLoadBalancer{
-		lbType:  info.lbType,
-		stats:   info.stats,
-		subSets: make(map[string]types.ValueSubsetMap),
-		hostSet: hostSet,
+		lbType:           info.lbType,
+		stats:            info.stats,
+		subSets:          make(map[string]types.ValueSubsetMap),
+		hostSet:          hostSet,
+		info:             info,
+		defaultSubsetCfg: subsetInfo.DefaultSubset(),
 	}
 	// create fallback
 	subsetLB.createFallbackSubset(info, subsetInfo.FallbackPolicy(), subsetInfo.DefaultSubset())
@@ -62,12 +80,62 @@ func (sslb *subsetLoadBalancer) ChooseHost(ctx types.LoadBalancerContext) types.
 			return host
 		}
 	}
-	if sslb.fallbackSubset == nil {
+	fallback := sslb.resolveFallback(ctx)
+	if fallback == nil {
 		log.DefaultLogger.Errorf("[upstream] [subset lb] subset load balancer: failure, fallback subset is nil")
 		return nil
 	}
 	sslb.stats.LBSubSetsFallBack.Inc(1)
-	return sslb.fallbackSubset.LoadBalancer().ChooseHost(ctx)
+	return fallback.LoadBalancer().ChooseHost(ctx)
+}
+
+// resolveFallback returns the fallback subset entry to use for this
+// request: the route's subset_fallback_policy override if it has one,
+// otherwise the cluster's configured fallback.
+func (sslb *subsetLoadBalancer) resolveFallback(ctx types.LoadBalancerContext) *LBSubsetEntryImpl {
+	if ctx != nil {
+		if route := ctx.DownstreamRoute(); route != nil && route.RouteRule() != nil {
+			if override, ok := route.RouteRule().Policy().(routeFallbackPolicyOverride); ok {
+				if policy, has := override.SubsetFallbackPolicy(); has {
+					return sslb.fallbackForPolicy(policy)
+				}
+			}
+		}
+	}
+	return sslb.fallbackSubset
+}
+
+// fallbackForPolicy lazily builds (once) and returns the fallback subset
+// entry for the given policy, independent of the cluster's own fallback
+// configuration.
+func (sslb *subsetLoadBalancer) fallbackForPolicy(policy types.FallBackPolicy) *LBSubsetEntryImpl {
+	if policy == types.NoFallBack {
+		return nil
+	}
+
+	sslb.fallbackMu.Lock()
+	defer sslb.fallbackMu.Unlock()
+	if sslb.fallbackOverrides == nil {
+		sslb.fallbackOverrides = make(map[types.FallBackPolicy]*LBSubsetEntryImpl)
+	}
+	if entry, ok := sslb.fallbackOverrides[policy]; ok {
+		return entry
+	}
+
+	entry := &LBSubsetEntryImpl{children: nil}
+	switch policy {
+	case types.AnyEndPoint:
+		entry.CreateLoadBalancer(sslb.info, sslb.hostSet)
+	case types.DefaultSubset:
+		subHostset := sslb.hostSet.createSubset(func(host types.Host) bool {
+			return HostMatches(sslb.defaultSubsetCfg, host)
+		})
+		entry.CreateLoadBalancer(sslb.info, subHostset)
+	default:
+		return nil
+	}
+	sslb.fallbackOverrides[policy] = entry
+	return entry
 }
 
 func (sslb *subsetLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestClusterTransportSocketMatch(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "test_tsm",
+		LbType: v2.LB_RANDOM,
+		// cluster default is plaintext
+		TransportSocketMatches: []v2.TransportSocketMatch{
+			{
+				TransportSocketMatchConfig: v2.TransportSocketMatchConfig{
+					Name: "istio-mtls",
+					TLSContext: v2.TLSConfig{
+						Status:       true,
+						InsecureSkip: true,
+					},
+				},
+				Match: api.Metadata{"tlsMode": "istio"},
+			},
+		},
+	}
+	cluster := newSimpleCluster(clusterConfig).(*simpleCluster)
+	info := cluster.info
+
+	mtlsHost := NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{Address: "127.0.0.1:10000"},
+		MetaData:   api.Metadata{"tlsMode": "istio"},
+	}, info)
+	if !mtlsHost.SupportTLS() {
+		t.Fatal("host with tlsMode=istio metadata should use the matched TLS context")
+	}
+
+	plaintextHost := NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{Address: "127.0.0.1:10001"},
+	}, info)
+	if plaintextHost.SupportTLS() {
+		t.Fatal("host with no matching metadata should fall back to the cluster's plaintext default")
+	}
+
+	otherHost := NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{Address: "127.0.0.1:10002"},
+		MetaData:   api.Metadata{"tlsMode": "disabled"},
+	}, info)
+	if otherHost.SupportTLS() {
+		t.Fatal("host with a non-matching value for the match key should fall back to the cluster's plaintext default")
+	}
+}
+
+func TestClusterInfoTLSMngByMetadataFallsBackToDefault(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "test_tsm_default_tls",
+		LbType: v2.LB_RANDOM,
+		TLS: v2.TLSConfig{
+			Status:       true,
+			InsecureSkip: true,
+		},
+	}
+	cluster := newSimpleCluster(clusterConfig).(*simpleCluster)
+	info := cluster.info
+
+	if !info.TLSMngByMetadata(nil).Enabled() {
+		t.Fatal("with no transport socket matches configured, TLSMngByMetadata should return the cluster's default TLS manager")
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// zoneAwareLoadBalancer prefers hosts in the local zone (as identified by the
+// types.ZoneMetadataKey host metadata entry), spilling traffic to other
+// zones proportionally when the local zone's healthy capacity can't absorb
+// its fair share of the load.
+type zoneAwareLoadBalancer struct {
+	mutex     sync.Mutex
+	rand      *rand.Rand
+	hosts     types.HostSet
+	localZone string
+	// rrLB is used both as the cross-zone fallback and for selection inside
+	// a zone, since zones are just filtered host lists.
+	rrLB types.LoadBalancer
+}
+
+func newZoneAwareLoadBalancer(info types.ClusterInfo, hosts types.HostSet) types.LoadBalancer {
+	lb := &zoneAwareLoadBalancer{
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		hosts: hosts,
+		rrLB:  rrFactory.newRoundRobinLoadBalancer(info, hosts),
+	}
+	if info != nil && info.LbConfig() != nil {
+		if cfg, ok := info.LbConfig().(*v2.ZoneAwareLbConfig); ok {
+			lb.localZone = cfg.LocalZone
+		}
+	}
+	return lb
+}
+
+func (lb *zoneAwareLoadBalancer) zoneOf(host types.Host) string {
+	return host.Metadata()[types.ZoneMetadataKey]
+}
+
+// residualCapacity returns, per zone, the fraction of healthy hosts in that
+// zone, so operators can see which zones are short of capacity.
+func (lb *zoneAwareLoadBalancer) residualCapacity() map[string]float64 {
+	total := map[string]int{}
+	healthy := map[string]int{}
+	for _, h := range lb.hosts.Hosts() {
+		zone := lb.zoneOf(h)
+		total[zone]++
+		if h.Health() {
+			healthy[zone]++
+		}
+	}
+	capacity := make(map[string]float64, len(total))
+	for zone, t := range total {
+		if t == 0 {
+			continue
+		}
+		capacity[zone] = float64(healthy[zone]) / float64(t)
+	}
+	return capacity
+}
+
+func (lb *zoneAwareLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	allHosts := lb.hosts.Hosts()
+	if len(allHosts) == 0 || lb.localZone == "" {
+		return lb.rrLB.ChooseHost(context)
+	}
+
+	var localHosts, localHealthy, globalHealthy []types.Host
+	for _, h := range allHosts {
+		if h.Health() {
+			globalHealthy = append(globalHealthy, h)
+		}
+		if lb.zoneOf(h) == lb.localZone {
+			localHosts = append(localHosts, h)
+			if h.Health() {
+				localHealthy = append(localHealthy, h)
+			}
+		}
+	}
+
+	if len(localHosts) == 0 || len(globalHealthy) == 0 {
+		return lb.rrLB.ChooseHost(context)
+	}
+
+	localHealthyPct := float64(len(localHealthy)) / float64(len(localHosts))
+	globalHealthyPct := float64(len(globalHealthy)) / float64(len(allHosts))
+
+	// the local zone has at least its fair share of healthy capacity:
+	// keep all traffic local.
+	if globalHealthyPct == 0 || localHealthyPct >= globalHealthyPct {
+		if len(localHealthy) > 0 {
+			return lb.pick(localHealthy)
+		}
+		return lb.rrLB.ChooseHost(context)
+	}
+
+	// local zone is short on capacity: spill the deficit proportion of
+	// traffic to the rest of the cluster.
+	lb.mutex.Lock()
+	roll := lb.rand.Float64()
+	lb.mutex.Unlock()
+	if roll < localHealthyPct/globalHealthyPct {
+		return lb.pick(localHealthy)
+	}
+	return lb.pick(globalHealthy)
+}
+
+func (lb *zoneAwareLoadBalancer) pick(hosts []types.Host) types.Host {
+	lb.mutex.Lock()
+	idx := lb.rand.Intn(len(hosts))
+	lb.mutex.Unlock()
+	return hosts[idx]
+}
+
+func (lb *zoneAwareLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *zoneAwareLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
@@ -30,6 +30,14 @@ func init() {
 	sessionFactories = make(map[types.ProtocolName]types.HealthCheckSessionFactory)
 }
 
+// RegisterSessionFactory registers f as the HealthCheckSessionFactory used
+// for clusters configured with health_check.protocol == p. This is the
+// extension point for protocol-specific active health checks: an x-protocol
+// implementation (e.g. a Dubbo heartbeat frame) can ship its own
+// types.HealthCheckSessionFactory and call this from its own init(), the
+// same way it registers itself with xprotocol.RegisterProtocol, without any
+// changes to this package. Protocols that never register one fall back to
+// TCPDialSessionFactory.
 func RegisterSessionFactory(p types.ProtocolName, f types.HealthCheckSessionFactory) {
 	sessionFactories[p] = f
 }
@@ -46,6 +54,16 @@ func CreateHealthCheck(cfg v2.HealthCheck) types.HealthChecker {
 	return newHealthChecker(cfg, f)
 }
 
+// checkAddress returns the address a health check session should dial for
+// host: the per-host HealthCheckConfig.Address override if one is
+// configured, otherwise host's normal traffic address.
+func checkAddress(host types.Host) string {
+	if addr := host.Config().HealthCheckConfig.Address; addr != "" {
+		return addr
+	}
+	return host.AddressString()
+}
+
 // common callback is not related to specific cluster, which can be registered before cluster create
 // and bind to health checker by config
 var commonCallbacks sync.Map
@@ -24,6 +24,7 @@ import (
 
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
 )
@@ -49,10 +50,17 @@ type healthChecker struct {
 	timeout            time.Duration
 	intervalBase       time.Duration
 	intervalJitter     time.Duration
+	initialJitter      time.Duration
+	noTrafficInterval  time.Duration
 	healthyThreshold   uint32
 	unhealthyThreshold uint32
 	rander             *rand.Rand
 	hostCheckCallbacks []types.HealthCheckCb
+	// tlsMng is the transport used to dial a health check session. It is
+	// independent of the cluster's data-path TLS, so a check can use a
+	// different SNI/ALPN, or plaintext, regardless of how real traffic is
+	// served. nil when the health check is not configured to use TLS.
+	tlsMng types.TLSContextManager
 }
 
 func newHealthChecker(cfg v2.HealthCheck, f types.HealthCheckSessionFactory) types.HealthChecker {
@@ -70,6 +78,8 @@ func newHealthChecker(cfg v2.HealthCheck, f types.HealthCheckSessionFactory) typ
 		timeout:            timeout,
 		intervalBase:       interval,
 		intervalJitter:     cfg.IntervalJitter,
+		initialJitter:      cfg.InitialJitter,
+		noTrafficInterval:  cfg.NoTrafficInterval,
 		healthyThreshold:   cfg.HealthyThreshold,
 		unhealthyThreshold: cfg.UnhealthyThreshold,
 		//runtime and stats
@@ -79,6 +89,11 @@ func newHealthChecker(cfg v2.HealthCheck, f types.HealthCheckSessionFactory) typ
 		checkers:           make(map[string]*sessionChecker),
 		stats:              newHealthCheckStats(cfg.ServiceName),
 	}
+	if tlsMng, err := mtls.NewTLSClientContextManager(&cfg.TLS); err == nil {
+		hc.tlsMng = tlsMng
+	} else {
+		log.DefaultLogger.Alertf("healthcheck.tls", "[upstream] [health check] create tls context manager for %s failed: %v", cfg.ServiceName, err)
+	}
 	// Add common callbacks when create
 	// common callbacks should be registered and configured
 	for _, name := range cfg.CommonCallbacks {
@@ -132,7 +147,7 @@ func (hc *healthChecker) SetHealthCheckerHostSet(hostSet types.HostSet) {
 func (hc *healthChecker) startCheck(host types.Host) {
 	addr := host.AddressString()
 	if _, ok := hc.checkers[addr]; !ok {
-		s := hc.sessionFactory.NewSession(hc.sessionConfig, host)
+		s := hc.sessionFactory.NewSession(hc.sessionConfig, host, hc.tlsMng)
 		if s == nil {
 			log.DefaultLogger.Alertf("healthcheck.session", "[upstream] [health check] Create Health Check Session Error, Remote Address = %s", addr)
 			return
@@ -162,15 +177,25 @@ func (hc *healthChecker) stopCheck(host types.Host) {
 	}
 }
 
-func (hc *healthChecker) runCallbacks(host types.Host, changed bool, isHealthy bool) {
+func (hc *healthChecker) runCallbacks(host types.Host, changed bool, isHealthy bool, reason types.FailureType) {
+	wasHealthy := isHealthy
+	if changed {
+		wasHealthy = !isHealthy
+	}
 	hc.stats.healthy.Update(atomic.LoadInt64(&hc.localProcessHealthy))
 	for _, cb := range hc.hostCheckCallbacks {
-		cb(host, changed, isHealthy)
+		cb(host, changed, isHealthy, wasHealthy, reason)
 	}
 }
 
-func (hc *healthChecker) getCheckInterval() time.Duration {
+func (hc *healthChecker) getCheckInterval(host types.Host) time.Duration {
 	interval := hc.intervalBase
+	// a host that has received no traffic since its last check is probed on
+	// the longer no-traffic cadence instead, so idle hosts are not checked
+	// as aggressively as ones actively taking requests.
+	if hc.noTrafficInterval > 0 && host.HostStats().UpstreamRequestTotal.Count() == 0 {
+		interval = hc.noTrafficInterval
+	}
 	if hc.intervalJitter > 0 {
 		interval += time.Duration(hc.rander.Int63n(int64(hc.intervalJitter)))
 	}
@@ -178,13 +203,25 @@ func (hc *healthChecker) getCheckInterval() time.Duration {
 	return interval
 }
 
+// getInitialInterval returns the delay before a host's very first check.
+// Jittering it independently from the steady-state interval spreads out the
+// burst of first checks that would otherwise happen for every host right
+// after a config push.
+func (hc *healthChecker) getInitialInterval() time.Duration {
+	interval := firstInterval
+	if hc.initialJitter > 0 {
+		interval += time.Duration(hc.rander.Int63n(int64(hc.initialJitter)))
+	}
+	return interval
+}
+
 func (hc *healthChecker) incHealthy(host types.Host, changed bool) {
 	hc.stats.success.Inc(1)
 	if changed {
 		log.DefaultLogger.Infof("[upstream] [health check] host %s is healthy", host.AddressString())
 		atomic.AddInt64(&hc.localProcessHealthy, 1)
 	}
-	hc.runCallbacks(host, changed, true)
+	hc.runCallbacks(host, changed, true, "")
 }
 
 func (hc *healthChecker) decHealthy(host types.Host, reason types.FailureType, changed bool) {
@@ -199,9 +236,11 @@ func (hc *healthChecker) decHealthy(host types.Host, reason types.FailureType, c
 		hc.stats.activeFailure.Inc(1)
 	case types.FailureNetwork:
 		hc.stats.networkFailure.Inc(1)
+	case types.FailureTimeout:
+		hc.stats.timeoutFailure.Inc(1)
 	case types.FailurePassive: //TODO: not support yet
 		hc.stats.passiveFailure.Inc(1)
 	}
-	hc.runCallbacks(host, changed, false)
+	hc.runCallbacks(host, changed, false, reason)
 
 }
@@ -19,6 +19,7 @@ package healthcheck
 
 import (
 	"fmt"
+	"math/rand"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -36,7 +37,7 @@ type testResult struct {
 	results map[string]*testCounter
 }
 
-func (r *testResult) testCallback(host types.Host, changed bool, isHealthy bool) {
+func (r *testResult) testCallback(host types.Host, changed bool, isHealthy bool, wasHealthy bool, reason types.FailureType) {
 	addr := host.AddressString()
 	c, ok := r.results[addr]
 	if !ok {
@@ -147,9 +148,10 @@ func TestHealthCheck(t *testing.T) {
 					hc.stats.success.Count() == 0 &&
 					hc.stats.failure.Count() <= 2 &&
 					hc.stats.networkFailure.Count() <= 2 &&
+					hc.stats.timeoutFailure.Count() >= 1 &&
 					hc.stats.healthy.Value() == 0) {
-					return fmt.Errorf("stats not expected, %d, %d, %d, %d, %d", hc.stats.attempt.Count(), hc.stats.success.Count(),
-						hc.stats.failure.Count(), hc.stats.networkFailure.Count(), hc.stats.healthy.Value())
+					return fmt.Errorf("stats not expected, %d, %d, %d, %d, %d, %d", hc.stats.attempt.Count(), hc.stats.success.Count(),
+						hc.stats.failure.Count(), hc.stats.networkFailure.Count(), hc.stats.timeoutFailure.Count(), hc.stats.healthy.Value())
 				}
 				return nil
 			},
@@ -215,3 +217,93 @@ func TestHealthCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestHealthCheckCbTransitionEdge(t *testing.T) {
+	type call struct {
+		changed    bool
+		isHealthy  bool
+		wasHealthy bool
+		reason     types.FailureType
+	}
+	var calls []call
+	hc := &healthChecker{
+		stats: newHealthCheckStats("test_transition_edge"),
+		hostCheckCallbacks: []types.HealthCheckCb{
+			func(host types.Host, changed bool, isHealthy bool, wasHealthy bool, reason types.FailureType) {
+				calls = append(calls, call{changed, isHealthy, wasHealthy, reason})
+			},
+		},
+	}
+	host := &mockHost{addr: "test_transition_edge"}
+
+	// an unchanged success: still healthy before and after, no reason
+	hc.incHealthy(host, false)
+	if c := calls[len(calls)-1]; c.changed || !c.isHealthy || !c.wasHealthy || c.reason != "" {
+		t.Errorf("unchanged success callback = %+v, want changed=false isHealthy=true wasHealthy=true reason=\"\"", c)
+	}
+
+	// a changed failure: was healthy, is now unhealthy, with a reason
+	hc.decHealthy(host, types.FailureNetwork, true)
+	if c := calls[len(calls)-1]; !c.changed || c.isHealthy || !c.wasHealthy || c.reason != types.FailureNetwork {
+		t.Errorf("changed failure callback = %+v, want changed=true isHealthy=false wasHealthy=true reason=Network", c)
+	}
+
+	// an unchanged failure: was already unhealthy, stays unhealthy
+	hc.decHealthy(host, types.FailureActive, false)
+	if c := calls[len(calls)-1]; c.changed || c.isHealthy || c.wasHealthy || c.reason != types.FailureActive {
+		t.Errorf("unchanged failure callback = %+v, want changed=false isHealthy=false wasHealthy=false reason=Active", c)
+	}
+
+	// a changed success: was unhealthy, is now healthy again
+	hc.incHealthy(host, true)
+	if c := calls[len(calls)-1]; !c.changed || !c.isHealthy || c.wasHealthy || c.reason != "" {
+		t.Errorf("changed success callback = %+v, want changed=true isHealthy=true wasHealthy=false reason=\"\"", c)
+	}
+}
+
+func TestGetInitialInterval(t *testing.T) {
+	saved := firstInterval
+	firstInterval = 100 * time.Millisecond
+	defer func() { firstInterval = saved }()
+
+	hc := &healthChecker{
+		initialJitter: 50 * time.Millisecond,
+		rander:        rand.New(rand.NewSource(1)),
+	}
+	for i := 0; i < 10; i++ {
+		d := hc.getInitialInterval()
+		if d < firstInterval || d >= firstInterval+hc.initialJitter {
+			t.Fatalf("getInitialInterval returned %v, want in [%v, %v)", d, firstInterval, firstInterval+hc.initialJitter)
+		}
+	}
+
+	// without jitter configured, the initial interval is exactly firstInterval
+	hc.initialJitter = 0
+	if d := hc.getInitialInterval(); d != firstInterval {
+		t.Errorf("getInitialInterval without jitter = %v, want %v", d, firstInterval)
+	}
+}
+
+func TestGetCheckIntervalNoTraffic(t *testing.T) {
+	hc := &healthChecker{
+		intervalBase:      time.Second,
+		noTrafficInterval: 5 * time.Second,
+		rander:            rand.New(rand.NewSource(1)),
+	}
+	idle := &mockHost{addr: "idle"}
+	if d := hc.getCheckInterval(idle); d != hc.noTrafficInterval {
+		t.Errorf("getCheckInterval for an idle host = %v, want no_traffic_interval %v", d, hc.noTrafficInterval)
+	}
+
+	active := &mockHost{addr: "active"}
+	active.HostStats().UpstreamRequestTotal.Inc(1)
+	if d := hc.getCheckInterval(active); d != hc.intervalBase {
+		t.Errorf("getCheckInterval for an active host = %v, want interval base %v", d, hc.intervalBase)
+	}
+
+	// no_traffic_interval disabled (0) always falls back to intervalBase
+	hc.noTrafficInterval = 0
+	if d := hc.getCheckInterval(idle); d != hc.intervalBase {
+		t.Errorf("getCheckInterval with no_traffic_interval disabled = %v, want interval base %v", d, hc.intervalBase)
+	}
+}
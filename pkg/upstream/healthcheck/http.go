@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	RegisterSessionFactory(protocol.HTTP1, &HTTPSessionFactory{})
+}
+
+// defaultHTTPHealthCheckTimeout bounds how long a single HTTP health check
+// request may block, matching the dial timeout used by TCPDialSession.
+const defaultHTTPHealthCheckTimeout = 30 * time.Second
+
+// defaultExpectedStatusRanges is used when "expected_statuses" is not set in
+// the health check's check_config.
+var defaultExpectedStatusRanges = []statusRange{{min: 200, max: 299}}
+
+// statusRange is an inclusive range of HTTP status codes, e.g. 200-299.
+type statusRange struct {
+	min int
+	max int
+}
+
+func (r statusRange) contains(status int) bool {
+	return status >= r.min && status <= r.max
+}
+
+// parseStatusRanges parses a comma separated list of status codes and
+// ranges, e.g. "200-299,404".
+func parseStatusRanges(raw string) ([]statusRange, error) {
+	var ranges []statusRange
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if lo, hi, ok := strings.Cut(part, "-"); ok {
+			min, err := strconv.Atoi(strings.TrimSpace(lo))
+			if err != nil {
+				return nil, fmt.Errorf("invalid status range %q: %v", part, err)
+			}
+			max, err := strconv.Atoi(strings.TrimSpace(hi))
+			if err != nil {
+				return nil, fmt.Errorf("invalid status range %q: %v", part, err)
+			}
+			ranges = append(ranges, statusRange{min: min, max: max})
+			continue
+		}
+		status, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status %q: %v", part, err)
+		}
+		ranges = append(ranges, statusRange{min: status, max: status})
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no status codes found in %q", raw)
+	}
+	return ranges, nil
+}
+
+// HTTPSessionFactory creates HTTPSessions, the health check session for the
+// "Http1" protocol. check_config supports:
+//   - path: the request path, defaults to "/"
+//   - host: the Host header to send, defaults to the host's address
+//   - method: the HTTP method, defaults to GET
+//   - expected_statuses: a comma separated list of status codes/ranges
+//     (e.g. "200-299,404") that are considered healthy, defaults to 200-299
+type HTTPSessionFactory struct{}
+
+func (f *HTTPSessionFactory) NewSession(cfg map[string]interface{}, host types.Host, tlsMng types.TLSContextManager) types.HealthCheckSession {
+	s := &HTTPSession{
+		addr:             checkAddress(host),
+		path:             "/",
+		method:           http.MethodGet,
+		expectedStatuses: defaultExpectedStatusRanges,
+		tlsMng:           tlsMng,
+	}
+	if v, ok := cfg["path"].(string); ok && v != "" {
+		s.path = v
+	}
+	if v, ok := cfg["host"].(string); ok && v != "" {
+		s.hostHeader = v
+	}
+	if v, ok := cfg["method"].(string); ok && v != "" {
+		s.method = strings.ToUpper(v)
+	}
+	if v, ok := cfg["expected_statuses"].(string); ok && v != "" {
+		if ranges, err := parseStatusRanges(v); err != nil {
+			log.DefaultLogger.Alertf("healthcheck.http", "[upstream] [health check] [http session] invalid expected_statuses %q, fall back to default: %v", v, err)
+		} else {
+			s.expectedStatuses = ranges
+		}
+	}
+	return s
+}
+
+// HTTPSession is a types.HealthCheckSession that considers a host healthy
+// when an HTTP request to it returns a status code in expectedStatuses.
+type HTTPSession struct {
+	addr             string
+	path             string
+	hostHeader       string
+	method           string
+	expectedStatuses []statusRange
+	// tlsMng is the health check's own TLS transport, independent of the
+	// cluster's data-path TLS. nil means the check always dials in
+	// plaintext regardless of how the cluster serves real traffic.
+	tlsMng types.TLSContextManager
+
+	client *http.Client
+}
+
+func (s *HTTPSession) CheckHealth() bool {
+	scheme := "http"
+	if s.tlsMng != nil && s.tlsMng.Enabled() {
+		scheme = "https"
+	}
+	if s.client == nil {
+		dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		}
+		s.client = &http.Client{
+			Timeout: defaultHTTPHealthCheckTimeout,
+			Transport: &http.Transport{
+				DialContext: dial,
+				// DialTLSContext bypasses the Transport's own TLS handshake so
+				// the health check's independent TLS config (tlsMng), rather
+				// than the data-path TLS, is what gets negotiated.
+				DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					conn, err := dial(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					return s.tlsMng.Conn(conn)
+				},
+			},
+		}
+	}
+
+	req, err := http.NewRequest(s.method, scheme+"://"+s.addr+s.path, nil)
+	if err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [http session] build request for host %s error: %v", s.addr, err)
+		return false
+	}
+	if s.hostHeader != "" {
+		req.Host = s.hostHeader
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [http session] request for host %s error: %v", s.addr, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	for _, r := range s.expectedStatuses {
+		if r.contains(resp.StatusCode) {
+			return true
+		}
+	}
+	log.DefaultLogger.Infof("[upstream] [health check] [http session] host %s returned unexpected status %d", s.addr, resp.StatusCode)
+	return false
+}
+
+func (s *HTTPSession) OnTimeout() {}
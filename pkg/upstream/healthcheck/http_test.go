@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls"
+)
+
+func TestHTTPSessionCheckHealth(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+	addr := strings.Split(s.URL, "http://")[1]
+	host := &mockHost{addr: addr}
+
+	factory := &HTTPSessionFactory{}
+	session := factory.NewSession(map[string]interface{}{
+		"path": "/healthz",
+	}, host, nil)
+	if !session.CheckHealth() {
+		t.Error("http session check health failed, want ok")
+	}
+
+	session = factory.NewSession(map[string]interface{}{
+		"path": "/maintenance",
+	}, host, nil)
+	if session.CheckHealth() {
+		t.Error("http session check health passed for a 503 response, want failure")
+	}
+
+	session = factory.NewSession(map[string]interface{}{
+		"path":              "/maintenance",
+		"expected_statuses": "200-299,503",
+	}, host, nil)
+	if !session.CheckHealth() {
+		t.Error("http session check health failed for a configured expected status, want ok")
+	}
+
+	session = factory.NewSession(nil, &mockHost{addr: "127.0.0.1:1"}, nil)
+	if session.CheckHealth() {
+		t.Error("http session check health passed against an unreachable host, want failure")
+	}
+
+	// HealthCheckConfig.Address overrides the dialed address; traffic's
+	// address is left unreachable to prove the override, not the traffic
+	// address, was used.
+	session = factory.NewSession(map[string]interface{}{
+		"path": "/healthz",
+	}, &mockHost{addr: "127.0.0.1:1", checkAddr: addr}, nil)
+	if !session.CheckHealth() {
+		t.Error("http session check health failed when using a health_check_config address override, want ok")
+	}
+}
+
+// TestHTTPSessionCheckHealthTLS verifies a health check's own TLS config is
+// used to dial, independent of any data-path TLS setting: the check is
+// configured with insecure_skip so it succeeds against the test server's
+// self-signed certificate without any of the cluster's own TLS settings.
+func TestHTTPSessionCheckHealthTLS(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	s := httptest.NewTLSServer(mux)
+	defer s.Close()
+	addr := strings.TrimPrefix(s.URL, "https://")
+	host := &mockHost{addr: addr}
+
+	tlsMng, err := mtls.NewTLSClientContextManager(&v2.TLSConfig{
+		Status:       true,
+		InsecureSkip: true,
+	})
+	if err != nil {
+		t.Fatalf("create tls context manager failed: %v", err)
+	}
+
+	factory := &HTTPSessionFactory{}
+	session := factory.NewSession(map[string]interface{}{
+		"path": "/healthz",
+	}, host, tlsMng)
+	if !session.CheckHealth() {
+		t.Error("https session check health failed, want ok")
+	}
+
+	// without a tls context manager, the check dials in plaintext and a tls
+	// only server rejects the connection
+	plain := factory.NewSession(map[string]interface{}{
+		"path": "/healthz",
+	}, host, nil)
+	if plain.CheckHealth() {
+		t.Error("plaintext session check health passed against a tls only server, want failure")
+	}
+}
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := parseStatusRanges("200-299,404")
+	if err != nil {
+		t.Fatalf("parseStatusRanges failed: %v", err)
+	}
+	if len(ranges) != 2 || ranges[0] != (statusRange{200, 299}) || ranges[1] != (statusRange{404, 404}) {
+		t.Errorf("parseStatusRanges returned unexpected ranges: %+v", ranges)
+	}
+
+	if _, err := parseStatusRanges("not-a-status"); err == nil {
+		t.Error("parseStatusRanges should fail on invalid input")
+	}
+}
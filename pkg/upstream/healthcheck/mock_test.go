@@ -21,7 +21,9 @@ import (
 	"sync"
 	"time"
 
+	gometrics "github.com/rcrowley/go-metrics"
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -29,7 +31,7 @@ import (
 type mockSessionFactory struct {
 }
 
-func (f *mockSessionFactory) NewSession(cfg map[string]interface{}, host types.Host) types.HealthCheckSession {
+func (f *mockSessionFactory) NewSession(cfg map[string]interface{}, host types.Host, tlsMng types.TLSContextManager) types.HealthCheckSession {
 	return &mockSession{host}
 }
 
@@ -65,14 +67,22 @@ func (hs *mockHostSet) Hosts() []types.Host {
 	return hs.hosts
 }
 
+func (hs *mockHostSet) DegradedHosts() []types.Host {
+	return nil
+}
+
 type mockHost struct {
 	types.Host
 	addr string
-	flag uint64
+	// checkAddr, if set, overrides the address returned by Config(), for
+	// testing HostConfig.HealthCheckConfig.Address.
+	checkAddr string
+	flag      uint64
 	// mock status
-	delay  time.Duration
-	lock   sync.Mutex
-	status bool
+	delay    time.Duration
+	lock     sync.Mutex
+	status   bool
+	reqTotal gometrics.Counter
 }
 
 func (h *mockHost) SetHealth(health bool) {
@@ -92,6 +102,24 @@ func (h *mockHost) AddressString() string {
 	return h.addr
 }
 
+// HostStats returns just enough of a real types.HostStats for
+// getCheckInterval's no-traffic check: a working UpstreamRequestTotal
+// counter, defaulting to zero (no traffic) unless reqTotal is set.
+func (h *mockHost) HostStats() types.HostStats {
+	if h.reqTotal == nil {
+		h.reqTotal = gometrics.NewCounter()
+	}
+	return types.HostStats{UpstreamRequestTotal: h.reqTotal}
+}
+
+func (h *mockHost) Config() v2.Host {
+	return v2.Host{
+		HostConfig: v2.HostConfig{
+			HealthCheckConfig: v2.HostHealthCheckConfig{Address: h.checkAddr},
+		},
+	}
+}
+
 func (h *mockHost) ClearHealthFlag(flag api.HealthFlag) {
 	h.flag &= ^uint64(flag)
 }
@@ -72,7 +72,7 @@ func (c *sessionChecker) Start() {
 		c.checkTimer.Stop()
 		c.checkTimeout.Stop()
 	}()
-	c.checkTimer = utils.NewTimer(firstInterval, c.OnCheck)
+	c.checkTimer = utils.NewTimer(c.HealthChecker.getInitialInterval(), c.OnCheck)
 	for {
 		select {
 		case <-c.stop:
@@ -93,7 +93,7 @@ func (c *sessionChecker) Start() {
 						c.HandleFailure(types.FailureActive)
 					}
 					// next health checker
-					c.checkTimer = utils.NewTimer(c.HealthChecker.getCheckInterval(), c.OnCheck)
+					c.checkTimer = utils.NewTimer(c.HealthChecker.getCheckInterval(c.Host), c.OnCheck)
 					if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 						log.DefaultLogger.Debugf("[upstream] [health check] [session checker] receive a response id: %d", resp.ID)
 					}
@@ -105,9 +105,9 @@ func (c *sessionChecker) Start() {
 			case <-c.timeout:
 				c.checkTimer.Stop()
 				c.Session.OnTimeout() // session timeout callbacks
-				c.HandleFailure(types.FailureNetwork)
+				c.HandleFailure(types.FailureTimeout)
 				// next health checker
-				c.checkTimer = utils.NewTimer(c.HealthChecker.getCheckInterval(), c.OnCheck)
+				c.checkTimer = utils.NewTimer(c.HealthChecker.getCheckInterval(c.Host), c.OnCheck)
 				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 					log.DefaultLogger.Debugf("[upstream] [health check] [session checker] receive a timeout response at id: %d", currentID)
 				}
@@ -32,6 +32,7 @@ type healthCheckStats struct {
 	passiveFailure gometrics.Counter
 	activeFailure  gometrics.Counter
 	networkFailure gometrics.Counter
+	timeoutFailure gometrics.Counter
 	verifyCluster  gometrics.Counter
 	healthy        gometrics.Gauge
 }
@@ -45,6 +46,7 @@ func newHealthCheckStats(namespace string) *healthCheckStats {
 		activeFailure:  m.Counter(metrics.HealthCheckActiveFailure),
 		passiveFailure: m.Counter(metrics.HealthCheckPassiveFailure),
 		networkFailure: m.Counter(metrics.HealthCheckNetworkFailure),
+		timeoutFailure: m.Counter(metrics.HealthCheckTimeoutFailure),
 		verifyCluster:  m.Counter(metrics.HealthCheckVeirfyCluster),
 		healthy:        m.Gauge(metrics.HealthCheckHealthy),
 	}
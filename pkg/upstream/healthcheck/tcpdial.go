@@ -18,34 +18,173 @@
 package healthcheck
 
 import (
+	"bytes"
+	"encoding/hex"
+	"io"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 )
 
+// defaultTCPReceiveTimeout bounds how long a send/expect health check waits
+// for the expected response once the payload is sent.
+const defaultTCPReceiveTimeout = 5 * time.Second
+
+// TCPDialSessionFactory creates TCPDialSessions. By default a session only
+// dials the host and checks that the connection succeeds. check_config
+// additionally supports:
+//   - send: a payload written to the connection once it is established; a
+//     "hex:" prefix allows sending arbitrary binary bytes, e.g. "hex:2a0d0a"
+//   - expect: the response the host must send back for it to be considered
+//     healthy; ignored unless send is also set
+//   - reuse_connection: if true, a successful check keeps its connection open
+//     and reuses it on the next check instead of dialing a fresh one; the
+//     session still re-dials whenever the kept connection is found unusable
 type TCPDialSessionFactory struct{}
 
-func (f *TCPDialSessionFactory) NewSession(cfg map[string]interface{}, host types.Host) types.HealthCheckSession {
-	return &TCPDialSession{
-		addr: host.AddressString(),
+func (f *TCPDialSessionFactory) NewSession(cfg map[string]interface{}, host types.Host, tlsMng types.TLSContextManager) types.HealthCheckSession {
+	s := &TCPDialSession{
+		addr:   checkAddress(host),
+		tlsMng: tlsMng,
+	}
+	if v, ok := cfg["send"].(string); ok && v != "" {
+		s.send = decodeTCPPayload(v)
+	}
+	if v, ok := cfg["expect"].(string); ok && v != "" {
+		s.expect = decodeTCPPayload(v)
+	}
+	if v, ok := cfg["reuse_connection"].(bool); ok {
+		s.reuseConn = v
+	}
+	return s
+}
+
+// decodeTCPPayload decodes a check_config payload string. A "hex:" prefix
+// selects hex decoding for binary payloads; otherwise the string is used
+// literally.
+func decodeTCPPayload(raw string) []byte {
+	if rest, ok := cutPrefix(raw, "hex:"); ok {
+		if decoded, err := hex.DecodeString(rest); err == nil {
+			return decoded
+		}
+		log.DefaultLogger.Alertf("healthcheck.tcpdial", "[upstream] [health check] [tcpdial session] invalid hex payload %q, sending literally", raw)
+	}
+	return []byte(raw)
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
 	}
+	return s[len(prefix):], true
 }
 
 type TCPDialSession struct {
-	addr string
+	addr      string
+	send      []byte
+	expect    []byte
+	tlsMng    types.TLSContextManager
+	reuseConn bool
+
+	mutex sync.Mutex
+	conn  net.Conn
 }
 
 func (s *TCPDialSession) CheckHealth() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	conn := s.conn
+	if conn != nil && !tcpConnAlive(conn) {
+		conn.Close()
+		conn = nil
+		s.conn = nil
+	}
+	if conn == nil {
+		var err error
+		conn, err = s.dial()
+		if err != nil {
+			return false
+		}
+	}
+
+	healthy := s.probe(conn)
+	if !healthy || !s.reuseConn {
+		conn.Close()
+		s.conn = nil
+		return healthy
+	}
+	s.conn = conn
+	return true
+}
+
+// unixAddrPrefix marks a host address as a unix domain socket path instead
+// of a host:port, matching cluster.unixAddrPrefix.
+const unixAddrPrefix = "unix://"
+
+func (s *TCPDialSession) dial() (net.Conn, error) {
+	network, addr := "tcp", s.addr
+	if strings.HasPrefix(addr, unixAddrPrefix) {
+		network, addr = "unix", strings.TrimPrefix(addr, unixAddrPrefix)
+	}
 	// default dial timeout, maybe already timeout by checker
-	conn, err := net.DialTimeout("tcp", s.addr, 30*time.Second)
+	conn, err := net.DialTimeout(network, addr, 30*time.Second)
 	if err != nil {
 		log.DefaultLogger.Infof("[upstream] [health check] [tcpdial session] dial tcp for host %s error: %v", s.addr, err)
+		return nil, err
+	}
+	if s.tlsMng != nil && s.tlsMng.Enabled() {
+		conn, err = s.tlsMng.Conn(conn)
+		if err != nil {
+			log.DefaultLogger.Infof("[upstream] [health check] [tcpdial session] tls handshake for host %s error: %v", s.addr, err)
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// probe runs the send/expect exchange (or just checks the connection is
+// established, if neither is configured) against an already-dialed conn.
+func (s *TCPDialSession) probe(conn net.Conn) bool {
+	if len(s.send) == 0 {
+		return true
+	}
+
+	if _, err := conn.Write(s.send); err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [tcpdial session] send payload to host %s error: %v", s.addr, err)
 		return false
 	}
-	conn.Close()
-	return true
+	if len(s.expect) == 0 {
+		return true
+	}
+
+	conn.SetReadDeadline(time.Now().Add(defaultTCPReceiveTimeout))
+	buf := make([]byte, len(s.expect))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [tcpdial session] read response from host %s error: %v", s.addr, err)
+		return false
+	}
+	return bytes.Equal(buf, s.expect)
+}
+
+// tcpConnAlive peeks at a kept-open connection without blocking, to detect a
+// remote close before reusing it for the next check.
+func tcpConnAlive(conn net.Conn) bool {
+	conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	one := make([]byte, 1)
+	if _, err := conn.Read(one); err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return true
+		}
+		return false
+	}
+	// unexpected data on an otherwise idle health check connection; treat it
+	// as no longer trustworthy and let the next check redial
+	return false
 }
 
 func (s *TCPDialSession) OnTimeout() {}
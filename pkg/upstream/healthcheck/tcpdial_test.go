@@ -18,9 +18,15 @@
 package healthcheck
 
 import (
+	"net"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls"
 )
 
 func TestTCPDial(t *testing.T) {
@@ -30,7 +36,7 @@ func TestTCPDial(t *testing.T) {
 		addr: addr,
 	}
 	dialfactory := &TCPDialSessionFactory{}
-	session := dialfactory.NewSession(nil, host)
+	session := dialfactory.NewSession(nil, host, nil)
 	if !session.CheckHealth() {
 		t.Error("tcp dial check health failed")
 	}
@@ -39,3 +45,172 @@ func TestTCPDial(t *testing.T) {
 		t.Error("tcp dial a closed server, but returns ok")
 	}
 }
+
+func TestTCPDialUnixSocket(t *testing.T) {
+	sockPath := t.TempDir() + "/mosn-healthcheck-test.sock"
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	host := &mockHost{addr: "unix://" + sockPath}
+	dialfactory := &TCPDialSessionFactory{}
+	session := dialfactory.NewSession(nil, host, nil)
+	if !session.CheckHealth() {
+		t.Error("tcp dial check health over a unix socket failed")
+	}
+}
+
+func TestTCPDialSendExpect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 4)
+				if _, err := conn.Read(buf); err != nil {
+					return
+				}
+				if string(buf) == "PING" {
+					conn.Write([]byte("PONG"))
+				} else {
+					conn.Write([]byte("NOPE"))
+				}
+			}()
+		}
+	}()
+
+	host := &mockHost{addr: ln.Addr().String()}
+	factory := &TCPDialSessionFactory{}
+
+	session := factory.NewSession(map[string]interface{}{
+		"send":   "PING",
+		"expect": "PONG",
+	}, host, nil)
+	if !session.CheckHealth() {
+		t.Error("tcp dial send/expect check health failed, want ok")
+	}
+
+	session = factory.NewSession(map[string]interface{}{
+		"send":   "HELO",
+		"expect": "PONG",
+	}, host, nil)
+	if session.CheckHealth() {
+		t.Error("tcp dial send/expect check health passed for a mismatched response, want failure")
+	}
+}
+
+// TestTCPDialTLS verifies a tcpdial session wraps its connection with the
+// health check's own tls context manager, independent of the data-path TLS.
+func TestTCPDialTLS(t *testing.T) {
+	s := httptest.NewTLSServer(nil)
+	defer s.Close()
+	addr := strings.TrimPrefix(s.URL, "https://")
+	host := &mockHost{addr: addr}
+
+	tlsMng, err := mtls.NewTLSClientContextManager(&v2.TLSConfig{
+		Status:       true,
+		InsecureSkip: true,
+	})
+	if err != nil {
+		t.Fatalf("create tls context manager failed: %v", err)
+	}
+
+	dialfactory := &TCPDialSessionFactory{}
+	session := dialfactory.NewSession(nil, host, tlsMng)
+	if !session.CheckHealth() {
+		t.Error("tcp dial over tls check health failed, want ok")
+	}
+}
+
+func TestTCPDialReuseConnection(t *testing.T) {
+	var accepts int32
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepts, 1)
+			go func() {
+				buf := make([]byte, 1)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						conn.Close()
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	waitAccepts := func(want int32) {
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if atomic.LoadInt32(&accepts) == want {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	host := &mockHost{addr: ln.Addr().String()}
+	factory := &TCPDialSessionFactory{}
+
+	session := factory.NewSession(map[string]interface{}{
+		"reuse_connection": true,
+	}, host, nil)
+	for i := 0; i < 3; i++ {
+		if !session.CheckHealth() {
+			t.Fatalf("check %d failed, want ok", i)
+		}
+	}
+	waitAccepts(1)
+	if got := atomic.LoadInt32(&accepts); got != 1 {
+		t.Errorf("reuse_connection session dialed %d times, want 1", got)
+	}
+
+	s := session.(*TCPDialSession)
+	s.conn.Close()
+	if !session.CheckHealth() {
+		t.Error("check after the kept connection was closed should redial and succeed")
+	}
+	waitAccepts(2)
+	if got := atomic.LoadInt32(&accepts); got != 2 {
+		t.Errorf("session should have redialed once the kept connection died, got %d accepts", got)
+	}
+}
+
+func TestDecodeTCPPayload(t *testing.T) {
+	if got := string(decodeTCPPayload("PING")); got != "PING" {
+		t.Errorf("decodeTCPPayload(%q) = %q, want literal", "PING", got)
+	}
+	if got := decodeTCPPayload("hex:2a0d0a"); string(got) != "*\r\n" {
+		t.Errorf("decodeTCPPayload(hex) = %v, want %q", got, "*\r\n")
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/pkg/buffer"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// defaultXProtocolReceiveTimeout bounds how long an XProtocolSession waits
+// for a heartbeat reply once it has been sent.
+const defaultXProtocolReceiveTimeout = 5 * time.Second
+
+func init() {
+	// bolt and dubbo are registered by name here rather than by importing
+	// their packages: xprotocol.GetProtocol resolves against whatever
+	// sub-protocols the running mosn actually links in (see
+	// pkg/stream/xprotocol/factory.go's ProtocolMatch for the same
+	// by-name-only pattern), so this stays a no-op for a build that never
+	// imports bolt or dubbo, instead of forcing them in.
+	RegisterSessionFactory("bolt", &XProtocolSessionFactory{protocolName: "bolt"})
+	RegisterSessionFactory("dubbo", &XProtocolSessionFactory{protocolName: "dubbo"})
+}
+
+// XProtocolSessionFactory creates XProtocolSessions for a fixed sub-protocol,
+// used as the health_check session for a cluster configured with
+// health_check.protocol equal to that sub-protocol's name (e.g. "bolt"). It
+// checks health by using the sub-protocol's own Heartbeater to build and
+// recognize a real heartbeat frame, instead of TCPDialSession's raw
+// send/expect bytes.
+type XProtocolSessionFactory struct {
+	protocolName types.ProtocolName
+}
+
+func (f *XProtocolSessionFactory) NewSession(cfg map[string]interface{}, host types.Host, tlsMng types.TLSContextManager) types.HealthCheckSession {
+	return &XProtocolSession{
+		protocolName: f.protocolName,
+		addr:         checkAddress(host),
+		tlsMng:       tlsMng,
+	}
+}
+
+// XProtocolSession dials a fresh connection per check and sends the
+// sub-protocol's own heartbeat frame, considering the host healthy if a
+// well-formed reply frame comes back before the deadline.
+type XProtocolSession struct {
+	protocolName types.ProtocolName
+	addr         string
+	tlsMng       types.TLSContextManager
+
+	requestId uint64
+}
+
+func (s *XProtocolSession) CheckHealth() bool {
+	proto := xprotocol.GetProtocol(s.protocolName)
+	if proto == nil {
+		log.DefaultLogger.Alertf("healthcheck.xprotocol", "[upstream] [health check] [xprotocol session] sub-protocol %s is not registered in this build", s.protocolName)
+		return false
+	}
+
+	conn, err := s.dial()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	heartbeat := proto.Trigger(atomic.AddUint64(&s.requestId, 1))
+	data, err := proto.Encode(ctx, heartbeat)
+	if err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] encode %s heartbeat for host %s error: %v", s.protocolName, s.addr, err)
+		return false
+	}
+	if _, err := conn.Write(data.Bytes()); err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] send %s heartbeat to host %s error: %v", s.protocolName, s.addr, err)
+		return false
+	}
+
+	conn.SetReadDeadline(time.Now().Add(defaultXProtocolReceiveTimeout))
+	buf := buffer.NewIoBuffer(256)
+	for {
+		n, err := buf.ReadFrom(conn)
+		if err != nil {
+			log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] read %s heartbeat reply from host %s error: %v", s.protocolName, s.addr, err)
+			return false
+		}
+		if n == 0 {
+			// remote closed the connection (ReadFrom treats EOF as a clean
+			// end of stream, not an error) without a full reply: no heartbeat
+			// ack, so not healthy.
+			log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] host %s closed connection before a %s heartbeat reply arrived", s.addr, s.protocolName)
+			return false
+		}
+		model, err := proto.Decode(ctx, buf)
+		if err != nil {
+			log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] decode %s heartbeat reply from host %s error: %v", s.protocolName, s.addr, err)
+			return false
+		}
+		if model == nil {
+			// not enough data yet, keep reading until the deadline fires
+			continue
+		}
+		_, ok := model.(xprotocol.XRespFrame)
+		return ok
+	}
+}
+
+// unixAddrPrefix marks a host address as a unix domain socket path instead
+// of a host:port, matching TCPDialSession.dial.
+func (s *XProtocolSession) dial() (net.Conn, error) {
+	network, addr := "tcp", s.addr
+	if strings.HasPrefix(addr, unixAddrPrefix) {
+		network, addr = "unix", strings.TrimPrefix(addr, unixAddrPrefix)
+	}
+	conn, err := net.DialTimeout(network, addr, 30*time.Second)
+	if err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] dial %s for host %s error: %v", s.protocolName, s.addr, err)
+		return nil, err
+	}
+	if s.tlsMng != nil && s.tlsMng.Enabled() {
+		conn, err = s.tlsMng.Conn(conn)
+		if err != nil {
+			log.DefaultLogger.Infof("[upstream] [health check] [xprotocol session] tls handshake for host %s error: %v", s.addr, err)
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (s *XProtocolSession) OnTimeout() {}
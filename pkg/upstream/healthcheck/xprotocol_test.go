@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/pkg/buffer"
+
+	_ "mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+)
+
+// boltHeartbeatServer accepts a single connection, decodes the request with
+// the real bolt codec, and if it is a heartbeat, answers with the real bolt
+// heartbeat ack built by Heartbeater.Reply. Any other request is ignored so
+// the caller times out, standing in for a host that doesn't understand the
+// heartbeat.
+func boltHeartbeatServer(t *testing.T, ln net.Listener, answerHeartbeat bool) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	proto := xprotocol.GetProtocol("bolt")
+	ctx := context.Background()
+	buf := buffer.NewIoBuffer(256)
+	for {
+		// re-armed on every attempt: a short deadline here just keeps this
+		// read loop responsive so it notices the request soon after it
+		// arrives, the same way it would poll a socket with no data pending
+		// yet.
+		conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		if _, err := buf.ReadFrom(conn); err != nil {
+			return
+		}
+		model, err := proto.Decode(ctx, buf)
+		if err != nil || model == nil {
+			if err != nil {
+				return
+			}
+			continue
+		}
+		frame, ok := model.(xprotocol.XFrame)
+		if !ok || !frame.IsHeartbeatFrame() || !answerHeartbeat {
+			return
+		}
+		ack := proto.Reply(frame)
+		data, err := proto.Encode(ctx, ack)
+		if err != nil {
+			t.Errorf("encode heartbeat ack: %v", err)
+			return
+		}
+		conn.Write(data.Bytes())
+		return
+	}
+}
+
+func TestXProtocolSessionHeartbeat(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go boltHeartbeatServer(t, ln, true)
+
+	host := &mockHost{addr: ln.Addr().String()}
+	factory := &XProtocolSessionFactory{protocolName: "bolt"}
+	session := factory.NewSession(nil, host, nil)
+	if !session.CheckHealth() {
+		t.Error("bolt heartbeat check health failed, want ok")
+	}
+}
+
+func TestXProtocolSessionNoReply(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go boltHeartbeatServer(t, ln, false)
+
+	host := &mockHost{addr: ln.Addr().String()}
+	factory := &XProtocolSessionFactory{protocolName: "bolt"}
+	session := factory.NewSession(nil, host, nil)
+	if session.CheckHealth() {
+		t.Error("bolt heartbeat check health passed with no reply, want failure")
+	}
+}
+
+func TestXProtocolSessionUnregisteredProtocol(t *testing.T) {
+	host := &mockHost{addr: "127.0.0.1:1"}
+	factory := &XProtocolSessionFactory{protocolName: "not-a-real-protocol"}
+	session := factory.NewSession(nil, host, nil)
+	if session.CheckHealth() {
+		t.Error("check health passed for an unregistered sub-protocol, want failure")
+	}
+}
@@ -170,11 +170,11 @@ func ConvertClustersConfig(xdsClusters []*xdsapi.Cluster) []*v2.Cluster {
 			ConnBufferLimitBytes: xdsCluster.GetPerConnectionBufferLimitBytes().GetValue(),
 			HealthCheck:          convertHealthChecks(xdsCluster.GetHealthChecks()),
 			CirBreThresholds:     convertCircuitBreakers(xdsCluster.GetCircuitBreakers()),
-			//OutlierDetection:     convertOutlierDetection(xdsCluster.GetOutlierDetection()),
-			Hosts:    convertClusterHosts(xdsCluster.GetHosts()),
-			Spec:     convertSpec(xdsCluster),
-			TLS:      convertTLS(xdsCluster.GetTlsContext()),
-			LbConfig: convertLbConfig(xdsCluster.LbConfig),
+			OutlierDetection:     convertOutlierDetection(xdsCluster.GetOutlierDetection()),
+			Hosts:                convertClusterHosts(xdsCluster.GetHosts()),
+			Spec:                 convertSpec(xdsCluster),
+			TLS:                  convertTLS(xdsCluster.GetTlsContext()),
+			LbConfig:             convertLbConfig(xdsCluster.LbConfig),
 		}
 
 		if ass := xdsCluster.GetLoadAssignment(); ass != nil {
@@ -1219,26 +1219,24 @@ func convertCircuitBreakers(xdsCircuitBreaker *xdscluster.CircuitBreakers) v2.Ci
 	}
 }
 
-/*
- func convertOutlierDetection(xdsOutlierDetection *xdscluster.OutlierDetection) v2.OutlierDetection {
-	 if xdsOutlierDetection == nil || xdsOutlierDetection.Size() == 0 {
-		 return v2.OutlierDetection{}
-	 }
-	 return v2.OutlierDetection{
-		 Consecutive5xx:                     xdsOutlierDetection.GetConsecutive_5Xx().GetValue(),
-		 Interval:                           convertDuration(xdsOutlierDetection.GetInterval()),
-		 BaseEjectionTime:                   convertDuration(xdsOutlierDetection.GetBaseEjectionTime()),
-		 MaxEjectionPercent:                 xdsOutlierDetection.GetMaxEjectionPercent().GetValue(),
-		 ConsecutiveGatewayFailure:          xdsOutlierDetection.GetEnforcingConsecutive_5Xx().GetValue(),
-		 EnforcingConsecutive5xx:            xdsOutlierDetection.GetConsecutive_5Xx().GetValue(),
-		 EnforcingConsecutiveGatewayFailure: xdsOutlierDetection.GetEnforcingConsecutiveGatewayFailure().GetValue(),
-		 EnforcingSuccessRate:               xdsOutlierDetection.GetEnforcingSuccessRate().GetValue(),
-		 SuccessRateMinimumHosts:            xdsOutlierDetection.GetSuccessRateMinimumHosts().GetValue(),
-		 SuccessRateRequestVolume:           xdsOutlierDetection.GetSuccessRateRequestVolume().GetValue(),
-		 SuccessRateStdevFactor:             xdsOutlierDetection.GetSuccessRateStdevFactor().GetValue(),
-	 }
- }
-*/
+func convertOutlierDetection(xdsOutlierDetection *xdscluster.OutlierDetection) v2.OutlierDetection {
+	if xdsOutlierDetection == nil {
+		return v2.OutlierDetection{}
+	}
+	return v2.OutlierDetection{
+		Consecutive5xx:                     xdsOutlierDetection.GetConsecutive_5Xx().GetValue(),
+		ConsecutiveGatewayFailure:          xdsOutlierDetection.GetConsecutiveGatewayFailure().GetValue(),
+		Interval:                           api.DurationConfig{Duration: convertTimeDurPoint2TimeDur(xdsOutlierDetection.GetInterval())},
+		BaseEjectionTime:                   api.DurationConfig{Duration: convertTimeDurPoint2TimeDur(xdsOutlierDetection.GetBaseEjectionTime())},
+		MaxEjectionPercent:                 xdsOutlierDetection.GetMaxEjectionPercent().GetValue(),
+		EnforcingConsecutive5xx:            xdsOutlierDetection.GetEnforcingConsecutive_5Xx().GetValue(),
+		EnforcingConsecutiveGatewayFailure: xdsOutlierDetection.GetEnforcingConsecutiveGatewayFailure().GetValue(),
+		EnforcingSuccessRate:               xdsOutlierDetection.GetEnforcingSuccessRate().GetValue(),
+		SuccessRateMinimumHosts:            xdsOutlierDetection.GetSuccessRateMinimumHosts().GetValue(),
+		SuccessRateRequestVolume:           xdsOutlierDetection.GetSuccessRateRequestVolume().GetValue(),
+		SuccessRateStdevFactor:             xdsOutlierDetection.GetSuccessRateStdevFactor().GetValue(),
+	}
+}
 
 func convertSpec(xdsCluster *xdsapi.Cluster) v2.ClusterSpecInfo {
 	if xdsCluster == nil || xdsCluster.GetEdsClusterConfig() == nil {
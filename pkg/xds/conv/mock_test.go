@@ -28,6 +28,12 @@ type mockCMF struct{}
 
 func (cmf *mockCMF) OnCreated(cccb types.ClusterConfigFactoryCb, chcb types.ClusterHostFactoryCb) {}
 
+func (cmf *mockCMF) OnClusterAdded(clusterName string) {}
+
+func (cmf *mockCMF) OnClusterRemoved(clusterName string) {}
+
+func (cmf *mockCMF) OnHostsChanged(clusterName string, addHosts, delHosts []types.Host) {}
+
 type mockNetworkFilterFactory struct{}
 
 func (ff *mockNetworkFilterFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
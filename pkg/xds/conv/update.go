@@ -18,13 +18,15 @@
 package conv
 
 import (
-	"fmt"
+	"hash/fnv"
+
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	jsoniter "github.com/json-iterator/go"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
+	mosnsync "mosn.io/mosn/pkg/sync"
 	clusterAdapter "mosn.io/mosn/pkg/upstream/cluster"
 )
 
@@ -156,10 +158,62 @@ func ConvertDeleteClusters(clusters []*envoy_api_v2.Cluster) {
 	}
 }
 
-// ConverUpdateEndpoints converts cluster configuration, used to udpate hosts
-func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignment) error {
-	var errGlobal error
+// edsUpdateShards bounds how many clusters' host updates ConvertUpdateEndpoints
+// can apply concurrently. Jobs are sharded by a hash of the cluster name, so
+// updates to the same cluster stay ordered on the same shard while a bulk EDS
+// push spanning many clusters fans out across the rest, instead of applying
+// one cluster at a time and stalling the data plane behind the whole batch.
+const edsUpdateShards = 32
+
+// edsUpdateQueueSize is the total job queue capacity across all shards.
+const edsUpdateQueueSize = edsUpdateShards * 64
+
+var edsUpdatePool, _ = mosnsync.NewShardWorkerPool(edsUpdateQueueSize, edsUpdateShards, edsUpdateWorker)
+
+func init() {
+	edsUpdatePool.Init()
+}
 
+// edsHostUpdateJob is one cluster's worth of endpoints from a single EDS
+// ClusterLoadAssignment, to be applied by edsUpdatePool. done is closed once
+// the update has been applied, so ConvertUpdateEndpoints can wait for its
+// whole batch before returning - callers ack the EDS response on return, and
+// the ack must not race ahead of the update it's acknowledging.
+type edsHostUpdateJob struct {
+	clusterName string
+	hosts       []v2.Host
+	done        chan struct{}
+}
+
+func (j *edsHostUpdateJob) Source() uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(j.clusterName))
+	return h.Sum32()
+}
+
+func edsUpdateWorker(shard int, jobCh <-chan interface{}) {
+	for raw := range jobCh {
+		job := raw.(*edsHostUpdateJob)
+		clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+		if clusterMngAdapter == nil {
+			log.DefaultLogger.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", job.hosts)
+		} else if err := clusterMngAdapter.TriggerClusterHostUpdate(job.clusterName, job.hosts); err != nil {
+			log.DefaultLogger.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), job.hosts)
+		} else {
+			log.DefaultLogger.Debugf("xds client update host success,hosts are %+v", job.hosts)
+		}
+		close(job.done)
+	}
+}
+
+// ConverUpdateEndpoints converts cluster configuration, used to udpate hosts.
+// Each cluster's update is applied on edsUpdatePool, so a bulk EDS push
+// covering many clusters does not serialize the whole batch behind one
+// cluster's update. ConvertUpdateEndpoints still only returns once every
+// update in the batch has been applied, so the caller's EDS ack, sent right
+// after this returns, reflects hosts that are actually live.
+func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignment) error {
+	var pending []chan struct{}
 	for _, loadAssignment := range loadAssignments {
 		clusterName := loadAssignment.ClusterName
 
@@ -170,21 +224,14 @@ func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignmen
 				log.DefaultLogger.Debugf("host[%d] is : %+v", index, host)
 			}
 
-			clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
-			if clusterMngAdapter == nil {
-				log.DefaultLogger.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", hosts)
-				errGlobal = fmt.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", hosts)
-			}
-
-			if err := clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterHostUpdate(clusterName, hosts); err != nil {
-				log.DefaultLogger.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), hosts)
-				errGlobal = fmt.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), hosts)
-
-			} else {
-				log.DefaultLogger.Debugf("xds client update host success,hosts are %+v", hosts)
-			}
+			done := make(chan struct{})
+			edsUpdatePool.Offer(&edsHostUpdateJob{clusterName: clusterName, hosts: hosts, done: done}, true)
+			pending = append(pending, done)
 		}
 	}
+	for _, done := range pending {
+		<-done
+	}
 
-	return errGlobal
+	return nil
 }
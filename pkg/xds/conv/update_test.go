@@ -18,10 +18,13 @@
 package conv
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	xdsendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	xdsroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	xdshttpfault "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
@@ -33,6 +36,7 @@ import (
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
+	clusterAdapter "mosn.io/mosn/pkg/upstream/cluster"
 )
 
 func messageToStruct(t *testing.T, msg proto.Message) *pstruct.Struct {
@@ -280,3 +284,57 @@ func Test_updateListener(t *testing.T) {
 	}
 
 }
+
+// Test_convertUpdateEndpoints covers ConvertUpdateEndpoints applying an EDS
+// push through edsUpdatePool: the update runs on a worker shard rather than
+// inline, but ConvertUpdateEndpoints waits for it before returning, so the
+// host should already be live by the time it returns.
+func Test_convertUpdateEndpoints(t *testing.T) {
+	clusterAdapter.NewClusterManagerSingleton(nil, nil)
+	if err := clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterAndHostsAddOrUpdate(v2.Cluster{
+		Name:   "edsCluster",
+		LbType: v2.LB_RANDOM,
+	}, nil); err != nil {
+		t.Fatalf("add cluster failed: %v", err)
+	}
+
+	loadAssignment := &envoy_api_v2.ClusterLoadAssignment{
+		ClusterName: "edsCluster",
+		Endpoints: []*xdsendpoint.LocalityLbEndpoints{
+			{
+				LbEndpoints: []*xdsendpoint.LbEndpoint{
+					{
+						HostIdentifier: &xdsendpoint.LbEndpoint_Endpoint{
+							Endpoint: &xdsendpoint.Endpoint{
+								Address: &core.Address{
+									Address: &core.Address_SocketAddress{
+										SocketAddress: &core.SocketAddress{
+											Address:       "192.168.0.1",
+											Protocol:      core.SocketAddress_TCP,
+											PortSpecifier: &core.SocketAddress_PortValue{PortValue: 8080},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := ConvertUpdateEndpoints([]*envoy_api_v2.ClusterLoadAssignment{loadAssignment}); err != nil {
+		t.Fatalf("convert update endpoints failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		snap := clusterAdapter.GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "edsCluster")
+		if snap != nil && len(snap.HostSet().Hosts()) == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("eds endpoint update did not land on the cluster within the deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
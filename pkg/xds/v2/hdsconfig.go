@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"mosn.io/mosn/pkg/log"
+)
+
+// InitHDS parses an hds_config source into the XDSConfig, reusing the
+// clusters already loaded by Init. hdsSource may be nil, meaning the
+// management server does not delegate health checking to mosn.
+func (c *XDSConfig) InitHDS(hdsSource *core.ApiConfigSource) error {
+	if hdsSource == nil {
+		return nil
+	}
+	if err := hdsSource.Validate(); err != nil {
+		log.DefaultLogger.Errorf("invalid hds_config")
+		return err
+	}
+	config, err := c.getHDSSourceEndpoint(hdsSource)
+	if err != nil {
+		log.DefaultLogger.Errorf("fail to get hds source endpoint")
+		return err
+	}
+	c.HDSConfig = config
+	return nil
+}
+
+func (c *XDSConfig) getHDSSourceEndpoint(source *core.ApiConfigSource) (*HDSConfig, error) {
+	config := &HDSConfig{}
+	if source.ApiType != core.ApiConfigSource_GRPC {
+		log.DefaultLogger.Errorf("unsupported api type: %v", source.ApiType)
+		return nil, errors.New("only support GRPC api type yet")
+	}
+	config.APIType = source.ApiType
+
+	config.Services = make([]*ServiceConfig, 0, len(source.GrpcServices))
+	for _, service := range source.GrpcServices {
+		t := service.TargetSpecifier
+		if target, ok := t.(*core.GrpcService_EnvoyGrpc_); ok {
+			serviceConfig := ServiceConfig{}
+			clusterName := target.EnvoyGrpc.ClusterName
+			serviceConfig.ClusterConfig = c.Clusters[clusterName]
+			if serviceConfig.ClusterConfig == nil {
+				log.DefaultLogger.Errorf("cluster not found: %s", clusterName)
+				return nil, fmt.Errorf("cluster not found: %s", clusterName)
+			}
+			config.Services = append(config.Services, &serviceConfig)
+		} else if _, ok := t.(*core.GrpcService_GoogleGrpc_); ok {
+			log.DefaultLogger.Warnf("GrpcService_GoogleGrpc_ not support yet")
+			continue
+		}
+	}
+	return config, nil
+}
+
+// GetStreamClient returns a grpc stream client connected to the HDS endpoint
+func (c *HDSConfig) GetStreamClient() ads.HealthDiscoveryService_StreamHealthCheckClient {
+	if c.StreamClient != nil && c.StreamClient.Client != nil {
+		return c.StreamClient.Client
+	}
+
+	sc := &HDSStreamClient{}
+
+	if c.Services == nil {
+		log.DefaultLogger.Errorf("no available hds service")
+		return nil
+	}
+	var endpoint string
+	for _, service := range c.Services {
+		if service.ClusterConfig == nil {
+			continue
+		}
+		endpoint, _ = service.ClusterConfig.GetEndpoint()
+		if len(endpoint) > 0 {
+			break
+		}
+	}
+	if len(endpoint) == 0 {
+		log.DefaultLogger.Errorf("no available hds endpoint")
+		return nil
+	}
+
+	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	if err != nil {
+		log.DefaultLogger.Errorf("did not connect: %v", err)
+		return nil
+	}
+	log.DefaultLogger.Infof("mosn estab grpc connection to hds server at %v", endpoint)
+	sc.Conn = conn
+
+	client := ads.NewHealthDiscoveryServiceClient(sc.Conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sc.Cancel = cancel
+	streamClient, err := client.StreamHealthCheck(ctx)
+	if err != nil {
+		log.DefaultLogger.Infof("fail to create hds stream client: %v", err)
+		if sc.Conn != nil {
+			sc.Conn.Close()
+		}
+		return nil
+	}
+	sc.Client = streamClient
+	c.StreamClient = sc
+	return streamClient
+}
+
+func (c *HDSConfig) closeHDSStreamClient() {
+	if c.StreamClient == nil {
+		return
+	}
+	c.StreamClient.Cancel()
+	if c.StreamClient.Conn != nil {
+		c.StreamClient.Conn.Close()
+		c.StreamClient.Conn = nil
+	}
+	c.StreamClient.Client = nil
+	c.StreamClient = nil
+}
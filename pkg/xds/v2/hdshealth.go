@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	xdsendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+// defaultEndpointCheckTimeout bounds how long a single HDS-delegated probe
+// waits for the dial to succeed before the endpoint is reported unhealthy
+const defaultEndpointCheckTimeout = 5 * time.Second
+
+// handleHealthCheckSpecifier runs the checks requested by a HealthCheckSpecifier
+// and streams the results back as an EndpointHealthResponse. Results are also
+// applied to the matching mosn cluster host via SetHostHealth, so that the
+// rest of mosn (load balancing, outlier detection, admin api) observes the
+// same HealthFlag plumbing as it does for mosn's own active health checker.
+func (hdsClient *HDSClient) handleHealthCheckSpecifier(streamClient ads.HealthDiscoveryService_StreamHealthCheckClient, specifier *ads.HealthCheckSpecifier) {
+	endpointsHealth := make([]*ads.EndpointHealth, 0)
+	for _, chc := range specifier.GetClusterHealthChecks() {
+		clusterName := chc.GetClusterName()
+		for _, localityEndpoints := range chc.GetLocalityEndpoints() {
+			for _, ep := range localityEndpoints.GetEndpoints() {
+				addr, err := endpointAddress(ep)
+				if err != nil {
+					log.DefaultLogger.Warnf("[xds] [hds client] skip endpoint in cluster %s: %v", clusterName, err)
+					continue
+				}
+				healthy := dialEndpoint(addr)
+				if err := cluster.GetClusterMngAdapterInstance().SetHostHealth(clusterName, addr, healthy); err != nil {
+					log.DefaultLogger.Debugf("[xds] [hds client] cluster %s host %s not managed by mosn: %v", clusterName, addr, err)
+				}
+				status := xdscore.HealthStatus_UNHEALTHY
+				if healthy {
+					status = xdscore.HealthStatus_HEALTHY
+				}
+				endpointsHealth = append(endpointsHealth, &ads.EndpointHealth{
+					Endpoint:     ep,
+					HealthStatus: status,
+				})
+			}
+		}
+	}
+
+	if err := streamClient.Send(&ads.HealthCheckRequestOrEndpointHealthResponse{
+		RequestType: &ads.HealthCheckRequestOrEndpointHealthResponse_EndpointHealthResponse{
+			EndpointHealthResponse: &ads.EndpointHealthResponse{
+				EndpointsHealth: endpointsHealth,
+			},
+		},
+	}); err != nil {
+		log.DefaultLogger.Errorf("[xds] [hds client] send endpoint health response fail: %v", err)
+	}
+}
+
+// endpointAddress resolves an HDS endpoint into the "ip:port" form mosn uses
+// to identify hosts elsewhere (cluster config, SetHostHealth, ...)
+func endpointAddress(ep *xdsendpoint.Endpoint) (string, error) {
+	address := ep.GetAddress()
+	socketAddress, ok := address.GetAddress().(*xdscore.Address_SocketAddress)
+	if !ok {
+		return "", fmt.Errorf("unsupported address type")
+	}
+	switch port := socketAddress.SocketAddress.GetPortSpecifier().(type) {
+	case *xdscore.SocketAddress_PortValue:
+		return fmt.Sprintf("%s:%d", socketAddress.SocketAddress.GetAddress(), port.PortValue), nil
+	case *xdscore.SocketAddress_NamedPort:
+		return fmt.Sprintf("%s:%s", socketAddress.SocketAddress.GetAddress(), port.NamedPort), nil
+	default:
+		return "", fmt.Errorf("unsupported port specifier")
+	}
+}
+
+// dialEndpoint runs a minimal TCP dial probe against addr, mirroring the
+// plain-dial behavior of the tcpdial health check session
+func dialEndpoint(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, defaultEndpointCheckTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"net"
+	"testing"
+
+	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	xdsendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+)
+
+func Test_endpointAddress(t *testing.T) {
+	ep := &xdsendpoint.Endpoint{
+		Address: &xdscore.Address{
+			Address: &xdscore.Address_SocketAddress{
+				SocketAddress: &xdscore.SocketAddress{
+					Address:       "127.0.0.1",
+					PortSpecifier: &xdscore.SocketAddress_PortValue{PortValue: 8080},
+				},
+			},
+		},
+	}
+	addr, err := endpointAddress(ep)
+	if err != nil {
+		t.Fatalf("endpointAddress() error = %v", err)
+	}
+	if addr != "127.0.0.1:8080" {
+		t.Fatalf("endpointAddress() = %s, want 127.0.0.1:8080", addr)
+	}
+
+	pipeEp := &xdsendpoint.Endpoint{
+		Address: &xdscore.Address{
+			Address: &xdscore.Address_Pipe{
+				Pipe: &xdscore.Pipe{Path: "/tmp/test.sock"},
+			},
+		},
+	}
+	if _, err := endpointAddress(pipeEp); err == nil {
+		t.Fatalf("endpointAddress() for a pipe address should fail")
+	}
+}
+
+func Test_dialEndpoint(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	defer l.Close()
+
+	if !dialEndpoint(l.Addr().String()) {
+		t.Fatalf("dialEndpoint() should succeed against a listening address")
+	}
+	if dialEndpoint("127.0.0.1:1") {
+		t.Fatalf("dialEndpoint() should fail against a closed port")
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+// Start hdsClient send goroutine and receive goroutine.
+// send goroutine announces mosn's health checking capability once;
+// receive goroutine handles HealthCheckSpecifier pushed by the server and
+// streams EndpointHealthResponse back for each one it handles.
+func (hdsClient *HDSClient) Start() {
+	hdsClient.StreamClient = hdsClient.HdsConfig.GetStreamClient()
+	utils.GoWithRecover(func() {
+		hdsClient.sendThread()
+	}, nil)
+	utils.GoWithRecover(func() {
+		hdsClient.receiveThread()
+	}, nil)
+}
+
+func (hdsClient *HDSClient) sendThread() {
+	log.DefaultLogger.Debugf("[xds] [hds client] send thread announce capability")
+	if err := hdsClient.reqCapability(hdsClient.StreamClient); err != nil {
+		log.DefaultLogger.Infof("[xds] [hds client] send thread announce capability fail, auto retry")
+		hdsClient.reconnect()
+	}
+
+	<-hdsClient.SendControlChan
+	log.DefaultLogger.Debugf("[xds] [hds client] send thread receive graceful shut down signal")
+	hdsClient.HdsConfig.closeHDSStreamClient()
+	hdsClient.StopChan <- 1
+}
+
+func (hdsClient *HDSClient) receiveThread() {
+	for {
+		select {
+		case <-hdsClient.RecvControlChan:
+			log.DefaultLogger.Debugf("[xds] [hds client] receive thread receive graceful shut down signal")
+			hdsClient.StopChan <- 2
+			return
+		default:
+			hdsClient.StreamClientMutex.RLock()
+			sc := hdsClient.StreamClient
+			hdsClient.StreamClientMutex.RUnlock()
+			if sc == nil {
+				log.DefaultLogger.Infof("[xds] [hds client] stream client closed, sleep 1s and wait for reconnect")
+				time.Sleep(time.Second)
+				continue
+			}
+			specifier, err := sc.Recv()
+			if err != nil {
+				log.DefaultLogger.Infof("[xds] [hds client] get resp timeout: %v, retry after 1s", err)
+				time.Sleep(time.Second)
+				continue
+			}
+			hdsClient.handleHealthCheckSpecifier(sc, specifier)
+		}
+	}
+}
+
+func (hdsClient *HDSClient) reconnect() {
+	hdsClient.HdsConfig.closeHDSStreamClient()
+	hdsClient.StreamClientMutex.Lock()
+	hdsClient.StreamClient = nil
+	hdsClient.StreamClientMutex.Unlock()
+	log.DefaultLogger.Infof("[xds] [hds client] stream client closed")
+
+	interval := time.Second
+
+	for {
+		if !disableReconnect {
+			sc := hdsClient.HdsConfig.GetStreamClient()
+			if sc != nil {
+				hdsClient.StreamClientMutex.Lock()
+				hdsClient.StreamClient = sc
+				hdsClient.StreamClientMutex.Unlock()
+				log.DefaultLogger.Infof("[xds] [hds client] stream client reconnected")
+				return
+			}
+			log.DefaultLogger.Infof("[xds] [hds client] stream client reconnect failed, retry after %v", interval)
+		}
+		// sleep random
+		time.Sleep(interval + time.Duration(rand.Intn(1000))*time.Millisecond)
+		interval = computeInterval(interval)
+	}
+}
+
+// Stop hdsClient wait for send/receive goroutine graceful exit
+func (hdsClient *HDSClient) Stop() {
+	hdsClient.SendControlChan <- 1
+	hdsClient.RecvControlChan <- 1
+	for i := 0; i < 2; i++ {
+		select {
+		case <-hdsClient.StopChan:
+			log.DefaultLogger.Debugf("[xds] [hds client] stop signal")
+		}
+	}
+	close(hdsClient.SendControlChan)
+	close(hdsClient.RecvControlChan)
+	close(hdsClient.StopChan)
+}
+
+func (hdsClient *HDSClient) reqCapability(streamClient ads.HealthDiscoveryService_StreamHealthCheckClient) error {
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+	err := streamClient.Send(&ads.HealthCheckRequestOrEndpointHealthResponse{
+		RequestType: &ads.HealthCheckRequestOrEndpointHealthResponse_HealthCheckRequest{
+			HealthCheckRequest: &ads.HealthCheckRequest{
+				Node: &envoy_api_v2_core1.Node{
+					Id:       types.GetGlobalXdsInfo().ServiceNode,
+					Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
+					Metadata: types.GetGlobalXdsInfo().Metadata,
+				},
+				Capability: &ads.Capability{
+					HealthCheckProtocols: []ads.Capability_Protocol{ads.Capability_TCP, ads.Capability_HTTP},
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.DefaultLogger.Infof("[xds] [hds client] announce capability fail: %v", err)
+		return err
+	}
+	return nil
+}
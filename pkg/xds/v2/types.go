@@ -33,6 +33,7 @@ import (
 // XDSConfig contains ADS config and clusters info
 type XDSConfig struct {
 	ADSConfig *ADSConfig
+	HDSConfig *HDSConfig
 	Clusters  map[string]*ClusterConfig
 }
 
@@ -78,3 +79,31 @@ type StreamClient struct {
 
 // TypeURLHandleFunc is a function that used to parse ads type url data
 type TypeURLHandleFunc func(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse)
+
+// HDSConfig contains HDS config from dynamic resources
+type HDSConfig struct {
+	APIType      core.ApiConfigSource_ApiType
+	Services     []*ServiceConfig
+	StreamClient *HDSStreamClient
+}
+
+// HDSClient delegates active health checking to the management server: it
+// streams HealthCheckSpecifier requests from the server, runs the checks
+// against the matching mosn clusters and streams the results back as
+// EndpointHealthResponse
+type HDSClient struct {
+	HdsConfig         *HDSConfig
+	StreamClientMutex sync.RWMutex
+	StreamClient      ads.HealthDiscoveryService_StreamHealthCheckClient
+	MosnConfig        *v2.MOSNConfig
+	SendControlChan   chan int
+	RecvControlChan   chan int
+	StopChan          chan int
+}
+
+// HDSStreamClient is a grpc client for the Health Discovery Service
+type HDSStreamClient struct {
+	Client ads.HealthDiscoveryService_StreamHealthCheckClient
+	Conn   *grpc.ClientConn
+	Cancel context.CancelFunc
+}
@@ -32,6 +32,7 @@ import (
 	"time"
 
 	apicluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
 
 	jsoniter "github.com/json-iterator/go"
@@ -45,6 +46,7 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 // Client provide an ADS client
 type Client struct {
 	adsClient *v2.ADSClient
+	hdsClient *v2.HDSClient
 }
 
 func duration2String(duration *duration.Duration) string {
@@ -181,6 +183,35 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 	return dynamicResources, staticResources, nil
 }
 
+// UnmarshalHDSConfig extracts an optional hds_config from the raw
+// dynamic_resources, used when the management server delegates active
+// health checking to mosn. A nil result (with no error) means hds_config is
+// simply not present, which is a valid, common case
+func UnmarshalHDSConfig(config *mv2.MOSNConfig) (*core.ApiConfigSource, error) {
+	if len(config.RawDynamicResources) == 0 {
+		return nil, nil
+	}
+	resources := map[string]jsoniter.RawMessage{}
+	if err := json.Unmarshal(config.RawDynamicResources, &resources); err != nil {
+		log.DefaultLogger.Errorf("fail to unmarshal dynamic_resources: %v", err)
+		return nil, err
+	}
+	hdsConfigRaw, ok := resources["hds_config"]
+	if !ok {
+		return nil, nil
+	}
+	hdsSource := &core.ApiConfigSource{}
+	if err := jsonpb.UnmarshalString(string(hdsConfigRaw), hdsSource); err != nil {
+		log.DefaultLogger.Errorf("fail to unmarshal hds_config: %v", err)
+		return nil, err
+	}
+	if err := hdsSource.Validate(); err != nil {
+		log.DefaultLogger.Errorf("invalid hds_config: %v", err)
+		return nil, err
+	}
+	return hdsSource, nil
+}
+
 // Start used to fetch listeners/clusters/clusterloadassignment config from pilot in cycle,
 // usually called when mosn start
 func (c *Client) Start(config *mv2.MOSNConfig) error {
@@ -215,6 +246,33 @@ func (c *Client) Start(config *mv2.MOSNConfig) error {
 	}
 	adsClient.Start()
 	c.adsClient = adsClient
+
+	hdsSource, err := UnmarshalHDSConfig(config)
+	if err != nil {
+		log.DefaultLogger.Warnf("fail to unmarshal hds_config, skip hds: %v", err)
+		return nil
+	}
+	if hdsSource == nil {
+		return nil
+	}
+	if err := xdsConfig.InitHDS(hdsSource); err != nil {
+		log.DefaultLogger.Warnf("fail to init hds config, skip hds: %v", err)
+		return nil
+	}
+	if xdsConfig.HDSConfig == nil {
+		return nil
+	}
+	hdsClient := &v2.HDSClient{
+		HdsConfig:         xdsConfig.HDSConfig,
+		StreamClientMutex: sync.RWMutex{},
+		StreamClient:      nil,
+		MosnConfig:        config,
+		SendControlChan:   make(chan int),
+		RecvControlChan:   make(chan int),
+		StopChan:          make(chan int),
+	}
+	hdsClient.Start()
+	c.hdsClient = hdsClient
 	return nil
 }
 
@@ -226,4 +284,9 @@ func (c *Client) Stop() {
 		c.adsClient.Stop()
 		log.DefaultLogger.Infof("xds client stop")
 	}
+	if c.hdsClient != nil {
+		log.DefaultLogger.Infof("prepare to stop hds client")
+		c.hdsClient.Stop()
+		log.DefaultLogger.Infof("hds client stop")
+	}
 }
@@ -4,10 +4,12 @@ import (
 	"testing"
 	"time"
 
+	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/module/http2"
 	"mosn.io/mosn/pkg/mosn"
 	"mosn.io/mosn/pkg/protocol"
-	_ "mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	_ "mosn.io/mosn/pkg/protocol/xprotocol/dubbo"
 	"mosn.io/mosn/pkg/stream"
 	_ "mosn.io/mosn/pkg/stream/http"
 	_ "mosn.io/mosn/pkg/stream/http2"
@@ -130,3 +132,32 @@ func TestProtocolHttp1(t *testing.T) {
 		t.Errorf("[ERROR MESSAGE] type error protocol :%v", err)
 	}
 }
+
+// TestProtocolXProtocolAuto verifies that a listener configured with
+// downstream_protocol Auto and an extend_config sub_protocol list (e.g.
+// "bolt,dubbo") can also detect one of those x-protocols by magic byte,
+// the same way it already detects HTTP/1.x and HTTP/2 above.
+func TestProtocolXProtocolAuto(t *testing.T) {
+	ctx := mosnctx.WithValue(nil, types.ContextSubProtocol, "bolt,dubbo")
+
+	// long enough that HTTP/1.x and HTTP/2 both give a definitive "not
+	// mine" instead of asking for more bytes, so only the sub_protocol
+	// check below is what determines the result.
+	magic := []byte{bolt.ProtocolCode, 0, 0, 0, 0, 0, 0, 0}
+	prot, err := stream.SelectStreamFactoryProtocol(ctx, "", magic)
+	if err != nil || prot != protocol.Xprotocol {
+		t.Errorf("[ERROR MESSAGE] bolt magic byte with sub_protocol configured: prot=%v err=%v", prot, err)
+	}
+
+	_, err = stream.SelectStreamFactoryProtocol(ctx, "", []byte{})
+	if err != stream.EAGAIN {
+		t.Errorf("[ERROR MESSAGE] no data yet should ask for more: err=%v", err)
+	}
+
+	// without sub_protocol configured, xprotocol has no candidates to try
+	// and must not claim the connection, even though the bytes look like bolt.
+	_, err = stream.SelectStreamFactoryProtocol(nil, "", magic)
+	if err != stream.FAILED {
+		t.Errorf("[ERROR MESSAGE] bolt magic byte without sub_protocol configured: err=%v", err)
+	}
+}